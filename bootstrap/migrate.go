@@ -33,6 +33,10 @@ var autoMigrateModels = []interface{}{
 	&model.StoreSupplier{},
 	&model.PurchaseOrder{},
 	&model.PurchaseOrderItem{},
+	&model.PurchaseReceipt{},
+	&model.PurchaseReceiptItem{},
+	&model.SupplierPayableEntry{},
+	&model.SupplierPayment{},
 	&model.DictType{},
 	&model.DictData{},
 	&model.Inventory{},
@@ -40,6 +44,15 @@ var autoMigrateModels = []interface{}{
 	&model.InventoryOrderItem{},
 	&model.InventoryLossOrder{},
 	&model.InventoryLossOrderItem{},
+	&model.InventoryLot{},
+	&model.StockTransfer{},
+	&model.StockTransferItem{},
+	&model.Stocktake{},
+	&model.StocktakeItem{},
+	&model.StocktakeEntry{},
+	&model.InventoryMovement{},
+	&model.InventoryMovementLot{},
+	&model.StoreProductReorderSetting{},
 	&model.Gallery{},
 	&model.GalleryUploadSession{},
 	&model.StoreAccount{},
@@ -56,14 +69,25 @@ var autoMigrateModels = []interface{}{
 	&model.MessageTemplate{},
 	&model.Member{},
 	&model.MemberPointRule{},
+	&model.MemberPointLog{},
+	&model.MemberTier{},
+	&model.MemberTierLog{},
 	&model.WalletLog{},
 	&model.RechargeOrder{},
+	&model.RechargePackage{},
+	&model.RechargePackageStore{},
 	&model.MemberWineStorage{},
 	&model.MemberWineTransaction{},
+	&model.MemberWinePolicy{},
 	&model.B2BCustomer{},
 	&model.B2BCustomerProductPrice{},
 	&model.B2BSupplyOrder{},
 	&model.B2BSupplyOrderItem{},
+	&model.B2BReceivableEntry{},
+	&model.B2BPayment{},
+	&model.B2BPaymentAllocation{},
+	&model.B2BSupplyReturn{},
+	&model.B2BSupplyReturnItem{},
 	&model.PreOrder{},
 	&model.PreOrderItem{},
 	&model.PreOrderReminderLog{},
@@ -73,6 +97,13 @@ var autoMigrateModels = []interface{}{
 	&model.ThirdPartyRouteStore{},
 	&model.ThirdPartyLogisticsSheet{},
 	&model.AuditLog{},
+	&model.NotificationOutbox{},
+	&model.NotificationDelivery{},
+	&model.NotificationRoute{},
+	&model.MessageTemplateVersion{},
+	&model.DingTalkApproval{},
+	&model.PrintJob{},
+	&model.PrintAutoRule{},
 }
 
 func AutoMigrateAndSeeds() {
@@ -207,7 +238,116 @@ func shouldSkipMigration() bool {
 		return false
 	}
 
-	if migrator.HasTable(&model.StoreReturn{}) && !migrator.HasColumn(&model.StoreReturn{}, "photo_urls") {
+	if migrator.HasTable(&model.StoreReturn{}) &&
+		(!migrator.HasColumn(&model.StoreReturn{}, "photo_urls") || !migrator.HasColumn(&model.StoreReturn{}, "supplier_id")) {
+		return false
+	}
+
+	// B2B 信用额度控制字段
+	if migrator.HasTable(&model.B2BCustomer{}) && !migrator.HasColumn(&model.B2BCustomer{}, "credit_control") {
+		return false
+	}
+	if migrator.HasTable(&model.B2BSupplyOrder{}) && !migrator.HasColumn(&model.B2BSupplyOrder{}, "credit_override") {
+		return false
+	}
+
+	// 通知发件箱按路由规则区分接收目标类型
+	if migrator.HasTable(&model.NotificationOutbox{}) && !migrator.HasColumn(&model.NotificationOutbox{}, "target_type") {
+		return false
+	}
+
+	// 消息模板变量结构与版本号
+	if migrator.HasTable(&model.MessageTemplate{}) &&
+		(!migrator.HasColumn(&model.MessageTemplate{}, "schema") || !migrator.HasColumn(&model.MessageTemplate{}, "version")) {
+		return false
+	}
+
+	// B2B 供货退货累计字段
+	if migrator.HasTable(&model.B2BSupplyOrder{}) && !migrator.HasColumn(&model.B2BSupplyOrder{}, "returned_amount") {
+		return false
+	}
+	if migrator.HasTable(&model.B2BSupplyOrderItem{}) && !migrator.HasColumn(&model.B2BSupplyOrderItem{}, "returned_quantity") {
+		return false
+	}
+
+	// 采购收货登记依赖的收货状态与累计实收字段
+	if migrator.HasTable(&model.PurchaseOrder{}) && !migrator.HasColumn(&model.PurchaseOrder{}, "receive_status") {
+		return false
+	}
+	if migrator.HasTable(&model.PurchaseOrderItem{}) &&
+		(!migrator.HasColumn(&model.PurchaseOrderItem{}, "received_quantity") || !migrator.HasColumn(&model.PurchaseOrderItem{}, "received_amount")) {
+		return false
+	}
+
+	// 钉钉审批互动卡片模板
+	if migrator.HasTable(&model.DingTalkBot{}) && !migrator.HasColumn(&model.DingTalkBot{}, "approval_card_template_id") {
+		return false
+	}
+
+	// 打印机纸宽
+	if migrator.HasTable(&model.Printer{}) && !migrator.HasColumn(&model.Printer{}, "paper_width") {
+		return false
+	}
+
+	// 会员积分：记账积分抵扣、积分规则有效期与积分价值
+	if migrator.HasTable(&model.StoreAccount{}) &&
+		(!migrator.HasColumn(&model.StoreAccount{}, "points_redeemed") || !migrator.HasColumn(&model.StoreAccount{}, "points_deduction")) {
+		return false
+	}
+	if migrator.HasTable(&model.MemberPointRule{}) &&
+		(!migrator.HasColumn(&model.MemberPointRule{}, "valid_days") || !migrator.HasColumn(&model.MemberPointRule{}, "point_value")) {
+		return false
+	}
+
+	// 会员等级：记账会员折扣
+	if migrator.HasTable(&model.StoreAccount{}) &&
+		(!migrator.HasColumn(&model.StoreAccount{}, "member_discount_rate") || !migrator.HasColumn(&model.StoreAccount{}, "member_discount")) {
+		return false
+	}
+
+	// 会员余额支付记账
+	if migrator.HasTable(&model.StoreAccount{}) &&
+		(!migrator.HasColumn(&model.StoreAccount{}, "balance_amount") || !migrator.HasColumn(&model.StoreAccount{}, "pay_method")) {
+		return false
+	}
+
+	// 充值套餐：充值单记录套餐与首充赠送
+	if migrator.HasTable(&model.RechargeOrder{}) &&
+		(!migrator.HasColumn(&model.RechargeOrder{}, "package_id") ||
+			!migrator.HasColumn(&model.RechargeOrder{}, "package_name") ||
+			!migrator.HasColumn(&model.RechargeOrder{}, "first_gift")) {
+		return false
+	}
+
+	// 会员存酒期限：存入批次与到期提醒
+	if migrator.HasTable(&model.MemberWineStorage{}) &&
+		(!migrator.HasColumn(&model.MemberWineStorage{}, "expire_at") ||
+			!migrator.HasColumn(&model.MemberWineStorage{}, "last_access_at") ||
+			!migrator.HasColumn(&model.MemberWineStorage{}, "idle_reminded_at")) {
+		return false
+	}
+	if migrator.HasTable(&model.MemberWineTransaction{}) &&
+		(!migrator.HasColumn(&model.MemberWineTransaction{}, "product_id") ||
+			!migrator.HasColumn(&model.MemberWineTransaction{}, "remaining") ||
+			!migrator.HasColumn(&model.MemberWineTransaction{}, "expire_at") ||
+			!migrator.HasColumn(&model.MemberWineTransaction{}, "reminded_at") ||
+			!migrator.HasColumn(&model.MemberWineTransaction{}, "photo_urls") ||
+			!migrator.HasColumn(&model.MemberWineTransaction{}, "lot_id") ||
+			!migrator.HasColumn(&model.MemberWineTransaction{}, "inventory_order_no")) {
+		return false
+	}
+
+	// 调拨明细按调出批次拆行
+	if migrator.HasTable(&model.StockTransferItem{}) &&
+		(!migrator.HasColumn(&model.StockTransferItem{}, "production_date") ||
+			!migrator.HasColumn(&model.StockTransferItem{}, "expiry_date")) {
+		return false
+	}
+
+	// 按日均消耗补货：到货天数与安全库存
+	if migrator.HasTable(&model.StoreProductReorderSetting{}) &&
+		(!migrator.HasColumn(&model.StoreProductReorderSetting{}, "lead_days") ||
+			!migrator.HasColumn(&model.StoreProductReorderSetting{}, "safety_stock")) {
 		return false
 	}
 
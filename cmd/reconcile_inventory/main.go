@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/bootstrap"
+	"github.com/Kevin-Jii/tower-go/config"
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/module"
+	"github.com/Kevin-Jii/tower-go/utils/database"
+)
+
+// driftTolerance 结余比对容差（数量按两位小数存储）
+const driftTolerance = 0.005
+
+func main() {
+	storeID := flag.Uint("store", 0, "仅核对指定门店，0 表示全部门店")
+	writeOpening := flag.Bool("write-opening", false, "为没有期初流水的门店商品补写期初（启用流水前的历史库存）")
+	flag.Parse()
+
+	// 初始化配置
+	config.InitConfig()
+
+	// 初始化数据库连接
+	bootstrap.InitDatabase()
+
+	// 获取数据库实例
+	db := database.GetDB()
+	if db == nil {
+		log.Fatal("数据库连接失败")
+	}
+
+	fmt.Println("==============================================")
+	fmt.Println("库存流水对账工具")
+	fmt.Println("==============================================")
+	fmt.Println()
+
+	movementModule := module.NewInventoryMovementModule(db)
+	inventoryModule := module.NewInventoryModule(db)
+
+	keys, err := movementModule.ListReconcileKeys(*storeID)
+	if err != nil {
+		log.Fatalf("读取门店商品失败: %v", err)
+	}
+
+	openingCount := 0
+	driftCount := 0
+	breakCount := 0
+	for _, key := range keys {
+		rows, err := movementModule.ListAllByProduct(key.StoreID, key.ProductID)
+		if err != nil {
+			log.Fatalf("读取库存流水失败 (门店 %d 商品 %d): %v", key.StoreID, key.ProductID, err)
+		}
+		quantity, unit := 0.0, ""
+		if inv, err := inventoryModule.GetByStoreAndProduct(key.StoreID, key.ProductID); err == nil {
+			quantity, unit = inv.Quantity, inv.Unit
+		}
+
+		if *writeOpening {
+			written, err := writeOpeningMovement(movementModule, key, rows, quantity, unit)
+			if err != nil {
+				log.Fatalf("补写期初失败 (门店 %d 商品 %d): %v", key.StoreID, key.ProductID, err)
+			}
+			if written {
+				openingCount++
+				if rows, err = movementModule.ListAllByProduct(key.StoreID, key.ProductID); err != nil {
+					log.Fatalf("读取库存流水失败 (门店 %d 商品 %d): %v", key.StoreID, key.ProductID, err)
+				}
+			}
+		}
+
+		result := module.ReconcileMovements(rows)
+		for _, b := range result.Breaks {
+			breakCount++
+			fmt.Printf("⚠️  断链 门店 %d 商品 %d 流水 #%d: 应为 %.2f，记录 %.2f\n", key.StoreID, key.ProductID, b.MovementID, b.Expected, b.Actual)
+		}
+		if drift := quantity - result.Recomputed; math.Abs(drift) > driftTolerance {
+			driftCount++
+			fmt.Printf("❌ 差异 门店 %d 商品 %d: 库存 %.2f，流水重算 %.2f，差额 %.2f\n", key.StoreID, key.ProductID, quantity, result.Recomputed, drift)
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("==============================================")
+	fmt.Printf("核对门店商品: %d\n", len(keys))
+	if *writeOpening {
+		fmt.Printf("补写期初: %d\n", openingCount)
+	}
+	fmt.Printf("结余断链: %d\n", breakCount)
+	fmt.Printf("库存差异: %d\n", driftCount)
+	fmt.Println("==============================================")
+
+	if driftCount > 0 || breakCount > 0 {
+		os.Exit(1)
+	}
+	fmt.Println("✅ 库存与流水一致")
+}
+
+// writeOpeningMovement 补写期初流水：尚无流水时以当前库存为期初；
+// 已有流水但缺少期初时，以第一条流水变动前的结余为期初，时间早于该流水。
+func writeOpeningMovement(movementModule *module.InventoryMovementModule, key module.InventoryMovementKey, rows []model.InventoryMovement, quantity float64, unit string) (bool, error) {
+	if len(rows) == 0 {
+		if math.Abs(quantity) <= driftTolerance {
+			return false, nil
+		}
+		return true, movementModule.CreateOpening(key.StoreID, key.ProductID, quantity, unit, time.Now())
+	}
+	gap := module.MovementOpeningGap(rows)
+	if math.Abs(gap) <= driftTolerance {
+		return false, nil
+	}
+	first := rows[0]
+	for _, row := range rows[1:] {
+		if row.ID < first.ID {
+			first = row
+		}
+	}
+	return true, movementModule.CreateOpening(key.StoreID, key.ProductID, gap, first.Unit, first.CreatedAt.Add(-time.Second))
+}
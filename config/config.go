@@ -19,6 +19,7 @@ type Config struct {
 	InternalService InternalServiceConfig
 	RustFS          RustFSConfig
 	Xpyun           XpyunConfig
+	Inventory       InventoryConfig
 	Performance     PerformanceConfig
 }
 
@@ -34,6 +35,12 @@ type XpyunConfig struct {
 	BaseURL string
 }
 
+// InventoryConfig 库存业务配置
+type InventoryConfig struct {
+	ExpiryWarningDays      int // 临期预警天数：到期日在该天数内的批次视为临期
+	ReorderConsumptionDays int // 补货建议统计日均消耗的默认天数
+}
+
 // RustFSConfig RustFS对象存储配置（S3兼容）
 type RustFSConfig struct {
 	Enabled                   bool
@@ -115,6 +122,7 @@ func InitConfig() {
 		InternalService: loadInternalServiceConfig(),
 		RustFS:          loadRustFSConfig(),
 		Xpyun:           loadXpyunConfig(),
+		Inventory:       loadInventoryConfig(),
 		Performance:     loadPerformanceConfig(),
 	}
 }
@@ -368,3 +376,24 @@ func loadXpyunConfig() XpyunConfig {
 func GetXpyunConfig() XpyunConfig {
 	return GetConfig().Xpyun
 }
+
+// loadInventoryConfig 加载库存业务配置
+func loadInventoryConfig() InventoryConfig {
+	days := getAppInt("INVENTORY_EXPIRY_WARNING_DAYS", 7)
+	if days < 1 {
+		days = 1
+	}
+	consumptionDays := getAppInt("INVENTORY_REORDER_CONSUMPTION_DAYS", 30)
+	if consumptionDays < 1 {
+		consumptionDays = 1
+	}
+	return InventoryConfig{
+		ExpiryWarningDays:      days,
+		ReorderConsumptionDays: consumptionDays,
+	}
+}
+
+// GetInventoryConfig 获取库存业务配置
+func GetInventoryConfig() InventoryConfig {
+	return GetConfig().Inventory
+}
@@ -223,7 +223,7 @@ func (c *B2BController) UpdateSupplyOrderPayment(ctx *gin.Context) {
 	if !http.BindJSON(ctx, &req) {
 		return
 	}
-	order, err := c.service.UpdateSupplyOrderPayment(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), &req)
+	order, err := c.service.UpdateSupplyOrderPayment(id, middleware.GetStoreID(ctx), middleware.GetUserID(ctx), middleware.HQUnboundAdmin(ctx), &req)
 	if err != nil {
 		http.ErrorFrom(ctx, err)
 		return
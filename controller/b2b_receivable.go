@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"math"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/middleware"
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/utils/excelxml"
+	"github.com/Kevin-Jii/tower-go/utils/http"
+	"github.com/gin-gonic/gin"
+)
+
+func (c *B2BController) CreatePayment(ctx *gin.Context) {
+	var req model.CreateB2BPaymentReq
+	if !http.BindJSON(ctx, &req) {
+		return
+	}
+	payment, err := c.service.CreatePayment(middleware.GetStoreID(ctx), middleware.GetUserID(ctx), middleware.HQUnboundAdmin(ctx), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, payment)
+}
+
+func (c *B2BController) ListPayments(ctx *gin.Context) {
+	var req model.ListB2BPaymentReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	req.StoreID = middleware.ResolveQueryStoreID(ctx, "store_id")
+	rows, total, err := c.service.ListPayments(&req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.SuccessWithPagination(ctx, rows, total, req.Page, req.PageSize)
+}
+
+func (c *B2BController) GetPayment(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	payment, err := c.service.GetPayment(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, payment)
+}
+
+func (c *B2BController) VoidPayment(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req model.VoidB2BPaymentReq
+	if !http.BindJSON(ctx, &req) {
+		return
+	}
+	if err := c.service.VoidPayment(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), req.Reason); err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, nil)
+}
+
+func (c *B2BController) CustomerStatement(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req model.B2BStatementReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	statement, err := c.service.Statement(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), &req, time.Now())
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, statement)
+}
+
+func (c *B2BController) ExportCustomerStatement(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req model.B2BStatementReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	statement, err := c.service.Statement(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), &req, time.Now())
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+
+	summary := [][]interface{}{
+		{"客户", statement.CustomerName},
+		{"对账周期", statement.PeriodStart + " 至 " + statement.PeriodEnd},
+		{"信用额度", formatAmount(statement.CreditLimit)},
+		{"期初应收", formatAmount(statement.OpeningBalance)},
+		{"本期供货", formatAmount(statement.SupplyAmount)},
+		{"本期退货", formatAmount(statement.ReturnAmount)},
+		{"本期收款", formatAmount(statement.PaymentAmount)},
+		{"本期调整", formatAmount(statement.AdjustmentAmount)},
+		{"期末应收", formatAmount(statement.ClosingBalance)},
+	}
+	rows := make([][]interface{}, 0, len(statement.Entries))
+	balance := statement.OpeningBalance
+	for _, entry := range statement.Entries {
+		balance = math.Round((balance+entry.Amount)*100) / 100
+		rows = append(rows, []interface{}{
+			entry.BizDate.Format("2006-01-02"),
+			b2bReceivableSourceLabel(entry.SourceType),
+			entry.SourceNo,
+			formatAmount(entry.Amount),
+			formatAmount(balance),
+			entry.Remark,
+		})
+	}
+
+	data := excelxml.Build([]excelxml.Sheet{
+		{
+			Name:    "对账汇总",
+			Headers: []string{"项目", "内容"},
+			Rows:    summary,
+		},
+		{
+			Name:    "对账明细",
+			Headers: []string{"业务日期", "类型", "单号", "金额", "应收余额", "备注"},
+			Rows:    rows,
+		},
+	})
+	http.File(ctx, data, excelxml.Filename("b2b-statement-"+statement.PeriodStart))
+}
+
+func (c *B2BController) ListStatements(ctx *gin.Context) {
+	var req model.ListB2BStatementReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	req.StoreID = middleware.ResolveQueryStoreID(ctx, "store_id")
+	statements, err := c.service.ListStatements(&req, time.Now())
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, statements)
+}
+
+func (c *B2BController) ReceivableAging(ctx *gin.Context) {
+	var req model.B2BAgingReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	req.StoreID = middleware.ResolveQueryStoreID(ctx, "store_id")
+	report, err := c.service.Aging(&req, time.Now())
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, report)
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"github.com/Kevin-Jii/tower-go/middleware"
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/utils/http"
+	"github.com/gin-gonic/gin"
+)
+
+func (c *B2BController) CreateSupplyReturn(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req model.CreateB2BSupplyReturnReq
+	if !http.BindJSON(ctx, &req) {
+		return
+	}
+	ret, err := c.service.CreateSupplyReturn(id, middleware.GetStoreID(ctx), middleware.GetUserID(ctx), middleware.HQUnboundAdmin(ctx), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, ret)
+}
+
+func (c *B2BController) ListOrderSupplyReturns(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	order, err := c.service.GetSupplyOrder(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	req := model.ListB2BSupplyReturnReq{OrderID: order.ID, Page: 1, PageSize: 100}
+	rows, _, err := c.service.ListSupplyReturns(&req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, rows)
+}
+
+func (c *B2BController) ListSupplyReturns(ctx *gin.Context) {
+	var req model.ListB2BSupplyReturnReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	req.StoreID = middleware.ResolveQueryStoreID(ctx, "store_id")
+	rows, total, err := c.service.ListSupplyReturns(&req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.SuccessWithPagination(ctx, rows, total, req.Page, req.PageSize)
+}
+
+func (c *B2BController) GetSupplyReturn(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	ret, err := c.service.GetSupplyReturn(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, ret)
+}
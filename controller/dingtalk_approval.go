@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"github.com/Kevin-Jii/tower-go/middleware"
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/service"
+	"github.com/Kevin-Jii/tower-go/utils/http"
+	"github.com/gin-gonic/gin"
+)
+
+type DingTalkApprovalController struct {
+	service *service.DingTalkApprovalService
+}
+
+func NewDingTalkApprovalController(service *service.DingTalkApprovalService) *DingTalkApprovalController {
+	return &DingTalkApprovalController{service: service}
+}
+
+// List godoc
+// @Summary 钉钉审批记录列表
+// @Tags 钉钉审批
+// @Produce json
+// @Security Bearer
+// @Param store_id query int false "门店ID（仅总部账号可指定）"
+// @Param biz_type query string false "审批类型 approval_purchase_order/approval_store_account_cancel/approval_member_balance"
+// @Param status query int false "状态 1=待审批 2=已通过 3=已驳回 4=执行失败"
+// @Param keyword query string false "业务单号/标题"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} http.Response{data=[]model.DingTalkApproval}
+// @Router /dingtalk/approvals [get]
+func (c *DingTalkApprovalController) List(ctx *gin.Context) {
+	var req model.ListDingTalkApprovalReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	req.StoreID = middleware.ResolveQueryStoreID(ctx, "store_id")
+	rows, total, err := c.service.List(&req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.SuccessWithPagination(ctx, rows, total, req.Page, req.PageSize)
+}
+
+// Get godoc
+// @Summary 钉钉审批详情
+// @Tags 钉钉审批
+// @Produce json
+// @Security Bearer
+// @Param id path int true "审批ID"
+// @Success 200 {object} http.Response{data=model.DingTalkApproval}
+// @Router /dingtalk/approvals/{id} [get]
+func (c *DingTalkApprovalController) Get(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	approval, err := c.service.Get(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, approval)
+}
@@ -81,6 +81,23 @@ func b2bPaymentLabel(status int) string {
 	}
 }
 
+func b2bReceivableSourceLabel(t string) string {
+	switch t {
+	case model.B2BReceivableSourceSupplyOrder:
+		return "供货"
+	case model.B2BReceivableSourcePayment:
+		return "收款"
+	case model.B2BReceivableSourcePaymentVoid:
+		return "收款作废"
+	case model.B2BReceivableSourceAdjustment:
+		return "调整"
+	case model.B2BReceivableSourceCreditNote:
+		return "退货"
+	default:
+		return t
+	}
+}
+
 func b2bDeliveryLabel(status int) string {
 	switch status {
 	case model.B2BDeliveryPending:
@@ -101,6 +118,9 @@ func inventoryTypeLabel(t int8) string {
 	if t == model.InventoryTypeOut {
 		return "出库"
 	}
+	if t == model.InventoryTypeAdjust {
+		return "盘点调整"
+	}
 	return fmt.Sprintf("未知(%d)", t)
 }
 
@@ -120,6 +140,21 @@ func inventoryLossTypeLabel(t string) string {
 	}
 }
 
+func payableSourceLabel(t string) string {
+	switch t {
+	case model.PayableSourcePurchaseReceipt:
+		return "采购收货"
+	case model.PayableSourceStoreReturn:
+		return "返厂押金"
+	case model.PayableSourcePayment:
+		return "付款"
+	case model.PayableSourcePaymentVoid:
+		return "付款作废"
+	default:
+		return t
+	}
+}
+
 func yesNo(v bool) string {
 	if v {
 		return "是"
@@ -25,6 +25,7 @@ func NewInventoryController(inventoryService *service.InventoryService) *Invento
 // @Param store_id query int false "门店ID"
 // @Param product_id query int false "商品ID"
 // @Param keyword query string false "商品名称关键词"
+// @Param with_lots query bool false "是否返回批次明细"
 // @Param page query int false "页码"
 // @Param page_size query int false "每页数量"
 // @Success 200 {object} http.Response{data=[]model.InventoryWithProduct}
@@ -53,6 +54,29 @@ func (c *InventoryController) ListInventory(ctx *gin.Context) {
 	http.SuccessWithPagination(ctx, list, total, req.Page, req.PageSize)
 }
 
+// GetInventoryLots godoc
+// @Summary 库存批次明细
+// @Description 按到期日先到先出顺序返回库存批次，untracked_quantity 为启用批次前未分批的历史库存
+// @Tags 库存管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "库存ID"
+// @Success 200 {object} http.Response{data=model.InventoryLotBreakdown}
+// @Router /inventories/{id}/lots [get]
+func (c *InventoryController) GetInventoryLots(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	breakdown, err := c.inventoryService.GetInventoryLots(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, breakdown)
+}
+
 // CreateOrder godoc
 // @Summary 创建出入库单
 // @Tags 库存管理
@@ -227,7 +251,7 @@ func (c *InventoryController) GetOrderByID(ctx *gin.Context) {
 
 // UpdateInventory godoc
 // @Summary 修改库存数量
-// @Description 直接修改库存数量（门店管理员仅限本店，总部管理员和超级管理员按数据范围操作）
+// @Description 直接修改库存数量（门店管理员仅限本店，总部管理员和超级管理员按数据范围操作），差额记入库存流水
 // @Tags 库存管理
 // @Accept json
 // @Produce json
@@ -258,7 +282,7 @@ func (c *InventoryController) UpdateInventory(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.inventoryService.UpdateInventory(id, req.Quantity); err != nil {
+	if err := c.inventoryService.UpdateInventory(id, req.Quantity, middleware.GetUserID(ctx), req.Remark); err != nil {
 		http.ErrorFrom(ctx, err)
 		return
 	}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"github.com/Kevin-Jii/tower-go/middleware"
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/service"
+	"github.com/Kevin-Jii/tower-go/utils/http"
+	"github.com/gin-gonic/gin"
+)
+
+type InventoryMovementController struct {
+	movementService *service.InventoryMovementService
+}
+
+func NewInventoryMovementController(movementService *service.InventoryMovementService) *InventoryMovementController {
+	return &InventoryMovementController{movementService: movementService}
+}
+
+// StockCard godoc
+// @Summary 商品库存卡
+// @Description 返回门店商品在日期区间内的期初结余、逐笔库存流水（来源单据、操作人、变动后结余）与期末结余
+// @Tags 库存流水
+// @Produce json
+// @Security Bearer
+// @Param store_id query int false "门店ID（仅总部账号可指定）"
+// @Param product_id query int true "商品ID"
+// @Param start_date query string true "开始日期 yyyy-mm-dd"
+// @Param end_date query string true "结束日期 yyyy-mm-dd"
+// @Success 200 {object} http.Response{data=model.StockCard}
+// @Router /inventory-movements/stock-card [get]
+func (c *InventoryMovementController) StockCard(ctx *gin.Context) {
+	var req model.StockCardReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+
+	card, err := c.movementService.GetStockCard(middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, card)
+}
@@ -300,6 +300,138 @@ func (c *MemberController) DeletePointRule(ctx *gin.Context) {
 	http.Success(ctx, nil)
 }
 
+// ListTiers 查询会员等级定义
+func (c *MemberController) ListTiers(ctx *gin.Context) {
+	var req model.ListMemberTierReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	storeID := middleware.GetStoreID(ctx)
+	list, err := c.service.ListTiers(&req, storeID, middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, list)
+}
+
+// CreateTier 新增会员等级
+func (c *MemberController) CreateTier(ctx *gin.Context) {
+	var req model.UpsertMemberTierReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	storeID := middleware.GetStoreID(ctx)
+	row, err := c.service.CreateTier(&req, storeID, middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, row)
+}
+
+// UpdateTier 更新会员等级
+func (c *MemberController) UpdateTier(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		http.Error(ctx, 400, "invalid id")
+		return
+	}
+	var req model.UpsertMemberTierReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	storeID := middleware.GetStoreID(ctx)
+	row, err := c.service.UpdateTier(uint(id), &req, storeID, middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, row)
+}
+
+// DeleteTier 删除会员等级
+func (c *MemberController) DeleteTier(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		http.Error(ctx, 400, "invalid id")
+		return
+	}
+	storeID := middleware.GetStoreID(ctx)
+	if err := c.service.DeleteTier(uint(id), storeID, middleware.HQUnboundAdmin(ctx)); err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, nil)
+}
+
+// ListTierLogs 查询会员等级变动记录
+func (c *MemberController) ListTierLogs(ctx *gin.Context) {
+	var req model.ListMemberTierLogReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	req.Page = http.GetPage(ctx)
+	req.PageSize = http.GetPageSize(ctx)
+
+	storeID := middleware.GetStoreID(ctx)
+	list, total, err := c.service.ListTierLogs(&req, storeID, middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.SuccessWithPagination(ctx, list, total, req.Page, req.PageSize)
+}
+
+// ListPointLogs 查询会员积分流水
+func (c *MemberController) ListPointLogs(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		http.Error(ctx, 400, "invalid id")
+		return
+	}
+	var req model.ListMemberPointLogReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	req.Page = http.GetPage(ctx)
+	req.PageSize = http.GetPageSize(ctx)
+
+	storeID := middleware.GetStoreID(ctx)
+	list, total, err := c.service.ListPointLogs(uint(id), &req, storeID, middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.SuccessWithPagination(ctx, list, total, req.Page, req.PageSize)
+}
+
+// RedeemPoints 会员积分兑换礼品
+func (c *MemberController) RedeemPoints(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		http.Error(ctx, 400, "invalid id")
+		return
+	}
+	var req model.RedeemMemberPointsReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	storeID := middleware.GetStoreID(ctx)
+	member, err := c.service.RedeemPoints(uint(id), storeID, middleware.GetUserID(ctx), middleware.HQUnboundAdmin(ctx), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, member)
+}
+
 // ListWineStorages 查询会员存酒
 func (c *MemberController) ListWineStorages(ctx *gin.Context) {
 	var req model.ListMemberWineStorageReq
@@ -370,9 +502,102 @@ func (c *MemberController) ListWineTransactions(ctx *gin.Context) {
 	http.SuccessWithPagination(ctx, list, total, req.Page, req.PageSize)
 }
 
+// GetWinePolicy 查询存酒期限策略
+func (c *MemberController) GetWinePolicy(ctx *gin.Context) {
+	var req model.GetMemberWinePolicyReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	storeID := middleware.GetStoreID(ctx)
+	row, err := c.service.GetWinePolicy(&req, storeID, middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, row)
+}
+
+// UpsertWinePolicy 保存存酒期限策略
+func (c *MemberController) UpsertWinePolicy(ctx *gin.Context) {
+	var req model.UpsertMemberWinePolicyReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	storeID := middleware.GetStoreID(ctx)
+	row, err := c.service.UpsertWinePolicy(&req, storeID, middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, row)
+}
+
+// ListWineLots 查询存酒批次（按到期时间排序）
+func (c *MemberController) ListWineLots(ctx *gin.Context) {
+	var req model.ListMemberWineLotReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	req.Page = http.GetPage(ctx)
+	req.PageSize = http.GetPageSize(ctx)
+
+	storeID := middleware.GetStoreID(ctx)
+	list, total, err := c.service.ListWineLots(&req, storeID, middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.SuccessWithPagination(ctx, list, total, req.Page, req.PageSize)
+}
+
+// ExtendWineLot 存酒批次续存
+func (c *MemberController) ExtendWineLot(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		http.Error(ctx, 400, "invalid id")
+		return
+	}
+	var req model.ExtendMemberWineLotReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	storeID := middleware.GetStoreID(ctx)
+	row, err := c.service.ExtendWineLot(uint(id), storeID, middleware.GetUserID(ctx), middleware.HQUnboundAdmin(ctx), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, row)
+}
+
+// ForfeitWineLot 存酒批次转归门店，关联商品的生成入库单
+func (c *MemberController) ForfeitWineLot(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		http.Error(ctx, 400, "invalid id")
+		return
+	}
+	var req model.ForfeitMemberWineLotReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	storeID := middleware.GetStoreID(ctx)
+	row, err := c.service.ForfeitWineLot(uint(id), storeID, middleware.GetUserID(ctx), middleware.HQUnboundAdmin(ctx), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, row)
+}
+
 // AdjustBalance 调整余额
 // @Summary 调整会员余额
-// @Description 使用乐观锁调整会员余额（仅管理员可操作）
+// @Description 使用乐观锁调整会员余额（仅管理员可操作）；金额达到钉钉审批门槛时返回待审批记录，审批通过后生效
 // @Tags 会员管理
 // @Accept json
 // @Produce json
@@ -405,11 +630,15 @@ func (c *MemberController) AdjustBalance(ctx *gin.Context) {
 	storeID := middleware.GetStoreID(ctx)
 	userID := middleware.GetUserID(ctx)
 
-	member, err := c.service.AdjustBalance(uint(id), req.Amount, req.Type, req.Remark, req.Version, storeID, userID, middleware.HQUnboundAdmin(ctx))
+	member, approval, err := c.service.AdjustBalance(uint(id), req.Amount, req.Type, req.Remark, req.Version, storeID, userID, middleware.HQUnboundAdmin(ctx))
 	if err != nil {
 		http.ErrorFrom(ctx, err)
 		return
 	}
+	if approval != nil {
+		http.Custom(ctx, 200, "已提交钉钉审批，审批通过后生效", approval)
+		return
+	}
 	http.Success(ctx, member)
 }
 
@@ -632,6 +861,101 @@ func (c *MemberController) CancelRechargeOrder(ctx *gin.Context) {
 	http.Success(ctx, order)
 }
 
+// ListRechargePackages 查询充值套餐
+func (c *MemberController) ListRechargePackages(ctx *gin.Context) {
+	var req model.ListRechargePackageReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	list, err := c.service.ListRechargePackages(&req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, list)
+}
+
+// ListAvailableRechargePackages 查询会员当前可用的充值套餐
+func (c *MemberController) ListAvailableRechargePackages(ctx *gin.Context) {
+	memberID, _ := strconv.Atoi(ctx.Query("memberId"))
+	if memberID < 0 {
+		memberID = 0
+	}
+	storeID := middleware.GetStoreID(ctx)
+	list, err := c.service.ListAvailableRechargePackages(uint(memberID), storeID, middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, list)
+}
+
+// CreateRechargePackage 新增充值套餐
+func (c *MemberController) CreateRechargePackage(ctx *gin.Context) {
+	var req model.UpsertRechargePackageReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	row, err := c.service.CreateRechargePackage(&req, middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, row)
+}
+
+// UpdateRechargePackage 更新充值套餐
+func (c *MemberController) UpdateRechargePackage(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		http.Error(ctx, 400, "invalid id")
+		return
+	}
+	var req model.UpsertRechargePackageReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	row, err := c.service.UpdateRechargePackage(uint(id), &req, middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, row)
+}
+
+// DeleteRechargePackage 删除充值套餐
+func (c *MemberController) DeleteRechargePackage(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		http.Error(ctx, 400, "invalid id")
+		return
+	}
+	if err := c.service.DeleteRechargePackage(uint(id), middleware.HQUnboundAdmin(ctx)); err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, nil)
+}
+
+// RechargePackageReport 充值活动统计（充值量与赠送负债）
+func (c *MemberController) RechargePackageReport(ctx *gin.Context) {
+	var req model.RechargePackageReportReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	storeID := middleware.GetStoreID(ctx)
+	report, err := c.service.RechargePackageReport(&req, storeID, middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, report)
+}
+
 // ListMemberConsumptions 查询会员消费记录
 // @Summary 查询会员消费记录
 // @Description 按会员查询门店记账消费记录，支持日期筛选与汇总
@@ -731,3 +1055,33 @@ func (c *MemberController) ExportMemberConsumptions(ctx *gin.Context) {
 	}})
 	http.File(ctx, data, excelxml.Filename("member-consumptions-"+date))
 }
+
+// SettleAccountsFromWallet 使用会员余额批量结算未结记账单
+// @Summary 会员余额结算未结账单
+// @Description 按实收金额从会员余额扣款，将未结记账单批量改为已支付；不传 account_ids 时结算该会员全部未结账单
+// @Tags 会员管理
+// @Accept json
+// @Produce json
+// @Param id path int true "会员ID"
+// @Param data body model.SettleMemberAccountsReq true "结算信息"
+// @Success 200 {object} http.Response{data=model.SettleMemberAccountsResult}
+// @Router /members/{id}/settle-accounts [post]
+func (c *MemberController) SettleAccountsFromWallet(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		http.Error(ctx, 400, "invalid id")
+		return
+	}
+	var req model.SettleMemberAccountsReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	storeID := middleware.GetStoreID(ctx)
+	result, err := c.service.SettleAccountsFromWallet(uint(id), &req, storeID, middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, result)
+}
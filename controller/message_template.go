@@ -87,7 +87,7 @@ func (c *MessageTemplateController) Create(ctx *gin.Context) {
 		return
 	}
 
-	template, err := c.svc.Create(&req)
+	template, err := c.svc.Create(&req, middleware.GetUserID(ctx))
 	if err != nil {
 		httpPkg.ErrorFrom(ctx, err)
 		return
@@ -122,7 +122,7 @@ func (c *MessageTemplateController) Update(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.svc.Update(id, &req); err != nil {
+	if err := c.svc.Update(id, &req, middleware.GetUserID(ctx)); err != nil {
 		httpPkg.ErrorFrom(ctx, err)
 		return
 	}
@@ -156,3 +156,101 @@ func (c *MessageTemplateController) Delete(ctx *gin.Context) {
 
 	httpPkg.Success(ctx, gin.H{"message": "deleted"})
 }
+
+// Preview godoc
+// @Summary 预览消息模板
+// @Description 可传入未保存的标题/内容/变量结构；数据取 data、doc_id 对应单据或按变量结构生成的示例
+// @Tags 消息模板
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "模板ID"
+// @Param preview body model.PreviewMessageTemplateReq true "预览参数"
+// @Success 200 {object} http.Response{data=model.MessageTemplatePreview}
+// @Router /message-templates/{id}/preview [post]
+func (c *MessageTemplateController) Preview(ctx *gin.Context) {
+	if !middleware.IsAdmin(ctx) {
+		httpPkg.Error(ctx, 403, "only admin can preview templates")
+		return
+	}
+
+	id, ok := httpPkg.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var req model.PreviewMessageTemplateReq
+	if !httpPkg.BindJSON(ctx, &req) {
+		return
+	}
+
+	preview, err := c.svc.Preview(id, &req)
+	if err != nil {
+		httpPkg.ErrorFrom(ctx, err)
+		return
+	}
+
+	httpPkg.Success(ctx, preview)
+}
+
+// ListVersions godoc
+// @Summary 获取消息模板历史版本
+// @Tags 消息模板
+// @Produce json
+// @Security Bearer
+// @Param id path int true "模板ID"
+// @Success 200 {object} http.Response{data=[]model.MessageTemplateVersion}
+// @Router /message-templates/{id}/versions [get]
+func (c *MessageTemplateController) ListVersions(ctx *gin.Context) {
+	if !middleware.IsAdmin(ctx) {
+		httpPkg.Error(ctx, 403, "only admin can view templates")
+		return
+	}
+
+	id, ok := httpPkg.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	versions, err := c.svc.ListVersions(id)
+	if err != nil {
+		httpPkg.ErrorFrom(ctx, err)
+		return
+	}
+
+	httpPkg.Success(ctx, versions)
+}
+
+// Rollback godoc
+// @Summary 回滚消息模板到指定版本
+// @Description 以历史版本内容生成新版本
+// @Tags 消息模板
+// @Produce json
+// @Security Bearer
+// @Param id path int true "模板ID"
+// @Param version path int true "版本号"
+// @Success 200 {object} http.Response{data=model.MessageTemplate}
+// @Router /message-templates/{id}/versions/{version}/rollback [post]
+func (c *MessageTemplateController) Rollback(ctx *gin.Context) {
+	if !middleware.IsAdmin(ctx) {
+		httpPkg.Error(ctx, 403, "only admin can update templates")
+		return
+	}
+
+	id, ok := httpPkg.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	version, ok := httpPkg.ParseUintParam(ctx, "version")
+	if !ok {
+		return
+	}
+
+	template, err := c.svc.Rollback(id, int(version), middleware.GetUserID(ctx))
+	if err != nil {
+		httpPkg.ErrorFrom(ctx, err)
+		return
+	}
+
+	httpPkg.Success(ctx, template)
+}
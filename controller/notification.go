@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"github.com/Kevin-Jii/tower-go/middleware"
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/service"
+	"github.com/Kevin-Jii/tower-go/utils/http"
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationController struct {
+	service *service.NotificationService
+}
+
+func NewNotificationController(service *service.NotificationService) *NotificationController {
+	return &NotificationController{service: service}
+}
+
+// List godoc
+// @Summary 外发通知列表
+// @Tags 通知发件箱
+// @Produce json
+// @Security Bearer
+// @Param store_id query int false "门店ID（仅总部账号可指定）"
+// @Param status query int false "状态 1=待发送 2=发送中 3=成功 4=失败待重试 5=死信"
+// @Param biz_type query string false "业务来源"
+// @Param keyword query string false "业务单号/标题"
+// @Param start_date query string false "开始日期"
+// @Param end_date query string false "结束日期"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} http.Response{data=[]model.NotificationOutbox}
+// @Router /notifications [get]
+func (c *NotificationController) List(ctx *gin.Context) {
+	var req model.ListNotificationReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	req.StoreID = middleware.ResolveQueryStoreID(ctx, "store_id")
+	rows, total, err := c.service.List(&req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.SuccessWithPagination(ctx, rows, total, req.Page, req.PageSize)
+}
+
+// Get godoc
+// @Summary 外发通知详情
+// @Description 含消息内容与每次投递结果
+// @Tags 通知发件箱
+// @Produce json
+// @Security Bearer
+// @Param id path int true "通知ID"
+// @Success 200 {object} http.Response{data=model.NotificationOutbox}
+// @Router /notifications/{id} [get]
+func (c *NotificationController) Get(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	outbox, err := c.service.Get(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, outbox)
+}
+
+// Resend godoc
+// @Summary 重发通知
+// @Description 仅失败或死信通知可重发：重新入队并立即投递一次，之后失败继续按退避重试
+// @Tags 通知发件箱
+// @Produce json
+// @Security Bearer
+// @Param id path int true "通知ID"
+// @Success 200 {object} http.Response{data=model.NotificationOutbox}
+// @Router /notifications/{id}/resend [post]
+func (c *NotificationController) Resend(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	outbox, err := c.service.Resend(id, middleware.GetStoreID(ctx), middleware.GetUserID(ctx), middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, outbox)
+}
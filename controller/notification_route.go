@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"github.com/Kevin-Jii/tower-go/middleware"
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	"github.com/Kevin-Jii/tower-go/service"
+	"github.com/Kevin-Jii/tower-go/utils/http"
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationRouteController 通知路由规则跨门店生效，仅总部账号可维护
+type NotificationRouteController struct {
+	service *service.NotificationRouteService
+}
+
+func NewNotificationRouteController(service *service.NotificationRouteService) *NotificationRouteController {
+	return &NotificationRouteController{service: service}
+}
+
+func (c *NotificationRouteController) requireHQ(ctx *gin.Context) bool {
+	if !middleware.HQUnboundAdmin(ctx) {
+		http.ErrorFrom(ctx, apicode.New(apicode.OperationDenied))
+		return false
+	}
+	return true
+}
+
+// List godoc
+// @Summary 通知路由规则列表
+// @Tags 通知路由
+// @Produce json
+// @Security Bearer
+// @Param event_type query string false "通知事件"
+// @Param store_id query int false "门店ID"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} http.Response{data=[]model.NotificationRoute}
+// @Router /notification-routes [get]
+func (c *NotificationRouteController) List(ctx *gin.Context) {
+	if !c.requireHQ(ctx) {
+		return
+	}
+	var req model.ListNotificationRouteReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	rows, total, err := c.service.List(&req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.SuccessWithPagination(ctx, rows, total, req.Page, req.PageSize)
+}
+
+// Get godoc
+// @Summary 通知路由规则详情
+// @Tags 通知路由
+// @Produce json
+// @Security Bearer
+// @Param id path int true "规则ID"
+// @Success 200 {object} http.Response{data=model.NotificationRoute}
+// @Router /notification-routes/{id} [get]
+func (c *NotificationRouteController) Get(ctx *gin.Context) {
+	if !c.requireHQ(ctx) {
+		return
+	}
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	route, err := c.service.Get(id)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, route)
+}
+
+// Create godoc
+// @Summary 新增通知路由规则
+// @Description 事件 + 门店（0=全部门店）+ 可选渠道/金额门槛，发送到 Webhook 群、Stream 指定用户或群会话；群会话可只填群号自动解析
+// @Tags 通知路由
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body model.UpsertNotificationRouteReq true "规则"
+// @Success 200 {object} http.Response{data=model.NotificationRoute}
+// @Router /notification-routes [post]
+func (c *NotificationRouteController) Create(ctx *gin.Context) {
+	if !c.requireHQ(ctx) {
+		return
+	}
+	var req model.UpsertNotificationRouteReq
+	if !http.BindJSON(ctx, &req) {
+		return
+	}
+	route, err := c.service.Create(&req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, route)
+}
+
+// Update godoc
+// @Summary 修改通知路由规则
+// @Tags 通知路由
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "规则ID"
+// @Param body body model.UpsertNotificationRouteReq true "规则"
+// @Success 200 {object} http.Response{data=model.NotificationRoute}
+// @Router /notification-routes/{id} [put]
+func (c *NotificationRouteController) Update(ctx *gin.Context) {
+	if !c.requireHQ(ctx) {
+		return
+	}
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req model.UpsertNotificationRouteReq
+	if !http.BindJSON(ctx, &req) {
+		return
+	}
+	route, err := c.service.Update(id, &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, route)
+}
+
+// Delete godoc
+// @Summary 删除通知路由规则
+// @Tags 通知路由
+// @Produce json
+// @Security Bearer
+// @Param id path int true "规则ID"
+// @Success 200 {object} http.Response
+// @Router /notification-routes/{id} [delete]
+func (c *NotificationRouteController) Delete(ctx *gin.Context) {
+	if !c.requireHQ(ctx) {
+		return
+	}
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	if err := c.service.Delete(id); err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, nil)
+}
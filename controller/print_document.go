@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"github.com/Kevin-Jii/tower-go/middleware"
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/service"
+	"github.com/Kevin-Jii/tower-go/utils/http"
+	"github.com/gin-gonic/gin"
+)
+
+type PrintDocumentController struct {
+	service *service.PrintDocumentService
+}
+
+func NewPrintDocumentController(service *service.PrintDocumentService) *PrintDocumentController {
+	return &PrintDocumentController{service: service}
+}
+
+type printDocumentFunc func(id, storeID uint, isHQ bool, operatorID uint, req *model.PrintDocumentReq) (*model.PrintJob, error)
+
+// print 各单据打印接口共用：解析单据ID与可选请求体，按当前账号门店范围提交打印任务
+func (c *PrintDocumentController) print(ctx *gin.Context, fn printDocumentFunc) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req model.PrintDocumentReq
+	if ctx.Request.ContentLength > 0 && !http.BindJSON(ctx, &req) {
+		return
+	}
+	job, err := fn(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), middleware.GetUserID(ctx), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, job)
+}
+
+// PrintStoreAccount godoc
+// @Summary 打印记账销售小票
+// @Description 按打印机纸宽（58/80mm）排版，不指定打印机时使用门店默认打印机
+// @Tags 单据打印
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "记账ID"
+// @Param body body model.PrintDocumentReq false "打印参数"
+// @Success 200 {object} http.Response{data=model.PrintJob}
+// @Router /printers/documents/store-accounts/{id} [post]
+func (c *PrintDocumentController) PrintStoreAccount(ctx *gin.Context) {
+	c.print(ctx, c.service.PrintStoreAccount)
+}
+
+// PrintPreOrderPicking godoc
+// @Summary 打印预订单拣货单
+// @Tags 单据打印
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "预订单ID"
+// @Param body body model.PrintDocumentReq false "打印参数"
+// @Success 200 {object} http.Response{data=model.PrintJob}
+// @Router /printers/documents/pre-orders/{id} [post]
+func (c *PrintDocumentController) PrintPreOrderPicking(ctx *gin.Context) {
+	c.print(ctx, c.service.PrintPreOrderPicking)
+}
+
+// PrintB2BDeliveryNote godoc
+// @Summary 打印 B2B 送货单
+// @Description 含客户签收栏
+// @Tags 单据打印
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "供货单ID"
+// @Param body body model.PrintDocumentReq false "打印参数"
+// @Success 200 {object} http.Response{data=model.PrintJob}
+// @Router /printers/documents/b2b-supply-orders/{id} [post]
+func (c *PrintDocumentController) PrintB2BDeliveryNote(ctx *gin.Context) {
+	c.print(ctx, c.service.PrintB2BDeliveryNote)
+}
+
+// PrintInventoryOrder godoc
+// @Summary 打印出入库单
+// @Tags 单据打印
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "出入库单ID"
+// @Param body body model.PrintDocumentReq false "打印参数"
+// @Success 200 {object} http.Response{data=model.PrintJob}
+// @Router /printers/documents/inventory-orders/{id} [post]
+func (c *PrintDocumentController) PrintInventoryOrder(ctx *gin.Context) {
+	c.print(ctx, c.service.PrintInventoryOrder)
+}
+
+// PrintStoreReturn godoc
+// @Summary 打印返厂单
+// @Tags 单据打印
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "返厂单ID"
+// @Param body body model.PrintDocumentReq false "打印参数"
+// @Success 200 {object} http.Response{data=model.PrintJob}
+// @Router /printers/documents/store-returns/{id} [post]
+func (c *PrintDocumentController) PrintStoreReturn(ctx *gin.Context) {
+	c.print(ctx, c.service.PrintStoreReturn)
+}
+
+// ListAutoRules godoc
+// @Summary 自动打印规则列表
+// @Description 总部可按 store_id 筛选，门店账号只返回本门店规则
+// @Tags 单据打印
+// @Produce json
+// @Security Bearer
+// @Param store_id query int false "门店ID"
+// @Success 200 {object} http.Response{data=[]model.PrintAutoRule}
+// @Router /printers/auto-rules [get]
+func (c *PrintDocumentController) ListAutoRules(ctx *gin.Context) {
+	rules, err := c.service.ListAutoRules(middleware.ResolveQueryStoreID(ctx, "store_id"))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, rules)
+}
+
+// SaveAutoRule godoc
+// @Summary 保存自动打印规则
+// @Description 记账、预订单、B2B 供货单创建后按规则自动打印；同一门店同一单据类型只保留一条规则，不指定打印机时使用门店默认打印机
+// @Tags 单据打印
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body model.SavePrintAutoRuleReq true "规则"
+// @Success 200 {object} http.Response{data=model.PrintAutoRule}
+// @Router /printers/auto-rules [put]
+func (c *PrintDocumentController) SaveAutoRule(ctx *gin.Context) {
+	var req model.SavePrintAutoRuleReq
+	if !http.BindJSON(ctx, &req) {
+		return
+	}
+	rule, err := c.service.SaveAutoRule(middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, rule)
+}
+
+// DeleteAutoRule godoc
+// @Summary 删除自动打印规则
+// @Tags 单据打印
+// @Produce json
+// @Security Bearer
+// @Param id path int true "规则ID"
+// @Success 200 {object} http.Response
+// @Router /printers/auto-rules/{id} [delete]
+func (c *PrintDocumentController) DeleteAutoRule(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	if err := c.service.DeleteAutoRule(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx)); err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, nil)
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"github.com/Kevin-Jii/tower-go/middleware"
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/service"
+	"github.com/Kevin-Jii/tower-go/utils/http"
+	"github.com/gin-gonic/gin"
+)
+
+type PrintLabelController struct {
+	service *service.PrintLabelService
+}
+
+func NewPrintLabelController(service *service.PrintLabelService) *PrintLabelController {
+	return &PrintLabelController{service: service}
+}
+
+// PrintPriceTags godoc
+// @Summary 打印货架价签
+// @Description 按价目单打印价签，每个商品一张；不指定商品时打印全部显示中的商品，不指定打印机时使用门店标签打印机
+// @Tags 标签打印
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body model.PrintPriceTagReq true "价签参数"
+// @Success 200 {object} http.Response{data=model.PrintJob}
+// @Router /printers/labels/price-tags [post]
+func (c *PrintLabelController) PrintPriceTags(ctx *gin.Context) {
+	var req model.PrintPriceTagReq
+	if !http.BindJSON(ctx, &req) {
+		return
+	}
+	job, err := c.service.PrintPriceTags(middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), middleware.GetUserID(ctx), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, job)
+}
+
+// PrintLotLabels godoc
+// @Summary 打印库存批次标签
+// @Description 标签含商品名称、生产日期、到期日、数量及入库单号
+// @Tags 标签打印
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body model.PrintLotLabelReq true "批次参数"
+// @Success 200 {object} http.Response{data=model.PrintJob}
+// @Router /printers/labels/inventory-lots [post]
+func (c *PrintLabelController) PrintLotLabels(ctx *gin.Context) {
+	var req model.PrintLotLabelReq
+	if !http.BindJSON(ctx, &req) {
+		return
+	}
+	job, err := c.service.PrintLotLabels(middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), middleware.GetUserID(ctx), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, job)
+}
+
+// PrintWineTag godoc
+// @Summary 补打会员存酒牌
+// @Description 存酒时会自动打印，此接口用于补打，数量显示当前存量
+// @Tags 标签打印
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "存酒记录ID"
+// @Param body body model.PrintDocumentReq false "打印参数"
+// @Success 200 {object} http.Response{data=model.PrintJob}
+// @Router /printers/labels/wine-storages/{id} [post]
+func (c *PrintLabelController) PrintWineTag(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req model.PrintDocumentReq
+	if ctx.Request.ContentLength > 0 && !http.BindJSON(ctx, &req) {
+		return
+	}
+	job, err := c.service.PrintWineTag(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), middleware.GetUserID(ctx), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, job)
+}
@@ -325,15 +325,15 @@ func (c *PrinterController) TestPrint(ctx *gin.Context) {
 		req.Copies = 1
 	}
 
-	orderId, err := c.printerService.TestPrint(id, req.Content, req.Copies)
+	job, err := c.printerService.TestPrint(id, req.Content, req.Copies, middleware.GetUserID(ctx))
 	if err != nil {
 		fmt.Printf("❌ 打印失败: %v\n", err)
 		http.ErrorFrom(ctx, err)
 		return
 	}
 
-	fmt.Printf("✅ 打印成功，订单ID: %s\n\n", orderId)
-	http.Success(ctx, gin.H{"order_id": orderId})
+	fmt.Printf("✅ 打印成功，订单ID: %s\n\n", job.XpyunOrderID)
+	http.Success(ctx, gin.H{"order_id": job.XpyunOrderID, "job": job})
 }
 
 // PrintPurchaseOrderReq 打印采购单请求
@@ -373,13 +373,92 @@ func (c *PrinterController) PrintPurchaseOrder(ctx *gin.Context) {
 	fmt.Printf("采购单ID: %d\n", req.OrderID)
 	fmt.Printf("======================================\n\n")
 
-	orderId, err := c.printerService.PrintPurchaseOrder(id, req.OrderID)
+	job, err := c.printerService.PrintPurchaseOrder(id, req.OrderID, middleware.GetUserID(ctx))
 	if err != nil {
 		fmt.Printf("❌ 打印失败: %v\n\n", err)
 		http.ErrorFrom(ctx, err)
 		return
 	}
 
-	fmt.Printf("✅ 采购单打印成功，订单ID: %s\n\n", orderId)
-	http.Success(ctx, gin.H{"order_id": orderId})
+	fmt.Printf("✅ 采购单打印成功，订单ID: %s\n\n", job.XpyunOrderID)
+	http.Success(ctx, gin.H{"order_id": job.XpyunOrderID, "job": job})
+}
+
+// ListPrintJobs godoc
+// @Summary 打印任务列表
+// @Description 查看打印任务及出纸状态，门店账号只能查看本门店
+// @Tags 打印机管理
+// @Produce json
+// @Security Bearer
+// @Param store_id query int false "门店ID（仅总部账号可指定）"
+// @Param printer_id query int false "打印机ID"
+// @Param doc_type query string false "单据类型 test/purchase_order"
+// @Param status query int false "状态 1=待发送 2=已发送 3=已打印 4=失败"
+// @Param keyword query string false "单据编号/芯烨云订单ID"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} http.Response{data=[]model.PrintJob}
+// @Router /printers/jobs [get]
+func (c *PrinterController) ListPrintJobs(ctx *gin.Context) {
+	var req model.ListPrintJobReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	req.StoreID = middleware.ResolveQueryStoreID(ctx, "store_id")
+	jobs, total, err := c.printerService.ListPrintJobs(&req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.SuccessWithPagination(ctx, jobs, total, req.Page, req.PageSize)
+}
+
+// GetPrintJob godoc
+// @Summary 打印任务详情
+// @Tags 打印机管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "打印任务ID"
+// @Success 200 {object} http.Response{data=model.PrintJob}
+// @Router /printers/jobs/{id} [get]
+func (c *PrinterController) GetPrintJob(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	job, err := c.printerService.GetPrintJob(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, job)
+}
+
+// ReprintJob godoc
+// @Summary 补打
+// @Description 按原打印任务内容重新打印，生成新任务；不指定打印机时沿用原打印机，离线时自动切换
+// @Tags 打印机管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "打印任务ID"
+// @Param body body model.ReprintJobReq false "补打参数"
+// @Success 200 {object} http.Response{data=model.PrintJob}
+// @Router /printers/jobs/{id}/reprint [post]
+func (c *PrinterController) ReprintJob(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req model.ReprintJobReq
+	if ctx.Request.ContentLength > 0 && !http.BindJSON(ctx, &req) {
+		return
+	}
+	job, err := c.printerService.Reprint(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), middleware.GetUserID(ctx), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, job)
 }
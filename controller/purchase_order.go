@@ -215,7 +215,7 @@ func (c *PurchaseOrderController) ConfirmOrder(ctx *gin.Context) {
 }
 
 // CompleteOrder godoc
-// @Summary 完成采购单
+// @Summary 完成采购单（剩余未收数量按订单单价一次收齐入库）
 // @Tags 采购单管理
 // @Produce json
 // @Security Bearer
@@ -227,7 +227,7 @@ func (c *PurchaseOrderController) CompleteOrder(ctx *gin.Context) {
 	if !ok {
 		return
 	}
-	if err := c.orderService.CompleteOrderScoped(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx)); err != nil {
+	if err := c.orderService.CompleteOrderScoped(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), middleware.GetUserID(ctx)); err != nil {
 		http.ErrorFrom(ctx, err)
 		return
 	}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"github.com/Kevin-Jii/tower-go/middleware"
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/utils/http"
+	"github.com/gin-gonic/gin"
+)
+
+// ReceiveOrder godoc
+// @Summary 采购收货
+// @Description 按采购单明细登记实收数量与实际单价（不传按订单单价），生成采购入库单；可分多次收货，收齐后采购单自动完成
+// @Tags 采购单管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "采购单ID"
+// @Param body body model.ReceivePurchaseOrderReq true "收货明细"
+// @Success 200 {object} http.Response{data=model.PurchaseReceipt}
+// @Router /purchase-orders/{id}/receive [post]
+func (c *PurchaseOrderController) ReceiveOrder(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req model.ReceivePurchaseOrderReq
+	if !http.BindJSON(ctx, &req) {
+		return
+	}
+	receipt, err := c.orderService.ReceiveOrder(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), middleware.GetUserID(ctx), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, receipt)
+}
+
+// CloseShort godoc
+// @Summary 短收结案
+// @Description 部分收货的采购单不再等待剩余到货，置为已完成；未收数量计入收货差异报表的短收
+// @Tags 采购单管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "采购单ID"
+// @Param body body model.ClosePurchaseOrderShortReq true "结案原因"
+// @Success 200 {object} http.Response
+// @Router /purchase-orders/{id}/close-short [post]
+func (c *PurchaseOrderController) CloseShort(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req model.ClosePurchaseOrderShortReq
+	if !http.BindJSON(ctx, &req) {
+		return
+	}
+	if err := c.orderService.CloseShortScoped(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), req.Reason); err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, nil)
+}
+
+// ListReceipts godoc
+// @Summary 采购单收货记录
+// @Tags 采购单管理
+// @Produce json
+// @Security Bearer
+// @Param id path int true "采购单ID"
+// @Success 200 {object} http.Response{data=[]model.PurchaseReceipt}
+// @Router /purchase-orders/{id}/receipts [get]
+func (c *PurchaseOrderController) ListReceipts(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	receipts, err := c.orderService.ListReceiptsScoped(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, receipts)
+}
+
+// VarianceReport godoc
+// @Summary 采购收货差异报表
+// @Description 按供应商汇总报菜日期区间内的订货、实收、短收、待到货金额与价格差异及到货率
+// @Tags 采购单管理
+// @Produce json
+// @Security Bearer
+// @Param store_id query int false "门店ID（仅总部账号可指定）"
+// @Param supplier_id query int false "供应商ID"
+// @Param start_date query string true "开始日期 格式:2024-01-01"
+// @Param end_date query string true "结束日期 格式:2024-01-31"
+// @Success 200 {object} http.Response{data=model.PurchaseVarianceReport}
+// @Router /purchase-orders/variance [get]
+func (c *PurchaseOrderController) VarianceReport(ctx *gin.Context) {
+	var req model.PurchaseVarianceReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	report, err := c.orderService.VarianceReport(middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, report)
+}
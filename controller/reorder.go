@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/middleware"
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/service"
+	"github.com/Kevin-Jii/tower-go/utils/http"
+	"github.com/gin-gonic/gin"
+)
+
+type ReorderController struct {
+	reorderService *service.ReorderService
+}
+
+func NewReorderController(reorderService *service.ReorderService) *ReorderController {
+	return &ReorderController{reorderService: reorderService}
+}
+
+// UpsertSetting godoc
+// @Summary 保存商品补货设置
+// @Description 按门店+商品新增或覆盖补货点与补货目标；basis=consumption 时补货目标按日均消耗 × 覆盖天数计算
+// @Tags 补货建议
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body model.UpsertReorderSettingReq true "补货设置"
+// @Success 200 {object} http.Response{data=model.StoreProductReorderSetting}
+// @Router /reorder-settings [post]
+func (c *ReorderController) UpsertSetting(ctx *gin.Context) {
+	var req model.UpsertReorderSettingReq
+	if !http.BindJSON(ctx, &req) {
+		return
+	}
+
+	setting, err := c.reorderService.UpsertSetting(middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, setting)
+}
+
+// ListSettings godoc
+// @Summary 商品补货设置列表
+// @Tags 补货建议
+// @Produce json
+// @Security Bearer
+// @Param store_id query int false "门店ID"
+// @Param supplier_id query int false "供应商ID"
+// @Param keyword query string false "商品名称"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} http.Response{data=[]model.StoreProductReorderSetting}
+// @Router /reorder-settings [get]
+func (c *ReorderController) ListSettings(ctx *gin.Context) {
+	var req model.ListReorderSettingReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	if !middleware.HQUnboundAdmin(ctx) {
+		req.StoreID = middleware.GetStoreID(ctx)
+	}
+
+	list, total, err := c.reorderService.ListSettings(&req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.SuccessWithPagination(ctx, list, total, req.Page, req.PageSize)
+}
+
+// DeleteSetting godoc
+// @Summary 删除商品补货设置
+// @Tags 补货建议
+// @Produce json
+// @Security Bearer
+// @Param id path int true "补货设置ID"
+// @Success 200 {object} http.Response
+// @Router /reorder-settings/{id} [delete]
+func (c *ReorderController) DeleteSetting(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	if err := c.reorderService.DeleteSetting(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx)); err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, nil)
+}
+
+// Suggest godoc
+// @Summary 补货建议
+// @Description 比较当前库存 + 未完成采购单在途数量与补货点，按供应商生成采购单草稿；草稿确认后提交 POST /purchase-orders 即可
+// @Tags 补货建议
+// @Produce json
+// @Security Bearer
+// @Param store_id query int false "门店ID（仅总部账号可指定）"
+// @Param supplier_id query int false "仅计算指定供应商"
+// @Param consumption_days query int false "统计日均消耗的天数，默认取 INVENTORY_REORDER_CONSUMPTION_DAYS"
+// @Success 200 {object} http.Response{data=model.ReorderSuggestion}
+// @Router /purchase-orders/suggestions [get]
+func (c *ReorderController) Suggest(ctx *gin.Context) {
+	var req model.ReorderSuggestionReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+
+	suggestion, err := c.reorderService.Suggest(middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), &req, time.Now())
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, suggestion)
+}
@@ -1,6 +1,11 @@
 package controller
 
 import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/Kevin-Jii/tower-go/middleware"
 	"github.com/Kevin-Jii/tower-go/service"
 	"github.com/Kevin-Jii/tower-go/utils/http"
@@ -9,10 +14,11 @@ import (
 
 type StatisticsController struct {
 	statisticsService *service.StatisticsService
+	expiryService     *service.InventoryExpiryService
 }
 
-func NewStatisticsController(statisticsService *service.StatisticsService) *StatisticsController {
-	return &StatisticsController{statisticsService: statisticsService}
+func NewStatisticsController(statisticsService *service.StatisticsService, expiryService *service.InventoryExpiryService) *StatisticsController {
+	return &StatisticsController{statisticsService: statisticsService, expiryService: expiryService}
 }
 
 // Dashboard godoc
@@ -170,3 +176,43 @@ func (c *StatisticsController) HomeCharts(ctx *gin.Context) {
 
 	http.Success(ctx, stats)
 }
+
+// NearExpiry godoc
+// @Summary 临期/过期库存统计
+// @Description 按门店列出已过期和指定天数内到期的批次数量及成本，便于决定促销、调拨或报损
+// @Tags 统计分析
+// @Produce json
+// @Security Bearer
+// @Param store_id query int false "门店ID"
+// @Param days query int false "临期天数，默认取 INVENTORY_EXPIRY_WARNING_DAYS"
+// @Success 200 {object} http.Response{data=model.ExpiryStats}
+// @Router /statistics/near-expiry [get]
+func (c *StatisticsController) NearExpiry(ctx *gin.Context) {
+	queryStoreID := middleware.ResolveQueryStoreID(ctx, "store_id")
+	days, err := resolveNearExpiryDays(ctx.Query("days"))
+	if err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+
+	stats, err := c.expiryService.GetExpiryStats(queryStoreID, days, time.Now())
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+
+	http.Success(ctx, stats)
+}
+
+// resolveNearExpiryDays 解析临期天数：未传时返回 0（由服务取默认配置），传入时须在 1-365 之间
+func resolveNearExpiryDays(raw string) (int, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, nil
+	}
+	days, err := strconv.Atoi(raw)
+	if err != nil || days < 1 || days > 365 {
+		return 0, errors.New("临期天数需在 1-365 之间")
+	}
+	return days, nil
+}
@@ -0,0 +1,17 @@
+package controller
+
+import "testing"
+
+func TestResolveNearExpiryDays(t *testing.T) {
+	if got, err := resolveNearExpiryDays(""); err != nil || got != 0 {
+		t.Fatalf("missing days = %d, %v; want default 0", got, err)
+	}
+	if got, err := resolveNearExpiryDays("30"); err != nil || got != 30 {
+		t.Fatalf("days=30 = %d, %v", got, err)
+	}
+	for _, raw := range []string{"0", "-1", "366", "abc"} {
+		if _, err := resolveNearExpiryDays(raw); err == nil {
+			t.Fatalf("days=%s unexpectedly passed validation", raw)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"github.com/Kevin-Jii/tower-go/middleware"
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/service"
+	"github.com/Kevin-Jii/tower-go/utils/http"
+	"github.com/gin-gonic/gin"
+)
+
+type StockTransferController struct {
+	stockTransferService *service.StockTransferService
+}
+
+func NewStockTransferController(stockTransferService *service.StockTransferService) *StockTransferController {
+	return &StockTransferController{stockTransferService: stockTransferService}
+}
+
+// Create godoc
+// @Summary 创建调拨单（发货）
+// @Description 调出门店按基础库存出库，货物进入在途状态，待调入门店确认收货后入库
+// @Tags 库存调拨
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body model.CreateStockTransferReq true "调拨单信息"
+// @Success 200 {object} http.Response{data=model.StockTransfer}
+// @Router /stock-transfers [post]
+func (c *StockTransferController) Create(ctx *gin.Context) {
+	var req model.CreateStockTransferReq
+	if !http.BindJSON(ctx, &req) {
+		return
+	}
+
+	transfer, err := c.stockTransferService.Create(middleware.GetStoreID(ctx), middleware.GetUserID(ctx), &req, middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, transfer)
+}
+
+// List godoc
+// @Summary 调拨单列表
+// @Tags 库存调拨
+// @Produce json
+// @Security Bearer
+// @Param store_id query int false "门店ID"
+// @Param direction query string false "方向 in=调入 out=调出"
+// @Param status query int false "状态 1=在途 2=部分收货 3=已收货 4=已撤销"
+// @Param keyword query string false "单号/门店名称"
+// @Param start_date query string false "开始日期"
+// @Param end_date query string false "结束日期"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} http.Response{data=[]model.StockTransfer}
+// @Router /stock-transfers [get]
+func (c *StockTransferController) List(ctx *gin.Context) {
+	var req model.ListStockTransferReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	if !middleware.HQUnboundAdmin(ctx) {
+		req.StoreID = middleware.GetStoreID(ctx)
+	}
+
+	list, total, err := c.stockTransferService.List(ctx.Request.Context(), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.SuccessWithPagination(ctx, list, total, req.Page, req.PageSize)
+}
+
+// Get godoc
+// @Summary 调拨单详情
+// @Tags 库存调拨
+// @Produce json
+// @Security Bearer
+// @Param id path int true "调拨单ID"
+// @Success 200 {object} http.Response{data=model.StockTransfer}
+// @Router /stock-transfers/{id} [get]
+func (c *StockTransferController) Get(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	transfer, err := c.stockTransferService.Get(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.Error(ctx, 404, "未找到该调拨单")
+		return
+	}
+	http.Success(ctx, transfer)
+}
+
+// Receive godoc
+// @Summary 调拨收货
+// @Description 调入门店按基础库存单位填写实收数量，可多次收货；finish=true 时未到数量记为差异并结单
+// @Tags 库存调拨
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "调拨单ID"
+// @Param body body model.ReceiveStockTransferReq true "收货信息"
+// @Success 200 {object} http.Response{data=model.StockTransfer}
+// @Router /stock-transfers/{id}/receive [post]
+func (c *StockTransferController) Receive(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req model.ReceiveStockTransferReq
+	if !http.BindJSON(ctx, &req) {
+		return
+	}
+
+	transfer, err := c.stockTransferService.Receive(id, middleware.GetStoreID(ctx), middleware.GetUserID(ctx), &req, middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, transfer)
+}
+
+// Cancel godoc
+// @Summary 撤销调拨单
+// @Description 仅未收货的在途调拨单可以撤销，在途库存退回调出门店
+// @Tags 库存调拨
+// @Produce json
+// @Security Bearer
+// @Param id path int true "调拨单ID"
+// @Success 200 {object} http.Response
+// @Router /stock-transfers/{id} [delete]
+func (c *StockTransferController) Cancel(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	if err := c.stockTransferService.Cancel(id, middleware.GetStoreID(ctx), middleware.GetUserID(ctx), middleware.HQUnboundAdmin(ctx)); err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, nil)
+}
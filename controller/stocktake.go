@@ -0,0 +1,246 @@
+package controller
+
+import (
+	"github.com/Kevin-Jii/tower-go/middleware"
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/service"
+	"github.com/Kevin-Jii/tower-go/utils/excelxml"
+	"github.com/Kevin-Jii/tower-go/utils/http"
+	"github.com/gin-gonic/gin"
+)
+
+type StocktakeController struct {
+	stocktakeService *service.StocktakeService
+}
+
+func NewStocktakeController(stocktakeService *service.StocktakeService) *StocktakeController {
+	return &StocktakeController{stocktakeService: stocktakeService}
+}
+
+// Create godoc
+// @Summary 开启盘点
+// @Description 快照门店当前系统库存，同一门店同时只能有一张盘点中的单据
+// @Tags 库存盘点
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body model.CreateStocktakeReq true "盘点信息"
+// @Success 200 {object} http.Response{data=model.Stocktake}
+// @Router /stocktakes [post]
+func (c *StocktakeController) Create(ctx *gin.Context) {
+	var req model.CreateStocktakeReq
+	if !http.BindJSON(ctx, &req) {
+		return
+	}
+
+	stocktake, err := c.stocktakeService.Open(middleware.GetStoreID(ctx), middleware.GetUserID(ctx), &req, middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, stocktake)
+}
+
+// List godoc
+// @Summary 盘点单列表
+// @Tags 库存盘点
+// @Produce json
+// @Security Bearer
+// @Param store_id query int false "门店ID"
+// @Param status query int false "状态 1=盘点中 2=已过账 3=已作废"
+// @Param keyword query string false "单号/门店名称"
+// @Param start_date query string false "开始日期"
+// @Param end_date query string false "结束日期"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} http.Response{data=[]model.Stocktake}
+// @Router /stocktakes [get]
+func (c *StocktakeController) List(ctx *gin.Context) {
+	var req model.ListStocktakeReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	if !middleware.HQUnboundAdmin(ctx) {
+		req.StoreID = middleware.GetStoreID(ctx)
+	}
+
+	list, total, err := c.stocktakeService.List(ctx.Request.Context(), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.SuccessWithPagination(ctx, list, total, req.Page, req.PageSize)
+}
+
+// Get godoc
+// @Summary 盘点单详情
+// @Description 返回明细、差异数量与差异成本，以及每次实盘录入记录
+// @Tags 库存盘点
+// @Produce json
+// @Security Bearer
+// @Param id path int true "盘点单ID"
+// @Success 200 {object} http.Response{data=model.Stocktake}
+// @Router /stocktakes/{id} [get]
+func (c *StocktakeController) Get(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	stocktake, err := c.stocktakeService.Get(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.Error(ctx, 404, "未找到该盘点单")
+		return
+	}
+	http.Success(ctx, stocktake)
+}
+
+// RecordCounts godoc
+// @Summary 录入实盘数量
+// @Description 可按任意规格分多次录入，同一商品的录入数量换算为基础库存单位后累加；replace=true 时先清空该商品已录入数量
+// @Tags 库存盘点
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "盘点单ID"
+// @Param body body model.RecordStocktakeCountReq true "实盘数量"
+// @Success 200 {object} http.Response{data=model.Stocktake}
+// @Router /stocktakes/{id}/counts [post]
+func (c *StocktakeController) RecordCounts(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req model.RecordStocktakeCountReq
+	if !http.BindJSON(ctx, &req) {
+		return
+	}
+
+	stocktake, err := c.stocktakeService.RecordCounts(id, middleware.GetStoreID(ctx), middleware.GetUserID(ctx), &req, middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, stocktake)
+}
+
+// Post godoc
+// @Summary 盘点过账
+// @Description 将差异生成一张盘点调整单（原因：库存调整），盘盈入库、盘亏出库；仅门店管理员或超级管理员可操作
+// @Tags 库存盘点
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "盘点单ID"
+// @Param body body model.PostStocktakeReq true "过账选项"
+// @Success 200 {object} http.Response{data=model.Stocktake}
+// @Router /stocktakes/{id}/post [post]
+func (c *StocktakeController) Post(ctx *gin.Context) {
+	if !middleware.IsStoreManager(ctx) {
+		http.Error(ctx, 403, "仅门店管理员或超级管理员可过账盘点")
+		return
+	}
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req model.PostStocktakeReq
+	if !http.BindJSON(ctx, &req) {
+		return
+	}
+
+	stocktake, err := c.stocktakeService.Post(id, middleware.GetStoreID(ctx), middleware.GetUserID(ctx), &req, middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, stocktake)
+}
+
+// Cancel godoc
+// @Summary 作废盘点单
+// @Description 仅盘点中的单据可以作废，不影响库存
+// @Tags 库存盘点
+// @Produce json
+// @Security Bearer
+// @Param id path int true "盘点单ID"
+// @Success 200 {object} http.Response
+// @Router /stocktakes/{id} [delete]
+func (c *StocktakeController) Cancel(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	if err := c.stocktakeService.Cancel(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx)); err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, nil)
+}
+
+// Export godoc
+// @Summary 导出盘点单
+// @Tags 库存盘点
+// @Produce application/vnd.ms-excel
+// @Security Bearer
+// @Param id path int true "盘点单ID"
+// @Success 200 {file} file
+// @Router /stocktakes/{id}/export [get]
+func (c *StocktakeController) Export(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	stocktake, err := c.stocktakeService.Get(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx))
+	if err != nil {
+		http.Error(ctx, 404, "未找到该盘点单")
+		return
+	}
+
+	rows := make([][]interface{}, 0, len(stocktake.Items))
+	entryRows := make([][]interface{}, 0)
+	for _, item := range stocktake.Items {
+		counted := "-"
+		variance := "-"
+		varianceAmount := "-"
+		if item.Counted {
+			counted = formatAmount(item.CountedQuantity)
+			variance = formatAmount(item.VarianceQuantity)
+			varianceAmount = formatAmount(item.VarianceAmount)
+		}
+		rows = append(rows, []interface{}{
+			item.ProductName,
+			item.BaseUnit,
+			formatAmount(item.SystemQuantity),
+			counted,
+			variance,
+			formatAmount(item.CostPrice),
+			varianceAmount,
+			item.Remark,
+		})
+		for _, entry := range item.Entries {
+			entryRows = append(entryRows, []interface{}{
+				entry.CreatedAt,
+				item.ProductName,
+				entry.Unit,
+				formatAmount(entry.Quantity),
+				formatAmount(entry.BaseQuantity),
+				item.BaseUnit,
+				entry.CounterName,
+			})
+		}
+	}
+	data := excelxml.Build([]excelxml.Sheet{
+		{
+			Name:    "盘点差异",
+			Headers: []string{"商品", "库存单位", "系统数量", "实盘数量", "差异数量", "成本单价", "差异金额", "备注"},
+			Rows:    rows,
+		},
+		{
+			Name:    "录入记录",
+			Headers: []string{"时间", "商品", "规格", "数量", "折算数量", "库存单位", "录入人"},
+			Rows:    entryRows,
+		},
+	})
+	http.File(ctx, data, excelxml.Filename("stocktake-"+stocktake.StocktakeNo))
+}
@@ -228,7 +228,7 @@ func (c *StoreAccountController) Delete(ctx *gin.Context) {
 // @Security Bearer
 // @Param id path int true "记账ID"
 // @Param body body model.CancelStoreAccountReq false "作废备注"
-// @Success 200 {object} http.Response
+// @Success 200 {object} http.Response{data=model.DingTalkApproval} "配置了作废审批时返回待审批记录"
 // @Router /store-accounts/{id}/cancel [post]
 func (c *StoreAccountController) Cancel(ctx *gin.Context) {
 	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
@@ -243,10 +243,15 @@ func (c *StoreAccountController) Cancel(ctx *gin.Context) {
 			return
 		}
 	}
-	if err := c.storeAccountService.CancelScoped(uint(id), middleware.GetStoreID(ctx), middleware.GetUserID(ctx), middleware.HQUnboundAdmin(ctx), &req); err != nil {
+	approval, err := c.storeAccountService.CancelScoped(uint(id), middleware.GetStoreID(ctx), middleware.GetUserID(ctx), middleware.HQUnboundAdmin(ctx), &req)
+	if err != nil {
 		http.ErrorFrom(ctx, err)
 		return
 	}
+	if approval != nil {
+		http.Custom(ctx, 200, "已提交钉钉审批，审批通过后作废", approval)
+		return
+	}
 	http.Success(ctx, nil)
 }
 
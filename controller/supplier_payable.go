@@ -0,0 +1,238 @@
+package controller
+
+import (
+	"math"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/middleware"
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/service"
+	"github.com/Kevin-Jii/tower-go/utils/excelxml"
+	"github.com/Kevin-Jii/tower-go/utils/http"
+	"github.com/gin-gonic/gin"
+)
+
+type SupplierPayableController struct {
+	payableService *service.SupplierPayableService
+}
+
+func NewSupplierPayableController(payableService *service.SupplierPayableService) *SupplierPayableController {
+	return &SupplierPayableController{payableService: payableService}
+}
+
+// CreatePayment godoc
+// @Summary 登记供应商付款
+// @Description 付款冲减门店对该供应商的应付；门店账号固定本店，总部账号须指定 store_id
+// @Tags 供应商应付
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body model.CreateSupplierPaymentReq true "付款信息"
+// @Success 200 {object} http.Response{data=model.SupplierPayment}
+// @Router /supplier-payables/payments [post]
+func (c *SupplierPayableController) CreatePayment(ctx *gin.Context) {
+	var req model.CreateSupplierPaymentReq
+	if !http.BindJSON(ctx, &req) {
+		return
+	}
+	payment, err := c.payableService.CreatePayment(middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), middleware.GetUserID(ctx), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, payment)
+}
+
+// ListPayments godoc
+// @Summary 供应商付款记录
+// @Tags 供应商应付
+// @Produce json
+// @Security Bearer
+// @Param store_id query int false "门店ID（仅总部账号可指定）"
+// @Param supplier_id query int false "供应商ID"
+// @Param start_date query string false "付款开始日期"
+// @Param end_date query string false "付款结束日期"
+// @Param status query int false "状态 1=有效 2=已作废"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} http.Response{data=[]model.SupplierPayment}
+// @Router /supplier-payables/payments [get]
+func (c *SupplierPayableController) ListPayments(ctx *gin.Context) {
+	var req model.ListSupplierPaymentReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	list, total, err := c.payableService.ListPayments(middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.SuccessWithPagination(ctx, list, total, req.Page, req.PageSize)
+}
+
+// VoidPayment godoc
+// @Summary 作废供应商付款
+// @Description 作废后追加冲正分录恢复应付，业务日期沿用原付款日期
+// @Tags 供应商应付
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "付款记录ID"
+// @Param body body model.VoidSupplierPaymentReq true "作废原因"
+// @Success 200 {object} http.Response
+// @Router /supplier-payables/payments/{id}/void [post]
+func (c *SupplierPayableController) VoidPayment(ctx *gin.Context) {
+	id, ok := http.ParseUintParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req model.VoidSupplierPaymentReq
+	if !http.BindJSON(ctx, &req) {
+		return
+	}
+	if err := c.payableService.VoidPayment(id, middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), req.Reason); err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, nil)
+}
+
+// ListEntries godoc
+// @Summary 供应商应付台账
+// @Tags 供应商应付
+// @Produce json
+// @Security Bearer
+// @Param store_id query int false "门店ID（仅总部账号可指定）"
+// @Param supplier_id query int false "供应商ID"
+// @Param source_type query string false "来源 purchase_receipt/store_return/payment/payment_void"
+// @Param start_date query string false "业务开始日期"
+// @Param end_date query string false "业务结束日期"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} http.Response{data=[]model.SupplierPayableEntry}
+// @Router /supplier-payables/entries [get]
+func (c *SupplierPayableController) ListEntries(ctx *gin.Context) {
+	var req model.ListSupplierPayableEntryReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	list, total, err := c.payableService.ListEntries(middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.SuccessWithPagination(ctx, list, total, req.Page, req.PageSize)
+}
+
+// Statement godoc
+// @Summary 供应商月度对账单
+// @Tags 供应商应付
+// @Produce json
+// @Security Bearer
+// @Param store_id query int false "门店ID（总部账号不传为全部门店）"
+// @Param supplier_id query int true "供应商ID"
+// @Param month query string true "月份 格式:2024-01"
+// @Success 200 {object} http.Response{data=model.SupplierStatement}
+// @Router /supplier-payables/statement [get]
+func (c *SupplierPayableController) Statement(ctx *gin.Context) {
+	var req model.SupplierStatementReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	statement, err := c.payableService.Statement(middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, statement)
+}
+
+// ExportStatement godoc
+// @Summary 导出供应商月度对账单
+// @Tags 供应商应付
+// @Produce application/vnd.ms-excel
+// @Security Bearer
+// @Param store_id query int false "门店ID（总部账号不传为全部门店）"
+// @Param supplier_id query int true "供应商ID"
+// @Param month query string true "月份 格式:2024-01"
+// @Success 200 {file} file
+// @Router /supplier-payables/statement/export [get]
+func (c *SupplierPayableController) ExportStatement(ctx *gin.Context) {
+	var req model.SupplierStatementReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	statement, err := c.payableService.Statement(middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), &req)
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+
+	summary := [][]interface{}{
+		{"供应商", statement.SupplierName},
+		{"门店", statement.StoreName},
+		{"对账月份", statement.Month},
+		{"期初应付", formatAmount(statement.OpeningBalance)},
+		{"本月采购", formatAmount(statement.PurchaseAmount)},
+		{"本月返厂冲减", formatAmount(statement.ReturnAmount)},
+		{"本月付款", formatAmount(statement.PaymentAmount)},
+		{"期末应付", formatAmount(statement.ClosingBalance)},
+	}
+	rows := make([][]interface{}, 0, len(statement.Entries))
+	balance := statement.OpeningBalance
+	for _, entry := range statement.Entries {
+		balance = math.Round((balance+entry.Amount)*100) / 100
+		rows = append(rows, []interface{}{
+			entry.BizDate.Format("2006-01-02"),
+			storeName(entry.Store),
+			payableSourceLabel(entry.SourceType),
+			entry.SourceNo,
+			formatAmount(entry.Amount),
+			formatAmount(balance),
+			entry.Remark,
+		})
+	}
+
+	data := excelxml.Build([]excelxml.Sheet{
+		{
+			Name:    "对账汇总",
+			Headers: []string{"项目", "内容"},
+			Rows:    summary,
+		},
+		{
+			Name:    "对账明细",
+			Headers: []string{"业务日期", "门店", "类型", "单号", "金额", "应付余额", "备注"},
+			Rows:    rows,
+		},
+	})
+	http.File(ctx, data, excelxml.Filename("supplier-statement-"+statement.Month))
+}
+
+// Aging godoc
+// @Summary 供应商应付账龄
+// @Description 付款与返厂冲减先抵扣最早的应付，未核销应付按 0-30 / 31-60 / 60 天以上分段
+// @Tags 供应商应付
+// @Produce json
+// @Security Bearer
+// @Param store_id query int false "门店ID（总部账号不传为全部门店）"
+// @Param supplier_id query int false "供应商ID"
+// @Param as_of query string false "截止日期，默认当天"
+// @Success 200 {object} http.Response{data=model.SupplierAgingReport}
+// @Router /supplier-payables/aging [get]
+func (c *SupplierPayableController) Aging(ctx *gin.Context) {
+	var req model.SupplierAgingReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		http.Error(ctx, 400, err.Error())
+		return
+	}
+	report, err := c.payableService.Aging(middleware.GetStoreID(ctx), middleware.HQUnboundAdmin(ctx), &req, time.Now())
+	if err != nil {
+		http.ErrorFrom(ctx, err)
+		return
+	}
+	http.Success(ctx, report)
+}
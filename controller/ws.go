@@ -1,6 +1,9 @@
 package controller
 
 import (
+	"github.com/Kevin-Jii/tower-go/internal/authctx"
+	"github.com/Kevin-Jii/tower-go/middleware"
+	"github.com/Kevin-Jii/tower-go/service"
 	authPkg "github.com/Kevin-Jii/tower-go/utils/auth"
 	"github.com/Kevin-Jii/tower-go/utils/session"
 	"net/http"
@@ -18,6 +21,11 @@ var upgrader = websocket.Upgrader{
 // WebSocketHandler 处理用户 WebSocket 连接
 // 客户端应在 Header: Authorization: Bearer <token>
 // 可选查询参数 device_id 指定设备ID
+//
+// 订阅协议：客户端发送 {"type":"subscribe","topic":"store:1:accounts"}，成功回复 subscribed，
+// 无权限或主题不合法回复 error；{"type":"unsubscribe","topic":...} 取消订阅。
+// 主题：store:{门店ID}:accounts|inventory|pre-orders|purchase-orders、hq:alerts，
+// 数据变更时推送 {"type":"event","payload":{"topic","resource","action","store_id","id","no"}}。
 func WebSocketHandler(c *gin.Context) {
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
@@ -30,6 +38,8 @@ func WebSocketHandler(c *gin.Context) {
 		c.JSON(401, gin.H{"error": "invalid token"})
 		return
 	}
+	middleware.SetAuthClaims(c, claims)
+	ac := authctx.FromGin(c)
 
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -54,14 +64,14 @@ func WebSocketHandler(c *gin.Context) {
 	}
 
 	// 创建 session，可能返回被挤下线的旧会话
-	newSession, kicked := sm.CreateSession(claims.UserID, deviceID, token, claims.ExpiresAt.Time, conn)
+	newSession, kicked := sm.CreateSession(claims.UserID, deviceID, token, claims.ExpiresAt.Time, conn, ac)
 	for _, ks := range kicked {
 		kickMsg := session.WebSocketMessage{
 			Type:    "kick",
 			Payload: gin.H{"reason": "replaced"},
 			Ts:      time.Now().Unix(),
 		}
-		_ = ks.Conn.WriteJSON(kickMsg)
+		_ = ks.WriteJSON(kickMsg)
 		ks.Conn.Close()
 	}
 	connMsg := session.WebSocketMessage{
@@ -69,9 +79,9 @@ func WebSocketHandler(c *gin.Context) {
 		Payload: gin.H{"session_id": newSession.ID},
 		Ts:      time.Now().Unix(),
 	}
-	conn.WriteJSON(connMsg)
+	newSession.WriteJSON(connMsg)
 
-	// 读取循环，支持 ping/pong
+	// 读取循环，支持 ping/pong 与主题订阅
 	for {
 		var incoming map[string]interface{}
 		if err := conn.ReadJSON(&incoming); err != nil {
@@ -84,15 +94,31 @@ func WebSocketHandler(c *gin.Context) {
 				Type: "pong",
 				Ts:   time.Now().Unix(),
 			}
-			conn.WriteJSON(pongMsg)
+			newSession.WriteJSON(pongMsg)
+		case "subscribe", "unsubscribe":
+			topic, _ := incoming["topic"].(string)
+			newSession.WriteJSON(handleTopicMessage(sm, newSession, msgType, topic))
 		default:
 			echoMsg := session.WebSocketMessage{
 				Type:    "echo",
 				Payload: incoming,
 				Ts:      time.Now().Unix(),
 			}
-			conn.WriteJSON(echoMsg)
+			newSession.WriteJSON(echoMsg)
 		}
 	}
 	sm.RemoveSession(newSession.ID)
 }
+
+// handleTopicMessage 处理订阅/取消订阅，订阅前按数据范围校验主题
+func handleTopicMessage(sm *session.SessionManager, s *session.Session, msgType, topic string) session.WebSocketMessage {
+	if msgType == "unsubscribe" {
+		sm.Unsubscribe(s.ID, topic)
+		return session.WebSocketMessage{Type: "unsubscribed", Payload: gin.H{"topic": topic}, Ts: time.Now().Unix()}
+	}
+	if err := service.AuthorizeRealtimeTopic(s.Auth, topic); err != nil {
+		return session.WebSocketMessage{Type: "error", Payload: gin.H{"topic": topic, "message": err.Error()}, Ts: time.Now().Unix()}
+	}
+	sm.Subscribe(s.ID, topic)
+	return session.WebSocketMessage{Type: "subscribed", Payload: gin.H{"topic": topic}, Ts: time.Now().Unix()}
+}
@@ -0,0 +1,27 @@
+package cron
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/service"
+	"github.com/robfig/cron/v3"
+)
+
+func StartInventoryExpiryWarnings(expiryService *service.InventoryExpiryService) (*cron.Cron, error) {
+	location, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		return nil, fmt.Errorf("加载库存临期预警时区失败: %w", err)
+	}
+	c := cron.New(cron.WithSeconds(), cron.WithLocation(location))
+	if _, err := c.AddFunc("0 0 9 * * *", func() {
+		if err := expiryService.ProcessExpiryWarnings(time.Now()); err != nil {
+			fmt.Printf("[InventoryExpiry] 临期预警处理失败: %v\n", err)
+		}
+	}); err != nil {
+		return nil, fmt.Errorf("添加库存临期预警任务失败: %w", err)
+	}
+	c.Start()
+	fmt.Println("[InventoryExpiry] 库存临期预警任务已启动 (每日 09:00)")
+	return c, nil
+}
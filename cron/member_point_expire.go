@@ -0,0 +1,27 @@
+package cron
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/service"
+	"github.com/robfig/cron/v3"
+)
+
+func StartMemberPointExpiry(memberService *service.MemberService) (*cron.Cron, error) {
+	location, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		return nil, fmt.Errorf("加载积分过期任务时区失败: %w", err)
+	}
+	c := cron.New(cron.WithSeconds(), cron.WithLocation(location))
+	if _, err := c.AddFunc("0 10 0 * * *", func() {
+		if err := memberService.ExpirePoints(time.Now()); err != nil {
+			fmt.Printf("[MemberPointExpiry] 积分过期处理失败: %v\n", err)
+		}
+	}); err != nil {
+		return nil, fmt.Errorf("添加积分过期任务失败: %w", err)
+	}
+	c.Start()
+	fmt.Println("[MemberPointExpiry] 会员积分过期任务已启动 (每日 00:10)")
+	return c, nil
+}
@@ -0,0 +1,28 @@
+package cron
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/service"
+	"github.com/robfig/cron/v3"
+)
+
+func StartMemberTierEvaluation(memberService *service.MemberService) (*cron.Cron, error) {
+	location, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		return nil, fmt.Errorf("加载会员等级评定任务时区失败: %w", err)
+	}
+	c := cron.New(cron.WithSeconds(), cron.WithLocation(location))
+	// 在积分过期清零之后执行，按清零后的积分评定
+	if _, err := c.AddFunc("0 30 1 * * *", func() {
+		if err := memberService.EvaluateTiers(time.Now()); err != nil {
+			fmt.Printf("[MemberTierEvaluation] 会员等级评定失败: %v\n", err)
+		}
+	}); err != nil {
+		return nil, fmt.Errorf("添加会员等级评定任务失败: %w", err)
+	}
+	c.Start()
+	fmt.Println("[MemberTierEvaluation] 会员等级评定任务已启动 (每日 01:30)")
+	return c, nil
+}
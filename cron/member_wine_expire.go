@@ -0,0 +1,27 @@
+package cron
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/service"
+	"github.com/robfig/cron/v3"
+)
+
+func StartMemberWineExpiry(memberService *service.MemberService) (*cron.Cron, error) {
+	location, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		return nil, fmt.Errorf("加载存酒到期任务时区失败: %w", err)
+	}
+	c := cron.New(cron.WithSeconds(), cron.WithLocation(location))
+	if _, err := c.AddFunc("0 30 9 * * *", func() {
+		if err := memberService.ProcessWineExpiry(time.Now()); err != nil {
+			fmt.Printf("[MemberWineExpiry] 存酒到期处理失败: %v\n", err)
+		}
+	}); err != nil {
+		return nil, fmt.Errorf("添加存酒到期任务失败: %w", err)
+	}
+	c.Start()
+	fmt.Println("[MemberWineExpiry] 会员存酒到期提醒任务已启动 (每日 09:30)")
+	return c, nil
+}
@@ -0,0 +1,28 @@
+package cron
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/service"
+	"github.com/robfig/cron/v3"
+)
+
+// StartNotificationDelivery 启动发件箱投递任务，每 10 秒投递一轮到期通知
+func StartNotificationDelivery(notificationService *service.NotificationService) (*cron.Cron, error) {
+	if notificationService == nil {
+		return nil, nil
+	}
+	// 上一轮未结束时跳过，避免钉钉接口变慢时任务堆积
+	c := cron.New(cron.WithSeconds(), cron.WithChain(cron.SkipIfStillRunning(cron.DefaultLogger)))
+	if _, err := c.AddFunc("*/10 * * * * *", func() {
+		if _, err := notificationService.DeliverDue(time.Now()); err != nil {
+			fmt.Printf("[NotificationDelivery] 投递失败: %v\n", err)
+		}
+	}); err != nil {
+		return nil, fmt.Errorf("添加通知投递任务失败: %w", err)
+	}
+	c.Start()
+	fmt.Println("[NotificationDelivery] 通知发件箱投递任务已启动 (每10秒执行)")
+	return c, nil
+}
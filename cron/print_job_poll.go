@@ -0,0 +1,27 @@
+package cron
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/service"
+	"github.com/robfig/cron/v3"
+)
+
+// StartPrintJobPoll 启动打印任务状态轮询，每 15 秒查询一轮已发送任务是否出纸
+func StartPrintJobPoll(printerService *service.PrinterService) (*cron.Cron, error) {
+	if printerService == nil {
+		return nil, nil
+	}
+	c := cron.New(cron.WithSeconds(), cron.WithChain(cron.SkipIfStillRunning(cron.DefaultLogger)))
+	if _, err := c.AddFunc("*/15 * * * * *", func() {
+		if _, err := printerService.PollPrintJobs(time.Now()); err != nil {
+			fmt.Printf("[PrintJobPoll] 查询打印结果失败: %v\n", err)
+		}
+	}); err != nil {
+		return nil, fmt.Errorf("添加打印任务轮询失败: %w", err)
+	}
+	c.Start()
+	fmt.Println("[PrintJobPoll] 打印任务状态轮询已启动 (每15秒执行)")
+	return c, nil
+}
@@ -0,0 +1,43 @@
+package datascope
+
+import (
+	"github.com/Kevin-Jii/tower-go/internal/authctx"
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+// CanAccessRow 内存中判断单行数据是否在 AuthContext 的数据范围内（与 DataPermission 的 WHERE 条件一致），
+// 供实时推送等不经过 SQL 的场景使用。creatorID 为 0 表示该数据没有「本人」维度（如库存）。
+func CanAccessRow(ac *authctx.Context, storeID, creatorID uint) bool {
+	if ac == nil {
+		return false
+	}
+	switch ac.EffectiveDataScope {
+	case model.DataScopeAll:
+		return true
+	case model.DataScopeSelf:
+		if ac.StoreID > 0 && storeID != ac.StoreID {
+			return false
+		}
+		if creatorID > 0 {
+			return creatorID == ac.UserID
+		}
+		return ac.StoreID > 0
+	case model.DataScopeTenant, model.DataScopeStore:
+		fallthrough
+	default:
+		if len(ac.CustomStoreIDs) > 0 {
+			for _, id := range ac.CustomStoreIDs {
+				if id == storeID {
+					return true
+				}
+			}
+			return false
+		}
+		return ac.StoreID > 0 && storeID == ac.StoreID
+	}
+}
+
+// CanAccessStore 是否可以查看某门店的数据（不区分创建人）
+func CanAccessStore(ac *authctx.Context, storeID uint) bool {
+	return CanAccessRow(ac, storeID, 0)
+}
@@ -212,6 +212,7 @@ func auditModuleFromPath(path string) (string, string, string) {
 		"members":               {"member", "会员管理"},
 		"wallet-logs":           {"wallet_log", "钱包流水"},
 		"recharge-orders":       {"recharge_order", "充值订单"},
+		"member-wines":          {"member_wine", "会员存酒"},
 		"b2b":                   {"b2b", "B2B"},
 		"price-lists":           {"price_list", "价格清单"},
 		"statistics":            {"statistics", "统计分析"},
@@ -44,23 +44,7 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// 将用户信息存储到上下文（优先使用 Token 中的值，安全可靠）
-		c.Set("userID", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("storeID", claims.StoreID)
-		c.Set("roleCode", claims.RoleCode)
-		c.Set("roleID", claims.RoleID)
-
-		// 加载角色（数据权限 data_scope）
-		if claims.RoleID > 0 && database.DB != nil {
-			var role model.Role
-			if err := database.DB.First(&role, claims.RoleID).Error; err == nil {
-				c.Set("roleModel", &role)
-			}
-		}
-
-		// 统一 AuthContext（数据权限 Pipeline P0，供 internal/datascope 与后续 Repository 使用）
-		c.Set(authctx.GinKey, buildAuthContext(c, claims))
+		SetAuthClaims(c, claims)
 
 		// 同时保存请求头中的值（供日志或特殊场景使用）
 		c.Set("headerUserID", c.GetHeader("X-User-Id"))
@@ -70,6 +54,27 @@ func AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// SetAuthClaims 将已校验的 Token 信息、角色与 AuthContext 写入上下文（WebSocket 握手等不走 AuthMiddleware 的入口复用）
+func SetAuthClaims(c *gin.Context, claims *auth.Claims) {
+	// 将用户信息存储到上下文（优先使用 Token 中的值，安全可靠）
+	c.Set("userID", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("storeID", claims.StoreID)
+	c.Set("roleCode", claims.RoleCode)
+	c.Set("roleID", claims.RoleID)
+
+	// 加载角色（数据权限 data_scope）
+	if claims.RoleID > 0 && database.DB != nil {
+		var role model.Role
+		if err := database.DB.First(&role, claims.RoleID).Error; err == nil {
+			c.Set("roleModel", &role)
+		}
+	}
+
+	// 统一 AuthContext（数据权限 Pipeline P0，供 internal/datascope 与后续 Repository 使用）
+	c.Set(authctx.GinKey, buildAuthContext(c, claims))
+}
+
 func buildAuthContext(c *gin.Context, claims *auth.Claims) *authctx.Context {
 	return &authctx.Context{
 		UserID:             claims.UserID,
@@ -123,6 +123,8 @@ CREATE TABLE IF NOT EXISTS `message_templates` (
   `content` TEXT NOT NULL,
   `description` VARCHAR(500) DEFAULT NULL,
   `variables` TEXT,
+  `schema` JSON DEFAULT NULL COMMENT '变量结构定义',
+  `version` BIGINT NOT NULL DEFAULT 1 COMMENT '当前版本号',
   `is_enabled` TINYINT(1) NOT NULL DEFAULT 1,
   `created_at` DATETIME(3) DEFAULT NULL,
   `updated_at` DATETIME(3) DEFAULT NULL,
@@ -263,6 +265,12 @@ CREATE TABLE IF NOT EXISTS `store_accounts` (
   `total_amount` DECIMAL(10,2) DEFAULT NULL,
   `other_expense_amount` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '其他支出金额',
   `round_amount` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '抹零金额',
+  `points_redeemed` INT NOT NULL DEFAULT 0 COMMENT '抵扣使用积分',
+  `points_deduction` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '积分抵扣金额',
+  `member_discount_rate` DECIMAL(5,4) NOT NULL DEFAULT 0 COMMENT '会员等级折扣率，0表示未打折',
+  `member_discount` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '会员折扣优惠金额',
+  `balance_amount` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '会员余额支付金额',
+  `pay_method` VARCHAR(20) NOT NULL DEFAULT '' COMMENT '余额以外部分的支付方式 cash/bank/wechat/alipay/other',
   `is_gift_wine` TINYINT NOT NULL DEFAULT 0 COMMENT '是否赠酒 1=是 0=否',
   `gift_wine_product_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '赠酒商品ID',
   `gift_wine_product_name` VARCHAR(200) NOT NULL DEFAULT '' COMMENT '赠酒商品名称',
@@ -361,6 +369,7 @@ CREATE TABLE IF NOT EXISTS `store_returns` (
   `return_no` VARCHAR(50) NOT NULL,
   `client_req_id` VARCHAR(64) DEFAULT NULL,
   `store_id` BIGINT UNSIGNED NOT NULL,
+  `supplier_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '返厂供应商ID，0=未关联（不计入应付）',
   `return_date` DATE DEFAULT NULL,
   `logistics_fee` DECIMAL(10,2) NOT NULL DEFAULT 0,
   `total_deposit` DECIMAL(10,2) NOT NULL DEFAULT 0,
@@ -376,6 +385,7 @@ CREATE TABLE IF NOT EXISTS `store_returns` (
   UNIQUE KEY `idx_store_returns_return_no` (`return_no`),
   UNIQUE KEY `idx_store_returns_client_req_id` (`client_req_id`),
   KEY `idx_store_returns_store_id` (`store_id`),
+  KEY `idx_store_returns_supplier_id` (`supplier_id`),
   KEY `idx_store_returns_return_date` (`return_date`),
   KEY `idx_store_returns_deleted_at` (`deleted_at`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='门店返厂单';
@@ -837,12 +847,16 @@ CREATE TABLE IF NOT EXISTS `t_recharge_order` (
   `gift_amount` decimal(10,2) NOT NULL DEFAULT '0.00' COMMENT '赠送金额',
   `total_amount` decimal(10,2) NOT NULL DEFAULT '0.00' COMMENT '总金额',
   `pay_status` tinyint NOT NULL DEFAULT '0' COMMENT '支付状态: 0=待支付 1=已支付 2=已取消 3=已退款',
+  `package_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '充值套餐ID，0表示未使用套餐',
+  `package_name` varchar(100) DEFAULT NULL COMMENT '充值套餐名称',
+  `first_gift` decimal(10,2) NOT NULL DEFAULT '0.00' COMMENT '首充赠送金额（已含在赠送金额中）',
   `pay_time` datetime DEFAULT NULL COMMENT '支付时间',
   `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
   `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
   PRIMARY KEY (`id`),
   UNIQUE KEY `idx_order_no` (`order_no`),
-  KEY `idx_member_id` (`member_id`)
+  KEY `idx_member_id` (`member_id`),
+  KEY `idx_t_recharge_order_package_id` (`package_id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='充值单表';
 
 -- 会员存酒当前存量
@@ -854,12 +868,16 @@ CREATE TABLE IF NOT EXISTS `member_wine_storages` (
   `unit` VARCHAR(20) NOT NULL DEFAULT '瓶' COMMENT '单位',
   `quantity` DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '当前数量',
   `remark` VARCHAR(500) DEFAULT NULL COMMENT '备注',
+  `expire_at` DATETIME(3) DEFAULT NULL COMMENT '最早到期时间',
+  `last_access_at` DATETIME(3) DEFAULT NULL COMMENT '最近存取时间',
+  `idle_reminded_at` DATETIME(3) DEFAULT NULL COMMENT '久未存取提醒时间，存取后清空',
   `created_at` DATETIME(3) DEFAULT NULL,
   `updated_at` DATETIME(3) DEFAULT NULL,
   PRIMARY KEY (`id`),
   UNIQUE KEY `idx_member_wine_storage_unique` (`store_id`, `member_id`, `wine_name`, `unit`),
   KEY `idx_member_wine_storages_store_id` (`store_id`),
   KEY `idx_member_wine_storages_member_id` (`member_id`),
+  KEY `idx_member_wine_storages_expire_at` (`expire_at`),
   KEY `idx_member_wine_storages_updated_at` (`updated_at`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='会员存酒当前存量';
 
@@ -869,7 +887,7 @@ CREATE TABLE IF NOT EXISTS `member_wine_transactions` (
   `store_id` BIGINT UNSIGNED NOT NULL COMMENT '门店ID',
   `storage_id` BIGINT UNSIGNED NOT NULL COMMENT '存酒记录ID',
   `member_id` BIGINT UNSIGNED NOT NULL COMMENT '会员ID',
-  `type` INT NOT NULL COMMENT '类型 1=存入 2=取出',
+  `type` INT NOT NULL COMMENT '类型 1=存入 2=取出 3=到期入库 4=续存',
   `wine_name` VARCHAR(120) NOT NULL COMMENT '酒品名称',
   `unit` VARCHAR(20) NOT NULL DEFAULT '瓶' COMMENT '单位',
   `quantity` DECIMAL(12,2) NOT NULL COMMENT '本次数量',
@@ -877,12 +895,21 @@ CREATE TABLE IF NOT EXISTS `member_wine_transactions` (
   `remark` VARCHAR(500) DEFAULT NULL COMMENT '备注',
   `operator_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '操作人ID',
   `operator_name` VARCHAR(100) DEFAULT NULL COMMENT '操作人',
+  `product_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '关联商品ID，到期转入库存用',
+  `remaining` DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '存入批次剩余数量',
+  `expire_at` DATETIME(3) DEFAULT NULL COMMENT '存入批次到期时间',
+  `reminded_at` DATETIME(3) DEFAULT NULL COMMENT '到期提醒时间，续存后清空',
+  `photo_urls` JSON DEFAULT NULL COMMENT '存酒照片URL，最多3张',
+  `lot_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '续存/到期入库对应的存入流水ID',
+  `inventory_order_no` VARCHAR(50) DEFAULT NULL COMMENT '到期入库单号',
   `created_at` DATETIME(3) DEFAULT NULL,
   PRIMARY KEY (`id`),
   KEY `idx_member_wine_transactions_store_id` (`store_id`),
   KEY `idx_member_wine_transactions_storage_id` (`storage_id`),
   KEY `idx_member_wine_transactions_member_id` (`member_id`),
   KEY `idx_member_wine_transactions_type` (`type`),
+  KEY `idx_member_wine_transactions_expire_at` (`expire_at`),
+  KEY `idx_member_wine_transactions_lot_id` (`lot_id`),
   KEY `idx_member_wine_transactions_created_at` (`created_at`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='会员存取酒流水';
 
@@ -1362,7 +1389,8 @@ CREATE TABLE IF NOT EXISTS b2b_customers (
   address VARCHAR(255) NOT NULL DEFAULT '' COMMENT '地址',
   settlement VARCHAR(30) NOT NULL DEFAULT 'cash' COMMENT '结算方式 cash/week/month',
   price_level VARCHAR(30) NOT NULL DEFAULT '' COMMENT '价格等级',
-  credit_limit DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '信用额度',
+  credit_limit DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '信用额度，0=不限',
+  credit_control VARCHAR(10) NOT NULL DEFAULT 'hard' COMMENT '超额控制 hard=拒绝 soft=确认后放行',
   receivable DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '当前应收余额',
   status TINYINT NOT NULL DEFAULT 1 COMMENT '状态 1=启用 2=停用',
   remark TEXT NULL COMMENT '备注',
@@ -1412,9 +1440,11 @@ CREATE TABLE IF NOT EXISTS b2b_supply_orders (
   total_amount DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '订单金额',
   paid_amount DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '已收金额',
   unpaid_amount DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '未收金额',
+  returned_amount DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '已退货金额',
   cost_amount DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '成本金额',
-  profit_amount DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '毛利金额',
+  profit_amount DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '毛利金额（已扣除退货）',
   payment_status TINYINT NOT NULL DEFAULT 1 COMMENT '收款状态 1=未收 2=部分 3=已收',
+  credit_override TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否确认超信用额度下单',
   delivery_status TINYINT NOT NULL DEFAULT 1 COMMENT '配送状态 1=待配送 2=已配送 3=已取消',
   remark TEXT NULL COMMENT '备注',
   operator_id BIGINT UNSIGNED NOT NULL COMMENT '操作人ID',
@@ -1441,6 +1471,7 @@ CREATE TABLE IF NOT EXISTS b2b_supply_order_items (
   unit_name VARCHAR(50) NOT NULL DEFAULT '' COMMENT '规格名称快照',
   factor_to_base DECIMAL(12,6) NOT NULL DEFAULT 1 COMMENT '换算基础库存系数',
   quantity DECIMAL(10,2) NOT NULL COMMENT '下单数量',
+  returned_quantity DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '已退货数量',
   base_quantity DECIMAL(12,2) NOT NULL COMMENT '扣减基础库存数量',
   supply_price DECIMAL(10,2) NOT NULL COMMENT '供货单价',
   cost_price DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '成本单价',
@@ -1571,6 +1602,465 @@ PREPARE stmt_add_stores_third_party_account_idx FROM @sql_add_stores_third_party
 EXECUTE stmt_add_stores_third_party_account_idx;
 DEALLOCATE PREPARE stmt_add_stores_third_party_account_idx;
 
+SET @sql_add_store_returns_supplier_id = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'store_returns'
+        AND COLUMN_NAME = 'supplier_id'
+    ),
+    'SELECT ''skip add store_returns.supplier_id''',
+    'ALTER TABLE store_returns ADD COLUMN supplier_id BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT ''返厂供应商ID，0=未关联（不计入应付）'' AFTER store_id, ADD INDEX idx_store_returns_supplier_id (supplier_id)'
+  )
+);
+PREPARE stmt_add_store_returns_supplier_id FROM @sql_add_store_returns_supplier_id;
+EXECUTE stmt_add_store_returns_supplier_id;
+DEALLOCATE PREPARE stmt_add_store_returns_supplier_id;
+
+SET @sql_add_b2b_customers_credit_control = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'b2b_customers'
+        AND COLUMN_NAME = 'credit_control'
+    ),
+    'SELECT ''skip add b2b_customers.credit_control''',
+    'ALTER TABLE b2b_customers ADD COLUMN credit_control VARCHAR(10) NOT NULL DEFAULT ''hard'' COMMENT ''超额控制 hard=拒绝 soft=确认后放行'' AFTER credit_limit'
+  )
+);
+PREPARE stmt_add_b2b_customers_credit_control FROM @sql_add_b2b_customers_credit_control;
+EXECUTE stmt_add_b2b_customers_credit_control;
+DEALLOCATE PREPARE stmt_add_b2b_customers_credit_control;
+
+SET @sql_add_b2b_supply_orders_credit_override = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'b2b_supply_orders'
+        AND COLUMN_NAME = 'credit_override'
+    ),
+    'SELECT ''skip add b2b_supply_orders.credit_override''',
+    'ALTER TABLE b2b_supply_orders ADD COLUMN credit_override TINYINT(1) NOT NULL DEFAULT 0 COMMENT ''是否确认超信用额度下单'' AFTER payment_status'
+  )
+);
+PREPARE stmt_add_b2b_supply_orders_credit_override FROM @sql_add_b2b_supply_orders_credit_override;
+EXECUTE stmt_add_b2b_supply_orders_credit_override;
+DEALLOCATE PREPARE stmt_add_b2b_supply_orders_credit_override;
+
+SET @sql_add_b2b_supply_orders_returned_amount = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'b2b_supply_orders'
+        AND COLUMN_NAME = 'returned_amount'
+    ),
+    'SELECT ''skip add b2b_supply_orders.returned_amount''',
+    'ALTER TABLE b2b_supply_orders ADD COLUMN returned_amount DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT ''已退货金额'' AFTER unpaid_amount'
+  )
+);
+PREPARE stmt_add_b2b_supply_orders_returned_amount FROM @sql_add_b2b_supply_orders_returned_amount;
+EXECUTE stmt_add_b2b_supply_orders_returned_amount;
+DEALLOCATE PREPARE stmt_add_b2b_supply_orders_returned_amount;
+
+SET @sql_add_b2b_supply_order_items_returned_quantity = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'b2b_supply_order_items'
+        AND COLUMN_NAME = 'returned_quantity'
+    ),
+    'SELECT ''skip add b2b_supply_order_items.returned_quantity''',
+    'ALTER TABLE b2b_supply_order_items ADD COLUMN returned_quantity DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT ''已退货数量'' AFTER quantity'
+  )
+);
+PREPARE stmt_add_b2b_supply_order_items_returned_quantity FROM @sql_add_b2b_supply_order_items_returned_quantity;
+EXECUTE stmt_add_b2b_supply_order_items_returned_quantity;
+DEALLOCATE PREPARE stmt_add_b2b_supply_order_items_returned_quantity;
+
+SET @sql_add_message_templates_schema = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'message_templates'
+        AND COLUMN_NAME = 'schema'
+    ),
+    'SELECT ''skip add message_templates.schema''',
+    'ALTER TABLE message_templates ADD COLUMN `schema` JSON DEFAULT NULL COMMENT ''变量结构定义'' AFTER variables'
+  )
+);
+PREPARE stmt_add_message_templates_schema FROM @sql_add_message_templates_schema;
+EXECUTE stmt_add_message_templates_schema;
+DEALLOCATE PREPARE stmt_add_message_templates_schema;
+
+SET @sql_add_message_templates_version = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'message_templates'
+        AND COLUMN_NAME = 'version'
+    ),
+    'SELECT ''skip add message_templates.version''',
+    'ALTER TABLE message_templates ADD COLUMN version BIGINT NOT NULL DEFAULT 1 COMMENT ''当前版本号'' AFTER `schema`'
+  )
+);
+PREPARE stmt_add_message_templates_version FROM @sql_add_message_templates_version;
+EXECUTE stmt_add_message_templates_version;
+DEALLOCATE PREPARE stmt_add_message_templates_version;
+
+SET @sql_add_printers_paper_width = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'printers'
+        AND COLUMN_NAME = 'paper_width'
+    ),
+    'SELECT ''skip add printers.paper_width''',
+    'ALTER TABLE printers ADD COLUMN paper_width INT NOT NULL DEFAULT 58 COMMENT ''纸宽(mm)：58 或 80'' AFTER `online`'
+  )
+);
+PREPARE stmt_add_printers_paper_width FROM @sql_add_printers_paper_width;
+EXECUTE stmt_add_printers_paper_width;
+DEALLOCATE PREPARE stmt_add_printers_paper_width;
+
+SET @sql_add_store_accounts_points_redeemed = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'store_accounts'
+        AND COLUMN_NAME = 'points_redeemed'
+    ),
+    'SELECT ''skip add store_accounts.points_redeemed''',
+    'ALTER TABLE store_accounts ADD COLUMN points_redeemed INT NOT NULL DEFAULT 0 COMMENT ''抵扣使用积分'' AFTER round_amount'
+  )
+);
+PREPARE stmt_add_store_accounts_points_redeemed FROM @sql_add_store_accounts_points_redeemed;
+EXECUTE stmt_add_store_accounts_points_redeemed;
+DEALLOCATE PREPARE stmt_add_store_accounts_points_redeemed;
+
+SET @sql_add_store_accounts_points_deduction = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'store_accounts'
+        AND COLUMN_NAME = 'points_deduction'
+    ),
+    'SELECT ''skip add store_accounts.points_deduction''',
+    'ALTER TABLE store_accounts ADD COLUMN points_deduction DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT ''积分抵扣金额'' AFTER points_redeemed'
+  )
+);
+PREPARE stmt_add_store_accounts_points_deduction FROM @sql_add_store_accounts_points_deduction;
+EXECUTE stmt_add_store_accounts_points_deduction;
+DEALLOCATE PREPARE stmt_add_store_accounts_points_deduction;
+
+SET @sql_add_store_accounts_member_discount_rate = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'store_accounts'
+        AND COLUMN_NAME = 'member_discount_rate'
+    ),
+    'SELECT ''skip add store_accounts.member_discount_rate''',
+    'ALTER TABLE store_accounts ADD COLUMN member_discount_rate DECIMAL(5,4) NOT NULL DEFAULT 0 COMMENT ''会员等级折扣率，0表示未打折'' AFTER points_deduction'
+  )
+);
+PREPARE stmt_add_store_accounts_member_discount_rate FROM @sql_add_store_accounts_member_discount_rate;
+EXECUTE stmt_add_store_accounts_member_discount_rate;
+DEALLOCATE PREPARE stmt_add_store_accounts_member_discount_rate;
+
+SET @sql_add_store_accounts_member_discount = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'store_accounts'
+        AND COLUMN_NAME = 'member_discount'
+    ),
+    'SELECT ''skip add store_accounts.member_discount''',
+    'ALTER TABLE store_accounts ADD COLUMN member_discount DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT ''会员折扣优惠金额'' AFTER member_discount_rate'
+  )
+);
+PREPARE stmt_add_store_accounts_member_discount FROM @sql_add_store_accounts_member_discount;
+EXECUTE stmt_add_store_accounts_member_discount;
+DEALLOCATE PREPARE stmt_add_store_accounts_member_discount;
+
+SET @sql_add_t_recharge_order_package_id = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 't_recharge_order'
+        AND COLUMN_NAME = 'package_id'
+    ),
+    'SELECT ''skip add t_recharge_order.package_id''',
+    'ALTER TABLE t_recharge_order ADD COLUMN package_id BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT ''充值套餐ID，0表示未使用套餐'' AFTER pay_status'
+  )
+);
+PREPARE stmt_add_t_recharge_order_package_id FROM @sql_add_t_recharge_order_package_id;
+EXECUTE stmt_add_t_recharge_order_package_id;
+DEALLOCATE PREPARE stmt_add_t_recharge_order_package_id;
+
+SET @sql_add_t_recharge_order_package_name = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 't_recharge_order'
+        AND COLUMN_NAME = 'package_name'
+    ),
+    'SELECT ''skip add t_recharge_order.package_name''',
+    'ALTER TABLE t_recharge_order ADD COLUMN package_name VARCHAR(100) DEFAULT NULL COMMENT ''充值套餐名称'' AFTER package_id'
+  )
+);
+PREPARE stmt_add_t_recharge_order_package_name FROM @sql_add_t_recharge_order_package_name;
+EXECUTE stmt_add_t_recharge_order_package_name;
+DEALLOCATE PREPARE stmt_add_t_recharge_order_package_name;
+
+SET @sql_add_t_recharge_order_first_gift = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 't_recharge_order'
+        AND COLUMN_NAME = 'first_gift'
+    ),
+    'SELECT ''skip add t_recharge_order.first_gift''',
+    'ALTER TABLE t_recharge_order ADD COLUMN first_gift DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT ''首充赠送金额（已含在赠送金额中）'' AFTER package_name'
+  )
+);
+PREPARE stmt_add_t_recharge_order_first_gift FROM @sql_add_t_recharge_order_first_gift;
+EXECUTE stmt_add_t_recharge_order_first_gift;
+DEALLOCATE PREPARE stmt_add_t_recharge_order_first_gift;
+
+SET @sql_add_store_accounts_balance_amount = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'store_accounts'
+        AND COLUMN_NAME = 'balance_amount'
+    ),
+    'SELECT ''skip add store_accounts.balance_amount''',
+    'ALTER TABLE store_accounts ADD COLUMN balance_amount DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT ''会员余额支付金额'' AFTER member_discount'
+  )
+);
+PREPARE stmt_add_store_accounts_balance_amount FROM @sql_add_store_accounts_balance_amount;
+EXECUTE stmt_add_store_accounts_balance_amount;
+DEALLOCATE PREPARE stmt_add_store_accounts_balance_amount;
+
+SET @sql_add_store_accounts_pay_method = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'store_accounts'
+        AND COLUMN_NAME = 'pay_method'
+    ),
+    'SELECT ''skip add store_accounts.pay_method''',
+    'ALTER TABLE store_accounts ADD COLUMN pay_method VARCHAR(20) NOT NULL DEFAULT '''' COMMENT ''余额以外部分的支付方式 cash/bank/wechat/alipay/other'' AFTER balance_amount'
+  )
+);
+PREPARE stmt_add_store_accounts_pay_method FROM @sql_add_store_accounts_pay_method;
+EXECUTE stmt_add_store_accounts_pay_method;
+DEALLOCATE PREPARE stmt_add_store_accounts_pay_method;
+
+SET @sql_add_member_wine_storages_expire_at = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'member_wine_storages'
+        AND COLUMN_NAME = 'expire_at'
+    ),
+    'SELECT ''skip add member_wine_storages.expire_at''',
+    'ALTER TABLE member_wine_storages ADD COLUMN expire_at DATETIME(3) DEFAULT NULL COMMENT ''最早到期时间'' AFTER remark'
+  )
+);
+PREPARE stmt_add_member_wine_storages_expire_at FROM @sql_add_member_wine_storages_expire_at;
+EXECUTE stmt_add_member_wine_storages_expire_at;
+DEALLOCATE PREPARE stmt_add_member_wine_storages_expire_at;
+
+SET @sql_add_member_wine_storages_last_access_at = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'member_wine_storages'
+        AND COLUMN_NAME = 'last_access_at'
+    ),
+    'SELECT ''skip add member_wine_storages.last_access_at''',
+    'ALTER TABLE member_wine_storages ADD COLUMN last_access_at DATETIME(3) DEFAULT NULL COMMENT ''最近存取时间'' AFTER expire_at'
+  )
+);
+PREPARE stmt_add_member_wine_storages_last_access_at FROM @sql_add_member_wine_storages_last_access_at;
+EXECUTE stmt_add_member_wine_storages_last_access_at;
+DEALLOCATE PREPARE stmt_add_member_wine_storages_last_access_at;
+
+SET @sql_add_member_wine_storages_idle_reminded_at = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'member_wine_storages'
+        AND COLUMN_NAME = 'idle_reminded_at'
+    ),
+    'SELECT ''skip add member_wine_storages.idle_reminded_at''',
+    'ALTER TABLE member_wine_storages ADD COLUMN idle_reminded_at DATETIME(3) DEFAULT NULL COMMENT ''久未存取提醒时间，存取后清空'' AFTER last_access_at'
+  )
+);
+PREPARE stmt_add_member_wine_storages_idle_reminded_at FROM @sql_add_member_wine_storages_idle_reminded_at;
+EXECUTE stmt_add_member_wine_storages_idle_reminded_at;
+DEALLOCATE PREPARE stmt_add_member_wine_storages_idle_reminded_at;
+
+SET @sql_add_member_wine_transactions_product_id = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'member_wine_transactions'
+        AND COLUMN_NAME = 'product_id'
+    ),
+    'SELECT ''skip add member_wine_transactions.product_id''',
+    'ALTER TABLE member_wine_transactions ADD COLUMN product_id BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT ''关联商品ID，到期转入库存用'' AFTER operator_name'
+  )
+);
+PREPARE stmt_add_member_wine_transactions_product_id FROM @sql_add_member_wine_transactions_product_id;
+EXECUTE stmt_add_member_wine_transactions_product_id;
+DEALLOCATE PREPARE stmt_add_member_wine_transactions_product_id;
+
+SET @sql_add_member_wine_transactions_remaining = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'member_wine_transactions'
+        AND COLUMN_NAME = 'remaining'
+    ),
+    'SELECT ''skip add member_wine_transactions.remaining''',
+    'ALTER TABLE member_wine_transactions ADD COLUMN remaining DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT ''存入批次剩余数量'' AFTER product_id'
+  )
+);
+PREPARE stmt_add_member_wine_transactions_remaining FROM @sql_add_member_wine_transactions_remaining;
+EXECUTE stmt_add_member_wine_transactions_remaining;
+DEALLOCATE PREPARE stmt_add_member_wine_transactions_remaining;
+
+SET @sql_add_member_wine_transactions_expire_at = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'member_wine_transactions'
+        AND COLUMN_NAME = 'expire_at'
+    ),
+    'SELECT ''skip add member_wine_transactions.expire_at''',
+    'ALTER TABLE member_wine_transactions ADD COLUMN expire_at DATETIME(3) DEFAULT NULL COMMENT ''存入批次到期时间'' AFTER remaining'
+  )
+);
+PREPARE stmt_add_member_wine_transactions_expire_at FROM @sql_add_member_wine_transactions_expire_at;
+EXECUTE stmt_add_member_wine_transactions_expire_at;
+DEALLOCATE PREPARE stmt_add_member_wine_transactions_expire_at;
+
+SET @sql_add_member_wine_transactions_reminded_at = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'member_wine_transactions'
+        AND COLUMN_NAME = 'reminded_at'
+    ),
+    'SELECT ''skip add member_wine_transactions.reminded_at''',
+    'ALTER TABLE member_wine_transactions ADD COLUMN reminded_at DATETIME(3) DEFAULT NULL COMMENT ''到期提醒时间，续存后清空'' AFTER expire_at'
+  )
+);
+PREPARE stmt_add_member_wine_transactions_reminded_at FROM @sql_add_member_wine_transactions_reminded_at;
+EXECUTE stmt_add_member_wine_transactions_reminded_at;
+DEALLOCATE PREPARE stmt_add_member_wine_transactions_reminded_at;
+
+SET @sql_add_member_wine_transactions_photo_urls = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'member_wine_transactions'
+        AND COLUMN_NAME = 'photo_urls'
+    ),
+    'SELECT ''skip add member_wine_transactions.photo_urls''',
+    'ALTER TABLE member_wine_transactions ADD COLUMN photo_urls JSON DEFAULT NULL COMMENT ''存酒照片URL，最多3张'' AFTER reminded_at'
+  )
+);
+PREPARE stmt_add_member_wine_transactions_photo_urls FROM @sql_add_member_wine_transactions_photo_urls;
+EXECUTE stmt_add_member_wine_transactions_photo_urls;
+DEALLOCATE PREPARE stmt_add_member_wine_transactions_photo_urls;
+
+SET @sql_add_member_wine_transactions_lot_id = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'member_wine_transactions'
+        AND COLUMN_NAME = 'lot_id'
+    ),
+    'SELECT ''skip add member_wine_transactions.lot_id''',
+    'ALTER TABLE member_wine_transactions ADD COLUMN lot_id BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT ''续存/到期入库对应的存入流水ID'' AFTER photo_urls'
+  )
+);
+PREPARE stmt_add_member_wine_transactions_lot_id FROM @sql_add_member_wine_transactions_lot_id;
+EXECUTE stmt_add_member_wine_transactions_lot_id;
+DEALLOCATE PREPARE stmt_add_member_wine_transactions_lot_id;
+
+SET @sql_add_member_wine_transactions_inventory_order_no = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'member_wine_transactions'
+        AND COLUMN_NAME = 'inventory_order_no'
+    ),
+    'SELECT ''skip add member_wine_transactions.inventory_order_no''',
+    'ALTER TABLE member_wine_transactions ADD COLUMN inventory_order_no VARCHAR(50) DEFAULT NULL COMMENT ''到期入库单号'' AFTER lot_id'
+  )
+);
+PREPARE stmt_add_member_wine_transactions_inventory_order_no FROM @sql_add_member_wine_transactions_inventory_order_no;
+EXECUTE stmt_add_member_wine_transactions_inventory_order_no;
+DEALLOCATE PREPARE stmt_add_member_wine_transactions_inventory_order_no;
+
 -- 超级管理员 store_id=0 不引用 stores，移除 GORM 自动创建的外键（若存在）
 SET @db_name = DATABASE();
 SET @sql_drop_users_store_fk = (
@@ -1842,3 +2332,742 @@ WHERE NOT EXISTS (SELECT 1 FROM dict_data WHERE type_code='EXPENDITURECLASS' AND
 INSERT INTO dict_data (`type_id`, `type_code`, `label`, `value`, `sort`, `css_class`, `list_class`, `is_default`, `remark`, `status`, `created_at`, `updated_at`)
 SELECT @expense_type_id, 'EXPENDITURECLASS', '其他', 'other', 99, '', 'info', false, '', 1, NOW(3), NOW(3)
 WHERE NOT EXISTS (SELECT 1 FROM dict_data WHERE type_code='EXPENDITURECLASS' AND value='other');
+
+-- 门店间调拨单（调出门店发货出库 → 在途 → 调入门店收货入库）
+CREATE TABLE IF NOT EXISTS `stock_transfers` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `transfer_no` VARCHAR(50) NOT NULL COMMENT '调拨单号',
+  `from_store_id` BIGINT UNSIGNED NOT NULL COMMENT '调出门店ID',
+  `from_store_name` VARCHAR(100) DEFAULT NULL COMMENT '调出门店名称',
+  `to_store_id` BIGINT UNSIGNED NOT NULL COMMENT '调入门店ID',
+  `to_store_name` VARCHAR(100) DEFAULT NULL COMMENT '调入门店名称',
+  `status` TINYINT NOT NULL DEFAULT 1 COMMENT '状态 1=在途 2=部分收货 3=已收货 4=已撤销',
+  `total_quantity` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '调出基础库存总数量',
+  `received_total` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '已收基础库存总数量',
+  `item_count` INT NOT NULL DEFAULT 0 COMMENT '商品种类数',
+  `has_discrepancy` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否存在收货差异',
+  `out_order_no` VARCHAR(50) DEFAULT NULL COMMENT '调出出库单号',
+  `remark` VARCHAR(500) DEFAULT NULL COMMENT '备注',
+  `dispatcher_id` BIGINT UNSIGNED NOT NULL COMMENT '发货人ID',
+  `dispatcher_name` VARCHAR(50) DEFAULT NULL COMMENT '发货人姓名',
+  `dispatched_at` DATETIME(3) DEFAULT NULL COMMENT '发货时间',
+  `receiver_id` BIGINT UNSIGNED DEFAULT NULL COMMENT '最近收货人ID',
+  `receiver_name` VARCHAR(50) DEFAULT NULL COMMENT '最近收货人姓名',
+  `received_at` DATETIME(3) DEFAULT NULL COMMENT '收货完成时间',
+  `canceled_at` DATETIME(3) DEFAULT NULL COMMENT '撤销时间',
+  `created_at` DATETIME(3) DEFAULT NULL,
+  `updated_at` DATETIME(3) DEFAULT NULL,
+  `deleted_at` DATETIME(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_stock_transfers_transfer_no` (`transfer_no`),
+  KEY `idx_stock_transfers_from_store_id` (`from_store_id`),
+  KEY `idx_stock_transfers_to_store_id` (`to_store_id`),
+  KEY `idx_stock_transfers_status` (`status`),
+  KEY `idx_stock_transfers_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='门店间调拨单';
+
+CREATE TABLE IF NOT EXISTS `stock_transfer_items` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `transfer_id` BIGINT UNSIGNED NOT NULL COMMENT '调拨单ID',
+  `product_id` BIGINT UNSIGNED NOT NULL COMMENT '商品ID',
+  `product_name` VARCHAR(200) DEFAULT NULL COMMENT '商品名称',
+  `unit` VARCHAR(50) DEFAULT NULL COMMENT '选择规格单位',
+  `quantity` DECIMAL(10,2) NOT NULL COMMENT '选择规格数量',
+  `base_quantity` DECIMAL(10,2) NOT NULL COMMENT '调出基础库存数量',
+  `base_unit` VARCHAR(20) DEFAULT NULL COMMENT '基础库存单位',
+  `production_date` DATE DEFAULT NULL COMMENT '批次生产日期',
+  `expiry_date` DATE DEFAULT NULL COMMENT '批次到期日',
+  `received_quantity` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '已收基础库存数量',
+  `discrepancy_quantity` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '差异数量（调出-实收）',
+  `discrepancy_reason` VARCHAR(200) DEFAULT NULL COMMENT '差异原因',
+  `remark` VARCHAR(500) DEFAULT NULL COMMENT '备注',
+  `created_at` DATETIME(3) DEFAULT NULL,
+  `updated_at` DATETIME(3) DEFAULT NULL,
+  `deleted_at` DATETIME(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_stock_transfer_items_transfer_id` (`transfer_id`),
+  KEY `idx_stock_transfer_items_product_id` (`product_id`),
+  KEY `idx_stock_transfer_items_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='门店间调拨单明细';
+
+-- 调拨明细按调出批次拆行，记录批次生产日期与到期日
+SET @sql_add_stock_transfer_items_production_date = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'stock_transfer_items'
+        AND COLUMN_NAME = 'production_date'
+    ),
+    'SELECT ''skip add stock_transfer_items.production_date''',
+    'ALTER TABLE stock_transfer_items ADD COLUMN production_date DATE DEFAULT NULL COMMENT ''批次生产日期'' AFTER base_unit'
+  )
+);
+PREPARE stmt_add_stock_transfer_items_production_date FROM @sql_add_stock_transfer_items_production_date;
+EXECUTE stmt_add_stock_transfer_items_production_date;
+DEALLOCATE PREPARE stmt_add_stock_transfer_items_production_date;
+SET @sql_add_stock_transfer_items_expiry_date = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'stock_transfer_items'
+        AND COLUMN_NAME = 'expiry_date'
+    ),
+    'SELECT ''skip add stock_transfer_items.expiry_date''',
+    'ALTER TABLE stock_transfer_items ADD COLUMN expiry_date DATE DEFAULT NULL COMMENT ''批次到期日'' AFTER production_date'
+  )
+);
+PREPARE stmt_add_stock_transfer_items_expiry_date FROM @sql_add_stock_transfer_items_expiry_date;
+EXECUTE stmt_add_stock_transfer_items_expiry_date;
+DEALLOCATE PREPARE stmt_add_stock_transfer_items_expiry_date;
+
+-- 库存批次（按门店+商品+到期日聚合，出库按到期日先到先出扣减）
+CREATE TABLE IF NOT EXISTS `inventory_lots` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `store_id` BIGINT UNSIGNED NOT NULL COMMENT '门店ID',
+  `product_id` BIGINT UNSIGNED NOT NULL COMMENT '商品ID',
+  `lot_key` VARCHAR(20) NOT NULL DEFAULT '' COMMENT '批次键（到期日 yyyy-mm-dd，无到期日为空）',
+  `production_date` DATE DEFAULT NULL COMMENT '生产日期（首次入库）',
+  `expiry_date` DATE DEFAULT NULL COMMENT '到期日',
+  `quantity` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '批次剩余数量（基础库存单位）',
+  `unit` VARCHAR(20) DEFAULT NULL COMMENT '基础库存单位',
+  `source_order_no` VARCHAR(50) DEFAULT NULL COMMENT '首次入库单号',
+  `created_at` DATETIME(3) DEFAULT NULL,
+  `updated_at` DATETIME(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_inventory_lots_key` (`store_id`, `product_id`, `lot_key`),
+  KEY `idx_inventory_lots_product_id` (`product_id`),
+  KEY `idx_inventory_lots_expiry_date` (`expiry_date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='库存批次';
+
+-- 门店盘点单（开单快照系统库存 → 分次录入实盘 → 差异过账为库存调整单）
+CREATE TABLE IF NOT EXISTS `stocktakes` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `stocktake_no` VARCHAR(50) NOT NULL COMMENT '盘点单号',
+  `store_id` BIGINT UNSIGNED NOT NULL COMMENT '门店ID',
+  `store_name` VARCHAR(100) DEFAULT NULL COMMENT '门店名称',
+  `status` TINYINT NOT NULL DEFAULT 1 COMMENT '状态 1=盘点中 2=已过账 3=已作废',
+  `item_count` INT NOT NULL DEFAULT 0 COMMENT '盘点商品数',
+  `counted_count` INT NOT NULL DEFAULT 0 COMMENT '已录入实盘的商品数',
+  `variance_count` INT NOT NULL DEFAULT 0 COMMENT '过账时存在差异的商品数',
+  `gain_amount` DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '盘盈成本金额',
+  `loss_amount` DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '盘亏成本金额',
+  `adjust_order_no` VARCHAR(50) DEFAULT NULL COMMENT '库存调整单号',
+  `remark` VARCHAR(500) DEFAULT NULL COMMENT '备注',
+  `creator_id` BIGINT UNSIGNED NOT NULL COMMENT '开单人ID',
+  `creator_name` VARCHAR(50) DEFAULT NULL COMMENT '开单人姓名',
+  `poster_id` BIGINT UNSIGNED DEFAULT NULL COMMENT '过账人ID',
+  `poster_name` VARCHAR(50) DEFAULT NULL COMMENT '过账人姓名',
+  `posted_at` DATETIME(3) DEFAULT NULL COMMENT '过账时间',
+  `canceled_at` DATETIME(3) DEFAULT NULL COMMENT '作废时间',
+  `created_at` DATETIME(3) DEFAULT NULL,
+  `updated_at` DATETIME(3) DEFAULT NULL,
+  `deleted_at` DATETIME(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_stocktakes_stocktake_no` (`stocktake_no`),
+  KEY `idx_stocktakes_store_id` (`store_id`),
+  KEY `idx_stocktakes_status` (`status`),
+  KEY `idx_stocktakes_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='门店盘点单';
+
+CREATE TABLE IF NOT EXISTS `stocktake_items` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `stocktake_id` BIGINT UNSIGNED NOT NULL COMMENT '盘点单ID',
+  `product_id` BIGINT UNSIGNED NOT NULL COMMENT '商品ID',
+  `product_name` VARCHAR(200) DEFAULT NULL COMMENT '商品名称',
+  `base_unit` VARCHAR(20) DEFAULT NULL COMMENT '基础库存单位',
+  `system_quantity` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '开单时系统库存',
+  `counted_quantity` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '实盘数量',
+  `counted` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否已录入实盘',
+  `variance_quantity` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '差异数量（实盘-系统）',
+  `cost_price` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '基础单位成本价',
+  `variance_amount` DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '差异成本金额',
+  `remark` VARCHAR(500) DEFAULT NULL COMMENT '备注',
+  `created_at` DATETIME(3) DEFAULT NULL,
+  `updated_at` DATETIME(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_stocktake_items_product` (`stocktake_id`, `product_id`),
+  KEY `idx_stocktake_items_stocktake_id` (`stocktake_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='门店盘点明细';
+
+CREATE TABLE IF NOT EXISTS `stocktake_entries` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `stocktake_id` BIGINT UNSIGNED NOT NULL COMMENT '盘点单ID',
+  `item_id` BIGINT UNSIGNED NOT NULL COMMENT '盘点明细ID',
+  `product_id` BIGINT UNSIGNED NOT NULL COMMENT '商品ID',
+  `unit` VARCHAR(50) DEFAULT NULL COMMENT '录入规格单位',
+  `quantity` DECIMAL(10,2) NOT NULL COMMENT '录入规格数量',
+  `base_quantity` DECIMAL(10,2) NOT NULL COMMENT '折算基础库存数量',
+  `counter_id` BIGINT UNSIGNED NOT NULL COMMENT '录入人ID',
+  `counter_name` VARCHAR(50) DEFAULT NULL COMMENT '录入人姓名',
+  `created_at` DATETIME(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_stocktake_entries_stocktake_id` (`stocktake_id`),
+  KEY `idx_stocktake_entries_item_id` (`item_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='门店盘点实盘录入记录';
+
+-- 库存流水（只增不改，balance_after 为变动后结余）
+CREATE TABLE IF NOT EXISTS `inventory_movements` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `store_id` BIGINT UNSIGNED NOT NULL COMMENT '门店ID',
+  `product_id` BIGINT UNSIGNED NOT NULL COMMENT '商品ID',
+  `delta` DECIMAL(10,2) NOT NULL COMMENT '变动数量（入正出负）',
+  `balance_after` DECIMAL(10,2) NOT NULL COMMENT '变动后结余',
+  `unit` VARCHAR(20) DEFAULT NULL COMMENT '库存单位',
+  `source_type` VARCHAR(30) NOT NULL COMMENT '来源类型',
+  `source_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '来源单据ID',
+  `source_no` VARCHAR(50) DEFAULT NULL COMMENT '来源单号',
+  `operator_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '操作人ID',
+  `operator_name` VARCHAR(50) DEFAULT NULL COMMENT '操作人姓名',
+  `remark` VARCHAR(500) DEFAULT NULL COMMENT '备注',
+  `created_at` DATETIME(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_inventory_movements_card` (`store_id`, `product_id`, `created_at`),
+  KEY `idx_inventory_movements_source` (`source_type`, `source_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='库存流水';
+
+-- 门店商品补货设置（补货点 + 补货目标，或按日均消耗计算目标）
+CREATE TABLE IF NOT EXISTS `store_product_reorder_settings` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `store_id` BIGINT UNSIGNED NOT NULL COMMENT '门店ID',
+  `product_id` BIGINT UNSIGNED NOT NULL COMMENT '商品ID',
+  `supplier_id` BIGINT UNSIGNED NOT NULL COMMENT '供应商ID',
+  `reorder_point` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '补货点（最低库存）',
+  `target_quantity` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '补货目标（最高库存）',
+  `basis` VARCHAR(20) NOT NULL DEFAULT 'fixed' COMMENT '目标依据 fixed=固定目标 consumption=日均消耗',
+  `cover_days` INT NOT NULL DEFAULT 0 COMMENT '按日均消耗时目标覆盖的天数',
+  `lead_days` INT NOT NULL DEFAULT 0 COMMENT '按日均消耗时的到货天数',
+  `safety_stock` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '按日均消耗时的安全库存',
+  `enabled` TINYINT(1) NOT NULL DEFAULT 1 COMMENT '是否启用',
+  `remark` VARCHAR(200) DEFAULT NULL COMMENT '备注',
+  `created_at` DATETIME(3) DEFAULT NULL,
+  `updated_at` DATETIME(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_reorder_settings_store_product` (`store_id`, `product_id`),
+  KEY `idx_store_product_reorder_settings_supplier_id` (`supplier_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='门店商品补货设置';
+
+-- 按日均消耗补货：到货天数与安全库存推导补货点
+SET @sql_add_store_product_reorder_settings_lead_days = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'store_product_reorder_settings'
+        AND COLUMN_NAME = 'lead_days'
+    ),
+    'SELECT ''skip add store_product_reorder_settings.lead_days''',
+    'ALTER TABLE store_product_reorder_settings ADD COLUMN lead_days INT NOT NULL DEFAULT 0 COMMENT ''按日均消耗时的到货天数'' AFTER cover_days'
+  )
+);
+PREPARE stmt_add_store_product_reorder_settings_lead_days FROM @sql_add_store_product_reorder_settings_lead_days;
+EXECUTE stmt_add_store_product_reorder_settings_lead_days;
+DEALLOCATE PREPARE stmt_add_store_product_reorder_settings_lead_days;
+SET @sql_add_store_product_reorder_settings_safety_stock = (
+  SELECT IF(
+    EXISTS(
+      SELECT 1
+      FROM information_schema.COLUMNS
+      WHERE TABLE_SCHEMA = @db_name
+        AND TABLE_NAME = 'store_product_reorder_settings'
+        AND COLUMN_NAME = 'safety_stock'
+    ),
+    'SELECT ''skip add store_product_reorder_settings.safety_stock''',
+    'ALTER TABLE store_product_reorder_settings ADD COLUMN safety_stock DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT ''按日均消耗时的安全库存'' AFTER lead_days'
+  )
+);
+PREPARE stmt_add_store_product_reorder_settings_safety_stock FROM @sql_add_store_product_reorder_settings_safety_stock;
+EXECUTE stmt_add_store_product_reorder_settings_safety_stock;
+DEALLOCATE PREPARE stmt_add_store_product_reorder_settings_safety_stock;
+
+-- 采购收货单（一张采购单可分多次收货，每次生成一张采购入库单）
+CREATE TABLE IF NOT EXISTS `purchase_receipts` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `receipt_no` VARCHAR(50) NOT NULL COMMENT '收货单号',
+  `purchase_order_id` BIGINT UNSIGNED NOT NULL COMMENT '采购单ID',
+  `purchase_order_no` VARCHAR(32) DEFAULT NULL COMMENT '采购单号',
+  `store_id` BIGINT UNSIGNED NOT NULL COMMENT '门店ID',
+  `inventory_order_no` VARCHAR(50) DEFAULT NULL COMMENT '采购入库单号',
+  `total_quantity` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '实收总数量',
+  `total_amount` DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '实收总金额',
+  `price_variance` DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '价格差异金额（实际-订单价）',
+  `remark` VARCHAR(500) DEFAULT NULL COMMENT '备注',
+  `operator_id` BIGINT UNSIGNED NOT NULL COMMENT '收货人ID',
+  `operator_name` VARCHAR(50) DEFAULT NULL COMMENT '收货人姓名',
+  `created_at` DATETIME(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_purchase_receipts_receipt_no` (`receipt_no`),
+  KEY `idx_purchase_receipts_purchase_order_id` (`purchase_order_id`),
+  KEY `idx_purchase_receipts_store_id` (`store_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='采购收货单';
+
+CREATE TABLE IF NOT EXISTS `purchase_receipt_items` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `receipt_id` BIGINT UNSIGNED NOT NULL COMMENT '收货单ID',
+  `order_item_id` BIGINT UNSIGNED NOT NULL COMMENT '采购单明细ID',
+  `supplier_id` BIGINT UNSIGNED NOT NULL COMMENT '供应商ID',
+  `product_id` BIGINT UNSIGNED NOT NULL COMMENT '商品ID',
+  `product_name` VARCHAR(200) DEFAULT NULL COMMENT '商品名称',
+  `unit` VARCHAR(20) DEFAULT NULL COMMENT '单位',
+  `quantity` DECIMAL(10,2) NOT NULL COMMENT '实收数量',
+  `order_price` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '订单单价',
+  `unit_price` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '实际单价',
+  `amount` DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '实收金额',
+  `price_variance` DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '价格差异金额',
+  `production_date` DATE DEFAULT NULL COMMENT '生产日期',
+  `expiry_date` DATE DEFAULT NULL COMMENT '到期日',
+  `remark` VARCHAR(200) DEFAULT NULL COMMENT '备注',
+  `created_at` DATETIME(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_purchase_receipt_items_receipt_id` (`receipt_id`),
+  KEY `idx_purchase_receipt_items_order_item_id` (`order_item_id`),
+  KEY `idx_purchase_receipt_items_supplier_id` (`supplier_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='采购收货明细';
+
+-- 供应商应付台账（采购收货增加应付，返厂押金与付款冲减应付，只追加不修改）
+CREATE TABLE IF NOT EXISTS `supplier_payable_entries` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `store_id` BIGINT UNSIGNED NOT NULL COMMENT '门店ID',
+  `supplier_id` BIGINT UNSIGNED NOT NULL COMMENT '供应商ID',
+  `source_type` VARCHAR(30) NOT NULL COMMENT '来源类型',
+  `source_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '来源单据ID',
+  `source_no` VARCHAR(50) DEFAULT NULL COMMENT '来源单号',
+  `biz_date` DATE NOT NULL COMMENT '业务日期',
+  `amount` DECIMAL(12,2) NOT NULL COMMENT '金额（正数增加应付，负数冲减）',
+  `remark` VARCHAR(500) DEFAULT NULL COMMENT '备注',
+  `created_at` DATETIME(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_supplier_payable_entries_ledger` (`supplier_id`, `store_id`, `biz_date`),
+  KEY `idx_supplier_payable_entries_source` (`source_type`, `source_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='供应商应付台账';
+
+-- 供应商付款记录
+CREATE TABLE IF NOT EXISTS `supplier_payments` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `payment_no` VARCHAR(50) NOT NULL COMMENT '付款单号',
+  `store_id` BIGINT UNSIGNED NOT NULL COMMENT '门店ID',
+  `supplier_id` BIGINT UNSIGNED NOT NULL COMMENT '供应商ID',
+  `amount` DECIMAL(12,2) NOT NULL COMMENT '付款金额',
+  `pay_date` DATE NOT NULL COMMENT '付款日期',
+  `method` VARCHAR(20) DEFAULT NULL COMMENT '付款方式',
+  `reference` VARCHAR(100) DEFAULT NULL COMMENT '流水号/凭证号',
+  `remark` VARCHAR(500) DEFAULT NULL COMMENT '备注',
+  `status` TINYINT NOT NULL DEFAULT 1 COMMENT '状态 1=有效 2=已作废',
+  `void_reason` VARCHAR(200) DEFAULT NULL COMMENT '作废原因',
+  `voided_at` DATETIME(3) DEFAULT NULL,
+  `operator_id` BIGINT UNSIGNED NOT NULL COMMENT '操作人ID',
+  `operator_name` VARCHAR(50) DEFAULT NULL COMMENT '操作人姓名',
+  `created_at` DATETIME(3) DEFAULT NULL,
+  `updated_at` DATETIME(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_supplier_payments_payment_no` (`payment_no`),
+  KEY `idx_supplier_payments_store_id` (`store_id`),
+  KEY `idx_supplier_payments_supplier_id` (`supplier_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='供应商付款记录';
+
+-- B2B 客户应收台账（供货增加应收，收款冲减应收，只追加不修改）
+CREATE TABLE IF NOT EXISTS `b2b_receivable_entries` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `store_id` BIGINT UNSIGNED NOT NULL COMMENT '门店ID',
+  `customer_id` BIGINT UNSIGNED NOT NULL COMMENT '客户ID',
+  `source_type` VARCHAR(30) NOT NULL COMMENT '来源类型',
+  `source_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '来源单据ID',
+  `source_no` VARCHAR(50) DEFAULT NULL COMMENT '来源单号',
+  `biz_date` DATE NOT NULL COMMENT '业务日期',
+  `amount` DECIMAL(12,2) NOT NULL COMMENT '金额（正数增加应收，负数冲减）',
+  `remark` VARCHAR(500) DEFAULT NULL COMMENT '备注',
+  `created_at` DATETIME(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_b2b_receivable_entries_store_id` (`store_id`),
+  KEY `idx_b2b_receivable_entries_ledger` (`customer_id`, `biz_date`),
+  KEY `idx_b2b_receivable_entries_source` (`source_type`, `source_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='B2B客户应收台账';
+
+-- B2B 客户收款记录
+CREATE TABLE IF NOT EXISTS `b2b_payments` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `payment_no` VARCHAR(50) NOT NULL COMMENT '收款单号',
+  `store_id` BIGINT UNSIGNED NOT NULL COMMENT '门店ID',
+  `customer_id` BIGINT UNSIGNED NOT NULL COMMENT '客户ID',
+  `customer_name` VARCHAR(100) DEFAULT NULL COMMENT '客户名称快照',
+  `amount` DECIMAL(12,2) NOT NULL COMMENT '收款金额',
+  `pay_date` DATE NOT NULL COMMENT '收款日期',
+  `method` VARCHAR(20) DEFAULT NULL COMMENT '收款方式 cash/bank/wechat/alipay/other',
+  `reference` VARCHAR(100) DEFAULT NULL COMMENT '流水号/凭证号',
+  `remark` VARCHAR(500) DEFAULT NULL COMMENT '备注',
+  `status` TINYINT NOT NULL DEFAULT 1 COMMENT '状态 1=有效 2=已作废',
+  `void_reason` VARCHAR(200) DEFAULT NULL COMMENT '作废原因',
+  `voided_at` DATETIME(3) DEFAULT NULL,
+  `operator_id` BIGINT UNSIGNED NOT NULL COMMENT '操作人ID',
+  `operator_name` VARCHAR(50) DEFAULT NULL COMMENT '操作人名称',
+  `created_at` DATETIME(3) DEFAULT NULL,
+  `updated_at` DATETIME(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_b2b_payments_payment_no` (`payment_no`),
+  KEY `idx_b2b_payments_store_id` (`store_id`),
+  KEY `idx_b2b_payments_customer_id` (`customer_id`),
+  KEY `idx_b2b_payments_pay_date` (`pay_date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='B2B客户收款记录';
+
+CREATE TABLE IF NOT EXISTS `b2b_payment_allocations` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `payment_id` BIGINT UNSIGNED NOT NULL COMMENT '收款记录ID',
+  `order_id` BIGINT UNSIGNED NOT NULL COMMENT '供货单ID',
+  `order_no` VARCHAR(50) DEFAULT NULL COMMENT '供货单号',
+  `amount` DECIMAL(12,2) NOT NULL COMMENT '核销金额',
+  `created_at` DATETIME(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_b2b_payment_allocations_payment_id` (`payment_id`),
+  KEY `idx_b2b_payment_allocations_order_id` (`order_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='B2B收款核销明细';
+
+-- 启用应收台账前的历史供货单回填：按供货日期记入订单金额与已收金额（仅回填尚无台账的供货单）
+INSERT INTO b2b_receivable_entries (store_id, customer_id, source_type, source_id, source_no, biz_date, amount, remark, created_at)
+SELECT o.store_id, o.customer_id, 'adjustment', o.id, o.order_no, o.order_date, -o.paid_amount, '历史已收金额', NOW(3)
+FROM b2b_supply_orders o
+WHERE o.deleted_at IS NULL
+  AND o.paid_amount > 0
+  AND NOT EXISTS (
+    SELECT 1 FROM b2b_receivable_entries e
+    WHERE e.source_type = 'supply_order' AND e.source_id = o.id
+  );
+
+INSERT INTO b2b_receivable_entries (store_id, customer_id, source_type, source_id, source_no, biz_date, amount, remark, created_at)
+SELECT o.store_id, o.customer_id, 'supply_order', o.id, o.order_no, o.order_date, o.total_amount, '历史供货单', NOW(3)
+FROM b2b_supply_orders o
+WHERE o.deleted_at IS NULL
+  AND o.total_amount <> 0
+  AND NOT EXISTS (
+    SELECT 1 FROM b2b_receivable_entries e
+    WHERE e.source_type = 'supply_order' AND e.source_id = o.id
+  );
+
+-- B2B 供货退货单（红字冲销）
+CREATE TABLE IF NOT EXISTS `b2b_supply_returns` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `return_no` VARCHAR(50) NOT NULL COMMENT '退货单号',
+  `store_id` BIGINT UNSIGNED NOT NULL COMMENT '门店ID',
+  `order_id` BIGINT UNSIGNED NOT NULL COMMENT '原供货单ID',
+  `order_no` VARCHAR(50) DEFAULT NULL COMMENT '原供货单号',
+  `customer_id` BIGINT UNSIGNED NOT NULL COMMENT '客户ID',
+  `customer_name` VARCHAR(100) DEFAULT NULL COMMENT '客户名称快照',
+  `return_date` DATE NOT NULL COMMENT '退货日期',
+  `total_amount` DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '退货金额',
+  `cost_amount` DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '退回成本',
+  `profit_amount` DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '冲减毛利',
+  `reason` VARCHAR(200) DEFAULT NULL COMMENT '退货原因',
+  `remark` VARCHAR(500) DEFAULT NULL COMMENT '备注',
+  `operator_id` BIGINT UNSIGNED NOT NULL COMMENT '操作人ID',
+  `operator_name` VARCHAR(50) DEFAULT NULL COMMENT '操作人名称',
+  `created_at` DATETIME(3) DEFAULT NULL,
+  `updated_at` DATETIME(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_b2b_supply_returns_return_no` (`return_no`),
+  KEY `idx_b2b_supply_returns_store_id` (`store_id`),
+  KEY `idx_b2b_supply_returns_order_id` (`order_id`),
+  KEY `idx_b2b_supply_returns_customer_id` (`customer_id`),
+  KEY `idx_b2b_supply_returns_return_date` (`return_date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='B2B供货退货单';
+
+CREATE TABLE IF NOT EXISTS `b2b_supply_return_items` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `return_id` BIGINT UNSIGNED NOT NULL COMMENT '退货单ID',
+  `order_item_id` BIGINT UNSIGNED NOT NULL COMMENT '原供货单明细ID',
+  `product_id` BIGINT UNSIGNED NOT NULL COMMENT '商品ID',
+  `product_name` VARCHAR(200) DEFAULT NULL COMMENT '商品名称快照',
+  `unit_spec_id` BIGINT UNSIGNED NOT NULL COMMENT '规格ID',
+  `unit_name` VARCHAR(50) DEFAULT NULL COMMENT '规格名称快照',
+  `factor_to_base` DECIMAL(12,6) NOT NULL DEFAULT 1 COMMENT '换算基础库存系数',
+  `quantity` DECIMAL(10,2) NOT NULL COMMENT '退货数量',
+  `base_quantity` DECIMAL(12,2) NOT NULL COMMENT '回补基础库存数量',
+  `supply_price` DECIMAL(10,2) NOT NULL COMMENT '供货单价',
+  `cost_price` DECIMAL(10,2) NOT NULL DEFAULT 0 COMMENT '成本单价',
+  `amount` DECIMAL(12,2) NOT NULL COMMENT '退货金额',
+  `cost_amount` DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '退回成本',
+  `profit_amount` DECIMAL(12,2) NOT NULL DEFAULT 0 COMMENT '冲减毛利',
+  `created_at` DATETIME(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_b2b_supply_return_items_return_id` (`return_id`),
+  KEY `idx_b2b_supply_return_items_order_item_id` (`order_item_id`),
+  KEY `idx_b2b_supply_return_items_product_id` (`product_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='B2B供货退货明细';
+
+-- 外发通知发件箱（业务写入，后台任务投递，失败指数退避重试，超限转死信）
+CREATE TABLE IF NOT EXISTS `notification_outbox` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `store_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '门店ID',
+  `channel` varchar(20) NOT NULL DEFAULT 'dingtalk' COMMENT '渠道',
+  `bot_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '钉钉机器人ID',
+  `target_type` varchar(20) NOT NULL DEFAULT '' COMMENT '接收目标 webhook/user/group，空=按机器人类型',
+  `target` varchar(100) NOT NULL DEFAULT '' COMMENT '接收人手机号或群会话ID',
+  `biz_type` varchar(30) NOT NULL DEFAULT '' COMMENT '业务来源',
+  `biz_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '业务单据ID',
+  `biz_no` varchar(64) NOT NULL DEFAULT '' COMMENT '业务单号',
+  `msg_type` varchar(20) NOT NULL DEFAULT 'markdown' COMMENT '消息类型 markdown/card',
+  `title` varchar(200) NOT NULL DEFAULT '' COMMENT '标题',
+  `content` text COMMENT 'Markdown 正文',
+  `image_url` varchar(500) NOT NULL DEFAULT '' COMMENT '附带图片',
+  `payload` text COMMENT '卡片等扩展参数(JSON)',
+  `status` tinyint NOT NULL DEFAULT 1 COMMENT '状态 1=待发送 2=发送中 3=成功 4=失败待重试 5=死信',
+  `attempts` int NOT NULL DEFAULT 0 COMMENT '已发送次数',
+  `max_attempts` int NOT NULL DEFAULT 6 COMMENT '最大发送次数',
+  `next_attempt_at` datetime(3) NOT NULL COMMENT '下次发送时间',
+  `sent_at` datetime(3) DEFAULT NULL,
+  `error_message` varchar(500) NOT NULL DEFAULT '' COMMENT '最近一次错误',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_notification_outbox_store_id` (`store_id`),
+  KEY `idx_notification_outbox_biz` (`biz_type`, `biz_id`),
+  KEY `idx_notification_outbox_due` (`status`, `next_attempt_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='外发通知发件箱';
+
+-- 外发通知投递记录（每次发送一条，只增不改）
+CREATE TABLE IF NOT EXISTS `notification_deliveries` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `outbox_id` bigint unsigned NOT NULL COMMENT '发件箱ID',
+  `attempt` int NOT NULL COMMENT '第几次发送',
+  `success` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否成功',
+  `error_message` varchar(500) NOT NULL DEFAULT '' COMMENT '错误信息',
+  `duration_ms` bigint NOT NULL DEFAULT 0 COMMENT '耗时(毫秒)',
+  `operator_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '人工重发操作人ID，0=后台任务',
+  `created_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_notification_deliveries_outbox_id` (`outbox_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='外发通知投递记录';
+
+-- 通知路由规则（事件 + 门店 + 可选渠道/金额门槛 → 机器人与接收目标）
+CREATE TABLE IF NOT EXISTS `notification_routes` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `name` varchar(100) NOT NULL COMMENT '规则名称',
+  `event_type` varchar(30) NOT NULL COMMENT '通知事件',
+  `store_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '门店ID，0=全部门店',
+  `channel` varchar(50) NOT NULL DEFAULT '' COMMENT '渠道条件，空=不限',
+  `min_amount` decimal(12,2) NOT NULL DEFAULT 0.00 COMMENT '金额门槛，0=不限',
+  `bot_id` bigint unsigned NOT NULL COMMENT '钉钉机器人ID',
+  `target_type` varchar(20) NOT NULL COMMENT '接收目标 webhook/user/group',
+  `mobiles` json DEFAULT NULL COMMENT '接收人手机号（user）',
+  `include_store_phone` tinyint(1) NOT NULL DEFAULT 0 COMMENT '同时发给门店负责人手机号（user）',
+  `chat_id` varchar(100) NOT NULL DEFAULT '' COMMENT '群号（group）',
+  `open_conversation_id` varchar(100) NOT NULL DEFAULT '' COMMENT '群会话ID（group）',
+  `is_enabled` tinyint(1) NOT NULL DEFAULT 1 COMMENT '是否启用',
+  `remark` varchar(500) NOT NULL DEFAULT '' COMMENT '备注',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_notification_routes_event` (`event_type`, `store_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='通知路由规则';
+
+-- 消息模板版本快照（每次修改标题、内容或变量结构生成一版，用于回滚）
+CREATE TABLE IF NOT EXISTS `message_template_versions` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `template_id` bigint unsigned NOT NULL COMMENT '模板ID',
+  `version` bigint NOT NULL COMMENT '版本号',
+  `title` varchar(200) DEFAULT NULL COMMENT '消息标题模板',
+  `content` text NOT NULL COMMENT '消息内容模板',
+  `schema` json DEFAULT NULL COMMENT '变量结构定义',
+  `remark` varchar(200) NOT NULL DEFAULT '' COMMENT '版本说明',
+  `operator_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '操作人ID',
+  `created_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_message_template_version` (`template_id`, `version`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息模板版本';
+
+-- 钉钉审批（互动卡片按钮回调后执行或驳回业务）
+CREATE TABLE IF NOT EXISTS `ding_talk_approvals` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `biz_type` varchar(30) NOT NULL COMMENT '审批业务类型',
+  `biz_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '业务单据ID',
+  `biz_no` varchar(64) NOT NULL DEFAULT '' COMMENT '业务单号',
+  `store_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '门店ID',
+  `amount` decimal(12,2) NOT NULL DEFAULT 0.00 COMMENT '涉及金额',
+  `title` varchar(200) NOT NULL DEFAULT '' COMMENT '卡片标题',
+  `content` text COMMENT '卡片正文(Markdown)',
+  `payload` text COMMENT '执行参数(JSON)',
+  `status` tinyint NOT NULL DEFAULT 1 COMMENT '状态 1=待审批 2=已通过 3=已驳回 4=执行失败',
+  `requester_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '发起人ID',
+  `approver_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '审批人ID',
+  `approver_name` varchar(100) NOT NULL DEFAULT '' COMMENT '审批人姓名',
+  `approver_staff_id` varchar(100) NOT NULL DEFAULT '' COMMENT '审批人钉钉用户ID',
+  `decided_at` datetime(3) DEFAULT NULL COMMENT '审批时间',
+  `result_message` varchar(500) NOT NULL DEFAULT '' COMMENT '处理结果',
+  `card_track_ids` json DEFAULT NULL COMMENT '已发送卡片的 outTrackId',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_dingtalk_approvals_biz` (`biz_type`, `biz_id`),
+  KEY `idx_ding_talk_approvals_store_id` (`store_id`),
+  KEY `idx_ding_talk_approvals_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='钉钉审批';
+
+-- 打印任务（记录发送内容与芯烨云订单号，轮询出纸结果，支持补打）
+CREATE TABLE IF NOT EXISTS `print_jobs` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `store_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '门店ID',
+  `printer_id` bigint unsigned NOT NULL COMMENT '实际打印的打印机ID',
+  `printer_sn` varchar(32) NOT NULL DEFAULT '' COMMENT '打印机SN',
+  `printer_name` varchar(100) NOT NULL DEFAULT '' COMMENT '打印机名称',
+  `failover_from` bigint unsigned NOT NULL DEFAULT 0 COMMENT '原打印机离线时切换前的打印机ID',
+  `doc_type` varchar(30) NOT NULL COMMENT '单据类型',
+  `doc_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '单据ID',
+  `doc_no` varchar(64) NOT NULL DEFAULT '' COMMENT '单据编号',
+  `content` text COMMENT '渲染后的打印内容',
+  `copies` bigint NOT NULL DEFAULT 1 COMMENT '打印份数',
+  `xpyun_order_id` varchar(64) NOT NULL DEFAULT '' COMMENT '芯烨云订单ID',
+  `status` tinyint NOT NULL DEFAULT 1 COMMENT '状态 1=待发送 2=已发送 3=已打印 4=失败',
+  `last_error` varchar(500) NOT NULL DEFAULT '' COMMENT '失败原因',
+  `reprint_of` bigint unsigned NOT NULL DEFAULT 0 COMMENT '补打来源任务ID',
+  `operator_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '操作人ID',
+  `sent_at` datetime(3) DEFAULT NULL COMMENT '发送时间',
+  `printed_at` datetime(3) DEFAULT NULL COMMENT '确认出纸时间',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_print_jobs_store_id` (`store_id`),
+  KEY `idx_print_jobs_printer_id` (`printer_id`),
+  KEY `idx_print_jobs_doc` (`doc_type`, `doc_id`),
+  KEY `idx_print_jobs_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='打印任务';
+
+-- 自动打印规则（单据创建后按门店规则自动打印）
+CREATE TABLE IF NOT EXISTS `print_auto_rules` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `store_id` bigint unsigned NOT NULL COMMENT '门店ID',
+  `doc_type` varchar(30) NOT NULL COMMENT '单据类型',
+  `printer_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '打印机ID，0=门店默认打印机',
+  `copies` bigint NOT NULL DEFAULT 1 COMMENT '打印份数',
+  `enabled` tinyint NOT NULL DEFAULT 1 COMMENT '是否启用 1=启用 0=停用',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_print_auto_rule` (`store_id`, `doc_type`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='自动打印规则';
+
+-- 会员积分流水（获得/退回记录同时作为积分批次，按先到期先扣并到期清零）
+CREATE TABLE IF NOT EXISTS `t_member_point_log` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `member_id` bigint unsigned NOT NULL COMMENT '会员ID',
+  `store_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '门店ID',
+  `change_type` bigint NOT NULL COMMENT '变动类型 1=消费获得 2=作废扣回 3=积分抵扣 4=兑换礼品 5=抵扣退回 6=过期清零',
+  `change_points` bigint NOT NULL COMMENT '变动积分，正数增加负数减少',
+  `points_after` bigint NOT NULL DEFAULT 0 COMMENT '变动后积分',
+  `remaining` bigint NOT NULL DEFAULT 0 COMMENT '批次剩余可用积分',
+  `expire_at` datetime(3) DEFAULT NULL COMMENT '批次过期时间，空表示永久有效',
+  `store_account_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '关联记账单ID',
+  `rule_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '积分规则ID',
+  `deduction_amount` decimal(10,2) NOT NULL DEFAULT 0.00 COMMENT '抵扣金额',
+  `related_order_no` varchar(64) DEFAULT NULL COMMENT '关联单号',
+  `remark` varchar(255) DEFAULT NULL COMMENT '备注',
+  `operator_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '操作人ID',
+  `create_time` datetime(3) DEFAULT NULL,
+  `update_time` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_t_member_point_log_member_id` (`member_id`),
+  KEY `idx_t_member_point_log_store_id` (`store_id`),
+  KEY `idx_t_member_point_log_expire_at` (`expire_at`),
+  KEY `idx_t_member_point_log_store_account_id` (`store_account_id`),
+  KEY `idx_t_member_point_log_related_order_no` (`related_order_no`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='会员积分流水';
+
+-- 会员等级定义（门店配置了等级时使用门店等级，否则使用全局等级 store_id=0）
+CREATE TABLE IF NOT EXISTS `member_tiers` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `store_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '门店ID，0表示全局',
+  `level` bigint NOT NULL COMMENT '等级，对应会员 level',
+  `name` varchar(50) NOT NULL COMMENT '等级名称',
+  `min_spend` decimal(12,2) NOT NULL DEFAULT 0.00 COMMENT '近12个月消费门槛',
+  `min_recharge` decimal(12,2) NOT NULL DEFAULT 0.00 COMMENT '累计充值门槛',
+  `min_points` bigint NOT NULL DEFAULT 0 COMMENT '当前积分门槛',
+  `discount_rate` decimal(5,4) NOT NULL DEFAULT 1.0000 COMMENT '记账商品折扣率，1表示不打折',
+  `points_multiplier` decimal(5,2) NOT NULL DEFAULT 1.00 COMMENT '积分倍数',
+  `recharge_bonus_rate` decimal(5,4) NOT NULL DEFAULT 0.0000 COMMENT '充值赠送比例',
+  `status` bigint NOT NULL DEFAULT 1 COMMENT '状态 1=启用 2=停用',
+  `remark` varchar(500) DEFAULT NULL COMMENT '备注',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_member_tier_level` (`store_id`, `level`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='会员等级';
+
+-- 会员等级变动记录
+CREATE TABLE IF NOT EXISTS `member_tier_logs` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `member_id` bigint unsigned NOT NULL COMMENT '会员ID',
+  `store_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '会员所属门店ID',
+  `change_type` varchar(20) NOT NULL COMMENT '变动类型 upgrade=升级 downgrade=降级',
+  `from_level` bigint NOT NULL COMMENT '原等级',
+  `from_name` varchar(50) DEFAULT NULL COMMENT '原等级名称',
+  `to_level` bigint NOT NULL COMMENT '新等级',
+  `to_name` varchar(50) DEFAULT NULL COMMENT '新等级名称',
+  `spend` decimal(12,2) NOT NULL DEFAULT 0.00 COMMENT '评定时近12个月消费',
+  `recharge_total` decimal(12,2) NOT NULL DEFAULT 0.00 COMMENT '评定时累计充值',
+  `points` bigint NOT NULL DEFAULT 0 COMMENT '评定时积分',
+  `created_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_member_tier_logs_member_id` (`member_id`),
+  KEY `idx_member_tier_logs_store_id` (`store_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='会员等级变动记录';
+
+-- 充值套餐（总部配置，充值满额赠送、首充加赠，可限时与限门店）
+CREATE TABLE IF NOT EXISTS `recharge_packages` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `name` varchar(100) NOT NULL COMMENT '套餐名称',
+  `pay_amount` decimal(10,2) NOT NULL COMMENT '充值金额门槛',
+  `gift_amount` decimal(10,2) NOT NULL DEFAULT 0.00 COMMENT '赠送金额',
+  `first_gift_amount` decimal(10,2) NOT NULL DEFAULT 0.00 COMMENT '首充额外赠送金额',
+  `start_at` datetime(3) DEFAULT NULL COMMENT '活动开始时间，空表示不限',
+  `end_at` datetime(3) DEFAULT NULL COMMENT '活动结束时间，空表示不限',
+  `status` bigint NOT NULL DEFAULT 1 COMMENT '状态 1=启用 2=停用',
+  `remark` varchar(500) DEFAULT NULL COMMENT '备注',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='充值套餐';
+
+-- 充值套餐适用门店（无记录表示全部门店可用）
+CREATE TABLE IF NOT EXISTS `recharge_package_stores` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `package_id` bigint unsigned NOT NULL COMMENT '充值套餐ID',
+  `store_id` bigint unsigned NOT NULL COMMENT '门店ID',
+  `created_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_recharge_package_stores_package_id` (`package_id`),
+  KEY `idx_recharge_package_stores_store_id` (`store_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='充值套餐适用门店';
+
+-- 会员存酒期限策略（store_id=0 为全局，门店策略优先）
+CREATE TABLE IF NOT EXISTS `member_wine_policies` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `store_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '门店ID，0表示全局',
+  `storage_days` bigint NOT NULL DEFAULT 180 COMMENT '存酒期限天数，0表示不限期',
+  `remind_days` bigint NOT NULL DEFAULT 7 COMMENT '到期前提醒天数，0表示不提醒',
+  `idle_days` bigint NOT NULL DEFAULT 90 COMMENT '久未存取提醒天数，0表示不提醒',
+  `expire_action` varchar(20) NOT NULL DEFAULT 'extend' COMMENT '到期处理 forfeit=转入门店库存 extend=自动续存',
+  `extend_days` bigint NOT NULL DEFAULT 30 COMMENT '自动续存天数',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_member_wine_policies_store_id` (`store_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='会员存酒期限策略';
+
+-- 出库流水扣减的库存批次明细（撤销、退货回补时按原批次退回）
+CREATE TABLE IF NOT EXISTS `inventory_movement_lots` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `movement_id` bigint unsigned NOT NULL COMMENT '库存流水ID',
+  `store_id` bigint unsigned NOT NULL COMMENT '门店ID',
+  `product_id` bigint unsigned NOT NULL COMMENT '商品ID',
+  `source_type` varchar(30) NOT NULL COMMENT '来源类型',
+  `source_id` bigint unsigned NOT NULL DEFAULT 0 COMMENT '来源单据ID',
+  `lot_id` bigint unsigned NOT NULL COMMENT '库存批次ID',
+  `quantity` decimal(10,2) NOT NULL COMMENT '扣减数量',
+  `restored_quantity` decimal(10,2) NOT NULL DEFAULT 0 COMMENT '已回补数量',
+  `created_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_inventory_movement_lots_movement_id` (`movement_id`),
+  KEY `idx_inventory_movement_lots_source` (`source_type`, `source_id`, `product_id`),
+  KEY `idx_inventory_movement_lots_lot_id` (`lot_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='库存流水批次明细';
@@ -149,6 +149,37 @@ INSERT INTO menus (parent_id, name, title, icon, path, component, type, sort, pe
 SELECT @audit_log_id, 'audit-log-detail', '查看日志详情', '', '', '', 3, 1, 'system:audit-log:detail', 1, 1, NOW(), NOW()
 WHERE NOT EXISTS (SELECT 1 FROM menus WHERE parent_id=@audit_log_id AND name='audit-log-detail' AND type=3);
 
+-- 通知发件箱（系统管理下）
+INSERT INTO menus (parent_id, name, title, icon, path, component, type, sort, permission, visible, status, created_at, updated_at)
+SELECT @system_id, 'notification', '通知发件箱', 'notification', '/system/notification', 'system/notification/index', 2, 8, 'system:notification:list', 1, 1, NOW(), NOW()
+WHERE NOT EXISTS (SELECT 1 FROM menus WHERE parent_id=@system_id AND name='notification' AND type=2);
+SET @notification_id = (SELECT id FROM menus WHERE parent_id=@system_id AND name='notification' AND type=2 ORDER BY id LIMIT 1);
+
+INSERT INTO menus (parent_id, name, title, icon, path, component, type, sort, permission, visible, status, created_at, updated_at)
+SELECT @notification_id, 'notification-resend', '重发通知', '', '', '', 3, 1, 'system:notification:resend', 1, 1, NOW(), NOW()
+WHERE NOT EXISTS (SELECT 1 FROM menus WHERE parent_id=@notification_id AND name='notification-resend' AND type=3);
+
+-- 通知路由（系统管理下）
+INSERT INTO menus (parent_id, name, title, icon, path, component, type, sort, permission, visible, status, created_at, updated_at)
+SELECT @system_id, 'notification-route', '通知路由', 'share', '/system/notification-route', 'system/notification-route/index', 2, 9, 'system:notification-route:list', 1, 1, NOW(), NOW()
+WHERE NOT EXISTS (SELECT 1 FROM menus WHERE parent_id=@system_id AND name='notification-route' AND type=2);
+SET @notification_route_id = (SELECT id FROM menus WHERE parent_id=@system_id AND name='notification-route' AND type=2 ORDER BY id LIMIT 1);
+
+INSERT INTO menus (parent_id, name, title, icon, path, component, type, sort, permission, visible, status, created_at, updated_at)
+SELECT @notification_route_id, 'notification-route-add', '新增规则', '', '', '', 3, 1, 'system:notification-route:add', 1, 1, NOW(), NOW()
+WHERE NOT EXISTS (SELECT 1 FROM menus WHERE parent_id=@notification_route_id AND name='notification-route-add' AND type=3);
+INSERT INTO menus (parent_id, name, title, icon, path, component, type, sort, permission, visible, status, created_at, updated_at)
+SELECT @notification_route_id, 'notification-route-edit', '编辑规则', '', '', '', 3, 2, 'system:notification-route:edit', 1, 1, NOW(), NOW()
+WHERE NOT EXISTS (SELECT 1 FROM menus WHERE parent_id=@notification_route_id AND name='notification-route-edit' AND type=3);
+INSERT INTO menus (parent_id, name, title, icon, path, component, type, sort, permission, visible, status, created_at, updated_at)
+SELECT @notification_route_id, 'notification-route-delete', '删除规则', '', '', '', 3, 3, 'system:notification-route:delete', 1, 1, NOW(), NOW()
+WHERE NOT EXISTS (SELECT 1 FROM menus WHERE parent_id=@notification_route_id AND name='notification-route-delete' AND type=3);
+
+-- 钉钉审批记录（系统管理下）
+INSERT INTO menus (parent_id, name, title, icon, path, component, type, sort, permission, visible, status, created_at, updated_at)
+SELECT @system_id, 'dingtalk-approval', '钉钉审批', 'check-circle', '/system/dingtalk-approval', 'system/dingtalk-approval/index', 2, 10, 'system:dingtalk-approval:list', 1, 1, NOW(), NOW()
+WHERE NOT EXISTS (SELECT 1 FROM menus WHERE parent_id=@system_id AND name='dingtalk-approval' AND type=2);
+
 -- 门店管理（目录）
 INSERT INTO menus (parent_id, name, title, icon, path, component, type, sort, permission, visible, status, created_at, updated_at)
 SELECT 0, 'store', '门店管理', 'shop', '', '', 1, 2, '', 1, 1, NOW(), NOW()
@@ -564,14 +595,14 @@ INSERT INTO message_templates (code, name, title, content, description, variable
 
 发送以下指令可快速使用功能：
 
-- **菜单**：查看可用菜单
-- **记账**：创建记账
-- **库存**：库存相关操作
-- **供应商**：供应商管理
+{{.CommandList}}
+
+---
+入库、确认采购等写操作会先回复预览，回复 **确认** 后才执行
 
 如需更多帮助请联系管理员',
-'钉钉机器人帮助菜单模板',
-'[]',
+'钉钉机器人帮助菜单模板，命令列表按发送者权限生成',
+'["CommandList"]',
 1, NOW(), NOW()),
 ('bot_unknown', '未知命令回复', '🤖 智能助手',
 '## 🤖 智能助手
@@ -593,6 +624,56 @@ ON DUPLICATE KEY UPDATE
   is_enabled=VALUES(is_enabled),
   updated_at=NOW();
 
+-- 带变量结构的模板（由代码拼装迁移而来；已存在时保留用户修改，不覆盖）
+INSERT INTO message_templates (code, name, title, content, description, `schema`, version, is_enabled, created_at, updated_at) VALUES
+('store_account_card', '记账通知卡片', '新记账通知 - {{.StoreName}}',
+'### 新记账通知 - {{.StoreName}}
+
+- 记账编号：{{.AccountNo}}
+- 渠道来源：{{.ChannelName}}
+- 记账日期：{{.AccountDate}}
+- 操作人：{{.OperatorName}}
+
+#### 记账明细
+{{range $i, $item := limit .Items 8}}- {{inc $i}}. {{$item.ProductName}} x{{$item.Quantity}} = {{$item.Amount}}
+{{else}}- 暂无商品明细
+{{end}}{{if gt (len .Items) 8}}- ...等共 {{len .Items}} 项
+{{end}}
+**合计：¥{{.TotalAmount}}**
+
+{{if .OtherExpense}}- 其他支出：¥{{money .OtherExpense}}
+{{end}}- 净收入：¥{{money .NetIncome}}
+{{with .Remark}}
+备注：{{.}}
+{{end}}{{with .ImageURL}}
+![记账回单]({{.}})
+{{end}}
+> 本消息由系统自动发送',
+'记账通知的 ActionCard 正文，未配置钉钉卡片或卡片发送失败时使用',
+'[{"name":"StoreName","type":"string","required":true,"description":"门店名称","example":"旗舰店"},{"name":"AccountNo","type":"string","required":true,"description":"记账编号","example":"JZ20260101001"},{"name":"ChannelName","type":"string","description":"渠道名称","example":"美团"},{"name":"AccountDate","type":"string","description":"记账日期","example":"2026-01-01"},{"name":"OperatorName","type":"string","description":"操作人","example":"张三"},{"name":"Items","type":"list","required":true,"description":"商品明细","fields":[{"name":"ProductName","type":"string","description":"商品名称","example":"青岛啤酒"},{"name":"Quantity","type":"string","description":"数量及单位","example":"2.00箱"},{"name":"Amount","type":"string","description":"金额","example":"¥96.00"}]},{"name":"ItemList","type":"string","description":"商品明细文本（每行一项）","example":"1. 青岛啤酒 x2.00箱 = ¥96.00"},{"name":"TotalAmount","type":"string","description":"合计金额（两位小数）","example":"192.00"},{"name":"ItemCount","type":"number","description":"商品项数","example":2},{"name":"OtherExpense","type":"number","description":"其他支出","example":0},{"name":"NetIncome","type":"number","description":"净收入","example":192},{"name":"Remark","type":"string","description":"备注","example":""},{"name":"ImageURL","type":"string","description":"回单图片地址","example":""},{"name":"CreateTime","type":"string","description":"通知时间","example":"2026-01-01 12:00:00"}]',
+1, 1, NOW(), NOW()),
+('pre_order_reminder', '预订单配送提醒', '预订单提醒｜{{.RelativeDate}}配送',
+'### 预订单{{.RelativeDate}}提醒
+
+- **门店：** {{.StoreName}}
+- **客户：** {{.CustomerName}}
+- **配送时间：** {{.ScheduledAt}}
+{{with .Contact}}- **联系人：** {{.}}
+{{end}}{{with .DeliveryAddress}}- **配送地址：** {{.}}
+{{end}}
+**备货明细**
+
+{{range .Items}}- {{.ProductName}} / {{.UnitName}} × {{.Quantity}}{{with .Remark}}（{{.}}）{{end}}
+{{end}}{{with .Remark}}
+- **备注：** {{.}}
+{{end}}
+预订单号：{{.OrderNo}}',
+'预订单配送前的定时提醒',
+'[{"name":"RelativeDate","type":"string","required":true,"description":"相对日期：今天/明天","example":"明天"},{"name":"StoreName","type":"string","required":true,"description":"门店名称","example":"旗舰店"},{"name":"CustomerName","type":"string","required":true,"description":"客户名称","example":"某某酒楼"},{"name":"ScheduledAt","type":"string","required":true,"description":"配送时间","example":"2026-01-02 10:00"},{"name":"Contact","type":"string","description":"联系人及电话","example":"李四 13800000000"},{"name":"DeliveryAddress","type":"string","description":"配送地址","example":"人民路 1 号"},{"name":"Items","type":"list","required":true,"description":"备货明细","fields":[{"name":"ProductName","type":"string","description":"商品名称","example":"青岛啤酒"},{"name":"UnitName","type":"string","description":"单位","example":"箱"},{"name":"Quantity","type":"number","description":"数量","example":5},{"name":"Remark","type":"string","description":"明细备注","example":""}]},{"name":"Remark","type":"string","description":"备注","example":""},{"name":"OrderNo","type":"string","required":true,"description":"预订单号","example":"YD20260101001"}]',
+1, 1, NOW(), NOW())
+ON DUPLICATE KEY UPDATE
+  updated_at=updated_at;
+
 -- 角色菜单权限（role_menus 一般会有联合唯一键；即使没有，重复执行也不会影响数据正确性）
 -- 总部管理员(ID:1): 所有权限
 INSERT INTO role_menus (role_id, menu_id, permissions)
@@ -17,6 +17,14 @@ const (
 	B2BDeliveryPending = 1
 	B2BDeliveryDone    = 2
 	B2BDeliveryCancel  = 3
+
+	B2BSettlementCash  = "cash"
+	B2BSettlementWeek  = "week"
+	B2BSettlementMonth = "month"
+
+	// 超出信用额度时：hard 直接拒绝，soft 需确认后放行
+	B2BCreditControlHard = "hard"
+	B2BCreditControlSoft = "soft"
 )
 
 type B2BCustomer struct {
@@ -29,7 +37,8 @@ type B2BCustomer struct {
 	Address       string         `json:"address" gorm:"type:varchar(255);comment:地址"`
 	Settlement    string         `json:"settlement" gorm:"type:varchar(30);default:'cash';comment:结算方式 cash/week/month"`
 	PriceLevel    string         `json:"price_level" gorm:"type:varchar(30);comment:价格等级"`
-	CreditLimit   float64        `json:"credit_limit" gorm:"type:decimal(12,2);default:0;comment:信用额度"`
+	CreditLimit   float64        `json:"credit_limit" gorm:"type:decimal(12,2);default:0;comment:信用额度，0=不限"`
+	CreditControl string         `json:"credit_control" gorm:"type:varchar(10);not null;default:'hard';comment:超额控制 hard=拒绝 soft=确认后放行"`
 	Receivable    float64        `json:"receivable" gorm:"type:decimal(12,2);default:0;comment:当前应收余额"`
 	Status        int            `json:"status" gorm:"not null;default:1;index;comment:状态 1=启用 2=停用"`
 	Remark        string         `json:"remark" gorm:"type:text;comment:备注"`
@@ -77,9 +86,11 @@ type B2BSupplyOrder struct {
 	TotalAmount    float64        `json:"total_amount" gorm:"type:decimal(12,2);default:0;comment:订单金额"`
 	PaidAmount     float64        `json:"paid_amount" gorm:"type:decimal(12,2);default:0;comment:已收金额"`
 	UnpaidAmount   float64        `json:"unpaid_amount" gorm:"type:decimal(12,2);default:0;comment:未收金额"`
+	ReturnedAmount float64        `json:"returned_amount" gorm:"type:decimal(12,2);not null;default:0;comment:已退货金额"`
 	CostAmount     float64        `json:"cost_amount" gorm:"type:decimal(12,2);default:0;comment:成本金额"`
-	ProfitAmount   float64        `json:"profit_amount" gorm:"type:decimal(12,2);default:0;comment:毛利金额"`
+	ProfitAmount   float64        `json:"profit_amount" gorm:"type:decimal(12,2);default:0;comment:毛利金额（已扣除退货）"`
 	PaymentStatus  int            `json:"payment_status" gorm:"not null;default:1;index;comment:收款状态 1=未收 2=部分 3=已收"`
+	CreditOverride bool           `json:"credit_override" gorm:"not null;default:false;comment:是否确认超信用额度下单"`
 	DeliveryStatus int            `json:"delivery_status" gorm:"not null;default:1;index;comment:配送状态 1=待配送 2=已配送 3=已取消"`
 	Remark         string         `json:"remark" gorm:"type:text;comment:备注"`
 	OperatorID     uint           `json:"operator_id" gorm:"not null;comment:操作人ID"`
@@ -96,24 +107,30 @@ func (B2BSupplyOrder) TableName() string {
 	return "b2b_supply_orders"
 }
 
+// NetAmount 扣除退货后的应收金额
+func (o *B2BSupplyOrder) NetAmount() float64 {
+	return o.TotalAmount - o.ReturnedAmount
+}
+
 type B2BSupplyOrderItem struct {
-	ID           uint           `json:"id" gorm:"primaryKey;autoIncrement"`
-	OrderID      uint           `json:"order_id" gorm:"not null;index;comment:供货单ID"`
-	ProductID    uint           `json:"product_id" gorm:"not null;index;comment:商品ID"`
-	ProductName  string         `json:"product_name" gorm:"type:varchar(200);comment:商品名称快照"`
-	UnitSpecID   uint           `json:"unit_spec_id" gorm:"not null;index;comment:规格ID"`
-	UnitName     string         `json:"unit_name" gorm:"type:varchar(50);comment:规格名称快照"`
-	FactorToBase float64        `json:"factor_to_base" gorm:"type:decimal(12,6);not null;default:1;comment:换算基础库存系数"`
-	Quantity     float64        `json:"quantity" gorm:"type:decimal(10,2);not null;comment:下单数量"`
-	BaseQuantity float64        `json:"base_quantity" gorm:"type:decimal(12,2);not null;comment:扣减基础库存数量"`
-	SupplyPrice  float64        `json:"supply_price" gorm:"type:decimal(10,2);not null;comment:供货单价"`
-	CostPrice    float64        `json:"cost_price" gorm:"type:decimal(10,2);not null;default:0;comment:成本单价"`
-	Amount       float64        `json:"amount" gorm:"type:decimal(12,2);not null;comment:行金额"`
-	CostAmount   float64        `json:"cost_amount" gorm:"type:decimal(12,2);not null;default:0;comment:行成本"`
-	ProfitAmount float64        `json:"profit_amount" gorm:"type:decimal(12,2);not null;default:0;comment:行毛利"`
-	Remark       string         `json:"remark" gorm:"type:varchar(500);comment:备注"`
-	CreatedAt    time.Time      `json:"created_at"`
-	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+	ID               uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	OrderID          uint           `json:"order_id" gorm:"not null;index;comment:供货单ID"`
+	ProductID        uint           `json:"product_id" gorm:"not null;index;comment:商品ID"`
+	ProductName      string         `json:"product_name" gorm:"type:varchar(200);comment:商品名称快照"`
+	UnitSpecID       uint           `json:"unit_spec_id" gorm:"not null;index;comment:规格ID"`
+	UnitName         string         `json:"unit_name" gorm:"type:varchar(50);comment:规格名称快照"`
+	FactorToBase     float64        `json:"factor_to_base" gorm:"type:decimal(12,6);not null;default:1;comment:换算基础库存系数"`
+	Quantity         float64        `json:"quantity" gorm:"type:decimal(10,2);not null;comment:下单数量"`
+	ReturnedQuantity float64        `json:"returned_quantity" gorm:"type:decimal(10,2);not null;default:0;comment:已退货数量"`
+	BaseQuantity     float64        `json:"base_quantity" gorm:"type:decimal(12,2);not null;comment:扣减基础库存数量"`
+	SupplyPrice      float64        `json:"supply_price" gorm:"type:decimal(10,2);not null;comment:供货单价"`
+	CostPrice        float64        `json:"cost_price" gorm:"type:decimal(10,2);not null;default:0;comment:成本单价"`
+	Amount           float64        `json:"amount" gorm:"type:decimal(12,2);not null;comment:行金额"`
+	CostAmount       float64        `json:"cost_amount" gorm:"type:decimal(12,2);not null;default:0;comment:行成本"`
+	ProfitAmount     float64        `json:"profit_amount" gorm:"type:decimal(12,2);not null;default:0;comment:行毛利"`
+	Remark           string         `json:"remark" gorm:"type:varchar(500);comment:备注"`
+	CreatedAt        time.Time      `json:"created_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 func (B2BSupplyOrderItem) TableName() string {
@@ -130,6 +147,7 @@ type CreateB2BCustomerReq struct {
 	Settlement    string  `json:"settlement" binding:"max=30"`
 	PriceLevel    string  `json:"price_level" binding:"max=30"`
 	CreditLimit   float64 `json:"credit_limit" binding:"gte=0"`
+	CreditControl string  `json:"credit_control" binding:"omitempty,oneof=hard soft"`
 	Remark        string  `json:"remark" binding:"max=500"`
 }
 
@@ -142,6 +160,7 @@ type UpdateB2BCustomerReq struct {
 	Settlement    *string  `json:"settlement,omitempty" binding:"omitempty,max=30"`
 	PriceLevel    *string  `json:"price_level,omitempty" binding:"omitempty,max=30"`
 	CreditLimit   *float64 `json:"credit_limit,omitempty" binding:"omitempty,gte=0"`
+	CreditControl *string  `json:"credit_control,omitempty" binding:"omitempty,oneof=hard soft"`
 	Status        *int     `json:"status,omitempty" binding:"omitempty,oneof=1 2"`
 	Remark        *string  `json:"remark,omitempty" binding:"omitempty,max=500"`
 }
@@ -183,9 +202,12 @@ type CreateB2BSupplyOrderReq struct {
 	CustomerID     uint                       `json:"customer_id" binding:"required"`
 	OrderDate      string                     `json:"order_date"`
 	PaidAmount     float64                    `json:"paid_amount" binding:"gte=0"`
+	PayMethod      string                     `json:"pay_method" binding:"omitempty,oneof=cash bank wechat alipay other"` // 下单时收款的方式，默认现金
 	DeliveryStatus int                        `json:"delivery_status" binding:"omitempty,oneof=1 2"`
 	Remark         string                     `json:"remark" binding:"max=500"`
 	Items          []CreateB2BSupplyOrderItem `json:"items" binding:"required,min=1,dive"`
+	// 软控客户超出信用额度时，确认后带 true 重新提交
+	ConfirmOverCredit bool `json:"confirm_over_credit"`
 }
 
 type CreateB2BSupplyOrderItem struct {
@@ -203,6 +225,7 @@ type UpdateB2BSupplyOrderDeliveryReq struct {
 type UpdateB2BSupplyOrderPaymentReq struct {
 	PaymentStatus int     `json:"payment_status" binding:"required,oneof=1 2 3"`
 	PaidAmount    float64 `json:"paid_amount" binding:"gte=0"`
+	PayMethod     string  `json:"pay_method" binding:"omitempty,oneof=cash bank wechat alipay other"` // 增加已收金额时生成收款记录的方式
 }
 
 type ListB2BSupplyOrderReq struct {
@@ -0,0 +1,181 @@
+package model
+
+import "time"
+
+// B2B 应收台账来源类型
+const (
+	B2BReceivableSourceSupplyOrder = "supply_order" // 供货单，增加应收
+	B2BReceivableSourcePayment     = "payment"      // 收款，冲减应收
+	B2BReceivableSourcePaymentVoid = "payment_void" // 收款作废，恢复应收
+	B2BReceivableSourceAdjustment  = "adjustment"   // 手工调低已收金额，恢复应收
+	B2BReceivableSourceCreditNote  = "credit_note"  // 供货退货，冲减应收
+)
+
+// B2B 收款记录状态
+const (
+	B2BPaymentRecordValid  int8 = 1 // 有效
+	B2BPaymentRecordVoided int8 = 2 // 已作废
+)
+
+// B2BReceivableEntry B2B 客户应收台账，只追加不修改；Amount 为正增加应收，为负冲减应收
+type B2BReceivableEntry struct {
+	ID         uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	StoreID    uint      `json:"store_id" gorm:"not null;index;comment:门店ID"`
+	CustomerID uint      `json:"customer_id" gorm:"not null;index:idx_b2b_receivable_entries_ledger,priority:1;comment:客户ID"`
+	SourceType string    `json:"source_type" gorm:"type:varchar(30);not null;index:idx_b2b_receivable_entries_source,priority:1;comment:来源类型"`
+	SourceID   uint      `json:"source_id" gorm:"not null;default:0;index:idx_b2b_receivable_entries_source,priority:2;comment:来源单据ID"`
+	SourceNo   string    `json:"source_no" gorm:"type:varchar(50);comment:来源单号"`
+	BizDate    time.Time `json:"biz_date" gorm:"type:date;not null;index:idx_b2b_receivable_entries_ledger,priority:2;comment:业务日期"`
+	Amount     float64   `json:"amount" gorm:"type:decimal(12,2);not null;comment:金额（正数增加应收，负数冲减）"`
+	Remark     string    `json:"remark" gorm:"type:varchar(500);comment:备注"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (B2BReceivableEntry) TableName() string {
+	return "b2b_receivable_entries"
+}
+
+// B2BPayment B2B 客户收款记录，一笔收款可核销多张供货单
+type B2BPayment struct {
+	ID           uint                   `json:"id" gorm:"primaryKey;autoIncrement"`
+	PaymentNo    string                 `json:"payment_no" gorm:"type:varchar(50);uniqueIndex;not null;comment:收款单号"`
+	StoreID      uint                   `json:"store_id" gorm:"not null;index;comment:门店ID"`
+	CustomerID   uint                   `json:"customer_id" gorm:"not null;index;comment:客户ID"`
+	CustomerName string                 `json:"customer_name" gorm:"type:varchar(100);comment:客户名称快照"`
+	Amount       float64                `json:"amount" gorm:"type:decimal(12,2);not null;comment:收款金额"`
+	PayDate      time.Time              `json:"pay_date" gorm:"type:date;not null;index;comment:收款日期"`
+	Method       string                 `json:"method" gorm:"type:varchar(20);comment:收款方式 cash/bank/wechat/alipay/other"`
+	Reference    string                 `json:"reference" gorm:"type:varchar(100);comment:流水号/凭证号"`
+	Remark       string                 `json:"remark" gorm:"type:varchar(500);comment:备注"`
+	Status       int8                   `json:"status" gorm:"not null;default:1;comment:状态 1=有效 2=已作废"`
+	VoidReason   string                 `json:"void_reason" gorm:"type:varchar(200);comment:作废原因"`
+	VoidedAt     *time.Time             `json:"voided_at"`
+	OperatorID   uint                   `json:"operator_id" gorm:"not null;comment:操作人ID"`
+	OperatorName string                 `json:"operator_name" gorm:"type:varchar(50);comment:操作人名称"`
+	CreatedAt    time.Time              `json:"created_at"`
+	UpdatedAt    time.Time              `json:"updated_at"`
+	Allocations  []B2BPaymentAllocation `json:"allocations,omitempty" gorm:"foreignKey:PaymentID"`
+}
+
+func (B2BPayment) TableName() string {
+	return "b2b_payments"
+}
+
+// B2BPaymentAllocation 收款核销到供货单的金额
+type B2BPaymentAllocation struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	PaymentID uint      `json:"payment_id" gorm:"not null;index;comment:收款记录ID"`
+	OrderID   uint      `json:"order_id" gorm:"not null;index;comment:供货单ID"`
+	OrderNo   string    `json:"order_no" gorm:"type:varchar(50);comment:供货单号"`
+	Amount    float64   `json:"amount" gorm:"type:decimal(12,2);not null;comment:核销金额"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (B2BPaymentAllocation) TableName() string {
+	return "b2b_payment_allocations"
+}
+
+// CreateB2BPaymentReq 登记客户收款；不指定核销明细时按供货日期从早到晚自动核销未收供货单
+type CreateB2BPaymentReq struct {
+	CustomerID  uint                      `json:"customer_id" binding:"required"`
+	Amount      float64                   `json:"amount" binding:"required,gt=0"`
+	PayDate     string                    `json:"pay_date"` // 不传取当天
+	Method      string                    `json:"method" binding:"omitempty,oneof=cash bank wechat alipay other"`
+	Reference   string                    `json:"reference" binding:"max=100"`
+	Remark      string                    `json:"remark" binding:"max=500"`
+	Allocations []B2BPaymentAllocationReq `json:"allocations" binding:"omitempty,dive"`
+}
+
+type B2BPaymentAllocationReq struct {
+	OrderID uint    `json:"order_id" binding:"required"`
+	Amount  float64 `json:"amount" binding:"required,gt=0"`
+}
+
+type VoidB2BPaymentReq struct {
+	Reason string `json:"reason" binding:"required,max=200"`
+}
+
+type ListB2BPaymentReq struct {
+	StoreID    uint   `form:"store_id"`
+	CustomerID uint   `form:"customer_id"`
+	Status     int8   `form:"status"`
+	StartDate  string `form:"start_date"`
+	EndDate    string `form:"end_date"`
+	Page       int    `form:"page,default=1" binding:"min=1"`
+	PageSize   int    `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// B2BStatementReq 客户对账单：Date 所在结算周期（周结为周一至周日，月结为自然月），不传取上一个完整周期。
+// 现结客户须通过 Period 指定 week 或 month。
+type B2BStatementReq struct {
+	Date   string `form:"date"`
+	Period string `form:"period" binding:"omitempty,oneof=week month"`
+}
+
+// ListB2BStatementReq 按结算方式批量生成对账单汇总
+type ListB2BStatementReq struct {
+	StoreID    uint   `form:"store_id"`
+	Settlement string `form:"settlement" binding:"required,oneof=week month"`
+	Date       string `form:"date"`
+}
+
+// B2BStatement 客户对账单
+type B2BStatement struct {
+	CustomerID       uint                  `json:"customer_id"`
+	CustomerName     string                `json:"customer_name"`
+	Settlement       string                `json:"settlement"`
+	CreditLimit      float64               `json:"credit_limit"`
+	PeriodStart      string                `json:"period_start"`
+	PeriodEnd        string                `json:"period_end"`
+	OpeningBalance   float64               `json:"opening_balance"`
+	SupplyAmount     float64               `json:"supply_amount"`     // 本期供货
+	ReturnAmount     float64               `json:"return_amount"`     // 本期退货冲减（正数）
+	PaymentAmount    float64               `json:"payment_amount"`    // 本期净收款（正数）
+	AdjustmentAmount float64               `json:"adjustment_amount"` // 本期手工调整
+	ClosingBalance   float64               `json:"closing_balance"`
+	Entries          []*B2BReceivableEntry `json:"entries,omitempty"`
+}
+
+// B2BReceivableTotal 客户维度的应收发生额汇总
+type B2BReceivableTotal struct {
+	CustomerID uint    `json:"customer_id"`
+	SourceType string  `json:"source_type"`
+	Amount     float64 `json:"amount"`
+}
+
+// B2BAgingReq 应收账龄查询，AsOf 不传取当天
+type B2BAgingReq struct {
+	StoreID    uint   `form:"store_id"`
+	CustomerID uint   `form:"customer_id"`
+	AsOf       string `form:"as_of"`
+}
+
+// B2BReceivableDailyNet 客户按业务日期汇总的应收净额
+type B2BReceivableDailyNet struct {
+	CustomerID uint      `json:"customer_id"`
+	BizDate    time.Time `json:"biz_date"`
+	Amount     float64   `json:"amount"`
+}
+
+// B2BCustomerAging 单个客户的应收余额与账龄分布（先发生的应收先被收款冲减）
+type B2BCustomerAging struct {
+	CustomerID      uint    `json:"customer_id"`
+	CustomerName    string  `json:"customer_name"`
+	Settlement      string  `json:"settlement"`
+	CreditLimit     float64 `json:"credit_limit"`
+	Balance         float64 `json:"balance"`
+	Days0To30       float64 `json:"days_0_30"`
+	Days31To60      float64 `json:"days_31_60"`
+	DaysOver60      float64 `json:"days_over_60"`
+	UnappliedCredit float64 `json:"unapplied_credit"`
+}
+
+// B2BAgingReport 应收账龄汇总
+type B2BAgingReport struct {
+	AsOf       string             `json:"as_of"`
+	Balance    float64            `json:"balance"`
+	Days0To30  float64            `json:"days_0_30"`
+	Days31To60 float64            `json:"days_31_60"`
+	DaysOver60 float64            `json:"days_over_60"`
+	Customers  []B2BCustomerAging `json:"customers"`
+}
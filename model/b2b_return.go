@@ -0,0 +1,78 @@
+package model
+
+import "time"
+
+// B2BSupplyReturn B2B 供货退货单（红字冲销）：退回商品按规格换算回补基础库存，
+// 冲减原供货单的金额与毛利、客户应收，并生成一张负数记账单。
+type B2BSupplyReturn struct {
+	ID           uint                  `json:"id" gorm:"primaryKey;autoIncrement"`
+	ReturnNo     string                `json:"return_no" gorm:"type:varchar(50);uniqueIndex;not null;comment:退货单号"`
+	StoreID      uint                  `json:"store_id" gorm:"not null;index;comment:门店ID"`
+	OrderID      uint                  `json:"order_id" gorm:"not null;index;comment:原供货单ID"`
+	OrderNo      string                `json:"order_no" gorm:"type:varchar(50);comment:原供货单号"`
+	CustomerID   uint                  `json:"customer_id" gorm:"not null;index;comment:客户ID"`
+	CustomerName string                `json:"customer_name" gorm:"type:varchar(100);comment:客户名称快照"`
+	ReturnDate   time.Time             `json:"return_date" gorm:"type:date;not null;index;comment:退货日期"`
+	TotalAmount  float64               `json:"total_amount" gorm:"type:decimal(12,2);not null;default:0;comment:退货金额"`
+	CostAmount   float64               `json:"cost_amount" gorm:"type:decimal(12,2);not null;default:0;comment:退回成本"`
+	ProfitAmount float64               `json:"profit_amount" gorm:"type:decimal(12,2);not null;default:0;comment:冲减毛利"`
+	Reason       string                `json:"reason" gorm:"type:varchar(200);comment:退货原因"`
+	Remark       string                `json:"remark" gorm:"type:varchar(500);comment:备注"`
+	OperatorID   uint                  `json:"operator_id" gorm:"not null;comment:操作人ID"`
+	OperatorName string                `json:"operator_name" gorm:"type:varchar(50);comment:操作人名称"`
+	CreatedAt    time.Time             `json:"created_at"`
+	UpdatedAt    time.Time             `json:"updated_at"`
+	Items        []B2BSupplyReturnItem `json:"items,omitempty" gorm:"foreignKey:ReturnID"`
+}
+
+func (B2BSupplyReturn) TableName() string {
+	return "b2b_supply_returns"
+}
+
+// B2BSupplyReturnItem 退货明细，单价与成本沿用原供货单行
+type B2BSupplyReturnItem struct {
+	ID           uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	ReturnID     uint      `json:"return_id" gorm:"not null;index;comment:退货单ID"`
+	OrderItemID  uint      `json:"order_item_id" gorm:"not null;index;comment:原供货单明细ID"`
+	ProductID    uint      `json:"product_id" gorm:"not null;index;comment:商品ID"`
+	ProductName  string    `json:"product_name" gorm:"type:varchar(200);comment:商品名称快照"`
+	UnitSpecID   uint      `json:"unit_spec_id" gorm:"not null;comment:规格ID"`
+	UnitName     string    `json:"unit_name" gorm:"type:varchar(50);comment:规格名称快照"`
+	FactorToBase float64   `json:"factor_to_base" gorm:"type:decimal(12,6);not null;default:1;comment:换算基础库存系数"`
+	Quantity     float64   `json:"quantity" gorm:"type:decimal(10,2);not null;comment:退货数量"`
+	BaseQuantity float64   `json:"base_quantity" gorm:"type:decimal(12,2);not null;comment:回补基础库存数量"`
+	SupplyPrice  float64   `json:"supply_price" gorm:"type:decimal(10,2);not null;comment:供货单价"`
+	CostPrice    float64   `json:"cost_price" gorm:"type:decimal(10,2);not null;default:0;comment:成本单价"`
+	Amount       float64   `json:"amount" gorm:"type:decimal(12,2);not null;comment:退货金额"`
+	CostAmount   float64   `json:"cost_amount" gorm:"type:decimal(12,2);not null;default:0;comment:退回成本"`
+	ProfitAmount float64   `json:"profit_amount" gorm:"type:decimal(12,2);not null;default:0;comment:冲减毛利"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (B2BSupplyReturnItem) TableName() string {
+	return "b2b_supply_return_items"
+}
+
+// CreateB2BSupplyReturnReq 对已配送供货单登记退货
+type CreateB2BSupplyReturnReq struct {
+	ReturnDate string                      `json:"return_date"` // 不传取当天
+	Reason     string                      `json:"reason" binding:"required,max=200"`
+	Remark     string                      `json:"remark" binding:"max=500"`
+	Items      []CreateB2BSupplyReturnItem `json:"items" binding:"required,min=1,dive"`
+}
+
+type CreateB2BSupplyReturnItem struct {
+	OrderItemID uint    `json:"order_item_id" binding:"required"`
+	Quantity    float64 `json:"quantity" binding:"required,gt=0"` // 按下单规格计的退货数量
+}
+
+type ListB2BSupplyReturnReq struct {
+	StoreID    uint   `form:"store_id"`
+	CustomerID uint   `form:"customer_id"`
+	OrderID    uint   `form:"order_id"`
+	Keyword    string `form:"keyword"`
+	StartDate  string `form:"start_date"`
+	EndDate    string `form:"end_date"`
+	Page       int    `form:"page,default=1" binding:"min=1"`
+	PageSize   int    `form:"page_size,default=20" binding:"min=1,max=100"`
+}
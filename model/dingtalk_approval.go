@@ -0,0 +1,87 @@
+package model
+
+import "time"
+
+// 钉钉审批业务类型，同时作为通知路由的事件类型：命中路由规则才需要审批，规则的金额门槛即"大额"标准
+const (
+	ApprovalBizPurchaseOrder      = "approval_purchase_order"       // 待确认采购单
+	ApprovalBizStoreAccountCancel = "approval_store_account_cancel" // 记账单作废
+	ApprovalBizMemberBalance      = "approval_member_balance"       // 会员余额调整
+)
+
+// 审批状态
+const (
+	ApprovalPending  int8 = 1 // 待审批
+	ApprovalApproved int8 = 2 // 已通过（业务已执行）
+	ApprovalRejected int8 = 3 // 已驳回
+	ApprovalFailed   int8 = 4 // 已通过但执行失败
+)
+
+// 卡片按钮回调的动作
+const (
+	ApprovalActionApprove = "approve"
+	ApprovalActionReject  = "reject"
+)
+
+// DingTalkApproval 钉钉审批单：通过互动卡片发给审批人，按钮回调后执行或驳回对应业务
+type DingTalkApproval struct {
+	ID              uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	BizType         string     `json:"biz_type" gorm:"type:varchar(30);not null;index:idx_dingtalk_approvals_biz,priority:1;comment:审批业务类型"`
+	BizID           uint       `json:"biz_id" gorm:"not null;default:0;index:idx_dingtalk_approvals_biz,priority:2;comment:业务单据ID"`
+	BizNo           string     `json:"biz_no" gorm:"type:varchar(64);not null;default:'';comment:业务单号"`
+	StoreID         uint       `json:"store_id" gorm:"not null;default:0;index;comment:门店ID"`
+	Amount          float64    `json:"amount" gorm:"type:decimal(12,2);not null;default:0;comment:涉及金额"`
+	Title           string     `json:"title" gorm:"type:varchar(200);not null;default:'';comment:卡片标题"`
+	Content         string     `json:"content" gorm:"type:text;comment:卡片正文(Markdown)"`
+	Payload         string     `json:"payload" gorm:"type:text;comment:执行参数(JSON)"`
+	Status          int8       `json:"status" gorm:"not null;default:1;index;comment:状态 1=待审批 2=已通过 3=已驳回 4=执行失败"`
+	RequesterID     uint       `json:"requester_id" gorm:"not null;default:0;comment:发起人ID"`
+	ApproverID      uint       `json:"approver_id" gorm:"not null;default:0;comment:审批人ID"`
+	ApproverName    string     `json:"approver_name" gorm:"type:varchar(100);not null;default:'';comment:审批人姓名"`
+	ApproverStaffID string     `json:"approver_staff_id" gorm:"type:varchar(100);not null;default:'';comment:审批人钉钉用户ID"`
+	DecidedAt       *time.Time `json:"decided_at,omitempty" gorm:"comment:审批时间"`
+	ResultMessage   string     `json:"result_message" gorm:"type:varchar(500);not null;default:'';comment:处理结果"`
+	CardTrackIDs    StringList `json:"card_track_ids" gorm:"type:json;comment:已发送卡片的 outTrackId"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func (DingTalkApproval) TableName() string {
+	return "ding_talk_approvals"
+}
+
+// ApprovalStatusText 审批状态文案，用于卡片展示
+func ApprovalStatusText(status int8) string {
+	switch status {
+	case ApprovalPending:
+		return "待审批"
+	case ApprovalApproved:
+		return "已通过"
+	case ApprovalRejected:
+		return "已驳回"
+	case ApprovalFailed:
+		return "执行失败"
+	}
+	return "未知"
+}
+
+// ApprovalStoreAccountCancelPayload 记账作废审批的执行参数
+type ApprovalStoreAccountCancelPayload struct {
+	Remark string `json:"remark"`
+}
+
+// ApprovalMemberBalancePayload 余额调整审批的执行参数
+type ApprovalMemberBalancePayload struct {
+	Amount     DecimalType    `json:"amount"`
+	ChangeType ChangeTypeEnum `json:"change_type"`
+	Remark     string         `json:"remark"`
+}
+
+type ListDingTalkApprovalReq struct {
+	StoreID  uint   `form:"store_id"`
+	BizType  string `form:"biz_type"`
+	Status   int8   `form:"status"`
+	Keyword  string `form:"keyword"` // 业务单号/标题
+	Page     int    `form:"page,default=1" binding:"min=1"`
+	PageSize int    `form:"page_size,default=20" binding:"min=1,max=100"`
+}
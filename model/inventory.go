@@ -30,7 +30,7 @@ func (Inventory) TableName() string {
 type InventoryOrder struct {
 	ID            uint                 `json:"id" gorm:"primaryKey;autoIncrement"`
 	OrderNo       string               `json:"order_no" gorm:"type:varchar(50);uniqueIndex;not null;comment:单据编号"`
-	Type          int8                 `json:"type" gorm:"not null;comment:类型 1=入库 2=出库"`
+	Type          int8                 `json:"type" gorm:"not null;comment:类型 1=入库 2=出库 3=盘点调整"`
 	StoreID       uint                 `json:"store_id" gorm:"not null;index;comment:门店ID"`
 	StoreName     string               `json:"store_name" gorm:"type:varchar(100);comment:门店名称"`
 	Reason        string               `json:"reason" gorm:"type:varchar(100);comment:原因"`
@@ -71,8 +71,9 @@ func (InventoryOrderItem) TableName() string {
 
 // 出入库类型常量
 const (
-	InventoryTypeIn  int8 = 1 // 入库
-	InventoryTypeOut int8 = 2 // 出库
+	InventoryTypeIn     int8 = 1 // 入库
+	InventoryTypeOut    int8 = 2 // 出库
+	InventoryTypeAdjust int8 = 3 // 盘点调整（明细数量带符号：正数盘盈入库，负数盘亏出库）
 )
 
 // 出入库原因
@@ -84,6 +85,7 @@ const (
 	ReasonSale        = "销售出库"
 	ReasonTransferIn  = "调拨入库"
 	ReasonTransferOut = "调拨出库"
+	ReasonWineForfeit = "存酒到期入库"
 )
 
 // CreateInventoryOrderReq 创建出入库单请求
@@ -109,6 +111,7 @@ type ListInventoryReq struct {
 	StoreID   uint   `form:"store_id"`
 	ProductID uint   `form:"product_id"`
 	Keyword   string `form:"keyword"`
+	WithLots  bool   `form:"with_lots"` // 是否返回批次明细
 	Page      int    `form:"page,default=1" binding:"min=1"`
 	PageSize  int    `form:"page_size,default=20" binding:"min=1,max=100"`
 
@@ -141,6 +144,10 @@ type InventoryWithProduct struct {
 	Price       float64 `json:"price"`
 	Quantity    float64 `json:"quantity"`
 	Unit        string  `json:"unit"`
+
+	// 批次明细（with_lots=true 时返回）
+	UntrackedQuantity float64             `json:"untracked_quantity,omitempty" gorm:"-"`
+	Lots              []*InventoryLotView `json:"lots,omitempty" gorm:"-"`
 }
 
 // UpdateInventoryReq 修改库存数量请求
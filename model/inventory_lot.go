@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// InventoryLot 库存批次：按门店+商品+到期日聚合，出库时按到期日先到先出（FEFO）扣减。
+// 无到期日的入库（调整等）归入 LotKey 为空的批次，排在所有有到期日批次之后扣减；撤销、退货回补按 InventoryMovementLot 退回原批次。
+type InventoryLot struct {
+	ID             uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	StoreID        uint       `json:"store_id" gorm:"not null;uniqueIndex:uk_inventory_lots_key,priority:1;comment:门店ID"`
+	ProductID      uint       `json:"product_id" gorm:"not null;uniqueIndex:uk_inventory_lots_key,priority:2;index;comment:商品ID"`
+	LotKey         string     `json:"lot_key" gorm:"type:varchar(20);not null;default:'';uniqueIndex:uk_inventory_lots_key,priority:3;comment:批次键（到期日 yyyy-mm-dd，无到期日为空）"`
+	ProductionDate *time.Time `json:"production_date" gorm:"type:date;comment:生产日期（首次入库）"`
+	ExpiryDate     *time.Time `json:"expiry_date" gorm:"type:date;index;comment:到期日"`
+	Quantity       float64    `json:"quantity" gorm:"type:decimal(10,2);not null;default:0;comment:批次剩余数量（基础库存单位）"`
+	Unit           string     `json:"unit" gorm:"type:varchar(20);comment:基础库存单位"`
+	SourceOrderNo  string     `json:"source_order_no" gorm:"type:varchar(50);comment:首次入库单号"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (InventoryLot) TableName() string {
+	return "inventory_lots"
+}
+
+// InventoryLotKey 由到期日生成批次键
+func InventoryLotKey(expiryDate *time.Time) string {
+	if expiryDate == nil || expiryDate.IsZero() {
+		return ""
+	}
+	return expiryDate.Format("2006-01-02")
+}
+
+// InventoryLotView 库存列表中的批次明细
+type InventoryLotView struct {
+	ID             uint       `json:"id"`
+	ProductionDate *time.Time `json:"production_date"`
+	ExpiryDate     *time.Time `json:"expiry_date"`
+	Quantity       float64    `json:"quantity"`
+	Unit           string     `json:"unit"`
+	SourceOrderNo  string     `json:"source_order_no"`
+}
+
+// InventoryLotBreakdown 单个库存记录的批次拆分；UntrackedQuantity 为启用批次前的历史库存（未分批）
+type InventoryLotBreakdown struct {
+	InventoryID       uint                `json:"inventory_id"`
+	StoreID           uint                `json:"store_id"`
+	ProductID         uint                `json:"product_id"`
+	Quantity          float64             `json:"quantity"`
+	Unit              string              `json:"unit"`
+	UntrackedQuantity float64             `json:"untracked_quantity"`
+	Lots              []*InventoryLotView `json:"lots"`
+}
+
+// InventoryLotLabel 打印批次标签所需信息
+type InventoryLotLabel struct {
+	InventoryLot
+	ProductName string `json:"product_name"`
+}
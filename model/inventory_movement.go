@@ -0,0 +1,90 @@
+package model
+
+import "time"
+
+// 库存流水来源类型
+const (
+	MovementSourceOpening        = "opening"           // 期初（启用流水前的历史库存）
+	MovementSourceInventoryOrder = "inventory_order"   // 出入库单（含调拨、盘点调整）
+	MovementSourceStoreAccount   = "store_account"     // 门店记账出库/编辑/作废回补
+	MovementSourceB2BSupplyOrder = "b2b_supply_order"  // B2B供货出库
+	MovementSourceB2BReturn      = "b2b_supply_return" // B2B供货退货回补
+	MovementSourceInventoryLoss  = "inventory_loss"    // 报损/自用/赠送及撤销回补
+	MovementSourceManualAdjust   = "manual_adjust"     // 直接修改库存数量
+	MovementSourceUnitCorrection = "unit_correction"   // 历史库存单位纠偏
+)
+
+// InventoryMovement 库存流水：每次库存数量变动追加一行，只增不改。
+// BalanceAfter 为变动后的库存结余，同一门店商品按 ID 顺序首尾相接，可据此核对 inventories。
+type InventoryMovement struct {
+	ID           uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	StoreID      uint      `json:"store_id" gorm:"not null;index:idx_inventory_movements_card,priority:1;comment:门店ID"`
+	ProductID    uint      `json:"product_id" gorm:"not null;index:idx_inventory_movements_card,priority:2;comment:商品ID"`
+	Delta        float64   `json:"delta" gorm:"type:decimal(10,2);not null;comment:变动数量（入正出负）"`
+	BalanceAfter float64   `json:"balance_after" gorm:"type:decimal(10,2);not null;comment:变动后结余"`
+	Unit         string    `json:"unit" gorm:"type:varchar(20);comment:库存单位"`
+	SourceType   string    `json:"source_type" gorm:"type:varchar(30);not null;index:idx_inventory_movements_source,priority:1;comment:来源类型"`
+	SourceID     uint      `json:"source_id" gorm:"not null;default:0;index:idx_inventory_movements_source,priority:2;comment:来源单据ID"`
+	SourceNo     string    `json:"source_no" gorm:"type:varchar(50);comment:来源单号"`
+	OperatorID   uint      `json:"operator_id" gorm:"not null;default:0;comment:操作人ID"`
+	OperatorName string    `json:"operator_name" gorm:"type:varchar(50);comment:操作人姓名"`
+	Remark       string    `json:"remark" gorm:"type:varchar(500);comment:备注"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index:idx_inventory_movements_card,priority:3"`
+}
+
+func (InventoryMovement) TableName() string {
+	return "inventory_movements"
+}
+
+// InventoryMovementLot 出库流水按 FEFO 扣减的批次明细，撤销、退货等回补时按原批次退回。
+// SourceType/SourceID 冗余自流水，便于按来源单据查找；RestoredQuantity 为已退回数量。
+type InventoryMovementLot struct {
+	ID               uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	MovementID       uint      `json:"movement_id" gorm:"not null;index;comment:库存流水ID"`
+	StoreID          uint      `json:"store_id" gorm:"not null;comment:门店ID"`
+	ProductID        uint      `json:"product_id" gorm:"not null;index:idx_inventory_movement_lots_source,priority:3;comment:商品ID"`
+	SourceType       string    `json:"source_type" gorm:"type:varchar(30);not null;index:idx_inventory_movement_lots_source,priority:1;comment:来源类型"`
+	SourceID         uint      `json:"source_id" gorm:"not null;default:0;index:idx_inventory_movement_lots_source,priority:2;comment:来源单据ID"`
+	LotID            uint      `json:"lot_id" gorm:"not null;index;comment:库存批次ID"`
+	Quantity         float64   `json:"quantity" gorm:"type:decimal(10,2);not null;comment:扣减数量"`
+	RestoredQuantity float64   `json:"restored_quantity" gorm:"type:decimal(10,2);not null;default:0;comment:已回补数量"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func (InventoryMovementLot) TableName() string {
+	return "inventory_movement_lots"
+}
+
+// InventoryMovementSource 库存变动的来源与操作人，随数量变动一起写入流水
+type InventoryMovementSource struct {
+	Type         string
+	ID           uint
+	No           string
+	OperatorID   uint
+	OperatorName string
+	Remark       string
+}
+
+// StockCardReq 商品库存卡查询
+type StockCardReq struct {
+	StoreID   uint   `form:"store_id"`
+	ProductID uint   `form:"product_id" binding:"required"`
+	StartDate string `form:"start_date" binding:"required"`
+	EndDate   string `form:"end_date" binding:"required"`
+}
+
+// StockCard 商品库存卡：期初结余 + 区间内流水 + 期末结余
+type StockCard struct {
+	StoreID        uint                 `json:"store_id"`
+	StoreName      string               `json:"store_name"`
+	ProductID      uint                 `json:"product_id"`
+	ProductName    string               `json:"product_name"`
+	Unit           string               `json:"unit"`
+	StartDate      string               `json:"start_date"`
+	EndDate        string               `json:"end_date"`
+	OpeningBalance float64              `json:"opening_balance"`
+	InQuantity     float64              `json:"in_quantity"`
+	OutQuantity    float64              `json:"out_quantity"`
+	ClosingBalance float64              `json:"closing_balance"`
+	Movements      []*InventoryMovement `json:"movements"`
+}
@@ -48,6 +48,19 @@ type MemberUnsettledAccounts struct {
 	UnsettledAccounts []*StoreAccount `json:"unsettled_accounts"`
 }
 
+// SettleMemberAccountsReq 会员余额批量结算未结账单，AccountIDs 为空时结算全部未结账单
+type SettleMemberAccountsReq struct {
+	AccountIDs    []uint `json:"account_ids" binding:"max=200"`
+	MemberVersion *int   `json:"member_version"`
+}
+
+// SettleMemberAccountsResult 余额结算结果
+type SettleMemberAccountsResult struct {
+	Member        *Member         `json:"member"`
+	Accounts      []*StoreAccount `json:"accounts"`
+	SettledAmount float64         `json:"settled_amount"`
+}
+
 // TableName 指定表名为 t_member
 func (Member) TableName() string {
 	return "t_member"
@@ -95,6 +108,9 @@ type RechargeOrder struct {
 	PayType     int             `json:"payType" gorm:"type:int;default:0;comment:支付方式"`
 	PayTypeName string          `json:"payTypeName" gorm:"-"` // 不存数据库
 	PayStatus   PayStatusEnum   `json:"payStatus" gorm:"type:int;default:0;comment:支付状态"`
+	PackageID   uint            `json:"packageId" gorm:"not null;default:0;index;comment:充值套餐ID，0表示未使用套餐"`
+	PackageName string          `json:"packageName" gorm:"type:varchar(100);comment:充值套餐名称"`
+	FirstGift   decimal.Decimal `json:"firstGift" gorm:"type:decimal(10,2);not null;default:0;comment:首充赠送金额（已含在赠送金额中）"`
 	StatusName  string          `json:"statusName" gorm:"-"` // 不存数据库
 	PayTime     *time.Time      `json:"payTime" gorm:"comment:支付时间"`
 	Remark      string          `json:"remark" gorm:"type:varchar(255);comment:备注"`
@@ -172,6 +188,8 @@ type MemberPointRule struct {
 	Name        string         `json:"name" gorm:"type:varchar(100);not null;comment:规则名称"`
 	SpendAmount float64        `json:"spend_amount" gorm:"type:decimal(10,2);not null;default:1;comment:消费金额"`
 	Points      int            `json:"points" gorm:"not null;default:1;comment:获得积分"`
+	ValidDays   int            `json:"valid_days" gorm:"not null;default:0;comment:积分有效天数，0表示永久有效"`
+	PointValue  float64        `json:"point_value" gorm:"type:decimal(10,4);not null;default:0;comment:每积分抵扣金额，0表示不可抵扣"`
 	Status      int            `json:"status" gorm:"not null;default:1;index;comment:状态 1=启用 2=停用"`
 	Remark      string         `json:"remark" gorm:"type:varchar(500);comment:备注"`
 	CreatedAt   time.Time      `json:"created_at"`
@@ -188,6 +206,8 @@ type UpsertMemberPointRuleReq struct {
 	Name        string  `json:"name" binding:"required,max=100"`
 	SpendAmount float64 `json:"spend_amount" binding:"required,gt=0"`
 	Points      int     `json:"points" binding:"required,gt=0"`
+	ValidDays   int     `json:"valid_days" binding:"gte=0,lte=3650"`
+	PointValue  float64 `json:"point_value" binding:"gte=0"`
 	Status      int     `json:"status" binding:"omitempty,oneof=1 2"`
 	Remark      string  `json:"remark" binding:"max=500"`
 }
@@ -207,6 +227,10 @@ type CreateRechargeOrderReq struct {
 	GiftAmount decimal.Decimal `json:"giftAmount"`
 	PayType    int             `json:"payType" binding:"required"`
 	Remark     string          `json:"remark"`
+	PackageID  uint            `json:"packageId"` // 指定充值套餐；不传时按充值金额自动匹配可用套餐
+
+	PackageName string          `json:"-"`
+	FirstGift   decimal.Decimal `json:"-"`
 }
 
 // PayRechargeOrderReq 支付充值单请求
@@ -253,42 +277,60 @@ type MemberConsumptionSummary struct {
 const (
 	MemberWineTxnDeposit  = 1 // 存入
 	MemberWineTxnWithdraw = 2 // 取出
+	MemberWineTxnForfeit  = 3 // 到期转入门店库存
+	MemberWineTxnExtend   = 4 // 续存
 )
 
-// MemberWineStorage 会员存酒当前存量
+// 存酒到期处理方式
+const (
+	MemberWineExpireForfeit = "forfeit" // 到期转入门店库存
+	MemberWineExpireExtend  = "extend"  // 到期自动续存
+)
+
+// MemberWineStorage 会员存酒当前存量；ExpireAt 为剩余存入批次中最早的到期时间，存取、续存与到期处理后同步刷新
 type MemberWineStorage struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	StoreID   uint      `json:"store_id" gorm:"not null;index;uniqueIndex:idx_member_wine_storage_unique,priority:1;comment:门店ID"`
-	MemberID  uint      `json:"member_id" gorm:"not null;index;uniqueIndex:idx_member_wine_storage_unique,priority:2;comment:会员ID"`
-	Member    *Member   `json:"member,omitempty" gorm:"foreignKey:MemberID"`
-	WineName  string    `json:"wine_name" gorm:"type:varchar(120);not null;uniqueIndex:idx_member_wine_storage_unique,priority:3;comment:酒品名称"`
-	Unit      string    `json:"unit" gorm:"type:varchar(20);not null;default:'瓶';uniqueIndex:idx_member_wine_storage_unique,priority:4;comment:单位"`
-	Quantity  float64   `json:"quantity" gorm:"type:decimal(12,2);not null;default:0;comment:当前数量"`
-	Remark    string    `json:"remark" gorm:"type:varchar(500);comment:备注"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	StoreID        uint       `json:"store_id" gorm:"not null;index;uniqueIndex:idx_member_wine_storage_unique,priority:1;comment:门店ID"`
+	MemberID       uint       `json:"member_id" gorm:"not null;index;uniqueIndex:idx_member_wine_storage_unique,priority:2;comment:会员ID"`
+	Member         *Member    `json:"member,omitempty" gorm:"foreignKey:MemberID"`
+	WineName       string     `json:"wine_name" gorm:"type:varchar(120);not null;uniqueIndex:idx_member_wine_storage_unique,priority:3;comment:酒品名称"`
+	Unit           string     `json:"unit" gorm:"type:varchar(20);not null;default:'瓶';uniqueIndex:idx_member_wine_storage_unique,priority:4;comment:单位"`
+	Quantity       float64    `json:"quantity" gorm:"type:decimal(12,2);not null;default:0;comment:当前数量"`
+	Remark         string     `json:"remark" gorm:"type:varchar(500);comment:备注"`
+	ExpireAt       *time.Time `json:"expire_at" gorm:"index;comment:最早到期时间"`
+	LastAccessAt   *time.Time `json:"last_access_at" gorm:"comment:最近存取时间"`
+	IdleRemindedAt *time.Time `json:"idle_reminded_at" gorm:"comment:久未存取提醒时间，存取后清空"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
 }
 
 func (MemberWineStorage) TableName() string {
 	return "member_wine_storages"
 }
 
-// MemberWineTransaction 会员存取酒流水
+// MemberWineTransaction 会员存取酒流水；存入流水同时作为批次，Remaining 为该批次尚未取出的数量，取酒按到期先后扣减
 type MemberWineTransaction struct {
-	ID           uint      `json:"id" gorm:"primaryKey"`
-	StoreID      uint      `json:"store_id" gorm:"not null;index;comment:门店ID"`
-	StorageID    uint      `json:"storage_id" gorm:"not null;index;comment:存酒记录ID"`
-	MemberID     uint      `json:"member_id" gorm:"not null;index;comment:会员ID"`
-	Member       *Member   `json:"member,omitempty" gorm:"foreignKey:MemberID"`
-	Type         int       `json:"type" gorm:"not null;index;comment:类型 1=存入 2=取出"`
-	WineName     string    `json:"wine_name" gorm:"type:varchar(120);not null;comment:酒品名称"`
-	Unit         string    `json:"unit" gorm:"type:varchar(20);not null;default:'瓶';comment:单位"`
-	Quantity     float64   `json:"quantity" gorm:"type:decimal(12,2);not null;comment:本次数量"`
-	BalanceAfter float64   `json:"balance_after" gorm:"type:decimal(12,2);not null;default:0;comment:操作后数量"`
-	Remark       string    `json:"remark" gorm:"type:varchar(500);comment:备注"`
-	OperatorID   uint      `json:"operator_id" gorm:"index;comment:操作人ID"`
-	OperatorName string    `json:"operator_name" gorm:"type:varchar(100);comment:操作人"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	StoreID          uint       `json:"store_id" gorm:"not null;index;comment:门店ID"`
+	StorageID        uint       `json:"storage_id" gorm:"not null;index;comment:存酒记录ID"`
+	MemberID         uint       `json:"member_id" gorm:"not null;index;comment:会员ID"`
+	Member           *Member    `json:"member,omitempty" gorm:"foreignKey:MemberID"`
+	Type             int        `json:"type" gorm:"not null;index;comment:类型 1=存入 2=取出 3=到期入库 4=续存"`
+	WineName         string     `json:"wine_name" gorm:"type:varchar(120);not null;comment:酒品名称"`
+	Unit             string     `json:"unit" gorm:"type:varchar(20);not null;default:'瓶';comment:单位"`
+	Quantity         float64    `json:"quantity" gorm:"type:decimal(12,2);not null;comment:本次数量"`
+	BalanceAfter     float64    `json:"balance_after" gorm:"type:decimal(12,2);not null;default:0;comment:操作后数量"`
+	Remark           string     `json:"remark" gorm:"type:varchar(500);comment:备注"`
+	OperatorID       uint       `json:"operator_id" gorm:"index;comment:操作人ID"`
+	OperatorName     string     `json:"operator_name" gorm:"type:varchar(100);comment:操作人"`
+	ProductID        uint       `json:"product_id" gorm:"not null;default:0;comment:关联商品ID，到期转入库存用"`
+	Remaining        float64    `json:"remaining" gorm:"type:decimal(12,2);not null;default:0;comment:存入批次剩余数量"`
+	ExpireAt         *time.Time `json:"expire_at" gorm:"index;comment:存入批次到期时间"`
+	RemindedAt       *time.Time `json:"reminded_at" gorm:"comment:到期提醒时间，续存后清空"`
+	Photos           StringList `json:"photos" gorm:"column:photo_urls;type:json;comment:存酒照片URL，最多3张"`
+	LotID            uint       `json:"lot_id" gorm:"not null;default:0;index;comment:续存/到期入库对应的存入流水ID"`
+	InventoryOrderNo string     `json:"inventory_order_no" gorm:"type:varchar(50);comment:到期入库单号"`
+	CreatedAt        time.Time  `json:"created_at"`
 }
 
 func (MemberWineTransaction) TableName() string {
@@ -310,6 +352,12 @@ type MemberWineAdjustReq struct {
 	Unit     string  `json:"unit" binding:"max=20"`
 	Quantity float64 `json:"quantity" binding:"required,gt=0"`
 	Remark   string  `json:"remark" binding:"max=500"`
+	// 以下仅存入时使用：关联商品后到期可转入门店库存；StorageDays 为空时按存酒策略计算到期日
+	ProductID   uint     `json:"product_id"`
+	StorageDays int      `json:"storage_days" binding:"omitempty,min=1,max=3650"`
+	Photos      []string `json:"photos" binding:"max=3,dive,max=500"`
+
+	PhotoURLs StringList `json:"-"`
 }
 
 type ListMemberWineTransactionReq struct {
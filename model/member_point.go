@@ -0,0 +1,77 @@
+package model
+
+import "time"
+
+// PointChangeTypeEnum 积分变动类型枚举
+type PointChangeTypeEnum int
+
+const (
+	PointChangeEarn    PointChangeTypeEnum = 1 // 消费获得
+	PointChangeReverse PointChangeTypeEnum = 2 // 记账作废扣回
+	PointChangeDeduct  PointChangeTypeEnum = 3 // 抵扣消费
+	PointChangeGift    PointChangeTypeEnum = 4 // 兑换礼品
+	PointChangeRefund  PointChangeTypeEnum = 5 // 抵扣退回
+	PointChangeExpire  PointChangeTypeEnum = 6 // 过期清零
+)
+
+// String 获取积分变动类型名称
+func (t PointChangeTypeEnum) String() string {
+	switch t {
+	case PointChangeEarn:
+		return "消费获得"
+	case PointChangeReverse:
+		return "作废扣回"
+	case PointChangeDeduct:
+		return "积分抵扣"
+	case PointChangeGift:
+		return "兑换礼品"
+	case PointChangeRefund:
+		return "抵扣退回"
+	case PointChangeExpire:
+		return "过期清零"
+	default:
+		return "未知"
+	}
+}
+
+// MemberPointLog 积分流水表；获得/退回记录同时作为积分批次，Remaining 按先进先出扣减并到期清零
+type MemberPointLog struct {
+	ID              uint                `json:"id" gorm:"primaryKey"`
+	MemberID        uint                `json:"member_id" gorm:"not null;index;comment:会员ID"`
+	StoreID         uint                `json:"store_id" gorm:"not null;default:0;index;comment:门店ID"`
+	ChangeType      PointChangeTypeEnum `json:"change_type" gorm:"type:int;not null;comment:变动类型 1=消费获得 2=作废扣回 3=积分抵扣 4=兑换礼品 5=抵扣退回 6=过期清零"`
+	ChangeTypeName  string              `json:"change_type_name" gorm:"-"`
+	ChangePoints    int                 `json:"change_points" gorm:"not null;comment:变动积分，正数增加负数减少"`
+	PointsAfter     int                 `json:"points_after" gorm:"not null;default:0;comment:变动后积分"`
+	Remaining       int                 `json:"remaining" gorm:"not null;default:0;comment:批次剩余可用积分"`
+	ExpireAt        *time.Time          `json:"expire_at,omitempty" gorm:"index;comment:批次过期时间，空表示永久有效"`
+	StoreAccountID  uint                `json:"store_account_id" gorm:"not null;default:0;index;comment:关联记账单ID"`
+	RuleID          uint                `json:"rule_id" gorm:"not null;default:0;comment:积分规则ID"`
+	DeductionAmount float64             `json:"deduction_amount" gorm:"type:decimal(10,2);not null;default:0;comment:抵扣金额"`
+	RelatedOrderNo  string              `json:"related_order_no" gorm:"type:varchar(64);index;comment:关联单号"`
+	Remark          string              `json:"remark" gorm:"type:varchar(255);comment:备注"`
+	OperatorID      uint                `json:"operator_id" gorm:"not null;default:0;comment:操作人ID"`
+	CreateTime      time.Time           `json:"createTime" gorm:"autoCreateTime"`
+	UpdateTime      time.Time           `json:"updateTime" gorm:"autoUpdateTime"`
+}
+
+// TableName 指定表名为 t_member_point_log
+func (MemberPointLog) TableName() string {
+	return "t_member_point_log"
+}
+
+// RedeemMemberPointsReq 积分兑换礼品请求
+type RedeemMemberPointsReq struct {
+	Points   int    `json:"points" binding:"required,gt=0"`
+	GiftName string `json:"gift_name" binding:"required,max=100"`
+	Remark   string `json:"remark" binding:"max=200"`
+}
+
+// ListMemberPointLogReq 查询积分流水请求
+type ListMemberPointLogReq struct {
+	ChangeType int    `form:"change_type" binding:"omitempty,min=1,max=6"`
+	StartDate  string `form:"start_date"`
+	EndDate    string `form:"end_date"`
+	Page       int    `form:"page"`
+	PageSize   int    `form:"page_size"`
+}
@@ -0,0 +1,94 @@
+package model
+
+import "time"
+
+const (
+	MemberTierEnabled  = 1
+	MemberTierDisabled = 2
+)
+
+// 会员等级变动原因
+const (
+	MemberTierChangeUpgrade   = "upgrade"   // 升级
+	MemberTierChangeDowngrade = "downgrade" // 降级
+)
+
+// MemberTier 会员等级定义：满足任一门槛（近 12 个月消费、累计充值、当前积分）即可达到该等级，门槛为 0 表示不按该项评定。
+// 门店配置了等级时使用门店等级，否则使用全局等级（store_id=0）
+type MemberTier struct {
+	ID                uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	StoreID           uint      `json:"store_id" gorm:"not null;default:0;uniqueIndex:uk_member_tier_level,priority:1;comment:门店ID，0表示全局"`
+	Level             int       `json:"level" gorm:"not null;uniqueIndex:uk_member_tier_level,priority:2;comment:等级，对应会员 level"`
+	Name              string    `json:"name" gorm:"type:varchar(50);not null;comment:等级名称"`
+	MinSpend          float64   `json:"min_spend" gorm:"type:decimal(12,2);not null;default:0;comment:近12个月消费门槛"`
+	MinRecharge       float64   `json:"min_recharge" gorm:"type:decimal(12,2);not null;default:0;comment:累计充值门槛"`
+	MinPoints         int       `json:"min_points" gorm:"not null;default:0;comment:当前积分门槛"`
+	DiscountRate      float64   `json:"discount_rate" gorm:"type:decimal(5,4);not null;default:1;comment:记账商品折扣率，1表示不打折"`
+	PointsMultiplier  float64   `json:"points_multiplier" gorm:"type:decimal(5,2);not null;default:1;comment:积分倍数"`
+	RechargeBonusRate float64   `json:"recharge_bonus_rate" gorm:"type:decimal(5,4);not null;default:0;comment:充值赠送比例"`
+	Status            int       `json:"status" gorm:"not null;default:1;comment:状态 1=启用 2=停用"`
+	Remark            string    `json:"remark" gorm:"type:varchar(500);comment:备注"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+func (MemberTier) TableName() string {
+	return "member_tiers"
+}
+
+// MemberTierLog 会员等级变动记录
+type MemberTierLog struct {
+	ID            uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	MemberID      uint      `json:"member_id" gorm:"not null;index;comment:会员ID"`
+	Member        *Member   `json:"member,omitempty" gorm:"foreignKey:MemberID"`
+	StoreID       uint      `json:"store_id" gorm:"not null;default:0;index;comment:会员所属门店ID"`
+	ChangeType    string    `json:"change_type" gorm:"type:varchar(20);not null;comment:变动类型 upgrade=升级 downgrade=降级"`
+	FromLevel     int       `json:"from_level" gorm:"not null;comment:原等级"`
+	FromName      string    `json:"from_name" gorm:"type:varchar(50);comment:原等级名称"`
+	ToLevel       int       `json:"to_level" gorm:"not null;comment:新等级"`
+	ToName        string    `json:"to_name" gorm:"type:varchar(50);comment:新等级名称"`
+	Spend         float64   `json:"spend" gorm:"type:decimal(12,2);not null;default:0;comment:评定时近12个月消费"`
+	RechargeTotal float64   `json:"recharge_total" gorm:"type:decimal(12,2);not null;default:0;comment:评定时累计充值"`
+	Points        int       `json:"points" gorm:"not null;default:0;comment:评定时积分"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (MemberTierLog) TableName() string {
+	return "member_tier_logs"
+}
+
+// MemberTierMetrics 会员等级评定指标
+type MemberTierMetrics struct {
+	Spend         float64
+	RechargeTotal float64
+	Points        int
+}
+
+type UpsertMemberTierReq struct {
+	StoreID           uint    `json:"store_id"`
+	Level             int     `json:"level" binding:"required,min=1,max=99"`
+	Name              string  `json:"name" binding:"required,max=50"`
+	MinSpend          float64 `json:"min_spend" binding:"gte=0"`
+	MinRecharge       float64 `json:"min_recharge" binding:"gte=0"`
+	MinPoints         int     `json:"min_points" binding:"gte=0"`
+	DiscountRate      float64 `json:"discount_rate" binding:"gte=0,lte=1"` // 0 按 1 处理
+	PointsMultiplier  float64 `json:"points_multiplier" binding:"gte=0,lte=10"`
+	RechargeBonusRate float64 `json:"recharge_bonus_rate" binding:"gte=0,lte=1"`
+	Status            int     `json:"status" binding:"omitempty,oneof=1 2"`
+	Remark            string  `json:"remark" binding:"max=500"`
+}
+
+type ListMemberTierReq struct {
+	StoreID uint `form:"store_id"`
+	Status  int  `form:"status" binding:"omitempty,oneof=1 2"`
+}
+
+type ListMemberTierLogReq struct {
+	StoreID    uint   `form:"store_id"`
+	MemberID   uint   `form:"member_id"`
+	ChangeType string `form:"change_type" binding:"omitempty,oneof=upgrade downgrade"`
+	StartDate  string `form:"start_date"`
+	EndDate    string `form:"end_date"`
+	Page       int    `form:"page"`
+	PageSize   int    `form:"page_size"`
+}
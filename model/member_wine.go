@@ -0,0 +1,67 @@
+package model
+
+import "time"
+
+// MemberWinePolicy 存酒期限策略：门店配置了策略时使用门店策略，否则使用全局策略（store_id=0），都未配置时使用默认值
+type MemberWinePolicy struct {
+	ID           uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	StoreID      uint      `json:"store_id" gorm:"not null;default:0;uniqueIndex;comment:门店ID，0表示全局"`
+	StorageDays  int       `json:"storage_days" gorm:"not null;default:180;comment:存酒期限天数，0表示不限期"`
+	RemindDays   int       `json:"remind_days" gorm:"not null;default:7;comment:到期前提醒天数，0表示不提醒"`
+	IdleDays     int       `json:"idle_days" gorm:"not null;default:90;comment:久未存取提醒天数，0表示不提醒"`
+	ExpireAction string    `json:"expire_action" gorm:"type:varchar(20);not null;default:'extend';comment:到期处理 forfeit=转入门店库存 extend=自动续存"`
+	ExtendDays   int       `json:"extend_days" gorm:"not null;default:30;comment:自动续存天数"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (MemberWinePolicy) TableName() string {
+	return "member_wine_policies"
+}
+
+// DefaultMemberWinePolicy 未配置存酒策略时的默认值：存放 180 天，到期前 7 天提醒，90 天未存取提醒，到期自动续存 30 天
+func DefaultMemberWinePolicy(storeID uint) *MemberWinePolicy {
+	return &MemberWinePolicy{
+		StoreID:      storeID,
+		StorageDays:  180,
+		RemindDays:   7,
+		IdleDays:     90,
+		ExpireAction: MemberWineExpireExtend,
+		ExtendDays:   30,
+	}
+}
+
+type GetMemberWinePolicyReq struct {
+	StoreID uint `form:"store_id"`
+}
+
+type UpsertMemberWinePolicyReq struct {
+	StoreID      uint   `json:"store_id"`
+	StorageDays  int    `json:"storage_days" binding:"min=0,max=3650"`
+	RemindDays   int    `json:"remind_days" binding:"min=0,max=365"`
+	IdleDays     int    `json:"idle_days" binding:"min=0,max=3650"`
+	ExpireAction string `json:"expire_action" binding:"required,oneof=forfeit extend"`
+	ExtendDays   int    `json:"extend_days" binding:"min=0,max=3650"`
+}
+
+// ListMemberWineLotReq 查询存入批次；ExpireWithin>0 时只看该天数内到期（含已到期）的批次
+type ListMemberWineLotReq struct {
+	StoreID      uint `form:"store_id"`
+	MemberID     uint `form:"member_id"`
+	StorageID    uint `form:"storage_id"`
+	ExpireWithin int  `form:"expire_within"`
+	Page         int  `form:"page"`
+	PageSize     int  `form:"page_size"`
+}
+
+type ExtendMemberWineLotReq struct {
+	Days   int    `json:"days" binding:"required,min=1,max=3650"`
+	Remark string `json:"remark" binding:"max=500"`
+}
+
+// ForfeitMemberWineLotReq 手工转归存酒；可填写酒瓶标注的生产日期与到期日（yyyy-mm-dd），入库时记入对应批次
+type ForfeitMemberWineLotReq struct {
+	ProductionDate string `json:"production_date"`
+	ExpiryDate     string `json:"expiry_date"`
+	Remark         string `json:"remark" binding:"max=500"`
+}
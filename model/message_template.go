@@ -1,19 +1,26 @@
 package model
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 // MessageTemplate 消息模板
 type MessageTemplate struct {
-	ID          uint      `json:"id" gorm:"primaryKey;autoIncrement"`
-	Code        string    `json:"code" gorm:"type:varchar(50);uniqueIndex;not null;comment:模板编码"`
-	Name        string    `json:"name" gorm:"type:varchar(100);not null;comment:模板名称"`
-	Title       string    `json:"title" gorm:"type:varchar(200);comment:消息标题模板"`
-	Content     string    `json:"content" gorm:"type:text;not null;comment:消息内容模板"`
-	Description string    `json:"description" gorm:"type:varchar(500);comment:模板说明"`
-	Variables   string    `json:"variables" gorm:"type:text;comment:可用变量说明(JSON)"`
-	IsEnabled   bool      `json:"is_enabled" gorm:"default:true;comment:是否启用"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	Code        string         `json:"code" gorm:"type:varchar(50);uniqueIndex;not null;comment:模板编码"`
+	Name        string         `json:"name" gorm:"type:varchar(100);not null;comment:模板名称"`
+	Title       string         `json:"title" gorm:"type:varchar(200);comment:消息标题模板"`
+	Content     string         `json:"content" gorm:"type:text;not null;comment:消息内容模板"`
+	Description string         `json:"description" gorm:"type:varchar(500);comment:模板说明"`
+	Variables   string         `json:"variables" gorm:"type:text;comment:可用变量说明(JSON)"`
+	Schema      TemplateSchema `json:"schema" gorm:"type:json;comment:变量结构定义"`
+	Version     int            `json:"version" gorm:"not null;default:1;comment:当前版本号"`
+	IsEnabled   bool           `json:"is_enabled" gorm:"default:true;comment:是否启用"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
 }
 
 func (MessageTemplate) TableName() string {
@@ -25,6 +32,8 @@ const (
 	TemplateStoreAccountCreated = "store_account_created" // 记账通知
 	TemplateInventoryCreated    = "inventory_created"     // 入库通知
 	TemplatePurchaseCreated     = "purchase_created"      // 采购通知
+	TemplateStoreAccountCard    = "store_account_card"    // 记账通知卡片（ActionCard 正文）
+	TemplatePreOrderReminder    = "pre_order_reminder"    // 预订单配送提醒
 
 	// 钉钉机器人命令回复模板
 	TemplateBotHelp           = "bot_help"            // 帮助菜单
@@ -37,21 +46,115 @@ const (
 
 // CreateMessageTemplateReq 创建消息模板请求
 type CreateMessageTemplateReq struct {
-	Code        string `json:"code" binding:"required,max=50"`
-	Name        string `json:"name" binding:"required,max=100"`
-	Title       string `json:"title" binding:"max=200"`
-	Content     string `json:"content" binding:"required"`
-	Description string `json:"description" binding:"max=500"`
-	Variables   string `json:"variables"`
-	IsEnabled   *bool  `json:"is_enabled"`
+	Code        string         `json:"code" binding:"required,max=50"`
+	Name        string         `json:"name" binding:"required,max=100"`
+	Title       string         `json:"title" binding:"max=200"`
+	Content     string         `json:"content" binding:"required"`
+	Description string         `json:"description" binding:"max=500"`
+	Variables   string         `json:"variables"`
+	Schema      TemplateSchema `json:"schema"`
+	IsEnabled   *bool          `json:"is_enabled"`
 }
 
 // UpdateMessageTemplateReq 更新消息模板请求
 type UpdateMessageTemplateReq struct {
-	Name        *string `json:"name" binding:"omitempty,max=100"`
-	Title       *string `json:"title" binding:"omitempty,max=200"`
-	Content     *string `json:"content"`
-	Description *string `json:"description" binding:"omitempty,max=500"`
-	Variables   *string `json:"variables"`
-	IsEnabled   *bool   `json:"is_enabled"`
+	Name        *string         `json:"name" binding:"omitempty,max=100"`
+	Title       *string         `json:"title" binding:"omitempty,max=200"`
+	Content     *string         `json:"content"`
+	Description *string         `json:"description" binding:"omitempty,max=500"`
+	Variables   *string         `json:"variables"`
+	Schema      *TemplateSchema `json:"schema"`
+	IsEnabled   *bool           `json:"is_enabled"`
+	Remark      string          `json:"remark" binding:"max=200"` // 版本说明
+}
+
+// 模板变量类型
+const (
+	TemplateVarString = "string"
+	TemplateVarNumber = "number"
+	TemplateVarBool   = "bool"
+	TemplateVarList   = "list"   // 可 range 遍历，元素字段见 Fields
+	TemplateVarObject = "object" // 嵌套对象，字段见 Fields
+)
+
+// TemplateVariable 模板变量声明
+type TemplateVariable struct {
+	Name        string             `json:"name"`
+	Type        string             `json:"type"`
+	Required    bool               `json:"required,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Example     interface{}        `json:"example,omitempty"` // 预览时的示例值
+	Fields      []TemplateVariable `json:"fields,omitempty"`  // list 元素或 object 的字段
+}
+
+// TemplateSchema 模板变量结构，以 JSON 存储；为空表示不做变量校验（兼容旧模板）
+type TemplateSchema []TemplateVariable
+
+func (s *TemplateSchema) Scan(value interface{}) error {
+	if value == nil {
+		*s = nil
+		return nil
+	}
+	var data []byte
+	switch typed := value.(type) {
+	case []byte:
+		data = typed
+	case string:
+		data = []byte(typed)
+	default:
+		return fmt.Errorf("scan TemplateSchema from %T", value)
+	}
+	if len(data) == 0 {
+		*s = nil
+		return nil
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return fmt.Errorf("decode TemplateSchema: %w", err)
+	}
+	return nil
+}
+
+func (s TemplateSchema) Value() (driver.Value, error) {
+	if s == nil {
+		s = TemplateSchema{}
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("encode TemplateSchema: %w", err)
+	}
+	return string(data), nil
+}
+
+// MessageTemplateVersion 模板版本快照，每次修改标题、内容或变量结构时生成一版，用于回滚
+type MessageTemplateVersion struct {
+	ID         uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	TemplateID uint           `json:"template_id" gorm:"not null;uniqueIndex:uk_message_template_version,priority:1;comment:模板ID"`
+	Version    int            `json:"version" gorm:"not null;uniqueIndex:uk_message_template_version,priority:2;comment:版本号"`
+	Title      string         `json:"title" gorm:"type:varchar(200);comment:消息标题模板"`
+	Content    string         `json:"content" gorm:"type:text;not null;comment:消息内容模板"`
+	Schema     TemplateSchema `json:"schema" gorm:"type:json;comment:变量结构定义"`
+	Remark     string         `json:"remark" gorm:"type:varchar(200);not null;default:'';comment:版本说明"`
+	OperatorID uint           `json:"operator_id" gorm:"not null;default:0;comment:操作人ID"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+func (MessageTemplateVersion) TableName() string {
+	return "message_template_versions"
+}
+
+// PreviewMessageTemplateReq 模板预览：可传入未保存的草稿，数据取 data、单据（doc_id）或按变量结构生成的示例
+type PreviewMessageTemplateReq struct {
+	Title   *string                `json:"title"`
+	Content *string                `json:"content"`
+	Schema  *TemplateSchema        `json:"schema"`
+	Data    map[string]interface{} `json:"data"`
+	DocID   uint                   `json:"doc_id"` // 按真实单据渲染，如记账单ID、预订单ID
+}
+
+// MessageTemplatePreview 预览结果，Warnings 为数据与变量结构不一致之处
+type MessageTemplatePreview struct {
+	Title    string                 `json:"title"`
+	Content  string                 `json:"content"`
+	Data     map[string]interface{} `json:"data"`
+	Warnings []string               `json:"warnings"`
 }
@@ -0,0 +1,125 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 发件箱状态：待发送 → 发送中 → 成功；失败后按退避时间重试，超过最大次数转入死信，可人工重发
+const (
+	NotificationPending int8 = 1 // 待发送
+	NotificationSending int8 = 2 // 发送中
+	NotificationSent    int8 = 3 // 成功
+	NotificationFailed  int8 = 4 // 失败待重试
+	NotificationDead    int8 = 5 // 死信（不再自动重试）
+)
+
+// 通知渠道
+const (
+	NotificationChannelDingTalk = "dingtalk"
+)
+
+// 通知消息类型
+const (
+	NotificationMsgMarkdown = "markdown" // Markdown（可附图片）
+	NotificationMsgCard     = "card"     // 互动卡片，失败依次回退到 ActionCard、Markdown
+)
+
+// 通知业务来源
+const (
+	NotificationBizInventoryOrder = "inventory_order"
+	NotificationBizStoreAccount   = "store_account"
+	NotificationBizPurchaseOrder  = "purchase_order"
+	NotificationBizMemberRecharge = "member_recharge"
+	NotificationBizMemberBalance  = "member_balance"
+	NotificationBizMemberTier     = "member_tier"
+
+	NotificationBizInventoryExpiry  = "inventory_expiry"
+	NotificationBizPreOrderReminder = "pre_order_reminder"
+	NotificationBizMemberWine       = "member_wine"
+)
+
+// NotificationDefaultMaxAttempts 默认最大发送次数
+const NotificationDefaultMaxAttempts = 6
+
+// NotificationOutbox 外发通知发件箱：业务写入后由后台任务投递，失败按指数退避重试
+type NotificationOutbox struct {
+	ID            uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	StoreID       uint       `json:"store_id" gorm:"not null;default:0;index;comment:门店ID"`
+	Channel       string     `json:"channel" gorm:"type:varchar(20);not null;default:'dingtalk';comment:渠道"`
+	BotID         uint       `json:"bot_id" gorm:"not null;default:0;comment:钉钉机器人ID"`
+	TargetType    string     `json:"target_type" gorm:"type:varchar(20);not null;default:'';comment:接收目标 webhook/user/group，空=按机器人类型"`
+	Target        string     `json:"target" gorm:"type:varchar(100);not null;default:'';comment:接收人手机号或群会话ID"`
+	BizType       string     `json:"biz_type" gorm:"type:varchar(30);not null;default:'';index:idx_notification_outbox_biz,priority:1;comment:业务来源"`
+	BizID         uint       `json:"biz_id" gorm:"not null;default:0;index:idx_notification_outbox_biz,priority:2;comment:业务单据ID"`
+	BizNo         string     `json:"biz_no" gorm:"type:varchar(64);not null;default:'';comment:业务单号"`
+	MsgType       string     `json:"msg_type" gorm:"type:varchar(20);not null;default:'markdown';comment:消息类型 markdown/card"`
+	Title         string     `json:"title" gorm:"type:varchar(200);not null;default:'';comment:标题"`
+	Content       string     `json:"content" gorm:"type:text;comment:Markdown 正文"`
+	ImageURL      string     `json:"image_url" gorm:"type:varchar(500);not null;default:'';comment:附带图片"`
+	Payload       string     `json:"payload" gorm:"type:text;comment:卡片等扩展参数(JSON)"`
+	Status        int8       `json:"status" gorm:"not null;default:1;index:idx_notification_outbox_due,priority:1;comment:状态 1=待发送 2=发送中 3=成功 4=失败待重试 5=死信"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0;comment:已发送次数"`
+	MaxAttempts   int        `json:"max_attempts" gorm:"not null;default:6;comment:最大发送次数"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"not null;index:idx_notification_outbox_due,priority:2;comment:下次发送时间"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	ErrorMessage  string     `json:"error_message" gorm:"type:varchar(500);not null;default:'';comment:最近一次错误"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	Deliveries []NotificationDelivery `json:"deliveries,omitempty" gorm:"foreignKey:OutboxID"`
+}
+
+func (NotificationOutbox) TableName() string {
+	return "notification_outbox"
+}
+
+// NotificationPayload 卡片消息参数，序列化后存入 Payload；CardMsgKey 为空时使用发送机器人配置的卡片模板
+type NotificationPayload struct {
+	CardMsgKey        string                 `json:"card_msg_key,omitempty"`
+	CardParam         map[string]interface{} `json:"card_param,omitempty"`
+	ActionTitle       string                 `json:"action_title,omitempty"`
+	ActionText        string                 `json:"action_text,omitempty"`
+	ActionButtonTitle string                 `json:"action_button_title,omitempty"`
+	ActionButtonURL   string                 `json:"action_button_url,omitempty"`
+	Image             *NotificationImageSpec `json:"image,omitempty"`
+}
+
+// NotificationImageKindStoreAccount 记账回单图片
+const NotificationImageKindStoreAccount = "store_account"
+
+// NotificationImageSpec 待生成的通知图片：写入发件箱时只保存数据，由投递任务生成图片后回填到
+// 发件箱 ImageURL、卡片参数 CardParamKeys 以及 ActionCard 按钮（ButtonTitle 非空时）
+type NotificationImageSpec struct {
+	Kind          string          `json:"kind"`
+	Data          json.RawMessage `json:"data"`
+	CardParamKeys []string        `json:"card_param_keys,omitempty"`
+	ButtonTitle   string          `json:"button_title,omitempty"`
+}
+
+// NotificationDelivery 每次投递的结果记录，只增不改
+type NotificationDelivery struct {
+	ID           uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	OutboxID     uint      `json:"outbox_id" gorm:"not null;index;comment:发件箱ID"`
+	Attempt      int       `json:"attempt" gorm:"not null;comment:第几次发送"`
+	Success      bool      `json:"success" gorm:"not null;default:false;comment:是否成功"`
+	ErrorMessage string    `json:"error_message" gorm:"type:varchar(500);not null;default:'';comment:错误信息"`
+	DurationMs   int64     `json:"duration_ms" gorm:"not null;default:0;comment:耗时(毫秒)"`
+	OperatorID   uint      `json:"operator_id" gorm:"not null;default:0;comment:人工重发操作人ID，0=后台任务"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (NotificationDelivery) TableName() string {
+	return "notification_deliveries"
+}
+
+type ListNotificationReq struct {
+	StoreID   uint   `form:"store_id"`
+	Status    int8   `form:"status"`
+	BizType   string `form:"biz_type"`
+	Keyword   string `form:"keyword"` // 业务单号/标题
+	StartDate string `form:"start_date"`
+	EndDate   string `form:"end_date"`
+	Page      int    `form:"page,default=1" binding:"min=1"`
+	PageSize  int    `form:"page_size,default=20" binding:"min=1,max=100"`
+}
@@ -0,0 +1,99 @@
+package model
+
+import "time"
+
+// 通知接收目标类型
+const (
+	NotificationTargetWebhook = "webhook" // Webhook 机器人所在群
+	NotificationTargetUser    = "user"    // Stream 机器人单聊指定手机号
+	NotificationTargetGroup   = "group"   // Stream 机器人发送到群会话（openConversationId）
+)
+
+// NotificationEventTypes 可配置路由的通知事件，与发件箱业务来源一致；approval_* 为钉钉审批，只支持 Stream 机器人的 user/group 目标
+var NotificationEventTypes = []string{
+	NotificationBizInventoryOrder,
+	NotificationBizStoreAccount,
+	NotificationBizPurchaseOrder,
+	NotificationBizMemberRecharge,
+	NotificationBizMemberBalance,
+	NotificationBizMemberTier,
+	NotificationBizInventoryExpiry,
+	NotificationBizPreOrderReminder,
+	NotificationBizMemberWine,
+	ApprovalBizPurchaseOrder,
+	ApprovalBizStoreAccountCancel,
+	ApprovalBizMemberBalance,
+}
+
+func IsNotificationEventType(eventType string) bool {
+	for _, t := range NotificationEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationRoute 通知路由规则：按事件类型、门店及可选的渠道/金额门槛，决定由哪个机器人发给谁。
+// 命中门店专属规则时不再使用全部门店规则；没有任何规则命中时回退到门店绑定的机器人与门店手机号。
+type NotificationRoute struct {
+	ID                 uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name               string     `json:"name" gorm:"type:varchar(100);not null;comment:规则名称"`
+	EventType          string     `json:"event_type" gorm:"type:varchar(30);not null;index:idx_notification_routes_event,priority:1;comment:通知事件"`
+	StoreID            uint       `json:"store_id" gorm:"not null;default:0;index:idx_notification_routes_event,priority:2;comment:门店ID，0=全部门店"`
+	Channel            string     `json:"channel" gorm:"type:varchar(50);not null;default:'';comment:渠道条件，空=不限"`
+	MinAmount          float64    `json:"min_amount" gorm:"type:decimal(12,2);not null;default:0;comment:金额门槛，0=不限"`
+	BotID              uint       `json:"bot_id" gorm:"not null;comment:钉钉机器人ID"`
+	TargetType         string     `json:"target_type" gorm:"type:varchar(20);not null;comment:接收目标 webhook/user/group"`
+	Mobiles            StringList `json:"mobiles" gorm:"type:json;comment:接收人手机号（user）"`
+	IncludeStorePhone  bool       `json:"include_store_phone" gorm:"not null;default:false;comment:同时发给门店负责人手机号（user）"`
+	ChatID             string     `json:"chat_id" gorm:"type:varchar(100);not null;default:'';comment:群号（group）"`
+	OpenConversationID string     `json:"open_conversation_id" gorm:"type:varchar(100);not null;default:'';comment:群会话ID（group）"`
+	IsEnabled          bool       `json:"is_enabled" gorm:"not null;default:true;comment:是否启用"`
+	Remark             string     `json:"remark" gorm:"type:varchar(500);not null;default:'';comment:备注"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+	Store              *Store     `json:"store,omitempty" gorm:"foreignKey:StoreID"`
+}
+
+func (NotificationRoute) TableName() string {
+	return "notification_routes"
+}
+
+// NotificationEvent 一次业务通知的路由条件
+type NotificationEvent struct {
+	EventType string
+	StoreID   uint
+	Channel   string
+	Amount    float64
+}
+
+// NotificationRecipient 路由解析出的一个接收方
+type NotificationRecipient struct {
+	BotID      uint
+	TargetType string
+	Target     string // 手机号或群会话ID，Webhook 为空
+}
+
+type UpsertNotificationRouteReq struct {
+	Name               string   `json:"name" binding:"required,max=100"`
+	EventType          string   `json:"event_type" binding:"required"`
+	StoreID            uint     `json:"store_id"`
+	Channel            string   `json:"channel" binding:"max=50"`
+	MinAmount          float64  `json:"min_amount" binding:"gte=0"`
+	BotID              uint     `json:"bot_id" binding:"required"`
+	TargetType         string   `json:"target_type" binding:"required,oneof=webhook user group"`
+	Mobiles            []string `json:"mobiles"`
+	IncludeStorePhone  bool     `json:"include_store_phone"`
+	ChatID             string   `json:"chat_id" binding:"max=100"`
+	OpenConversationID string   `json:"open_conversation_id" binding:"max=100"` // 不传时按 chat_id 自动解析
+	IsEnabled          *bool    `json:"is_enabled"`
+	Remark             string   `json:"remark" binding:"max=500"`
+}
+
+type ListNotificationRouteReq struct {
+	EventType string `form:"event_type"`
+	StoreID   uint   `form:"store_id"`
+	Page      int    `form:"page,default=1" binding:"min=1"`
+	PageSize  int    `form:"page_size,default=20" binding:"min=1,max=100"`
+}
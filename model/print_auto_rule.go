@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// PrintAutoRule 门店自动打印规则：单据创建后按规则自动打印，每个门店每种单据一条
+type PrintAutoRule struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	StoreID   uint      `json:"store_id" gorm:"not null;uniqueIndex:uk_print_auto_rule,priority:1;comment:门店ID"`
+	DocType   string    `json:"doc_type" gorm:"type:varchar(30);not null;uniqueIndex:uk_print_auto_rule,priority:2;comment:单据类型"`
+	PrinterID uint      `json:"printer_id" gorm:"not null;default:0;comment:打印机ID，0=门店默认打印机"`
+	Copies    int       `json:"copies" gorm:"not null;default:1;comment:打印份数"`
+	Enabled   int8      `json:"enabled" gorm:"not null;default:1;comment:是否启用 1=启用 0=停用"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (PrintAutoRule) TableName() string {
+	return "print_auto_rules"
+}
+
+// SavePrintAutoRuleReq 保存自动打印规则（按门店+单据类型覆盖），门店账号只能设置本门店
+type SavePrintAutoRuleReq struct {
+	StoreID   uint   `json:"store_id"`
+	DocType   string `json:"doc_type" binding:"required,oneof=store_account pre_order_pick b2b_delivery"`
+	PrinterID uint   `json:"printer_id"`
+	Copies    int    `json:"copies" binding:"omitempty,min=1,max=10"`
+	Enabled   *int8  `json:"enabled" binding:"omitempty,oneof=0 1"`
+}
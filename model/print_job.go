@@ -0,0 +1,105 @@
+package model
+
+import "time"
+
+// 打印任务状态
+const (
+	PrintJobPending int8 = 1 // 待发送
+	PrintJobSent    int8 = 2 // 已发送，等待打印机出纸
+	PrintJobPrinted int8 = 3 // 已打印
+	PrintJobFailed  int8 = 4 // 发送失败或超时未打印
+)
+
+// 打印单据类型
+const (
+	PrintDocTest          = "test"            // 测试打印
+	PrintDocPurchaseOrder = "purchase_order"  // 采购单
+	PrintDocStoreAccount  = "store_account"   // 记账销售小票
+	PrintDocPreOrderPick  = "pre_order_pick"  // 预订单拣货单
+	PrintDocB2BDelivery   = "b2b_delivery"    // B2B 送货单
+	PrintDocInventory     = "inventory_order" // 出入库单
+	PrintDocStoreReturn   = "store_return"    // 返厂单
+	PrintDocPriceTag      = "price_tag"       // 货架价签
+	PrintDocLotLabel      = "inventory_lot"   // 库存批次标签
+	PrintDocWineTag       = "wine_storage"    // 会员存酒牌
+)
+
+// PrintJob 打印任务：记录每次发给芯烨云的内容与订单号，轮询出纸结果，支持补打
+type PrintJob struct {
+	ID           uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	StoreID      uint       `json:"store_id" gorm:"not null;default:0;index;comment:门店ID"`
+	PrinterID    uint       `json:"printer_id" gorm:"not null;index;comment:实际打印的打印机ID"`
+	PrinterSn    string     `json:"printer_sn" gorm:"type:varchar(32);not null;default:'';comment:打印机SN"`
+	PrinterName  string     `json:"printer_name" gorm:"type:varchar(100);not null;default:'';comment:打印机名称"`
+	FailoverFrom uint       `json:"failover_from" gorm:"not null;default:0;comment:原打印机离线时切换前的打印机ID"`
+	DocType      string     `json:"doc_type" gorm:"type:varchar(30);not null;index:idx_print_jobs_doc,priority:1;comment:单据类型"`
+	DocID        uint       `json:"doc_id" gorm:"not null;default:0;index:idx_print_jobs_doc,priority:2;comment:单据ID"`
+	DocNo        string     `json:"doc_no" gorm:"type:varchar(64);not null;default:'';comment:单据编号"`
+	Content      string     `json:"content" gorm:"type:text;comment:渲染后的打印内容"`
+	Copies       int        `json:"copies" gorm:"not null;default:1;comment:打印份数"`
+	XpyunOrderID string     `json:"xpyun_order_id" gorm:"type:varchar(64);not null;default:'';comment:芯烨云订单ID"`
+	Status       int8       `json:"status" gorm:"not null;default:1;index;comment:状态 1=待发送 2=已发送 3=已打印 4=失败"`
+	LastError    string     `json:"last_error" gorm:"type:varchar(500);not null;default:'';comment:失败原因"`
+	ReprintOf    uint       `json:"reprint_of" gorm:"not null;default:0;comment:补打来源任务ID"`
+	OperatorID   uint       `json:"operator_id" gorm:"not null;default:0;comment:操作人ID"`
+	SentAt       *time.Time `json:"sent_at,omitempty" gorm:"comment:发送时间"`
+	PrintedAt    *time.Time `json:"printed_at,omitempty" gorm:"comment:确认出纸时间"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (PrintJob) TableName() string {
+	return "print_jobs"
+}
+
+// PrintJobStatusText 打印任务状态文案
+func PrintJobStatusText(status int8) string {
+	switch status {
+	case PrintJobPending:
+		return "待发送"
+	case PrintJobSent:
+		return "已发送"
+	case PrintJobPrinted:
+		return "已打印"
+	case PrintJobFailed:
+		return "失败"
+	}
+	return "未知"
+}
+
+type ListPrintJobReq struct {
+	StoreID   uint   `form:"store_id"`
+	PrinterID uint   `form:"printer_id"`
+	DocType   string `form:"doc_type"`
+	Status    int8   `form:"status"`
+	Keyword   string `form:"keyword"` // 单据编号/芯烨云订单ID
+	Page      int    `form:"page,default=1" binding:"min=1"`
+	PageSize  int    `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// ReprintJobReq 补打请求，不指定打印机时使用原任务的打印机（离线时自动切换）
+type ReprintJobReq struct {
+	PrinterID uint `json:"printer_id"`
+	Copies    int  `json:"copies" binding:"omitempty,min=1,max=10"`
+}
+
+// PrintDocumentReq 打印业务单据，不指定打印机时使用单据所属门店的默认打印机
+type PrintDocumentReq struct {
+	PrinterID uint `json:"printer_id"`
+	Copies    int  `json:"copies" binding:"omitempty,min=1,max=10"`
+}
+
+// PrintPriceTagReq 打印货架价签，不指定商品时打印价目单内全部显示中的商品
+type PrintPriceTagReq struct {
+	PriceListID uint   `json:"price_list_id" binding:"required"`
+	ItemIDs     []uint `json:"item_ids" binding:"max=200"`
+	PrinterID   uint   `json:"printer_id"`
+	Copies      int    `json:"copies" binding:"omitempty,min=1,max=10"`
+}
+
+// PrintLotLabelReq 打印库存批次标签
+type PrintLotLabelReq struct {
+	LotIDs    []uint `json:"lot_ids" binding:"required,min=1,max=100"`
+	PrinterID uint   `json:"printer_id"`
+	Copies    int    `json:"copies" binding:"omitempty,min=1,max=10"`
+}
@@ -10,6 +10,12 @@ const (
 	PrinterTypeLabel   PrinterType = 2 // 标签打印机
 )
 
+// 小票纸宽（mm），决定单据排版的每行字符数
+const (
+	PaperWidth58 = 58
+	PaperWidth80 = 80
+)
+
 // Printer 打印机表
 type Printer struct {
 	ID            uint        `json:"id" gorm:"primarykey"`
@@ -20,6 +26,7 @@ type Printer struct {
 	Status        int         `json:"status" gorm:"default:1"`                        // 状态：1=正常，2=停用
 	IsDefault     int         `json:"is_default" gorm:"default:0"`                    // 是否为默认打印机：0=否，1=是
 	Online        int         `json:"online" gorm:"default:0"`                        // 在线状态：0=离线，1=在线，2=异常
+	PaperWidth    int         `json:"paper_width" gorm:"not null;default:58"`         // 纸宽(mm)：58 或 80
 	LastHeartbeat *time.Time  `json:"last_heartbeat,omitempty" gorm:"type:datetime"`  // 最后心跳时间（可为空）
 	Remark        string      `json:"remark" gorm:"type:text"`                        // 备注
 	CreatedAt     time.Time   `json:"created_at"`
@@ -47,6 +54,7 @@ type UpdatePrinterReq struct {
 	Type      *PrinterType `json:"type,omitempty"`
 	Status    *int         `json:"status,omitempty"`
 	IsDefault *int         `json:"is_default,omitempty"`
+	PaperWidth *int        `json:"paper_width,omitempty" binding:"omitempty,oneof=58 80"`
 	Remark    *string      `json:"remark,omitempty"`
 }
 
@@ -57,6 +65,7 @@ type BindPrinterReq struct {
 	Name      string `json:"name"`
 	Type      int    `json:"type"`
 	IsDefault int    `json:"is_default"`
+	PaperWidth int   `json:"paper_width" binding:"omitempty,oneof=58 80"` // 默认 58
 	Remark    string `json:"remark"`
 }
 
@@ -73,6 +82,7 @@ type PrinterResp struct {
 	StatusName    string     `json:"status_name"`
 	IsDefault     int        `json:"is_default"`
 	Online        int        `json:"online"`               // 在线状态：0=离线，1=在线，2=异常
+	PaperWidth    int        `json:"paper_width"`          // 纸宽(mm)
 	LastHeartbeat *time.Time `json:"last_heartbeat,omitempty"` // 最后心跳时间
 	Remark        string     `json:"remark"`
 	CreatedAt     time.Time  `json:"created_at"`
@@ -4,19 +4,20 @@ import "time"
 
 // PurchaseOrder 采购单（报菜单）
 type PurchaseOrder struct {
-	ID          uint                `json:"id" gorm:"primaryKey;autoIncrement"`
-	OrderNo     string              `json:"order_no" gorm:"uniqueIndex;type:varchar(32);not null;comment:订单编号"`
-	StoreID     uint                `json:"store_id" gorm:"not null;index;comment:门店ID"`
-	Store       *Store              `json:"store,omitempty" gorm:"foreignKey:StoreID"`
-	TotalAmount float64             `json:"total_amount" gorm:"type:decimal(12,2);not null;default:0;comment:总金额"`
-	Status      int8                `json:"status" gorm:"not null;default:1;comment:状态 1=待确认 2=已确认 3=已完成 4=已取消"`
-	Remark      string              `json:"remark" gorm:"type:varchar(500);comment:备注"`
-	OrderDate   time.Time           `json:"order_date" gorm:"type:date;not null;comment:报菜日期"`
-	CreatedBy   uint                `json:"created_by" gorm:"not null;comment:创建人ID"`
-	Creator     *User               `json:"creator,omitempty" gorm:"foreignKey:CreatedBy"`
-	Items       []PurchaseOrderItem `json:"items,omitempty" gorm:"foreignKey:OrderID"`
-	CreatedAt   time.Time           `json:"created_at"`
-	UpdatedAt   time.Time           `json:"updated_at"`
+	ID            uint                `json:"id" gorm:"primaryKey;autoIncrement"`
+	OrderNo       string              `json:"order_no" gorm:"uniqueIndex;type:varchar(32);not null;comment:订单编号"`
+	StoreID       uint                `json:"store_id" gorm:"not null;index;comment:门店ID"`
+	Store         *Store              `json:"store,omitempty" gorm:"foreignKey:StoreID"`
+	TotalAmount   float64             `json:"total_amount" gorm:"type:decimal(12,2);not null;default:0;comment:总金额"`
+	Status        int8                `json:"status" gorm:"not null;default:1;comment:状态 1=待确认 2=已确认 3=已完成 4=已取消"`
+	ReceiveStatus int8                `json:"receive_status" gorm:"not null;default:0;comment:收货状态 0=未收货 1=部分收货 2=已收齐 3=短收结案"`
+	Remark        string              `json:"remark" gorm:"type:varchar(500);comment:备注"`
+	OrderDate     time.Time           `json:"order_date" gorm:"type:date;not null;comment:报菜日期"`
+	CreatedBy     uint                `json:"created_by" gorm:"not null;comment:创建人ID"`
+	Creator       *User               `json:"creator,omitempty" gorm:"foreignKey:CreatedBy"`
+	Items         []PurchaseOrderItem `json:"items,omitempty" gorm:"foreignKey:OrderID"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
 }
 
 func (PurchaseOrder) TableName() string {
@@ -25,18 +26,20 @@ func (PurchaseOrder) TableName() string {
 
 // PurchaseOrderItem 采购单明细
 type PurchaseOrderItem struct {
-	ID         uint             `json:"id" gorm:"primaryKey;autoIncrement"`
-	OrderID    uint             `json:"order_id" gorm:"not null;index;comment:采购单ID"`
-	SupplierID uint             `json:"supplier_id" gorm:"not null;index;comment:供应商ID"`
-	Supplier   *Supplier        `json:"supplier,omitempty" gorm:"foreignKey:SupplierID"`
-	ProductID  uint             `json:"product_id" gorm:"not null;comment:商品ID"`
-	Product    *SupplierProduct `json:"product,omitempty" gorm:"foreignKey:ProductID"`
-	Quantity   float64          `json:"quantity" gorm:"type:decimal(10,2);not null;comment:数量"`
-	UnitPrice  float64          `json:"unit_price" gorm:"type:decimal(10,2);not null;comment:单价"`
-	Amount     float64          `json:"amount" gorm:"type:decimal(12,2);not null;comment:金额"`
-	Remark     string           `json:"remark" gorm:"type:varchar(200);comment:备注"`
-	CreatedAt  time.Time        `json:"created_at"`
-	UpdatedAt  time.Time        `json:"updated_at"`
+	ID               uint             `json:"id" gorm:"primaryKey;autoIncrement"`
+	OrderID          uint             `json:"order_id" gorm:"not null;index;comment:采购单ID"`
+	SupplierID       uint             `json:"supplier_id" gorm:"not null;index;comment:供应商ID"`
+	Supplier         *Supplier        `json:"supplier,omitempty" gorm:"foreignKey:SupplierID"`
+	ProductID        uint             `json:"product_id" gorm:"not null;comment:商品ID"`
+	Product          *SupplierProduct `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	Quantity         float64          `json:"quantity" gorm:"type:decimal(10,2);not null;comment:数量"`
+	UnitPrice        float64          `json:"unit_price" gorm:"type:decimal(10,2);not null;comment:单价"`
+	Amount           float64          `json:"amount" gorm:"type:decimal(12,2);not null;comment:金额"`
+	ReceivedQuantity float64          `json:"received_quantity" gorm:"type:decimal(10,2);not null;default:0;comment:累计实收数量"`
+	ReceivedAmount   float64          `json:"received_amount" gorm:"type:decimal(12,2);not null;default:0;comment:累计实收金额（按实际单价）"`
+	Remark           string           `json:"remark" gorm:"type:varchar(200);comment:备注"`
+	CreatedAt        time.Time        `json:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at"`
 }
 
 func (PurchaseOrderItem) TableName() string {
@@ -86,6 +89,14 @@ const (
 	PurchaseStatusCancelled int8 = 4 // 已取消
 )
 
+// 采购单收货状态
+const (
+	PurchaseReceiveNone        int8 = 0 // 未收货
+	PurchaseReceivePartial     int8 = 1 // 部分收货
+	PurchaseReceiveFull        int8 = 2 // 已收齐
+	PurchaseReceiveClosedShort int8 = 3 // 短收结案
+)
+
 // SupplierGroupedItems 按供应商分组的采购单明细
 type SupplierGroupedItems struct {
 	SupplierID   uint                        `json:"supplier_id"`
@@ -0,0 +1,117 @@
+package model
+
+import "time"
+
+// PurchaseReceipt 采购收货单：一次到货登记一张，按实收数量生成采购入库单；一张采购单可分多次收货。
+type PurchaseReceipt struct {
+	ID               uint                  `json:"id" gorm:"primaryKey;autoIncrement"`
+	ReceiptNo        string                `json:"receipt_no" gorm:"type:varchar(50);uniqueIndex;not null;comment:收货单号"`
+	PurchaseOrderID  uint                  `json:"purchase_order_id" gorm:"not null;index;comment:采购单ID"`
+	PurchaseOrderNo  string                `json:"purchase_order_no" gorm:"type:varchar(32);comment:采购单号"`
+	StoreID          uint                  `json:"store_id" gorm:"not null;index;comment:门店ID"`
+	InventoryOrderNo string                `json:"inventory_order_no" gorm:"type:varchar(50);comment:采购入库单号"`
+	TotalQuantity    float64               `json:"total_quantity" gorm:"type:decimal(10,2);not null;default:0;comment:实收总数量"`
+	TotalAmount      float64               `json:"total_amount" gorm:"type:decimal(12,2);not null;default:0;comment:实收总金额"`
+	PriceVariance    float64               `json:"price_variance" gorm:"type:decimal(12,2);not null;default:0;comment:价格差异金额（实际-订单价）"`
+	Remark           string                `json:"remark" gorm:"type:varchar(500);comment:备注"`
+	OperatorID       uint                  `json:"operator_id" gorm:"not null;comment:收货人ID"`
+	OperatorName     string                `json:"operator_name" gorm:"type:varchar(50);comment:收货人姓名"`
+	CreatedAt        time.Time             `json:"created_at"`
+	Items            []PurchaseReceiptItem `json:"items,omitempty" gorm:"foreignKey:ReceiptID"`
+}
+
+func (PurchaseReceipt) TableName() string {
+	return "purchase_receipts"
+}
+
+// PurchaseReceiptItem 收货明细，数量单位与采购单明细一致
+type PurchaseReceiptItem struct {
+	ID             uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	ReceiptID      uint       `json:"receipt_id" gorm:"not null;index;comment:收货单ID"`
+	OrderItemID    uint       `json:"order_item_id" gorm:"not null;index;comment:采购单明细ID"`
+	SupplierID     uint       `json:"supplier_id" gorm:"not null;index;comment:供应商ID"`
+	ProductID      uint       `json:"product_id" gorm:"not null;comment:商品ID"`
+	ProductName    string     `json:"product_name" gorm:"type:varchar(200);comment:商品名称"`
+	Unit           string     `json:"unit" gorm:"type:varchar(20);comment:单位"`
+	Quantity       float64    `json:"quantity" gorm:"type:decimal(10,2);not null;comment:实收数量"`
+	OrderPrice     float64    `json:"order_price" gorm:"type:decimal(10,2);not null;default:0;comment:订单单价"`
+	UnitPrice      float64    `json:"unit_price" gorm:"type:decimal(10,2);not null;default:0;comment:实际单价"`
+	Amount         float64    `json:"amount" gorm:"type:decimal(12,2);not null;default:0;comment:实收金额"`
+	PriceVariance  float64    `json:"price_variance" gorm:"type:decimal(12,2);not null;default:0;comment:价格差异金额"`
+	ProductionDate *time.Time `json:"production_date" gorm:"type:date;comment:生产日期"`
+	ExpiryDate     *time.Time `json:"expiry_date" gorm:"type:date;comment:到期日"`
+	Remark         string     `json:"remark" gorm:"type:varchar(200);comment:备注"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func (PurchaseReceiptItem) TableName() string {
+	return "purchase_receipt_items"
+}
+
+// ReceivePurchaseOrderReq 采购收货请求
+type ReceivePurchaseOrderReq struct {
+	Remark string                        `json:"remark" binding:"max=500"`
+	Items  []ReceivePurchaseOrderItemReq `json:"items" binding:"required,min=1,dive"`
+}
+
+// ReceivePurchaseOrderItemReq 收货明细；UnitPrice 不传时按订单单价入账。
+type ReceivePurchaseOrderItemReq struct {
+	OrderItemID    uint     `json:"order_item_id" binding:"required"`
+	Quantity       float64  `json:"quantity" binding:"required,gt=0"`
+	UnitPrice      *float64 `json:"unit_price" binding:"omitempty,gte=0"`
+	ProductionDate string   `json:"production_date"`
+	ExpiryDate     string   `json:"expiry_date"`
+	Remark         string   `json:"remark" binding:"max=200"`
+}
+
+// ClosePurchaseOrderShortReq 短收结案请求
+type ClosePurchaseOrderShortReq struct {
+	Reason string `json:"reason" binding:"required,max=200"`
+}
+
+// PurchaseVarianceReq 采购收货差异报表查询（按报菜日期）
+type PurchaseVarianceReq struct {
+	StoreID    uint   `form:"store_id"`
+	SupplierID uint   `form:"supplier_id"`
+	StartDate  string `form:"start_date" binding:"required"`
+	EndDate    string `form:"end_date" binding:"required"`
+}
+
+// PurchaseVarianceRow 按供应商+商品汇总的订货与实收
+type PurchaseVarianceRow struct {
+	SupplierID           uint    `json:"supplier_id"`
+	SupplierName         string  `json:"supplier_name"`
+	ProductID            uint    `json:"product_id"`
+	ProductName          string  `json:"product_name"`
+	Unit                 string  `json:"unit"`
+	OrderedQuantity      float64 `json:"ordered_quantity"`
+	ReceivedQuantity     float64 `json:"received_quantity"`
+	ShortQuantity        float64 `json:"short_quantity"` // 已结案采购单中未到货的数量
+	OpenQuantity         float64 `json:"open_quantity"`  // 未结案采购单中待到货的数量
+	OrderedAmount        float64 `json:"ordered_amount"`
+	ReceivedAmount       float64 `json:"received_amount"`
+	ShortAmount          float64 `json:"short_amount"` // 短收数量按订单单价折算
+	OpenAmount           float64 `json:"open_amount"`  // 待到货数量按订单单价折算
+	ReceivedAtOrderPrice float64 `json:"-"`
+	PriceVariance        float64 `json:"price_variance"` // 实收金额 - 实收数量 × 订单单价
+}
+
+// PurchaseSupplierVariance 单个供应商的收货差异
+type PurchaseSupplierVariance struct {
+	SupplierID     uint                  `json:"supplier_id"`
+	SupplierName   string                `json:"supplier_name"`
+	OrderedAmount  float64               `json:"ordered_amount"`
+	ReceivedAmount float64               `json:"received_amount"`
+	ShortAmount    float64               `json:"short_amount"`
+	OpenAmount     float64               `json:"open_amount"`
+	PriceVariance  float64               `json:"price_variance"`
+	FillRate       float64               `json:"fill_rate"` // 到货率：实收（按订单单价）/ 不含待到货部分的订货金额
+	Products       []PurchaseVarianceRow `json:"products"`
+}
+
+// PurchaseVarianceReport 采购收货差异报表
+type PurchaseVarianceReport struct {
+	StartDate string                     `json:"start_date"`
+	EndDate   string                     `json:"end_date"`
+	Suppliers []PurchaseSupplierVariance `json:"suppliers"`
+}
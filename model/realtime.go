@@ -0,0 +1,69 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 实时推送的门店资源，主题格式为 store:{门店ID}:{资源}
+const (
+	RealtimeAccounts       = "accounts"        // 门店记账
+	RealtimeInventory      = "inventory"       // 库存：created 的 ID 为出入库单，updated 的 ID 为库存记录
+	RealtimePreOrders      = "pre-orders"      // 预订单
+	RealtimePurchaseOrders = "purchase-orders" // 采购单
+)
+
+// RealtimeTopicHQAlerts 总部告警主题（新采购单、记账作废等），仅全部数据范围的账号可订阅
+const RealtimeTopicHQAlerts = "hq:alerts"
+
+// 实时事件动作
+const (
+	RealtimeActionCreated  = "created"
+	RealtimeActionUpdated  = "updated"
+	RealtimeActionCanceled = "canceled"
+	RealtimeActionDeleted  = "deleted"
+)
+
+// RealtimeStoreTopic 门店资源主题
+func RealtimeStoreTopic(storeID uint, resource string) string {
+	return fmt.Sprintf("store:%d:%s", storeID, resource)
+}
+
+// ParseRealtimeStoreTopic 解析门店资源主题，格式或资源不合法时 ok 为 false
+func ParseRealtimeStoreTopic(topic string) (storeID uint, resource string, ok bool) {
+	parts := strings.Split(topic, ":")
+	if len(parts) != 3 || parts[0] != "store" {
+		return 0, "", false
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil || id == 0 {
+		return 0, "", false
+	}
+	switch parts[2] {
+	case RealtimeAccounts, RealtimeInventory, RealtimePreOrders, RealtimePurchaseOrders:
+		return uint(id), parts[2], true
+	}
+	return 0, "", false
+}
+
+// RealtimeEvent 推送给订阅端的业务变更通知，客户端收到后按需重新拉取列表或详情
+type RealtimeEvent struct {
+	Topic    string `json:"topic"`
+	Resource string `json:"resource"`
+	Action   string `json:"action"`
+	StoreID  uint   `json:"store_id"`
+	ID       uint   `json:"id"`
+	No       string `json:"no,omitempty"`
+}
+
+// DataChangedEvent 业务数据变更事件（记账、出入库、预订单、采购单），发布到事件总线后按主题推送
+type DataChangedEvent struct {
+	Resource  string // 资源，见 Realtime* 常量
+	Action    string // 动作，见 RealtimeAction* 常量
+	StoreID   uint
+	CreatorID uint // 仅本人数据范围的账号只收到自己创建的数据；为 0 表示不区分创建人
+	ID        uint
+	No        string
+	Alert     bool // 同时推送总部告警主题
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	RechargePackageEnabled  = 1
+	RechargePackageDisabled = 2
+)
+
+// RechargePackage 充值套餐（总部配置）：充值满 PayAmount 赠送 GiftAmount，会员首次充值额外赠送 FirstGiftAmount。
+// 未配置适用门店时全部门店可用；StartAt/EndAt 为空表示不限时间
+type RechargePackage struct {
+	ID              uint                   `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name            string                 `json:"name" gorm:"type:varchar(100);not null;comment:套餐名称"`
+	PayAmount       decimal.Decimal        `json:"payAmount" gorm:"type:decimal(10,2);not null;comment:充值金额门槛"`
+	GiftAmount      decimal.Decimal        `json:"giftAmount" gorm:"type:decimal(10,2);not null;default:0;comment:赠送金额"`
+	FirstGiftAmount decimal.Decimal        `json:"firstGiftAmount" gorm:"type:decimal(10,2);not null;default:0;comment:首充额外赠送金额"`
+	StartAt         *time.Time             `json:"startAt" gorm:"comment:活动开始时间，空表示不限"`
+	EndAt           *time.Time             `json:"endAt" gorm:"comment:活动结束时间，空表示不限"`
+	Status          int                    `json:"status" gorm:"not null;default:1;comment:状态 1=启用 2=停用"`
+	Remark          string                 `json:"remark" gorm:"type:varchar(500);comment:备注"`
+	CreatedAt       time.Time              `json:"createdAt"`
+	UpdatedAt       time.Time              `json:"updatedAt"`
+	Stores          []RechargePackageStore `json:"stores,omitempty" gorm:"foreignKey:PackageID"`
+}
+
+func (RechargePackage) TableName() string {
+	return "recharge_packages"
+}
+
+// RechargePackageStore 充值套餐适用门店
+type RechargePackageStore struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	PackageID uint      `json:"packageId" gorm:"not null;index;comment:充值套餐ID"`
+	StoreID   uint      `json:"storeId" gorm:"not null;index;comment:门店ID"`
+	CreatedAt time.Time `json:"createdAt"`
+	Store     *Store    `json:"store,omitempty" gorm:"foreignKey:StoreID"`
+}
+
+func (RechargePackageStore) TableName() string {
+	return "recharge_package_stores"
+}
+
+type UpsertRechargePackageReq struct {
+	Name            string          `json:"name" binding:"required,max=100"`
+	PayAmount       decimal.Decimal `json:"payAmount" binding:"required"`
+	GiftAmount      decimal.Decimal `json:"giftAmount"`
+	FirstGiftAmount decimal.Decimal `json:"firstGiftAmount"`
+	StartAt         *time.Time      `json:"startAt"`
+	EndAt           *time.Time      `json:"endAt"`
+	Status          int             `json:"status" binding:"omitempty,oneof=1 2"`
+	Remark          string          `json:"remark" binding:"max=500"`
+	StoreIDs        []uint          `json:"storeIds"` // 为空表示全部门店可用
+}
+
+type ListRechargePackageReq struct {
+	Status int `form:"status" binding:"omitempty,oneof=1 2"`
+}
+
+type RechargePackageReportReq struct {
+	StoreID   uint   `form:"storeId"`
+	StartDate string `form:"startDate"`
+	EndDate   string `form:"endDate"`
+}
+
+// RechargePackageReportRow 充值活动统计：按套餐汇总已支付充值单，PackageID=0 为未使用套餐的充值
+type RechargePackageReportRow struct {
+	PackageID   uint            `json:"packageId"`
+	PackageName string          `json:"packageName"`
+	OrderCount  int64           `json:"orderCount"`
+	FirstCount  int64           `json:"firstCount"`
+	PayAmount   decimal.Decimal `json:"payAmount"`
+	GiftAmount  decimal.Decimal `json:"giftAmount"`
+	FirstGift   decimal.Decimal `json:"firstGift"`
+}
+
+// RechargePackageReport 充值活动统计
+type RechargePackageReport struct {
+	Rows       []RechargePackageReportRow `json:"rows"`
+	OrderCount int64                      `json:"orderCount"`
+	PayAmount  decimal.Decimal            `json:"payAmount"`
+	GiftAmount decimal.Decimal            `json:"giftAmount"`
+	// 赠送负债：会员当前余额按历史累计赠送占充值到账比例估算的赠送部分
+	MemberBalance  decimal.Decimal `json:"memberBalance"`
+	BonusLiability decimal.Decimal `json:"bonusLiability"`
+}
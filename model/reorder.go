@@ -0,0 +1,106 @@
+package model
+
+import "time"
+
+// 补货目标依据
+const (
+	ReorderBasisFixed       = "fixed"       // 固定补货目标（最高库存）
+	ReorderBasisConsumption = "consumption" // 按日均消耗 × 覆盖天数计算补货目标
+)
+
+// StoreProductReorderSetting 门店商品补货设置：库存（含在途采购）不高于补货点时，建议补到目标数量。
+// 数量均为商品库存单位（与采购单数量单位一致）。
+type StoreProductReorderSetting struct {
+	ID             uint             `json:"id" gorm:"primaryKey;autoIncrement"`
+	StoreID        uint             `json:"store_id" gorm:"not null;uniqueIndex:uk_reorder_settings_store_product,priority:1;comment:门店ID"`
+	ProductID      uint             `json:"product_id" gorm:"not null;uniqueIndex:uk_reorder_settings_store_product,priority:2;comment:商品ID"`
+	SupplierID     uint             `json:"supplier_id" gorm:"not null;index;comment:供应商ID"`
+	ReorderPoint   float64          `json:"reorder_point" gorm:"type:decimal(10,2);not null;default:0;comment:补货点（最低库存）"`
+	TargetQuantity float64          `json:"target_quantity" gorm:"type:decimal(10,2);not null;default:0;comment:补货目标（最高库存）"`
+	Basis          string           `json:"basis" gorm:"type:varchar(20);not null;default:'fixed';comment:目标依据 fixed=固定目标 consumption=日均消耗"`
+	CoverDays      int              `json:"cover_days" gorm:"not null;default:0;comment:按日均消耗时目标覆盖的天数"`
+	LeadDays       int              `json:"lead_days" gorm:"not null;default:0;comment:按日均消耗时的到货天数"`
+	SafetyStock    float64          `json:"safety_stock" gorm:"type:decimal(10,2);not null;default:0;comment:按日均消耗时的安全库存"`
+	Enabled        bool             `json:"enabled" gorm:"not null;default:true;comment:是否启用"`
+	Remark         string           `json:"remark" gorm:"type:varchar(200);comment:备注"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	Product        *SupplierProduct `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+}
+
+func (StoreProductReorderSetting) TableName() string {
+	return "store_product_reorder_settings"
+}
+
+// UpsertReorderSettingReq 新增或修改门店商品补货设置
+type UpsertReorderSettingReq struct {
+	StoreID        uint    `json:"store_id"` // 仅总部账号可指定门店
+	ProductID      uint    `json:"product_id" binding:"required"`
+	ReorderPoint   float64 `json:"reorder_point" binding:"gte=0"`
+	TargetQuantity float64 `json:"target_quantity" binding:"gte=0"`
+	Basis          string  `json:"basis" binding:"omitempty,oneof=fixed consumption"`
+	CoverDays      int     `json:"cover_days" binding:"gte=0,lte=365"`
+	LeadDays       int     `json:"lead_days" binding:"gte=0,lte=365"`
+	SafetyStock    float64 `json:"safety_stock" binding:"gte=0"`
+	Enabled        *bool   `json:"enabled"`
+	Remark         string  `json:"remark" binding:"max=200"`
+}
+
+// ListReorderSettingReq 补货设置列表查询
+type ListReorderSettingReq struct {
+	StoreID    uint   `form:"store_id"`
+	SupplierID uint   `form:"supplier_id"`
+	Keyword    string `form:"keyword"`
+	Page       int    `form:"page,default=1" binding:"min=1"`
+	PageSize   int    `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// ReorderSuggestionReq 补货建议查询；ConsumptionDays 为统计日均消耗的天数，0 时取配置默认值。
+type ReorderSuggestionReq struct {
+	StoreID         uint `form:"store_id"`
+	SupplierID      uint `form:"supplier_id"`
+	ConsumptionDays int  `form:"consumption_days" binding:"gte=0,lte=180"`
+}
+
+// ProductQuantity 按商品和单位汇总的数量
+type ProductQuantity struct {
+	ProductID uint    `json:"product_id"`
+	Unit      string  `json:"unit"`
+	Quantity  float64 `json:"quantity"`
+}
+
+// ReorderSuggestionLine 单个商品的补货计算
+type ReorderSuggestionLine struct {
+	ProductID         uint    `json:"product_id"`
+	ProductName       string  `json:"product_name"`
+	SupplierID        uint    `json:"supplier_id"`
+	Unit              string  `json:"unit"`
+	OnHand            float64 `json:"on_hand"`            // 当前库存
+	OnOrder           float64 `json:"on_order"`           // 未完成采购单在途数量
+	AvgDailyUsage     float64 `json:"avg_daily_usage"`    // 日均消耗
+	ReorderPoint      float64 `json:"reorder_point"`      // 生效的补货点
+	TargetQuantity    float64 `json:"target_quantity"`    // 生效的补货目标
+	Basis             string  `json:"basis"`              // 目标依据
+	SuggestedQuantity float64 `json:"suggested_quantity"` // 建议采购数量
+	UnitPrice         float64 `json:"unit_price"`         // 采购单价
+	Amount            float64 `json:"amount"`             // 建议采购金额
+}
+
+// ReorderSupplierDraft 按供应商分组的采购单草稿，Draft 可由店长调整后直接提交创建采购单
+type ReorderSupplierDraft struct {
+	SupplierID   uint                    `json:"supplier_id"`
+	SupplierName string                  `json:"supplier_name"`
+	SubTotal     float64                 `json:"sub_total"`
+	Lines        []ReorderSuggestionLine `json:"lines"`
+	Draft        CreatePurchaseOrderReq  `json:"draft"`
+}
+
+// ReorderSuggestion 门店补货建议
+type ReorderSuggestion struct {
+	StoreID         uint                    `json:"store_id"`
+	StoreName       string                  `json:"store_name"`
+	ConsumptionDays int                     `json:"consumption_days"`
+	TotalAmount     float64                 `json:"total_amount"`
+	Suppliers       []ReorderSupplierDraft  `json:"suppliers"`
+	Lines           []ReorderSuggestionLine `json:"lines"` // 所有已设置商品的计算明细（含无需补货的商品）
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // DashboardStats 统计面板数据
 type DashboardStats struct {
 	Inventory InventoryStats `json:"inventory"` // 库存统计
@@ -143,3 +145,53 @@ type HomeChartsStats struct {
 	Radar     []RadarMetricItem     `json:"radar"`    // 雷达图：经营指标
 	Overview  BusinessOverviewStats `json:"overview"` // 汇总卡片
 }
+
+// ExpiringLotRow 临期/过期批次查询行
+type ExpiringLotRow struct {
+	LotID       uint      `json:"lot_id"`
+	StoreID     uint      `json:"store_id"`
+	StoreName   string    `json:"store_name"`
+	ProductID   uint      `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	ExpiryDate  time.Time `json:"-"`
+	Quantity    float64   `json:"quantity"`
+	Unit        string    `json:"unit"`
+	CostPrice   float64   `json:"cost_price" gorm:"-"` // 基础单位成本价
+}
+
+// ExpiryLotItem 临期/过期批次明细
+type ExpiryLotItem struct {
+	LotID       uint    `json:"lot_id"`
+	ProductID   uint    `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	ExpiryDate  string  `json:"expiry_date"`
+	DaysLeft    int     `json:"days_left"` // 距到期天数，负数表示已过期天数
+	Expired     bool    `json:"expired"`
+	Quantity    float64 `json:"quantity"`
+	Unit        string  `json:"unit"`
+	CostPrice   float64 `json:"cost_price"`
+	CostAmount  float64 `json:"cost_amount"`
+}
+
+// ExpiryStoreStats 单门店临期/过期库存汇总
+type ExpiryStoreStats struct {
+	StoreID              uint            `json:"store_id"`
+	StoreName            string          `json:"store_name"`
+	ExpiredQuantity      float64         `json:"expired_quantity"`
+	ExpiredCostAmount    float64         `json:"expired_cost_amount"`
+	NearExpiryQuantity   float64         `json:"near_expiry_quantity"`
+	NearExpiryCostAmount float64         `json:"near_expiry_cost_amount"`
+	Items                []ExpiryLotItem `json:"items"`
+}
+
+// ExpiryStats 临期/过期库存统计
+type ExpiryStats struct {
+	WarningDays          int                `json:"warning_days"`
+	Today                string             `json:"today"`
+	Cutoff               string             `json:"cutoff"` // 临期截止日期（含）
+	ExpiredQuantity      float64            `json:"expired_quantity"`
+	ExpiredCostAmount    float64            `json:"expired_cost_amount"`
+	NearExpiryQuantity   float64            `json:"near_expiry_quantity"`
+	NearExpiryCostAmount float64            `json:"near_expiry_cost_amount"`
+	Stores               []ExpiryStoreStats `json:"stores"`
+}
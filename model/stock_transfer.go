@@ -0,0 +1,124 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 调拨单状态
+const (
+	StockTransferStatusInTransit = 1 // 在途（已从调出门店出库）
+	StockTransferStatusPartial   = 2 // 部分收货
+	StockTransferStatusReceived  = 3 // 已收货（含短收结单）
+	StockTransferStatusCanceled  = 4 // 已撤销（在途库存退回调出门店）
+)
+
+// StockTransfer 门店间调拨单：发货时调出门店出库，货物处于在途，调入门店确认收货后入库。
+type StockTransfer struct {
+	ID             uint                `json:"id" gorm:"primaryKey;autoIncrement"`
+	TransferNo     string              `json:"transfer_no" gorm:"type:varchar(50);uniqueIndex;not null;comment:调拨单号"`
+	FromStoreID    uint                `json:"from_store_id" gorm:"not null;index;comment:调出门店ID"`
+	FromStoreName  string              `json:"from_store_name" gorm:"type:varchar(100);comment:调出门店名称"`
+	ToStoreID      uint                `json:"to_store_id" gorm:"not null;index;comment:调入门店ID"`
+	ToStoreName    string              `json:"to_store_name" gorm:"type:varchar(100);comment:调入门店名称"`
+	Status         int8                `json:"status" gorm:"not null;default:1;index;comment:状态 1=在途 2=部分收货 3=已收货 4=已撤销"`
+	TotalQuantity  float64             `json:"total_quantity" gorm:"type:decimal(10,2);not null;default:0;comment:调出基础库存总数量"`
+	ReceivedTotal  float64             `json:"received_total" gorm:"type:decimal(10,2);not null;default:0;comment:已收基础库存总数量"`
+	ItemCount      int                 `json:"item_count" gorm:"not null;default:0;comment:商品种类数"`
+	HasDiscrepancy bool                `json:"has_discrepancy" gorm:"not null;default:false;comment:是否存在收货差异"`
+	OutOrderNo     string              `json:"out_order_no" gorm:"type:varchar(50);comment:调出出库单号"`
+	Remark         string              `json:"remark" gorm:"type:varchar(500);comment:备注"`
+	DispatcherID   uint                `json:"dispatcher_id" gorm:"not null;comment:发货人ID"`
+	DispatcherName string              `json:"dispatcher_name" gorm:"type:varchar(50);comment:发货人姓名"`
+	DispatchedAt   time.Time           `json:"dispatched_at" gorm:"comment:发货时间"`
+	ReceiverID     *uint               `json:"receiver_id,omitempty" gorm:"comment:最近收货人ID"`
+	ReceiverName   string              `json:"receiver_name" gorm:"type:varchar(50);comment:最近收货人姓名"`
+	ReceivedAt     *time.Time          `json:"received_at,omitempty" gorm:"comment:收货完成时间"`
+	CanceledAt     *time.Time          `json:"canceled_at,omitempty" gorm:"comment:撤销时间"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt      `json:"-" gorm:"index"`
+	Items          []StockTransferItem `json:"items,omitempty" gorm:"foreignKey:TransferID"`
+}
+
+func (StockTransfer) TableName() string {
+	return "stock_transfers"
+}
+
+// StockTransferItem 调拨单明细。数量字段中 Quantity 为所选规格数量，其余均为基础库存单位。
+// 调出时按实际扣减的批次拆行，ProductionDate/ExpiryDate 随收货或撤销入库带入对应批次。
+type StockTransferItem struct {
+	ID                  uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	TransferID          uint           `json:"transfer_id" gorm:"not null;index;comment:调拨单ID"`
+	ProductID           uint           `json:"product_id" gorm:"not null;index;comment:商品ID"`
+	ProductName         string         `json:"product_name" gorm:"type:varchar(200);comment:商品名称"`
+	Unit                string         `json:"unit" gorm:"type:varchar(50);comment:选择规格单位"`
+	Quantity            float64        `json:"quantity" gorm:"type:decimal(10,2);not null;comment:选择规格数量"`
+	BaseQuantity        float64        `json:"base_quantity" gorm:"type:decimal(10,2);not null;comment:调出基础库存数量"`
+	BaseUnit            string         `json:"base_unit" gorm:"type:varchar(20);comment:基础库存单位"`
+	ProductionDate      *time.Time     `json:"production_date" gorm:"type:date;comment:批次生产日期"`
+	ExpiryDate          *time.Time     `json:"expiry_date" gorm:"type:date;comment:批次到期日"`
+	ReceivedQuantity    float64        `json:"received_quantity" gorm:"type:decimal(10,2);not null;default:0;comment:已收基础库存数量"`
+	DiscrepancyQuantity float64        `json:"discrepancy_quantity" gorm:"type:decimal(10,2);not null;default:0;comment:差异数量（调出-实收）"`
+	DiscrepancyReason   string         `json:"discrepancy_reason" gorm:"type:varchar(200);comment:差异原因"`
+	Remark              string         `json:"remark" gorm:"type:varchar(500);comment:备注"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (StockTransferItem) TableName() string {
+	return "stock_transfer_items"
+}
+
+// PendingQuantity 在途未收的基础库存数量
+func (i StockTransferItem) PendingQuantity() float64 {
+	pending := i.BaseQuantity - i.ReceivedQuantity - i.DiscrepancyQuantity
+	if pending < 0 {
+		return 0
+	}
+	return pending
+}
+
+// CreateStockTransferReq 创建调拨单（发货）请求
+type CreateStockTransferReq struct {
+	FromStoreID uint                         `json:"from_store_id"` // 仅总部账号可指定调出门店
+	ToStoreID   uint                         `json:"to_store_id" binding:"required"`
+	Remark      string                       `json:"remark" binding:"max=500"`
+	Items       []CreateStockTransferItemReq `json:"items" binding:"required,min=1,dive"`
+}
+
+// CreateStockTransferItemReq 调拨单明细请求
+type CreateStockTransferItemReq struct {
+	ProductID uint    `json:"product_id" binding:"required"`
+	Unit      string  `json:"unit" binding:"max=50"`
+	Quantity  float64 `json:"quantity" binding:"required,gt=0"`
+	Remark    string  `json:"remark" binding:"max=500"`
+}
+
+// ReceiveStockTransferReq 调入门店收货请求；可多次收货，Finish=true 时未到数量记为差异并结单。
+type ReceiveStockTransferReq struct {
+	Items  []ReceiveStockTransferItemReq `json:"items" binding:"dive"`
+	Finish bool                          `json:"finish"`
+	Remark string                        `json:"remark" binding:"max=500"`
+}
+
+// ReceiveStockTransferItemReq 收货明细，ReceivedQuantity 为本次实收的基础库存数量。
+type ReceiveStockTransferItemReq struct {
+	ItemID            uint    `json:"item_id" binding:"required"`
+	ReceivedQuantity  float64 `json:"received_quantity" binding:"gte=0"`
+	DiscrepancyReason string  `json:"discrepancy_reason" binding:"max=200"`
+}
+
+// ListStockTransferReq 调拨单列表查询
+type ListStockTransferReq struct {
+	StoreID   uint   `form:"store_id"`
+	Direction string `form:"direction" binding:"omitempty,oneof=in out"` // in=调入 out=调出，为空表示全部
+	Status    int8   `form:"status"`
+	Keyword   string `form:"keyword"`
+	StartDate string `form:"start_date"`
+	EndDate   string `form:"end_date"`
+	Page      int    `form:"page,default=1" binding:"min=1"`
+	PageSize  int    `form:"page_size,default=20" binding:"min=1,max=100"`
+}
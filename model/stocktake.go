@@ -0,0 +1,129 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 盘点单状态
+const (
+	StocktakeStatusCounting = 1 // 盘点中（可多次录入实盘数量）
+	StocktakeStatusPosted   = 2 // 已过账（差异已生成库存调整单）
+	StocktakeStatusCanceled = 3 // 已作废
+)
+
+// Stocktake 门店盘点单：开单时快照系统库存，分多次录入实盘数量，复核后将差异过账为一张库存调整单。
+type Stocktake struct {
+	ID            uint            `json:"id" gorm:"primaryKey;autoIncrement"`
+	StocktakeNo   string          `json:"stocktake_no" gorm:"type:varchar(50);uniqueIndex;not null;comment:盘点单号"`
+	StoreID       uint            `json:"store_id" gorm:"not null;index;comment:门店ID"`
+	StoreName     string          `json:"store_name" gorm:"type:varchar(100);comment:门店名称"`
+	Status        int8            `json:"status" gorm:"not null;default:1;index;comment:状态 1=盘点中 2=已过账 3=已作废"`
+	ItemCount     int             `json:"item_count" gorm:"not null;default:0;comment:盘点商品数"`
+	CountedCount  int             `json:"counted_count" gorm:"not null;default:0;comment:已录入实盘的商品数"`
+	VarianceCount int             `json:"variance_count" gorm:"not null;default:0;comment:过账时存在差异的商品数"`
+	GainAmount    float64         `json:"gain_amount" gorm:"type:decimal(12,2);not null;default:0;comment:盘盈成本金额"`
+	LossAmount    float64         `json:"loss_amount" gorm:"type:decimal(12,2);not null;default:0;comment:盘亏成本金额"`
+	AdjustOrderNo string          `json:"adjust_order_no" gorm:"type:varchar(50);comment:库存调整单号"`
+	Remark        string          `json:"remark" gorm:"type:varchar(500);comment:备注"`
+	CreatorID     uint            `json:"creator_id" gorm:"not null;comment:开单人ID"`
+	CreatorName   string          `json:"creator_name" gorm:"type:varchar(50);comment:开单人姓名"`
+	PosterID      *uint           `json:"poster_id,omitempty" gorm:"comment:过账人ID"`
+	PosterName    string          `json:"poster_name" gorm:"type:varchar(50);comment:过账人姓名"`
+	PostedAt      *time.Time      `json:"posted_at,omitempty" gorm:"comment:过账时间"`
+	CanceledAt    *time.Time      `json:"canceled_at,omitempty" gorm:"comment:作废时间"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt  `json:"-" gorm:"index"`
+	Items         []StocktakeItem `json:"items,omitempty" gorm:"foreignKey:StocktakeID"`
+}
+
+func (Stocktake) TableName() string {
+	return "stocktakes"
+}
+
+// StocktakeItem 盘点明细，数量均为基础库存单位。CostPrice 为开单时的基础单位成本价。
+type StocktakeItem struct {
+	ID               uint             `json:"id" gorm:"primaryKey;autoIncrement"`
+	StocktakeID      uint             `json:"stocktake_id" gorm:"not null;index;uniqueIndex:uk_stocktake_items_product,priority:1;comment:盘点单ID"`
+	ProductID        uint             `json:"product_id" gorm:"not null;uniqueIndex:uk_stocktake_items_product,priority:2;comment:商品ID"`
+	ProductName      string           `json:"product_name" gorm:"type:varchar(200);comment:商品名称"`
+	BaseUnit         string           `json:"base_unit" gorm:"type:varchar(20);comment:基础库存单位"`
+	SystemQuantity   float64          `json:"system_quantity" gorm:"type:decimal(10,2);not null;default:0;comment:开单时系统库存"`
+	CountedQuantity  float64          `json:"counted_quantity" gorm:"type:decimal(10,2);not null;default:0;comment:实盘数量"`
+	Counted          bool             `json:"counted" gorm:"not null;default:false;comment:是否已录入实盘"`
+	VarianceQuantity float64          `json:"variance_quantity" gorm:"type:decimal(10,2);not null;default:0;comment:差异数量（实盘-系统）"`
+	CostPrice        float64          `json:"cost_price" gorm:"type:decimal(10,2);not null;default:0;comment:基础单位成本价"`
+	VarianceAmount   float64          `json:"variance_amount" gorm:"type:decimal(12,2);not null;default:0;comment:差异成本金额"`
+	Remark           string           `json:"remark" gorm:"type:varchar(500);comment:备注"`
+	CreatedAt        time.Time        `json:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at"`
+	Entries          []StocktakeEntry `json:"entries,omitempty" gorm:"foreignKey:ItemID"`
+}
+
+func (StocktakeItem) TableName() string {
+	return "stocktake_items"
+}
+
+// StocktakeEntry 实盘录入记录：按规格录入，可分多次、多人录入，合计为明细实盘数量。
+type StocktakeEntry struct {
+	ID           uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	StocktakeID  uint      `json:"stocktake_id" gorm:"not null;index;comment:盘点单ID"`
+	ItemID       uint      `json:"item_id" gorm:"not null;index;comment:盘点明细ID"`
+	ProductID    uint      `json:"product_id" gorm:"not null;comment:商品ID"`
+	Unit         string    `json:"unit" gorm:"type:varchar(50);comment:录入规格单位"`
+	Quantity     float64   `json:"quantity" gorm:"type:decimal(10,2);not null;comment:录入规格数量"`
+	BaseQuantity float64   `json:"base_quantity" gorm:"type:decimal(10,2);not null;comment:折算基础库存数量"`
+	CounterID    uint      `json:"counter_id" gorm:"not null;comment:录入人ID"`
+	CounterName  string    `json:"counter_name" gorm:"type:varchar(50);comment:录入人姓名"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (StocktakeEntry) TableName() string {
+	return "stocktake_entries"
+}
+
+// CreateStocktakeReq 开启盘点请求
+type CreateStocktakeReq struct {
+	StoreID uint   `json:"store_id"` // 仅总部账号可指定门店
+	Remark  string `json:"remark" binding:"max=500"`
+}
+
+// RecordStocktakeCountReq 录入实盘数量请求
+type RecordStocktakeCountReq struct {
+	Items []RecordStocktakeCountItemReq `json:"items" binding:"required,min=1,dive"`
+}
+
+// RecordStocktakeCountItemReq 实盘录入明细；Replace=true 时先清空该商品已录入的数量（用于更正）。
+type RecordStocktakeCountItemReq struct {
+	ProductID uint    `json:"product_id" binding:"required"`
+	Unit      string  `json:"unit" binding:"max=50"`
+	Quantity  float64 `json:"quantity" binding:"gte=0"`
+	Replace   bool    `json:"replace"`
+	Remark    string  `json:"remark" binding:"max=500"`
+}
+
+// PostStocktakeReq 过账请求；ZeroUncounted=true 时未录入实盘的商品按 0 盘亏处理，否则不调整。
+type PostStocktakeReq struct {
+	ZeroUncounted bool   `json:"zero_uncounted"`
+	Remark        string `json:"remark" binding:"max=500"`
+}
+
+// ListStocktakeReq 盘点单列表查询
+type ListStocktakeReq struct {
+	StoreID   uint   `form:"store_id"`
+	Status    int8   `form:"status"`
+	Keyword   string `form:"keyword"`
+	StartDate string `form:"start_date"`
+	EndDate   string `form:"end_date"`
+	Page      int    `form:"page,default=1" binding:"min=1"`
+	PageSize  int    `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// StocktakeCountLine 服务层完成规格换算后的实盘录入行。Item 为商品信息模板，商品不在盘点快照中时据此新增明细。
+type StocktakeCountLine struct {
+	Item    StocktakeItem
+	Entry   StocktakeEntry
+	Replace bool
+}
@@ -10,7 +10,8 @@ const (
 	StoreAccountPaymentPaid   = 1 // 已支付
 	StoreAccountPaymentUnpaid = 2 // 未支付
 
-	StoreAccountSourceB2BSupplyOrder = "b2b_supply_order"
+	StoreAccountSourceB2BSupplyOrder  = "b2b_supply_order"
+	StoreAccountSourceB2BSupplyReturn = "b2b_supply_return" // 供货退货生成的负数记账单
 )
 
 // StoreAccountItemCustomProductID 手写/自定义商品明细（非系统商品），不参与库存扣减
@@ -30,6 +31,12 @@ type StoreAccount struct {
 	TotalAmount         float64                  `json:"total_amount" gorm:"type:decimal(10,2);comment:总金额"`
 	OtherExpenseAmount  float64                  `json:"other_expense_amount" gorm:"type:decimal(10,2);default:0;comment:其他支出金额"`
 	RoundAmount         float64                  `json:"round_amount" gorm:"type:decimal(10,2);not null;default:0;comment:抹零金额"`
+	PointsRedeemed      int                      `json:"points_redeemed" gorm:"not null;default:0;comment:抵扣使用积分"`
+	PointsDeduction     float64                  `json:"points_deduction" gorm:"type:decimal(10,2);not null;default:0;comment:积分抵扣金额"`
+	MemberDiscountRate  float64                  `json:"member_discount_rate" gorm:"type:decimal(5,4);not null;default:0;comment:会员等级折扣率，0表示未打折"`
+	MemberDiscount      float64                  `json:"member_discount" gorm:"type:decimal(10,2);not null;default:0;comment:会员折扣优惠金额"`
+	BalanceAmount       float64                  `json:"balance_amount" gorm:"type:decimal(10,2);not null;default:0;comment:会员余额支付金额"`
+	PayMethod           string                   `json:"pay_method" gorm:"type:varchar(20);not null;default:'';comment:余额以外部分的支付方式 cash/bank/wechat/alipay/other"`
 	IsGiftWine          int                      `json:"is_gift_wine" gorm:"not null;default:0;index;comment:是否赠酒 1=是 0=否"`
 	GiftWineProductID   uint                     `json:"gift_wine_product_id" gorm:"not null;default:0;index;comment:赠酒商品ID"`
 	GiftWineProductName string                   `json:"gift_wine_product_name" gorm:"type:varchar(200);comment:赠酒商品名称"`
@@ -59,6 +66,7 @@ type StoreAccount struct {
 	CanBindConsumables  bool                     `json:"can_bind_consumables" gorm:"-"`
 	CanCancel           bool                     `json:"can_cancel" gorm:"-"`
 	IsReadOnly          bool                     `json:"is_read_only" gorm:"-"`
+	MemberVersion       *int                     `json:"-" gorm:"-"` // 余额支付时客户端提交的会员版本号（乐观锁）
 
 	// 关联
 	Store    *Store  `json:"store,omitempty" gorm:"foreignKey:StoreID"`
@@ -70,8 +78,9 @@ func (StoreAccount) TableName() string {
 	return "store_accounts"
 }
 
+// IsB2BSupplyOrderAccount 供货单及其退货单生成的记账单，均随 B2B 单据维护，只读
 func (a *StoreAccount) IsB2BSupplyOrderAccount() bool {
-	return a != nil && a.SourceType == StoreAccountSourceB2BSupplyOrder
+	return a != nil && (a.SourceType == StoreAccountSourceB2BSupplyOrder || a.SourceType == StoreAccountSourceB2BSupplyReturn)
 }
 
 // StoreAccountItem 门店记账明细（子表）
@@ -106,6 +115,10 @@ type CreateStoreAccountReq struct {
 	Remark             string                            `json:"remark" binding:"max=500"`
 	OtherExpenseAmount float64                           `json:"other_expense_amount" binding:"gte=0"`
 	RoundAmount        float64                           `json:"round_amount" binding:"gte=0"`
+	RedeemPoints       int                               `json:"redeem_points" binding:"gte=0"`                                      // 使用积分抵扣，需关联会员
+	BalanceAmount      float64                           `json:"balance_amount" binding:"gte=0"`                                     // 使用会员余额支付的金额，需关联会员且为已支付
+	PayMethod          string                            `json:"pay_method" binding:"omitempty,oneof=cash bank wechat alipay other"` // 余额以外部分的支付方式，默认现金
+	MemberVersion      *int                              `json:"member_version"`                                                     // 会员版本号，传入时校验余额未被并发修改
 	IsSupplement       int                               `json:"is_supplement" binding:"omitempty,oneof=0 1"`
 	AccountDate        string                            `json:"account_date" binding:"omitempty"`
 	IsGiftWine         int                               `json:"is_gift_wine" binding:"omitempty,oneof=0 1"`
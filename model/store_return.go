@@ -12,6 +12,7 @@ type StoreReturn struct {
 	ReturnNo     string            `json:"return_no" gorm:"type:varchar(50);uniqueIndex;not null;comment:返厂单号"`
 	ClientReqID  *string           `json:"client_request_id" gorm:"type:varchar(64);uniqueIndex;comment:前端提交幂等ID"`
 	StoreID      uint              `json:"store_id" gorm:"not null;index;comment:门店ID"`
+	SupplierID   uint              `json:"supplier_id" gorm:"not null;default:0;index;comment:返厂供应商ID，0=未关联（不计入应付）"`
 	ReturnDate   time.Time         `json:"return_date" gorm:"type:date;index;comment:返厂日期"`
 	LogisticsFee float64           `json:"logistics_fee" gorm:"type:decimal(10,2);not null;default:0;comment:货拉拉费用"`
 	TotalDeposit float64           `json:"total_deposit" gorm:"type:decimal(10,2);not null;default:0;comment:押金总额"`
@@ -26,6 +27,7 @@ type StoreReturn struct {
 	Items        []StoreReturnItem `json:"items,omitempty" gorm:"foreignKey:ReturnID"`
 	Store        *Store            `json:"store,omitempty" gorm:"foreignKey:StoreID"`
 	Operator     *User             `json:"operator,omitempty" gorm:"foreignKey:OperatorID"`
+	Supplier     *Supplier         `json:"supplier,omitempty" gorm:"foreignKey:SupplierID"`
 }
 
 func (StoreReturn) TableName() string {
@@ -70,6 +72,7 @@ func (StoreReturnProduct) TableName() string {
 type CreateStoreReturnReq struct {
 	StoreID      uint                       `json:"store_id"`
 	ClientReqID  string                     `json:"client_request_id" binding:"max=64"`
+	SupplierID   uint                       `json:"supplier_id"` // 关联供应商后押金冲减该供应商应付
 	ReturnDate   string                     `json:"return_date" binding:"required"`
 	LogisticsFee float64                    `json:"logistics_fee" binding:"gte=0"`
 	Photos       []string                   `json:"photos" binding:"max=3,dive,max=500"`
@@ -87,6 +90,7 @@ type CreateStoreReturnItemReq struct {
 
 type UpdateStoreReturnReq struct {
 	StoreID      uint                       `json:"store_id"`
+	SupplierID   uint                       `json:"supplier_id"` // 关联供应商后押金冲减该供应商应付
 	ReturnDate   string                     `json:"return_date" binding:"required"`
 	LogisticsFee float64                    `json:"logistics_fee" binding:"gte=0"`
 	Photos       []string                   `json:"photos" binding:"max=3,dive,max=500"`
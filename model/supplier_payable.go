@@ -0,0 +1,159 @@
+package model
+
+import "time"
+
+// 应付台账来源类型
+const (
+	PayableSourcePurchaseReceipt = "purchase_receipt" // 采购收货，增加应付
+	PayableSourceStoreReturn     = "store_return"     // 返厂押金，冲减应付
+	PayableSourcePayment         = "payment"          // 付款，冲减应付
+	PayableSourcePaymentVoid     = "payment_void"     // 付款作废，恢复应付
+)
+
+// 付款记录状态
+const (
+	SupplierPaymentStatusValid  int8 = 1 // 有效
+	SupplierPaymentStatusVoided int8 = 2 // 已作废
+)
+
+// SupplierPayableEntry 供应商应付台账（门店维度），只追加不修改。
+// Amount 为正表示增加应付，为负表示冲减；来源单据变更时按差额追加冲正分录，业务日期沿用原单据。
+type SupplierPayableEntry struct {
+	ID         uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	StoreID    uint      `json:"store_id" gorm:"not null;index:idx_supplier_payable_entries_ledger,priority:2;comment:门店ID"`
+	SupplierID uint      `json:"supplier_id" gorm:"not null;index:idx_supplier_payable_entries_ledger,priority:1;comment:供应商ID"`
+	SourceType string    `json:"source_type" gorm:"type:varchar(30);not null;index:idx_supplier_payable_entries_source,priority:1;comment:来源类型"`
+	SourceID   uint      `json:"source_id" gorm:"not null;default:0;index:idx_supplier_payable_entries_source,priority:2;comment:来源单据ID"`
+	SourceNo   string    `json:"source_no" gorm:"type:varchar(50);comment:来源单号"`
+	BizDate    time.Time `json:"biz_date" gorm:"type:date;not null;index:idx_supplier_payable_entries_ledger,priority:3;comment:业务日期"`
+	Amount     float64   `json:"amount" gorm:"type:decimal(12,2);not null;comment:金额（正数增加应付，负数冲减）"`
+	Remark     string    `json:"remark" gorm:"type:varchar(500);comment:备注"`
+	CreatedAt  time.Time `json:"created_at"`
+	Store      *Store    `json:"store,omitempty" gorm:"foreignKey:StoreID"`
+}
+
+func (SupplierPayableEntry) TableName() string {
+	return "supplier_payable_entries"
+}
+
+// SupplierPayment 供应商付款记录
+type SupplierPayment struct {
+	ID           uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	PaymentNo    string     `json:"payment_no" gorm:"type:varchar(50);uniqueIndex;not null;comment:付款单号"`
+	StoreID      uint       `json:"store_id" gorm:"not null;index;comment:门店ID"`
+	SupplierID   uint       `json:"supplier_id" gorm:"not null;index;comment:供应商ID"`
+	Amount       float64    `json:"amount" gorm:"type:decimal(12,2);not null;comment:付款金额"`
+	PayDate      time.Time  `json:"pay_date" gorm:"type:date;not null;comment:付款日期"`
+	Method       string     `json:"method" gorm:"type:varchar(20);comment:付款方式"`
+	Reference    string     `json:"reference" gorm:"type:varchar(100);comment:流水号/凭证号"`
+	Remark       string     `json:"remark" gorm:"type:varchar(500);comment:备注"`
+	Status       int8       `json:"status" gorm:"not null;default:1;comment:状态 1=有效 2=已作废"`
+	VoidReason   string     `json:"void_reason" gorm:"type:varchar(200);comment:作废原因"`
+	VoidedAt     *time.Time `json:"voided_at"`
+	OperatorID   uint       `json:"operator_id" gorm:"not null;comment:操作人ID"`
+	OperatorName string     `json:"operator_name" gorm:"type:varchar(50);comment:操作人姓名"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	Store        *Store     `json:"store,omitempty" gorm:"foreignKey:StoreID"`
+	Supplier     *Supplier  `json:"supplier,omitempty" gorm:"foreignKey:SupplierID"`
+}
+
+func (SupplierPayment) TableName() string {
+	return "supplier_payments"
+}
+
+// CreateSupplierPaymentReq 登记供应商付款
+type CreateSupplierPaymentReq struct {
+	StoreID    uint    `json:"store_id"` // 仅总部账号可指定门店
+	SupplierID uint    `json:"supplier_id" binding:"required"`
+	Amount     float64 `json:"amount" binding:"required,gt=0"`
+	PayDate    string  `json:"pay_date"` // 不传取当天
+	Method     string  `json:"method" binding:"omitempty,oneof=bank cash wechat alipay other"`
+	Reference  string  `json:"reference" binding:"max=100"`
+	Remark     string  `json:"remark" binding:"max=500"`
+}
+
+// VoidSupplierPaymentReq 作废付款
+type VoidSupplierPaymentReq struct {
+	Reason string `json:"reason" binding:"required,max=200"`
+}
+
+// ListSupplierPaymentReq 付款记录查询
+type ListSupplierPaymentReq struct {
+	StoreID    uint   `form:"store_id"`
+	SupplierID uint   `form:"supplier_id"`
+	StartDate  string `form:"start_date"`
+	EndDate    string `form:"end_date"`
+	Status     *int8  `form:"status"`
+	Page       int    `form:"page,default=1" binding:"min=1"`
+	PageSize   int    `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// ListSupplierPayableEntryReq 应付台账查询
+type ListSupplierPayableEntryReq struct {
+	StoreID    uint   `form:"store_id"`
+	SupplierID uint   `form:"supplier_id"`
+	SourceType string `form:"source_type"`
+	StartDate  string `form:"start_date"`
+	EndDate    string `form:"end_date"`
+	Page       int    `form:"page,default=1" binding:"min=1"`
+	PageSize   int    `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// SupplierStatementReq 供应商月度对账单查询，Month 格式 2024-01
+type SupplierStatementReq struct {
+	StoreID    uint   `form:"store_id"`
+	SupplierID uint   `form:"supplier_id" binding:"required"`
+	Month      string `form:"month" binding:"required"`
+}
+
+// SupplierStatement 供应商月度对账单
+type SupplierStatement struct {
+	SupplierID     uint                    `json:"supplier_id"`
+	SupplierName   string                  `json:"supplier_name"`
+	StoreID        uint                    `json:"store_id"` // 0 表示全部门店
+	StoreName      string                  `json:"store_name"`
+	Month          string                  `json:"month"`
+	OpeningBalance float64                 `json:"opening_balance"`
+	PurchaseAmount float64                 `json:"purchase_amount"` // 本月采购收货
+	ReturnAmount   float64                 `json:"return_amount"`   // 本月返厂冲减（正数）
+	PaymentAmount  float64                 `json:"payment_amount"`  // 本月净付款（正数）
+	ClosingBalance float64                 `json:"closing_balance"`
+	Entries        []*SupplierPayableEntry `json:"entries"`
+}
+
+// SupplierAgingReq 应付账龄查询，AsOf 不传取当天
+type SupplierAgingReq struct {
+	StoreID    uint   `form:"store_id"`
+	SupplierID uint   `form:"supplier_id"`
+	AsOf       string `form:"as_of"`
+}
+
+// PayableDailyNet 门店+供应商按业务日期汇总的应付净额
+type PayableDailyNet struct {
+	StoreID    uint      `json:"store_id"`
+	SupplierID uint      `json:"supplier_id"`
+	BizDate    time.Time `json:"biz_date"`
+	Amount     float64   `json:"amount"`
+}
+
+// SupplierAging 单个供应商的应付余额与账龄分布（先发生的应付先被付款/返厂冲减）
+type SupplierAging struct {
+	SupplierID      uint    `json:"supplier_id"`
+	SupplierName    string  `json:"supplier_name"`
+	Balance         float64 `json:"balance"`
+	Days0To30       float64 `json:"days_0_30"`
+	Days31To60      float64 `json:"days_31_60"`
+	DaysOver60      float64 `json:"days_over_60"`
+	UnappliedCredit float64 `json:"unapplied_credit"` // 超付或未抵扣的冲减金额
+}
+
+// SupplierAgingReport 应付账龄汇总
+type SupplierAgingReport struct {
+	AsOf       string          `json:"as_of"`
+	Balance    float64         `json:"balance"`
+	Days0To30  float64         `json:"days_0_30"`
+	Days31To60 float64         `json:"days_31_60"`
+	DaysOver60 float64         `json:"days_over_60"`
+	Suppliers  []SupplierAging `json:"suppliers"`
+}
@@ -194,8 +194,20 @@ func (m *B2BModule) GetConfiguredPrice(storeID, customerID, productID, unitSpecI
 	return nil, nil
 }
 
-func (m *B2BModule) CreateSupplyOrderWithInventory(order *model.B2BSupplyOrder, account *model.StoreAccount) error {
+// CreateSupplyOrderWithInventory 锁定客户校验信用额度后创建供货单、记账单并扣减库存，
+// 同时记入客户应收；下单即收款时生成收款记录（payment 可为 nil）。
+func (m *B2BModule) CreateSupplyOrderWithInventory(order *model.B2BSupplyOrder, account *model.StoreAccount, payment *model.B2BPayment, confirmOverCredit bool) error {
 	return m.db.Transaction(func(tx *gorm.DB) error {
+		var customer model.B2BCustomer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, order.CustomerID).Error; err != nil {
+			return apicode.New(apicode.CustomerNotFound)
+		}
+		override, err := checkB2BCredit(&customer, order.UnpaidAmount, confirmOverCredit)
+		if err != nil {
+			return err
+		}
+		order.CreditOverride = override
+
 		for _, item := range order.Items {
 			var inv model.Inventory
 			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
@@ -218,22 +230,42 @@ func (m *B2BModule) CreateSupplyOrderWithInventory(order *model.B2BSupplyOrder,
 			}
 		}
 
+		src := model.InventoryMovementSource{
+			Type:         model.MovementSourceB2BSupplyOrder,
+			ID:           order.ID,
+			No:           order.OrderNo,
+			OperatorID:   order.OperatorID,
+			OperatorName: order.OperatorName,
+			Remark:       "B2B供货出库",
+		}
 		for _, item := range order.Items {
-			res := tx.Model(&model.Inventory{}).
-				Where("store_id = ? AND product_id = ? AND quantity >= ?", order.StoreID, item.ProductID, item.BaseQuantity).
-				Update("quantity", gorm.Expr("quantity - ?", item.BaseQuantity))
-			if res.Error != nil {
-				return res.Error
+			ok, err := deductInventoryQuantity(tx, order.StoreID, item.ProductID, item.BaseQuantity, src)
+			if err != nil {
+				return err
 			}
-			if res.RowsAffected == 0 {
+			if !ok {
 				return apicode.Newf(apicode.InventoryInsufficient, "商品【%s】库存不足，出库失败", item.ProductName)
 			}
 		}
 
-		if order.UnpaidAmount != 0 {
-			if err := tx.Model(&model.B2BCustomer{}).
-				Where("id = ?", order.CustomerID).
-				Update("receivable", gorm.Expr("receivable + ?", order.UnpaidAmount)).Error; err != nil {
+		if err := adjustB2BCustomerReceivable(tx, order.CustomerID, order.UnpaidAmount); err != nil {
+			return err
+		}
+		if err := recordB2BReceivable(tx, &model.B2BReceivableEntry{
+			StoreID:    order.StoreID,
+			CustomerID: order.CustomerID,
+			SourceType: model.B2BReceivableSourceSupplyOrder,
+			SourceID:   order.ID,
+			SourceNo:   order.OrderNo,
+			BizDate:    order.OrderDate,
+			Amount:     order.TotalAmount,
+			Remark:     order.Remark,
+		}); err != nil {
+			return err
+		}
+		if payment != nil {
+			payment.Allocations = []model.B2BPaymentAllocation{{OrderID: order.ID, OrderNo: order.OrderNo, Amount: payment.Amount}}
+			if err := insertB2BPayment(tx, payment); err != nil {
 				return err
 			}
 		}
@@ -291,35 +323,6 @@ func (m *B2BModule) UpdateSupplyOrderDelivery(id uint, deliveryStatus int) error
 	}).Error
 }
 
-func (m *B2BModule) UpdateSupplyOrderPayment(id, customerID uint, paymentStatus int, paidAmount, unpaidAmount, receivableDelta float64) error {
-	return m.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Model(&model.B2BSupplyOrder{}).Where("id = ?", id).Updates(map[string]interface{}{
-			"payment_status": paymentStatus,
-			"paid_amount":    paidAmount,
-			"unpaid_amount":  unpaidAmount,
-		}).Error; err != nil {
-			return err
-		}
-		if receivableDelta != 0 {
-			if err := tx.Model(&model.B2BCustomer{}).
-				Where("id = ?", customerID).
-				Update("receivable", gorm.Expr("receivable + ?", receivableDelta)).Error; err != nil {
-				return err
-			}
-		}
-		accountPaymentStatus := model.StoreAccountPaymentUnpaid
-		if paymentStatus == model.B2BPaymentPaid {
-			accountPaymentStatus = model.StoreAccountPaymentPaid
-		}
-		if err := tx.Model(&model.StoreAccount{}).
-			Where("source_type = ? AND source_id = ?", model.StoreAccountSourceB2BSupplyOrder, id).
-			Update("payment_status", accountPaymentStatus).Error; err != nil {
-			return err
-		}
-		return nil
-	})
-}
-
 func (m *B2BModule) GenerateSupplyOrderNo() string {
 	today := time.Now().Format("20060102")
 	pattern := "B2B" + today + "%"
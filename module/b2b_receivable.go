@@ -0,0 +1,444 @@
+package module
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// recordB2BReceivable 在调用方事务内追加应收分录，金额为 0 时跳过
+func recordB2BReceivable(tx *gorm.DB, entry *model.B2BReceivableEntry) error {
+	entry.Amount = roundMoney(entry.Amount)
+	if entry.Amount == 0 {
+		return nil
+	}
+	return tx.Create(entry).Error
+}
+
+// b2bPaymentStatus 按已收金额推导供货单收款状态；total 为扣除退货后的金额，全部退货的单据视为已结清
+func b2bPaymentStatus(total, paid float64) int {
+	switch {
+	case total <= 0:
+		return model.B2BPaymentPaid
+	case paid <= 0:
+		return model.B2BPaymentUnpaid
+	case paid >= total:
+		return model.B2BPaymentPaid
+	default:
+		return model.B2BPaymentPartial
+	}
+}
+
+// checkB2BCredit 新增未收金额后是否超出信用额度：额度为 0 不限；硬控直接拒绝，软控须确认。
+// 返回值表示本次是否为确认后的超额放行。
+func checkB2BCredit(customer *model.B2BCustomer, addUnpaid float64, confirmed bool) (bool, error) {
+	if customer.CreditLimit <= 0 || addUnpaid <= 0 {
+		return false, nil
+	}
+	exposure := roundMoney(customer.Receivable + addUnpaid)
+	if exposure <= customer.CreditLimit {
+		return false, nil
+	}
+	if customer.CreditControl == model.B2BCreditControlSoft {
+		if confirmed {
+			return true, nil
+		}
+		return false, apicode.Newf(apicode.CreditOverrideRequired, "客户【%s】信用额度 %.2f，当前应收 %.2f，本单后将达到 %.2f，请确认后继续",
+			customer.Name, customer.CreditLimit, customer.Receivable, exposure)
+	}
+	return false, apicode.Newf(apicode.CreditLimitExceeded, "客户【%s】信用额度 %.2f，当前应收 %.2f，本单后将达到 %.2f",
+		customer.Name, customer.CreditLimit, customer.Receivable, exposure)
+}
+
+// planB2BPaymentAllocations 生成收款核销明细。未指定时按 orders 顺序（供货日期从早到晚）依次核销；
+// 指定时逐单校验不超过未收金额。核销合计必须等于收款金额。
+func planB2BPaymentAllocations(amount float64, orders []*model.B2BSupplyOrder, requested []model.B2BPaymentAllocationReq) ([]model.B2BPaymentAllocation, error) {
+	amount = roundMoney(amount)
+	allocations := make([]model.B2BPaymentAllocation, 0)
+	left := amount
+
+	if len(requested) == 0 {
+		for _, order := range orders {
+			if left <= 0 {
+				break
+			}
+			if order.UnpaidAmount <= 0 {
+				continue
+			}
+			applied := order.UnpaidAmount
+			if left < applied {
+				applied = left
+			}
+			allocations = append(allocations, model.B2BPaymentAllocation{OrderID: order.ID, OrderNo: order.OrderNo, Amount: roundMoney(applied)})
+			left = roundMoney(left - applied)
+		}
+		if left > 0 {
+			return nil, apicode.Newf(apicode.ValidationFailed, "收款金额超过客户未收金额 %.2f", roundMoney(amount-left))
+		}
+		return allocations, nil
+	}
+
+	byID := make(map[uint]*model.B2BSupplyOrder, len(orders))
+	for _, order := range orders {
+		byID[order.ID] = order
+	}
+	planned := make(map[uint]float64, len(requested))
+	for _, line := range requested {
+		order, ok := byID[line.OrderID]
+		if !ok {
+			return nil, apicode.Newf(apicode.OrderNotFound, "供货单 %d 不属于该客户或已取消", line.OrderID)
+		}
+		planned[order.ID] = roundMoney(planned[order.ID] + line.Amount)
+		if planned[order.ID] > order.UnpaidAmount {
+			return nil, apicode.Newf(apicode.ValidationFailed, "供货单 %s 核销金额超过未收金额 %.2f", order.OrderNo, order.UnpaidAmount)
+		}
+		allocations = append(allocations, model.B2BPaymentAllocation{OrderID: order.ID, OrderNo: order.OrderNo, Amount: roundMoney(line.Amount)})
+		left = roundMoney(left - line.Amount)
+	}
+	if left != 0 {
+		return nil, apicode.Newf(apicode.ValidationFailed, "核销合计须等于收款金额 %.2f", amount)
+	}
+	return allocations, nil
+}
+
+// b2bOrderUnpaid 扣除退货与已收后的未收金额；退货后已收超出部分转为客户预收，不计负数
+func b2bOrderUnpaid(order *model.B2BSupplyOrder, paid float64) float64 {
+	unpaid := roundMoney(order.NetAmount() - paid)
+	if unpaid < 0 {
+		return 0
+	}
+	return unpaid
+}
+
+// setB2BOrderPaid 更新供货单已收/未收金额与收款状态，并同步关联记账单的收款状态
+func setB2BOrderPaid(tx *gorm.DB, order *model.B2BSupplyOrder, paid float64) error {
+	paid = roundMoney(paid)
+	order.PaidAmount = paid
+	order.UnpaidAmount = b2bOrderUnpaid(order, paid)
+	order.PaymentStatus = b2bPaymentStatus(roundMoney(order.NetAmount()), paid)
+	if err := tx.Model(&model.B2BSupplyOrder{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+		"payment_status": order.PaymentStatus,
+		"paid_amount":    order.PaidAmount,
+		"unpaid_amount":  order.UnpaidAmount,
+	}).Error; err != nil {
+		return err
+	}
+	accountPaymentStatus := model.StoreAccountPaymentUnpaid
+	if order.PaymentStatus == model.B2BPaymentPaid {
+		accountPaymentStatus = model.StoreAccountPaymentPaid
+	}
+	return tx.Model(&model.StoreAccount{}).
+		Where("source_type = ? AND source_id = ?", model.StoreAccountSourceB2BSupplyOrder, order.ID).
+		Update("payment_status", accountPaymentStatus).Error
+}
+
+func adjustB2BCustomerReceivable(tx *gorm.DB, customerID uint, delta float64) error {
+	if delta = roundMoney(delta); delta == 0 {
+		return nil
+	}
+	return tx.Model(&model.B2BCustomer{}).
+		Where("id = ?", customerID).
+		Update("receivable", gorm.Expr("receivable + ?", delta)).Error
+}
+
+// insertB2BPayment 写入收款记录（含核销明细）并冲减应收台账
+func insertB2BPayment(tx *gorm.DB, payment *model.B2BPayment) error {
+	if err := tx.Create(payment).Error; err != nil {
+		return err
+	}
+	return recordB2BReceivable(tx, &model.B2BReceivableEntry{
+		StoreID:    payment.StoreID,
+		CustomerID: payment.CustomerID,
+		SourceType: model.B2BReceivableSourcePayment,
+		SourceID:   payment.ID,
+		SourceNo:   payment.PaymentNo,
+		BizDate:    payment.PayDate,
+		Amount:     -payment.Amount,
+		Remark:     payment.Remark,
+	})
+}
+
+// CreatePayment 登记客户收款：锁定客户未收供货单生成核销明细，更新供货单收款状态并冲减客户应收
+func (m *B2BModule) CreatePayment(payment *model.B2BPayment, requested []model.B2BPaymentAllocationReq) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		var customer model.B2BCustomer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND store_id = ?", payment.CustomerID, payment.StoreID).
+			First(&customer).Error; err != nil {
+			return apicode.New(apicode.CustomerNotFound)
+		}
+
+		var orders []*model.B2BSupplyOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("customer_id = ? AND unpaid_amount > 0 AND delivery_status <> ?", customer.ID, model.B2BDeliveryCancel).
+			Order("order_date ASC, id ASC").
+			Find(&orders).Error; err != nil {
+			return err
+		}
+		allocations, err := planB2BPaymentAllocations(payment.Amount, orders, requested)
+		if err != nil {
+			return err
+		}
+
+		byID := make(map[uint]*model.B2BSupplyOrder, len(orders))
+		for _, order := range orders {
+			byID[order.ID] = order
+		}
+		for _, allocation := range allocations {
+			order := byID[allocation.OrderID]
+			if err := setB2BOrderPaid(tx, order, order.PaidAmount+allocation.Amount); err != nil {
+				return err
+			}
+		}
+		if err := adjustB2BCustomerReceivable(tx, customer.ID, -payment.Amount); err != nil {
+			return err
+		}
+		payment.CustomerName = customer.Name
+		payment.Allocations = allocations
+		return insertB2BPayment(tx, payment)
+	})
+}
+
+// VoidPayment 作废收款：按核销明细恢复供货单未收金额与客户应收，冲正分录的业务日期沿用收款日期
+func (m *B2BModule) VoidPayment(id, storeID uint, isHQ bool, reason string) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		var payment model.B2BPayment
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id)
+		if !isHQ {
+			query = query.Where("store_id = ?", storeID)
+		}
+		if err := query.First(&payment).Error; err != nil {
+			return apicode.New(apicode.NotFound)
+		}
+		if payment.Status != model.B2BPaymentRecordValid {
+			return apicode.Newf(apicode.OrderStateConflict, "收款记录已作废")
+		}
+
+		var allocations []model.B2BPaymentAllocation
+		if err := tx.Where("payment_id = ?", payment.ID).Find(&allocations).Error; err != nil {
+			return err
+		}
+		for _, allocation := range allocations {
+			var order model.B2BSupplyOrder
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, allocation.OrderID).Error; err != nil {
+				return err
+			}
+			paid := order.PaidAmount - allocation.Amount
+			if paid < 0 {
+				paid = 0
+			}
+			if err := setB2BOrderPaid(tx, &order, paid); err != nil {
+				return err
+			}
+		}
+		if err := adjustB2BCustomerReceivable(tx, payment.CustomerID, payment.Amount); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&payment).Updates(map[string]interface{}{
+			"status":      model.B2BPaymentRecordVoided,
+			"void_reason": reason,
+			"voided_at":   &now,
+		}).Error; err != nil {
+			return err
+		}
+		return recordB2BReceivable(tx, &model.B2BReceivableEntry{
+			StoreID:    payment.StoreID,
+			CustomerID: payment.CustomerID,
+			SourceType: model.B2BReceivableSourcePaymentVoid,
+			SourceID:   payment.ID,
+			SourceNo:   payment.PaymentNo,
+			BizDate:    payment.PayDate,
+			Amount:     payment.Amount,
+			Remark:     reason,
+		})
+	})
+}
+
+// ReduceSupplyOrderPaid 手工调低供货单已收金额（不能低于有效收款记录的核销合计），差额恢复为客户应收
+func (m *B2BModule) ReduceSupplyOrderPaid(id uint, paid float64) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		var order model.B2BSupplyOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, id).Error; err != nil {
+			return apicode.New(apicode.OrderNotFound)
+		}
+		var allocated float64
+		if err := tx.Table("b2b_payment_allocations a").
+			Joins("JOIN b2b_payments p ON p.id = a.payment_id").
+			Where("a.order_id = ? AND p.status = ?", order.ID, model.B2BPaymentRecordValid).
+			Select("COALESCE(SUM(a.amount), 0)").
+			Scan(&allocated).Error; err != nil {
+			return err
+		}
+		if paid < roundMoney(allocated) {
+			return apicode.Newf(apicode.OrderStateConflict, "该供货单已登记收款 %.2f，请作废对应收款记录后再调整", roundMoney(allocated))
+		}
+
+		delta := roundMoney(order.PaidAmount - paid)
+		if delta <= 0 {
+			return nil
+		}
+		if err := setB2BOrderPaid(tx, &order, paid); err != nil {
+			return err
+		}
+		if err := adjustB2BCustomerReceivable(tx, order.CustomerID, delta); err != nil {
+			return err
+		}
+		return recordB2BReceivable(tx, &model.B2BReceivableEntry{
+			StoreID:    order.StoreID,
+			CustomerID: order.CustomerID,
+			SourceType: model.B2BReceivableSourceAdjustment,
+			SourceID:   order.ID,
+			SourceNo:   order.OrderNo,
+			BizDate:    time.Now(),
+			Amount:     delta,
+			Remark:     "调低已收金额",
+		})
+	})
+}
+
+func (m *B2BModule) GetPaymentScoped(id, storeID uint, isHQ bool) (*model.B2BPayment, error) {
+	var payment model.B2BPayment
+	query := m.db.Preload("Allocations").Where("id = ?", id)
+	if !isHQ {
+		query = query.Where("store_id = ?", storeID)
+	}
+	if err := query.First(&payment).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (m *B2BModule) ListPayments(req *model.ListB2BPaymentReq) ([]*model.B2BPayment, int64, error) {
+	var rows []*model.B2BPayment
+	var total int64
+	q := m.db.Model(&model.B2BPayment{})
+	if req.StoreID > 0 {
+		q = q.Where("store_id = ?", req.StoreID)
+	}
+	if req.CustomerID > 0 {
+		q = q.Where("customer_id = ?", req.CustomerID)
+	}
+	if req.Status > 0 {
+		q = q.Where("status = ?", req.Status)
+	}
+	if req.StartDate != "" {
+		q = q.Where("pay_date >= ?", req.StartDate)
+	}
+	if req.EndDate != "" {
+		q = q.Where("pay_date <= ?", req.EndDate)
+	}
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (req.Page - 1) * req.PageSize
+	if err := q.Preload("Allocations").Order("id DESC").Offset(offset).Limit(req.PageSize).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
+
+// ListCustomersBySettlement 门店下指定结算方式的启用客户
+func (m *B2BModule) ListCustomersBySettlement(storeID uint, settlement string) ([]*model.B2BCustomer, error) {
+	var rows []*model.B2BCustomer
+	q := m.db.Where("settlement = ? AND status = ?", settlement, model.B2BCustomerStatusEnabled)
+	if storeID > 0 {
+		q = q.Where("store_id = ?", storeID)
+	}
+	err := q.Order("id ASC").Find(&rows).Error
+	return rows, err
+}
+
+func (m *B2BModule) GetCustomersByIDs(ids []uint) ([]*model.B2BCustomer, error) {
+	var rows []*model.B2BCustomer
+	if len(ids) == 0 {
+		return rows, nil
+	}
+	err := m.db.Unscoped().Where("id IN ?", ids).Find(&rows).Error
+	return rows, err
+}
+
+// ReceivableBalancesBefore 业务日期早于 date 的各客户应收余额
+func (m *B2BModule) ReceivableBalancesBefore(customerIDs []uint, date time.Time) (map[uint]float64, error) {
+	var rows []model.B2BReceivableTotal
+	balances := make(map[uint]float64, len(customerIDs))
+	if len(customerIDs) == 0 {
+		return balances, nil
+	}
+	err := m.db.Model(&model.B2BReceivableEntry{}).
+		Select("customer_id, SUM(amount) AS amount").
+		Where("customer_id IN ? AND biz_date < ?", customerIDs, date.Format("2006-01-02")).
+		Group("customer_id").
+		Scan(&rows).Error
+	for _, row := range rows {
+		balances[row.CustomerID] = roundMoney(row.Amount)
+	}
+	return balances, err
+}
+
+// ReceivableTotalsBetween 业务日期 [start, end) 内各客户按来源汇总的发生额
+func (m *B2BModule) ReceivableTotalsBetween(customerIDs []uint, start, end time.Time) ([]model.B2BReceivableTotal, error) {
+	var rows []model.B2BReceivableTotal
+	if len(customerIDs) == 0 {
+		return rows, nil
+	}
+	err := m.db.Model(&model.B2BReceivableEntry{}).
+		Select("customer_id, source_type, SUM(amount) AS amount").
+		Where("customer_id IN ? AND biz_date >= ? AND biz_date < ?", customerIDs, start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Group("customer_id, source_type").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// ReceivableEntriesBetween 客户在业务日期 [start, end) 内的应收分录，按业务日期、记账先后排列
+func (m *B2BModule) ReceivableEntriesBetween(customerID uint, start, end time.Time) ([]*model.B2BReceivableEntry, error) {
+	var entries []*model.B2BReceivableEntry
+	err := m.db.Where("customer_id = ? AND biz_date >= ? AND biz_date < ?", customerID, start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Order("biz_date ASC, id ASC").
+		Find(&entries).Error
+	return entries, err
+}
+
+// ReceivableDailyNets 截至 asOf（含）各客户按业务日期汇总的应收净额，用于账龄计算
+func (m *B2BModule) ReceivableDailyNets(storeID, customerID uint, asOf time.Time) ([]model.B2BReceivableDailyNet, error) {
+	var rows []model.B2BReceivableDailyNet
+	query := m.db.Model(&model.B2BReceivableEntry{}).
+		Select("customer_id, biz_date, SUM(amount) AS amount").
+		Where("biz_date <= ?", asOf.Format("2006-01-02"))
+	if storeID > 0 {
+		query = query.Where("store_id = ?", storeID)
+	}
+	if customerID > 0 {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	err := query.Group("customer_id, biz_date").
+		Order("customer_id ASC, biz_date ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// GeneratePaymentNo 收款单号：SK + 日期 + 序号，如 SK202412070001
+func (m *B2BModule) GeneratePaymentNo() string {
+	prefix := "SK"
+	today := time.Now().Format("20060102")
+	pattern := prefix + today + "%"
+
+	var maxNo string
+	m.db.Model(&model.B2BPayment{}).
+		Where("payment_no LIKE ?", pattern).
+		Order("payment_no DESC").
+		Limit(1).
+		Pluck("payment_no", &maxNo)
+
+	seq := 1
+	if maxNo != "" && len(maxNo) >= 14 {
+		fmt.Sscanf(maxNo[len(maxNo)-4:], "%d", &seq)
+		seq++
+	}
+	return fmt.Sprintf("%s%s%04d", prefix, today, seq)
+}
@@ -0,0 +1,69 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+)
+
+func TestCheckB2BCredit(t *testing.T) {
+	hard := &model.B2BCustomer{Name: "酒楼", CreditLimit: 1000, Receivable: 800, CreditControl: model.B2BCreditControlHard}
+	if override, err := checkB2BCredit(hard, 200, false); err != nil || override {
+		t.Fatalf("within limit: override=%v err=%v", override, err)
+	}
+	if _, err := checkB2BCredit(hard, 200.01, true); !apicode.Is(err, apicode.CreditLimitExceeded) {
+		t.Fatalf("hard over limit err = %v", err)
+	}
+
+	soft := &model.B2BCustomer{Name: "超市", CreditLimit: 1000, Receivable: 800, CreditControl: model.B2BCreditControlSoft}
+	if _, err := checkB2BCredit(soft, 300, false); !apicode.Is(err, apicode.CreditOverrideRequired) {
+		t.Fatalf("soft without confirm err = %v", err)
+	}
+	if override, err := checkB2BCredit(soft, 300, true); err != nil || !override {
+		t.Fatalf("soft confirmed: override=%v err=%v", override, err)
+	}
+
+	unlimited := &model.B2BCustomer{Receivable: 99999}
+	if _, err := checkB2BCredit(unlimited, 500, false); err != nil {
+		t.Fatalf("zero limit should not block: %v", err)
+	}
+}
+
+func TestPlanB2BPaymentAllocationsOldestFirst(t *testing.T) {
+	orders := []*model.B2BSupplyOrder{
+		{ID: 1, OrderNo: "B1", UnpaidAmount: 100},
+		{ID: 2, OrderNo: "B2", UnpaidAmount: 250},
+		{ID: 3, OrderNo: "B3", UnpaidAmount: 80},
+	}
+	allocations, err := planB2BPaymentAllocations(300, orders, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(allocations) != 2 || allocations[0].OrderID != 1 || allocations[0].Amount != 100 || allocations[1].OrderID != 2 || allocations[1].Amount != 200 {
+		t.Fatalf("allocations = %#v", allocations)
+	}
+	if _, err := planB2BPaymentAllocations(431, orders, nil); err == nil {
+		t.Fatal("overpayment should be rejected")
+	}
+}
+
+func TestPlanB2BPaymentAllocationsRequested(t *testing.T) {
+	orders := []*model.B2BSupplyOrder{
+		{ID: 1, OrderNo: "B1", UnpaidAmount: 100},
+		{ID: 2, OrderNo: "B2", UnpaidAmount: 250},
+	}
+	allocations, err := planB2BPaymentAllocations(150, orders, []model.B2BPaymentAllocationReq{{OrderID: 2, Amount: 150}})
+	if err != nil || len(allocations) != 1 || allocations[0].OrderNo != "B2" {
+		t.Fatalf("allocations = %#v err = %v", allocations, err)
+	}
+	if _, err := planB2BPaymentAllocations(150, orders, []model.B2BPaymentAllocationReq{{OrderID: 1, Amount: 120}, {OrderID: 2, Amount: 30}}); err == nil {
+		t.Fatal("allocation above unpaid should be rejected")
+	}
+	if _, err := planB2BPaymentAllocations(150, orders, []model.B2BPaymentAllocationReq{{OrderID: 2, Amount: 100}}); err == nil {
+		t.Fatal("allocation total must equal payment amount")
+	}
+	if _, err := planB2BPaymentAllocations(50, orders, []model.B2BPaymentAllocationReq{{OrderID: 9, Amount: 50}}); !apicode.Is(err, apicode.OrderNotFound) {
+		t.Fatalf("foreign order err = %v", err)
+	}
+}
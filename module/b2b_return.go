@@ -0,0 +1,269 @@
+package module
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// planB2BSupplyReturnItems 按原供货单行生成退货明细：数量不得超过可退数量，基础库存按原行换算系数回补，
+// 单价与成本沿用原行；退完剩余数量时取原行金额的余数，避免分次退货的舍入误差。
+func planB2BSupplyReturnItems(orderItems []model.B2BSupplyOrderItem, lines []model.CreateB2BSupplyReturnItem) ([]model.B2BSupplyReturnItem, error) {
+	byID := make(map[uint]model.B2BSupplyOrderItem, len(orderItems))
+	for _, item := range orderItems {
+		byID[item.ID] = item
+	}
+	planned := make(map[uint]float64, len(lines))
+	items := make([]model.B2BSupplyReturnItem, 0, len(lines))
+	for _, line := range lines {
+		item, ok := byID[line.OrderItemID]
+		if !ok {
+			return nil, apicode.Newf(apicode.ValidationFailed, "明细 %d 不属于该供货单", line.OrderItemID)
+		}
+		returned := roundQuantity(item.ReturnedQuantity + planned[item.ID])
+		remaining := roundQuantity(item.Quantity - returned)
+		quantity := roundQuantity(line.Quantity)
+		if quantity <= 0 || quantity > remaining {
+			return nil, apicode.Newf(apicode.ValidationFailed, "商品【%s】可退数量 %.2f%s", item.ProductName, remaining, item.UnitName)
+		}
+		planned[item.ID] = roundQuantity(planned[item.ID] + quantity)
+
+		factor := item.FactorToBase
+		if factor <= 0 {
+			factor = 1
+		}
+		amount := roundMoney(quantity * item.SupplyPrice)
+		costAmount := roundMoney(quantity * item.CostPrice)
+		if quantity == remaining {
+			amount = roundMoney(item.Amount - roundMoney(returned*item.SupplyPrice))
+			costAmount = roundMoney(item.CostAmount - roundMoney(returned*item.CostPrice))
+		}
+		items = append(items, model.B2BSupplyReturnItem{
+			OrderItemID:  item.ID,
+			ProductID:    item.ProductID,
+			ProductName:  item.ProductName,
+			UnitSpecID:   item.UnitSpecID,
+			UnitName:     item.UnitName,
+			FactorToBase: factor,
+			Quantity:     quantity,
+			BaseQuantity: quantity * factor,
+			SupplyPrice:  item.SupplyPrice,
+			CostPrice:    item.CostPrice,
+			Amount:       amount,
+			CostAmount:   costAmount,
+			ProfitAmount: roundMoney(amount - costAmount),
+		})
+	}
+	return items, nil
+}
+
+// buildB2BSupplyReturnAccount 退货对应的负数记账单，与原供货单记账单相加即为退货后的净额
+func buildB2BSupplyReturnAccount(ret *model.B2BSupplyReturn, orderPaymentStatus int) *model.StoreAccount {
+	items := make([]model.StoreAccountItem, 0, len(ret.Items))
+	for _, item := range ret.Items {
+		items = append(items, model.StoreAccountItem{
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			Spec:        item.UnitName,
+			Quantity:    -item.Quantity,
+			Unit:        item.UnitName,
+			Price:       item.SupplyPrice,
+			Amount:      -item.Amount,
+		})
+	}
+	paymentStatus := model.StoreAccountPaymentUnpaid
+	if orderPaymentStatus == model.B2BPaymentPaid {
+		paymentStatus = model.StoreAccountPaymentPaid
+	}
+	return &model.StoreAccount{
+		AccountNo:       "JZ-" + ret.ReturnNo,
+		StoreID:         ret.StoreID,
+		PaymentStatus:   paymentStatus,
+		Channel:         "B2B供货",
+		OrderNo:         ret.OrderNo,
+		SourceType:      model.StoreAccountSourceB2BSupplyReturn,
+		SourceID:        ret.ID,
+		TotalAmount:     -ret.TotalAmount,
+		NetIncomeAmount: -ret.ProfitAmount,
+		ItemCount:       len(items),
+		Remark:          strings.TrimSpace("退货 " + ret.ReturnNo + " " + ret.Reason),
+		OperatorID:      ret.OperatorID,
+		AccountDate:     ret.ReturnDate,
+		Items:           items,
+	}
+}
+
+// CreateSupplyReturn 对已配送供货单登记退货：锁定供货单校验可退数量，回补库存，冲减供货单金额与毛利、
+// 客户应收，并生成负数记账单。ret 由调用方填好单号、日期、原因与操作人，明细在事务内按 lines 生成。
+func (m *B2BModule) CreateSupplyReturn(ret *model.B2BSupplyReturn, lines []model.CreateB2BSupplyReturnItem) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		var order model.B2BSupplyOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, ret.OrderID).Error; err != nil {
+			return apicode.New(apicode.OrderNotFound)
+		}
+		if order.DeliveryStatus != model.B2BDeliveryDone {
+			return apicode.Newf(apicode.OrderStateConflict, "仅已配送的供货单可以退货")
+		}
+		if ret.ReturnDate.Before(order.OrderDate) {
+			return apicode.Newf(apicode.ValidationFailed, "退货日期不能早于供货日期 %s", order.OrderDate.Format("2006-01-02"))
+		}
+		var orderItems []model.B2BSupplyOrderItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_id = ?", order.ID).Find(&orderItems).Error; err != nil {
+			return err
+		}
+		items, err := planB2BSupplyReturnItems(orderItems, lines)
+		if err != nil {
+			return err
+		}
+
+		ret.StoreID = order.StoreID
+		ret.OrderNo = order.OrderNo
+		ret.CustomerID = order.CustomerID
+		ret.CustomerName = order.CustomerName
+		ret.Items = items
+		ret.TotalAmount, ret.CostAmount = 0, 0
+		for _, item := range items {
+			ret.TotalAmount += item.Amount
+			ret.CostAmount += item.CostAmount
+		}
+		ret.TotalAmount = roundMoney(ret.TotalAmount)
+		ret.CostAmount = roundMoney(ret.CostAmount)
+		ret.ProfitAmount = roundMoney(ret.TotalAmount - ret.CostAmount)
+		if err := tx.Create(ret).Error; err != nil {
+			return err
+		}
+
+		src := model.InventoryMovementSource{
+			Type:         model.MovementSourceB2BReturn,
+			ID:           ret.ID,
+			No:           ret.ReturnNo,
+			OperatorID:   ret.OperatorID,
+			OperatorName: ret.OperatorName,
+			Remark:       "B2B退货回补",
+		}
+		for _, item := range items {
+			if err := tx.Model(&model.B2BSupplyOrderItem{}).Where("id = ?", item.OrderItemID).
+				Update("returned_quantity", gorm.Expr("returned_quantity + ?", item.Quantity)).Error; err != nil {
+				return err
+			}
+			var unit string
+			if err := tx.Model(&model.Inventory{}).
+				Where("store_id = ? AND product_id = ?", order.StoreID, item.ProductID).
+				Limit(1).Pluck("unit", &unit).Error; err != nil {
+				return err
+			}
+			if err := restoreStockIn(tx, order.StoreID, item.ProductID, item.BaseQuantity, unit, model.MovementSourceB2BSupplyOrder, order.ID, src); err != nil {
+				return err
+			}
+		}
+
+		order.ReturnedAmount = roundMoney(order.ReturnedAmount + ret.TotalAmount)
+		order.CostAmount = roundMoney(order.CostAmount - ret.CostAmount)
+		order.ProfitAmount = roundMoney(order.ProfitAmount - ret.ProfitAmount)
+		if err := tx.Model(&model.B2BSupplyOrder{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+			"returned_amount": order.ReturnedAmount,
+			"cost_amount":     order.CostAmount,
+			"profit_amount":   order.ProfitAmount,
+		}).Error; err != nil {
+			return err
+		}
+		if err := setB2BOrderPaid(tx, &order, order.PaidAmount); err != nil {
+			return err
+		}
+
+		if err := tx.Create(buildB2BSupplyReturnAccount(ret, order.PaymentStatus)).Error; err != nil {
+			return err
+		}
+		if err := adjustB2BCustomerReceivable(tx, order.CustomerID, -ret.TotalAmount); err != nil {
+			return err
+		}
+		return recordB2BReceivable(tx, &model.B2BReceivableEntry{
+			StoreID:    order.StoreID,
+			CustomerID: order.CustomerID,
+			SourceType: model.B2BReceivableSourceCreditNote,
+			SourceID:   ret.ID,
+			SourceNo:   ret.ReturnNo,
+			BizDate:    ret.ReturnDate,
+			Amount:     -ret.TotalAmount,
+			Remark:     ret.Reason,
+		})
+	})
+}
+
+func (m *B2BModule) GetSupplyReturnScoped(id, storeID uint, isHQ bool) (*model.B2BSupplyReturn, error) {
+	var ret model.B2BSupplyReturn
+	query := m.db.Preload("Items").Where("id = ?", id)
+	if !isHQ {
+		query = query.Where("store_id = ?", storeID)
+	}
+	if err := query.First(&ret).Error; err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+func (m *B2BModule) ListSupplyReturns(req *model.ListB2BSupplyReturnReq) ([]*model.B2BSupplyReturn, int64, error) {
+	var rows []*model.B2BSupplyReturn
+	var total int64
+	q := m.db.Model(&model.B2BSupplyReturn{})
+	if req.StoreID > 0 {
+		q = q.Where("store_id = ?", req.StoreID)
+	}
+	if req.CustomerID > 0 {
+		q = q.Where("customer_id = ?", req.CustomerID)
+	}
+	if req.OrderID > 0 {
+		q = q.Where("order_id = ?", req.OrderID)
+	}
+	if req.StartDate != "" {
+		q = q.Where("return_date >= ?", req.StartDate)
+	}
+	if req.EndDate != "" {
+		q = q.Where("return_date <= ?", req.EndDate)
+	}
+	if kw := strings.TrimSpace(req.Keyword); kw != "" {
+		like := "%" + kw + "%"
+		q = q.Where("(return_no LIKE ? OR order_no LIKE ? OR customer_name LIKE ?)", like, like, like)
+	}
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (req.Page - 1) * req.PageSize
+	if err := q.Preload("Items").Order("id DESC").Offset(offset).Limit(req.PageSize).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
+
+// HasSupplyReturns 供货单是否已登记退货
+func (m *B2BModule) HasSupplyReturns(orderID uint) (bool, error) {
+	var count int64
+	err := m.db.Model(&model.B2BSupplyReturn{}).Where("order_id = ?", orderID).Count(&count).Error
+	return count > 0, err
+}
+
+// GenerateSupplyReturnNo 退货单号：BR + 日期 + 序号，如 BR202412070001
+func (m *B2BModule) GenerateSupplyReturnNo() string {
+	prefix := "BR"
+	today := time.Now().Format("20060102")
+	pattern := prefix + today + "%"
+
+	var maxNo string
+	m.db.Model(&model.B2BSupplyReturn{}).
+		Where("return_no LIKE ?", pattern).
+		Order("return_no DESC").
+		Limit(1).
+		Pluck("return_no", &maxNo)
+
+	seq := 1
+	if maxNo != "" && len(maxNo) >= 14 {
+		fmt.Sscanf(maxNo[len(maxNo)-4:], "%d", &seq)
+		seq++
+	}
+	return fmt.Sprintf("%s%s%04d", prefix, today, seq)
+}
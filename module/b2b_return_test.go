@@ -0,0 +1,77 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+)
+
+func TestPlanB2BSupplyReturnItems(t *testing.T) {
+	orderItems := []model.B2BSupplyOrderItem{
+		{ID: 1, ProductID: 10, ProductName: "啤酒", UnitName: "箱", FactorToBase: 12, Quantity: 3, SupplyPrice: 33.33, CostPrice: 20, Amount: 99.99, CostAmount: 60},
+		{ID: 2, ProductID: 11, ProductName: "白酒", UnitName: "瓶", FactorToBase: 1, Quantity: 5, ReturnedQuantity: 4, SupplyPrice: 100, CostPrice: 70, Amount: 500, CostAmount: 350},
+	}
+
+	items, err := planB2BSupplyReturnItems(orderItems, []model.CreateB2BSupplyReturnItem{{OrderItemID: 1, Quantity: 2}, {OrderItemID: 2, Quantity: 1}})
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if items[0].BaseQuantity != 24 || items[0].Amount != 66.66 || items[0].CostAmount != 40 || items[0].ProfitAmount != 26.66 {
+		t.Fatalf("partial line = %+v", items[0])
+	}
+	if items[1].Amount != 100 || items[1].CostAmount != 70 || items[1].BaseQuantity != 1 {
+		t.Fatalf("last remaining line = %+v", items[1])
+	}
+
+	items, err = planB2BSupplyReturnItems(orderItems, []model.CreateB2BSupplyReturnItem{{OrderItemID: 1, Quantity: 3}})
+	if err != nil || items[0].Amount != 99.99 {
+		t.Fatalf("full return should keep line amount: items=%+v err=%v", items, err)
+	}
+
+	if _, err := planB2BSupplyReturnItems(orderItems, []model.CreateB2BSupplyReturnItem{{OrderItemID: 2, Quantity: 1}, {OrderItemID: 2, Quantity: 1}}); !apicode.Is(err, apicode.ValidationFailed) {
+		t.Fatalf("over return err = %v", err)
+	}
+	if _, err := planB2BSupplyReturnItems(orderItems, []model.CreateB2BSupplyReturnItem{{OrderItemID: 9, Quantity: 1}}); !apicode.Is(err, apicode.ValidationFailed) {
+		t.Fatalf("foreign item err = %v", err)
+	}
+}
+
+func TestBuildB2BSupplyReturnAccount(t *testing.T) {
+	ret := &model.B2BSupplyReturn{
+		ID:           7,
+		ReturnNo:     "BR202501010001",
+		StoreID:      3,
+		OrderNo:      "B2B202412310001",
+		TotalAmount:  66.66,
+		ProfitAmount: 26.66,
+		Reason:       "破损",
+		Items:        []model.B2BSupplyReturnItem{{ProductID: 10, ProductName: "啤酒", UnitName: "箱", Quantity: 2, SupplyPrice: 33.33, Amount: 66.66}},
+	}
+	account := buildB2BSupplyReturnAccount(ret, model.B2BPaymentPaid)
+	if account.SourceType != model.StoreAccountSourceB2BSupplyReturn || account.SourceID != 7 || account.AccountNo != "JZ-BR202501010001" {
+		t.Fatalf("account source = %+v", account)
+	}
+	if account.TotalAmount != -66.66 || account.NetIncomeAmount != -26.66 || account.PaymentStatus != model.StoreAccountPaymentPaid {
+		t.Fatalf("account amounts = %.2f / %.2f status=%d", account.TotalAmount, account.NetIncomeAmount, account.PaymentStatus)
+	}
+	if account.Items[0].Quantity != -2 || account.Items[0].Amount != -66.66 {
+		t.Fatalf("account item = %+v", account.Items[0])
+	}
+	if !account.IsB2BSupplyOrderAccount() {
+		t.Fatal("return account should be read-only like supply order accounts")
+	}
+}
+
+func TestB2BOrderUnpaidAfterReturn(t *testing.T) {
+	order := &model.B2BSupplyOrder{TotalAmount: 300, ReturnedAmount: 100}
+	if got := b2bOrderUnpaid(order, 150); got != 50 {
+		t.Fatalf("unpaid = %.2f", got)
+	}
+	if got := b2bOrderUnpaid(order, 250); got != 0 {
+		t.Fatalf("overpaid unpaid = %.2f", got)
+	}
+	if got := b2bPaymentStatus(0, 0); got != model.B2BPaymentPaid {
+		t.Fatalf("fully returned status = %d", got)
+	}
+}
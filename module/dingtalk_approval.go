@@ -0,0 +1,102 @@
+package module
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"gorm.io/gorm"
+)
+
+type DingTalkApprovalModule struct {
+	db *gorm.DB
+}
+
+func NewDingTalkApprovalModule(db *gorm.DB) *DingTalkApprovalModule {
+	return &DingTalkApprovalModule{db: db}
+}
+
+func (m *DingTalkApprovalModule) Create(approval *model.DingTalkApproval) error {
+	approval.Status = model.ApprovalPending
+	return m.db.Create(approval).Error
+}
+
+func (m *DingTalkApprovalModule) GetByID(id uint) (*model.DingTalkApproval, error) {
+	var approval model.DingTalkApproval
+	if err := m.db.First(&approval, id).Error; err != nil {
+		return nil, err
+	}
+	return &approval, nil
+}
+
+// FindPending 查询单据待审批的记录，没有时返回 nil
+func (m *DingTalkApprovalModule) FindPending(bizType string, bizID uint) (*model.DingTalkApproval, error) {
+	var rows []*model.DingTalkApproval
+	if err := m.db.Where("biz_type = ? AND biz_id = ? AND status = ?", bizType, bizID, model.ApprovalPending).
+		Order("id DESC").Limit(1).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return rows[0], nil
+}
+
+// SetCardTrackIDs 记录已发送的卡片
+func (m *DingTalkApprovalModule) SetCardTrackIDs(id uint, trackIDs []string) error {
+	return m.db.Model(&model.DingTalkApproval{}).Where("id = ?", id).
+		Update("card_track_ids", model.StringList(trackIDs)).Error
+}
+
+// Decide 以条件更新抢占审批：只有待审批状态可以通过或驳回，返回是否抢占成功（并发点击时只有一人生效）
+func (m *DingTalkApprovalModule) Decide(approval *model.DingTalkApproval, status int8, approver *model.User, staffID, approverName string, now time.Time) (bool, error) {
+	res := m.db.Model(&model.DingTalkApproval{}).
+		Where("id = ? AND status = ?", approval.ID, model.ApprovalPending).
+		Updates(map[string]interface{}{
+			"status":            status,
+			"approver_id":       approver.ID,
+			"approver_name":     approverName,
+			"approver_staff_id": staffID,
+			"decided_at":        now,
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// Finish 记录业务执行结果；执行失败时状态改为执行失败
+func (m *DingTalkApprovalModule) Finish(id uint, status int8, message string) error {
+	if len(message) > 500 {
+		message = message[:500]
+	}
+	return m.db.Model(&model.DingTalkApproval{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"status": status, "result_message": message}).Error
+}
+
+func (m *DingTalkApprovalModule) List(req *model.ListDingTalkApprovalReq) ([]*model.DingTalkApproval, int64, error) {
+	var rows []*model.DingTalkApproval
+	var total int64
+	q := m.db.Model(&model.DingTalkApproval{})
+	if req.StoreID > 0 {
+		q = q.Where("store_id = ?", req.StoreID)
+	}
+	if req.BizType != "" {
+		q = q.Where("biz_type = ?", req.BizType)
+	}
+	if req.Status > 0 {
+		q = q.Where("status = ?", req.Status)
+	}
+	if kw := strings.TrimSpace(req.Keyword); kw != "" {
+		like := "%" + kw + "%"
+		q = q.Where("(biz_no LIKE ? OR title LIKE ?)", like, like)
+	}
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (req.Page - 1) * req.PageSize
+	if err := q.Order("id DESC").Offset(offset).Limit(req.PageSize).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
@@ -37,3 +37,15 @@ func (m *DingTalkUserModule) Upsert(user *model.DingTalkUser) error {
 		}).
 		FirstOrCreate(user).Error
 }
+
+// BindStaff 将钉钉用户与手机号绑定：移除该钉钉用户关联的其他手机号，保证一人只对应一个系统账号
+func (m *DingTalkUserModule) BindStaff(user *model.DingTalkUser) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND mobile <> ?", user.UserID, user.Mobile).Delete(&model.DingTalkUser{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("mobile = ?", user.Mobile).
+			Assign(model.DingTalkUser{UserID: user.UserID, Name: user.Name}).
+			FirstOrCreate(user).Error
+	})
+}
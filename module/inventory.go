@@ -44,20 +44,24 @@ func (m *InventoryModule) GetByStoreAndProduct(storeID, productID uint) (*model.
 	return &inv, nil
 }
 
-// AddQuantity 增加库存
-func (m *InventoryModule) AddQuantity(storeID, productID uint, quantity float64, unit string) error {
-	return incrementInventoryQuantity(m.db, storeID, productID, quantity, unit)
+// AddQuantity 增加库存（记入未标注到期日的批次）
+func (m *InventoryModule) AddQuantity(storeID, productID uint, quantity float64, unit string, src model.InventoryMovementSource) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		return stockIn(tx, storeID, productID, quantity, unit, nil, nil, src)
+	})
 }
 
-// SubQuantity 减少库存
-func (m *InventoryModule) SubQuantity(storeID, productID uint, quantity float64) error {
-	res := m.db.Model(&model.Inventory{}).
-		Where("store_id = ? AND product_id = ? AND quantity >= ?", storeID, productID, quantity).
-		Update("quantity", gorm.Expr("quantity - ?", quantity))
-	if res.Error != nil {
-		return res.Error
+// SubQuantity 减少库存（按 FEFO 扣减批次）
+func (m *InventoryModule) SubQuantity(storeID, productID uint, quantity float64, src model.InventoryMovementSource) error {
+	var ok bool
+	if err := m.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		ok, err = deductInventoryQuantity(tx, storeID, productID, quantity, src)
+		return err
+	}); err != nil {
+		return err
 	}
-	if res.RowsAffected > 0 {
+	if ok {
 		return nil
 	}
 
@@ -68,17 +72,41 @@ func (m *InventoryModule) SubQuantity(storeID, productID uint, quantity float64)
 	return apicode.Newf(apicode.InventoryInsufficient, "库存不足，当前库存: %.2f", inv.Quantity)
 }
 
-// UpdateQuantity 直接修改库存数量
-func (m *InventoryModule) UpdateQuantity(id uint, quantity float64) error {
-	return m.db.Model(&model.Inventory{}).Where("id = ?", id).Update("quantity", quantity).Error
+// UpdateQuantity 直接修改库存数量，调少时按 FEFO 校正批次，差额记入库存流水
+func (m *InventoryModule) UpdateQuantity(id uint, quantity float64, src model.InventoryMovementSource) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		var inv model.Inventory
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&inv, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Inventory{}).Where("id = ?", id).Update("quantity", quantity).Error; err != nil {
+			return err
+		}
+		if err := trimInventoryLots(tx, inv.StoreID, inv.ProductID, quantity); err != nil {
+			return err
+		}
+		return recordInventoryMovement(tx, inv.StoreID, inv.ProductID, quantity-inv.Quantity, src)
+	})
 }
 
-// UpdateQuantityAndUnit 同时更新库存数量和单位
-func (m *InventoryModule) UpdateQuantityAndUnit(id uint, quantity float64, unit string) error {
-	return m.db.Model(&model.Inventory{}).Where("id = ?", id).Updates(map[string]interface{}{
-		"quantity": quantity,
-		"unit":     unit,
-	}).Error
+// UpdateQuantityAndUnit 同时更新库存数量和单位（单位纠正），批次按同一比例换算为新单位，差额记入库存流水
+func (m *InventoryModule) UpdateQuantityAndUnit(id uint, quantity float64, unit string, src model.InventoryMovementSource) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		var inv model.Inventory
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&inv, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Inventory{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"quantity": quantity,
+			"unit":     unit,
+		}).Error; err != nil {
+			return err
+		}
+		if err := rescaleInventoryLots(tx, inv.StoreID, inv.ProductID, inv.Quantity, quantity, unit); err != nil {
+			return err
+		}
+		return recordInventoryMovement(tx, inv.StoreID, inv.ProductID, quantity-inv.Quantity, src)
+	})
 }
 
 // GetByID 根据ID获取库存
@@ -116,6 +144,11 @@ func (m *InventoryModule) List(req *model.ListInventoryReq) ([]*model.InventoryW
 	if err := query.Order("i.id DESC").Offset(offset).Limit(req.PageSize).Scan(&results).Error; err != nil {
 		return nil, 0, err
 	}
+	if req.WithLots {
+		if err := m.attachLots(results); err != nil {
+			return nil, 0, err
+		}
+	}
 
 	return results, total, nil
 }
@@ -128,57 +161,71 @@ func (m *InventoryModule) CreateOrder(order *model.InventoryOrder) error {
 // CreateOrderWithStockApply 创建出入库单并更新库存（同事务）
 func (m *InventoryModule) CreateOrderWithStockApply(order *model.InventoryOrder) error {
 	return m.db.Transaction(func(tx *gorm.DB) error {
-		if order.Type == model.InventoryTypeOut {
-			for _, item := range order.Items {
-				var inv model.Inventory
-				if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-					Where("store_id = ? AND product_id = ?", order.StoreID, item.ProductID).
-					First(&inv).Error; err != nil {
-					name := item.ProductName
-					if name == "" {
-						name = fmt.Sprintf("商品ID:%d", item.ProductID)
-					}
-					return apicode.Newf(apicode.InventoryNotFound, "商品【%s】不在库存中，无法出库", name)
-				}
-				if inv.Quantity < item.Quantity {
-					name := item.ProductName
-					if name == "" {
-						name = fmt.Sprintf("商品ID:%d", item.ProductID)
-					}
-					return apicode.Newf(apicode.InventoryInsufficient, "商品【%s】库存不足，当前库存: %.2f，出库数量: %.2f", name, inv.Quantity, item.Quantity)
-				}
+		return applyInventoryOrder(tx, order)
+	})
+}
+
+// applyInventoryOrder 在调用方事务内写入出入库单并同步库存。
+// 出库先对库存行加锁校验，再按条件扣减，避免并发下出现负库存；调拨、盘点调整等单据复用该路径。
+func applyInventoryOrder(tx *gorm.DB, order *model.InventoryOrder) error {
+	for _, item := range order.Items {
+		delta := inventoryOrderItemDelta(order.Type, item.Quantity)
+		if delta >= 0 {
+			continue
+		}
+		var inv model.Inventory
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("store_id = ? AND product_id = ?", order.StoreID, item.ProductID).
+			First(&inv).Error; err != nil {
+			return apicode.Newf(apicode.InventoryNotFound, "商品【%s】不在库存中，无法出库", inventoryItemName(item))
+		}
+		if inv.Quantity < -delta {
+			return apicode.Newf(apicode.InventoryInsufficient, "商品【%s】库存不足，当前库存: %.2f，出库数量: %.2f", inventoryItemName(item), inv.Quantity, -delta)
+		}
+	}
+
+	if err := tx.Create(order).Error; err != nil {
+		return err
+	}
+
+	src := inventoryOrderMovementSource(model.MovementSourceInventoryOrder, order)
+	for _, item := range order.Items {
+		delta := inventoryOrderItemDelta(order.Type, item.Quantity)
+		if delta > 0 {
+			if err := stockIn(tx, order.StoreID, item.ProductID, delta, item.Unit, item.ProductionDate, item.ExpiryDate, src); err != nil {
+				return err
 			}
+			continue
+		}
+		if delta == 0 {
+			continue
 		}
 
-		if err := tx.Create(order).Error; err != nil {
+		ok, err := deductInventoryQuantity(tx, order.StoreID, item.ProductID, -delta, src)
+		if err != nil {
 			return err
 		}
+		if !ok {
+			return apicode.Newf(apicode.InventoryInsufficient, "商品【%s】库存不足，出库失败", inventoryItemName(item))
+		}
+	}
 
-		for _, item := range order.Items {
-			if order.Type == model.InventoryTypeIn {
-				if err := incrementInventoryQuantity(tx, order.StoreID, item.ProductID, item.Quantity, item.Unit); err != nil {
-					return err
-				}
-				continue
-			}
+	return nil
+}
 
-			res := tx.Model(&model.Inventory{}).
-				Where("store_id = ? AND product_id = ? AND quantity >= ?", order.StoreID, item.ProductID, item.Quantity).
-				Update("quantity", gorm.Expr("quantity - ?", item.Quantity))
-			if res.Error != nil {
-				return res.Error
-			}
-			if res.RowsAffected == 0 {
-				name := item.ProductName
-				if name == "" {
-					name = fmt.Sprintf("商品ID:%d", item.ProductID)
-				}
-				return apicode.Newf(apicode.InventoryInsufficient, "商品【%s】库存不足，出库失败", name)
-			}
-		}
+// inventoryOrderItemDelta 明细对库存的变动量：入库为正、出库为负，盘点调整按明细自带符号
+func inventoryOrderItemDelta(orderType int8, quantity float64) float64 {
+	if orderType == model.InventoryTypeOut {
+		return -quantity
+	}
+	return quantity
+}
 
-		return nil
-	})
+func inventoryItemName(item model.InventoryOrderItem) string {
+	if item.ProductName == "" {
+		return fmt.Sprintf("商品ID:%d", item.ProductID)
+	}
+	return item.ProductName
 }
 
 // GetOrderByNo 根据单号获取出入库单
@@ -238,10 +285,13 @@ func (m *InventoryModule) ListOrders(req *model.ListInventoryOrderReq) ([]*model
 // GenerateOrderNo 生成单据编号
 // 入库: RK + 日期 + 序号，如 RK202412070001
 // 出库: CK + 日期 + 序号，如 CK202412070001
+// 盘点调整: TZ + 日期 + 序号，如 TZ202412070001
 func (m *InventoryModule) GenerateOrderNo(orderType int8) string {
 	prefix := "RK" // 入库
 	if orderType == model.InventoryTypeOut {
 		prefix = "CK" // 出库
+	} else if orderType == model.InventoryTypeAdjust {
+		prefix = "TZ" // 盘点调整
 	}
 
 	today := time.Now().Format("20060102")
@@ -45,14 +45,13 @@ func (m *InventoryLossModule) CreateWithStockDeduct(order *model.InventoryLossOr
 			return err
 		}
 
+		src := inventoryLossMovementSource(order, order.Reason)
 		for _, item := range order.Items {
-			res := tx.Model(&model.Inventory{}).
-				Where("store_id = ? AND product_id = ? AND quantity >= ?", order.StoreID, item.ProductID, item.BaseQuantity).
-				Update("quantity", gorm.Expr("quantity - ?", item.BaseQuantity))
-			if res.Error != nil {
-				return res.Error
+			ok, err := deductInventoryQuantity(tx, order.StoreID, item.ProductID, item.BaseQuantity, src)
+			if err != nil {
+				return err
 			}
-			if res.RowsAffected == 0 {
+			if !ok {
 				name := item.ProductName
 				if name == "" {
 					name = fmt.Sprintf("商品ID:%d", item.ProductID)
@@ -65,6 +64,18 @@ func (m *InventoryLossModule) CreateWithStockDeduct(order *model.InventoryLossOr
 	})
 }
 
+// inventoryLossMovementSource 报损单对应的流水来源
+func inventoryLossMovementSource(order *model.InventoryLossOrder, remark string) model.InventoryMovementSource {
+	return model.InventoryMovementSource{
+		Type:         model.MovementSourceInventoryLoss,
+		ID:           order.ID,
+		No:           order.OrderNo,
+		OperatorID:   order.OperatorID,
+		OperatorName: order.OperatorName,
+		Remark:       remark,
+	}
+}
+
 func (m *InventoryLossModule) GetByIDScoped(id, storeID uint, hqUnbound bool) (*model.InventoryLossOrder, error) {
 	var order model.InventoryLossOrder
 	query := m.db.Preload("Items").Preload("Member").Where("id = ?", id)
@@ -106,8 +117,9 @@ func (m *InventoryLossModule) CancelWithStockRestore(id, storeID uint, hqUnbound
 			return apicode.Newf(apicode.OrderStateConflict, "单据已撤销")
 		}
 
+		src := inventoryLossMovementSource(&order, "撤销回补")
 		for _, item := range order.Items {
-			if err := incrementInventoryQuantity(tx, order.StoreID, item.ProductID, item.BaseQuantity, item.BaseUnit); err != nil {
+			if err := restoreStockIn(tx, order.StoreID, item.ProductID, item.BaseQuantity, item.BaseUnit, src.Type, src.ID, src); err != nil {
 				return err
 			}
 		}
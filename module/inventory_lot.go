@@ -0,0 +1,380 @@
+package module
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// lotConsumption 单个批次的计划扣减量
+type lotConsumption struct {
+	LotID    uint
+	Quantity float64
+}
+
+// stockIn 在调用方事务内增加库存并记入对应到期日批次；无到期日的入库归入未标注到期日的批次。
+// 同时追加一条库存流水，批次的首次入库单号取 src.No。
+func stockIn(tx *gorm.DB, storeID, productID uint, quantity float64, unit string, productionDate, expiryDate *time.Time, src model.InventoryMovementSource) error {
+	if err := incrementInventoryQuantity(tx, storeID, productID, quantity, unit); err != nil {
+		return err
+	}
+	if err := addInventoryLot(tx, storeID, productID, quantity, unit, productionDate, expiryDate, src.No); err != nil {
+		return err
+	}
+	return recordInventoryMovement(tx, storeID, productID, quantity, src)
+}
+
+// deductInventoryQuantity 在调用方事务内按条件扣减库存（不足时返回 false），按 FEFO 同步扣减批次并追加库存流水。
+func deductInventoryQuantity(tx *gorm.DB, storeID, productID uint, quantity float64, src model.InventoryMovementSource) (bool, error) {
+	res := tx.Model(&model.Inventory{}).
+		Where("store_id = ? AND product_id = ? AND quantity >= ?", storeID, productID, quantity).
+		Update("quantity", gorm.Expr("quantity - ?", quantity))
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return false, nil
+	}
+	consumed, err := consumeInventoryLots(tx, storeID, productID, quantity)
+	if err != nil {
+		return false, err
+	}
+	movement, err := appendInventoryMovement(tx, storeID, productID, -quantity, src)
+	if err != nil {
+		return false, err
+	}
+	return true, recordMovementLots(tx, movement, consumed)
+}
+
+// restoreStockIn 在调用方事务内回补 originType/originID 来源此前扣减的库存：按扣减时记录的批次原样退回（后扣的先退），
+// 没有批次记录的部分（启用批次前的历史库存或已全部退回）记入未标注到期日的批次。同时追加一条库存流水。
+func restoreStockIn(tx *gorm.DB, storeID, productID uint, quantity float64, unit, originType string, originID uint, src model.InventoryMovementSource) error {
+	if err := incrementInventoryQuantity(tx, storeID, productID, quantity, unit); err != nil {
+		return err
+	}
+	var allocations []model.InventoryMovementLot
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("source_type = ? AND source_id = ? AND store_id = ? AND product_id = ? AND quantity > restored_quantity",
+			originType, originID, storeID, productID).
+		Order("id DESC").
+		Find(&allocations).Error; err != nil {
+		return err
+	}
+	remaining := quantity
+	for _, r := range planLotRestore(allocations, quantity) {
+		if err := tx.Model(&model.InventoryLot{}).Where("id = ?", r.LotID).
+			Update("quantity", gorm.Expr("quantity + ?", r.Quantity)).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.InventoryMovementLot{}).Where("id = ?", r.AllocationID).
+			Update("restored_quantity", gorm.Expr("restored_quantity + ?", r.Quantity)).Error; err != nil {
+			return err
+		}
+		remaining = roundQuantity(remaining - r.Quantity)
+	}
+	if err := addInventoryLot(tx, storeID, productID, remaining, unit, nil, nil, src.No); err != nil {
+		return err
+	}
+	return recordInventoryMovement(tx, storeID, productID, quantity, src)
+}
+
+// recordMovementLots 记录出库流水扣减的批次，供回补时按原批次退回
+func recordMovementLots(tx *gorm.DB, movement *model.InventoryMovement, consumed []lotConsumption) error {
+	if movement == nil || len(consumed) == 0 {
+		return nil
+	}
+	rows := make([]model.InventoryMovementLot, 0, len(consumed))
+	for _, c := range consumed {
+		rows = append(rows, model.InventoryMovementLot{
+			MovementID: movement.ID,
+			StoreID:    movement.StoreID,
+			ProductID:  movement.ProductID,
+			SourceType: movement.SourceType,
+			SourceID:   movement.SourceID,
+			LotID:      c.LotID,
+			Quantity:   c.Quantity,
+		})
+	}
+	return tx.Create(&rows).Error
+}
+
+func addInventoryLot(tx *gorm.DB, storeID, productID uint, quantity float64, unit string, productionDate, expiryDate *time.Time, sourceOrderNo string) error {
+	if quantity <= 0 {
+		return nil
+	}
+	lot := &model.InventoryLot{
+		StoreID:        storeID,
+		ProductID:      productID,
+		LotKey:         model.InventoryLotKey(expiryDate),
+		ProductionDate: productionDate,
+		Quantity:       quantity,
+		Unit:           unit,
+		SourceOrderNo:  sourceOrderNo,
+	}
+	if lot.LotKey != "" {
+		lot.ExpiryDate = expiryDate
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "store_id"}, {Name: "product_id"}, {Name: "lot_key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"quantity": gorm.Expr("quantity + VALUES(quantity)"),
+		}),
+	}).Create(lot).Error
+}
+
+// consumeInventoryLots 按到期日先到先出扣减批次，返回实际扣减的批次。批次合计少于扣减量时，差额视为启用批次前的历史库存，不报错。
+func consumeInventoryLots(tx *gorm.DB, storeID, productID uint, quantity float64) ([]lotConsumption, error) {
+	var lots []model.InventoryLot
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("store_id = ? AND product_id = ? AND quantity > 0", storeID, productID).
+		Find(&lots).Error; err != nil {
+		return nil, err
+	}
+	plan := planLotConsumption(lots, quantity)
+	for _, c := range plan {
+		if err := tx.Model(&model.InventoryLot{}).Where("id = ?", c.LotID).
+			Update("quantity", gorm.Expr("GREATEST(quantity - ?, 0)", c.Quantity)).Error; err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// trimInventoryLots 直接改库存数量后校正批次：批次合计超出新库存时按 FEFO 扣掉多余部分；
+// 新库存更大时差额保留为未分批库存。
+func trimInventoryLots(tx *gorm.DB, storeID, productID uint, quantity float64) error {
+	var lotTotal float64
+	if err := tx.Model(&model.InventoryLot{}).
+		Where("store_id = ? AND product_id = ? AND quantity > 0", storeID, productID).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&lotTotal).Error; err != nil {
+		return err
+	}
+	if excess := roundQuantity(lotTotal - quantity); excess > 0 {
+		_, err := consumeInventoryLots(tx, storeID, productID, excess)
+		return err
+	}
+	return nil
+}
+
+// rescaleInventoryLots 库存单位纠正后按同一比例换算批次数量和单位，并同步换算出库批次记录，
+// 使之后的回补按新单位退回；换算舍入后批次合计超出新库存的部分按 FEFO 扣掉。
+func rescaleInventoryLots(tx *gorm.DB, storeID, productID uint, oldQuantity, newQuantity float64, unit string) error {
+	var lots []model.InventoryLot
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("store_id = ? AND product_id = ? AND quantity > 0", storeID, productID).
+		Find(&lots).Error; err != nil {
+		return err
+	}
+	for _, l := range planLotRescale(lots, oldQuantity, newQuantity) {
+		if err := tx.Model(&model.InventoryLot{}).Where("id = ?", l.LotID).Updates(map[string]interface{}{
+			"quantity": l.Quantity,
+			"unit":     unit,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	if oldQuantity <= 0 || oldQuantity == newQuantity {
+		return nil
+	}
+	factor := newQuantity / oldQuantity
+	return tx.Model(&model.InventoryMovementLot{}).
+		Where("store_id = ? AND product_id = ?", storeID, productID).
+		Updates(map[string]interface{}{
+			"quantity":          gorm.Expr("ROUND(quantity * ?, 2)", factor),
+			"restored_quantity": gorm.Expr("ROUND(restored_quantity * ?, 2)", factor),
+		}).Error
+}
+
+// planLotRescale 按新旧库存数量的比例换算各批次的新数量（原库存不大于 0 时不换算），
+// 舍入后合计超出新库存时按 FEFO 扣掉多余部分；未分批的历史库存随库存总量一同换算，不补入批次。
+func planLotRescale(lots []model.InventoryLot, oldQuantity, newQuantity float64) []lotConsumption {
+	factor := 1.0
+	if oldQuantity > 0 {
+		factor = newQuantity / oldQuantity
+	}
+	scaled := make([]model.InventoryLot, len(lots))
+	index := make(map[uint]int, len(lots))
+	var total float64
+	for i, lot := range lots {
+		lot.Quantity = roundQuantity(lot.Quantity * factor)
+		scaled[i] = lot
+		index[lot.ID] = i
+		total = roundQuantity(total + lot.Quantity)
+	}
+	if excess := roundQuantity(total - max(newQuantity, 0)); excess > 0 {
+		for _, c := range planLotConsumption(append([]model.InventoryLot(nil), scaled...), excess) {
+			i := index[c.LotID]
+			scaled[i].Quantity = roundQuantity(scaled[i].Quantity - c.Quantity)
+		}
+	}
+	plan := make([]lotConsumption, 0, len(scaled))
+	for _, lot := range scaled {
+		plan = append(plan, lotConsumption{LotID: lot.ID, Quantity: lot.Quantity})
+	}
+	return plan
+}
+
+// sortLotsFEFO 按到期日升序排列，未标注到期日的批次排在最后，同到期日按入库先后
+func sortLotsFEFO(lots []model.InventoryLot) {
+	sort.SliceStable(lots, func(i, j int) bool {
+		a, b := lots[i].ExpiryDate, lots[j].ExpiryDate
+		switch {
+		case a == nil && b == nil:
+			return lots[i].ID < lots[j].ID
+		case a == nil:
+			return false
+		case b == nil:
+			return true
+		case !a.Equal(*b):
+			return a.Before(*b)
+		default:
+			return lots[i].ID < lots[j].ID
+		}
+	})
+}
+
+// planLotConsumption 计算 FEFO 扣减计划
+func planLotConsumption(lots []model.InventoryLot, quantity float64) []lotConsumption {
+	sortLotsFEFO(lots)
+	plan := make([]lotConsumption, 0, len(lots))
+	remaining := quantity
+	for _, lot := range lots {
+		if remaining <= 0 {
+			break
+		}
+		if lot.Quantity <= 0 {
+			continue
+		}
+		take := lot.Quantity
+		if take > remaining {
+			take = remaining
+		}
+		plan = append(plan, lotConsumption{LotID: lot.ID, Quantity: take})
+		remaining = roundQuantity(remaining - take)
+	}
+	return plan
+}
+
+// lotRestore 单条扣减记录的计划退回量
+type lotRestore struct {
+	AllocationID uint
+	LotID        uint
+	Quantity     float64
+}
+
+// planLotRestore 按 allocations 的顺序计算退回计划，每条最多退回其未退回的数量
+func planLotRestore(allocations []model.InventoryMovementLot, quantity float64) []lotRestore {
+	plan := make([]lotRestore, 0, len(allocations))
+	remaining := quantity
+	for _, a := range allocations {
+		if remaining <= 0 {
+			break
+		}
+		open := roundQuantity(a.Quantity - a.RestoredQuantity)
+		if open <= 0 {
+			continue
+		}
+		take := min(open, remaining)
+		plan = append(plan, lotRestore{AllocationID: a.ID, LotID: a.LotID, Quantity: take})
+		remaining = roundQuantity(remaining - take)
+	}
+	return plan
+}
+
+// ListLotsByInventory 查询单个库存记录的批次明细（含未分批的历史库存）
+func (m *InventoryModule) ListLotsByInventory(inv *model.Inventory) (*model.InventoryLotBreakdown, error) {
+	lotMap, err := m.listLotViews(m.db.Where("store_id = ? AND product_id = ?", inv.StoreID, inv.ProductID))
+	if err != nil {
+		return nil, err
+	}
+	lots := lotMap[lotOwner{inv.StoreID, inv.ProductID}]
+	if lots == nil {
+		lots = []*model.InventoryLotView{}
+	}
+	return &model.InventoryLotBreakdown{
+		InventoryID:       inv.ID,
+		StoreID:           inv.StoreID,
+		ProductID:         inv.ProductID,
+		Quantity:          inv.Quantity,
+		Unit:              inv.Unit,
+		UntrackedQuantity: untrackedLotQuantity(inv.Quantity, lots),
+		Lots:              lots,
+	}, nil
+}
+
+// attachLots 为库存列表行填充批次明细
+func (m *InventoryModule) attachLots(rows []*model.InventoryWithProduct) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	storeIDs := make([]uint, 0, len(rows))
+	productIDs := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		storeIDs = append(storeIDs, row.StoreID)
+		productIDs = append(productIDs, row.ProductID)
+	}
+	lotMap, err := m.listLotViews(m.db.Where("store_id IN ? AND product_id IN ?", storeIDs, productIDs))
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		row.Lots = lotMap[lotOwner{row.StoreID, row.ProductID}]
+		if row.Lots == nil {
+			row.Lots = []*model.InventoryLotView{}
+		}
+		row.UntrackedQuantity = untrackedLotQuantity(row.Quantity, row.Lots)
+	}
+	return nil
+}
+
+type lotOwner struct {
+	storeID   uint
+	productID uint
+}
+
+func (m *InventoryModule) listLotViews(query *gorm.DB) (map[lotOwner][]*model.InventoryLotView, error) {
+	var lots []model.InventoryLot
+	if err := query.Where("quantity > 0").Find(&lots).Error; err != nil {
+		return nil, err
+	}
+	sortLotsFEFO(lots)
+	result := make(map[lotOwner][]*model.InventoryLotView)
+	for _, lot := range lots {
+		key := lotOwner{lot.StoreID, lot.ProductID}
+		result[key] = append(result[key], &model.InventoryLotView{
+			ID:             lot.ID,
+			ProductionDate: lot.ProductionDate,
+			ExpiryDate:     lot.ExpiryDate,
+			Quantity:       lot.Quantity,
+			Unit:           lot.Unit,
+			SourceOrderNo:  lot.SourceOrderNo,
+		})
+	}
+	return result, nil
+}
+
+func untrackedLotQuantity(total float64, lots []*model.InventoryLotView) float64 {
+	remaining := total
+	for _, lot := range lots {
+		remaining -= lot.Quantity
+	}
+	if remaining = roundQuantity(remaining); remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// ListLotLabels 按批次ID查询批次及商品名称，用于打印批次标签
+func (m *InventoryModule) ListLotLabels(ids []uint) ([]*model.InventoryLotLabel, error) {
+	var rows []*model.InventoryLotLabel
+	err := m.db.Table("inventory_lots").
+		Select("inventory_lots.*, supplier_products.name AS product_name").
+		Joins("LEFT JOIN supplier_products ON supplier_products.id = inventory_lots.product_id").
+		Where("inventory_lots.id IN ?", ids).
+		Order("inventory_lots.id ASC").
+		Find(&rows).Error
+	return rows, err
+}
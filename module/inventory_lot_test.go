@@ -0,0 +1,105 @@
+package module
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+func lotDate(s string) *time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return &t
+}
+
+func TestPlanLotConsumptionFEFO(t *testing.T) {
+	lots := []model.InventoryLot{
+		{ID: 1, Quantity: 10},
+		{ID: 2, ExpiryDate: lotDate("2026-12-01"), Quantity: 5},
+		{ID: 3, ExpiryDate: lotDate("2026-11-01"), Quantity: 4},
+		{ID: 4, ExpiryDate: lotDate("2026-11-01"), Quantity: 0},
+	}
+	plan := planLotConsumption(lots, 12)
+	want := []lotConsumption{{LotID: 3, Quantity: 4}, {LotID: 2, Quantity: 5}, {LotID: 1, Quantity: 3}}
+	if len(plan) != len(want) {
+		t.Fatalf("plan = %#v, want %#v", plan, want)
+	}
+	for i := range want {
+		if plan[i] != want[i] {
+			t.Fatalf("plan[%d] = %#v, want %#v", i, plan[i], want[i])
+		}
+	}
+}
+
+func TestPlanLotConsumptionToleratesUntrackedStock(t *testing.T) {
+	lots := []model.InventoryLot{{ID: 1, ExpiryDate: lotDate("2026-11-01"), Quantity: 2}}
+	plan := planLotConsumption(lots, 5)
+	if len(plan) != 1 || plan[0].Quantity != 2 {
+		t.Fatalf("plan = %#v", plan)
+	}
+}
+
+func TestUntrackedLotQuantity(t *testing.T) {
+	lots := []*model.InventoryLotView{{Quantity: 3.5}, {Quantity: 1.2}}
+	if got := untrackedLotQuantity(10, lots); got != 5.3 {
+		t.Fatalf("untrackedLotQuantity() = %v, want 5.3", got)
+	}
+	if got := untrackedLotQuantity(4, lots); got != 0 {
+		t.Fatalf("untrackedLotQuantity() = %v, want 0", got)
+	}
+}
+
+func TestPlanLotRestoreSkipsRestoredAndCapsQuantity(t *testing.T) {
+	allocations := []model.InventoryMovementLot{
+		{ID: 9, LotID: 3, Quantity: 2, RestoredQuantity: 2},
+		{ID: 8, LotID: 2, Quantity: 5, RestoredQuantity: 1},
+		{ID: 7, LotID: 1, Quantity: 3},
+	}
+	plan := planLotRestore(allocations, 6)
+	want := []lotRestore{{AllocationID: 8, LotID: 2, Quantity: 4}, {AllocationID: 7, LotID: 1, Quantity: 2}}
+	if len(plan) != len(want) {
+		t.Fatalf("plan = %#v, want %#v", plan, want)
+	}
+	for i := range want {
+		if plan[i] != want[i] {
+			t.Fatalf("plan[%d] = %#v, want %#v", i, plan[i], want[i])
+		}
+	}
+	if plan := planLotRestore(allocations, 20); len(plan) != 2 || plan[0].Quantity != 4 || plan[1].Quantity != 3 {
+		t.Fatalf("over-restore plan = %#v", plan)
+	}
+}
+
+func TestPlanLotRescaleKeepsLotTotalsWithinStock(t *testing.T) {
+	lots := []model.InventoryLot{
+		{ID: 1, Quantity: 2},
+		{ID: 2, ExpiryDate: lotDate("2026-11-01"), Quantity: 1},
+		{ID: 3, ExpiryDate: lotDate("2026-12-01"), Quantity: 3},
+	}
+	// 6 箱纠正为 72 瓶（1 箱 = 12 瓶），另有 1 箱未分批的历史库存
+	plan := planLotRescale(lots, 7, 84)
+	want := map[uint]float64{1: 24, 2: 12, 3: 36}
+	var total float64
+	for _, c := range plan {
+		if c.Quantity != want[c.LotID] {
+			t.Fatalf("lot %d = %v, want %v", c.LotID, c.Quantity, want[c.LotID])
+		}
+		total += c.Quantity
+	}
+	if total != 72 {
+		t.Fatalf("lot total = %v, want 72", total)
+	}
+
+	// 舍入后超出新库存的部分按 FEFO 扣掉
+	plan = planLotRescale([]model.InventoryLot{
+		{ID: 1, Quantity: 1},
+		{ID: 2, ExpiryDate: lotDate("2026-11-01"), Quantity: 1},
+	}, 2, 0.05)
+	got := map[uint]float64{}
+	for _, c := range plan {
+		got[c.LotID] = c.Quantity
+	}
+	if got[1] != 0.03 || got[2] != 0.02 {
+		t.Fatalf("rounded plan = %#v", got)
+	}
+}
@@ -0,0 +1,235 @@
+package module
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"gorm.io/gorm"
+)
+
+// movementTolerance 结余比对容差（数量按两位小数存储）
+const movementTolerance = 0.005
+
+// 流水发生顺序：期初流水可能在启用后补写（ID 较大），始终排在该商品其他流水之前
+const (
+	movementOrderAsc  = "source_type = 'opening' DESC, id ASC"
+	movementOrderDesc = "source_type = 'opening' ASC, id DESC"
+)
+
+// recordInventoryMovement 在调用方事务内追加库存流水，结余取本事务更新后的库存行，
+// 库存行已被本事务的更新锁定，故同一门店商品的流水顺序与结余一致。
+func recordInventoryMovement(tx *gorm.DB, storeID, productID uint, delta float64, src model.InventoryMovementSource) error {
+	_, err := appendInventoryMovement(tx, storeID, productID, delta, src)
+	return err
+}
+
+// appendInventoryMovement 同 recordInventoryMovement，返回写入的流水（delta 为 0 时不写入，返回 nil）
+func appendInventoryMovement(tx *gorm.DB, storeID, productID uint, delta float64, src model.InventoryMovementSource) (*model.InventoryMovement, error) {
+	if delta == 0 {
+		return nil, nil
+	}
+	var inv model.Inventory
+	if err := tx.Unscoped().Select("quantity", "unit").
+		Where("store_id = ? AND product_id = ?", storeID, productID).
+		First(&inv).Error; err != nil {
+		return nil, err
+	}
+	movement := &model.InventoryMovement{
+		StoreID:      storeID,
+		ProductID:    productID,
+		Delta:        roundQuantity(delta),
+		BalanceAfter: inv.Quantity,
+		Unit:         inv.Unit,
+		SourceType:   src.Type,
+		SourceID:     src.ID,
+		SourceNo:     src.No,
+		OperatorID:   src.OperatorID,
+		OperatorName: src.OperatorName,
+		Remark:       src.Remark,
+	}
+	if err := tx.Create(movement).Error; err != nil {
+		return nil, err
+	}
+	return movement, nil
+}
+
+// inventoryOrderMovementSource 出入库单对应的流水来源
+func inventoryOrderMovementSource(sourceType string, order *model.InventoryOrder) model.InventoryMovementSource {
+	return model.InventoryMovementSource{
+		Type:         sourceType,
+		ID:           order.ID,
+		No:           order.OrderNo,
+		OperatorID:   order.OperatorID,
+		OperatorName: order.OperatorName,
+		Remark:       order.Remark,
+	}
+}
+
+type InventoryMovementModule struct {
+	db *gorm.DB
+}
+
+func NewInventoryMovementModule(db *gorm.DB) *InventoryMovementModule {
+	return &InventoryMovementModule{db: db}
+}
+
+// ListByProduct 门店商品在 [start, end) 内的流水，按发生顺序
+func (m *InventoryMovementModule) ListByProduct(storeID, productID uint, start, end time.Time) ([]*model.InventoryMovement, error) {
+	var rows []*model.InventoryMovement
+	err := m.db.Where("store_id = ? AND product_id = ? AND created_at >= ? AND created_at < ?", storeID, productID, start, end).
+		Order(movementOrderAsc).
+		Find(&rows).Error
+	return rows, err
+}
+
+// LastBefore 门店商品在 t 之前的最后一条流水，不存在时返回 nil
+func (m *InventoryMovementModule) LastBefore(storeID, productID uint, t time.Time) (*model.InventoryMovement, error) {
+	var rows []*model.InventoryMovement
+	if err := m.db.Where("store_id = ? AND product_id = ? AND created_at < ?", storeID, productID, t).
+		Order(movementOrderDesc).Limit(1).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return rows[0], nil
+}
+
+// FirstFrom 门店商品在 t 及之后的第一条流水，不存在时返回 nil
+func (m *InventoryMovementModule) FirstFrom(storeID, productID uint, t time.Time) (*model.InventoryMovement, error) {
+	var rows []*model.InventoryMovement
+	if err := m.db.Where("store_id = ? AND product_id = ? AND created_at >= ?", storeID, productID, t).
+		Order(movementOrderAsc).Limit(1).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return rows[0], nil
+}
+
+// ListAllByProduct 门店商品的全部流水（对账用）
+func (m *InventoryMovementModule) ListAllByProduct(storeID, productID uint) ([]model.InventoryMovement, error) {
+	var rows []model.InventoryMovement
+	err := m.db.Where("store_id = ? AND product_id = ?", storeID, productID).Order(movementOrderAsc).Find(&rows).Error
+	return rows, err
+}
+
+// InventoryMovementKey 有库存或流水的门店商品
+type InventoryMovementKey struct {
+	StoreID   uint
+	ProductID uint
+}
+
+// ListReconcileKeys 需要对账的门店商品：库存表与流水表的并集
+func (m *InventoryMovementModule) ListReconcileKeys(storeID uint) ([]InventoryMovementKey, error) {
+	var fromInventory, fromMovements []InventoryMovementKey
+	invQuery := m.db.Model(&model.Inventory{}).Select("store_id, product_id")
+	movQuery := m.db.Model(&model.InventoryMovement{}).Distinct("store_id, product_id")
+	if storeID > 0 {
+		invQuery = invQuery.Where("store_id = ?", storeID)
+		movQuery = movQuery.Where("store_id = ?", storeID)
+	}
+	if err := invQuery.Scan(&fromInventory).Error; err != nil {
+		return nil, err
+	}
+	if err := movQuery.Scan(&fromMovements).Error; err != nil {
+		return nil, err
+	}
+	seen := make(map[InventoryMovementKey]bool, len(fromInventory))
+	keys := make([]InventoryMovementKey, 0, len(fromInventory)+len(fromMovements))
+	for _, k := range append(fromInventory, fromMovements...) {
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].StoreID != keys[j].StoreID {
+			return keys[i].StoreID < keys[j].StoreID
+		}
+		return keys[i].ProductID < keys[j].ProductID
+	})
+	return keys, nil
+}
+
+// CreateOpening 写入期初流水：at 为期初时间点，早于该商品的第一条流水
+func (m *InventoryMovementModule) CreateOpening(storeID, productID uint, quantity float64, unit string, at time.Time) error {
+	return m.db.Create(&model.InventoryMovement{
+		StoreID:      storeID,
+		ProductID:    productID,
+		Delta:        roundQuantity(quantity),
+		BalanceAfter: roundQuantity(quantity),
+		Unit:         unit,
+		SourceType:   model.MovementSourceOpening,
+		Remark:       "启用库存流水前的历史库存",
+		CreatedAt:    at,
+	}).Error
+}
+
+// MovementChainBreak 结余断链：上一结余 + 本次变动 ≠ 本次结余
+type MovementChainBreak struct {
+	MovementID uint
+	Expected   float64
+	Actual     float64
+}
+
+// MovementReconcileResult 单个门店商品的流水重算结果
+type MovementReconcileResult struct {
+	Recomputed  float64              // 由期初起逐笔累加得到的结余
+	LastBalance float64              // 最后一条流水记录的结余
+	Breaks      []MovementChainBreak // 结余断链
+}
+
+// ReconcileMovements 按发生顺序重算结余并检查断链。期初流水无论 ID 先后都视为第一笔；
+// 没有期初流水时从 0 开始累加，第一条流水即会因历史库存而断链。
+func ReconcileMovements(rows []model.InventoryMovement) MovementReconcileResult {
+	ordered := make([]model.InventoryMovement, len(rows))
+	copy(ordered, rows)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		oi := ordered[i].SourceType == model.MovementSourceOpening
+		oj := ordered[j].SourceType == model.MovementSourceOpening
+		if oi != oj {
+			return oi
+		}
+		return ordered[i].ID < ordered[j].ID
+	})
+
+	var result MovementReconcileResult
+	for _, row := range ordered {
+		// 断链按上一条记录的结余判断，每处断点只报告一次
+		expected := roundQuantity(result.LastBalance + row.Delta)
+		if math.Abs(expected-row.BalanceAfter) > movementTolerance {
+			result.Breaks = append(result.Breaks, MovementChainBreak{
+				MovementID: row.ID,
+				Expected:   expected,
+				Actual:     row.BalanceAfter,
+			})
+		}
+		result.Recomputed = roundQuantity(result.Recomputed + row.Delta)
+		result.LastBalance = row.BalanceAfter
+	}
+	return result
+}
+
+// MovementOpeningGap 未写期初时缺失的历史库存：第一条非期初流水变动前的结余
+func MovementOpeningGap(rows []model.InventoryMovement) float64 {
+	var first *model.InventoryMovement
+	for i := range rows {
+		if rows[i].SourceType == model.MovementSourceOpening {
+			return 0
+		}
+		if first == nil || rows[i].ID < first.ID {
+			first = &rows[i]
+		}
+	}
+	if first == nil {
+		return 0
+	}
+	return roundQuantity(first.BalanceAfter - first.Delta)
+}
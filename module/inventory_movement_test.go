@@ -0,0 +1,66 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+func TestReconcileMovementsConsistentChain(t *testing.T) {
+	rows := []model.InventoryMovement{
+		{ID: 1, Delta: 10, BalanceAfter: 10},
+		{ID: 2, Delta: -3.5, BalanceAfter: 6.5},
+		{ID: 3, Delta: 2, BalanceAfter: 8.5},
+	}
+	result := ReconcileMovements(rows)
+	if len(result.Breaks) != 0 {
+		t.Fatalf("breaks = %#v", result.Breaks)
+	}
+	if result.Recomputed != 8.5 || result.LastBalance != 8.5 {
+		t.Fatalf("result = %#v", result)
+	}
+}
+
+func TestReconcileMovementsOpeningWrittenLater(t *testing.T) {
+	rows := []model.InventoryMovement{
+		{ID: 5, Delta: -2, BalanceAfter: 3},
+		{ID: 9, Delta: 5, BalanceAfter: 5, SourceType: model.MovementSourceOpening},
+		{ID: 6, Delta: 4, BalanceAfter: 7},
+	}
+	result := ReconcileMovements(rows)
+	if len(result.Breaks) != 0 {
+		t.Fatalf("breaks = %#v", result.Breaks)
+	}
+	if result.Recomputed != 7 {
+		t.Fatalf("recomputed = %v, want 7", result.Recomputed)
+	}
+}
+
+func TestReconcileMovementsReportsEachBreakOnce(t *testing.T) {
+	rows := []model.InventoryMovement{
+		{ID: 1, Delta: 10, BalanceAfter: 10},
+		{ID: 2, Delta: -2, BalanceAfter: 7},
+		{ID: 3, Delta: -1, BalanceAfter: 6},
+	}
+	result := ReconcileMovements(rows)
+	if len(result.Breaks) != 1 || result.Breaks[0].MovementID != 2 || result.Breaks[0].Expected != 8 {
+		t.Fatalf("breaks = %#v", result.Breaks)
+	}
+	if result.Recomputed != 7 || result.LastBalance != 6 {
+		t.Fatalf("result = %#v", result)
+	}
+}
+
+func TestMovementOpeningGap(t *testing.T) {
+	rows := []model.InventoryMovement{
+		{ID: 4, Delta: 1, BalanceAfter: 13},
+		{ID: 3, Delta: -3, BalanceAfter: 12},
+	}
+	if gap := MovementOpeningGap(rows); gap != 15 {
+		t.Fatalf("gap = %v, want 15", gap)
+	}
+	rows = append(rows, model.InventoryMovement{ID: 8, SourceType: model.MovementSourceOpening, Delta: 15, BalanceAfter: 15})
+	if gap := MovementOpeningGap(rows); gap != 0 {
+		t.Fatalf("gap with opening = %v, want 0", gap)
+	}
+}
@@ -434,6 +434,8 @@ func (m *MemberModule) CreatePointRule(req *model.UpsertMemberPointRuleReq, stor
 		Name:        name,
 		SpendAmount: req.SpendAmount,
 		Points:      req.Points,
+		ValidDays:   req.ValidDays,
+		PointValue:  req.PointValue,
 		Status:      status,
 		Remark:      strings.TrimSpace(req.Remark),
 	}
@@ -461,6 +463,8 @@ func (m *MemberModule) UpdatePointRule(id uint, req *model.UpsertMemberPointRule
 		"name":         name,
 		"spend_amount": req.SpendAmount,
 		"points":       req.Points,
+		"valid_days":   req.ValidDays,
+		"point_value":  req.PointValue,
 		"status":       status,
 		"remark":       strings.TrimSpace(req.Remark),
 	}
@@ -531,7 +535,7 @@ func (m *MemberModule) ListWineStorages(req *model.ListMemberWineStorageReq, sto
 	return rows, total, nil
 }
 
-// AdjustWineStorage 存入或取出会员存酒
+// AdjustWineStorage 存入或取出会员存酒：存入按存酒策略生成带到期时间的批次，取出按先到期先扣的顺序扣减批次
 func (m *MemberModule) AdjustWineStorage(storeID, operatorID uint, operatorName string, isAdmin bool, txnType int, req *model.MemberWineAdjustReq) (*model.MemberWineStorage, error) {
 	if req == nil {
 		return nil, apicode.New(apicode.MissingParameter)
@@ -541,6 +545,21 @@ func (m *MemberModule) AdjustWineStorage(storeID, operatorID uint, operatorName
 		return nil, apicode.Newf(apicode.ValidationFailed, "请填写酒品名称")
 	}
 	unit := strings.TrimSpace(req.Unit)
+	if txnType == model.MemberWineTxnDeposit && req.ProductID > 0 {
+		var product model.SupplierProduct
+		if err := m.db.Select("id", "unit").First(&product, req.ProductID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, apicode.Newf(apicode.NotFound, "关联商品不存在")
+			}
+			return nil, err
+		}
+		// 到期转入门店库存时按商品库存单位入库，存酒单位需与之一致
+		if unit == "" {
+			unit = product.Unit
+		} else if unit != product.Unit {
+			return nil, apicode.Newf(apicode.ValidationFailed, "关联商品的存酒单位需与商品库存单位（%s）一致", product.Unit)
+		}
+	}
 	if unit == "" {
 		unit = "瓶"
 	}
@@ -584,22 +603,38 @@ func (m *MemberModule) AdjustWineStorage(storeID, operatorID uint, operatorName
 			}
 		}
 
+		now := time.Now()
 		nextQty := storage.Quantity
+		var expireAt *time.Time
 		switch txnType {
 		case model.MemberWineTxnDeposit:
 			nextQty += req.Quantity
+			storageDays := req.StorageDays
+			if storageDays <= 0 {
+				policy, err := matchWinePolicy(tx, realStoreID)
+				if err != nil {
+					return err
+				}
+				storageDays = policy.StorageDays
+			}
+			expireAt = pointExpireAt(now, storageDays)
 		case model.MemberWineTxnWithdraw:
 			if storage.Quantity < req.Quantity {
 				return apicode.Newf(apicode.WineInsufficient, "存酒数量不足，当前剩余 %.2f%s", storage.Quantity, storage.Unit)
 			}
 			nextQty -= req.Quantity
+			if err := consumeWineLots(tx, storage.ID, req.Quantity); err != nil {
+				return err
+			}
 		default:
 			return apicode.Newf(apicode.ValidationFailed, "不支持的存酒操作类型")
 		}
 
 		if err := tx.Model(&storage).Updates(map[string]interface{}{
-			"quantity": nextQty,
-			"remark":   strings.TrimSpace(req.Remark),
+			"quantity":         nextQty,
+			"remark":           strings.TrimSpace(req.Remark),
+			"last_access_at":   now,
+			"idle_reminded_at": nil,
 		}).Error; err != nil {
 			return err
 		}
@@ -618,7 +653,16 @@ func (m *MemberModule) AdjustWineStorage(storeID, operatorID uint, operatorName
 			OperatorID:   operatorID,
 			OperatorName: operatorName,
 		}
-		return tx.Create(txn).Error
+		if txnType == model.MemberWineTxnDeposit {
+			txn.ProductID = req.ProductID
+			txn.Remaining = req.Quantity
+			txn.ExpireAt = expireAt
+			txn.Photos = req.PhotoURLs
+		}
+		if err := tx.Create(txn).Error; err != nil {
+			return err
+		}
+		return refreshWineStorageExpireAt(tx, storage.ID)
 	}); err != nil {
 		return nil, err
 	}
@@ -806,6 +850,9 @@ func (m *MemberModule) CreateRechargeOrder(req *model.CreateRechargeOrderReq, st
 		PayStatus:   model.PayStatusPending,
 		PayType:     req.PayType,
 		Remark:      req.Remark,
+		PackageID:   req.PackageID,
+		PackageName: req.PackageName,
+		FirstGift:   req.FirstGift,
 	}
 	if err := m.db.Create(order).Error; err != nil {
 		return nil, err
@@ -888,7 +935,8 @@ func (m *MemberModule) ListRechargeOrders(memberID uint, status *model.PayStatus
 	return orders, total, nil
 }
 
-// PayRechargeOrder 支付充值单（余额充值）
+// PayRechargeOrder 支付充值单（余额充值）。首充加赠在锁定会员的事务内复核：
+// 并发创建的首笔充值只有先支付的一笔保留首充加赠，其余按已有充值取消加赠。
 func (m *MemberModule) PayRechargeOrder(orderNo string, storeID uint, isAdmin bool) (*model.RechargeOrder, error) {
 	var order model.RechargeOrder
 	query := m.scopedRechargeOrderQuery(storeID, isAdmin)
@@ -896,45 +944,63 @@ func (m *MemberModule) PayRechargeOrder(orderNo string, storeID uint, isAdmin bo
 		return nil, err
 	}
 
-	// 获取会员并加锁
-	var member model.Member
-	if err := m.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&member, order.MemberID).Error; err != nil {
-		return nil, err
-	}
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		// 获取会员并加锁
+		member, err := lockMember(tx, order.MemberID)
+		if err != nil {
+			return err
+		}
+		// 锁内重新读取订单，防止重复支付
+		if err := tx.Where("id = ? AND pay_status = ?", order.ID, model.PayStatusPending).First(&order).Error; err != nil {
+			return err
+		}
 
-	// 设置会员名称到订单中（用于通知）
-	order.MemberName = member.Name
+		now := time.Now()
+		orderUpdates := map[string]interface{}{
+			"pay_status": model.PayStatusPaid,
+			"pay_time":   &now,
+		}
+		if order.FirstGift.IsPositive() {
+			hasPaid, err := hasPaidRecharge(tx, member.ID)
+			if err != nil {
+				return err
+			}
+			if hasPaid {
+				revokeFirstRechargeGift(&order)
+				orderUpdates["first_gift"] = order.FirstGift
+				orderUpdates["gift_amount"] = order.GiftAmount
+				orderUpdates["total_amount"] = order.TotalAmount
+				orderUpdates["remark"] = order.Remark
+			}
+		}
 
-	// 计算新余额
-	newBalance := member.Balance.Add(order.TotalAmount)
+		// 计算新余额
+		newBalance := member.Balance.Add(order.TotalAmount)
 
-	// 更新会员余额
-	if err := m.db.Model(&member).Updates(map[string]interface{}{
-		"balance": newBalance,
-		"version": member.Version + 1,
-	}).Error; err != nil {
-		return nil, err
-	}
+		// 更新会员余额
+		if err := tx.Model(member).Updates(map[string]interface{}{
+			"balance": newBalance,
+			"version": member.Version + 1,
+		}).Error; err != nil {
+			return err
+		}
 
-	now := time.Now()
-	// 更新订单状态
-	if err := m.db.Model(&order).Updates(map[string]interface{}{
-		"pay_status": model.PayStatusPaid,
-		"pay_time":   &now,
-	}).Error; err != nil {
-		return nil, err
-	}
+		// 更新订单状态
+		if err := tx.Model(&order).Updates(orderUpdates).Error; err != nil {
+			return err
+		}
 
-	// 记录流水
-	walletLog := &model.WalletLog{
-		MemberID:       order.MemberID,
-		ChangeType:     model.ChangeTypeRecharge,
-		ChangeAmount:   order.TotalAmount,
-		BalanceAfter:   newBalance,
-		RelatedOrderNo: orderNo,
-		Remark:         "充值",
-	}
-	if err := m.db.Create(walletLog).Error; err != nil {
+		// 记录流水
+		return tx.Create(&model.WalletLog{
+			MemberID:       order.MemberID,
+			ChangeType:     model.ChangeTypeRecharge,
+			ChangeAmount:   order.TotalAmount,
+			BalanceAfter:   newBalance,
+			RelatedOrderNo: orderNo,
+			Remark:         "充值",
+		}).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 
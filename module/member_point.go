@@ -0,0 +1,448 @@
+package module
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type pointConsumption struct {
+	LogID  uint
+	Points int
+}
+
+// calcEarnPoints 按规则计算消费可得积分：每满 SpendAmount 元得 Points 分
+func calcEarnPoints(amount float64, rule *model.MemberPointRule) int {
+	if rule == nil || rule.SpendAmount <= 0 || rule.Points <= 0 || amount <= 0 {
+		return 0
+	}
+	// 加一个极小量，避免 100/0.1 之类的浮点误差少算一档
+	return int(math.Floor(amount/rule.SpendAmount+1e-9)) * rule.Points
+}
+
+// pointExpireAt 积分批次过期时间：获得当日起算 validDays 天，到期日次日零点失效；0 表示永久有效
+func pointExpireAt(from time.Time, validDays int) *time.Time {
+	if validDays <= 0 {
+		return nil
+	}
+	y, m, d := from.Date()
+	t := time.Date(y, m, d, 0, 0, 0, 0, from.Location()).AddDate(0, 0, validDays+1)
+	return &t
+}
+
+// storeAccountPointBase 记账单计积分金额：实收金额（扣除抹零与积分抵扣部分）
+func storeAccountPointBase(account *model.StoreAccount) float64 {
+	base := roundMoney(account.TotalAmount - account.RoundAmount - account.PointsDeduction)
+	if base < 0 {
+		return 0
+	}
+	return base
+}
+
+// planPointConsumption 计算积分批次扣减计划：preferID 对应批次优先，其余按过期时间先到先扣，永久有效的排在最后
+func planPointConsumption(lots []model.MemberPointLog, points int, preferID uint) []pointConsumption {
+	sort.SliceStable(lots, func(i, j int) bool {
+		if (lots[i].ID == preferID) != (lots[j].ID == preferID) {
+			return lots[i].ID == preferID
+		}
+		a, b := lots[i].ExpireAt, lots[j].ExpireAt
+		switch {
+		case a == nil && b == nil:
+			return lots[i].ID < lots[j].ID
+		case a == nil:
+			return false
+		case b == nil:
+			return true
+		case !a.Equal(*b):
+			return a.Before(*b)
+		}
+		return lots[i].ID < lots[j].ID
+	})
+	plan := make([]pointConsumption, 0, len(lots))
+	remaining := points
+	for _, lot := range lots {
+		if remaining <= 0 {
+			break
+		}
+		if lot.Remaining <= 0 {
+			continue
+		}
+		take := lot.Remaining
+		if take > remaining {
+			take = remaining
+		}
+		plan = append(plan, pointConsumption{LogID: lot.ID, Points: take})
+		remaining -= take
+	}
+	return plan
+}
+
+// matchPointRule 取门店生效的积分规则，门店规则优先于全局规则；未配置时返回 nil
+func matchPointRule(tx *gorm.DB, storeID uint) (*model.MemberPointRule, error) {
+	var rule model.MemberPointRule
+	err := tx.Where("store_id IN ? AND status = ?", []uint{storeID, 0}, model.MemberPointRuleEnabled).
+		Order("store_id DESC, id DESC").
+		First(&rule).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// lockMember 在调用方事务内锁定会员行，积分变动均在锁内完成
+func lockMember(tx *gorm.DB, memberID uint) (*model.Member, error) {
+	var member model.Member
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", memberID).First(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// changeMemberPoints 在调用方事务内变更会员积分并追加积分流水，批次扣减由调用方处理
+func changeMemberPoints(tx *gorm.DB, member *model.Member, entry *model.MemberPointLog) error {
+	after := member.Points + entry.ChangePoints
+	if after < 0 {
+		return apicode.Newf(apicode.PointsInsufficient, "积分不足，当前可用 %d 分", member.Points)
+	}
+	if err := tx.Model(&model.Member{}).Where("id = ?", member.ID).Update("points", after).Error; err != nil {
+		return err
+	}
+	member.Points = after
+	entry.MemberID = member.ID
+	entry.PointsAfter = after
+	return tx.Create(entry).Error
+}
+
+// consumePointLots 在调用方事务内按先到期先扣的顺序扣减积分批次；批次不足（历史手工改积分）时只扣到为止
+func consumePointLots(tx *gorm.DB, memberID uint, points int, preferID uint) error {
+	var lots []model.MemberPointLog
+	if err := tx.Where("member_id = ? AND remaining > 0", memberID).Find(&lots).Error; err != nil {
+		return err
+	}
+	for _, c := range planPointConsumption(lots, points, preferID) {
+		if err := tx.Model(&model.MemberPointLog{}).Where("id = ?", c.LogID).
+			Update("remaining", gorm.Expr("GREATEST(remaining - ?, 0)", c.Points)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyStoreAccountPoints 在记账事务内扣减抵扣积分，已支付的记账单同时累计消费积分
+func applyStoreAccountPoints(tx *gorm.DB, account *model.StoreAccount) error {
+	if account.MemberID == nil || *account.MemberID == 0 {
+		if account.PointsRedeemed > 0 {
+			return apicode.Newf(apicode.ValidationFailed, "积分抵扣需关联会员")
+		}
+		return nil
+	}
+	if account.PointsRedeemed <= 0 && account.PaymentStatus != model.StoreAccountPaymentPaid {
+		return nil
+	}
+	member, err := lockMember(tx, *account.MemberID)
+	if err != nil {
+		return apicode.New(apicode.MemberNotFound)
+	}
+	if account.PointsRedeemed > 0 {
+		if member.Points < account.PointsRedeemed {
+			return apicode.Newf(apicode.PointsInsufficient, "积分不足，当前可用 %d 分", member.Points)
+		}
+		if err := consumePointLots(tx, member.ID, account.PointsRedeemed, 0); err != nil {
+			return err
+		}
+		if err := changeMemberPoints(tx, member, &model.MemberPointLog{
+			StoreID:         account.StoreID,
+			ChangeType:      model.PointChangeDeduct,
+			ChangePoints:    -account.PointsRedeemed,
+			StoreAccountID:  account.ID,
+			DeductionAmount: account.PointsDeduction,
+			RelatedOrderNo:  account.AccountNo,
+			Remark:          fmt.Sprintf("抵扣 %.2f 元", account.PointsDeduction),
+			OperatorID:      account.OperatorID,
+		}); err != nil {
+			return err
+		}
+	}
+	if account.PaymentStatus == model.StoreAccountPaymentPaid {
+		return accrueStoreAccountPoints(tx, member, account)
+	}
+	return nil
+}
+
+// accrueStoreAccountPoints 按门店积分规则累计记账单积分，同一记账单只累计一次
+func accrueStoreAccountPoints(tx *gorm.DB, member *model.Member, account *model.StoreAccount) error {
+	var count int64
+	if err := tx.Model(&model.MemberPointLog{}).
+		Where("store_account_id = ? AND change_type = ?", account.ID, model.PointChangeEarn).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	rule, err := matchPointRule(tx, account.StoreID)
+	if err != nil || rule == nil {
+		return err
+	}
+	tier, err := memberTierOf(tx, member)
+	if err != nil {
+		return err
+	}
+	base := storeAccountPointBase(account)
+	points := applyPointsMultiplier(calcEarnPoints(base, rule), tier)
+	if points <= 0 {
+		return nil
+	}
+	remark := fmt.Sprintf("消费 %.2f 元", base)
+	if tier != nil && tier.PointsMultiplier > 0 && tier.PointsMultiplier != 1 {
+		remark += fmt.Sprintf("，%s %g 倍积分", tier.Name, tier.PointsMultiplier)
+	}
+	return changeMemberPoints(tx, member, &model.MemberPointLog{
+		StoreID:        account.StoreID,
+		ChangeType:     model.PointChangeEarn,
+		ChangePoints:   points,
+		Remaining:      points,
+		ExpireAt:       pointExpireAt(time.Now(), rule.ValidDays),
+		StoreAccountID: account.ID,
+		RuleID:         rule.ID,
+		RelatedOrderNo: account.AccountNo,
+		Remark:         remark,
+		OperatorID:     account.OperatorID,
+	})
+}
+
+// reverseStoreAccountPoints 在作废事务内冲回记账单积分：扣回获得的积分（最多扣到 0），退回抵扣使用的积分
+func reverseStoreAccountPoints(tx *gorm.DB, account *model.StoreAccount, operatorID uint) error {
+	var logs []model.MemberPointLog
+	if err := tx.Where("store_account_id = ?", account.ID).Order("id ASC").Find(&logs).Error; err != nil {
+		return err
+	}
+	if len(logs) == 0 {
+		return nil
+	}
+	var earned, redeemed int
+	var earnLogID uint
+	for _, l := range logs {
+		switch l.ChangeType {
+		case model.PointChangeEarn:
+			earned += l.ChangePoints
+			earnLogID = l.ID
+		case model.PointChangeDeduct:
+			redeemed -= l.ChangePoints
+		case model.PointChangeReverse, model.PointChangeRefund:
+			return nil
+		}
+	}
+	member, err := lockMember(tx, logs[0].MemberID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if take := min(earned, member.Points); take > 0 {
+		remark := "记账作废扣回"
+		if take < earned {
+			remark = fmt.Sprintf("记账作废扣回，已使用 %d 分无法扣回", earned-take)
+		}
+		if err := consumePointLots(tx, member.ID, take, earnLogID); err != nil {
+			return err
+		}
+		if err := changeMemberPoints(tx, member, &model.MemberPointLog{
+			StoreID:        account.StoreID,
+			ChangeType:     model.PointChangeReverse,
+			ChangePoints:   -take,
+			StoreAccountID: account.ID,
+			RelatedOrderNo: account.AccountNo,
+			Remark:         remark,
+			OperatorID:     operatorID,
+		}); err != nil {
+			return err
+		}
+	}
+	if redeemed > 0 {
+		var validDays int
+		if rule, err := matchPointRule(tx, account.StoreID); err != nil {
+			return err
+		} else if rule != nil {
+			validDays = rule.ValidDays
+		}
+		if err := changeMemberPoints(tx, member, &model.MemberPointLog{
+			StoreID:        account.StoreID,
+			ChangeType:     model.PointChangeRefund,
+			ChangePoints:   redeemed,
+			Remaining:      redeemed,
+			ExpireAt:       pointExpireAt(time.Now(), validDays),
+			StoreAccountID: account.ID,
+			RelatedOrderNo: account.AccountNo,
+			Remark:         "记账作废退回抵扣积分",
+			OperatorID:     operatorID,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MatchPointRule 取门店生效的积分规则，未配置时返回 nil
+func (m *MemberModule) MatchPointRule(storeID uint) (*model.MemberPointRule, error) {
+	return matchPointRule(m.db, storeID)
+}
+
+// RedeemPointsForGift 积分兑换礼品
+func (m *MemberModule) RedeemPointsForGift(memberID, storeID, operatorID uint, isAdmin bool, req *model.RedeemMemberPointsReq) (*model.Member, error) {
+	member, err := m.GetMember(memberID, storeID, isAdmin)
+	if err != nil {
+		return nil, apicode.New(apicode.MemberNotFound)
+	}
+	giftName := strings.TrimSpace(req.GiftName)
+	if giftName == "" {
+		return nil, apicode.Newf(apicode.ValidationFailed, "请填写兑换礼品")
+	}
+	remark := "兑换礼品：" + giftName
+	if r := strings.TrimSpace(req.Remark); r != "" {
+		remark += "（" + r + "）"
+	}
+	logStoreID := storeID
+	if logStoreID == 0 {
+		logStoreID = member.StoreID
+	}
+	if err := m.db.Transaction(func(tx *gorm.DB) error {
+		locked, err := lockMember(tx, member.ID)
+		if err != nil {
+			return err
+		}
+		if locked.Points < req.Points {
+			return apicode.Newf(apicode.PointsInsufficient, "积分不足，当前可用 %d 分", locked.Points)
+		}
+		if err := consumePointLots(tx, locked.ID, req.Points, 0); err != nil {
+			return err
+		}
+		return changeMemberPoints(tx, locked, &model.MemberPointLog{
+			StoreID:      logStoreID,
+			ChangeType:   model.PointChangeGift,
+			ChangePoints: -req.Points,
+			Remark:       remark,
+			OperatorID:   operatorID,
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return m.GetMember(memberID, storeID, isAdmin)
+}
+
+// ListPointLogs 查询会员积分流水
+func (m *MemberModule) ListPointLogs(memberID uint, req *model.ListMemberPointLogReq, storeID uint, isAdmin bool) ([]model.MemberPointLog, int64, error) {
+	if _, err := m.GetMember(memberID, storeID, isAdmin); err != nil {
+		return nil, 0, apicode.New(apicode.MemberNotFound)
+	}
+	rows := make([]model.MemberPointLog, 0)
+	var total int64
+	page := req.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	query := m.db.Model(&model.MemberPointLog{}).Where("member_id = ?", memberID)
+	if req.ChangeType > 0 {
+		query = query.Where("change_type = ?", req.ChangeType)
+	}
+	if req.StartDate != "" {
+		query = query.Where("create_time >= ?", req.StartDate)
+	}
+	if req.EndDate != "" {
+		query = query.Where("create_time < DATE_ADD(?, INTERVAL 1 DAY)", req.EndDate)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	for i := range rows {
+		rows[i].ChangeTypeName = rows[i].ChangeType.String()
+	}
+	return rows, total, nil
+}
+
+// ExpireDuePoints 清零到期积分批次的剩余积分，每次最多处理 limit 个批次，返回处理批次数与清零积分合计
+func (m *MemberModule) ExpireDuePoints(now time.Time, limit int) (int, int, error) {
+	var lots []model.MemberPointLog
+	if err := m.db.Where("remaining > 0 AND expire_at IS NOT NULL AND expire_at <= ?", now).
+		Order("expire_at ASC, id ASC").Limit(limit).Find(&lots).Error; err != nil {
+		return 0, 0, err
+	}
+	var expiredTotal int
+	for _, lot := range lots {
+		var expired int
+		if err := m.db.Transaction(func(tx *gorm.DB) error {
+			member, err := lockMember(tx, lot.MemberID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			// 锁内重读批次，避免与并发抵扣重复扣减
+			var current model.MemberPointLog
+			if err := tx.Where("id = ? AND remaining > 0", lot.ID).First(&current).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			}
+			if err := tx.Model(&current).Update("remaining", 0).Error; err != nil {
+				return err
+			}
+			if member == nil {
+				return nil
+			}
+			expired = min(current.Remaining, member.Points)
+			if expired <= 0 {
+				return nil
+			}
+			return changeMemberPoints(tx, member, &model.MemberPointLog{
+				StoreID:        current.StoreID,
+				ChangeType:     model.PointChangeExpire,
+				ChangePoints:   -expired,
+				RelatedOrderNo: current.RelatedOrderNo,
+				Remark:         fmt.Sprintf("%s 获得的积分已到期", current.CreateTime.Format("2006-01-02")),
+			})
+		}); err != nil {
+			return 0, expiredTotal, err
+		}
+		expiredTotal += expired
+	}
+	return len(lots), expiredTotal, nil
+}
+
+// AccrueMemberPoints 未支付记账改为已支付后补记会员积分，已记过或已作废时跳过
+func (m *StoreAccountModule) AccrueMemberPoints(id uint) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		var account model.StoreAccount
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&account).Error; err != nil {
+			return err
+		}
+		if account.IsCanceled || account.PaymentStatus != model.StoreAccountPaymentPaid || account.MemberID == nil || *account.MemberID == 0 {
+			return nil
+		}
+		member, err := lockMember(tx, *account.MemberID)
+		if err != nil {
+			return err
+		}
+		return accrueStoreAccountPoints(tx, member, &account)
+	})
+}
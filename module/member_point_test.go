@@ -0,0 +1,87 @@
+package module
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+func TestCalcEarnPoints(t *testing.T) {
+	rule := &model.MemberPointRule{SpendAmount: 10, Points: 2}
+	cases := []struct {
+		amount float64
+		want   int
+	}{
+		{0, 0},
+		{9.99, 0},
+		{10, 2},
+		{58.5, 10},
+	}
+	for _, c := range cases {
+		if got := calcEarnPoints(c.amount, rule); got != c.want {
+			t.Fatalf("calcEarnPoints(%v) = %d, want %d", c.amount, got, c.want)
+		}
+	}
+	if got := calcEarnPoints(100, &model.MemberPointRule{SpendAmount: 0.1, Points: 1}); got != 1000 {
+		t.Fatalf("calcEarnPoints() with fractional spend = %d, want 1000", got)
+	}
+	if got := calcEarnPoints(100, nil); got != 0 {
+		t.Fatalf("calcEarnPoints() without rule = %d, want 0", got)
+	}
+}
+
+func TestStoreAccountPointBase(t *testing.T) {
+	account := &model.StoreAccount{TotalAmount: 128, RoundAmount: 3, PointsDeduction: 20}
+	if got := storeAccountPointBase(account); got != 105 {
+		t.Fatalf("storeAccountPointBase() = %v, want 105", got)
+	}
+	if got := storeAccountPointBase(&model.StoreAccount{TotalAmount: 5, PointsDeduction: 8}); got != 0 {
+		t.Fatalf("storeAccountPointBase() = %v, want 0", got)
+	}
+}
+
+func TestPointExpireAt(t *testing.T) {
+	from := time.Date(2026, 10, 18, 15, 30, 0, 0, time.Local)
+	if got := pointExpireAt(from, 0); got != nil {
+		t.Fatalf("pointExpireAt(0) = %v, want nil", got)
+	}
+	want := time.Date(2027, 10, 19, 0, 0, 0, 0, time.Local)
+	if got := pointExpireAt(from, 365); got == nil || !got.Equal(want) {
+		t.Fatalf("pointExpireAt(365) = %v, want %v", got, want)
+	}
+}
+
+func TestPlanPointConsumptionExpiringFirst(t *testing.T) {
+	lots := []model.MemberPointLog{
+		{ID: 1, Remaining: 50},
+		{ID: 2, Remaining: 30, ExpireAt: lotDate("2027-03-01")},
+		{ID: 3, Remaining: 20, ExpireAt: lotDate("2027-01-01")},
+		{ID: 4, Remaining: 0, ExpireAt: lotDate("2026-12-01")},
+	}
+	plan := planPointConsumption(lots, 60, 0)
+	want := []pointConsumption{{LogID: 3, Points: 20}, {LogID: 2, Points: 30}, {LogID: 1, Points: 10}}
+	if len(plan) != len(want) {
+		t.Fatalf("plan = %#v, want %#v", plan, want)
+	}
+	for i := range want {
+		if plan[i] != want[i] {
+			t.Fatalf("plan[%d] = %#v, want %#v", i, plan[i], want[i])
+		}
+	}
+}
+
+func TestPlanPointConsumptionPrefersLot(t *testing.T) {
+	lots := []model.MemberPointLog{
+		{ID: 1, Remaining: 10, ExpireAt: lotDate("2027-01-01")},
+		{ID: 2, Remaining: 15},
+	}
+	plan := planPointConsumption(lots, 20, 2)
+	if len(plan) != 2 || plan[0] != (pointConsumption{LogID: 2, Points: 15}) || plan[1] != (pointConsumption{LogID: 1, Points: 5}) {
+		t.Fatalf("plan = %#v", plan)
+	}
+	single := []model.MemberPointLog{{ID: 1, Remaining: 10}}
+	if plan := planPointConsumption(single, 30, 0); len(plan) != 1 || plan[0].Points != 10 {
+		t.Fatalf("plan with untracked points = %#v", plan)
+	}
+}
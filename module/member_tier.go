@@ -0,0 +1,314 @@
+package module
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	"gorm.io/gorm"
+)
+
+// memberTiersForStore 取门店生效的等级定义（按等级升序）：门店配置了启用的等级时使用门店等级，否则使用全局等级
+func memberTiersForStore(tx *gorm.DB, storeID uint) ([]model.MemberTier, error) {
+	var tiers []model.MemberTier
+	if err := tx.Where("store_id IN ? AND status = ?", []uint{storeID, 0}, model.MemberTierEnabled).
+		Order("level ASC").Find(&tiers).Error; err != nil {
+		return nil, err
+	}
+	return pickTierSet(tiers, storeID), nil
+}
+
+// pickTierSet 从门店与全局等级中选出生效的一组：门店有任一等级时整组使用门店等级，不与全局等级混用
+func pickTierSet(tiers []model.MemberTier, storeID uint) []model.MemberTier {
+	var own, global []model.MemberTier
+	for _, t := range tiers {
+		switch {
+		case storeID > 0 && t.StoreID == storeID:
+			own = append(own, t)
+		case t.StoreID == 0:
+			global = append(global, t)
+		}
+	}
+	if len(own) > 0 {
+		return own
+	}
+	return global
+}
+
+// memberTierOf 取会员当前等级的定义，未配置等级或等级不存在时返回 nil
+func memberTierOf(tx *gorm.DB, member *model.Member) (*model.MemberTier, error) {
+	tiers, err := memberTiersForStore(tx, member.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tiers {
+		if tiers[i].Level == member.Level {
+			return &tiers[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// applyPointsMultiplier 按等级积分倍数放大积分，倍数未设置时不变
+func applyPointsMultiplier(points int, tier *model.MemberTier) int {
+	if tier == nil || tier.PointsMultiplier <= 0 || tier.PointsMultiplier == 1 {
+		return points
+	}
+	return int(math.Floor(float64(points)*tier.PointsMultiplier + 1e-9))
+}
+
+// GetMemberTier 取会员当前等级的定义，未配置时返回 nil
+func (m *MemberModule) GetMemberTier(member *model.Member) (*model.MemberTier, error) {
+	return memberTierOf(m.db, member)
+}
+
+// ListTiers 查询会员等级定义
+func (m *MemberModule) ListTiers(req *model.ListMemberTierReq, storeID uint, isAdmin bool) ([]model.MemberTier, error) {
+	rows := make([]model.MemberTier, 0)
+	query := m.db.Model(&model.MemberTier{})
+	if isAdmin {
+		if req.StoreID > 0 {
+			query = query.Where("store_id = ?", req.StoreID)
+		}
+	} else {
+		query = query.Where("store_id IN ?", []uint{storeID, 0})
+	}
+	if req.Status > 0 {
+		query = query.Where("status = ?", req.Status)
+	}
+	if err := query.Order("store_id ASC, level ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// CreateTier 新增会员等级
+func (m *MemberModule) CreateTier(req *model.UpsertMemberTierReq, storeID uint, isAdmin bool) (*model.MemberTier, error) {
+	realStoreID := storeID
+	if isAdmin {
+		realStoreID = req.StoreID
+	}
+	tier := &model.MemberTier{StoreID: realStoreID}
+	if err := fillMemberTier(tier, req); err != nil {
+		return nil, err
+	}
+	if err := m.ensureTierLevelFree(realStoreID, tier.Level, 0); err != nil {
+		return nil, err
+	}
+	if err := m.db.Create(tier).Error; err != nil {
+		return nil, err
+	}
+	return tier, nil
+}
+
+// UpdateTier 更新会员等级，门店账号只能修改本门店等级
+func (m *MemberModule) UpdateTier(id uint, req *model.UpsertMemberTierReq, storeID uint, isAdmin bool) (*model.MemberTier, error) {
+	tier, err := m.GetTier(id, storeID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if err := fillMemberTier(tier, req); err != nil {
+		return nil, err
+	}
+	if err := m.ensureTierLevelFree(tier.StoreID, tier.Level, tier.ID); err != nil {
+		return nil, err
+	}
+	if err := m.db.Save(tier).Error; err != nil {
+		return nil, err
+	}
+	return tier, nil
+}
+
+// DeleteTier 删除会员等级；已处于该等级的会员在下次评定时重新定级
+func (m *MemberModule) DeleteTier(id uint, storeID uint, isAdmin bool) error {
+	tier, err := m.GetTier(id, storeID, isAdmin)
+	if err != nil {
+		return err
+	}
+	return m.db.Delete(&model.MemberTier{}, tier.ID).Error
+}
+
+func (m *MemberModule) GetTier(id uint, storeID uint, isAdmin bool) (*model.MemberTier, error) {
+	var tier model.MemberTier
+	query := m.db.Model(&model.MemberTier{})
+	if !isAdmin {
+		query = query.Where("store_id = ?", storeID)
+	}
+	if err := query.Where("id = ?", id).First(&tier).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apicode.New(apicode.NotFound)
+		}
+		return nil, err
+	}
+	return &tier, nil
+}
+
+func (m *MemberModule) ensureTierLevelFree(storeID uint, level int, excludeID uint) error {
+	var count int64
+	query := m.db.Model(&model.MemberTier{}).Where("store_id = ? AND level = ?", storeID, level)
+	if excludeID > 0 {
+		query = query.Where("id <> ?", excludeID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return apicode.Newf(apicode.DuplicateOperation, "等级 %d 已存在", level)
+	}
+	return nil
+}
+
+func fillMemberTier(tier *model.MemberTier, req *model.UpsertMemberTierReq) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return apicode.Newf(apicode.ValidationFailed, "请填写等级名称")
+	}
+	discountRate := req.DiscountRate
+	if discountRate <= 0 {
+		discountRate = 1
+	}
+	multiplier := req.PointsMultiplier
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+	status := req.Status
+	if status == 0 {
+		status = model.MemberTierEnabled
+	}
+	tier.Level = req.Level
+	tier.Name = name
+	tier.MinSpend = req.MinSpend
+	tier.MinRecharge = req.MinRecharge
+	tier.MinPoints = req.MinPoints
+	tier.DiscountRate = discountRate
+	tier.PointsMultiplier = multiplier
+	tier.RechargeBonusRate = req.RechargeBonusRate
+	tier.Status = status
+	tier.Remark = strings.TrimSpace(req.Remark)
+	return nil
+}
+
+// ListTiersForStore 取门店生效的等级定义（按等级升序），未配置时返回空
+func (m *MemberModule) ListTiersForStore(storeID uint) ([]model.MemberTier, error) {
+	return memberTiersForStore(m.db, storeID)
+}
+
+// ListMembersAfter 按 ID 顺序分批读取会员
+func (m *MemberModule) ListMembersAfter(afterID uint, limit int) ([]model.Member, error) {
+	var members []model.Member
+	if err := m.db.Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// LoadTierMetrics 统计会员等级评定指标：since 之后已支付未作废记账的实收金额、累计已支付充值金额与当前积分
+func (m *MemberModule) LoadTierMetrics(members []model.Member, since time.Time) (map[uint]*model.MemberTierMetrics, error) {
+	result := make(map[uint]*model.MemberTierMetrics, len(members))
+	if len(members) == 0 {
+		return result, nil
+	}
+	ids := make([]uint, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.ID)
+		result[member.ID] = &model.MemberTierMetrics{Points: member.Points}
+	}
+
+	type sumRow struct {
+		MemberID uint
+		Amount   float64
+	}
+	var spends []sumRow
+	if err := m.db.Model(&model.StoreAccount{}).
+		Select("member_id, COALESCE(SUM(GREATEST(total_amount - round_amount - points_deduction, 0)), 0) AS amount").
+		Where("member_id IN ? AND is_canceled = ? AND payment_status = ? AND account_date >= ?",
+			ids, false, model.StoreAccountPaymentPaid, since.Format("2006-01-02")).
+		Group("member_id").Scan(&spends).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range spends {
+		if metrics := result[row.MemberID]; metrics != nil {
+			metrics.Spend = roundMoney(row.Amount)
+		}
+	}
+
+	var recharges []sumRow
+	if err := m.db.Model(&model.RechargeOrder{}).
+		Select("member_id, COALESCE(SUM(pay_amount), 0) AS amount").
+		Where("member_id IN ? AND pay_status = ?", ids, model.PayStatusPaid).
+		Group("member_id").Scan(&recharges).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range recharges {
+		if metrics := result[row.MemberID]; metrics != nil {
+			metrics.RechargeTotal = roundMoney(row.Amount)
+		}
+	}
+	return result, nil
+}
+
+// ChangeMemberTier 变更会员等级并写入变动记录；会员等级已被并发修改时跳过
+func (m *MemberModule) ChangeMemberTier(member *model.Member, log *model.MemberTierLog) (bool, error) {
+	changed := false
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.Member{}).
+			Where("id = ? AND level = ?", member.ID, log.FromLevel).
+			Update("level", log.ToLevel)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		changed = true
+		log.MemberID = member.ID
+		log.StoreID = member.StoreID
+		return tx.Create(log).Error
+	})
+	return changed, err
+}
+
+// ListTierLogs 查询会员等级变动记录
+func (m *MemberModule) ListTierLogs(req *model.ListMemberTierLogReq, storeID uint, isAdmin bool) ([]model.MemberTierLog, int64, error) {
+	rows := make([]model.MemberTierLog, 0)
+	var total int64
+	page := req.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	query := m.db.Model(&model.MemberTierLog{}).Preload("Member")
+	if isAdmin {
+		if req.StoreID > 0 {
+			query = query.Where("store_id = ?", req.StoreID)
+		}
+	} else {
+		query = query.Where("store_id = ?", storeID)
+	}
+	if req.MemberID > 0 {
+		query = query.Where("member_id = ?", req.MemberID)
+	}
+	if req.ChangeType != "" {
+		query = query.Where("change_type = ?", req.ChangeType)
+	}
+	if req.StartDate != "" {
+		query = query.Where("created_at >= ?", req.StartDate)
+	}
+	if req.EndDate != "" {
+		query = query.Where("created_at < DATE_ADD(?, INTERVAL 1 DAY)", req.EndDate)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
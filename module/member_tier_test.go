@@ -0,0 +1,47 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+func TestPickTierSet(t *testing.T) {
+	tiers := []model.MemberTier{
+		{ID: 1, StoreID: 0, Level: 1},
+		{ID: 2, StoreID: 0, Level: 2},
+		{ID: 3, StoreID: 5, Level: 1},
+	}
+	own := pickTierSet(tiers, 5)
+	if len(own) != 1 || own[0].ID != 3 {
+		t.Fatalf("pickTierSet(store 5) = %+v, want only store tiers", own)
+	}
+	global := pickTierSet(tiers, 7)
+	if len(global) != 2 || global[0].ID != 1 || global[1].ID != 2 {
+		t.Fatalf("pickTierSet(store 7) = %+v, want global tiers", global)
+	}
+	if got := pickTierSet(nil, 5); len(got) != 0 {
+		t.Fatalf("pickTierSet(nil) = %+v, want empty", got)
+	}
+}
+
+func TestApplyPointsMultiplier(t *testing.T) {
+	cases := []struct {
+		points int
+		tier   *model.MemberTier
+		want   int
+	}{
+		{10, nil, 10},
+		{10, &model.MemberTier{PointsMultiplier: 0}, 10},
+		{10, &model.MemberTier{PointsMultiplier: 1}, 10},
+		{10, &model.MemberTier{PointsMultiplier: 1.5}, 15},
+		{7, &model.MemberTier{PointsMultiplier: 1.5}, 10},
+		{3, &model.MemberTier{PointsMultiplier: 1.1}, 3},
+		{10, &model.MemberTier{PointsMultiplier: 1.1}, 11},
+	}
+	for _, c := range cases {
+		if got := applyPointsMultiplier(c.points, c.tier); got != c.want {
+			t.Fatalf("applyPointsMultiplier(%d, %+v) = %d, want %d", c.points, c.tier, got, c.want)
+		}
+	}
+}
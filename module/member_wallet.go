@@ -0,0 +1,218 @@
+package module
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// storeAccountPayable 记账单实收金额：合计 - 抹零 - 积分抵扣
+func storeAccountPayable(account *model.StoreAccount) float64 {
+	payable := roundMoney(account.TotalAmount - account.RoundAmount - account.PointsDeduction)
+	if payable < 0 {
+		return 0
+	}
+	return payable
+}
+
+// debitMemberWallet 在调用方事务内扣减会员余额并记录消费流水；version 非空时校验会员版本号。
+// member 需已加锁，扣减后同步更新其余额与版本号
+func debitMemberWallet(tx *gorm.DB, member *model.Member, amount float64, version *int, orderNo, remark string) error {
+	if amount <= 0 {
+		return nil
+	}
+	if version != nil && *version != member.Version {
+		return apicode.New(apicode.OptimisticLockConflict)
+	}
+	change := decimal.NewFromFloat(amount).Round(2)
+	if member.Balance.LessThan(change) {
+		return apicode.Newf(apicode.BalanceInsufficient, "会员余额不足，当前余额 %s 元", member.Balance.StringFixed(2))
+	}
+	return changeMemberWallet(tx, member, member.Balance.Sub(change), &model.WalletLog{
+		ChangeType:     model.ChangeTypeConsume,
+		ChangeAmount:   change,
+		RelatedOrderNo: orderNo,
+		Remark:         remark,
+	})
+}
+
+// creditMemberWallet 在调用方事务内退回会员余额并记录退款流水，member 需已加锁
+func creditMemberWallet(tx *gorm.DB, member *model.Member, amount float64, orderNo, remark string) error {
+	if amount <= 0 {
+		return nil
+	}
+	change := decimal.NewFromFloat(amount).Round(2)
+	return changeMemberWallet(tx, member, member.Balance.Add(change), &model.WalletLog{
+		ChangeType:     model.ChangeTypeRefund,
+		ChangeAmount:   change,
+		RelatedOrderNo: orderNo,
+		Remark:         remark,
+	})
+}
+
+func changeMemberWallet(tx *gorm.DB, member *model.Member, balance decimal.Decimal, entry *model.WalletLog) error {
+	res := tx.Model(&model.Member{}).
+		Where("id = ? AND version = ?", member.ID, member.Version).
+		Updates(map[string]interface{}{
+			"balance": balance,
+			"version": member.Version + 1,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return apicode.New(apicode.OptimisticLockConflict)
+	}
+	member.Balance = balance
+	member.Version++
+	entry.MemberID = member.ID
+	entry.BalanceAfter = balance
+	return tx.Create(entry).Error
+}
+
+// applyStoreAccountWallet 在记账事务内按余额支付金额扣减会员余额
+func applyStoreAccountWallet(tx *gorm.DB, account *model.StoreAccount) error {
+	if account.BalanceAmount <= 0 {
+		return nil
+	}
+	if account.MemberID == nil || *account.MemberID == 0 {
+		return apicode.Newf(apicode.ValidationFailed, "余额支付需关联会员")
+	}
+	member, err := lockMember(tx, *account.MemberID)
+	if err != nil {
+		return apicode.New(apicode.MemberNotFound)
+	}
+	return debitMemberWallet(tx, member, account.BalanceAmount, account.MemberVersion, account.AccountNo, "记账余额支付")
+}
+
+// refundStoreAccountWallet 在调用方事务内把记账单的余额支付退回会员
+func refundStoreAccountWallet(tx *gorm.DB, account *model.StoreAccount, amount float64, remark string) error {
+	if amount <= 0 || account.MemberID == nil || *account.MemberID == 0 {
+		return nil
+	}
+	member, err := lockMember(tx, *account.MemberID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return creditMemberWallet(tx, member, amount, account.AccountNo, remark)
+}
+
+// refundWalletOverpayment 在调用方事务内将记账单余额支付超出实收的部分退回会员余额；未超出时不处理。
+// 须在记账单金额更新之后调用，与金额修改同事务提交。
+func refundWalletOverpayment(tx *gorm.DB, id uint) error {
+	var account model.StoreAccount
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&account, id).Error; err != nil {
+		return err
+	}
+	if account.IsCanceled || account.BalanceAmount <= 0 {
+		return nil
+	}
+	payable := storeAccountPayable(&account)
+	excess := roundMoney(account.BalanceAmount - payable)
+	if excess <= 0 {
+		return nil
+	}
+	if err := refundStoreAccountWallet(tx, &account, excess, "记账改价退回"); err != nil {
+		return err
+	}
+	updates := map[string]interface{}{"balance_amount": payable}
+	if payable <= 0 {
+		updates["pay_method"] = ""
+	}
+	return tx.Model(&model.StoreAccount{}).Where("id = ?", account.ID).Updates(updates).Error
+}
+
+// SettleAccountsFromWallet 使用会员余额批量结算未结记账单，每张记账单按实收全额扣款并补记积分
+func (m *MemberModule) SettleAccountsFromWallet(memberID uint, req *model.SettleMemberAccountsReq, storeID uint, isAdmin bool) (*model.SettleMemberAccountsResult, error) {
+	result := &model.SettleMemberAccountsResult{Accounts: make([]*model.StoreAccount, 0)}
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		var member model.Member
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", memberID)
+		if !isAdmin {
+			query = query.Where("store_id = ?", storeID)
+		}
+		if err := query.First(&member).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apicode.New(apicode.MemberNotFound)
+			}
+			return err
+		}
+		if req.MemberVersion != nil && *req.MemberVersion != member.Version {
+			return apicode.New(apicode.OptimisticLockConflict)
+		}
+
+		accountQuery := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("member_id = ? AND payment_status = ? AND is_canceled = ?", member.ID, model.StoreAccountPaymentUnpaid, false)
+		if len(req.AccountIDs) > 0 {
+			accountQuery = accountQuery.Where("id IN ?", req.AccountIDs)
+		}
+		var accounts []*model.StoreAccount
+		if err := accountQuery.Order("account_date ASC, id ASC").Find(&accounts).Error; err != nil {
+			return err
+		}
+		if len(accounts) == 0 {
+			return apicode.Newf(apicode.OrderStateConflict, "没有可结算的未结记账单")
+		}
+		if len(req.AccountIDs) > 0 && len(accounts) != len(uniqueUints(req.AccountIDs)) {
+			return apicode.Newf(apicode.OrderStateConflict, "部分记账单已结算、已作废或不属于该会员")
+		}
+
+		var total float64
+		for _, account := range accounts {
+			total += storeAccountPayable(account)
+		}
+		total = roundMoney(total)
+		if member.Balance.LessThan(decimal.NewFromFloat(total)) {
+			return apicode.Newf(apicode.BalanceInsufficient, "会员余额不足，需结算 %.2f 元，当前余额 %s 元", total, member.Balance.StringFixed(2))
+		}
+
+		for _, account := range accounts {
+			payable := storeAccountPayable(account)
+			if err := debitMemberWallet(tx, &member, payable, nil, account.AccountNo, "余额结算记账"); err != nil {
+				return err
+			}
+			account.PaymentStatus = model.StoreAccountPaymentPaid
+			account.BalanceAmount = payable
+			account.PayMethod = ""
+			if err := tx.Model(&model.StoreAccount{}).Where("id = ?", account.ID).Updates(map[string]interface{}{
+				"payment_status": account.PaymentStatus,
+				"balance_amount": account.BalanceAmount,
+				"pay_method":     account.PayMethod,
+			}).Error; err != nil {
+				return fmt.Errorf("settle store account %d: %w", account.ID, err)
+			}
+			if err := accrueStoreAccountPoints(tx, &member, account); err != nil {
+				return err
+			}
+		}
+		result.Member = &member
+		result.Accounts = accounts
+		result.SettledAmount = total
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func uniqueUints(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	out := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		out = append(out, id)
+	}
+	return out
+}
@@ -0,0 +1,34 @@
+package module
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+func TestStoreAccountPayable(t *testing.T) {
+	cases := []struct {
+		account model.StoreAccount
+		want    float64
+	}{
+		{model.StoreAccount{TotalAmount: 100}, 100},
+		{model.StoreAccount{TotalAmount: 100, RoundAmount: 0.5, PointsDeduction: 10}, 89.5},
+		{model.StoreAccount{TotalAmount: 10.1, RoundAmount: 0.1}, 10},
+		{model.StoreAccount{TotalAmount: 5, PointsDeduction: 8}, 0},
+	}
+	for _, c := range cases {
+		if got := storeAccountPayable(&c.account); got != c.want {
+			t.Fatalf("storeAccountPayable(%+v) = %v, want %v", c.account, got, c.want)
+		}
+	}
+}
+
+func TestUniqueUints(t *testing.T) {
+	if got := uniqueUints([]uint{3, 1, 3, 2, 1}); !reflect.DeepEqual(got, []uint{3, 1, 2}) {
+		t.Fatalf("uniqueUints() = %v", got)
+	}
+	if got := uniqueUints(nil); len(got) != 0 {
+		t.Fatalf("uniqueUints(nil) = %v", got)
+	}
+}
@@ -0,0 +1,392 @@
+package module
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type wineConsumption struct {
+	LotID    uint
+	Quantity float64
+}
+
+// matchWinePolicy 取门店生效的存酒策略，门店策略优先于全局策略；都未配置时返回默认策略（ID 为 0）
+func matchWinePolicy(tx *gorm.DB, storeID uint) (*model.MemberWinePolicy, error) {
+	var policy model.MemberWinePolicy
+	err := tx.Where("store_id IN ?", []uint{storeID, 0}).Order("store_id DESC").First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.DefaultMemberWinePolicy(storeID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// extendWineExpireAt 续存后的到期时间：未到期的批次在原到期时间上顺延，已到期的从当天起算
+func extendWineExpireAt(current *time.Time, now time.Time, days int) time.Time {
+	if current != nil && current.After(now) {
+		return current.AddDate(0, 0, days)
+	}
+	return *pointExpireAt(now, days)
+}
+
+// planWineConsumption 计算取酒时的批次扣减计划：按到期时间先到先扣，不限期的排在最后；
+// 批次合计不足（启用批次前的历史存量）时只扣到为止
+func planWineConsumption(lots []model.MemberWineTransaction, quantity float64) []wineConsumption {
+	sorted := append([]model.MemberWineTransaction(nil), lots...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].ExpireAt, sorted[j].ExpireAt
+		switch {
+		case a == nil && b == nil:
+			return sorted[i].ID < sorted[j].ID
+		case a == nil:
+			return false
+		case b == nil:
+			return true
+		case !a.Equal(*b):
+			return a.Before(*b)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	plan := make([]wineConsumption, 0, len(sorted))
+	remaining := roundQuantity(quantity)
+	for _, lot := range sorted {
+		if remaining <= 0 {
+			break
+		}
+		if lot.Remaining <= 0 {
+			continue
+		}
+		take := min(lot.Remaining, remaining)
+		plan = append(plan, wineConsumption{LotID: lot.ID, Quantity: take})
+		remaining = roundQuantity(remaining - take)
+	}
+	return plan
+}
+
+// consumeWineLots 在调用方事务内按先到期先扣的顺序扣减存入批次
+func consumeWineLots(tx *gorm.DB, storageID uint, quantity float64) error {
+	var lots []model.MemberWineTransaction
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("storage_id = ? AND type = ? AND remaining > 0", storageID, model.MemberWineTxnDeposit).
+		Find(&lots).Error; err != nil {
+		return err
+	}
+	for _, c := range planWineConsumption(lots, quantity) {
+		if err := tx.Model(&model.MemberWineTransaction{}).Where("id = ?", c.LotID).
+			Update("remaining", gorm.Expr("GREATEST(remaining - ?, 0)", c.Quantity)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshWineStorageExpireAt 在调用方事务内把存酒的到期时间刷新为剩余批次中最早的到期时间
+func refreshWineStorageExpireAt(tx *gorm.DB, storageID uint) error {
+	var next struct{ ExpireAt *time.Time }
+	if err := tx.Model(&model.MemberWineTransaction{}).
+		Select("MIN(expire_at) AS expire_at").
+		Where("storage_id = ? AND type = ? AND remaining > 0", storageID, model.MemberWineTxnDeposit).
+		Scan(&next).Error; err != nil {
+		return err
+	}
+	return tx.Model(&model.MemberWineStorage{}).Where("id = ?", storageID).Update("expire_at", next.ExpireAt).Error
+}
+
+// lockWineLot 在调用方事务内锁定存入批次及其存酒记录
+func lockWineLot(tx *gorm.DB, lotID uint) (*model.MemberWineTransaction, *model.MemberWineStorage, error) {
+	var lot model.MemberWineTransaction
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND type = ?", lotID, model.MemberWineTxnDeposit).
+		First(&lot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, apicode.New(apicode.WineStorageNotFound)
+		}
+		return nil, nil, err
+	}
+	var storage model.MemberWineStorage
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&storage, lot.StorageID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, apicode.New(apicode.WineStorageNotFound)
+		}
+		return nil, nil, err
+	}
+	return &lot, &storage, nil
+}
+
+// wineLotDue 批次到期时间不晚于 dueBefore；dueBefore 为空表示手工处理，不校验到期
+func wineLotDue(lot *model.MemberWineTransaction, dueBefore *time.Time) bool {
+	if dueBefore == nil {
+		return true
+	}
+	return lot.ExpireAt != nil && !lot.ExpireAt.After(*dueBefore)
+}
+
+// GetWinePolicy 获取门店生效的存酒策略
+func (m *MemberModule) GetWinePolicy(storeID uint) (*model.MemberWinePolicy, error) {
+	return matchWinePolicy(m.db, storeID)
+}
+
+// ListWinePolicies 查询已配置的存酒策略
+func (m *MemberModule) ListWinePolicies() ([]model.MemberWinePolicy, error) {
+	rows := make([]model.MemberWinePolicy, 0)
+	if err := m.db.Order("store_id ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// UpsertWinePolicy 保存门店（store_id=0 为全局）存酒策略，只影响之后的存入与到期处理
+func (m *MemberModule) UpsertWinePolicy(req *model.UpsertMemberWinePolicyReq) (*model.MemberWinePolicy, error) {
+	var policy model.MemberWinePolicy
+	err := m.db.Where("store_id = ?", req.StoreID).First(&policy).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	policy.StoreID = req.StoreID
+	policy.StorageDays = req.StorageDays
+	policy.RemindDays = req.RemindDays
+	policy.IdleDays = req.IdleDays
+	policy.ExpireAction = req.ExpireAction
+	policy.ExtendDays = req.ExtendDays
+	if err := m.db.Save(&policy).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// ListWineLots 查询有剩余的存入批次，按到期时间先后排序
+func (m *MemberModule) ListWineLots(req *model.ListMemberWineLotReq, storeID uint, isAdmin bool) ([]model.MemberWineTransaction, int64, error) {
+	rows := make([]model.MemberWineTransaction, 0)
+	var total int64
+	page := req.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	query := m.scopedWineTransactionQuery(storeID, isAdmin).
+		Where("member_wine_transactions.type = ? AND member_wine_transactions.remaining > 0", model.MemberWineTxnDeposit)
+	if req.StoreID > 0 && isAdmin {
+		query = query.Where("member_wine_transactions.store_id = ?", req.StoreID)
+	}
+	if req.MemberID > 0 {
+		query = query.Where("member_wine_transactions.member_id = ?", req.MemberID)
+	}
+	if req.StorageID > 0 {
+		query = query.Where("member_wine_transactions.storage_id = ?", req.StorageID)
+	}
+	if req.ExpireWithin > 0 {
+		query = query.Where("member_wine_transactions.expire_at IS NOT NULL AND member_wine_transactions.expire_at <= ?",
+			*pointExpireAt(time.Now(), req.ExpireWithin))
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("member_wine_transactions.expire_at IS NULL, member_wine_transactions.expire_at ASC, member_wine_transactions.id ASC").
+		Offset((page - 1) * pageSize).Limit(pageSize).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
+
+// GetWineLot 获取存入批次
+func (m *MemberModule) GetWineLot(id uint, storeID uint, isAdmin bool) (*model.MemberWineTransaction, error) {
+	var row model.MemberWineTransaction
+	if err := m.scopedWineTransactionQuery(storeID, isAdmin).
+		Where("member_wine_transactions.id = ? AND member_wine_transactions.type = ?", id, model.MemberWineTxnDeposit).
+		First(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apicode.New(apicode.WineStorageNotFound)
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// ListDueWineLots 查询已到期且有剩余的存入批次，每次最多 limit 个
+func (m *MemberModule) ListDueWineLots(now time.Time, limit int) ([]model.MemberWineTransaction, error) {
+	rows := make([]model.MemberWineTransaction, 0)
+	if err := m.db.Preload("Member").
+		Where("type = ? AND remaining > 0 AND expire_at IS NOT NULL AND expire_at <= ?", model.MemberWineTxnDeposit, now).
+		Order("expire_at ASC, id ASC").Limit(limit).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ExtendWineLot 存入批次续存 days 天并记录续存流水；dueBefore 非空时（定时任务）仅处理届时已到期的批次，否则返回 nil
+func (m *MemberModule) ExtendWineLot(lotID uint, days int, dueBefore *time.Time, operatorID uint, operatorName, remark string) (*model.MemberWineTransaction, error) {
+	if days <= 0 {
+		return nil, apicode.Newf(apicode.ValidationFailed, "续存天数必须大于0")
+	}
+	var txn *model.MemberWineTransaction
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		lot, storage, err := lockWineLot(tx, lotID)
+		if err != nil {
+			return err
+		}
+		if lot.Remaining <= 0 {
+			return apicode.Newf(apicode.OrderStateConflict, "该批次存酒已全部取出")
+		}
+		if !wineLotDue(lot, dueBefore) {
+			return nil
+		}
+		expireAt := extendWineExpireAt(lot.ExpireAt, time.Now(), days)
+		if err := tx.Model(&model.MemberWineTransaction{}).Where("id = ?", lot.ID).Updates(map[string]interface{}{
+			"expire_at":   expireAt,
+			"reminded_at": nil,
+		}).Error; err != nil {
+			return err
+		}
+		if remark == "" {
+			remark = fmt.Sprintf("续存%d天", days)
+		}
+		txn = &model.MemberWineTransaction{
+			StoreID:      lot.StoreID,
+			StorageID:    lot.StorageID,
+			MemberID:     lot.MemberID,
+			Type:         model.MemberWineTxnExtend,
+			WineName:     lot.WineName,
+			Unit:         lot.Unit,
+			Quantity:     lot.Remaining,
+			BalanceAfter: storage.Quantity,
+			Remark:       remark,
+			OperatorID:   operatorID,
+			OperatorName: operatorName,
+			ProductID:    lot.ProductID,
+			ExpireAt:     &expireAt,
+			LotID:        lot.ID,
+		}
+		if err := tx.Create(txn).Error; err != nil {
+			return err
+		}
+		return refreshWineStorageExpireAt(tx, storage.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return txn, nil
+}
+
+// ForfeitWineLot 存入批次剩余存酒转归门店：扣减会员存量并记录到期入库流水；
+// order 非空时（批次关联了商品）在同一事务内创建入库单并增加门店库存，数量以锁内剩余为准，
+// 按明细上的生产日期/到期日记入批次（未填写时记入未标注到期日的批次）。
+// dueBefore 非空时（定时任务）仅处理届时已到期的批次，否则返回 nil
+func (m *MemberModule) ForfeitWineLot(lotID uint, order *model.InventoryOrder, dueBefore *time.Time, operatorID uint, operatorName, remark string) (*model.MemberWineTransaction, error) {
+	var txn *model.MemberWineTransaction
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		lot, storage, err := lockWineLot(tx, lotID)
+		if err != nil {
+			return err
+		}
+		if lot.Remaining <= 0 {
+			return apicode.Newf(apicode.OrderStateConflict, "该批次存酒已全部取出")
+		}
+		if !wineLotDue(lot, dueBefore) {
+			return nil
+		}
+		quantity := min(lot.Remaining, storage.Quantity)
+		balance := roundQuantity(storage.Quantity - quantity)
+		if err := tx.Model(&model.MemberWineTransaction{}).Where("id = ?", lot.ID).Update("remaining", 0).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.MemberWineStorage{}).Where("id = ?", storage.ID).Update("quantity", balance).Error; err != nil {
+			return err
+		}
+
+		txn = &model.MemberWineTransaction{
+			StoreID:      lot.StoreID,
+			StorageID:    lot.StorageID,
+			MemberID:     lot.MemberID,
+			Type:         model.MemberWineTxnForfeit,
+			WineName:     lot.WineName,
+			Unit:         lot.Unit,
+			Quantity:     quantity,
+			BalanceAfter: balance,
+			Remark:       strings.TrimSpace(remark),
+			OperatorID:   operatorID,
+			OperatorName: operatorName,
+			ProductID:    lot.ProductID,
+			LotID:        lot.ID,
+		}
+		if order != nil && lot.ProductID > 0 && quantity > 0 && len(order.Items) > 0 {
+			order.TotalQuantity = quantity
+			order.Items[0].Quantity = quantity
+			if err := tx.Create(order).Error; err != nil {
+				return fmt.Errorf("create wine forfeit inventory order: %w", err)
+			}
+			src := model.InventoryMovementSource{
+				Type:         model.MovementSourceInventoryOrder,
+				ID:           order.ID,
+				No:           order.OrderNo,
+				OperatorID:   order.OperatorID,
+				OperatorName: order.OperatorName,
+				Remark:       order.Remark,
+			}
+			item := order.Items[0]
+			if err := stockIn(tx, lot.StoreID, lot.ProductID, quantity, item.Unit, item.ProductionDate, item.ExpiryDate, src); err != nil {
+				return fmt.Errorf("stock in forfeited wine for product %d: %w", lot.ProductID, err)
+			}
+			txn.InventoryOrderNo = order.OrderNo
+		}
+		if err := tx.Create(txn).Error; err != nil {
+			return err
+		}
+		return refreshWineStorageExpireAt(tx, storage.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return txn, nil
+}
+
+// ListWineLotsToRemind 查询到期时间不晚于 before、尚未提醒过的存入批次
+func (m *MemberModule) ListWineLotsToRemind(before time.Time) ([]model.MemberWineTransaction, error) {
+	rows := make([]model.MemberWineTransaction, 0)
+	if err := m.db.Preload("Member").
+		Where("type = ? AND remaining > 0 AND reminded_at IS NULL AND expire_at IS NOT NULL AND expire_at <= ?", model.MemberWineTxnDeposit, before).
+		Order("store_id ASC, expire_at ASC, id ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// MarkWineLotsReminded 标记存入批次已发送到期提醒
+func (m *MemberModule) MarkWineLotsReminded(ids []uint, now time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return m.db.Model(&model.MemberWineTransaction{}).Where("id IN ?", ids).Update("reminded_at", now).Error
+}
+
+// ListIdleWineStorages 查询最近存取时间早于 before、尚未提醒过的有存量存酒
+func (m *MemberModule) ListIdleWineStorages(before time.Time) ([]model.MemberWineStorage, error) {
+	rows := make([]model.MemberWineStorage, 0)
+	if err := m.db.Preload("Member").
+		Where("quantity > 0 AND idle_reminded_at IS NULL AND COALESCE(last_access_at, updated_at) <= ?", before).
+		Order("store_id ASC, id ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// MarkWineStoragesIdleReminded 标记存酒已发送久未存取提醒
+func (m *MemberModule) MarkWineStoragesIdleReminded(ids []uint, now time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return m.db.Model(&model.MemberWineStorage{}).Where("id IN ?", ids).Update("idle_reminded_at", now).Error
+}
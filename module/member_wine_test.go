@@ -0,0 +1,69 @@
+package module
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+func TestPlanWineConsumptionTakesEarliestExpiryFirst(t *testing.T) {
+	early := time.Date(2026, 11, 1, 0, 0, 0, 0, time.Local)
+	late := time.Date(2027, 1, 1, 0, 0, 0, 0, time.Local)
+	lots := []model.MemberWineTransaction{
+		{ID: 1, Remaining: 2},
+		{ID: 2, Remaining: 3, ExpireAt: &late},
+		{ID: 3, Remaining: 1.5, ExpireAt: &early},
+		{ID: 4, Remaining: 0, ExpireAt: &early},
+	}
+	plan := planWineConsumption(lots, 4)
+	want := []wineConsumption{{LotID: 3, Quantity: 1.5}, {LotID: 2, Quantity: 2.5}}
+	if len(plan) != len(want) {
+		t.Fatalf("planWineConsumption() = %#v, want %#v", plan, want)
+	}
+	for i := range want {
+		if plan[i] != want[i] {
+			t.Fatalf("planWineConsumption()[%d] = %#v, want %#v", i, plan[i], want[i])
+		}
+	}
+	if lots[0].ID != 1 {
+		t.Fatalf("planWineConsumption() reordered the caller's slice")
+	}
+}
+
+func TestPlanWineConsumptionStopsWhenLotsRunOut(t *testing.T) {
+	plan := planWineConsumption([]model.MemberWineTransaction{{ID: 1, Remaining: 2}}, 5)
+	if len(plan) != 1 || plan[0].Quantity != 2 {
+		t.Fatalf("planWineConsumption() with legacy stock = %#v", plan)
+	}
+}
+
+func TestExtendWineExpireAt(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.Local)
+	future := time.Date(2026, 10, 25, 0, 0, 0, 0, time.Local)
+	if got := extendWineExpireAt(&future, now, 30); !got.Equal(time.Date(2026, 11, 24, 0, 0, 0, 0, time.Local)) {
+		t.Fatalf("extend unexpired lot = %v", got)
+	}
+	past := time.Date(2026, 10, 18, 0, 0, 0, 0, time.Local)
+	if got := extendWineExpireAt(&past, now, 30); !got.Equal(time.Date(2026, 11, 18, 0, 0, 0, 0, time.Local)) {
+		t.Fatalf("extend expired lot = %v", got)
+	}
+	if got := extendWineExpireAt(nil, now, 1); !got.Equal(time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local)) {
+		t.Fatalf("extend lot without deadline = %v", got)
+	}
+}
+
+func TestWineLotDue(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.Local)
+	expired := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	if !wineLotDue(&model.MemberWineTransaction{ExpireAt: &future}, nil) {
+		t.Fatalf("manual handling should not require expiry")
+	}
+	if !wineLotDue(&model.MemberWineTransaction{ExpireAt: &expired}, &now) {
+		t.Fatalf("expired lot should be due")
+	}
+	if wineLotDue(&model.MemberWineTransaction{ExpireAt: &future}, &now) || wineLotDue(&model.MemberWineTransaction{}, &now) {
+		t.Fatalf("unexpired or unlimited lot should not be due")
+	}
+}
@@ -1,6 +1,8 @@
 package module
 
 import (
+	"errors"
+
 	"github.com/Kevin-Jii/tower-go/model"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -65,3 +67,88 @@ func (m *MessageTemplateModule) Upsert(template *model.MessageTemplate) error {
 		}),
 	}).Create(template).Error
 }
+
+// ErrTemplateVersionConflict 模板已被他人修改（版本号不一致）
+var ErrTemplateVersionConflict = errors.New("message template version conflict")
+
+// CreateWithVersion 创建模板并写入第 1 版快照
+func (m *MessageTemplateModule) CreateWithVersion(template *model.MessageTemplate, operatorID uint) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		template.Version = 1
+		if err := tx.Create(template).Error; err != nil {
+			return err
+		}
+		return tx.Create(templateSnapshot(template, "初始版本", operatorID)).Error
+	})
+}
+
+// CreateIfMissing 按编码补齐内置模板，已存在时不覆盖（保留用户修改）
+func (m *MessageTemplateModule) CreateIfMissing(template *model.MessageTemplate) error {
+	return m.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}},
+		DoNothing: true,
+	}).Create(template).Error
+}
+
+// UpdateWithVersion 以 current.Version 为条件更新模板并写入新版本快照；
+// 旧模板尚无版本记录时先补一版当前内容作为回滚基线。
+func (m *MessageTemplateModule) UpdateWithVersion(current *model.MessageTemplate, updates map[string]interface{}, remark string, operatorID uint) (int, error) {
+	next := current.Version + 1
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.MessageTemplateVersion{}).Where("template_id = ?", current.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			if err := tx.Create(templateSnapshot(current, "初始版本", 0)).Error; err != nil {
+				return err
+			}
+		}
+
+		updates["version"] = next
+		result := tx.Model(&model.MessageTemplate{}).Where("id = ? AND version = ?", current.ID, current.Version).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTemplateVersionConflict
+		}
+
+		var updated model.MessageTemplate
+		if err := tx.First(&updated, current.ID).Error; err != nil {
+			return err
+		}
+		return tx.Create(templateSnapshot(&updated, remark, operatorID)).Error
+	})
+	return next, err
+}
+
+// ListVersions 模板版本列表（新版本在前）
+func (m *MessageTemplateModule) ListVersions(templateID uint) ([]*model.MessageTemplateVersion, error) {
+	var versions []*model.MessageTemplateVersion
+	if err := m.db.Where("template_id = ?", templateID).Order("version DESC").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetVersion 获取模板指定版本
+func (m *MessageTemplateModule) GetVersion(templateID uint, version int) (*model.MessageTemplateVersion, error) {
+	var snapshot model.MessageTemplateVersion
+	if err := m.db.Where("template_id = ? AND version = ?", templateID, version).First(&snapshot).Error; err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+func templateSnapshot(template *model.MessageTemplate, remark string, operatorID uint) *model.MessageTemplateVersion {
+	return &model.MessageTemplateVersion{
+		TemplateID: template.ID,
+		Version:    template.Version,
+		Title:      template.Title,
+		Content:    template.Content,
+		Schema:     template.Schema,
+		Remark:     remark,
+		OperatorID: operatorID,
+	}
+}
@@ -0,0 +1,223 @@
+package module
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	"gorm.io/gorm"
+)
+
+// 重试退避：首次失败 30 秒后重试，之后每次翻倍，最长间隔 1 小时
+const (
+	notificationRetryBase = 30 * time.Second
+	notificationRetryMax  = time.Hour
+)
+
+type NotificationModule struct {
+	db *gorm.DB
+}
+
+func NewNotificationModule(db *gorm.DB) *NotificationModule {
+	return &NotificationModule{db: db}
+}
+
+// notificationRetryDelay 第 attempt 次发送失败后的等待时间
+func notificationRetryDelay(attempt int) time.Duration {
+	delay := notificationRetryBase
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= notificationRetryMax {
+			return notificationRetryMax
+		}
+	}
+	return delay
+}
+
+// notificationFailureState 发送失败后的状态：未达上限继续退避重试，达到上限转入死信
+func notificationFailureState(attempts, maxAttempts int, now time.Time) (int8, time.Time) {
+	if attempts >= maxAttempts {
+		return model.NotificationDead, now
+	}
+	return model.NotificationFailed, now.Add(notificationRetryDelay(attempts))
+}
+
+func truncateNotificationError(err error) string {
+	message := err.Error()
+	if len(message) > 500 {
+		message = message[:500]
+	}
+	return message
+}
+
+func (m *NotificationModule) Enqueue(outbox *model.NotificationOutbox) error {
+	if outbox.Channel == "" {
+		outbox.Channel = model.NotificationChannelDingTalk
+	}
+	if outbox.MsgType == "" {
+		outbox.MsgType = model.NotificationMsgMarkdown
+	}
+	if outbox.MaxAttempts <= 0 {
+		outbox.MaxAttempts = model.NotificationDefaultMaxAttempts
+	}
+	if outbox.NextAttemptAt.IsZero() {
+		outbox.NextAttemptAt = time.Now()
+	}
+	outbox.Status = model.NotificationPending
+	return m.db.Create(outbox).Error
+}
+
+var notificationClaimableStatuses = []int8{model.NotificationPending, model.NotificationFailed, model.NotificationSending}
+
+// claim 以条件更新抢占一条到期通知并累加发送次数；发送中的记录租约到期（进程中断）后可被重新抢占
+func (m *NotificationModule) claim(id uint, now time.Time, lease time.Duration) (*model.NotificationOutbox, error) {
+	res := m.db.Model(&model.NotificationOutbox{}).
+		Where("id = ? AND status IN ? AND next_attempt_at <= ?", id, notificationClaimableStatuses, now).
+		Updates(map[string]interface{}{
+			"status":          model.NotificationSending,
+			"attempts":        gorm.Expr("attempts + 1"),
+			"next_attempt_at": now.Add(lease),
+		})
+	if res.Error != nil || res.RowsAffected == 0 {
+		return nil, res.Error
+	}
+	var outbox model.NotificationOutbox
+	if err := m.db.First(&outbox, id).Error; err != nil {
+		return nil, err
+	}
+	return &outbox, nil
+}
+
+// ClaimDue 抢占最多 limit 条到期待发送的通知
+func (m *NotificationModule) ClaimDue(now time.Time, lease time.Duration, limit int) ([]*model.NotificationOutbox, error) {
+	var ids []uint
+	if err := m.db.Model(&model.NotificationOutbox{}).
+		Where("status IN ? AND next_attempt_at <= ?", notificationClaimableStatuses, now).
+		Order("next_attempt_at ASC, id ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	claimed := make([]*model.NotificationOutbox, 0, len(ids))
+	for _, id := range ids {
+		outbox, err := m.claim(id, now, lease)
+		if err != nil {
+			return claimed, err
+		}
+		if outbox != nil {
+			claimed = append(claimed, outbox)
+		}
+	}
+	return claimed, nil
+}
+
+// ClaimByID 抢占指定通知（人工重发时使用），已被其他进程抢占时返回 nil
+func (m *NotificationModule) ClaimByID(id uint, now time.Time, lease time.Duration) (*model.NotificationOutbox, error) {
+	return m.claim(id, now, lease)
+}
+
+// Complete 记录本次投递结果并推进状态
+func (m *NotificationModule) Complete(outbox *model.NotificationOutbox, sendErr error, duration time.Duration, operatorID uint, now time.Time) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		delivery := &model.NotificationDelivery{
+			OutboxID:   outbox.ID,
+			Attempt:    outbox.Attempts,
+			Success:    sendErr == nil,
+			DurationMs: duration.Milliseconds(),
+			OperatorID: operatorID,
+		}
+		updates := map[string]interface{}{}
+		if sendErr == nil {
+			updates["status"] = model.NotificationSent
+			updates["sent_at"] = now
+			updates["error_message"] = ""
+		} else {
+			delivery.ErrorMessage = truncateNotificationError(sendErr)
+			status, next := notificationFailureState(outbox.Attempts, outbox.MaxAttempts, now)
+			updates["status"] = status
+			updates["next_attempt_at"] = next
+			updates["error_message"] = delivery.ErrorMessage
+		}
+		// 租约过期后可能已被重新抢占，只有本次抢占（attempts 一致）才能推进状态
+		res := tx.Model(&model.NotificationOutbox{}).
+			Where("id = ? AND status = ? AND attempts = ?", outbox.ID, model.NotificationSending, outbox.Attempts).
+			Updates(updates)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		return tx.Create(delivery).Error
+	})
+}
+
+// SaveRenderedImage 回填投递时生成的图片地址与卡片参数，重试时不再重复生成
+func (m *NotificationModule) SaveRenderedImage(id uint, imageURL, payload string) error {
+	return m.db.Model(&model.NotificationOutbox{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"image_url": imageURL,
+			"payload":   payload,
+		}).Error
+}
+
+// Requeue 将失败或死信通知重新放回队列，并追加一轮重试次数
+func (m *NotificationModule) Requeue(id uint, now time.Time) error {
+	var outbox model.NotificationOutbox
+	if err := m.db.First(&outbox, id).Error; err != nil {
+		return apicode.New(apicode.NotFound)
+	}
+	if outbox.Status != model.NotificationFailed && outbox.Status != model.NotificationDead {
+		return apicode.Newf(apicode.OrderStateConflict, "仅失败或死信通知可以重发")
+	}
+	return m.db.Model(&model.NotificationOutbox{}).
+		Where("id = ? AND status = ?", outbox.ID, outbox.Status).
+		Updates(map[string]interface{}{
+			"status":          model.NotificationPending,
+			"next_attempt_at": now,
+			"max_attempts":    outbox.Attempts + model.NotificationDefaultMaxAttempts,
+		}).Error
+}
+
+func (m *NotificationModule) GetWithDeliveries(id uint) (*model.NotificationOutbox, error) {
+	var outbox model.NotificationOutbox
+	err := m.db.Preload("Deliveries", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).First(&outbox, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &outbox, nil
+}
+
+func (m *NotificationModule) List(req *model.ListNotificationReq) ([]*model.NotificationOutbox, int64, error) {
+	var rows []*model.NotificationOutbox
+	var total int64
+	q := m.db.Model(&model.NotificationOutbox{})
+	if req.StoreID > 0 {
+		q = q.Where("store_id = ?", req.StoreID)
+	}
+	if req.Status > 0 {
+		q = q.Where("status = ?", req.Status)
+	}
+	if req.BizType != "" {
+		q = q.Where("biz_type = ?", req.BizType)
+	}
+	if kw := strings.TrimSpace(req.Keyword); kw != "" {
+		like := "%" + kw + "%"
+		q = q.Where("(biz_no LIKE ? OR title LIKE ?)", like, like)
+	}
+	if req.StartDate != "" {
+		q = q.Where("created_at >= ?", req.StartDate+" 00:00:00")
+	}
+	if req.EndDate != "" {
+		q = q.Where("created_at <= ?", req.EndDate+" 23:59:59")
+	}
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (req.Page - 1) * req.PageSize
+	if err := q.Omit("content", "payload").Order("id DESC").Offset(offset).Limit(req.PageSize).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
@@ -0,0 +1,92 @@
+package module
+
+import (
+	"github.com/Kevin-Jii/tower-go/model"
+	"gorm.io/gorm"
+)
+
+type NotificationRouteModule struct {
+	db *gorm.DB
+}
+
+func NewNotificationRouteModule(db *gorm.DB) *NotificationRouteModule {
+	return &NotificationRouteModule{db: db}
+}
+
+// matchNotificationRoutes 筛选命中事件的规则：渠道为空或相同、金额达到门槛；
+// 存在门店专属规则命中时，忽略全部门店规则。
+func matchNotificationRoutes(routes []*model.NotificationRoute, event *model.NotificationEvent) []*model.NotificationRoute {
+	var storeRoutes, globalRoutes []*model.NotificationRoute
+	for _, route := range routes {
+		if !route.IsEnabled || route.EventType != event.EventType {
+			continue
+		}
+		if route.Channel != "" && route.Channel != event.Channel {
+			continue
+		}
+		if route.MinAmount > 0 && event.Amount < route.MinAmount {
+			continue
+		}
+		switch route.StoreID {
+		case event.StoreID:
+			storeRoutes = append(storeRoutes, route)
+		case 0:
+			globalRoutes = append(globalRoutes, route)
+		}
+	}
+	if len(storeRoutes) > 0 {
+		return storeRoutes
+	}
+	return globalRoutes
+}
+
+// Match 查询事件命中的启用规则
+func (m *NotificationRouteModule) Match(event *model.NotificationEvent) ([]*model.NotificationRoute, error) {
+	var routes []*model.NotificationRoute
+	if err := m.db.Where("event_type = ? AND store_id IN ? AND is_enabled = ?", event.EventType, []uint{0, event.StoreID}, true).
+		Order("id ASC").Find(&routes).Error; err != nil {
+		return nil, err
+	}
+	return matchNotificationRoutes(routes, event), nil
+}
+
+func (m *NotificationRouteModule) Create(route *model.NotificationRoute) error {
+	return m.db.Create(route).Error
+}
+
+func (m *NotificationRouteModule) Update(route *model.NotificationRoute) error {
+	return m.db.Omit("created_at", "Store").Save(route).Error
+}
+
+func (m *NotificationRouteModule) Delete(id uint) error {
+	return m.db.Delete(&model.NotificationRoute{}, id).Error
+}
+
+func (m *NotificationRouteModule) GetByID(id uint) (*model.NotificationRoute, error) {
+	var route model.NotificationRoute
+	if err := m.db.Preload("Store").First(&route, id).Error; err != nil {
+		return nil, err
+	}
+	return &route, nil
+}
+
+func (m *NotificationRouteModule) List(req *model.ListNotificationRouteReq) ([]*model.NotificationRoute, int64, error) {
+	var rows []*model.NotificationRoute
+	var total int64
+	q := m.db.Model(&model.NotificationRoute{})
+	if req.EventType != "" {
+		q = q.Where("event_type = ?", req.EventType)
+	}
+	if req.StoreID > 0 {
+		q = q.Where("store_id = ?", req.StoreID)
+	}
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (req.Page - 1) * req.PageSize
+	if err := q.Preload("Store").Order("event_type ASC, store_id ASC, id ASC").
+		Offset(offset).Limit(req.PageSize).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
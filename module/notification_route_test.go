@@ -0,0 +1,46 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+func TestMatchNotificationRoutes(t *testing.T) {
+	routes := []*model.NotificationRoute{
+		{ID: 1, EventType: model.NotificationBizStoreAccount, StoreID: 0, IsEnabled: true},
+		{ID: 2, EventType: model.NotificationBizStoreAccount, StoreID: 0, MinAmount: 1000, IsEnabled: true},
+		{ID: 3, EventType: model.NotificationBizStoreAccount, StoreID: 5, Channel: "meituan", IsEnabled: true},
+		{ID: 4, EventType: model.NotificationBizPurchaseOrder, StoreID: 0, IsEnabled: true},
+		{ID: 5, EventType: model.NotificationBizStoreAccount, StoreID: 0, IsEnabled: false},
+	}
+	ids := func(matched []*model.NotificationRoute) []uint {
+		out := make([]uint, 0, len(matched))
+		for _, route := range matched {
+			out = append(out, route.ID)
+		}
+		return out
+	}
+	cases := []struct {
+		name  string
+		event model.NotificationEvent
+		want  []uint
+	}{
+		{"global below threshold", model.NotificationEvent{EventType: model.NotificationBizStoreAccount, StoreID: 1, Amount: 999}, []uint{1}},
+		{"global above threshold", model.NotificationEvent{EventType: model.NotificationBizStoreAccount, StoreID: 1, Amount: 1000}, []uint{1, 2}},
+		{"store rule overrides global", model.NotificationEvent{EventType: model.NotificationBizStoreAccount, StoreID: 5, Channel: "meituan", Amount: 2000}, []uint{3}},
+		{"store rule channel mismatch falls back", model.NotificationEvent{EventType: model.NotificationBizStoreAccount, StoreID: 5, Channel: "eleme"}, []uint{1}},
+		{"no rule for event", model.NotificationEvent{EventType: model.NotificationBizMemberRecharge, StoreID: 1}, []uint{}},
+	}
+	for _, tc := range cases {
+		got := ids(matchNotificationRoutes(routes, &tc.event))
+		if len(got) != len(tc.want) {
+			t.Fatalf("%s: got %v want %v", tc.name, got, tc.want)
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Fatalf("%s: got %v want %v", tc.name, got, tc.want)
+			}
+		}
+	}
+}
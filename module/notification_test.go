@@ -0,0 +1,38 @@
+package module
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+func TestNotificationRetryDelay(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		5:  8 * time.Minute,
+		8:  time.Hour,
+		20: time.Hour,
+	}
+	for attempt, want := range cases {
+		if got := notificationRetryDelay(attempt); got != want {
+			t.Fatalf("attempt %d: got %v want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestNotificationFailureState(t *testing.T) {
+	now := time.Date(2024, 12, 7, 10, 0, 0, 0, time.Local)
+
+	status, next := notificationFailureState(2, 6, now)
+	if status != model.NotificationFailed || !next.Equal(now.Add(time.Minute)) {
+		t.Fatalf("retry: got %d %v", status, next)
+	}
+
+	status, next = notificationFailureState(6, 6, now)
+	if status != model.NotificationDead || !next.Equal(now) {
+		t.Fatalf("dead: got %d %v", status, next)
+	}
+}
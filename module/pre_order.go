@@ -41,6 +41,15 @@ func (m *PreOrderModule) GetByID(id uint) (*model.PreOrder, error) {
 	return &order, nil
 }
 
+// GetIDByOrderNo 根据预订单号查找ID
+func (m *PreOrderModule) GetIDByOrderNo(orderNo string) (uint, error) {
+	var order model.PreOrder
+	if err := m.db.Select("id").Where("order_no = ?", orderNo).First(&order).Error; err != nil {
+		return 0, err
+	}
+	return order.ID, nil
+}
+
 func (m *PreOrderModule) List(req *model.ListPreOrderReq) ([]*model.PreOrder, int64, error) {
 	var rows []*model.PreOrder
 	var total int64
@@ -0,0 +1,61 @@
+package module
+
+import (
+	"github.com/Kevin-Jii/tower-go/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PrintAutoRuleModule struct {
+	db *gorm.DB
+}
+
+func NewPrintAutoRuleModule(db *gorm.DB) *PrintAutoRuleModule {
+	return &PrintAutoRuleModule{db: db}
+}
+
+func (m *PrintAutoRuleModule) GetByID(id uint) (*model.PrintAutoRule, error) {
+	var rule model.PrintAutoRule
+	if err := m.db.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// GetEnabled 获取门店某类单据已启用的规则，未配置或已停用时返回 nil
+func (m *PrintAutoRuleModule) GetEnabled(storeID uint, docType string) (*model.PrintAutoRule, error) {
+	var rules []model.PrintAutoRule
+	err := m.db.Where("store_id = ? AND doc_type = ? AND enabled = 1", storeID, docType).
+		Limit(1).Find(&rules).Error
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+	return &rules[0], nil
+}
+
+// List 规则列表，storeID 为 0 时返回全部门店
+func (m *PrintAutoRuleModule) List(storeID uint) ([]*model.PrintAutoRule, error) {
+	var rules []*model.PrintAutoRule
+	query := m.db.Model(&model.PrintAutoRule{})
+	if storeID > 0 {
+		query = query.Where("store_id = ?", storeID)
+	}
+	err := query.Order("store_id ASC, doc_type ASC").Find(&rules).Error
+	return rules, err
+}
+
+// Upsert 按门店+单据类型创建或覆盖规则
+func (m *PrintAutoRuleModule) Upsert(rule *model.PrintAutoRule) error {
+	if err := m.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "store_id"}, {Name: "doc_type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"printer_id", "copies", "enabled", "updated_at"}),
+	}).Create(rule).Error; err != nil {
+		return err
+	}
+	// MySQL 冲突更新时不会回填主键，重新读取
+	return m.db.Where("store_id = ? AND doc_type = ?", rule.StoreID, rule.DocType).First(rule).Error
+}
+
+func (m *PrintAutoRuleModule) Delete(id uint) error {
+	return m.db.Delete(&model.PrintAutoRule{}, id).Error
+}
@@ -0,0 +1,97 @@
+package module
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"gorm.io/gorm"
+)
+
+type PrintJobModule struct {
+	db *gorm.DB
+}
+
+func NewPrintJobModule(db *gorm.DB) *PrintJobModule {
+	return &PrintJobModule{db: db}
+}
+
+func (m *PrintJobModule) Create(job *model.PrintJob) error {
+	return m.db.Create(job).Error
+}
+
+func (m *PrintJobModule) GetByID(id uint) (*model.PrintJob, error) {
+	var job model.PrintJob
+	if err := m.db.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// MarkSent 记录芯烨云受理结果，之后由轮询确认是否出纸
+func (m *PrintJobModule) MarkSent(id uint, orderID string, sentAt time.Time) error {
+	return m.db.Model(&model.PrintJob{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":         model.PrintJobSent,
+		"xpyun_order_id": orderID,
+		"sent_at":        sentAt,
+		"last_error":     "",
+	}).Error
+}
+
+// MarkPrinted 只更新仍处于已发送状态的任务，避免覆盖并发写入的结果
+func (m *PrintJobModule) MarkPrinted(id uint, printedAt time.Time) error {
+	return m.db.Model(&model.PrintJob{}).Where("id = ? AND status = ?", id, model.PrintJobSent).Updates(map[string]interface{}{
+		"status":     model.PrintJobPrinted,
+		"printed_at": printedAt,
+	}).Error
+}
+
+func (m *PrintJobModule) MarkFailed(id uint, message string) error {
+	if len(message) > 500 {
+		message = message[:500]
+	}
+	return m.db.Model(&model.PrintJob{}).Where("id = ? AND status IN ?", id, []int8{model.PrintJobPending, model.PrintJobSent}).
+		Updates(map[string]interface{}{
+			"status":     model.PrintJobFailed,
+			"last_error": message,
+		}).Error
+}
+
+// ListAwaiting 已发送但尚未确认出纸的任务，按发送时间先后
+func (m *PrintJobModule) ListAwaiting(sentBefore time.Time, limit int) ([]*model.PrintJob, error) {
+	var jobs []*model.PrintJob
+	err := m.db.Where("status = ? AND sent_at <= ?", model.PrintJobSent, sentBefore).
+		Order("sent_at ASC").Limit(limit).Find(&jobs).Error
+	return jobs, err
+}
+
+func (m *PrintJobModule) List(req *model.ListPrintJobReq) ([]*model.PrintJob, int64, error) {
+	var jobs []*model.PrintJob
+	var total int64
+	q := m.db.Model(&model.PrintJob{})
+	if req.StoreID > 0 {
+		q = q.Where("store_id = ?", req.StoreID)
+	}
+	if req.PrinterID > 0 {
+		q = q.Where("printer_id = ?", req.PrinterID)
+	}
+	if req.DocType != "" {
+		q = q.Where("doc_type = ?", req.DocType)
+	}
+	if req.Status > 0 {
+		q = q.Where("status = ?", req.Status)
+	}
+	if kw := strings.TrimSpace(req.Keyword); kw != "" {
+		like := "%" + kw + "%"
+		q = q.Where("(doc_no LIKE ? OR xpyun_order_id LIKE ?)", like, like)
+	}
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (req.Page - 1) * req.PageSize
+	// 列表不返回打印内容，详情再取
+	if err := q.Omit("content").Order("id DESC").Offset(offset).Limit(req.PageSize).Find(&jobs).Error; err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
@@ -39,6 +39,15 @@ func (m *PurchaseOrderModule) GetByID(id uint) (*model.PurchaseOrder, error) {
 	return &order, nil
 }
 
+// GetByOrderNo 根据订单编号获取采购单
+func (m *PurchaseOrderModule) GetByOrderNo(orderNo string) (*model.PurchaseOrder, error) {
+	var order model.PurchaseOrder
+	if err := m.db.Preload("Store").Where("order_no = ?", orderNo).First(&order).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
 // GetByIDWithDetails 获取采购单详情（包含关联数据）
 func (m *PurchaseOrderModule) GetByIDWithDetails(id uint) (*model.PurchaseOrder, error) {
 	var order model.PurchaseOrder
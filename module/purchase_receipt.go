@@ -0,0 +1,180 @@
+package module
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PurchaseReceiptModule struct {
+	db *gorm.DB
+}
+
+func NewPurchaseReceiptModule(db *gorm.DB) *PurchaseReceiptModule {
+	return &PurchaseReceiptModule{db: db}
+}
+
+// ReceiveWithStockIn 登记一次到货：锁定采购单与明细校验剩余可收数量，写入收货单、采购入库单和供应商应付，
+// 累加明细实收并更新收货状态；全部收齐时采购单同时置为已完成。返回采购单是否已收齐。
+func (m *PurchaseReceiptModule) ReceiveWithStockIn(receipt *model.PurchaseReceipt, invOrder *model.InventoryOrder) (bool, error) {
+	completed := false
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		var order model.PurchaseOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND store_id = ?", receipt.PurchaseOrderID, receipt.StoreID).
+			First(&order).Error; err != nil {
+			return apicode.New(apicode.OrderNotFound)
+		}
+		if order.Status != model.PurchaseStatusConfirmed {
+			return apicode.Newf(apicode.OrderStateConflict, "采购单须为已确认状态才能收货")
+		}
+
+		var items []model.PurchaseOrderItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_id = ?", order.ID).
+			Find(&items).Error; err != nil {
+			return err
+		}
+		remaining := make(map[uint]float64, len(items))
+		for _, item := range items {
+			remaining[item.ID] = roundQuantity(item.Quantity - item.ReceivedQuantity)
+		}
+		for _, line := range receipt.Items {
+			left, ok := remaining[line.OrderItemID]
+			if !ok {
+				return apicode.Newf(apicode.ValidationFailed, "收货明细不属于该采购单: %d", line.OrderItemID)
+			}
+			left = roundQuantity(left - line.Quantity)
+			if left < 0 {
+				return apicode.Newf(apicode.ValidationFailed, "商品【%s】收货数量超过未收数量", line.ProductName)
+			}
+			remaining[line.OrderItemID] = left
+		}
+
+		if err := applyInventoryOrder(tx, invOrder); err != nil {
+			return err
+		}
+		receipt.InventoryOrderNo = invOrder.OrderNo
+		if err := tx.Create(receipt).Error; err != nil {
+			return err
+		}
+		if err := recordPurchaseReceiptPayables(tx, receipt); err != nil {
+			return err
+		}
+		for _, line := range receipt.Items {
+			if err := tx.Model(&model.PurchaseOrderItem{}).Where("id = ?", line.OrderItemID).Updates(map[string]interface{}{
+				"received_quantity": gorm.Expr("received_quantity + ?", line.Quantity),
+				"received_amount":   gorm.Expr("received_amount + ?", line.Amount),
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		completed = true
+		for _, left := range remaining {
+			if left > 0 {
+				completed = false
+				break
+			}
+		}
+		updates := map[string]interface{}{"receive_status": model.PurchaseReceivePartial}
+		if completed {
+			updates["receive_status"] = model.PurchaseReceiveFull
+			updates["status"] = model.PurchaseStatusCompleted
+		}
+		return tx.Model(&order).Updates(updates).Error
+	})
+	return completed, err
+}
+
+// CloseShort 短收结案：已部分收货的采购单不再等待剩余到货，置为已完成并记录原因
+func (m *PurchaseReceiptModule) CloseShort(orderID, storeID uint, remark string) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		var order model.PurchaseOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND store_id = ?", orderID, storeID).
+			First(&order).Error; err != nil {
+			return apicode.New(apicode.OrderNotFound)
+		}
+		if order.Status != model.PurchaseStatusConfirmed {
+			return apicode.Newf(apicode.OrderStateConflict, "采购单须为已确认状态才能短收结案")
+		}
+		if order.ReceiveStatus != model.PurchaseReceivePartial {
+			return apicode.Newf(apicode.OrderStateConflict, "采购单尚未收货，请直接取消")
+		}
+		return tx.Model(&order).Updates(map[string]interface{}{
+			"status":         model.PurchaseStatusCompleted,
+			"receive_status": model.PurchaseReceiveClosedShort,
+			"remark":         remark,
+		}).Error
+	})
+}
+
+// ListByOrder 采购单的收货记录（含明细），按收货先后排列
+func (m *PurchaseReceiptModule) ListByOrder(orderID uint) ([]*model.PurchaseReceipt, error) {
+	var receipts []*model.PurchaseReceipt
+	err := m.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Where("purchase_order_id = ?", orderID).Order("id ASC").Find(&receipts).Error
+	return receipts, err
+}
+
+// VarianceRows 报菜日期 [start, end) 内采购单按供应商+商品汇总订货与实收。
+// 已取消的采购单不计入；启用收货登记前直接完成的采购单没有实收数据，也不计入。
+func (m *PurchaseReceiptModule) VarianceRows(req *model.PurchaseVarianceReq, start, end time.Time) ([]model.PurchaseVarianceRow, error) {
+	var rows []model.PurchaseVarianceRow
+	query := m.db.Table("purchase_order_items poi").
+		Select(`poi.supplier_id, s.supplier_name, poi.product_id, sp.name AS product_name, sp.unit,
+			SUM(poi.quantity) AS ordered_quantity,
+			SUM(poi.received_quantity) AS received_quantity,
+			SUM(CASE WHEN po.receive_status = ? THEN poi.quantity - poi.received_quantity ELSE 0 END) AS short_quantity,
+			SUM(CASE WHEN po.status IN ? THEN poi.quantity - poi.received_quantity ELSE 0 END) AS open_quantity,
+			SUM(poi.amount) AS ordered_amount,
+			SUM(poi.received_amount) AS received_amount,
+			SUM(CASE WHEN po.receive_status = ? THEN (poi.quantity - poi.received_quantity) * poi.unit_price ELSE 0 END) AS short_amount,
+			SUM(CASE WHEN po.status IN ? THEN (poi.quantity - poi.received_quantity) * poi.unit_price ELSE 0 END) AS open_amount,
+			SUM(poi.received_quantity * poi.unit_price) AS received_at_order_price`,
+			model.PurchaseReceiveClosedShort, []int8{model.PurchaseStatusPending, model.PurchaseStatusConfirmed},
+			model.PurchaseReceiveClosedShort, []int8{model.PurchaseStatusPending, model.PurchaseStatusConfirmed}).
+		Joins("JOIN purchase_orders po ON po.id = poi.order_id").
+		Joins("LEFT JOIN suppliers s ON s.id = poi.supplier_id").
+		Joins("LEFT JOIN supplier_products sp ON sp.id = poi.product_id").
+		Where("po.order_date >= ? AND po.order_date < ?", start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Where("po.status <> ?", model.PurchaseStatusCancelled).
+		Where("NOT (po.status = ? AND po.receive_status = ?)", model.PurchaseStatusCompleted, model.PurchaseReceiveNone)
+	if req.StoreID > 0 {
+		query = query.Where("po.store_id = ?", req.StoreID)
+	}
+	if req.SupplierID > 0 {
+		query = query.Where("poi.supplier_id = ?", req.SupplierID)
+	}
+	err := query.Group("poi.supplier_id, s.supplier_name, poi.product_id, sp.name, sp.unit").
+		Order("poi.supplier_id ASC, poi.product_id ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// GenerateReceiptNo 收货单号：SH + 日期 + 序号，如 SH202412070001
+func (m *PurchaseReceiptModule) GenerateReceiptNo() string {
+	prefix := "SH"
+	today := time.Now().Format("20060102")
+	pattern := prefix + today + "%"
+
+	var maxNo string
+	m.db.Model(&model.PurchaseReceipt{}).
+		Where("receipt_no LIKE ?", pattern).
+		Order("receipt_no DESC").
+		Limit(1).
+		Pluck("receipt_no", &maxNo)
+
+	seq := 1
+	if maxNo != "" && len(maxNo) >= 14 {
+		fmt.Sscanf(maxNo[len(maxNo)-4:], "%d", &seq)
+		seq++
+	}
+	return fmt.Sprintf("%s%s%04d", prefix, today, seq)
+}
@@ -0,0 +1,262 @@
+package module
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ListRechargePackages 查询充值套餐
+func (m *MemberModule) ListRechargePackages(req *model.ListRechargePackageReq) ([]model.RechargePackage, error) {
+	rows := make([]model.RechargePackage, 0)
+	query := m.db.Model(&model.RechargePackage{}).Preload("Stores").Preload("Stores.Store")
+	if req.Status > 0 {
+		query = query.Where("status = ?", req.Status)
+	}
+	if err := query.Order("pay_amount ASC, id ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetRechargePackage 获取充值套餐（含适用门店）
+func (m *MemberModule) GetRechargePackage(id uint) (*model.RechargePackage, error) {
+	var row model.RechargePackage
+	if err := m.db.Preload("Stores").Preload("Stores.Store").First(&row, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apicode.New(apicode.NotFound)
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// CreateRechargePackage 新增充值套餐
+func (m *MemberModule) CreateRechargePackage(req *model.UpsertRechargePackageReq) (*model.RechargePackage, error) {
+	row := &model.RechargePackage{}
+	if err := fillRechargePackage(row, req); err != nil {
+		return nil, err
+	}
+	if err := m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(row).Error; err != nil {
+			return err
+		}
+		return replaceRechargePackageStores(tx, row.ID, req.StoreIDs)
+	}); err != nil {
+		return nil, err
+	}
+	return m.GetRechargePackage(row.ID)
+}
+
+// UpdateRechargePackage 更新充值套餐，适用门店整体替换；已创建的充值单保留原套餐名称与赠送金额
+func (m *MemberModule) UpdateRechargePackage(id uint, req *model.UpsertRechargePackageReq) (*model.RechargePackage, error) {
+	row, err := m.GetRechargePackage(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := fillRechargePackage(row, req); err != nil {
+		return nil, err
+	}
+	row.Stores = nil
+	if err := m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(row).Error; err != nil {
+			return err
+		}
+		return replaceRechargePackageStores(tx, row.ID, req.StoreIDs)
+	}); err != nil {
+		return nil, err
+	}
+	return m.GetRechargePackage(row.ID)
+}
+
+// DeleteRechargePackage 删除充值套餐
+func (m *MemberModule) DeleteRechargePackage(id uint) error {
+	if _, err := m.GetRechargePackage(id); err != nil {
+		return err
+	}
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("package_id = ?", id).Delete(&model.RechargePackageStore{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.RechargePackage{}, id).Error
+	})
+}
+
+func replaceRechargePackageStores(tx *gorm.DB, packageID uint, storeIDs []uint) error {
+	if err := tx.Where("package_id = ?", packageID).Delete(&model.RechargePackageStore{}).Error; err != nil {
+		return err
+	}
+	seen := make(map[uint]bool, len(storeIDs))
+	rows := make([]model.RechargePackageStore, 0, len(storeIDs))
+	for _, storeID := range storeIDs {
+		if storeID == 0 || seen[storeID] {
+			continue
+		}
+		seen[storeID] = true
+		rows = append(rows, model.RechargePackageStore{PackageID: packageID, StoreID: storeID})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Create(&rows).Error
+}
+
+func fillRechargePackage(row *model.RechargePackage, req *model.UpsertRechargePackageReq) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return apicode.Newf(apicode.ValidationFailed, "请填写套餐名称")
+	}
+	if !req.PayAmount.IsPositive() {
+		return apicode.Newf(apicode.ValidationFailed, "充值金额必须大于 0")
+	}
+	if req.GiftAmount.IsNegative() || req.FirstGiftAmount.IsNegative() {
+		return apicode.Newf(apicode.ValidationFailed, "赠送金额不能为负数")
+	}
+	if req.StartAt != nil && req.EndAt != nil && !req.EndAt.After(*req.StartAt) {
+		return apicode.Newf(apicode.ValidationFailed, "活动结束时间必须晚于开始时间")
+	}
+	status := req.Status
+	if status == 0 {
+		status = model.RechargePackageEnabled
+	}
+	row.Name = name
+	row.PayAmount = req.PayAmount.Round(2)
+	row.GiftAmount = req.GiftAmount.Round(2)
+	row.FirstGiftAmount = req.FirstGiftAmount.Round(2)
+	row.StartAt = req.StartAt
+	row.EndAt = req.EndAt
+	row.Status = status
+	row.Remark = strings.TrimSpace(req.Remark)
+	return nil
+}
+
+// ListAvailableRechargePackages 查询门店当前可用的充值套餐：已启用、在活动时间内、未限制门店或包含该门店
+func (m *MemberModule) ListAvailableRechargePackages(storeID uint, now time.Time) ([]model.RechargePackage, error) {
+	rows := make([]model.RechargePackage, 0)
+	if err := m.db.Model(&model.RechargePackage{}).Preload("Stores").
+		Where("status = ?", model.RechargePackageEnabled).
+		Where("start_at IS NULL OR start_at <= ?", now).
+		Where("end_at IS NULL OR end_at >= ?", now).
+		Where("NOT EXISTS (SELECT 1 FROM recharge_package_stores s WHERE s.package_id = recharge_packages.id) OR "+
+			"EXISTS (SELECT 1 FROM recharge_package_stores s WHERE s.package_id = recharge_packages.id AND s.store_id = ?)", storeID).
+		Order("pay_amount ASC, id ASC").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// HasPaidRecharge 会员是否有过已支付的充值（用于判断首充）
+func (m *MemberModule) HasPaidRecharge(memberID uint) (bool, error) {
+	return hasPaidRecharge(m.db, memberID)
+}
+
+func hasPaidRecharge(db *gorm.DB, memberID uint) (bool, error) {
+	var count int64
+	if err := db.Model(&model.RechargeOrder{}).
+		Where("member_id = ? AND pay_status IN ?", memberID, []model.PayStatusEnum{model.PayStatusPaid, model.PayStatusRefunded}).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// revokeFirstRechargeGift 取消充值单的首充加赠：赠送与到账金额扣回首充部分，并在备注中说明
+func revokeFirstRechargeGift(order *model.RechargeOrder) {
+	if !order.FirstGift.IsPositive() {
+		return
+	}
+	note := fmt.Sprintf("会员已有充值记录，取消首充加赠%s", order.FirstGift.StringFixed(2))
+	order.GiftAmount = order.GiftAmount.Sub(order.FirstGift)
+	order.TotalAmount = order.TotalAmount.Sub(order.FirstGift)
+	order.FirstGift = decimal.Zero
+	if order.Remark == "" {
+		order.Remark = note
+	} else {
+		order.Remark = order.Remark + "；" + note
+	}
+}
+
+// RechargePackageReport 按套餐汇总已支付充值单的充值量与赠送金额，并估算会员余额中的赠送负债
+func (m *MemberModule) RechargePackageReport(req *model.RechargePackageReportReq, storeID uint, isAdmin bool) (*model.RechargePackageReport, error) {
+	scopeStoreID := storeID
+	if isAdmin {
+		scopeStoreID = req.StoreID
+	}
+	paidOrders := func() *gorm.DB {
+		q := m.db.Model(&model.RechargeOrder{}).
+			Joins("JOIN t_member ON t_member.id = t_recharge_order.member_id").
+			Where("t_recharge_order.pay_status = ?", model.PayStatusPaid)
+		if scopeStoreID > 0 {
+			q = q.Where("t_member.store_id = ?", scopeStoreID)
+		}
+		return q
+	}
+
+	query := paidOrders()
+	if req.StartDate != "" {
+		query = query.Where("t_recharge_order.pay_time >= ?", req.StartDate)
+	}
+	if req.EndDate != "" {
+		query = query.Where("t_recharge_order.pay_time < DATE_ADD(?, INTERVAL 1 DAY)", req.EndDate)
+	}
+	rows := make([]model.RechargePackageReportRow, 0)
+	if err := query.Select("t_recharge_order.package_id AS package_id, " +
+		"MAX(t_recharge_order.package_name) AS package_name, " +
+		"COUNT(*) AS order_count, " +
+		"SUM(CASE WHEN t_recharge_order.first_gift > 0 THEN 1 ELSE 0 END) AS first_count, " +
+		"COALESCE(SUM(t_recharge_order.pay_amount), 0) AS pay_amount, " +
+		"COALESCE(SUM(t_recharge_order.gift_amount), 0) AS gift_amount, " +
+		"COALESCE(SUM(t_recharge_order.first_gift), 0) AS first_gift").
+		Group("t_recharge_order.package_id").
+		Order("SUM(t_recharge_order.pay_amount) DESC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	report := &model.RechargePackageReport{Rows: rows}
+	for _, row := range rows {
+		report.OrderCount += row.OrderCount
+		report.PayAmount = report.PayAmount.Add(row.PayAmount)
+		report.GiftAmount = report.GiftAmount.Add(row.GiftAmount)
+	}
+
+	var lifetime struct {
+		GiftAmount  decimal.Decimal
+		TotalAmount decimal.Decimal
+	}
+	if err := paidOrders().
+		Select("COALESCE(SUM(t_recharge_order.gift_amount), 0) AS gift_amount, COALESCE(SUM(t_recharge_order.total_amount), 0) AS total_amount").
+		Scan(&lifetime).Error; err != nil {
+		return nil, err
+	}
+	balanceQuery := m.db.Model(&model.Member{})
+	if scopeStoreID > 0 {
+		balanceQuery = balanceQuery.Where("store_id = ?", scopeStoreID)
+	}
+	var balance struct{ Balance decimal.Decimal }
+	if err := balanceQuery.Select("COALESCE(SUM(balance), 0) AS balance").Scan(&balance).Error; err != nil {
+		return nil, err
+	}
+	report.MemberBalance = balance.Balance
+	report.BonusLiability = estimateBonusLiability(balance.Balance, lifetime.GiftAmount, lifetime.TotalAmount)
+	return report, nil
+}
+
+// estimateBonusLiability 按累计赠送占充值到账的比例估算余额中的赠送部分，不超过累计赠送金额
+func estimateBonusLiability(balance, giftTotal, rechargeTotal decimal.Decimal) decimal.Decimal {
+	if !balance.IsPositive() || !giftTotal.IsPositive() || !rechargeTotal.IsPositive() {
+		return decimal.Zero
+	}
+	liability := balance.Mul(giftTotal).Div(rechargeTotal).Round(2)
+	if liability.GreaterThan(giftTotal) {
+		return giftTotal
+	}
+	return liability
+}
@@ -0,0 +1,44 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/shopspring/decimal"
+)
+
+func TestEstimateBonusLiability(t *testing.T) {
+	d := decimal.NewFromInt
+	cases := []struct {
+		balance, gift, total decimal.Decimal
+		want                 decimal.Decimal
+	}{
+		{d(1000), d(200), d(1200), decimal.RequireFromString("166.67")},
+		{d(0), d(200), d(1200), d(0)},
+		{d(1000), d(0), d(1200), d(0)},
+		{d(1000), d(200), d(0), d(0)},
+		{d(5000), d(200), d(1200), d(200)},
+	}
+	for _, c := range cases {
+		if got := estimateBonusLiability(c.balance, c.gift, c.total); !got.Equal(c.want) {
+			t.Fatalf("estimateBonusLiability(%s, %s, %s) = %s, want %s", c.balance, c.gift, c.total, got, c.want)
+		}
+	}
+}
+
+func TestRevokeFirstRechargeGift(t *testing.T) {
+	d := decimal.NewFromInt
+	order := &model.RechargeOrder{PayAmount: d(1000), GiftAmount: d(150), TotalAmount: d(1150), FirstGift: d(50), Remark: "千元套餐赠送100.00，首充加赠50.00"}
+	revokeFirstRechargeGift(order)
+	if !order.GiftAmount.Equal(d(100)) || !order.TotalAmount.Equal(d(1100)) || !order.FirstGift.IsZero() {
+		t.Fatalf("order = %#v", order)
+	}
+	if order.Remark != "千元套餐赠送100.00，首充加赠50.00；会员已有充值记录，取消首充加赠50.00" {
+		t.Fatalf("remark = %q", order.Remark)
+	}
+
+	revokeFirstRechargeGift(order)
+	if !order.TotalAmount.Equal(d(1100)) {
+		t.Fatalf("revoking twice changed total: %#v", order)
+	}
+}
@@ -0,0 +1,125 @@
+package module
+
+import (
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReorderModule struct {
+	db *gorm.DB
+}
+
+func NewReorderModule(db *gorm.DB) *ReorderModule {
+	return &ReorderModule{db: db}
+}
+
+// Upsert 按门店+商品新增或覆盖补货设置
+func (m *ReorderModule) Upsert(setting *model.StoreProductReorderSetting) error {
+	return m.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "store_id"}, {Name: "product_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"supplier_id", "reorder_point", "target_quantity", "basis", "cover_days", "lead_days", "safety_stock", "enabled", "remark", "updated_at",
+		}),
+	}).Create(setting).Error
+}
+
+func (m *ReorderModule) GetByStoreAndProduct(storeID, productID uint) (*model.StoreProductReorderSetting, error) {
+	var setting model.StoreProductReorderSetting
+	if err := m.db.Preload("Product").Where("store_id = ? AND product_id = ?", storeID, productID).First(&setting).Error; err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+func (m *ReorderModule) GetByIDScoped(id, storeID uint, hqUnbound bool) (*model.StoreProductReorderSetting, error) {
+	var setting model.StoreProductReorderSetting
+	query := m.db.Where("id = ?", id)
+	if !hqUnbound {
+		query = query.Where("store_id = ?", storeID)
+	}
+	if err := query.First(&setting).Error; err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+func (m *ReorderModule) Delete(id uint) error {
+	return m.db.Delete(&model.StoreProductReorderSetting{}, id).Error
+}
+
+func (m *ReorderModule) List(req *model.ListReorderSettingReq) ([]*model.StoreProductReorderSetting, int64, error) {
+	var settings []*model.StoreProductReorderSetting
+	var total int64
+
+	query := m.db.Model(&model.StoreProductReorderSetting{})
+	if req.StoreID > 0 {
+		query = query.Where("store_product_reorder_settings.store_id = ?", req.StoreID)
+	}
+	if req.SupplierID > 0 {
+		query = query.Where("store_product_reorder_settings.supplier_id = ?", req.SupplierID)
+	}
+	if req.Keyword != "" {
+		query = query.Joins("JOIN supplier_products sp ON sp.id = store_product_reorder_settings.product_id").
+			Where("sp.name LIKE ?", "%"+req.Keyword+"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Preload("Product").
+		Order("store_product_reorder_settings.id DESC").
+		Offset(offset).Limit(req.PageSize).
+		Find(&settings).Error; err != nil {
+		return nil, 0, err
+	}
+	return settings, total, nil
+}
+
+// ListEnabledByStore 门店启用中的补货设置；商品须仍在售且供应商仍绑定到门店
+func (m *ReorderModule) ListEnabledByStore(storeID, supplierID uint) ([]*model.StoreProductReorderSetting, error) {
+	var settings []*model.StoreProductReorderSetting
+	query := m.db.Preload("Product").Preload("Product.Supplier").
+		Joins("JOIN supplier_products sp ON sp.id = store_product_reorder_settings.product_id AND sp.status = 1").
+		Where("store_product_reorder_settings.store_id = ? AND store_product_reorder_settings.enabled = ?", storeID, true).
+		Where("sp.supplier_id IN (?)", m.db.Model(&model.StoreSupplier{}).Select("supplier_id").Where("store_id = ? AND status = 1", storeID))
+	if supplierID > 0 {
+		query = query.Where("sp.supplier_id = ?", supplierID)
+	}
+	err := query.Order("store_product_reorder_settings.id ASC").Find(&settings).Error
+	return settings, err
+}
+
+// SumOpenPurchaseQuantities 门店未完成（待确认/已确认）采购单中各商品尚未到货的数量
+func (m *ReorderModule) SumOpenPurchaseQuantities(storeID uint) (map[uint]float64, error) {
+	var rows []model.ProductQuantity
+	if err := m.db.Table("purchase_order_items poi").
+		Select("poi.product_id, SUM(poi.quantity - poi.received_quantity) AS quantity").
+		Joins("JOIN purchase_orders po ON po.id = poi.order_id").
+		Where("po.store_id = ? AND po.status IN ?", storeID, []int8{model.PurchaseStatusPending, model.PurchaseStatusConfirmed}).
+		Group("poi.product_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[uint]float64, len(rows))
+	for _, row := range rows {
+		result[row.ProductID] = row.Quantity
+	}
+	return result, nil
+}
+
+// SumAccountConsumption 门店自 since 起（按记账日期）未作废记账明细的商品数量，按记账单位分组
+func (m *ReorderModule) SumAccountConsumption(storeID uint, since time.Time) ([]model.ProductQuantity, error) {
+	var rows []model.ProductQuantity
+	err := m.db.Table("store_account_items sai").
+		Select("sai.product_id, sai.unit, SUM(sai.quantity) AS quantity").
+		Joins("JOIN store_accounts sa ON sa.id = sai.account_id").
+		Where("sa.store_id = ? AND sa.account_date >= ? AND sa.is_canceled = ?", storeID, since.Format("2006-01-02"), false).
+		Where("sa.deleted_at IS NULL AND sai.deleted_at IS NULL AND sai.product_id > 0").
+		Group("sai.product_id, sai.unit").
+		Scan(&rows).Error
+	return rows, err
+}
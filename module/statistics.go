@@ -45,23 +45,31 @@ func (m *StatisticsModule) GetInventoryStats(storeID uint) (*model.InventoryStat
 	stats.TotalProducts = inventorySummary.TotalProducts
 	stats.TotalQuantity = inventorySummary.TotalQuantity
 
+	orderQuery := withStoreID(m.db.Model(&model.InventoryOrder{}).Where("deleted_at IS NULL"), storeID)
+	if err := orderQuery.Count(&stats.TotalRecords).Error; err != nil {
+		return nil, err
+	}
+
+	// 今日出入库按明细汇总：调整单（盘点等）明细数量带符号，盘盈计入入库、盘亏计入出库
 	today := time.Now().Format("2006-01-02")
-	var orderSummary struct {
-		TotalRecords int64
-		TodayIn      float64
-		TodayOut     float64
+	var todaySummary struct {
+		TodayIn  float64
+		TodayOut float64
 	}
-	orderQuery := withStoreID(m.db.Model(&model.InventoryOrder{}).Where("deleted_at IS NULL"), storeID)
-	if err := orderQuery.Select(`
-		COUNT(*) AS total_records,
-		COALESCE(SUM(CASE WHEN type = ? AND created_at >= ? AND created_at < DATE_ADD(?, INTERVAL 1 DAY) THEN total_quantity ELSE 0 END), 0) AS today_in,
-		COALESCE(SUM(CASE WHEN type = ? AND created_at >= ? AND created_at < DATE_ADD(?, INTERVAL 1 DAY) THEN total_quantity ELSE 0 END), 0) AS today_out
-	`, model.InventoryTypeIn, today, today, model.InventoryTypeOut, today, today).Scan(&orderSummary).Error; err != nil {
+	todayQuery := m.db.Table("inventory_order_items ioi").
+		Joins("JOIN inventory_orders io ON io.id = ioi.order_id AND io.deleted_at IS NULL").
+		Where("io.created_at >= ? AND io.created_at < DATE_ADD(?, INTERVAL 1 DAY)", today, today)
+	if storeID > 0 {
+		todayQuery = todayQuery.Where("io.store_id = ?", storeID)
+	}
+	if err := todayQuery.Select(`
+		COALESCE(SUM(CASE WHEN io.type = ? OR (io.type = ? AND ioi.quantity > 0) THEN ABS(ioi.quantity) ELSE 0 END), 0) AS today_in,
+		COALESCE(SUM(CASE WHEN io.type = ? OR (io.type = ? AND ioi.quantity < 0) THEN ABS(ioi.quantity) ELSE 0 END), 0) AS today_out
+	`, model.InventoryTypeIn, model.InventoryTypeAdjust, model.InventoryTypeOut, model.InventoryTypeAdjust).Scan(&todaySummary).Error; err != nil {
 		return nil, err
 	}
-	stats.TotalRecords = orderSummary.TotalRecords
-	stats.TodayIn = orderSummary.TodayIn
-	stats.TodayOut = orderSummary.TodayOut
+	stats.TodayIn = todaySummary.TodayIn
+	stats.TodayOut = todaySummary.TodayOut
 
 	return stats, nil
 }
@@ -239,8 +247,8 @@ func (m *StatisticsModule) GetBusinessOverview(storeID uint, startDate, endDate
 SELECT
 	COALESCE(sp.category_id, 0) AS category_id,
 	COALESCE(sc.name, '未分类') AS category_name,
-	COALESCE(SUM(CASE WHEN io.type = 1 THEN ioi.quantity * COALESCE(sp.price, 0) ELSE 0 END), 0) AS in_amount,
-	COALESCE(SUM(CASE WHEN io.type = 2 THEN ioi.quantity * COALESCE(sp.price, 0) ELSE 0 END), 0) AS out_amount
+	COALESCE(SUM(CASE WHEN io.type = 1 OR (io.type = 3 AND ioi.quantity > 0) THEN ABS(ioi.quantity) * COALESCE(sp.price, 0) ELSE 0 END), 0) AS in_amount,
+	COALESCE(SUM(CASE WHEN io.type = 2 OR (io.type = 3 AND ioi.quantity < 0) THEN ABS(ioi.quantity) * COALESCE(sp.price, 0) ELSE 0 END), 0) AS out_amount
 FROM inventory_order_items ioi
 JOIN inventory_orders io ON io.id = ioi.order_id AND io.deleted_at IS NULL
 LEFT JOIN supplier_products sp ON sp.id = ioi.product_id
@@ -425,7 +433,7 @@ ORDER BY dd.sort ASC, dd.id ASC
 		B2BSupplyOrderCount int64
 		B2BSupplyAmount     float64
 	}
-	if err := b2bQuery.Select("COUNT(*) AS b2b_supply_order_count, COALESCE(SUM(total_amount), 0) AS b2b_supply_amount").Scan(&b2bSummary).Error; err != nil {
+	if err := b2bQuery.Select("COUNT(*) AS b2b_supply_order_count, COALESCE(SUM(total_amount - returned_amount), 0) AS b2b_supply_amount").Scan(&b2bSummary).Error; err != nil {
 		return nil, err
 	}
 	stats.B2BSupplyOrderCount = b2bSummary.B2BSupplyOrderCount
@@ -529,3 +537,20 @@ func calculateBusinessOverviewNetProfit(stats *model.BusinessOverviewStats, item
 	// 门店支出在大屏单独展示；记账净利保持与有效记账单的净利润口径一致。
 	return stats.SalesAmount - stats.OtherExpenseAmount - stats.ErrandFeeAmount - stats.ConsumableAmount - itemCostAmount - stats.GiftWineCostAmount - stats.RoundAmount
 }
+
+// ListExpiringLots 查询到期日不晚于 cutoff 的有库存批次（含已过期），storeID 为 0 表示全部门店
+func (m *StatisticsModule) ListExpiringLots(storeID uint, cutoff string) ([]model.ExpiringLotRow, error) {
+	var rows []model.ExpiringLotRow
+	query := m.db.Table("inventory_lots AS l").
+		Select("l.id AS lot_id, l.store_id, s.name AS store_name, l.product_id, sp.name AS product_name, l.expiry_date, l.quantity, l.unit").
+		Joins("LEFT JOIN stores s ON s.id = l.store_id").
+		Joins("LEFT JOIN supplier_products sp ON sp.id = l.product_id").
+		Where("l.quantity > 0 AND l.expiry_date IS NOT NULL AND l.expiry_date <= ?", cutoff)
+	if storeID > 0 {
+		query = query.Where("l.store_id = ?", storeID)
+	}
+	if err := query.Order("l.store_id ASC, l.expiry_date ASC, l.id ASC").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,376 @@
+package module
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StockTransferModule struct {
+	db *gorm.DB
+}
+
+func NewStockTransferModule(db *gorm.DB) *StockTransferModule {
+	return &StockTransferModule{db: db}
+}
+
+// DispatchWithStockOut 保存调拨单并在调出门店出库（同事务，复用出入库单的加锁扣减路径）。
+// 明细按出库实际扣减的批次拆行，收货和撤销时按行上的日期入库到对应批次。
+func (m *StockTransferModule) DispatchWithStockOut(transfer *model.StockTransfer, outOrder *model.InventoryOrder) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := applyInventoryOrder(tx, outOrder); err != nil {
+			return err
+		}
+		draws, err := loadStockTransferLotDraws(tx, outOrder.ID)
+		if err != nil {
+			return err
+		}
+		transfer.Items = splitStockTransferItemsByLot(transfer.Items, draws)
+		transfer.ItemCount = len(transfer.Items)
+		transfer.OutOrderNo = outOrder.OrderNo
+		return tx.Create(transfer).Error
+	})
+}
+
+// stockTransferLotDraw 调出时从单个批次扣减的数量及批次日期
+type stockTransferLotDraw struct {
+	ProductID      uint
+	ProductionDate *time.Time
+	ExpiryDate     *time.Time
+	Quantity       float64
+}
+
+// loadStockTransferLotDraws 按扣减顺序查询调拨出库单扣减的批次，按商品分组
+func loadStockTransferLotDraws(tx *gorm.DB, outOrderID uint) (map[uint][]stockTransferLotDraw, error) {
+	var rows []stockTransferLotDraw
+	if err := tx.Table("inventory_movement_lots AS iml").
+		Select("iml.product_id, il.production_date, il.expiry_date, iml.quantity").
+		Joins("JOIN inventory_lots il ON il.id = iml.lot_id").
+		Where("iml.source_type = ? AND iml.source_id = ?", model.MovementSourceInventoryOrder, outOrderID).
+		Order("iml.id ASC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	draws := make(map[uint][]stockTransferLotDraw)
+	for _, row := range rows {
+		draws[row.ProductID] = append(draws[row.ProductID], row)
+	}
+	return draws, nil
+}
+
+// splitStockTransferItemsByLot 把明细按调出扣减的批次拆成每批次一行并带上批次日期。
+// 同一商品多行时按顺序领用批次；未分批的历史库存保留为不带日期的一行；选择规格数量按基础数量比例折算，末行取余。
+func splitStockTransferItemsByLot(items []model.StockTransferItem, draws map[uint][]stockTransferLotDraw) []model.StockTransferItem {
+	result := make([]model.StockTransferItem, 0, len(items))
+	for _, item := range items {
+		pending := draws[item.ProductID]
+		remaining := item.BaseQuantity
+		lines := make([]model.StockTransferItem, 0, 1)
+		for len(pending) > 0 && remaining > 0 {
+			draw := &pending[0]
+			take := min(draw.Quantity, remaining)
+			line := item
+			line.BaseQuantity = take
+			line.ProductionDate = draw.ProductionDate
+			line.ExpiryDate = draw.ExpiryDate
+			lines = append(lines, line)
+			draw.Quantity = roundQuantity(draw.Quantity - take)
+			remaining = roundQuantity(remaining - take)
+			if draw.Quantity <= 0 {
+				pending = pending[1:]
+			}
+		}
+		draws[item.ProductID] = pending
+		if remaining > 0 || len(lines) == 0 {
+			line := item
+			line.BaseQuantity = remaining
+			lines = append(lines, line)
+		}
+		if len(lines) > 1 && item.BaseQuantity > 0 {
+			var used float64
+			for i := range lines[:len(lines)-1] {
+				lines[i].Quantity = roundQuantity(item.Quantity * lines[i].BaseQuantity / item.BaseQuantity)
+				used += lines[i].Quantity
+			}
+			lines[len(lines)-1].Quantity = roundQuantity(item.Quantity - used)
+		}
+		result = append(result, lines...)
+	}
+	return result
+}
+
+// ReceiveWithStockIn 调入门店收货：锁定调拨单后校验在途数量，写入调拨入库单并回写明细与状态（同事务）。
+// inOrder 由调用方填好单号、原因与操作人，明细与门店信息在此按实收数量生成。
+func (m *StockTransferModule) ReceiveWithStockIn(id, storeID uint, hqUnbound bool, req *model.ReceiveStockTransferReq, receiverID uint, receiverName string, inOrder *model.InventoryOrder) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		var transfer model.StockTransfer
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").Where("id = ?", id)
+		if !hqUnbound {
+			query = query.Where("to_store_id = ?", storeID)
+		}
+		if err := query.First(&transfer).Error; err != nil {
+			return err
+		}
+		if transfer.Status != model.StockTransferStatusInTransit && transfer.Status != model.StockTransferStatusPartial {
+			return apicode.Newf(apicode.OrderStateConflict, "调拨单当前状态不允许收货")
+		}
+
+		received, err := applyStockTransferReceipt(transfer.Items, req.Items, req.Finish)
+		if err != nil {
+			return err
+		}
+		if len(received) == 0 && !req.Finish {
+			return apicode.Newf(apicode.ValidationFailed, "请填写实收数量")
+		}
+
+		var receivedTotal float64
+		if len(received) > 0 {
+			inOrder.Type = model.InventoryTypeIn
+			inOrder.StoreID = transfer.ToStoreID
+			inOrder.StoreName = transfer.ToStoreName
+			inOrder.Items = received
+			inOrder.ItemCount = len(received)
+			for _, item := range received {
+				receivedTotal += item.Quantity
+			}
+			inOrder.TotalQuantity = roundQuantity(receivedTotal)
+			if err := applyInventoryOrder(tx, inOrder); err != nil {
+				return err
+			}
+		}
+
+		for _, item := range transfer.Items {
+			if err := tx.Model(&model.StockTransferItem{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
+				"received_quantity":    item.ReceivedQuantity,
+				"discrepancy_quantity": item.DiscrepancyQuantity,
+				"discrepancy_reason":   item.DiscrepancyReason,
+			}).Error; err != nil {
+				return fmt.Errorf("update stock transfer item %d: %w", item.ID, err)
+			}
+		}
+
+		status, hasDiscrepancy := resolveStockTransferStatus(transfer.Items)
+		updates := map[string]interface{}{
+			"status":          status,
+			"received_total":  roundQuantity(transfer.ReceivedTotal + receivedTotal),
+			"has_discrepancy": hasDiscrepancy,
+			"receiver_id":     receiverID,
+			"receiver_name":   receiverName,
+		}
+		if status == model.StockTransferStatusReceived {
+			updates["received_at"] = time.Now()
+		}
+		return tx.Model(&model.StockTransfer{}).Where("id = ?", transfer.ID).Updates(updates).Error
+	})
+}
+
+// CancelWithStockRestore 撤销尚未收货的调拨单，在途数量退回调出门店（同事务）
+func (m *StockTransferModule) CancelWithStockRestore(id, storeID uint, hqUnbound bool, restoreOrder *model.InventoryOrder) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		var transfer model.StockTransfer
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").Where("id = ?", id)
+		if !hqUnbound {
+			query = query.Where("from_store_id = ?", storeID)
+		}
+		if err := query.First(&transfer).Error; err != nil {
+			return err
+		}
+		if transfer.Status != model.StockTransferStatusInTransit || transfer.ReceivedTotal > 0 {
+			return apicode.Newf(apicode.OrderStateConflict, "仅未收货的在途调拨单可以撤销")
+		}
+
+		items := make([]model.InventoryOrderItem, 0, len(transfer.Items))
+		for _, item := range transfer.Items {
+			items = append(items, model.InventoryOrderItem{
+				ProductID:      item.ProductID,
+				ProductName:    item.ProductName,
+				Quantity:       item.BaseQuantity,
+				Unit:           item.BaseUnit,
+				ProductionDate: item.ProductionDate,
+				ExpiryDate:     item.ExpiryDate,
+				Remark:         item.Remark,
+			})
+		}
+		restoreOrder.Type = model.InventoryTypeIn
+		restoreOrder.StoreID = transfer.FromStoreID
+		restoreOrder.StoreName = transfer.FromStoreName
+		restoreOrder.Items = items
+		restoreOrder.ItemCount = len(items)
+		restoreOrder.TotalQuantity = transfer.TotalQuantity
+		if err := applyInventoryOrder(tx, restoreOrder); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		res := tx.Model(&model.StockTransfer{}).
+			Where("id = ? AND status = ?", transfer.ID, model.StockTransferStatusInTransit).
+			Updates(map[string]interface{}{
+				"status":      model.StockTransferStatusCanceled,
+				"canceled_at": &now,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return apicode.Newf(apicode.OrderStateConflict, "调拨单状态已变化，请刷新后重试")
+		}
+		return nil
+	})
+}
+
+// GetByIDScoped 获取调拨单；门店账号仅能查看本店调出或调入的单据
+func (m *StockTransferModule) GetByIDScoped(id, storeID uint, hqUnbound bool) (*model.StockTransfer, error) {
+	var transfer model.StockTransfer
+	query := m.db.Preload("Items").Where("id = ?", id)
+	if !hqUnbound {
+		query = query.Where("from_store_id = ? OR to_store_id = ?", storeID, storeID)
+	}
+	if err := query.First(&transfer).Error; err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// List 调拨单列表
+func (m *StockTransferModule) List(req *model.ListStockTransferReq) ([]*model.StockTransfer, int64, error) {
+	transfers := make([]*model.StockTransfer, 0)
+	var total int64
+
+	query := m.db.Model(&model.StockTransfer{})
+	if req.StoreID > 0 {
+		switch req.Direction {
+		case "out":
+			query = query.Where("from_store_id = ?", req.StoreID)
+		case "in":
+			query = query.Where("to_store_id = ?", req.StoreID)
+		default:
+			query = query.Where("(from_store_id = ? OR to_store_id = ?)", req.StoreID, req.StoreID)
+		}
+	}
+	if req.Status > 0 {
+		query = query.Where("status = ?", req.Status)
+	}
+	if kw := strings.TrimSpace(req.Keyword); kw != "" {
+		like := "%" + kw + "%"
+		query = query.Where("(transfer_no LIKE ? OR from_store_name LIKE ? OR to_store_name LIKE ?)", like, like, like)
+	}
+	if req.StartDate != "" {
+		query = query.Where("created_at >= ?", req.StartDate+" 00:00:00")
+	}
+	if req.EndDate != "" {
+		query = query.Where("created_at <= ?", req.EndDate+" 23:59:59")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return transfers, 0, err
+	}
+
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Preload("Items").Order("id DESC").Offset(offset).Limit(req.PageSize).Find(&transfers).Error; err != nil {
+		return transfers, 0, err
+	}
+	return transfers, total, nil
+}
+
+// GenerateTransferNo 生成调拨单号：DB + 日期 + 序号，如 DB202412070001
+func (m *StockTransferModule) GenerateTransferNo() string {
+	prefix := "DB"
+	today := time.Now().Format("20060102")
+	pattern := prefix + today + "%"
+
+	var maxNo string
+	m.db.Model(&model.StockTransfer{}).
+		Where("transfer_no LIKE ?", pattern).
+		Order("transfer_no DESC").
+		Limit(1).
+		Pluck("transfer_no", &maxNo)
+
+	seq := 1
+	if maxNo != "" && len(maxNo) >= 14 {
+		fmt.Sscanf(maxNo[len(maxNo)-4:], "%d", &seq)
+		seq++
+	}
+	return fmt.Sprintf("%s%s%04d", prefix, today, seq)
+}
+
+// applyStockTransferReceipt 把本次收货数量累加到明细上，返回需要入库的明细行。
+// finish=true 时把剩余在途数量记为差异；实收超过在途数量时报错。
+func applyStockTransferReceipt(items []model.StockTransferItem, lines []model.ReceiveStockTransferItemReq, finish bool) ([]model.InventoryOrderItem, error) {
+	index := make(map[uint]int, len(items))
+	for i := range items {
+		index[items[i].ID] = i
+	}
+
+	received := make([]model.InventoryOrderItem, 0, len(lines))
+	for _, line := range lines {
+		i, ok := index[line.ItemID]
+		if !ok {
+			return nil, apicode.Newf(apicode.ItemNotFound, "调拨明细 %d 不属于该调拨单", line.ItemID)
+		}
+		item := &items[i]
+		qty := roundQuantity(line.ReceivedQuantity)
+		if qty > roundQuantity(item.PendingQuantity()) {
+			return nil, apicode.Newf(apicode.ValidationFailed, "商品【%s】实收数量超过在途数量 %.2f%s", item.ProductName, item.PendingQuantity(), item.BaseUnit)
+		}
+		if reason := strings.TrimSpace(line.DiscrepancyReason); reason != "" {
+			item.DiscrepancyReason = reason
+		}
+		if qty <= 0 {
+			continue
+		}
+		item.ReceivedQuantity = roundQuantity(item.ReceivedQuantity + qty)
+		received = append(received, model.InventoryOrderItem{
+			ProductID:      item.ProductID,
+			ProductName:    item.ProductName,
+			Quantity:       qty,
+			Unit:           item.BaseUnit,
+			ProductionDate: item.ProductionDate,
+			ExpiryDate:     item.ExpiryDate,
+			Remark:         item.Remark,
+		})
+	}
+
+	if finish {
+		for i := range items {
+			items[i].DiscrepancyQuantity = roundQuantity(items[i].BaseQuantity - items[i].ReceivedQuantity)
+		}
+	}
+	return received, nil
+}
+
+// resolveStockTransferStatus 根据明细收货情况推导调拨单状态及是否存在差异
+func resolveStockTransferStatus(items []model.StockTransferItem) (int8, bool) {
+	allDone := true
+	anyReceived := false
+	hasDiscrepancy := false
+	for _, item := range items {
+		if item.ReceivedQuantity > 0 {
+			anyReceived = true
+		}
+		if item.DiscrepancyQuantity != 0 {
+			hasDiscrepancy = true
+		}
+		if roundQuantity(item.PendingQuantity()) > 0 {
+			allDone = false
+		}
+	}
+	switch {
+	case allDone:
+		return model.StockTransferStatusReceived, hasDiscrepancy
+	case anyReceived:
+		return model.StockTransferStatusPartial, hasDiscrepancy
+	default:
+		return model.StockTransferStatusInTransit, hasDiscrepancy
+	}
+}
+
+// roundQuantity 库存数量按两位小数取整，与 decimal(10,2) 列保持一致
+func roundQuantity(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,117 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+)
+
+func newTransferItems() []model.StockTransferItem {
+	return []model.StockTransferItem{
+		{ID: 1, ProductID: 11, ProductName: "啤酒", BaseQuantity: 24, BaseUnit: "瓶"},
+		{ID: 2, ProductID: 12, ProductName: "白酒", BaseQuantity: 6, BaseUnit: "瓶"},
+	}
+}
+
+func TestApplyStockTransferReceiptPartial(t *testing.T) {
+	items := newTransferItems()
+	received, err := applyStockTransferReceipt(items, []model.ReceiveStockTransferItemReq{
+		{ItemID: 1, ReceivedQuantity: 20},
+	}, false)
+	if err != nil {
+		t.Fatalf("applyStockTransferReceipt() error = %v", err)
+	}
+	if len(received) != 1 || received[0].ProductID != 11 || received[0].Quantity != 20 || received[0].Unit != "瓶" {
+		t.Fatalf("received lines = %#v", received)
+	}
+	if items[0].ReceivedQuantity != 20 || items[0].DiscrepancyQuantity != 0 {
+		t.Fatalf("item 1 = %#v", items[0])
+	}
+	status, hasDiscrepancy := resolveStockTransferStatus(items)
+	if status != model.StockTransferStatusPartial || hasDiscrepancy {
+		t.Fatalf("status = %d/%v, want partial without discrepancy", status, hasDiscrepancy)
+	}
+}
+
+func TestApplyStockTransferReceiptFinishRecordsShortage(t *testing.T) {
+	items := newTransferItems()
+	items[0].ReceivedQuantity = 20
+	received, err := applyStockTransferReceipt(items, []model.ReceiveStockTransferItemReq{
+		{ItemID: 2, ReceivedQuantity: 5, DiscrepancyReason: " 运输破损 "},
+	}, true)
+	if err != nil {
+		t.Fatalf("applyStockTransferReceipt() error = %v", err)
+	}
+	if len(received) != 1 || received[0].Quantity != 5 {
+		t.Fatalf("received lines = %#v", received)
+	}
+	if items[0].DiscrepancyQuantity != 4 || items[1].DiscrepancyQuantity != 1 || items[1].DiscrepancyReason != "运输破损" {
+		t.Fatalf("discrepancies = %#v", items)
+	}
+	status, hasDiscrepancy := resolveStockTransferStatus(items)
+	if status != model.StockTransferStatusReceived || !hasDiscrepancy {
+		t.Fatalf("status = %d/%v, want received with discrepancy", status, hasDiscrepancy)
+	}
+}
+
+func TestApplyStockTransferReceiptRejectsInvalidLines(t *testing.T) {
+	if _, err := applyStockTransferReceipt(newTransferItems(), []model.ReceiveStockTransferItemReq{
+		{ItemID: 1, ReceivedQuantity: 25},
+	}, false); !apicode.Is(err, apicode.ValidationFailed) {
+		t.Fatalf("over receipt error = %v", err)
+	}
+	if _, err := applyStockTransferReceipt(newTransferItems(), []model.ReceiveStockTransferItemReq{
+		{ItemID: 99, ReceivedQuantity: 1},
+	}, false); !apicode.Is(err, apicode.ItemNotFound) {
+		t.Fatalf("foreign item error = %v", err)
+	}
+}
+
+func TestResolveStockTransferStatusFullReceipt(t *testing.T) {
+	items := newTransferItems()
+	items[0].ReceivedQuantity = 24
+	items[1].ReceivedQuantity = 6
+	status, hasDiscrepancy := resolveStockTransferStatus(items)
+	if status != model.StockTransferStatusReceived || hasDiscrepancy {
+		t.Fatalf("status = %d/%v, want received without discrepancy", status, hasDiscrepancy)
+	}
+}
+
+func TestSplitStockTransferItemsByLot(t *testing.T) {
+	items := []model.StockTransferItem{
+		{ProductID: 11, Unit: "箱", Quantity: 2, BaseQuantity: 24, BaseUnit: "瓶"},
+		{ProductID: 11, Unit: "瓶", Quantity: 6, BaseQuantity: 6, BaseUnit: "瓶"},
+		{ProductID: 12, Unit: "瓶", Quantity: 3, BaseQuantity: 3, BaseUnit: "瓶"},
+	}
+	draws := map[uint][]stockTransferLotDraw{
+		11: {
+			{ProductID: 11, ExpiryDate: lotDate("2026-11-01"), Quantity: 18},
+			{ProductID: 11, ExpiryDate: lotDate("2026-12-01"), Quantity: 10},
+		},
+		12: {{ProductID: 12, ExpiryDate: lotDate("2027-01-01"), Quantity: 3}},
+	}
+	lines := splitStockTransferItemsByLot(items, draws)
+	want := []struct {
+		productID uint
+		expiry    string
+		quantity  float64
+		base      float64
+	}{
+		{11, "2026-11-01", 1.5, 18},
+		{11, "2026-12-01", 0.5, 6},
+		{11, "2026-12-01", 4, 4},
+		{11, "", 2, 2},
+		{12, "2027-01-01", 3, 3},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("lines = %#v", lines)
+	}
+	for i, w := range want {
+		line := lines[i]
+		if line.ProductID != w.productID || model.InventoryLotKey(line.ExpiryDate) != w.expiry ||
+			line.Quantity != w.quantity || line.BaseQuantity != w.base {
+			t.Fatalf("line[%d] = %#v, want %+v", i, line, w)
+		}
+	}
+}
@@ -0,0 +1,390 @@
+package module
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StocktakeModule struct {
+	db *gorm.DB
+}
+
+func NewStocktakeModule(db *gorm.DB) *StocktakeModule {
+	return &StocktakeModule{db: db}
+}
+
+// ListStoreSnapshot 读取门店当前全部库存，作为盘点快照（数量为基础库存单位）
+func (m *StocktakeModule) ListStoreSnapshot(storeID uint) ([]model.StocktakeItem, error) {
+	var rows []struct {
+		ProductID   uint
+		ProductName string
+		Unit        string
+		Quantity    float64
+	}
+	if err := m.db.Table("inventories i").
+		Select("i.product_id, sp.name AS product_name, COALESCE(NULLIF(i.unit, ''), sp.unit) AS unit, i.quantity").
+		Joins("LEFT JOIN supplier_products sp ON sp.id = i.product_id").
+		Where("i.store_id = ? AND i.deleted_at IS NULL", storeID).
+		Order("i.product_id ASC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	items := make([]model.StocktakeItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, model.StocktakeItem{
+			ProductID:      row.ProductID,
+			ProductName:    row.ProductName,
+			BaseUnit:       row.Unit,
+			SystemQuantity: row.Quantity,
+		})
+	}
+	return items, nil
+}
+
+// CreateOpen 开启盘点：锁定门店行，确保同一门店只有一张盘点中的单据
+func (m *StocktakeModule) CreateOpen(stocktake *model.Stocktake) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		var store model.Store
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&store, stocktake.StoreID).Error; err != nil {
+			return apicode.New(apicode.StoreNotFound)
+		}
+		var openCount int64
+		if err := tx.Model(&model.Stocktake{}).
+			Where("store_id = ? AND status = ?", stocktake.StoreID, model.StocktakeStatusCounting).
+			Count(&openCount).Error; err != nil {
+			return err
+		}
+		if openCount > 0 {
+			return apicode.Newf(apicode.OrderStateConflict, "门店已有进行中的盘点单，请先过账或作废")
+		}
+		stocktake.ItemCount = len(stocktake.Items)
+		return tx.Create(stocktake).Error
+	})
+}
+
+// RecordCounts 录入实盘数量并重算差异（同事务）
+func (m *StocktakeModule) RecordCounts(id, storeID uint, hqUnbound bool, lines []model.StocktakeCountLine) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		stocktake, err := lockStocktake(tx, id, storeID, hqUnbound)
+		if err != nil {
+			return err
+		}
+		if stocktake.Status != model.StocktakeStatusCounting {
+			return apicode.Newf(apicode.OrderStateConflict, "盘点单已结束，不能继续录入")
+		}
+
+		var items []model.StocktakeItem
+		if err := tx.Where("stocktake_id = ?", stocktake.ID).Find(&items).Error; err != nil {
+			return err
+		}
+		byProduct := make(map[uint]*model.StocktakeItem, len(items))
+		for i := range items {
+			byProduct[items[i].ProductID] = &items[i]
+		}
+
+		touched := make(map[uint]*model.StocktakeItem)
+		cleared := make(map[uint]bool)
+		for _, line := range lines {
+			item := byProduct[line.Item.ProductID]
+			if item == nil {
+				newItem := line.Item
+				newItem.ID = 0
+				newItem.StocktakeID = stocktake.ID
+				if err := tx.Create(&newItem).Error; err != nil {
+					return err
+				}
+				item = &newItem
+				byProduct[item.ProductID] = item
+			}
+			if line.Replace && !cleared[item.ID] {
+				if err := tx.Where("item_id = ?", item.ID).Delete(&model.StocktakeEntry{}).Error; err != nil {
+					return err
+				}
+				cleared[item.ID] = true
+			}
+			entry := line.Entry
+			entry.ID = 0
+			entry.StocktakeID = stocktake.ID
+			entry.ItemID = item.ID
+			entry.ProductID = item.ProductID
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+			if remark := strings.TrimSpace(line.Item.Remark); remark != "" {
+				item.Remark = remark
+			}
+			touched[item.ID] = item
+		}
+
+		for _, item := range touched {
+			var counted float64
+			if err := tx.Model(&model.StocktakeEntry{}).Where("item_id = ?", item.ID).
+				Select("COALESCE(SUM(base_quantity), 0)").Scan(&counted).Error; err != nil {
+				return err
+			}
+			setStocktakeItemCount(item, counted)
+			if err := tx.Model(&model.StocktakeItem{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
+				"counted_quantity":  item.CountedQuantity,
+				"counted":           true,
+				"variance_quantity": item.VarianceQuantity,
+				"variance_amount":   item.VarianceAmount,
+				"remark":            item.Remark,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		var itemCount, countedCount int64
+		if err := tx.Model(&model.StocktakeItem{}).Where("stocktake_id = ?", stocktake.ID).Count(&itemCount).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.StocktakeItem{}).Where("stocktake_id = ? AND counted = ?", stocktake.ID, true).Count(&countedCount).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Stocktake{}).Where("id = ?", stocktake.ID).Updates(map[string]interface{}{
+			"item_count":    itemCount,
+			"counted_count": countedCount,
+		}).Error
+	})
+}
+
+// PostWithAdjustment 过账：将差异写成一张盘点调整单并同步库存（同事务）。
+// order 由调用方填好单号、门店、原因与操作人，明细在此按差异生成；无差异时不生成调整单。
+func (m *StocktakeModule) PostWithAdjustment(id, storeID uint, hqUnbound, zeroUncounted bool, posterID uint, posterName string, order *model.InventoryOrder) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		stocktake, err := lockStocktake(tx, id, storeID, hqUnbound)
+		if err != nil {
+			return err
+		}
+		if stocktake.Status != model.StocktakeStatusCounting {
+			return apicode.Newf(apicode.OrderStateConflict, "盘点单当前状态不允许过账")
+		}
+
+		var items []model.StocktakeItem
+		if err := tx.Where("stocktake_id = ?", stocktake.ID).Order("id ASC").Find(&items).Error; err != nil {
+			return err
+		}
+		if zeroUncounted {
+			for i := range items {
+				if items[i].Counted {
+					continue
+				}
+				setStocktakeItemCount(&items[i], 0)
+				if err := tx.Model(&model.StocktakeItem{}).Where("id = ?", items[i].ID).Updates(map[string]interface{}{
+					"counted_quantity":  0,
+					"counted":           true,
+					"variance_quantity": items[i].VarianceQuantity,
+					"variance_amount":   items[i].VarianceAmount,
+				}).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		// 盘点期间门店仍可能出入库，调整量按过账时锁定的当前库存计算（实盘 - 当前），开单快照差异仅用于展示
+		current, err := lockStocktakeInventories(tx, stocktake.StoreID, items)
+		if err != nil {
+			return err
+		}
+		adjustment := buildStocktakeAdjustment(items, current)
+		updates := map[string]interface{}{
+			"status":         model.StocktakeStatusPosted,
+			"variance_count": len(adjustment.Items),
+			"gain_amount":    adjustment.GainAmount,
+			"loss_amount":    adjustment.LossAmount,
+			"counted_count":  adjustment.CountedCount,
+			"poster_id":      posterID,
+			"poster_name":    posterName,
+			"posted_at":      time.Now(),
+		}
+		if len(adjustment.Items) > 0 {
+			order.Type = model.InventoryTypeAdjust
+			order.StoreID = stocktake.StoreID
+			order.StoreName = stocktake.StoreName
+			order.Items = adjustment.Items
+			order.ItemCount = len(adjustment.Items)
+			order.TotalQuantity = adjustment.NetQuantity
+			if err := applyInventoryOrder(tx, order); err != nil {
+				return err
+			}
+			updates["adjust_order_no"] = order.OrderNo
+		}
+		return tx.Model(&model.Stocktake{}).Where("id = ?", stocktake.ID).Updates(updates).Error
+	})
+}
+
+// Cancel 作废盘点中的单据
+func (m *StocktakeModule) Cancel(id, storeID uint, hqUnbound bool) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		stocktake, err := lockStocktake(tx, id, storeID, hqUnbound)
+		if err != nil {
+			return err
+		}
+		if stocktake.Status != model.StocktakeStatusCounting {
+			return apicode.Newf(apicode.OrderStateConflict, "仅盘点中的单据可以作废")
+		}
+		return tx.Model(&model.Stocktake{}).Where("id = ?", stocktake.ID).Updates(map[string]interface{}{
+			"status":      model.StocktakeStatusCanceled,
+			"canceled_at": time.Now(),
+		}).Error
+	})
+}
+
+func lockStocktake(tx *gorm.DB, id, storeID uint, hqUnbound bool) (*model.Stocktake, error) {
+	var stocktake model.Stocktake
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id)
+	if !hqUnbound {
+		query = query.Where("store_id = ?", storeID)
+	}
+	if err := query.First(&stocktake).Error; err != nil {
+		return nil, apicode.New(apicode.OrderNotFound)
+	}
+	return &stocktake, nil
+}
+
+// GetByIDScoped 获取盘点单详情（含明细与录入记录）
+func (m *StocktakeModule) GetByIDScoped(id, storeID uint, hqUnbound bool) (*model.Stocktake, error) {
+	var stocktake model.Stocktake
+	query := m.db.
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Items.Entries", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Where("id = ?", id)
+	if !hqUnbound {
+		query = query.Where("store_id = ?", storeID)
+	}
+	if err := query.First(&stocktake).Error; err != nil {
+		return nil, err
+	}
+	return &stocktake, nil
+}
+
+// List 盘点单列表（不含明细）
+func (m *StocktakeModule) List(req *model.ListStocktakeReq) ([]*model.Stocktake, int64, error) {
+	list := make([]*model.Stocktake, 0)
+	var total int64
+
+	query := m.db.Model(&model.Stocktake{})
+	if req.StoreID > 0 {
+		query = query.Where("store_id = ?", req.StoreID)
+	}
+	if req.Status > 0 {
+		query = query.Where("status = ?", req.Status)
+	}
+	if kw := strings.TrimSpace(req.Keyword); kw != "" {
+		like := "%" + kw + "%"
+		query = query.Where("(stocktake_no LIKE ? OR store_name LIKE ?)", like, like)
+	}
+	if req.StartDate != "" {
+		query = query.Where("created_at >= ?", req.StartDate+" 00:00:00")
+	}
+	if req.EndDate != "" {
+		query = query.Where("created_at <= ?", req.EndDate+" 23:59:59")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return list, 0, err
+	}
+
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(req.PageSize).Find(&list).Error; err != nil {
+		return list, 0, err
+	}
+	return list, total, nil
+}
+
+// GenerateStocktakeNo 生成盘点单号：PD + 日期 + 序号，如 PD202412070001
+func (m *StocktakeModule) GenerateStocktakeNo() string {
+	prefix := "PD"
+	today := time.Now().Format("20060102")
+	pattern := prefix + today + "%"
+
+	var maxNo string
+	m.db.Model(&model.Stocktake{}).
+		Where("stocktake_no LIKE ?", pattern).
+		Order("stocktake_no DESC").
+		Limit(1).
+		Pluck("stocktake_no", &maxNo)
+
+	seq := 1
+	if maxNo != "" && len(maxNo) >= 14 {
+		fmt.Sscanf(maxNo[len(maxNo)-4:], "%d", &seq)
+		seq++
+	}
+	return fmt.Sprintf("%s%s%04d", prefix, today, seq)
+}
+
+// stocktakeAdjustment 盘点差异汇总
+type stocktakeAdjustment struct {
+	Items        []model.InventoryOrderItem
+	NetQuantity  float64
+	GainAmount   float64
+	LossAmount   float64
+	CountedCount int
+}
+
+// setStocktakeItemCount 写入实盘数量并按开单快照重算差异
+func setStocktakeItemCount(item *model.StocktakeItem, counted float64) {
+	item.CountedQuantity = roundQuantity(counted)
+	item.Counted = true
+	item.VarianceQuantity = roundQuantity(item.CountedQuantity - item.SystemQuantity)
+	item.VarianceAmount = roundQuantity(item.VarianceQuantity * item.CostPrice)
+}
+
+// lockStocktakeInventories 锁定盘点明细涉及的库存行，返回商品ID到当前库存数量的映射（无库存记录的商品不在映射中）
+func lockStocktakeInventories(tx *gorm.DB, storeID uint, items []model.StocktakeItem) (map[uint]float64, error) {
+	current := make(map[uint]float64, len(items))
+	if len(items) == 0 {
+		return current, nil
+	}
+	productIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	var inventories []model.Inventory
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("store_id = ? AND product_id IN ?", storeID, productIDs).
+		Order("id ASC").
+		Find(&inventories).Error; err != nil {
+		return nil, err
+	}
+	for _, inv := range inventories {
+		current[inv.ProductID] = inv.Quantity
+	}
+	return current, nil
+}
+
+// buildStocktakeAdjustment 将已录入实盘的明细按「实盘 - 当前库存」转换为带符号的调整单明细，无差异的跳过
+func buildStocktakeAdjustment(items []model.StocktakeItem, current map[uint]float64) stocktakeAdjustment {
+	var result stocktakeAdjustment
+	for _, item := range items {
+		if !item.Counted {
+			continue
+		}
+		result.CountedCount++
+		quantity := current[item.ProductID]
+		delta := roundQuantity(item.CountedQuantity - quantity)
+		if delta == 0 {
+			continue
+		}
+		result.Items = append(result.Items, model.InventoryOrderItem{
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			Quantity:    delta,
+			Unit:        item.BaseUnit,
+			Remark:      fmt.Sprintf("盘点差异：开单 %g，过账时 %g，实盘 %g", item.SystemQuantity, quantity, item.CountedQuantity),
+		})
+		result.NetQuantity = roundQuantity(result.NetQuantity + delta)
+		if amount := roundQuantity(delta * item.CostPrice); amount > 0 {
+			result.GainAmount = roundQuantity(result.GainAmount + amount)
+		} else {
+			result.LossAmount = roundQuantity(result.LossAmount - amount)
+		}
+	}
+	return result
+}
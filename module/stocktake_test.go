@@ -0,0 +1,61 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+func TestSetStocktakeItemCountComputesVariance(t *testing.T) {
+	item := model.StocktakeItem{SystemQuantity: 24, CostPrice: 5.5}
+	setStocktakeItemCount(&item, 21)
+	if !item.Counted || item.CountedQuantity != 21 || item.VarianceQuantity != -3 || item.VarianceAmount != -16.5 {
+		t.Fatalf("item = %#v", item)
+	}
+}
+
+func TestBuildStocktakeAdjustmentSkipsUncountedAndZeroVariance(t *testing.T) {
+	items := []model.StocktakeItem{
+		{ProductID: 1, ProductName: "啤酒", BaseUnit: "瓶", SystemQuantity: 24, CountedQuantity: 21, Counted: true, VarianceQuantity: -3, VarianceAmount: -15, CostPrice: 5},
+		{ProductID: 2, ProductName: "白酒", BaseUnit: "瓶", SystemQuantity: 6, CountedQuantity: 8, Counted: true, VarianceQuantity: 2, VarianceAmount: 160, CostPrice: 80},
+		{ProductID: 3, ProductName: "红酒", BaseUnit: "瓶", SystemQuantity: 4, CountedQuantity: 4, Counted: true},
+		{ProductID: 4, ProductName: "果汁", BaseUnit: "瓶", SystemQuantity: 10},
+	}
+	adjustment := buildStocktakeAdjustment(items, map[uint]float64{1: 24, 2: 6, 3: 4, 4: 10})
+	if len(adjustment.Items) != 2 || adjustment.CountedCount != 3 {
+		t.Fatalf("adjustment = %#v", adjustment)
+	}
+	if adjustment.Items[0].ProductID != 1 || adjustment.Items[0].Quantity != -3 || adjustment.Items[1].Quantity != 2 {
+		t.Fatalf("adjustment items = %#v", adjustment.Items)
+	}
+	if adjustment.NetQuantity != -1 || adjustment.GainAmount != 160 || adjustment.LossAmount != 15 {
+		t.Fatalf("totals = %#v", adjustment)
+	}
+}
+
+func TestInventoryOrderItemDelta(t *testing.T) {
+	if got := inventoryOrderItemDelta(model.InventoryTypeIn, 3); got != 3 {
+		t.Fatalf("in delta = %v", got)
+	}
+	if got := inventoryOrderItemDelta(model.InventoryTypeOut, 3); got != -3 {
+		t.Fatalf("out delta = %v", got)
+	}
+	if got := inventoryOrderItemDelta(model.InventoryTypeAdjust, -2); got != -2 {
+		t.Fatalf("adjust delta = %v", got)
+	}
+}
+
+func TestBuildStocktakeAdjustmentUsesCurrentQuantity(t *testing.T) {
+	// 开单快照 24，盘点期间售出 4，实盘 20：过账时库存已是 20，不应再调整
+	items := []model.StocktakeItem{
+		{ProductID: 1, BaseUnit: "瓶", SystemQuantity: 24, CountedQuantity: 20, Counted: true, VarianceQuantity: -4, CostPrice: 5},
+		{ProductID: 2, BaseUnit: "瓶", SystemQuantity: 6, CountedQuantity: 5, Counted: true, VarianceQuantity: -1, CostPrice: 10},
+	}
+	adjustment := buildStocktakeAdjustment(items, map[uint]float64{1: 20, 2: 8})
+	if len(adjustment.Items) != 1 || adjustment.Items[0].ProductID != 2 || adjustment.Items[0].Quantity != -3 {
+		t.Fatalf("adjustment items = %#v", adjustment.Items)
+	}
+	if adjustment.LossAmount != 30 || adjustment.GainAmount != 0 || adjustment.CountedCount != 2 {
+		t.Fatalf("totals = %#v", adjustment)
+	}
+}
@@ -26,7 +26,7 @@ func (m *StoreAccountModule) Create(account *model.StoreAccount) error {
 	return m.db.Create(account).Error
 }
 
-// CreateWithInventoryOut 创建记账并自动出库（同事务）
+// CreateWithInventoryOut 创建记账并自动出库，会员积分抵扣与累计、余额支付在同一事务内完成
 func (m *StoreAccountModule) CreateWithInventoryOut(account *model.StoreAccount, outOrder *model.InventoryOrder) error {
 	return m.db.Transaction(func(tx *gorm.DB) error {
 		var deductItems []model.StoreAccountItem
@@ -74,6 +74,12 @@ func (m *StoreAccountModule) CreateWithInventoryOut(account *model.StoreAccount,
 		if err := tx.Create(account).Error; err != nil {
 			return err
 		}
+		if err := applyStoreAccountPoints(tx, account); err != nil {
+			return err
+		}
+		if err := applyStoreAccountWallet(tx, account); err != nil {
+			return err
+		}
 
 		if outOrder != nil {
 			if err := tx.Create(outOrder).Error; err != nil {
@@ -81,14 +87,13 @@ func (m *StoreAccountModule) CreateWithInventoryOut(account *model.StoreAccount,
 			}
 		}
 
+		src := storeAccountMovementSource(account, outOrder)
 		for _, item := range deductItems {
-			res := tx.Model(&model.Inventory{}).
-				Where("store_id = ? AND product_id = ? AND quantity >= ?", account.StoreID, item.ProductID, item.Quantity).
-				Update("quantity", gorm.Expr("quantity - ?", item.Quantity))
-			if res.Error != nil {
-				return res.Error
+			ok, err := deductInventoryQuantity(tx, account.StoreID, item.ProductID, item.Quantity, src)
+			if err != nil {
+				return err
 			}
-			if res.RowsAffected == 0 {
+			if !ok {
 				name := item.ProductName
 				if name == "" {
 					name = fmt.Sprintf("商品ID:%d", item.ProductID)
@@ -101,6 +106,22 @@ func (m *StoreAccountModule) CreateWithInventoryOut(account *model.StoreAccount,
 	})
 }
 
+// storeAccountMovementSource 记账单对应的流水来源，操作人与备注取随记账生成的出入库单
+func storeAccountMovementSource(account *model.StoreAccount, order *model.InventoryOrder) model.InventoryMovementSource {
+	src := model.InventoryMovementSource{
+		Type:       model.MovementSourceStoreAccount,
+		ID:         account.ID,
+		No:         account.AccountNo,
+		OperatorID: account.OperatorID,
+	}
+	if order != nil {
+		src.OperatorID = order.OperatorID
+		src.OperatorName = order.OperatorName
+		src.Remark = order.Remark
+	}
+	return src
+}
+
 // GetByID 根据ID获取记账（含明细）
 func (m *StoreAccountModule) GetByID(id uint) (*model.StoreAccount, error) {
 	var account model.StoreAccount
@@ -188,12 +209,17 @@ func (m *StoreAccountModule) List(req *model.ListStoreAccountReq) ([]*model.Stor
 	return accounts, total, nil
 }
 
-// Update 更新记账
+// Update 更新记账，金额改小时同事务退回余额支付超出实收的部分
 func (m *StoreAccountModule) Update(id uint, updates map[string]interface{}) error {
-	return m.db.Model(&model.StoreAccount{}).Where("id = ?", id).Updates(updates).Error
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.StoreAccount{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		return refundWalletOverpayment(tx, id)
+	})
 }
 
-// ReplaceItemsWithInventoryAdjustments 原子替换记账明细并应用库存差量。
+// ReplaceItemsWithInventoryAdjustments 原子替换记账明细并应用库存差量，同事务退回余额支付超出实收的部分。
 func (m *StoreAccountModule) ReplaceItemsWithInventoryAdjustments(
 	id, storeID uint,
 	hqUnbound bool,
@@ -243,8 +269,9 @@ func (m *StoreAccountModule) ReplaceItemsWithInventoryAdjustments(
 			if err := tx.Create(inOrder).Error; err != nil {
 				return fmt.Errorf("create account edit stock return order: %w", err)
 			}
+			src := storeAccountMovementSource(&account, inOrder)
 			for _, item := range inOrder.Items {
-				if err := incrementInventoryQuantity(tx, account.StoreID, item.ProductID, item.Quantity, item.Unit); err != nil {
+				if err := restoreStockIn(tx, account.StoreID, item.ProductID, item.Quantity, item.Unit, src.Type, src.ID, src); err != nil {
 					return fmt.Errorf("return account edit stock for product %d: %w", item.ProductID, err)
 				}
 			}
@@ -254,14 +281,13 @@ func (m *StoreAccountModule) ReplaceItemsWithInventoryAdjustments(
 			if err := tx.Create(outOrder).Error; err != nil {
 				return fmt.Errorf("create account edit stock out order: %w", err)
 			}
+			src := storeAccountMovementSource(&account, outOrder)
 			for _, item := range outOrder.Items {
-				res := tx.Model(&model.Inventory{}).
-					Where("store_id = ? AND product_id = ? AND quantity >= ?", account.StoreID, item.ProductID, item.Quantity).
-					Update("quantity", gorm.Expr("quantity - ?", item.Quantity))
-				if res.Error != nil {
-					return res.Error
+				ok, err := deductInventoryQuantity(tx, account.StoreID, item.ProductID, item.Quantity, src)
+				if err != nil {
+					return err
 				}
-				if res.RowsAffected == 0 {
+				if !ok {
 					return apicode.Newf(apicode.InventoryInsufficient, "商品【%s】库存不足，补扣失败", item.ProductName)
 				}
 			}
@@ -281,7 +307,10 @@ func (m *StoreAccountModule) ReplaceItemsWithInventoryAdjustments(
 			}
 		}
 
-		return tx.Model(&model.StoreAccount{}).Where("id = ?", account.ID).Updates(updates).Error
+		if err := tx.Model(&model.StoreAccount{}).Where("id = ?", account.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+		return refundWalletOverpayment(tx, account.ID)
 	})
 }
 
@@ -306,8 +335,9 @@ func (m *StoreAccountModule) CancelWithStockRestore(id, storeID uint, hqUnbound
 			if err := tx.Create(restoreOrder).Error; err != nil {
 				return fmt.Errorf("create store account cancel inventory order: %w", err)
 			}
+			src := storeAccountMovementSource(&account, restoreOrder)
 			for _, item := range restoreOrder.Items {
-				if err := incrementInventoryQuantity(tx, account.StoreID, item.ProductID, item.Quantity, item.Unit); err != nil {
+				if err := restoreStockIn(tx, account.StoreID, item.ProductID, item.Quantity, item.Unit, src.Type, src.ID, src); err != nil {
 					return fmt.Errorf("restore store account inventory for product %d: %w", item.ProductID, err)
 				}
 			}
@@ -328,7 +358,10 @@ func (m *StoreAccountModule) CancelWithStockRestore(id, storeID uint, hqUnbound
 		if res.RowsAffected == 0 {
 			return apicode.Newf(apicode.DuplicateOperation, "记账单已作废")
 		}
-		return nil
+		if err := reverseStoreAccountPoints(tx, &account, operatorID); err != nil {
+			return err
+		}
+		return refundStoreAccountWallet(tx, &account, account.BalanceAmount, "记账作废退回")
 	})
 }
 
@@ -17,8 +17,14 @@ func NewStoreReturnModule(db *gorm.DB) *StoreReturnModule {
 	return &StoreReturnModule{db: db}
 }
 
+// Create 创建返厂单，关联供应商时押金同步冲减应付
 func (m *StoreReturnModule) Create(record *model.StoreReturn) error {
-	return m.db.Create(record).Error
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(record).Error; err != nil {
+			return err
+		}
+		return syncStoreReturnPayable(tx, record.ID, record.ReturnNo, record)
+	})
 }
 
 func IsDuplicateKeyError(err error) bool {
@@ -93,6 +99,7 @@ func (m *StoreReturnModule) Update(record *model.StoreReturn) error {
 		}
 		if err := tx.Model(&model.StoreReturn{}).Where("id = ?", record.ID).Updates(map[string]interface{}{
 			"store_id":      record.StoreID,
+			"supplier_id":   record.SupplierID,
 			"client_req_id": record.ClientReqID,
 			"return_date":   record.ReturnDate,
 			"logistics_fee": record.LogisticsFee,
@@ -109,9 +116,11 @@ func (m *StoreReturnModule) Update(record *model.StoreReturn) error {
 			record.Items[i].ReturnID = record.ID
 		}
 		if len(record.Items) > 0 {
-			return tx.Create(&record.Items).Error
+			if err := tx.Create(&record.Items).Error; err != nil {
+				return err
+			}
 		}
-		return nil
+		return syncStoreReturnPayable(tx, record.ID, record.ReturnNo, record)
 	})
 }
 
@@ -128,7 +137,10 @@ func (m *StoreReturnModule) Delete(id, storeID uint, hqUnbound bool) error {
 		if err := tx.Where("return_id = ?", existing.ID).Delete(&model.StoreReturnItem{}).Error; err != nil {
 			return err
 		}
-		return tx.Delete(&existing).Error
+		if err := tx.Delete(&existing).Error; err != nil {
+			return err
+		}
+		return syncStoreReturnPayable(tx, existing.ID, existing.ReturnNo, nil)
 	})
 }
 
@@ -0,0 +1,350 @@
+package module
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// payableKey 应付分录的归属：门店 + 供应商 + 业务日期
+type payableKey struct {
+	StoreID    uint
+	SupplierID uint
+	BizDate    string
+}
+
+type payableNet struct {
+	StoreID    uint
+	SupplierID uint
+	BizDate    time.Time
+	Amount     float64
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// recordSupplierPayable 在调用方事务内追加应付分录，金额为 0 时跳过
+func recordSupplierPayable(tx *gorm.DB, entry *model.SupplierPayableEntry) error {
+	entry.Amount = roundMoney(entry.Amount)
+	if entry.Amount == 0 {
+		return nil
+	}
+	return tx.Create(entry).Error
+}
+
+// recordPurchaseReceiptPayables 收货单按供应商汇总实收金额记入应付
+func recordPurchaseReceiptPayables(tx *gorm.DB, receipt *model.PurchaseReceipt) error {
+	amounts := make(map[uint]float64)
+	supplierIDs := make([]uint, 0)
+	for _, item := range receipt.Items {
+		if _, ok := amounts[item.SupplierID]; !ok {
+			supplierIDs = append(supplierIDs, item.SupplierID)
+		}
+		amounts[item.SupplierID] += item.Amount
+	}
+	bizDate := receipt.CreatedAt
+	if bizDate.IsZero() {
+		bizDate = time.Now()
+	}
+	for _, supplierID := range supplierIDs {
+		if err := recordSupplierPayable(tx, &model.SupplierPayableEntry{
+			StoreID:    receipt.StoreID,
+			SupplierID: supplierID,
+			SourceType: model.PayableSourcePurchaseReceipt,
+			SourceID:   receipt.ID,
+			SourceNo:   receipt.ReceiptNo,
+			BizDate:    bizDate,
+			Amount:     amounts[supplierID],
+			Remark:     fmt.Sprintf("采购单 %s 收货", receipt.PurchaseOrderNo),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncStoreReturnPayable 使返厂单在应付台账上的净额与单据当前状态一致：
+// 关联供应商的返厂单押金冲减应付；修改门店、供应商、日期或金额以及删除时，按差额追加冲正分录。
+// record 为 nil 表示单据已删除。
+func syncStoreReturnPayable(tx *gorm.DB, returnID uint, returnNo string, record *model.StoreReturn) error {
+	var current []payableNet
+	if err := tx.Model(&model.SupplierPayableEntry{}).
+		Select("store_id, supplier_id, biz_date, SUM(amount) AS amount").
+		Where("source_type = ? AND source_id = ?", model.PayableSourceStoreReturn, returnID).
+		Group("store_id, supplier_id, biz_date").
+		Scan(&current).Error; err != nil {
+		return err
+	}
+	var target []payableNet
+	if record != nil && record.SupplierID > 0 {
+		target = append(target, payableNet{
+			StoreID:    record.StoreID,
+			SupplierID: record.SupplierID,
+			BizDate:    record.ReturnDate,
+			Amount:     -record.TotalDeposit,
+		})
+	}
+	for _, adj := range payableAdjustments(current, target) {
+		if err := recordSupplierPayable(tx, &model.SupplierPayableEntry{
+			StoreID:    adj.StoreID,
+			SupplierID: adj.SupplierID,
+			SourceType: model.PayableSourceStoreReturn,
+			SourceID:   returnID,
+			SourceNo:   returnNo,
+			BizDate:    adj.BizDate,
+			Amount:     adj.Amount,
+			Remark:     "返厂押金",
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// payableAdjustments 计算从当前净额调整到目标净额所需追加的分录（按门店+供应商+业务日期）
+func payableAdjustments(current, target []payableNet) []payableNet {
+	deltas := make(map[payableKey]*payableNet)
+	keys := make([]payableKey, 0)
+	add := func(n payableNet, sign float64) {
+		key := payableKey{StoreID: n.StoreID, SupplierID: n.SupplierID, BizDate: n.BizDate.Format("2006-01-02")}
+		d, ok := deltas[key]
+		if !ok {
+			d = &payableNet{StoreID: n.StoreID, SupplierID: n.SupplierID, BizDate: n.BizDate}
+			deltas[key] = d
+			keys = append(keys, key)
+		}
+		d.Amount += sign * n.Amount
+	}
+	for _, n := range target {
+		add(n, 1)
+	}
+	for _, n := range current {
+		add(n, -1)
+	}
+
+	result := make([]payableNet, 0, len(keys))
+	for _, key := range keys {
+		d := deltas[key]
+		if amount := roundMoney(d.Amount); amount != 0 {
+			d.Amount = amount
+			result = append(result, *d)
+		}
+	}
+	return result
+}
+
+type SupplierPayableModule struct {
+	db *gorm.DB
+}
+
+func NewSupplierPayableModule(db *gorm.DB) *SupplierPayableModule {
+	return &SupplierPayableModule{db: db}
+}
+
+// CreatePayment 登记付款并冲减应付（同事务）
+func (m *SupplierPayableModule) CreatePayment(payment *model.SupplierPayment) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(payment).Error; err != nil {
+			return err
+		}
+		return recordSupplierPayable(tx, &model.SupplierPayableEntry{
+			StoreID:    payment.StoreID,
+			SupplierID: payment.SupplierID,
+			SourceType: model.PayableSourcePayment,
+			SourceID:   payment.ID,
+			SourceNo:   payment.PaymentNo,
+			BizDate:    payment.PayDate,
+			Amount:     -payment.Amount,
+			Remark:     payment.Remark,
+		})
+	})
+}
+
+// VoidPayment 作废付款并恢复应付，冲正分录的业务日期沿用付款日期
+func (m *SupplierPayableModule) VoidPayment(id, storeID uint, hqUnbound bool, reason string) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		var payment model.SupplierPayment
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id)
+		if !hqUnbound {
+			query = query.Where("store_id = ?", storeID)
+		}
+		if err := query.First(&payment).Error; err != nil {
+			return apicode.New(apicode.NotFound)
+		}
+		if payment.Status != model.SupplierPaymentStatusValid {
+			return apicode.Newf(apicode.OrderStateConflict, "付款记录已作废")
+		}
+		now := time.Now()
+		if err := tx.Model(&payment).Updates(map[string]interface{}{
+			"status":      model.SupplierPaymentStatusVoided,
+			"void_reason": reason,
+			"voided_at":   &now,
+		}).Error; err != nil {
+			return err
+		}
+		return recordSupplierPayable(tx, &model.SupplierPayableEntry{
+			StoreID:    payment.StoreID,
+			SupplierID: payment.SupplierID,
+			SourceType: model.PayableSourcePaymentVoid,
+			SourceID:   payment.ID,
+			SourceNo:   payment.PaymentNo,
+			BizDate:    payment.PayDate,
+			Amount:     payment.Amount,
+			Remark:     reason,
+		})
+	})
+}
+
+func (m *SupplierPayableModule) GetPaymentScoped(id, storeID uint, hqUnbound bool) (*model.SupplierPayment, error) {
+	var payment model.SupplierPayment
+	query := m.db.Preload("Store").Preload("Supplier").Where("id = ?", id)
+	if !hqUnbound {
+		query = query.Where("store_id = ?", storeID)
+	}
+	if err := query.First(&payment).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (m *SupplierPayableModule) ListPayments(req *model.ListSupplierPaymentReq) ([]*model.SupplierPayment, int64, error) {
+	var payments []*model.SupplierPayment
+	var total int64
+
+	query := m.db.Model(&model.SupplierPayment{})
+	if req.StoreID > 0 {
+		query = query.Where("store_id = ?", req.StoreID)
+	}
+	if req.SupplierID > 0 {
+		query = query.Where("supplier_id = ?", req.SupplierID)
+	}
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	}
+	if req.StartDate != "" {
+		query = query.Where("pay_date >= ?", req.StartDate)
+	}
+	if req.EndDate != "" {
+		query = query.Where("pay_date <= ?", req.EndDate)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Preload("Store").Preload("Supplier").
+		Order("id DESC").
+		Offset(offset).Limit(req.PageSize).
+		Find(&payments).Error; err != nil {
+		return nil, 0, err
+	}
+	return payments, total, nil
+}
+
+func (m *SupplierPayableModule) ListEntries(req *model.ListSupplierPayableEntryReq) ([]*model.SupplierPayableEntry, int64, error) {
+	var entries []*model.SupplierPayableEntry
+	var total int64
+
+	query := m.db.Model(&model.SupplierPayableEntry{})
+	if req.StoreID > 0 {
+		query = query.Where("store_id = ?", req.StoreID)
+	}
+	if req.SupplierID > 0 {
+		query = query.Where("supplier_id = ?", req.SupplierID)
+	}
+	if req.SourceType != "" {
+		query = query.Where("source_type = ?", req.SourceType)
+	}
+	if req.StartDate != "" {
+		query = query.Where("biz_date >= ?", req.StartDate)
+	}
+	if req.EndDate != "" {
+		query = query.Where("biz_date <= ?", req.EndDate)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Preload("Store").
+		Order("biz_date DESC, id DESC").
+		Offset(offset).Limit(req.PageSize).
+		Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+func (m *SupplierPayableModule) scopedEntries(storeID, supplierID uint) *gorm.DB {
+	query := m.db.Model(&model.SupplierPayableEntry{}).Where("supplier_id = ?", supplierID)
+	if storeID > 0 {
+		query = query.Where("store_id = ?", storeID)
+	}
+	return query
+}
+
+// BalanceBefore 业务日期早于 date 的应付余额；storeID 为 0 时汇总全部门店
+func (m *SupplierPayableModule) BalanceBefore(storeID, supplierID uint, date time.Time) (float64, error) {
+	var balance float64
+	err := m.scopedEntries(storeID, supplierID).
+		Where("biz_date < ?", date.Format("2006-01-02")).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&balance).Error
+	return roundMoney(balance), err
+}
+
+// EntriesBetween 业务日期 [start, end) 内的应付分录，按业务日期、记账先后排列
+func (m *SupplierPayableModule) EntriesBetween(storeID, supplierID uint, start, end time.Time) ([]*model.SupplierPayableEntry, error) {
+	var entries []*model.SupplierPayableEntry
+	err := m.scopedEntries(storeID, supplierID).
+		Preload("Store").
+		Where("biz_date >= ? AND biz_date < ?", start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Order("biz_date ASC, id ASC").
+		Find(&entries).Error
+	return entries, err
+}
+
+// DailyNets 截至 asOf（含）各门店+供应商按业务日期汇总的应付净额，用于账龄计算
+func (m *SupplierPayableModule) DailyNets(storeID, supplierID uint, asOf time.Time) ([]model.PayableDailyNet, error) {
+	var rows []model.PayableDailyNet
+	query := m.db.Model(&model.SupplierPayableEntry{}).
+		Select("store_id, supplier_id, biz_date, SUM(amount) AS amount").
+		Where("biz_date <= ?", asOf.Format("2006-01-02"))
+	if storeID > 0 {
+		query = query.Where("store_id = ?", storeID)
+	}
+	if supplierID > 0 {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+	err := query.Group("store_id, supplier_id, biz_date").
+		Order("supplier_id ASC, store_id ASC, biz_date ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// GeneratePaymentNo 付款单号：FK + 日期 + 序号，如 FK202412070001
+func (m *SupplierPayableModule) GeneratePaymentNo() string {
+	prefix := "FK"
+	today := time.Now().Format("20060102")
+	pattern := prefix + today + "%"
+
+	var maxNo string
+	m.db.Model(&model.SupplierPayment{}).
+		Where("payment_no LIKE ?", pattern).
+		Order("payment_no DESC").
+		Limit(1).
+		Pluck("payment_no", &maxNo)
+
+	seq := 1
+	if maxNo != "" && len(maxNo) >= 14 {
+		fmt.Sscanf(maxNo[len(maxNo)-4:], "%d", &seq)
+		seq++
+	}
+	return fmt.Sprintf("%s%s%04d", prefix, today, seq)
+}
@@ -0,0 +1,37 @@
+package module
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPayableAdjustmentsWritesOnlyDeltas(t *testing.T) {
+	d1 := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	d2 := time.Date(2024, 5, 3, 0, 0, 0, 0, time.Local)
+	current := []payableNet{
+		{StoreID: 1, SupplierID: 7, BizDate: d1, Amount: -120},
+	}
+
+	// 押金从 120 改为 150，且返厂日期不变：只补记 -30
+	adj := payableAdjustments(current, []payableNet{{StoreID: 1, SupplierID: 7, BizDate: d1, Amount: -150}})
+	if len(adj) != 1 || adj[0].Amount != -30 || !adj[0].BizDate.Equal(d1) {
+		t.Fatalf("amount change = %#v", adj)
+	}
+
+	// 改日期并换供应商：原日期冲回，新日期新供应商记入
+	adj = payableAdjustments(current, []payableNet{{StoreID: 1, SupplierID: 8, BizDate: d2, Amount: -120}})
+	if len(adj) != 2 || adj[0].SupplierID != 8 || adj[0].Amount != -120 || adj[1].SupplierID != 7 || adj[1].Amount != 120 {
+		t.Fatalf("move = %#v", adj)
+	}
+
+	// 删除返厂单：全额冲回
+	adj = payableAdjustments(current, nil)
+	if len(adj) != 1 || adj[0].Amount != 120 {
+		t.Fatalf("delete = %#v", adj)
+	}
+
+	// 无变化不追加分录
+	if adj = payableAdjustments(current, current); len(adj) != 0 {
+		t.Fatalf("unchanged = %#v", adj)
+	}
+}
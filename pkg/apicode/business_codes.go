@@ -72,6 +72,10 @@ var (
 	UploadIncomplete          = Code{40928, "文件分片尚未上传完整"}
 	UploadAlreadyCompleting   = Code{40929, "文件正在合并，请稍后查询"}
 	UploadSessionConflict     = Code{40930, "上传会话与当前文件不匹配"}
+	CreditLimitExceeded       = Code{40931, "超出客户信用额度"}
+	CreditOverrideRequired    = Code{40932, "超出客户信用额度，需确认后继续"}
+	PointsInsufficient        = Code{40933, "积分不足"}
+	RechargePackageInvalid    = Code{40934, "充值套餐不可用"}
 
 	// 服务与外部依赖 500xx / 502xx
 	ConfigMissing           = Code{50002, "服务配置缺失"}
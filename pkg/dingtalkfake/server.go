@@ -0,0 +1,244 @@
+// Package dingtalkfake 本地模拟钉钉开放平台与 Stream 网关，用于离线测试机器人消息、互动卡片及卡片回调。
+//
+// 覆盖的接口：获取 accessToken、按手机号查用户、单聊/群聊消息、创建并投放卡片、更新卡片，
+// 以及 Stream 建连（/v1.0/gateway/connections/open + WebSocket）。服务端可通过 PushCardCallback
+// 向已连接的 Stream 客户端推送卡片回调，并拿到客户端的响应。
+package dingtalkfake
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/open-dingtalk/dingtalk-stream-sdk-go/card"
+	"github.com/open-dingtalk/dingtalk-stream-sdk-go/payload"
+)
+
+// AccessToken 模拟服务签发的 accessToken
+const AccessToken = "fake-dingtalk-access-token"
+
+// Request 记录一次开放平台接口调用
+type Request struct {
+	Method string
+	Path   string
+	Body   map[string]interface{}
+}
+
+// Server 模拟服务；URL 即开放平台地址（api 与 oapi 共用）
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	users     map[string]string // mobile -> userId
+	requests  []Request
+	conn      *websocket.Conn
+	connected chan struct{}
+	waiters   map[string]chan *payload.DataFrameResponse
+	seq       int
+}
+
+// New 启动模拟服务，测试结束需调用 Close
+func New() *Server {
+	s := &Server{
+		users:     make(map[string]string),
+		connected: make(chan struct{}),
+		waiters:   make(map[string]chan *payload.DataFrameResponse),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1.0/oauth2/accessToken", s.handleAccessToken)
+	mux.HandleFunc("/topapi/v2/user/getbymobile", s.handleGetByMobile)
+	mux.HandleFunc("/v1.0/gateway/connections/open", s.handleOpenConnection)
+	mux.HandleFunc("/connect", s.handleConnect)
+	mux.HandleFunc("/", s.handleOpenAPI)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Close 断开 Stream 连接并关闭服务
+func (s *Server) Close() {
+	s.mu.Lock()
+	if s.conn != nil {
+		_ = s.conn.Close()
+	}
+	s.mu.Unlock()
+	s.Server.Close()
+}
+
+// SetUser 登记手机号对应的钉钉用户ID
+func (s *Server) SetUser(mobile, userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[mobile] = userID
+}
+
+// Requests 返回指定路径收到的请求（accessToken、查用户等内部接口不记录）
+func (s *Server) Requests(path string) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Request
+	for _, r := range s.requests {
+		if r.Path == path {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// WaitConnected 等待 Stream 客户端建立 WebSocket 连接
+func (s *Server) WaitConnected(timeout time.Duration) error {
+	select {
+	case <-s.connected:
+		return nil
+	case <-time.After(timeout):
+		return errors.New("dingtalkfake: stream client not connected")
+	}
+}
+
+// PushCardCallback 模拟用户点击卡片按钮：向 Stream 客户端推送卡片回调并返回客户端的卡片更新响应
+func (s *Server) PushCardCallback(outTrackID, userID string, params map[string]interface{}, timeout time.Duration) (*card.CardResponse, error) {
+	content, _ := json.Marshal(card.PrivateCardActionData{CardPrivateData: card.CardPrivateData{Params: params}})
+	data, _ := json.Marshal(map[string]interface{}{
+		"outTrackId": outTrackID,
+		"userId":     userID,
+		"userIdType": 1,
+		"type":       "actionCallback",
+		"content":    string(content),
+	})
+	resp, err := s.Push(payload.CardInstanceCallbackTopic, string(data), timeout)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code != payload.DataFrameResponseStatusCodeKOK {
+		return nil, fmt.Errorf("dingtalkfake: callback failed, code=%d message=%s", resp.Code, resp.Message)
+	}
+	var wrapped struct {
+		Response *card.CardResponse `json:"response"`
+	}
+	if err := json.Unmarshal([]byte(resp.Data), &wrapped); err != nil {
+		return nil, err
+	}
+	return wrapped.Response, nil
+}
+
+// Push 向 Stream 客户端推送一条 CALLBACK 数据帧并等待同一 messageId 的响应
+func (s *Server) Push(topic, data string, timeout time.Duration) (*payload.DataFrameResponse, error) {
+	if err := s.WaitConnected(timeout); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.seq++
+	messageID := "fake-msg-" + strconv.Itoa(s.seq)
+	ch := make(chan *payload.DataFrameResponse, 1)
+	s.waiters[messageID] = ch
+	conn := s.conn
+	frame := &payload.DataFrame{
+		SpecVersion: "1.0",
+		Type:        "CALLBACK",
+		Time:        time.Now().UnixMilli(),
+		Headers: payload.DataFrameHeader{
+			payload.DataFrameHeaderKTopic:       topic,
+			payload.DataFrameHeaderKMessageId:   messageID,
+			payload.DataFrameHeaderKContentType: payload.DataFrameContentTypeKJson,
+			payload.DataFrameHeaderKTime:        strconv.FormatInt(time.Now().UnixMilli(), 10),
+		},
+		Data: data,
+	}
+	err := conn.WriteJSON(frame)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.waiters, messageID)
+		s.mu.Unlock()
+	}()
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-time.After(timeout):
+		return nil, errors.New("dingtalkfake: callback response timeout")
+	}
+}
+
+func (s *Server) handleAccessToken(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"accessToken": AccessToken, "expireIn": 7200})
+}
+
+func (s *Server) handleGetByMobile(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Mobile string `json:"mobile"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	s.mu.Lock()
+	userID, ok := s.users[body.Mobile]
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"errcode": 60121, "errmsg": "找不到该用户"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"errcode": 0, "result": map[string]interface{}{"userid": userID}})
+}
+
+func (s *Server) handleOpenConnection(w http.ResponseWriter, r *http.Request) {
+	endpoint := "ws" + strings.TrimPrefix(s.URL, "http") + "/connect"
+	writeJSON(w, http.StatusOK, map[string]interface{}{"endpoint": endpoint, "ticket": "fake-ticket"})
+}
+
+func (s *Server) handleConnect(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	first := s.conn == nil
+	s.conn = conn
+	s.mu.Unlock()
+	if first {
+		close(s.connected)
+	}
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var resp payload.DataFrameResponse
+		if json.Unmarshal(message, &resp) != nil {
+			continue
+		}
+		s.mu.Lock()
+		ch := s.waiters[resp.GetHeader(payload.DataFrameHeaderKMessageId)]
+		s.mu.Unlock()
+		if ch != nil {
+			ch <- &resp
+		}
+	}
+}
+
+// handleOpenAPI 其余开放平台接口统一记录请求并返回成功
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	raw, _ := io.ReadAll(r.Body)
+	body := make(map[string]interface{})
+	_ = json.Unmarshal(raw, &body)
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Body: body})
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "result": map[string]interface{}{}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -12,6 +12,10 @@ func RegisterB2BRoutes(v1 *gin.RouterGroup, c *Controllers) {
 		b2b.POST("/customers", middleware.Permission("b2b:customer:add"), c.B2B.CreateCustomer)
 		b2b.GET("/customers", middleware.Permission("b2b:customer:list"), c.B2B.ListCustomers)
 		b2b.PUT("/customers/:id", middleware.Permission("b2b:customer:edit"), c.B2B.UpdateCustomer)
+		b2b.GET("/customers/:id/statement", middleware.Permission("b2b:customer:list"), c.B2B.CustomerStatement)
+		b2b.GET("/customers/:id/statement/export", middleware.Permission("b2b:customer:list"), c.B2B.ExportCustomerStatement)
+		b2b.GET("/statements", middleware.Permission("b2b:customer:list"), c.B2B.ListStatements)
+		b2b.GET("/receivables/aging", middleware.Permission("b2b:customer:list"), c.B2B.ReceivableAging)
 
 		b2b.POST("/prices", middleware.Permission("b2b:price:edit"), c.B2B.UpsertPrice)
 		b2b.GET("/prices", middleware.Permission("b2b:price:list"), c.B2B.ListPrices)
@@ -23,5 +27,14 @@ func RegisterB2BRoutes(v1 *gin.RouterGroup, c *Controllers) {
 		b2b.GET("/supply-orders/:id", middleware.Permission("b2b:order:list"), c.B2B.GetSupplyOrder)
 		b2b.PUT("/supply-orders/:id/delivery-status", middleware.Permission("b2b:order:edit"), c.B2B.UpdateSupplyOrderDelivery)
 		b2b.PUT("/supply-orders/:id/payment-status", middleware.Permission("b2b:order:edit"), c.B2B.UpdateSupplyOrderPayment)
+		b2b.POST("/supply-orders/:id/returns", middleware.Permission("b2b:order:edit"), c.B2B.CreateSupplyReturn)
+		b2b.GET("/supply-orders/:id/returns", middleware.Permission("b2b:order:list"), c.B2B.ListOrderSupplyReturns)
+		b2b.GET("/supply-returns", middleware.Permission("b2b:order:list"), c.B2B.ListSupplyReturns)
+		b2b.GET("/supply-returns/:id", middleware.Permission("b2b:order:list"), c.B2B.GetSupplyReturn)
+
+		b2b.POST("/payments", middleware.Permission("b2b:order:edit"), c.B2B.CreatePayment)
+		b2b.GET("/payments", middleware.Permission("b2b:order:list"), c.B2B.ListPayments)
+		b2b.GET("/payments/:id", middleware.Permission("b2b:order:list"), c.B2B.GetPayment)
+		b2b.POST("/payments/:id/void", middleware.Permission("b2b:order:edit"), c.B2B.VoidPayment)
 	}
 }
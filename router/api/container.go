@@ -8,8 +8,10 @@ import (
 	"github.com/Kevin-Jii/tower-go/config"
 	"github.com/Kevin-Jii/tower-go/controller"
 	"github.com/Kevin-Jii/tower-go/cron"
+	"github.com/Kevin-Jii/tower-go/model"
 	userModulePkg "github.com/Kevin-Jii/tower-go/module"
 	"github.com/Kevin-Jii/tower-go/service"
+	"github.com/Kevin-Jii/tower-go/utils"
 	"github.com/Kevin-Jii/tower-go/utils/database"
 	"github.com/Kevin-Jii/tower-go/utils/logging"
 )
@@ -22,11 +24,16 @@ type Controllers struct {
 	DingTalkBot       *controller.DingTalkBotController
 	Supplier          *controller.SupplierController
 	SupplierProduct   *controller.SupplierProductController
+	SupplierPayable   *controller.SupplierPayableController
 	StoreSupplier     *controller.StoreSupplierController
 	PurchaseOrder     *controller.PurchaseOrderController
+	Reorder           *controller.ReorderController
 	Dict              *controller.DictController
 	Inventory         *controller.InventoryController
 	InventoryLoss     *controller.InventoryLossController
+	StockTransfer     *controller.StockTransferController
+	Stocktake         *controller.StocktakeController
+	InventoryMovement *controller.InventoryMovementController
 	File              *controller.FileController
 	Gallery           *controller.GalleryController
 	StoreAccount      *controller.StoreAccountController
@@ -37,6 +44,8 @@ type Controllers struct {
 	MessageTemplate   *controller.MessageTemplateController
 	Member            *controller.MemberController
 	Printer           *controller.PrinterController
+	PrintDocument     *controller.PrintDocumentController
+	PrintLabel        *controller.PrintLabelController
 	PriceList         *controller.PriceListController
 	B2B               *controller.B2BController
 	PreOrder          *controller.PreOrderController
@@ -44,10 +53,16 @@ type Controllers struct {
 	ThirdPartyRoute   *controller.ThirdPartyRouteController
 	AuditLog          *controller.AuditLogController
 	DailyTurnover     *controller.DailyTurnoverController
+	Notification      *controller.NotificationController
+	NotificationRoute *controller.NotificationRouteController
+	DingTalkApproval  *controller.DingTalkApprovalController
 	DingTalkBotModule *userModulePkg.DingTalkBotModule
 	PrinterService    *service.PrinterService
 	PreOrderService   *service.PreOrderService
+	InventoryExpiry   *service.InventoryExpiryService
 	GalleryService    *service.GalleryService
+	NotificationSvc   *service.NotificationService
+	MemberService     *service.MemberService
 }
 
 // BuildControllers 构建所有控制器及其依赖
@@ -66,9 +81,14 @@ func BuildControllers() *Controllers {
 	productUnitSpecModule := userModulePkg.NewProductUnitSpecModule(database.DB)
 	storeSupplierModule := userModulePkg.NewStoreSupplierModule(database.DB)
 	purchaseOrderModule := userModulePkg.NewPurchaseOrderModule(database.DB)
+	purchaseReceiptModule := userModulePkg.NewPurchaseReceiptModule(database.DB)
 	dictModule := userModulePkg.NewDictModule(database.DB)
 	inventoryModule := userModulePkg.NewInventoryModule(database.DB)
 	inventoryLossModule := userModulePkg.NewInventoryLossModule(database.DB)
+	stockTransferModule := userModulePkg.NewStockTransferModule(database.DB)
+	stocktakeModule := userModulePkg.NewStocktakeModule(database.DB)
+	inventoryMovementModule := userModulePkg.NewInventoryMovementModule(database.DB)
+	reorderModule := userModulePkg.NewReorderModule(database.DB)
 	galleryModule := userModulePkg.NewGalleryModule(database.DB)
 	storeAccountModule := userModulePkg.NewStoreAccountModule(database.DB)
 	storeExpenseModule := userModulePkg.NewStoreExpenseModule(database.DB)
@@ -85,6 +105,10 @@ func BuildControllers() *Controllers {
 	thirdPartyRouteModule := userModulePkg.NewThirdPartyRouteModule(database.DB)
 	auditLogModule := userModulePkg.NewAuditLogModule(database.DB)
 	dailyTurnoverModule := userModulePkg.NewDailyTurnoverModule(database.DB)
+	supplierPayableModule := userModulePkg.NewSupplierPayableModule(database.DB)
+	notificationModule := userModulePkg.NewNotificationModule(database.DB)
+	notificationRouteModule := userModulePkg.NewNotificationRouteModule(database.DB)
+	dingTalkApprovalModule := userModulePkg.NewDingTalkApprovalModule(database.DB)
 
 	userModulePkg.SetDB(database.DB)
 
@@ -123,33 +147,64 @@ func BuildControllers() *Controllers {
 	userService := service.NewUserService(userModule, storeModule)
 	storeService := service.NewStoreService(storeModule, thirdPartyAccountModule)
 	dingTalkService := service.NewDingTalkService(dingTalkBotModule, dingTalkUserModule)
+	notificationService := service.NewNotificationService(notificationModule, notificationRouteModule, dingTalkBotModule, storeModule, dingTalkService, imageGeneratorService)
+	notificationRouteService := service.NewNotificationRouteService(notificationRouteModule, dingTalkBotModule, storeModule, dingTalkService)
+	dingTalkApprovalService := service.NewDingTalkApprovalService(dingTalkApprovalModule, notificationRouteModule, dingTalkBotModule, storeModule, userModule, dingTalkService)
 	menuService := service.NewMenuService(menuModule, roleMenuModule, storeRoleMenuModule)
 	supplierService := service.NewSupplierService(supplierModule, storeSupplierModule)
 	supplierProductService := service.NewSupplierProductService(supplierProductModule, productUnitSpecModule, dictModule, supplierCategoryModule, supplierModule)
 	storeSupplierService := service.NewStoreSupplierService(storeSupplierModule, productUnitSpecModule)
-	purchaseOrderService := service.NewPurchaseOrderService(purchaseOrderModule, supplierProductModule, storeSupplierModule, storeModule, dingTalkBotModule, dingTalkService)
+	supplierPayableService := service.NewSupplierPayableService(supplierPayableModule, supplierModule, storeModule, userModule)
+	purchaseOrderService := service.NewPurchaseOrderService(purchaseOrderModule, purchaseReceiptModule, inventoryModule, userModule, supplierProductModule, storeSupplierModule, storeModule, notificationService)
 	dictService := service.NewDictService(dictModule)
 	messageTemplateService := service.NewMessageTemplateService(messageTemplateModule)
-	inventoryService := service.NewInventoryService(inventoryModule, productUnitSpecModule, userModule, storeModule, supplierProductModule, dingTalkService, dingTalkBotModule, messageTemplateService)
+	inventoryService := service.NewInventoryService(inventoryModule, productUnitSpecModule, userModule, storeModule, supplierProductModule, notificationService, messageTemplateService)
 	inventoryLossService := service.NewInventoryLossService(inventoryLossModule, supplierProductModule, productUnitSpecModule, memberModule, userModule, dictModule)
-	storeAccountService := service.NewStoreAccountService(storeAccountModule, inventoryModule, supplierProductModule, productUnitSpecModule, storeModule, memberModule, userModule, dictModule, b2bModule, dingTalkService, dingTalkBotModule, messageTemplateService, imageGeneratorService)
+	stockTransferService := service.NewStockTransferService(stockTransferModule, inventoryModule, productUnitSpecModule, supplierProductModule, storeModule, userModule)
+	stocktakeService := service.NewStocktakeService(stocktakeModule, inventoryModule, productUnitSpecModule, supplierProductModule, storeModule, userModule)
+	inventoryMovementService := service.NewInventoryMovementService(inventoryMovementModule, inventoryModule, storeModule, supplierProductModule)
+	reorderService := service.NewReorderService(reorderModule, inventoryModule, supplierProductModule, productUnitSpecModule, storeSupplierModule, storeModule)
+	storeAccountService := service.NewStoreAccountService(storeAccountModule, inventoryModule, supplierProductModule, productUnitSpecModule, storeModule, memberModule, userModule, dictModule, b2bModule, notificationService, messageTemplateService, imageGeneratorService)
 	storeExpenseService := service.NewStoreExpenseService(storeExpenseModule, dictModule, userModule)
-	storeReturnService := service.NewStoreReturnService(storeReturnModule, userModule)
+	storeReturnService := service.NewStoreReturnService(storeReturnModule, userModule, storeSupplierModule)
 	meituanAIService := service.NewMeituanAIService(meituanAIModule)
 	statisticsService := service.NewStatisticsService(statisticsModule)
+	inventoryExpiryService := service.NewInventoryExpiryService(statisticsModule, supplierProductModule, productUnitSpecModule, storeModule, notificationService)
 	memberService := service.NewMemberService(memberModule)
-	memberService.SetDependencies(storeModule, dingTalkBotModule, dictModule, userModule, dingTalkService)
+	memberService.SetDependencies(storeModule, dictModule, userModule, notificationService)
 	priceListService := service.NewPriceListService(priceListModule, storeModule, supplierProductModule)
 	b2bService := service.NewB2BService(b2bModule, storeModule, supplierProductModule, productUnitSpecModule, userModule)
-	preOrderService := service.NewPreOrderService(preOrderModule, memberModule, storeSupplierModule, supplierProductModule, productUnitSpecModule, storeModule, dingTalkBotModule, dingTalkService)
+	preOrderService := service.NewPreOrderService(preOrderModule, memberModule, storeSupplierModule, supplierProductModule, productUnitSpecModule, storeModule, notificationService, messageTemplateService)
 	thirdPartyAccountService := service.NewThirdPartyAccountService(thirdPartyAccountModule, thirdPartyOrderModule)
 	thirdPartyRouteService := service.NewThirdPartyRouteService(thirdPartyRouteModule, storeModule, thirdPartyOrderModule)
 	auditLogService := service.NewAuditLogService(auditLogModule)
 	dailyTurnoverService := service.NewDailyTurnoverService(dailyTurnoverModule, dictModule)
 
+	// 钉钉审批：各业务注册审批处理，卡片按钮回调经 Stream 客户端转给审批服务
+	purchaseOrderService.EnableDingTalkApproval(dingTalkApprovalService)
+	storeAccountService.EnableDingTalkApproval(dingTalkApprovalService)
+	memberService.EnableDingTalkApproval(dingTalkApprovalService)
+	dingTalkService.GetStreamClient().SetCardCallbackHandler(dingTalkApprovalService.HandleCardCallback)
+
+	// 模板预览可按真实单据渲染
+	messageTemplateService.RegisterDocumentSource(storeAccountService.NotificationTemplateData, model.TemplateStoreAccountCreated, model.TemplateStoreAccountCard)
+	messageTemplateService.RegisterDocumentSource(inventoryService.NotificationTemplateData, model.TemplateInventoryCreated)
+	messageTemplateService.RegisterDocumentSource(preOrderService.ReminderTemplateData, model.TemplatePreOrderReminder)
+
+	// WebSocket 实时推送：订阅业务变更事件，按主题与数据范围下发
+	service.NewRealtimeService().Start(utils.GlobalEventBus)
+
 	// 初始化打印机模块
 	printerModule := userModulePkg.NewPrinterModule(database.DB)
-	printerService := service.NewPrinterService(printerModule, storeModule, purchaseOrderModule)
+	printJobModule := userModulePkg.NewPrintJobModule(database.DB)
+	printerService := service.NewPrinterService(printerModule, storeModule, purchaseOrderModule, printJobModule)
+	printAutoRuleModule := userModulePkg.NewPrintAutoRuleModule(database.DB)
+	printDocumentService := service.NewPrintDocumentService(printerService, printerModule, storeModule, storeAccountModule, preOrderModule, b2bModule, inventoryModule, storeReturnModule, printAutoRuleModule)
+	storeAccountService.EnableAutoPrint(printDocumentService)
+	preOrderService.EnableAutoPrint(printDocumentService)
+	b2bService.EnableAutoPrint(printDocumentService)
+	printLabelService := service.NewPrintLabelService(printerService, printerModule, storeModule, priceListModule, inventoryModule, memberModule)
+	memberService.EnableWineLabelPrinting(printLabelService)
 
 	// 从配置初始化芯烨云客户端（如果配置了）
 	xpyunConfig := config.GetConfig().Xpyun
@@ -169,7 +224,8 @@ func BuildControllers() *Controllers {
 	}
 
 	// 初始化钉钉命令处理器
-	service.InitCommandHandler(inventoryModule, storeAccountModule, storeModule, userModule, messageTemplateService)
+	service.InitCommandHandler(inventoryModule, storeAccountModule, storeModule, userModule, messageTemplateService,
+		inventoryService, purchaseOrderService, preOrderService, dingTalkService)
 
 	// 初始化文件和图库控制器（依赖RustFS）
 	var fileController *controller.FileController
@@ -188,21 +244,28 @@ func BuildControllers() *Controllers {
 		DingTalkBot:       controller.NewDingTalkBotController(dingTalkService),
 		Supplier:          controller.NewSupplierController(supplierService),
 		SupplierProduct:   controller.NewSupplierProductController(supplierProductService, storeSupplierService),
+		SupplierPayable:   controller.NewSupplierPayableController(supplierPayableService),
 		StoreSupplier:     controller.NewStoreSupplierController(storeSupplierService),
 		PurchaseOrder:     controller.NewPurchaseOrderController(purchaseOrderService),
+		Reorder:           controller.NewReorderController(reorderService),
 		Dict:              controller.NewDictController(dictService),
 		Inventory:         controller.NewInventoryController(inventoryService),
 		InventoryLoss:     controller.NewInventoryLossController(inventoryLossService),
+		StockTransfer:     controller.NewStockTransferController(stockTransferService),
+		Stocktake:         controller.NewStocktakeController(stocktakeService),
+		InventoryMovement: controller.NewInventoryMovementController(inventoryMovementService),
 		File:              fileController,
 		Gallery:           galleryController,
 		StoreAccount:      controller.NewStoreAccountController(storeAccountService),
 		StoreExpense:      controller.NewStoreExpenseController(storeExpenseService),
 		StoreReturn:       controller.NewStoreReturnController(storeReturnService),
 		MeituanAI:         controller.NewMeituanAIController(meituanAIService),
-		Statistics:        controller.NewStatisticsController(statisticsService),
+		Statistics:        controller.NewStatisticsController(statisticsService, inventoryExpiryService),
 		MessageTemplate:   controller.NewMessageTemplateController(messageTemplateService),
 		Member:            controller.NewMemberController(memberService),
 		Printer:           controller.NewPrinterController(printerService),
+		PrintDocument:     controller.NewPrintDocumentController(printDocumentService),
+		PrintLabel:        controller.NewPrintLabelController(printLabelService),
 		PriceList:         controller.NewPriceListController(priceListService),
 		B2B:               controller.NewB2BController(b2bService),
 		PreOrder:          controller.NewPreOrderController(preOrderService),
@@ -210,10 +273,16 @@ func BuildControllers() *Controllers {
 		ThirdPartyRoute:   controller.NewThirdPartyRouteController(thirdPartyRouteService),
 		AuditLog:          controller.NewAuditLogController(auditLogService),
 		DailyTurnover:     controller.NewDailyTurnoverController(dailyTurnoverService),
+		Notification:      controller.NewNotificationController(notificationService),
+		NotificationRoute: controller.NewNotificationRouteController(notificationRouteService),
+		DingTalkApproval:  controller.NewDingTalkApprovalController(dingTalkApprovalService),
 		DingTalkBotModule: dingTalkBotModule,
 		PrinterService:    printerService,
 		PreOrderService:   preOrderService,
+		InventoryExpiry:   inventoryExpiryService,
 		GalleryService:    galleryService,
+		NotificationSvc:   notificationService,
+		MemberService:     memberService,
 	}
 }
 
@@ -226,9 +295,27 @@ func (c *Controllers) StartCronJobs() error {
 	if _, err := cron.StartPreOrderReminders(c.PreOrderService); err != nil {
 		jobErrors = append(jobErrors, err.Error())
 	}
+	if _, err := cron.StartInventoryExpiryWarnings(c.InventoryExpiry); err != nil {
+		jobErrors = append(jobErrors, err.Error())
+	}
 	if _, err := cron.StartGalleryUploadCleanup(c.GalleryService); err != nil {
 		jobErrors = append(jobErrors, err.Error())
 	}
+	if _, err := cron.StartNotificationDelivery(c.NotificationSvc); err != nil {
+		jobErrors = append(jobErrors, err.Error())
+	}
+	if _, err := cron.StartPrintJobPoll(c.PrinterService); err != nil {
+		jobErrors = append(jobErrors, err.Error())
+	}
+	if _, err := cron.StartMemberPointExpiry(c.MemberService); err != nil {
+		jobErrors = append(jobErrors, err.Error())
+	}
+	if _, err := cron.StartMemberTierEvaluation(c.MemberService); err != nil {
+		jobErrors = append(jobErrors, err.Error())
+	}
+	if _, err := cron.StartMemberWineExpiry(c.MemberService); err != nil {
+		jobErrors = append(jobErrors, err.Error())
+	}
 	if len(jobErrors) > 0 {
 		return fmt.Errorf("启动定时任务失败: %s", strings.Join(jobErrors, "; "))
 	}
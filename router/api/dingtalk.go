@@ -20,6 +20,12 @@ func RegisterDingTalkRoutes(v1 *gin.RouterGroup, c *Controllers) {
 			robots.POST("/:id/test", middleware.Permission("dingtalk:robot:test"), c.DingTalkBot.TestBot)
 			robots.POST("/:id/test-callback", middleware.Permission("dingtalk:robot:test"), c.DingTalkBot.TestStreamBotCallback)
 		}
+
+		approvals := dingtalk.Group("/approvals")
+		{
+			approvals.GET("", middleware.Permission("system:dingtalk-approval:list"), c.DingTalkApproval.List)
+			approvals.GET("/:id", middleware.Permission("system:dingtalk-approval:list"), c.DingTalkApproval.Get)
+		}
 	}
 
 }
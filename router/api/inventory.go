@@ -11,6 +11,7 @@ func RegisterInventoryRoutes(r *gin.RouterGroup, c *Controllers) {
 	inventories := r.Group("/inventories").Use(middleware.AuthMiddleware(), middleware.StoreBusinessGuard())
 	{
 		inventories.GET("", middleware.Permission("inventory:list"), c.Inventory.ListInventory)
+		inventories.GET("/:id/lots", middleware.Permission("inventory:list"), c.Inventory.GetInventoryLots)
 		inventories.PUT("/:id", c.Inventory.UpdateInventory)
 	}
 
@@ -34,4 +35,32 @@ func RegisterInventoryRoutes(r *gin.RouterGroup, c *Controllers) {
 		lossOrders.PUT("/:id", middleware.PermissionAny("inventory:out", "inventory:in"), c.InventoryLoss.UpdateOrder)
 		lossOrders.DELETE("/:id", middleware.PermissionAny("inventory:out", "inventory:in"), c.InventoryLoss.CancelOrder)
 	}
+
+	// 门店间调拨
+	transfers := r.Group("/stock-transfers").Use(middleware.AuthMiddleware(), middleware.StoreBusinessGuard())
+	{
+		transfers.POST("", middleware.Permission("inventory:out"), c.StockTransfer.Create)
+		transfers.GET("", middleware.Permission("inventory:record"), c.StockTransfer.List)
+		transfers.GET("/:id", middleware.Permission("inventory:record"), c.StockTransfer.Get)
+		transfers.POST("/:id/receive", middleware.Permission("inventory:in"), c.StockTransfer.Receive)
+		transfers.DELETE("/:id", middleware.Permission("inventory:out"), c.StockTransfer.Cancel)
+	}
+
+	// 库存盘点
+	stocktakes := r.Group("/stocktakes").Use(middleware.AuthMiddleware(), middleware.StoreBusinessGuard())
+	{
+		stocktakes.POST("", middleware.PermissionAny("inventory:in", "inventory:out"), c.Stocktake.Create)
+		stocktakes.GET("", middleware.Permission("inventory:record"), c.Stocktake.List)
+		stocktakes.GET("/:id", middleware.Permission("inventory:record"), c.Stocktake.Get)
+		stocktakes.GET("/:id/export", middleware.Permission("inventory:record"), c.Stocktake.Export)
+		stocktakes.POST("/:id/counts", middleware.PermissionAny("inventory:in", "inventory:out"), c.Stocktake.RecordCounts)
+		stocktakes.POST("/:id/post", middleware.PermissionAny("inventory:in", "inventory:out"), c.Stocktake.Post)
+		stocktakes.DELETE("/:id", middleware.PermissionAny("inventory:in", "inventory:out"), c.Stocktake.Cancel)
+	}
+
+	// 库存流水
+	movements := r.Group("/inventory-movements").Use(middleware.AuthMiddleware(), middleware.StoreBusinessGuard())
+	{
+		movements.GET("/stock-card", middleware.Permission("inventory:record"), c.InventoryMovement.StockCard)
+	}
 }
@@ -19,13 +19,21 @@ func RegisterMemberRoutes(v1 *gin.RouterGroup, c *Controllers) {
 		members.POST("/point-rules", middleware.Permission("store:member:edit"), c.Member.CreatePointRule)
 		members.PUT("/point-rules/:id", middleware.Permission("store:member:edit"), c.Member.UpdatePointRule)
 		members.DELETE("/point-rules/:id", middleware.Permission("store:member:edit"), c.Member.DeletePointRule)
+		members.GET("/tiers", middleware.Permission("store:member:list"), c.Member.ListTiers)
+		members.POST("/tiers", middleware.Permission("store:member:edit"), c.Member.CreateTier)
+		members.PUT("/tiers/:id", middleware.Permission("store:member:edit"), c.Member.UpdateTier)
+		members.DELETE("/tiers/:id", middleware.Permission("store:member:edit"), c.Member.DeleteTier)
+		members.GET("/tier-logs", middleware.Permission("store:member:list"), c.Member.ListTierLogs)
 		members.GET("/:id/consumptions", middleware.Permission("store:member:list"), c.Member.ListMemberConsumptions)
 		members.GET("/:id/consumptions/export", middleware.Permission("store:member:list"), c.Member.ExportMemberConsumptions)
+		members.GET("/:id/point-logs", middleware.Permission("store:member:list"), c.Member.ListPointLogs)
+		members.POST("/:id/points/redeem", middleware.Permission("store:member:edit"), c.Member.RedeemPoints)
 		members.GET("/:id/gift-records", middleware.Permission("store:member:list"), c.InventoryLoss.ListMemberGiftRecords)
 		members.GET("/:id", middleware.Permission("store:member:list"), c.Member.GetMember)
 		members.PUT("/:id", middleware.Permission("store:member:edit"), c.Member.UpdateMember)
 		members.DELETE("/:id", middleware.Permission("store:member:delete"), c.Member.DeleteMember)
 		members.POST("/:id/adjust-balance", middleware.Permission("store:member:balance"), c.Member.AdjustBalance)
+		members.POST("/:id/settle-accounts", middleware.Permission("store:member:balance"), c.Member.SettleAccountsFromWallet)
 	}
 
 	walletLogs := v1.Group("/wallet-logs")
@@ -44,6 +52,17 @@ func RegisterMemberRoutes(v1 *gin.RouterGroup, c *Controllers) {
 		rechargeOrders.POST("/:orderNo/cancel", middleware.Permission("store:member:edit"), c.Member.CancelRechargeOrder)
 	}
 
+	rechargePackages := v1.Group("/recharge-packages")
+	rechargePackages.Use(middleware.AuthMiddleware(), middleware.StoreBusinessGuard())
+	{
+		rechargePackages.GET("", middleware.Permission("store:member:list"), c.Member.ListRechargePackages)
+		rechargePackages.GET("/available", middleware.Permission("store:member:list"), c.Member.ListAvailableRechargePackages)
+		rechargePackages.GET("/report", middleware.Permission("store:member:list"), c.Member.RechargePackageReport)
+		rechargePackages.POST("", middleware.Permission("store:member:edit"), c.Member.CreateRechargePackage)
+		rechargePackages.PUT("/:id", middleware.Permission("store:member:edit"), c.Member.UpdateRechargePackage)
+		rechargePackages.DELETE("/:id", middleware.Permission("store:member:edit"), c.Member.DeleteRechargePackage)
+	}
+
 	memberWines := v1.Group("/member-wines")
 	memberWines.Use(middleware.AuthMiddleware(), middleware.StoreBusinessGuard())
 	{
@@ -51,5 +70,10 @@ func RegisterMemberRoutes(v1 *gin.RouterGroup, c *Controllers) {
 		memberWines.POST("/deposit", middleware.Permission("store:member:edit"), c.Member.DepositWine)
 		memberWines.POST("/withdraw", middleware.Permission("store:member:edit"), c.Member.WithdrawWine)
 		memberWines.GET("/transactions", middleware.Permission("store:member:list"), c.Member.ListWineTransactions)
+		memberWines.GET("/policy", middleware.Permission("store:member:list"), c.Member.GetWinePolicy)
+		memberWines.PUT("/policy", middleware.Permission("store:member:edit"), c.Member.UpsertWinePolicy)
+		memberWines.GET("/lots", middleware.Permission("store:member:list"), c.Member.ListWineLots)
+		memberWines.POST("/lots/:id/extend", middleware.Permission("store:member:edit"), c.Member.ExtendWineLot)
+		memberWines.POST("/lots/:id/forfeit", middleware.Permission("store:member:edit"), c.Member.ForfeitWineLot)
 	}
 }
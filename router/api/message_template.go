@@ -15,5 +15,8 @@ func RegisterMessageTemplateRoutes(r *gin.RouterGroup, c *Controllers) {
 		group.POST("", middleware.Permission("message:template:add"), c.MessageTemplate.Create)
 		group.PUT("/:id", middleware.Permission("message:template:edit"), c.MessageTemplate.Update)
 		group.DELETE("/:id", middleware.Permission("message:template:delete"), c.MessageTemplate.Delete)
+		group.POST("/:id/preview", middleware.Permission("message:template:list"), c.MessageTemplate.Preview)
+		group.GET("/:id/versions", middleware.Permission("message:template:list"), c.MessageTemplate.ListVersions)
+		group.POST("/:id/versions/:version/rollback", middleware.Permission("message:template:edit"), c.MessageTemplate.Rollback)
 	}
 }
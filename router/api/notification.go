@@ -0,0 +1,30 @@
+package api
+
+import (
+	"github.com/Kevin-Jii/tower-go/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterNotificationRoutes 注册外发通知发件箱路由
+func RegisterNotificationRoutes(v1 *gin.RouterGroup, c *Controllers) {
+	notifications := v1.Group("/notifications")
+	notifications.Use(middleware.AuthMiddleware())
+	{
+		notifications.GET("", middleware.Permission("system:notification:list"), c.Notification.List)
+		notifications.GET("/:id", middleware.Permission("system:notification:list"), c.Notification.Get)
+		notifications.POST("/:id/resend", middleware.Permission("system:notification:resend"), c.Notification.Resend)
+	}
+}
+
+// RegisterNotificationRouteRoutes 注册通知路由规则路由
+func RegisterNotificationRouteRoutes(v1 *gin.RouterGroup, c *Controllers) {
+	routes := v1.Group("/notification-routes")
+	routes.Use(middleware.AuthMiddleware())
+	{
+		routes.GET("", middleware.Permission("system:notification-route:list"), c.NotificationRoute.List)
+		routes.GET("/:id", middleware.Permission("system:notification-route:list"), c.NotificationRoute.Get)
+		routes.POST("", middleware.Permission("system:notification-route:add"), c.NotificationRoute.Create)
+		routes.PUT("/:id", middleware.Permission("system:notification-route:edit"), c.NotificationRoute.Update)
+		routes.DELETE("/:id", middleware.Permission("system:notification-route:delete"), c.NotificationRoute.Delete)
+	}
+}
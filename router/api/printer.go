@@ -29,6 +29,28 @@ func RegisterPrinterRoutes(v1 *gin.RouterGroup, c *Controllers) {
 		// 打印采购单
 		printers.POST("/:id/print/purchase-order", middleware.Permission("printer:query"), c.Printer.PrintPurchaseOrder)
 
+		// 打印任务
+		printers.GET("/jobs", middleware.Permission("printer:list"), c.Printer.ListPrintJobs)
+		printers.GET("/jobs/:id", middleware.Permission("printer:list"), c.Printer.GetPrintJob)
+		printers.POST("/jobs/:id/reprint", middleware.Permission("printer:query"), c.Printer.ReprintJob)
+
+		// 业务单据打印
+		printers.POST("/documents/store-accounts/:id", middleware.Permission("printer:query"), c.PrintDocument.PrintStoreAccount)
+		printers.POST("/documents/pre-orders/:id", middleware.Permission("printer:query"), c.PrintDocument.PrintPreOrderPicking)
+		printers.POST("/documents/b2b-supply-orders/:id", middleware.Permission("printer:query"), c.PrintDocument.PrintB2BDeliveryNote)
+		printers.POST("/documents/inventory-orders/:id", middleware.Permission("printer:query"), c.PrintDocument.PrintInventoryOrder)
+		printers.POST("/documents/store-returns/:id", middleware.Permission("printer:query"), c.PrintDocument.PrintStoreReturn)
+
+		// 自动打印规则
+		printers.GET("/auto-rules", middleware.Permission("printer:list"), c.PrintDocument.ListAutoRules)
+		printers.PUT("/auto-rules", middleware.Permission("printer:edit"), c.PrintDocument.SaveAutoRule)
+		printers.DELETE("/auto-rules/:id", middleware.Permission("printer:edit"), c.PrintDocument.DeleteAutoRule)
+
+		// 标签打印
+		printers.POST("/labels/price-tags", middleware.Permission("printer:query"), c.PrintLabel.PrintPriceTags)
+		printers.POST("/labels/inventory-lots", middleware.Permission("printer:query"), c.PrintLabel.PrintLotLabels)
+		printers.POST("/labels/wine-storages/:id", middleware.Permission("printer:query"), c.PrintLabel.PrintWineTag)
+
 		// 状态查询
 		printers.GET("/status", middleware.Permission("printer:query"), c.Printer.QueryPrinterStatus)
 		printers.GET("/status/batch", middleware.Permission("printer:query"), c.Printer.BatchQueryStatus)
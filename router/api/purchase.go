@@ -12,6 +12,8 @@ func RegisterPurchaseRoutes(v1 *gin.RouterGroup, c *Controllers) {
 	{
 		purchaseOrders.POST("", middleware.Permission("purchase:add"), c.PurchaseOrder.CreateOrder)
 		purchaseOrders.GET("", middleware.Permission("purchase:list"), c.PurchaseOrder.ListOrders)
+		purchaseOrders.GET("/suggestions", middleware.Permission("purchase:add"), c.Reorder.Suggest)
+		purchaseOrders.GET("/variance", middleware.Permission("purchase:list"), c.PurchaseOrder.VarianceReport)
 		purchaseOrders.GET("/:id", middleware.Permission("purchase:list"), c.PurchaseOrder.GetOrder)
 		purchaseOrders.PUT("/:id", middleware.Permission("purchase:edit"), c.PurchaseOrder.UpdateOrder)
 		purchaseOrders.DELETE("/:id", middleware.Permission("purchase:delete"), c.PurchaseOrder.DeleteOrder)
@@ -22,5 +24,19 @@ func RegisterPurchaseRoutes(v1 *gin.RouterGroup, c *Controllers) {
 		purchaseOrders.POST("/:id/confirm", middleware.Permission("purchase:edit"), c.PurchaseOrder.ConfirmOrder)
 		purchaseOrders.POST("/:id/complete", middleware.Permission("purchase:edit"), c.PurchaseOrder.CompleteOrder)
 		purchaseOrders.POST("/:id/cancel", middleware.Permission("purchase:edit"), c.PurchaseOrder.CancelOrder)
+
+		// 收货登记
+		purchaseOrders.POST("/:id/receive", middleware.Permission("purchase:edit"), c.PurchaseOrder.ReceiveOrder)
+		purchaseOrders.POST("/:id/close-short", middleware.Permission("purchase:edit"), c.PurchaseOrder.CloseShort)
+		purchaseOrders.GET("/:id/receipts", middleware.Permission("purchase:list"), c.PurchaseOrder.ListReceipts)
+	}
+
+	// 门店商品补货设置
+	reorderSettings := v1.Group("/reorder-settings")
+	reorderSettings.Use(middleware.StoreAuthMiddleware(), middleware.StoreBusinessGuard())
+	{
+		reorderSettings.POST("", middleware.Permission("purchase:edit"), c.Reorder.UpsertSetting)
+		reorderSettings.GET("", middleware.Permission("purchase:list"), c.Reorder.ListSettings)
+		reorderSettings.DELETE("/:id", middleware.Permission("purchase:edit"), c.Reorder.DeleteSetting)
 	}
 }
@@ -18,5 +18,6 @@ func RegisterStatisticsRoutes(v1 *gin.RouterGroup, c *Controllers) {
 		stats.GET("/channel", c.Statistics.ChannelStats)
 		stats.GET("/business-overview", c.Statistics.BusinessOverview)
 		stats.GET("/home-charts", c.Statistics.HomeCharts)
+		stats.GET("/near-expiry", c.Statistics.NearExpiry)
 	}
 }
@@ -57,6 +57,19 @@ func RegisterSupplierRoutes(v1 *gin.RouterGroup, c *Controllers) {
 		productUnitSpecs.DELETE("/:id", middleware.Permission("supplier:delete"), c.SupplierProduct.DeleteProductUnitSpec)
 	}
 
+	// 供应商应付：台账、付款、月度对账单与账龄（门店账号仅本店，总部账号可跨门店）
+	supplierPayables := v1.Group("/supplier-payables")
+	supplierPayables.Use(middleware.AuthMiddleware())
+	{
+		supplierPayables.GET("/entries", middleware.Permission("supplier:list"), c.SupplierPayable.ListEntries)
+		supplierPayables.GET("/payments", middleware.Permission("supplier:list"), c.SupplierPayable.ListPayments)
+		supplierPayables.POST("/payments", middleware.Permission("supplier:edit"), c.SupplierPayable.CreatePayment)
+		supplierPayables.POST("/payments/:id/void", middleware.Permission("supplier:edit"), c.SupplierPayable.VoidPayment)
+		supplierPayables.GET("/statement", middleware.Permission("supplier:list"), c.SupplierPayable.Statement)
+		supplierPayables.GET("/statement/export", middleware.Permission("supplier:list"), c.SupplierPayable.ExportStatement)
+		supplierPayables.GET("/aging", middleware.Permission("supplier:list"), c.SupplierPayable.Aging)
+	}
+
 	// 门店供应商关联
 	storeSuppliers := v1.Group("/store-suppliers")
 	storeSuppliers.Use(middleware.StoreAuthMiddleware())
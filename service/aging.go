@@ -0,0 +1,84 @@
+package service
+
+import "time"
+
+// 账龄分段上限（天）
+const (
+	agingBucket1 = 30
+	agingBucket2 = 60
+)
+
+// datedAmount 往来对象某个业务日期的净额，正数为挂账，负数为冲减
+type datedAmount struct {
+	Date   time.Time
+	Amount float64
+}
+
+// agingBalance 先进先出核销后的余额与账龄分布
+type agingBalance struct {
+	Balance         float64
+	Days0To30       float64
+	Days31To60      float64
+	DaysOver60      float64
+	UnappliedCredit float64
+}
+
+func (b *agingBalance) add(other agingBalance) {
+	b.Balance = roundMoney(b.Balance + other.Balance)
+	b.Days0To30 = roundMoney(b.Days0To30 + other.Days0To30)
+	b.Days31To60 = roundMoney(b.Days31To60 + other.Days31To60)
+	b.DaysOver60 = roundMoney(b.DaysOver60 + other.DaysOver60)
+	b.UnappliedCredit = roundMoney(b.UnappliedCredit + other.UnappliedCredit)
+}
+
+// ageDatedAmounts 对同一往来对象按日期排序的净额先进先出核销：冲减先抵扣最早的挂账，
+// 未核销的挂账按距 asOf 的天数分段，多出的冲减计为未抵扣金额并抵减余额。
+func ageDatedAmounts(amounts []datedAmount, asOf time.Time) agingBalance {
+	var charges []datedAmount
+	credit := 0.0
+	for _, a := range amounts {
+		if a.Amount > 0 {
+			charges = append(charges, a)
+		} else {
+			credit -= a.Amount
+		}
+		for len(charges) > 0 && credit > 0 {
+			applied := charges[0].Amount
+			if credit < applied {
+				applied = credit
+			}
+			charges[0].Amount = roundMoney(charges[0].Amount - applied)
+			credit = roundMoney(credit - applied)
+			if charges[0].Amount <= 0 {
+				charges = charges[1:]
+			}
+		}
+	}
+
+	var result agingBalance
+	for _, c := range charges {
+		if c.Amount <= 0 {
+			continue
+		}
+		days := calendarDaysBetween(c.Date, asOf)
+		switch {
+		case days <= agingBucket1:
+			result.Days0To30 = roundMoney(result.Days0To30 + c.Amount)
+		case days <= agingBucket2:
+			result.Days31To60 = roundMoney(result.Days31To60 + c.Amount)
+		default:
+			result.DaysOver60 = roundMoney(result.DaysOver60 + c.Amount)
+		}
+		result.Balance = roundMoney(result.Balance + c.Amount)
+	}
+	result.UnappliedCredit = roundMoney(credit)
+	result.Balance = roundMoney(result.Balance - credit)
+	return result
+}
+
+// calendarDaysBetween 两个日期之间相差的自然日数，只比较各自的年月日，不受时区影响
+func calendarDaysBetween(from, to time.Time) int {
+	f := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	t := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(t.Sub(f).Hours() / 24)
+}
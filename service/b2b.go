@@ -19,6 +19,7 @@ type B2BService struct {
 	productModule  *module.SupplierProductModule
 	unitSpecModule *module.ProductUnitSpecModule
 	userModule     *module.UserModule
+	autoPrinter    *PrintDocumentService
 }
 
 func NewB2BService(
@@ -37,6 +38,11 @@ func NewB2BService(
 	}
 }
 
+// EnableAutoPrint 开启供货单创建后按门店规则自动打印送货单
+func (s *B2BService) EnableAutoPrint(printer *PrintDocumentService) {
+	s.autoPrinter = printer
+}
+
 func (s *B2BService) CreateCustomer(storeID uint, req *model.CreateB2BCustomerReq) (*model.B2BCustomer, error) {
 	if storeID == 0 {
 		return nil, apicode.New(apicode.StoreRequired)
@@ -51,6 +57,7 @@ func (s *B2BService) CreateCustomer(storeID uint, req *model.CreateB2BCustomerRe
 		Settlement:    normalizeSettlement(req.Settlement),
 		PriceLevel:    strings.TrimSpace(req.PriceLevel),
 		CreditLimit:   req.CreditLimit,
+		CreditControl: normalizeCreditControl(req.CreditControl),
 		Status:        model.B2BCustomerStatusEnabled,
 		Remark:        strings.TrimSpace(req.Remark),
 	}
@@ -279,9 +286,31 @@ func (s *B2BService) CreateSupplyOrder(storeID, operatorID uint, isHQ bool, req
 		Items:          items,
 	}
 	account := buildB2BSupplyOrderAccount(order)
-	if err := s.b2bModule.CreateSupplyOrderWithInventory(order, account); err != nil {
+	var payment *model.B2BPayment
+	if paid > 0 {
+		payment = &model.B2BPayment{
+			PaymentNo:    s.b2bModule.GeneratePaymentNo(),
+			StoreID:      storeID,
+			CustomerID:   customer.ID,
+			CustomerName: customer.Name,
+			Amount:       paid,
+			PayDate:      orderDate,
+			Method:       defaultPayMethod(req.PayMethod),
+			Remark:       "供货下单收款",
+			Status:       model.B2BPaymentRecordValid,
+			OperatorID:   operatorID,
+			OperatorName: operatorName,
+		}
+	}
+	if err := s.b2bModule.CreateSupplyOrderWithInventory(order, account, payment, req.ConfirmOverCredit); err != nil {
 		return nil, err
 	}
+	if s.autoPrinter != nil {
+		go s.autoPrinter.AutoPrint(model.PrintDocB2BDelivery, order.ID, order.StoreID)
+	}
+	if account != nil {
+		publishStoreAccountChanged(account, model.RealtimeActionCreated, false)
+	}
 	return order, nil
 }
 
@@ -343,19 +372,30 @@ func (s *B2BService) UpdateSupplyOrderDelivery(id, storeID uint, isHQ bool, req
 	if err != nil {
 		return nil, err
 	}
+	if req.DeliveryStatus != model.B2BDeliveryDone {
+		returned, err := s.b2bModule.HasSupplyReturns(order.ID)
+		if err != nil {
+			return nil, err
+		}
+		if returned {
+			return nil, apicode.Newf(apicode.OrderStateConflict, "供货单已登记退货，不能修改配送状态")
+		}
+	}
 	if err := s.b2bModule.UpdateSupplyOrderDelivery(order.ID, req.DeliveryStatus); err != nil {
 		return nil, err
 	}
 	return s.GetSupplyOrder(id, storeID, isHQ)
 }
 
-func (s *B2BService) UpdateSupplyOrderPayment(id, storeID uint, isHQ bool, req *model.UpdateB2BSupplyOrderPaymentReq) (*model.B2BSupplyOrder, error) {
+// UpdateSupplyOrderPayment 按目标收款状态调整供货单已收金额（以扣除退货后的金额为准）：调高部分生成收款记录核销到该单，
+// 调低只允许冲回未登记收款记录的部分（已登记的请作废收款记录）。
+func (s *B2BService) UpdateSupplyOrderPayment(id, storeID, operatorID uint, isHQ bool, req *model.UpdateB2BSupplyOrderPaymentReq) (*model.B2BSupplyOrder, error) {
 	order, err := s.GetSupplyOrder(id, storeID, isHQ)
 	if err != nil {
 		return nil, err
 	}
 
-	total := roundMoney(order.TotalAmount)
+	total := roundMoney(order.NetAmount())
 	paid := roundMoney(req.PaidAmount)
 	switch req.PaymentStatus {
 	case model.B2BPaymentUnpaid:
@@ -372,9 +412,21 @@ func (s *B2BService) UpdateSupplyOrderPayment(id, storeID uint, isHQ bool, req *
 	if paid > total {
 		paid = total
 	}
-	unpaid := roundMoney(total - paid)
-	receivableDelta := roundMoney(unpaid - order.UnpaidAmount)
-	if err := s.b2bModule.UpdateSupplyOrderPayment(order.ID, order.CustomerID, req.PaymentStatus, paid, unpaid, receivableDelta); err != nil {
+
+	delta := roundMoney(paid - order.PaidAmount)
+	switch {
+	case delta > 0:
+		_, err = s.createPayment(order.StoreID, operatorID, &model.CreateB2BPaymentReq{
+			CustomerID:  order.CustomerID,
+			Amount:      delta,
+			Method:      req.PayMethod,
+			Remark:      "修改收款状态",
+			Allocations: []model.B2BPaymentAllocationReq{{OrderID: order.ID, Amount: delta}},
+		})
+	case delta < 0:
+		err = s.b2bModule.ReduceSupplyOrderPaid(order.ID, paid)
+	}
+	if err != nil {
 		return nil, err
 	}
 	return s.GetSupplyOrder(id, storeID, isHQ)
@@ -382,11 +434,25 @@ func (s *B2BService) UpdateSupplyOrderPayment(id, storeID uint, isHQ bool, req *
 
 func normalizeSettlement(v string) string {
 	switch strings.TrimSpace(v) {
-	case "week", "month":
+	case model.B2BSettlementWeek, model.B2BSettlementMonth:
 		return strings.TrimSpace(v)
 	default:
+		return model.B2BSettlementCash
+	}
+}
+
+func normalizeCreditControl(v string) string {
+	if strings.TrimSpace(v) == model.B2BCreditControlSoft {
+		return model.B2BCreditControlSoft
+	}
+	return model.B2BCreditControlHard
+}
+
+func defaultPayMethod(v string) string {
+	if strings.TrimSpace(v) == "" {
 		return "cash"
 	}
+	return strings.TrimSpace(v)
 }
 
 func roundMoney(v float64) float64 {
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+)
+
+// CreatePayment 登记客户收款并核销供货单
+func (s *B2BService) CreatePayment(storeID, operatorID uint, isHQ bool, req *model.CreateB2BPaymentReq) (*model.B2BPayment, error) {
+	customer, err := s.b2bModule.GetCustomer(req.CustomerID)
+	if err != nil {
+		return nil, apicode.New(apicode.CustomerNotFound)
+	}
+	if !isHQ && customer.StoreID != storeID {
+		return nil, apicode.New(apicode.OperationDenied)
+	}
+	return s.createPayment(customer.StoreID, operatorID, req)
+}
+
+func (s *B2BService) createPayment(storeID, operatorID uint, req *model.CreateB2BPaymentReq) (*model.B2BPayment, error) {
+	now := time.Now()
+	payDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if strings.TrimSpace(req.PayDate) != "" {
+		t, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(req.PayDate), time.Local)
+		if err != nil {
+			return nil, apicode.New(apicode.InvalidDate)
+		}
+		payDate = t
+	}
+
+	operatorName := ""
+	if user, err := s.userModule.GetByID(operatorID); err == nil && user != nil {
+		operatorName = user.Nickname
+		if operatorName == "" {
+			operatorName = user.Username
+		}
+	}
+
+	payment := &model.B2BPayment{
+		PaymentNo:    s.b2bModule.GeneratePaymentNo(),
+		StoreID:      storeID,
+		CustomerID:   req.CustomerID,
+		Amount:       roundMoney(req.Amount),
+		PayDate:      payDate,
+		Method:       defaultPayMethod(req.Method),
+		Reference:    strings.TrimSpace(req.Reference),
+		Remark:       strings.TrimSpace(req.Remark),
+		Status:       model.B2BPaymentRecordValid,
+		OperatorID:   operatorID,
+		OperatorName: operatorName,
+	}
+	if err := s.b2bModule.CreatePayment(payment, req.Allocations); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+func (s *B2BService) VoidPayment(id, storeID uint, isHQ bool, reason string) error {
+	return s.b2bModule.VoidPayment(id, storeID, isHQ, strings.TrimSpace(reason))
+}
+
+func (s *B2BService) GetPayment(id, storeID uint, isHQ bool) (*model.B2BPayment, error) {
+	payment, err := s.b2bModule.GetPaymentScoped(id, storeID, isHQ)
+	if err != nil {
+		return nil, apicode.New(apicode.NotFound)
+	}
+	return payment, nil
+}
+
+func (s *B2BService) ListPayments(req *model.ListB2BPaymentReq) ([]*model.B2BPayment, int64, error) {
+	return s.b2bModule.ListPayments(req)
+}
+
+// b2bStatementPeriod 结算周期 [start, end)：周结为周一至周日，月结为自然月。
+// date 为空时取上一个完整周期。
+func b2bStatementPeriod(period, date string, now time.Time) (time.Time, time.Time, error) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	explicit := strings.TrimSpace(date) != ""
+	if explicit {
+		t, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(date), now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, apicode.New(apicode.InvalidDate)
+		}
+		day = t
+	}
+
+	switch period {
+	case model.B2BSettlementWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		start := day.AddDate(0, 0, -offset)
+		if !explicit {
+			start = start.AddDate(0, 0, -7)
+		}
+		return start, start.AddDate(0, 0, 7), nil
+	case model.B2BSettlementMonth:
+		start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		if !explicit {
+			start = start.AddDate(0, -1, 0)
+		}
+		return start, start.AddDate(0, 1, 0), nil
+	default:
+		return time.Time{}, time.Time{}, apicode.Newf(apicode.MissingParameter, "现结客户请指定对账周期 period=week 或 month")
+	}
+}
+
+func newB2BStatement(customer *model.B2BCustomer, start, end time.Time) *model.B2BStatement {
+	return &model.B2BStatement{
+		CustomerID:   customer.ID,
+		CustomerName: customer.Name,
+		Settlement:   customer.Settlement,
+		CreditLimit:  customer.CreditLimit,
+		PeriodStart:  start.Format("2006-01-02"),
+		PeriodEnd:    end.AddDate(0, 0, -1).Format("2006-01-02"),
+	}
+}
+
+// summarizeB2BStatement 按来源汇总本期发生额并计算期末余额；退货与收款以正数展示（收款已扣除作废）
+func summarizeB2BStatement(statement *model.B2BStatement, opening float64, totals []model.B2BReceivableTotal) {
+	statement.OpeningBalance = roundMoney(opening)
+	closing := statement.OpeningBalance
+	for _, t := range totals {
+		switch t.SourceType {
+		case model.B2BReceivableSourceSupplyOrder:
+			statement.SupplyAmount = roundMoney(statement.SupplyAmount + t.Amount)
+		case model.B2BReceivableSourceCreditNote:
+			statement.ReturnAmount = roundMoney(statement.ReturnAmount - t.Amount)
+		case model.B2BReceivableSourcePayment, model.B2BReceivableSourcePaymentVoid:
+			statement.PaymentAmount = roundMoney(statement.PaymentAmount - t.Amount)
+		default:
+			statement.AdjustmentAmount = roundMoney(statement.AdjustmentAmount + t.Amount)
+		}
+		closing = roundMoney(closing + t.Amount)
+	}
+	statement.ClosingBalance = closing
+}
+
+// Statement 单个客户的结算周期对账单（含分录）
+func (s *B2BService) Statement(customerID, storeID uint, isHQ bool, req *model.B2BStatementReq, now time.Time) (*model.B2BStatement, error) {
+	customer, err := s.b2bModule.GetCustomer(customerID)
+	if err != nil {
+		return nil, apicode.New(apicode.CustomerNotFound)
+	}
+	if !isHQ && customer.StoreID != storeID {
+		return nil, apicode.New(apicode.OperationDenied)
+	}
+	period := req.Period
+	if period == "" {
+		period = customer.Settlement
+	}
+	start, end, err := b2bStatementPeriod(period, req.Date, now)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []uint{customer.ID}
+	openings, err := s.b2bModule.ReceivableBalancesBefore(ids, start)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := s.b2bModule.ReceivableTotalsBetween(ids, start, end)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.b2bModule.ReceivableEntriesBetween(customer.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	statement := newB2BStatement(customer, start, end)
+	summarizeB2BStatement(statement, openings[customer.ID], totals)
+	statement.Entries = entries
+	if statement.Entries == nil {
+		statement.Entries = []*model.B2BReceivableEntry{}
+	}
+	return statement, nil
+}
+
+// ListStatements 门店下某结算方式全部客户的本期对账汇总（不含分录），用于周结/月结批量出账
+func (s *B2BService) ListStatements(req *model.ListB2BStatementReq, now time.Time) ([]*model.B2BStatement, error) {
+	start, end, err := b2bStatementPeriod(req.Settlement, req.Date, now)
+	if err != nil {
+		return nil, err
+	}
+	customers, err := s.b2bModule.ListCustomersBySettlement(req.StoreID, req.Settlement)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(customers))
+	for _, customer := range customers {
+		ids = append(ids, customer.ID)
+	}
+	openings, err := s.b2bModule.ReceivableBalancesBefore(ids, start)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := s.b2bModule.ReceivableTotalsBetween(ids, start, end)
+	if err != nil {
+		return nil, err
+	}
+	byCustomer := make(map[uint][]model.B2BReceivableTotal, len(ids))
+	for _, t := range totals {
+		byCustomer[t.CustomerID] = append(byCustomer[t.CustomerID], t)
+	}
+
+	statements := make([]*model.B2BStatement, 0, len(customers))
+	for _, customer := range customers {
+		statement := newB2BStatement(customer, start, end)
+		summarizeB2BStatement(statement, openings[customer.ID], byCustomer[customer.ID])
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
+
+// buildB2BAging 逐个客户先进先出核销应收，rows 须按客户、业务日期排序
+func buildB2BAging(rows []model.B2BReceivableDailyNet, asOf time.Time) []model.B2BCustomerAging {
+	result := make([]model.B2BCustomerAging, 0)
+	var amounts []datedAmount
+	for i, row := range rows {
+		amounts = append(amounts, datedAmount{Date: row.BizDate, Amount: row.Amount})
+		if i < len(rows)-1 && rows[i+1].CustomerID == row.CustomerID {
+			continue
+		}
+		balance := ageDatedAmounts(amounts, asOf)
+		result = append(result, model.B2BCustomerAging{
+			CustomerID:      row.CustomerID,
+			Balance:         balance.Balance,
+			Days0To30:       balance.Days0To30,
+			Days31To60:      balance.Days31To60,
+			DaysOver60:      balance.DaysOver60,
+			UnappliedCredit: balance.UnappliedCredit,
+		})
+		amounts = nil
+	}
+	return result
+}
+
+// Aging 截至 asOf 的客户应收余额与账龄分布，余额为 0 的客户不列出
+func (s *B2BService) Aging(req *model.B2BAgingReq, now time.Time) (*model.B2BAgingReport, error) {
+	asOf := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if strings.TrimSpace(req.AsOf) != "" {
+		t, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(req.AsOf), time.Local)
+		if err != nil {
+			return nil, apicode.New(apicode.InvalidDate)
+		}
+		asOf = t
+	}
+
+	rows, err := s.b2bModule.ReceivableDailyNets(req.StoreID, req.CustomerID, asOf)
+	if err != nil {
+		return nil, err
+	}
+	aging := buildB2BAging(rows, asOf)
+	ids := make([]uint, 0, len(aging))
+	for _, a := range aging {
+		ids = append(ids, a.CustomerID)
+	}
+	customers, err := s.b2bModule.GetCustomersByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*model.B2BCustomer, len(customers))
+	for _, customer := range customers {
+		byID[customer.ID] = customer
+	}
+
+	report := &model.B2BAgingReport{AsOf: asOf.Format("2006-01-02"), Customers: make([]model.B2BCustomerAging, 0, len(aging))}
+	for _, a := range aging {
+		if a.Balance == 0 && a.UnappliedCredit == 0 {
+			continue
+		}
+		if customer, ok := byID[a.CustomerID]; ok {
+			a.CustomerName = customer.Name
+			a.Settlement = customer.Settlement
+			a.CreditLimit = customer.CreditLimit
+		}
+		report.Balance = roundMoney(report.Balance + a.Balance)
+		report.Days0To30 = roundMoney(report.Days0To30 + a.Days0To30)
+		report.Days31To60 = roundMoney(report.Days31To60 + a.Days31To60)
+		report.DaysOver60 = roundMoney(report.DaysOver60 + a.DaysOver60)
+		report.Customers = append(report.Customers, a)
+	}
+	return report, nil
+}
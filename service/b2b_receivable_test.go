@@ -0,0 +1,72 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+func TestB2BStatementPeriod(t *testing.T) {
+	now := time.Date(2024, 6, 12, 15, 0, 0, 0, time.Local) // 周三
+	start, end, err := b2bStatementPeriod(model.B2BSettlementWeek, "", now)
+	if err != nil || start.Format("2006-01-02") != "2024-06-03" || end.Format("2006-01-02") != "2024-06-10" {
+		t.Fatalf("previous week = %v ~ %v, %v", start, end, err)
+	}
+	start, end, _ = b2bStatementPeriod(model.B2BSettlementWeek, "2024-06-16", now) // 周日
+	if start.Format("2006-01-02") != "2024-06-10" || end.Format("2006-01-02") != "2024-06-17" {
+		t.Fatalf("week of sunday = %v ~ %v", start, end)
+	}
+	start, end, _ = b2bStatementPeriod(model.B2BSettlementMonth, "", now)
+	if start.Format("2006-01-02") != "2024-05-01" || end.Format("2006-01-02") != "2024-06-01" {
+		t.Fatalf("previous month = %v ~ %v", start, end)
+	}
+	if _, _, err := b2bStatementPeriod(model.B2BSettlementCash, "", now); err == nil {
+		t.Fatal("cash settlement requires explicit period")
+	}
+}
+
+func TestSummarizeB2BStatement(t *testing.T) {
+	statement := &model.B2BStatement{}
+	summarizeB2BStatement(statement, 300, []model.B2BReceivableTotal{
+		{SourceType: model.B2BReceivableSourceSupplyOrder, Amount: 1200},
+		{SourceType: model.B2BReceivableSourcePayment, Amount: -900},
+		{SourceType: model.B2BReceivableSourcePaymentVoid, Amount: 100},
+		{SourceType: model.B2BReceivableSourceAdjustment, Amount: 50},
+	})
+	if statement.SupplyAmount != 1200 || statement.PaymentAmount != 800 || statement.AdjustmentAmount != 50 || statement.ClosingBalance != 750 {
+		t.Fatalf("statement = %#v", statement)
+	}
+}
+
+func TestBuildB2BAging(t *testing.T) {
+	asOf := time.Date(2024, 6, 30, 0, 0, 0, 0, time.Local)
+	day := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 0, 0, 0, 0, time.Local) }
+	aging := buildB2BAging([]model.B2BReceivableDailyNet{
+		{CustomerID: 1, BizDate: day(3, 1), Amount: 500},
+		{CustomerID: 1, BizDate: day(6, 10), Amount: 300},
+		{CustomerID: 1, BizDate: day(6, 20), Amount: -600},
+		{CustomerID: 2, BizDate: day(5, 20), Amount: 120},
+	}, asOf)
+	if len(aging) != 2 {
+		t.Fatalf("aging = %#v", aging)
+	}
+	if aging[0].DaysOver60 != 0 || aging[0].Days0To30 != 200 || aging[0].Balance != 200 {
+		t.Fatalf("customer 1 = %#v", aging[0])
+	}
+	if aging[1].Days31To60 != 120 || aging[1].Balance != 120 {
+		t.Fatalf("customer 2 = %#v", aging[1])
+	}
+}
+
+func TestSummarizeB2BStatementCreditNote(t *testing.T) {
+	statement := &model.B2BStatement{}
+	summarizeB2BStatement(statement, 100, []model.B2BReceivableTotal{
+		{SourceType: model.B2BReceivableSourceSupplyOrder, Amount: 500},
+		{SourceType: model.B2BReceivableSourceCreditNote, Amount: -80},
+		{SourceType: model.B2BReceivableSourcePayment, Amount: -200},
+	})
+	if statement.ReturnAmount != 80 || statement.PaymentAmount != 200 || statement.ClosingBalance != 320 {
+		t.Fatalf("statement = %+v", statement)
+	}
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+)
+
+// CreateSupplyReturn 对供货单登记退货，退回数量按下单规格填写
+func (s *B2BService) CreateSupplyReturn(orderID, storeID, operatorID uint, isHQ bool, req *model.CreateB2BSupplyReturnReq) (*model.B2BSupplyReturn, error) {
+	order, err := s.GetSupplyOrder(orderID, storeID, isHQ)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	returnDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if strings.TrimSpace(req.ReturnDate) != "" {
+		t, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(req.ReturnDate), time.Local)
+		if err != nil {
+			return nil, apicode.New(apicode.InvalidDate)
+		}
+		returnDate = t
+	}
+
+	operatorName := ""
+	if user, err := s.userModule.GetByID(operatorID); err == nil && user != nil {
+		operatorName = user.Nickname
+		if operatorName == "" {
+			operatorName = user.Username
+		}
+	}
+
+	ret := &model.B2BSupplyReturn{
+		ReturnNo:     s.b2bModule.GenerateSupplyReturnNo(),
+		OrderID:      order.ID,
+		ReturnDate:   returnDate,
+		Reason:       strings.TrimSpace(req.Reason),
+		Remark:       strings.TrimSpace(req.Remark),
+		OperatorID:   operatorID,
+		OperatorName: operatorName,
+	}
+	if err := s.b2bModule.CreateSupplyReturn(ret, req.Items); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (s *B2BService) GetSupplyReturn(id, storeID uint, isHQ bool) (*model.B2BSupplyReturn, error) {
+	ret, err := s.b2bModule.GetSupplyReturnScoped(id, storeID, isHQ)
+	if err != nil {
+		return nil, apicode.New(apicode.NotFound)
+	}
+	return ret, nil
+}
+
+func (s *B2BService) ListSupplyReturns(req *model.ListB2BSupplyReturnReq) ([]*model.B2BSupplyReturn, int64, error) {
+	return s.b2bModule.ListSupplyReturns(req)
+}
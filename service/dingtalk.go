@@ -23,6 +23,12 @@ import (
 	updatesPkg "github.com/Kevin-Jii/tower-go/utils/updates"
 )
 
+// 钉钉开放平台地址；测试时指向本地模拟服务（pkg/dingtalkfake）
+var (
+	dingTalkAPIHost  = "https://api.dingtalk.com"
+	dingTalkOAPIHost = "https://oapi.dingtalk.com"
+)
+
 type DingTalkService struct {
 	botModule    *module.DingTalkBotModule
 	userModule   *module.DingTalkUserModule
@@ -437,7 +443,7 @@ func (s *DingTalkService) SendStreamImageText(bot *model.DingTalkBot, title, tex
 
 // uploadImage 上传图片到钉钉,返回 mediaId (旧版API，保留向后兼容)
 func (s *DingTalkService) uploadImage(accessToken string, imageData []byte) (string, error) {
-	apiURL := "https://oapi.dingtalk.com/media/upload?access_token=" + accessToken + "&type=image"
+	apiURL := dingTalkOAPIHost + "/media/upload?access_token=" + accessToken + "&type=image"
 
 	// 创建 multipart form
 	body := &bytes.Buffer{}
@@ -489,7 +495,7 @@ func (s *DingTalkService) uploadImage(accessToken string, imageData []byte) (str
 
 // uploadImageMedia 上传图片到钉钉媒体库(新版API)，返回 mediaId
 func (s *DingTalkService) uploadImageMedia(accessToken string, imageData []byte) (string, error) {
-	apiURL := "https://oapi.dingtalk.com/media/upload?access_token=" + accessToken + "&type=image"
+	apiURL := dingTalkOAPIHost + "/media/upload?access_token=" + accessToken + "&type=image"
 
 	// 创建 multipart form
 	body := &bytes.Buffer{}
@@ -549,7 +555,7 @@ func (s *DingTalkService) uploadImageMedia(accessToken string, imageData []byte)
 // sendAnnouncement 发送钉钉企业公告
 // 参考文档: https://open.dingtalk.com/document/orgapp/create-a-dingtalk-notification
 func (s *DingTalkService) sendAnnouncement(accessToken string, agentIDStr, title, content, mediaID string) error {
-	apiURL := "https://oapi.dingtalk.com/topapi/message/corpconversation/asyncsend_v2?access_token=" + accessToken
+	apiURL := dingTalkOAPIHost + "/topapi/message/corpconversation/asyncsend_v2?access_token=" + accessToken
 
 	// 将 agentID 从字符串转换为数字
 	var agentID int64
@@ -653,7 +659,7 @@ func (s *DingTalkService) sendAnnouncement(accessToken string, agentIDStr, title
 
 // getStreamAccessToken 获取 Stream 模式的 access_token
 func (s *DingTalkService) getStreamAccessToken(clientID, clientSecret string) (string, error) {
-	apiURL := "https://api.dingtalk.com/v1.0/oauth2/accessToken"
+	apiURL := dingTalkAPIHost + "/v1.0/oauth2/accessToken"
 
 	clientID = strings.TrimSpace(clientID)
 	clientSecret = strings.TrimSpace(clientSecret)
@@ -717,7 +723,7 @@ func (s *DingTalkService) GetUserIdByMobile(mobile, accessToken string) (string,
 	}
 
 	// 2. 调用钉钉API获取
-	apiURL := "https://oapi.dingtalk.com/topapi/v2/user/getbymobile?access_token=" + accessToken
+	apiURL := dingTalkOAPIHost + "/topapi/v2/user/getbymobile?access_token=" + accessToken
 
 	reqBody := map[string]string{
 		"mobile": mobile,
@@ -769,6 +775,33 @@ func (s *DingTalkService) GetUserIdByMobile(mobile, accessToken string) (string,
 	return userId, nil
 }
 
+// BindStaffMobile 通过机器人所属应用查询手机号对应的钉钉用户，与发送者一致时完成绑定；
+// 以钉钉通讯录为准，避免冒用他人手机号绑定系统账号
+func (s *DingTalkService) BindStaffMobile(botID uint, staffID, name, mobile string) error {
+	if s.userModule == nil {
+		return errors.New("钉钉用户模块未初始化")
+	}
+	bot, err := s.botModule.GetByID(botID)
+	if err != nil || bot == nil {
+		return fmt.Errorf("机器人 %d 不存在", botID)
+	}
+	if bot.ClientID == "" || bot.ClientSecret == "" {
+		return fmt.Errorf("机器人【%s】未配置应用凭证", bot.Name)
+	}
+	accessToken, err := s.getStreamAccessToken(bot.ClientID, bot.ClientSecret)
+	if err != nil {
+		return err
+	}
+	userID, err := s.GetUserIdByMobile(mobile, accessToken)
+	if err != nil {
+		return err
+	}
+	if userID != staffID {
+		return errors.New("手机号与当前钉钉账号不一致")
+	}
+	return s.userModule.BindStaff(&model.DingTalkUser{Mobile: mobile, UserID: staffID, Name: name})
+}
+
 // sendStreamMessage 通过钉钉服务端 API 发送单聊消息
 // 使用机器人发送单聊消息 API: https://open.dingtalk.com/document/orgapp/chatbots-send-one-on-one-chat-messages-in-batches
 func (s *DingTalkService) sendStreamMessage(robotCode, accessToken string, msgBody map[string]interface{}) error {
@@ -782,7 +815,7 @@ func (s *DingTalkService) sendStreamMessageToUsers(robotCode, accessToken string
 	}
 
 	// 使用单聊消息 API
-	apiURL := "https://api.dingtalk.com/v1.0/robot/oToMessages/batchSend"
+	apiURL := dingTalkAPIHost + "/v1.0/robot/oToMessages/batchSend"
 
 	// 构造完整请求体
 	msgType := msgBody["msgtype"].(string)
@@ -868,6 +901,71 @@ func (s *DingTalkService) sendStreamMessageToUsers(robotCode, accessToken string
 	return nil
 }
 
+// SendStreamMarkdownToGroup Stream 模式发送 Markdown 消息到群会话
+// 使用机器人发送群聊消息 API: https://open.dingtalk.com/document/orgapp/the-robot-sends-a-group-message
+func (s *DingTalkService) SendStreamMarkdownToGroup(bot *model.DingTalkBot, title, text, openConversationID string) error {
+	if bot.RobotCode == "" {
+		return errors.New("robotCode is required for stream mode")
+	}
+	if strings.TrimSpace(openConversationID) == "" {
+		return errors.New("openConversationId is required for group message")
+	}
+
+	accessToken, err := s.getStreamAccessToken(bot.ClientID, bot.ClientSecret)
+	if err != nil {
+		return fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	msgParamJSON, _ := json.Marshal(map[string]interface{}{
+		"title": title,
+		"text":  text,
+	})
+	reqBody := map[string]interface{}{
+		"msgKey":             "sampleMarkdown",
+		"msgParam":           string(msgParamJSON),
+		"robotCode":          bot.RobotCode,
+		"openConversationId": openConversationID,
+	}
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", dingTalkAPIHost+"/v1.0/robot/groupMessages/send", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-acs-dingtalk-access-token", accessToken)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if errCode, ok := result["code"].(string); ok && errCode != "" {
+		return fmt.Errorf("dingtalk api error: code=%v, msg=%v", errCode, result["message"])
+	}
+
+	if logging.SugaredLogger != nil {
+		logging.SugaredLogger.Infow("Group message sent successfully",
+			"robotCode", bot.RobotCode,
+			"openConversationId", openConversationID,
+		)
+	}
+	return nil
+}
+
 // sendMessage 发送消息到钉钉（Webhook 模式通用方法）
 func (s *DingTalkService) sendMessage(bot *model.DingTalkBot, message interface{}) error {
 	webhook := bot.Webhook
@@ -960,6 +1058,8 @@ func (s *DingTalkService) CreateBot(req *model.CreateDingTalkBotReq) (*model.Din
 		MsgType:      "markdown",
 		CardMsgKey:   strings.TrimSpace(req.CardMsgKey),
 		Remark:       req.Remark,
+
+		ApprovalCardTemplateID: strings.TrimSpace(req.ApprovalCardTemplateID),
 	}
 
 	if req.IsEnabled != nil {
@@ -1171,7 +1271,7 @@ func (s *DingTalkService) GetOpenConversationIdByBotId(botID uint, chatId string
 	}
 
 	// 方法1: 尝试使用新版API (v1.0)
-	apiURL := fmt.Sprintf("https://api.dingtalk.com/v1.0/im/conversations/%s", chatId)
+	apiURL := fmt.Sprintf("%s/v1.0/im/conversations/%s", dingTalkAPIHost, chatId)
 
 	client := &http.Client{Timeout: 10 * time.Second}
 	req, err := http.NewRequest("GET", apiURL, nil)
@@ -1263,7 +1363,7 @@ func (s *DingTalkService) GetOpenConversationIdByBotId(botID uint, chatId string
 // getOpenConversationIdByOldAPI 使用旧版API获取群会话ID
 func (s *DingTalkService) getOpenConversationIdByOldAPI(bot *model.DingTalkBot, chatId, accessToken string, botID uint) (map[string]interface{}, error) {
 	// 使用旧版API
-	apiURL := fmt.Sprintf("https://oapi.dingtalk.com/chat/get?access_token=%s&chatid=%s", accessToken, chatId)
+	apiURL := fmt.Sprintf("%s/chat/get?access_token=%s&chatid=%s", dingTalkOAPIHost, accessToken, chatId)
 
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Get(apiURL)
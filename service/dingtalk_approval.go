@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/module"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	"github.com/Kevin-Jii/tower-go/utils/logging"
+	"github.com/open-dingtalk/dingtalk-stream-sdk-go/card"
+	"go.uber.org/zap"
+)
+
+// DingTalkApprovalHandler 某类审批的业务处理：Permission 为审批人需要的权限码（与后台对应接口一致），
+// Approve 在审批通过后执行业务，Reject 可选，用于驳回后回滚业务状态。
+type DingTalkApprovalHandler struct {
+	Permission string
+	Approve    func(approval *model.DingTalkApproval, approver *model.User) error
+	Reject     func(approval *model.DingTalkApproval, approver *model.User) error
+}
+
+// DingTalkApprovalRequest 业务方提交的审批内容；Exclusive 为 true 时同一单据只保留一条待审批记录
+type DingTalkApprovalRequest struct {
+	BizType     string
+	BizID       uint
+	BizNo       string
+	StoreID     uint
+	Amount      float64
+	Title       string
+	Content     string
+	Payload     interface{}
+	RequesterID uint
+	Exclusive   bool
+}
+
+// DingTalkApprovalService 钉钉审批：按通知路由规则把审批卡片发给审批人，Stream 回调按钮结果后执行业务并更新卡片
+type DingTalkApprovalService struct {
+	approvalModule  *module.DingTalkApprovalModule
+	routeModule     *module.NotificationRouteModule
+	botModule       *module.DingTalkBotModule
+	storeModule     *module.StoreModule
+	userModule      *module.UserModule
+	dingTalkService *DingTalkService
+	handlers        map[string]*DingTalkApprovalHandler
+}
+
+func NewDingTalkApprovalService(
+	approvalModule *module.DingTalkApprovalModule,
+	routeModule *module.NotificationRouteModule,
+	botModule *module.DingTalkBotModule,
+	storeModule *module.StoreModule,
+	userModule *module.UserModule,
+	dingTalkService *DingTalkService,
+) *DingTalkApprovalService {
+	return &DingTalkApprovalService{
+		approvalModule:  approvalModule,
+		routeModule:     routeModule,
+		botModule:       botModule,
+		storeModule:     storeModule,
+		userModule:      userModule,
+		dingTalkService: dingTalkService,
+		handlers:        make(map[string]*DingTalkApprovalHandler),
+	}
+}
+
+// RegisterHandler 注册审批业务处理，由各业务服务在装配时调用
+func (s *DingTalkApprovalService) RegisterHandler(bizType string, handler *DingTalkApprovalHandler) {
+	s.handlers[bizType] = handler
+}
+
+// Submit 命中审批路由时创建审批并发送卡片，返回审批记录；未配置审批路由返回 nil，业务直接执行
+func (s *DingTalkApprovalService) Submit(req *DingTalkApprovalRequest) (*model.DingTalkApproval, error) {
+	if s == nil || s.handlers[req.BizType] == nil {
+		return nil, nil
+	}
+	routes, err := s.routeModule.Match(&model.NotificationEvent{EventType: req.BizType, StoreID: req.StoreID, Amount: req.Amount})
+	if err != nil {
+		return nil, err
+	}
+	if len(routes) == 0 {
+		return nil, nil
+	}
+	if req.Exclusive {
+		existing, err := s.approvalModule.FindPending(req.BizType, req.BizID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return existing, nil
+		}
+	}
+
+	approval := &model.DingTalkApproval{
+		BizType:     req.BizType,
+		BizID:       req.BizID,
+		BizNo:       req.BizNo,
+		StoreID:     req.StoreID,
+		Amount:      req.Amount,
+		Title:       req.Title,
+		Content:     req.Content,
+		RequesterID: req.RequesterID,
+	}
+	if req.Payload != nil {
+		data, err := json.Marshal(req.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("marshal approval payload: %w", err)
+		}
+		approval.Payload = string(data)
+	}
+	if err := s.approvalModule.Create(approval); err != nil {
+		return nil, err
+	}
+
+	trackIDs, deliverErr := s.deliverCards(approval, routes)
+	if len(trackIDs) == 0 {
+		msg := "没有可用的审批卡片接收方"
+		if deliverErr != nil {
+			msg = deliverErr.Error()
+		}
+		_ = s.approvalModule.Finish(approval.ID, model.ApprovalFailed, "卡片发送失败："+msg)
+		return nil, apicode.Newf(apicode.ExternalServiceFailed, "审批卡片发送失败：%s", msg)
+	}
+	if deliverErr != nil {
+		logging.LogWarn("部分审批卡片发送失败", zap.Uint("approval_id", approval.ID), zap.Error(deliverErr))
+	}
+	if err := s.approvalModule.SetCardTrackIDs(approval.ID, trackIDs); err != nil {
+		return nil, err
+	}
+	approval.CardTrackIDs = trackIDs
+	return approval, nil
+}
+
+// deliverCards 每个机器人创建一张卡片实例，同时投放到该机器人下的单聊和群聊；Webhook 机器人无法回调，跳过
+func (s *DingTalkApprovalService) deliverCards(approval *model.DingTalkApproval, routes []*model.NotificationRoute) ([]string, error) {
+	storePhone := ""
+	if s.storeModule != nil && approval.StoreID > 0 {
+		if store, err := s.storeModule.GetByID(approval.StoreID); err == nil && store != nil {
+			storePhone = strings.TrimSpace(store.Phone)
+		}
+	}
+
+	var botIDs []uint
+	deliveries := make(map[uint]*DingTalkCardDelivery)
+	for _, recipient := range expandNotificationRoutes(routes, storePhone) {
+		if recipient.TargetType == model.NotificationTargetWebhook {
+			continue
+		}
+		d := deliveries[recipient.BotID]
+		if d == nil {
+			d = &DingTalkCardDelivery{OutTrackID: approvalTrackID(approval.ID, recipient.BotID), Params: approvalCardParams(approval)}
+			deliveries[recipient.BotID] = d
+			botIDs = append(botIDs, recipient.BotID)
+		}
+		if recipient.TargetType == model.NotificationTargetGroup {
+			d.GroupIDs = append(d.GroupIDs, recipient.Target)
+		} else {
+			d.Mobiles = append(d.Mobiles, recipient.Target)
+		}
+	}
+
+	var trackIDs []string
+	var lastErr error
+	for _, botID := range botIDs {
+		bot, err := s.botModule.GetByID(botID)
+		if err != nil || !bot.IsEnabled {
+			lastErr = fmt.Errorf("机器人 %d 不存在或已停用", botID)
+			continue
+		}
+		if bot.ApprovalCardTemplateID == "" {
+			lastErr = fmt.Errorf("机器人 %s 未配置审批卡片模板", bot.Name)
+			continue
+		}
+		d := deliveries[botID]
+		d.TemplateID = bot.ApprovalCardTemplateID
+		if err := s.dingTalkService.CreateAndDeliverCard(bot, d); err != nil {
+			lastErr = err
+			continue
+		}
+		trackIDs = append(trackIDs, d.OutTrackID)
+	}
+	return trackIDs, lastErr
+}
+
+// HandleCardCallback 处理审批卡片按钮回调：校验审批人、抢占审批、执行业务，并把结果写回卡片
+func (s *DingTalkApprovalService) HandleCardCallback(ctx context.Context, botID uint, req *card.CardRequest) (*card.CardResponse, error) {
+	approvalID, _, ok := parseApprovalTrackID(req.OutTrackId)
+	if !ok {
+		return &card.CardResponse{}, nil
+	}
+	approval, err := s.approvalModule.GetByID(approvalID)
+	if err != nil {
+		logging.LogWarn("审批卡片回调找不到审批记录", zap.String("out_track_id", req.OutTrackId), zap.Error(err))
+		return &card.CardResponse{}, nil
+	}
+	if approval.Status != model.ApprovalPending {
+		return approvalCardResponse(approval, ""), nil
+	}
+
+	action := approvalCardAction(req)
+	if action == "" {
+		return approvalCardResponse(approval, "无法识别的操作"), nil
+	}
+	handler := s.handlers[approval.BizType]
+	if handler == nil {
+		return approvalCardResponse(approval, "该审批类型暂不支持处理"), nil
+	}
+	user := resolveDingTalkOperator(s.userModule, req.UserId)
+	if user == nil {
+		return approvalCardResponse(approval, "请先在机器人单聊发送「绑定 手机号」绑定系统账号"), nil
+	}
+	if isSelfApproval(approval, user, action) {
+		return approvalCardResponse(approval, "不能审批自己发起的申请"), nil
+	}
+	if !dingTalkUserHasPermission(user, handler.Permission) {
+		return approvalCardResponse(approval, "您的账号没有审批该单据的权限"), nil
+	}
+	if storeID, hqUnbound := dingTalkApproverScope(user); !hqUnbound && storeID != approval.StoreID {
+		return approvalCardResponse(approval, "只能审批本门店的单据"), nil
+	}
+
+	status := model.ApprovalApproved
+	if action == model.ApprovalActionReject {
+		status = model.ApprovalRejected
+	}
+	won, err := s.approvalModule.Decide(approval, status, user, req.UserId, dingTalkUserDisplay(user), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if won {
+		s.execute(approval, handler, status, user)
+	}
+
+	if latest, err := s.approvalModule.GetByID(approval.ID); err == nil {
+		approval = latest
+	}
+	go s.syncCards(approval, req.OutTrackId)
+	return approvalCardResponse(approval, ""), nil
+}
+
+// execute 执行审批结果对应的业务，失败时审批记为执行失败并保留原因
+func (s *DingTalkApprovalService) execute(approval *model.DingTalkApproval, handler *DingTalkApprovalHandler, status int8, approver *model.User) {
+	run, result := handler.Approve, "审批通过，已执行"
+	if status == model.ApprovalRejected {
+		run, result = handler.Reject, "已驳回"
+	}
+	if run != nil {
+		if err := run(approval, approver); err != nil {
+			logging.LogWarn("钉钉审批执行失败", zap.Uint("approval_id", approval.ID), zap.String("biz_type", approval.BizType), zap.Error(err))
+			status, result = model.ApprovalFailed, "执行失败："+dingTalkErrorText(err)
+		}
+	}
+	if err := s.approvalModule.Finish(approval.ID, status, result); err != nil {
+		logging.LogWarn("钉钉审批结果保存失败", zap.Uint("approval_id", approval.ID), zap.Error(err))
+	}
+}
+
+// syncCards 同一审批发给多个机器人时，回调只会更新被点击的那张卡片，其余卡片主动更新
+func (s *DingTalkApprovalService) syncCards(approval *model.DingTalkApproval, clickedTrackID string) {
+	params := approvalCardParams(approval)
+	for _, trackID := range approval.CardTrackIDs {
+		if trackID == clickedTrackID {
+			continue
+		}
+		_, botID, ok := parseApprovalTrackID(trackID)
+		if !ok {
+			continue
+		}
+		bot, err := s.botModule.GetByID(botID)
+		if err != nil {
+			continue
+		}
+		if err := s.dingTalkService.UpdateCard(bot, trackID, params); err != nil {
+			logging.LogWarn("审批卡片更新失败", zap.Uint("approval_id", approval.ID), zap.String("out_track_id", trackID), zap.Error(err))
+		}
+	}
+}
+
+// List 审批记录列表
+func (s *DingTalkApprovalService) List(req *model.ListDingTalkApprovalReq) ([]*model.DingTalkApproval, int64, error) {
+	return s.approvalModule.List(req)
+}
+
+// Get 审批详情，门店账号只能查看本店审批
+func (s *DingTalkApprovalService) Get(id, storeID uint, isHQ bool) (*model.DingTalkApproval, error) {
+	approval, err := s.approvalModule.GetByID(id)
+	if err != nil {
+		return nil, apicode.New(apicode.NotFound)
+	}
+	if !isHQ && approval.StoreID != storeID {
+		return nil, apicode.New(apicode.OperationDenied)
+	}
+	return approval, nil
+}
+
+// dingTalkApproverScope 审批人执行业务时的门店范围，与后台接口的数据权限一致
+func dingTalkApproverScope(user *model.User) (storeID uint, hqUnbound bool) {
+	return user.StoreID, model.HQUnboundAdminRole(dingTalkUserRoleCode(user), user.StoreID)
+}
+
+// isSelfApproval 发起人不能通过自己的申请；驳回视为撤回，允许发起人操作
+func isSelfApproval(approval *model.DingTalkApproval, user *model.User, action string) bool {
+	return action == model.ApprovalActionApprove && approval.RequesterID != 0 && user.ID == approval.RequesterID
+}
+
+// approvalTrackID 卡片 outTrackId：审批ID + 机器人ID，回调时据此找到审批与发送卡片的机器人
+func approvalTrackID(approvalID, botID uint) string {
+	return fmt.Sprintf("approval_%d_%d", approvalID, botID)
+}
+
+func parseApprovalTrackID(trackID string) (approvalID, botID uint, ok bool) {
+	parts := strings.Split(trackID, "_")
+	if len(parts) != 3 || parts[0] != "approval" {
+		return 0, 0, false
+	}
+	a, err1 := strconv.ParseUint(parts[1], 10, 64)
+	b, err2 := strconv.ParseUint(parts[2], 10, 64)
+	if err1 != nil || err2 != nil || a == 0 {
+		return 0, 0, false
+	}
+	return uint(a), uint(b), true
+}
+
+// approvalCardAction 卡片按钮通过回调参数 action 传递操作，也兼容以按钮 actionId 区分
+func approvalCardAction(req *card.CardRequest) string {
+	action := req.GetActionString("action")
+	if action == "" && len(req.CardActionData.CardPrivateData.ActionIdList) > 0 {
+		action = req.CardActionData.CardPrivateData.ActionIdList[0]
+	}
+	switch action {
+	case model.ApprovalActionApprove, model.ApprovalActionReject:
+		return action
+	}
+	return ""
+}
+
+// approvalCardParams 卡片公共数据，卡片模板按这些变量渲染；status 非 1 时模板应隐藏按钮
+func approvalCardParams(approval *model.DingTalkApproval) map[string]string {
+	decidedAt := ""
+	if approval.DecidedAt != nil {
+		decidedAt = approval.DecidedAt.Format("2006-01-02 15:04:05")
+	}
+	return map[string]string{
+		"title":       approval.Title,
+		"content":     approval.Content,
+		"status":      strconv.Itoa(int(approval.Status)),
+		"status_text": model.ApprovalStatusText(approval.Status),
+		"approver":    approval.ApproverName,
+		"decided_at":  decidedAt,
+		"result":      approval.ResultMessage,
+	}
+}
+
+// approvalCardResponse 回调响应：更新卡片公共数据，tip 只展示给点击人
+func approvalCardResponse(approval *model.DingTalkApproval, tip string) *card.CardResponse {
+	return &card.CardResponse{
+		CardUpdateOptions: &card.CardUpdateOptions{UpdateCardDataByKey: true, UpdatePrivateDataByKey: true},
+		CardData:          &card.CardDataDto{CardParamMap: approvalCardParams(approval)},
+		UserPrivateData:   &card.CardDataDto{CardParamMap: map[string]string{"tip": tip}},
+	}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/dingtalkfake"
+	"github.com/open-dingtalk/dingtalk-stream-sdk-go/card"
+	"github.com/open-dingtalk/dingtalk-stream-sdk-go/client"
+)
+
+// useDingTalkFake 将开放平台地址指向本地模拟服务，测试结束后恢复
+func useDingTalkFake(t *testing.T) *dingtalkfake.Server {
+	t.Helper()
+	fake := dingtalkfake.New()
+	api, oapi := dingTalkAPIHost, dingTalkOAPIHost
+	dingTalkAPIHost, dingTalkOAPIHost = fake.URL, fake.URL
+	t.Cleanup(func() {
+		dingTalkAPIHost, dingTalkOAPIHost = api, oapi
+		fake.Close()
+	})
+	return fake
+}
+
+func TestApprovalTrackID(t *testing.T) {
+	approvalID, botID, ok := parseApprovalTrackID(approvalTrackID(12, 3))
+	if !ok || approvalID != 12 || botID != 3 {
+		t.Fatalf("parse = %d %d %v, want 12 3 true", approvalID, botID, ok)
+	}
+	for _, bad := range []string{"", "approval_x_1", "order_1_2", "approval_0_1", "approval_1_2_3"} {
+		if _, _, ok := parseApprovalTrackID(bad); ok {
+			t.Fatalf("%q should not parse", bad)
+		}
+	}
+}
+
+func TestApprovalCardAction(t *testing.T) {
+	byParam := &card.CardRequest{}
+	byParam.CardActionData.CardPrivateData.Params = map[string]any{"action": "reject"}
+	if got := approvalCardAction(byParam); got != model.ApprovalActionReject {
+		t.Fatalf("action from params = %q", got)
+	}
+	byActionID := &card.CardRequest{}
+	byActionID.CardActionData.CardPrivateData.ActionIdList = []string{"approve"}
+	if got := approvalCardAction(byActionID); got != model.ApprovalActionApprove {
+		t.Fatalf("action from actionIds = %q", got)
+	}
+	unknown := &card.CardRequest{}
+	unknown.CardActionData.CardPrivateData.Params = map[string]any{"action": "delete"}
+	if got := approvalCardAction(unknown); got != "" {
+		t.Fatalf("unknown action should be ignored, got %q", got)
+	}
+}
+
+func TestIsSelfApproval(t *testing.T) {
+	approval := &model.DingTalkApproval{RequesterID: 7}
+	requester := &model.User{ID: 7}
+	if !isSelfApproval(approval, requester, model.ApprovalActionApprove) {
+		t.Fatal("requester approving own request should be denied")
+	}
+	if isSelfApproval(approval, requester, model.ApprovalActionReject) {
+		t.Fatal("requester may withdraw own request by rejecting")
+	}
+	if isSelfApproval(approval, &model.User{ID: 8}, model.ApprovalActionApprove) {
+		t.Fatal("other approver should not be treated as self approval")
+	}
+}
+
+func TestApprovalCardParams(t *testing.T) {
+	decidedAt := time.Date(2026, 10, 1, 9, 30, 0, 0, time.Local)
+	params := approvalCardParams(&model.DingTalkApproval{
+		Title:         "记账单作废申请",
+		Status:        model.ApprovalFailed,
+		ApproverName:  "张三（一号店）",
+		DecidedAt:     &decidedAt,
+		ResultMessage: "执行失败：记账单已作废",
+	})
+	if params["status"] != "4" || params["status_text"] != "执行失败" || params["approver"] != "张三（一号店）" ||
+		params["decided_at"] != "2026-10-01 09:30:00" || params["result"] != "执行失败：记账单已作废" {
+		t.Fatalf("card params = %#v", params)
+	}
+	if pending := approvalCardParams(&model.DingTalkApproval{Status: model.ApprovalPending}); pending["decided_at"] != "" || pending["status_text"] != "待审批" {
+		t.Fatalf("pending card params = %#v", pending)
+	}
+}
+
+func TestDingTalkCardDeliverAndUpdate(t *testing.T) {
+	fake := useDingTalkFake(t)
+	fake.SetUser("13800000000", "staff-1")
+	s := NewDingTalkService(nil, nil)
+	bot := &model.DingTalkBot{ID: 2, BotType: "stream", ClientID: "id", ClientSecret: "secret", RobotCode: "robot"}
+
+	err := s.CreateAndDeliverCard(bot, &DingTalkCardDelivery{
+		TemplateID: "tpl.schema",
+		OutTrackID: approvalTrackID(5, bot.ID),
+		Mobiles:    []string{"13800000000"},
+		GroupIDs:   []string{"cid-1"},
+		Params:     map[string]string{"title": "采购单待确认"},
+	})
+	if err != nil {
+		t.Fatalf("deliver card: %v", err)
+	}
+	created := fake.Requests("/v1.0/card/instances/createAndDeliver")
+	if len(created) != 1 {
+		t.Fatalf("createAndDeliver calls = %d, want 1", len(created))
+	}
+	body := created[0].Body
+	if body["openSpaceId"] != "dtv1.card//IM_ROBOT.staff-1;IM_GROUP.cid-1" || body["callbackType"] != "STREAM" ||
+		body["outTrackId"] != "approval_5_2" || body["cardTemplateId"] != "tpl.schema" {
+		t.Fatalf("createAndDeliver body = %#v", body)
+	}
+	if deliver, _ := body["imGroupOpenDeliverModel"].(map[string]interface{}); deliver["robotCode"] != "robot" {
+		t.Fatalf("group deliver model = %#v", body["imGroupOpenDeliverModel"])
+	}
+
+	if err := s.CreateAndDeliverCard(bot, &DingTalkCardDelivery{TemplateID: "tpl", OutTrackID: "approval_6_2", Mobiles: []string{"13900000000"}}); err == nil {
+		t.Fatalf("unknown mobile should fail")
+	}
+
+	if err := s.UpdateCard(bot, "approval_5_2", map[string]string{"status": "2"}); err != nil {
+		t.Fatalf("update card: %v", err)
+	}
+	updated := fake.Requests("/v1.0/card/instances")
+	if len(updated) != 1 || updated[0].Method != "PUT" || updated[0].Body["outTrackId"] != "approval_5_2" {
+		t.Fatalf("update requests = %#v", updated)
+	}
+}
+
+func TestStreamClientRoutesCardCallback(t *testing.T) {
+	fake := useDingTalkFake(t)
+	sc := &DingTalkStreamClient{clients: make(map[uint]*client.StreamClient)}
+	defer sc.StopAll()
+
+	var gotBotID uint
+	var gotReq *card.CardRequest
+	sc.SetCardCallbackHandler(func(ctx context.Context, botID uint, req *card.CardRequest) (*card.CardResponse, error) {
+		gotBotID, gotReq = botID, req
+		return approvalCardResponse(&model.DingTalkApproval{Status: model.ApprovalApproved, ApproverName: "张三"}, ""), nil
+	})
+	if err := sc.StartBot(&model.DingTalkBot{ID: 7, BotType: "stream", ClientID: "id", ClientSecret: "secret"}); err != nil {
+		t.Fatalf("start bot: %v", err)
+	}
+
+	resp, err := fake.PushCardCallback("approval_3_7", "staff-1", map[string]interface{}{"action": "approve"}, 5*time.Second)
+	if err != nil {
+		t.Fatalf("push card callback: %v", err)
+	}
+	if gotBotID != 7 || gotReq == nil || gotReq.UserId != "staff-1" || approvalCardAction(gotReq) != model.ApprovalActionApprove {
+		t.Fatalf("callback routed with bot=%d req=%#v", gotBotID, gotReq)
+	}
+	if resp == nil || resp.CardData == nil || resp.CardData.CardParamMap["status_text"] != "已通过" ||
+		resp.CardData.CardParamMap["approver"] != "张三" || !resp.CardUpdateOptions.UpdateCardDataByKey {
+		t.Fatalf("card response = %#v", resp)
+	}
+}
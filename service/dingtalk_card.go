@@ -0,0 +1,123 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+// DingTalkCardDelivery 互动卡片投放参数：同一张卡片可同时投放到多个单聊和群聊
+// 卡片回调走 Stream 通道，机器人必须是 Stream 模式
+type DingTalkCardDelivery struct {
+	TemplateID string            // 开发者后台创建的卡片模板ID
+	OutTrackID string            // 卡片实例唯一标识，回调和更新都靠它定位
+	Mobiles    []string          // 单聊接收人手机号
+	GroupIDs   []string          // 群聊 openConversationId
+	Params     map[string]string // 卡片公共数据
+}
+
+// CreateAndDeliverCard 创建并投放互动卡片
+// API: https://open.dingtalk.com/document/orgapp/create-and-deliver-cards
+func (s *DingTalkService) CreateAndDeliverCard(bot *model.DingTalkBot, d *DingTalkCardDelivery) error {
+	if bot.BotType != "stream" || bot.RobotCode == "" {
+		return errors.New("interactive card requires a stream bot with robotCode")
+	}
+	if strings.TrimSpace(d.TemplateID) == "" || d.OutTrackID == "" {
+		return errors.New("card template id and outTrackId are required")
+	}
+	if len(d.Mobiles) == 0 && len(d.GroupIDs) == 0 {
+		return errors.New("no card receivers")
+	}
+
+	accessToken, err := s.getStreamAccessToken(bot.ClientID, bot.ClientSecret)
+	if err != nil {
+		return fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	var spaces []string
+	for _, mobile := range d.Mobiles {
+		userID, err := s.GetUserIdByMobile(mobile, accessToken)
+		if err != nil {
+			return fmt.Errorf("failed to get userId by mobile %s: %w", mobile, err)
+		}
+		spaces = append(spaces, "IM_ROBOT."+userID)
+	}
+	for _, groupID := range d.GroupIDs {
+		spaces = append(spaces, "IM_GROUP."+groupID)
+	}
+
+	reqBody := map[string]interface{}{
+		"cardTemplateId": strings.TrimSpace(d.TemplateID),
+		"outTrackId":     d.OutTrackID,
+		"callbackType":   "STREAM",
+		"cardData":       map[string]interface{}{"cardParamMap": d.Params},
+		"userIdType":     1,
+		"openSpaceId":    "dtv1.card//" + strings.Join(spaces, ";"),
+	}
+	if len(d.Mobiles) > 0 {
+		reqBody["imRobotOpenSpaceModel"] = map[string]interface{}{"supportForward": false}
+		reqBody["imRobotOpenDeliverModel"] = map[string]interface{}{"spaceType": "IM_ROBOT", "robotCode": bot.RobotCode}
+	}
+	if len(d.GroupIDs) > 0 {
+		reqBody["imGroupOpenSpaceModel"] = map[string]interface{}{"supportForward": false}
+		reqBody["imGroupOpenDeliverModel"] = map[string]interface{}{"robotCode": bot.RobotCode}
+	}
+	return s.callCardAPI(http.MethodPost, "/v1.0/card/instances/createAndDeliver", accessToken, reqBody)
+}
+
+// UpdateCard 按 outTrackId 更新卡片公共数据，只覆盖传入的字段
+// API: https://open.dingtalk.com/document/orgapp/interactive-card-update-interface
+func (s *DingTalkService) UpdateCard(bot *model.DingTalkBot, outTrackID string, params map[string]string) error {
+	accessToken, err := s.getStreamAccessToken(bot.ClientID, bot.ClientSecret)
+	if err != nil {
+		return fmt.Errorf("failed to get access token: %w", err)
+	}
+	reqBody := map[string]interface{}{
+		"outTrackId":        outTrackID,
+		"cardData":          map[string]interface{}{"cardParamMap": params},
+		"cardUpdateOptions": map[string]interface{}{"updateCardDataByKey": true},
+	}
+	return s.callCardAPI(http.MethodPut, "/v1.0/card/instances", accessToken, reqBody)
+}
+
+// callCardAPI 调用卡片接口；新版接口出错时返回 HTTP 非 2xx 和 code/message
+func (s *DingTalkService) callCardAPI(method, path, accessToken string, reqBody map[string]interface{}) error {
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequest(method, dingTalkAPIHost+path, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-acs-dingtalk-access-token", accessToken)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	var result map[string]interface{}
+	_ = json.Unmarshal(body, &result)
+	if errCode, ok := result["code"].(string); ok && errCode != "" {
+		return fmt.Errorf("dingtalk api error: code=%v, msg=%v", errCode, result["message"])
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("dingtalk api error: status=%d, body=%s", resp.StatusCode, string(body))
+	}
+	return nil
+}
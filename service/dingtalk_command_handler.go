@@ -2,23 +2,35 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Kevin-Jii/tower-go/model"
 	"github.com/Kevin-Jii/tower-go/module"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
 	"github.com/Kevin-Jii/tower-go/utils/businessdate"
+	"github.com/Kevin-Jii/tower-go/utils/logging"
 	"github.com/open-dingtalk/dingtalk-stream-sdk-go/chatbot"
+	"go.uber.org/zap"
 )
 
 // DingTalkCommandHandler 钉钉命令处理器
 type DingTalkCommandHandler struct {
-	inventoryModule    *module.InventoryModule
-	storeAccountModule *module.StoreAccountModule
-	storeModule        *module.StoreModule
-	userModule         *module.UserModule
-	templateService    *MessageTemplateService
+	inventoryModule      *module.InventoryModule
+	storeAccountModule   *module.StoreAccountModule
+	storeModule          *module.StoreModule
+	userModule           *module.UserModule
+	templateService      *MessageTemplateService
+	inventoryService     *InventoryService
+	purchaseOrderService *PurchaseOrderService
+	preOrderService      *PreOrderService
+	dingTalkService      *DingTalkService
+	router               *DingTalkCommandRouter
+	pending              *dingTalkPendingStore
 }
 
 var globalCommandHandler *DingTalkCommandHandler
@@ -30,14 +42,26 @@ func InitCommandHandler(
 	storeModule *module.StoreModule,
 	userModule *module.UserModule,
 	templateService *MessageTemplateService,
+	inventoryService *InventoryService,
+	purchaseOrderService *PurchaseOrderService,
+	preOrderService *PreOrderService,
+	dingTalkService *DingTalkService,
 ) {
-	globalCommandHandler = &DingTalkCommandHandler{
-		inventoryModule:    inventoryModule,
-		storeAccountModule: storeAccountModule,
-		storeModule:        storeModule,
-		userModule:         userModule,
-		templateService:    templateService,
-	}
+	h := &DingTalkCommandHandler{
+		inventoryModule:      inventoryModule,
+		storeAccountModule:   storeAccountModule,
+		storeModule:          storeModule,
+		userModule:           userModule,
+		templateService:      templateService,
+		inventoryService:     inventoryService,
+		purchaseOrderService: purchaseOrderService,
+		preOrderService:      preOrderService,
+		dingTalkService:      dingTalkService,
+		router:               NewDingTalkCommandRouter(),
+		pending:              newDingTalkPendingStore(),
+	}
+	h.registerCommands()
+	globalCommandHandler = h
 }
 
 // GetCommandHandler 获取命令处理器
@@ -45,62 +69,426 @@ func GetCommandHandler() *DingTalkCommandHandler {
 	return globalCommandHandler
 }
 
-// HandleCommand 处理用户命令
-func (h *DingTalkCommandHandler) HandleCommand(ctx context.Context, data *chatbot.BotCallbackDataModel) (string, string) {
+// registerCommands 注册机器人命令；权限码与后台对应接口一致
+func (h *DingTalkCommandHandler) registerCommands() {
+	h.router.Register(&DingTalkCommand{
+		Name: "帮助", Aliases: []string{"菜单", "help"}, Usage: "帮助", Description: "查看可用命令",
+		AllowUnbound: true, Handler: h.handleHelp,
+	})
+	h.router.Register(&DingTalkCommand{
+		Name: "绑定", Usage: "绑定 手机号", Description: "绑定系统账号（手机号需与钉钉通讯录一致）",
+		AllowUnbound: true, MinArgs: 1, Handler: h.handleBind,
+	})
+	h.router.Register(&DingTalkCommand{
+		Name: "库存查询", Aliases: []string{"查库存"}, Usage: "库存查询", Description: "查看本店库存",
+		Permission: "inventory:list", Handler: func(c *DingTalkCommandContext) *DingTalkCommandReply {
+			return dingTalkReply(h.handleInventoryQuery(c.StoreID))
+		},
+	})
+	h.router.Register(&DingTalkCommand{
+		Name: "查询库存", Usage: "查询库存 商品名", Description: "按商品名搜索库存",
+		Permission: "inventory:list", MinArgs: 1, Handler: func(c *DingTalkCommandContext) *DingTalkCommandReply {
+			return dingTalkReply(h.handleInventorySearch(c.StoreID, strings.Join(c.Args, " ")))
+		},
+	})
+	h.router.Register(&DingTalkCommand{
+		Name: "今日记账", Aliases: []string{"记账查询"}, Usage: "今日记账", Description: "今日记账汇总",
+		Permission: "store:account:list", Handler: func(c *DingTalkCommandContext) *DingTalkCommandReply {
+			return dingTalkReply(h.handleTodayAccount(c.StoreID))
+		},
+	})
+	h.router.Register(&DingTalkCommand{
+		Name: "今日入库", Aliases: []string{"入库查询"}, Usage: "今日入库", Description: "今日入库汇总",
+		Permission: "inventory:record", Handler: func(c *DingTalkCommandContext) *DingTalkCommandReply {
+			return dingTalkReply(h.handleTodayInventoryIn(c.StoreID))
+		},
+	})
+	h.router.Register(&DingTalkCommand{
+		Name: "入库", Usage: "入库 商品名 数量[单位]", Description: "快捷入库，如：入库 青岛啤酒 5箱",
+		Permission: "inventory:in", MinArgs: 2, Handler: h.handleQuickInbound,
+	})
+	h.router.Register(&DingTalkCommand{
+		Name: "确认采购", Usage: "确认采购 采购单号", Description: "确认待确认的采购单",
+		Permission: "purchase:edit", MinArgs: 1, Handler: h.handleConfirmPurchase,
+	})
+	h.router.Register(&DingTalkCommand{
+		Name: "预订送达", Usage: "预订送达 预订单号", Description: "将已备货的预订单标记为已配送",
+		Permission: "preorder:edit", MinArgs: 1, Handler: h.handlePreOrderDelivered,
+	})
+	h.router.Register(&DingTalkCommand{
+		Name: "确认", Usage: "确认", Description: "执行待确认的操作", Handler: h.handleConfirm,
+	})
+	h.router.Register(&DingTalkCommand{
+		Name: "取消", Usage: "取消", Description: "放弃待确认的操作", Handler: h.handleCancel,
+	})
+}
+
+// HandleCommand 处理用户命令：解析命令与参数，校验账号绑定与权限；写操作先回复预览，确认后执行
+func (h *DingTalkCommandHandler) HandleCommand(ctx context.Context, botID uint, data *chatbot.BotCallbackDataModel) (string, string) {
 	if h == nil {
 		return "系统提示", "命令处理器未初始化"
 	}
 
-	content := strings.TrimSpace(data.Text.Content)
+	c := &DingTalkCommandContext{
+		Ctx:        ctx,
+		BotID:      botID,
+		StaffID:    data.SenderStaffId,
+		SenderNick: data.SenderNick,
+	}
+	if user := h.resolveUser(data.SenderStaffId); user != nil {
+		c.User = user
+		c.StoreID = user.StoreID
+		c.HQUnbound = model.HQUnboundAdminRole(dingTalkUserRoleCode(user), user.StoreID)
+	}
 
-	// 获取用户所属门店
-	storeID := h.getStoreIDByStaffId(data.SenderStaffId)
+	reply := h.dispatch(c, strings.TrimSpace(data.Text.Content))
+	return reply.Title, reply.Text
+}
 
-	switch {
-	case content == "帮助" || content == "菜单" || content == "help":
-		return h.handleHelp()
+func (h *DingTalkCommandHandler) dispatch(c *DingTalkCommandContext, content string) *DingTalkCommandReply {
+	cmd, args := h.router.Match(content)
+	if cmd == nil {
+		if c.User == nil {
+			return h.bindingRequired()
+		}
+		return dingTalkReply(h.handleUnknown(content))
+	}
+	if c.User == nil && !cmd.AllowUnbound {
+		return h.bindingRequired()
+	}
+	if c.User != nil && !h.hasPermission(c.User, cmd.Permission) {
+		return dingTalkReply("🚫 无权限", fmt.Sprintf("您的账号没有「%s」的权限，请联系管理员开通", cmd.Name))
+	}
+	if len(args) < cmd.MinArgs {
+		return dingTalkReply("⚠️ 参数不足", fmt.Sprintf("用法：**%s**\n\n%s", cmd.Usage, cmd.Description))
+	}
+	c.Args = args
 
-	case content == "库存查询" || content == "查库存":
-		return h.handleInventoryQuery(storeID)
+	reply := cmd.Handler(c)
+	if reply.Pending != nil {
+		h.pending.Put(c.StaffID, reply.Pending, time.Now())
+		reply.Text = fmt.Sprintf("%s\n\n---\n回复 **确认** 执行，回复 **取消** 放弃（%d 分钟内有效）",
+			reply.Pending.Summary, int(dingTalkPendingTTL/time.Minute))
+	}
+	return reply
+}
 
-	case content == "今日记账" || content == "记账查询":
-		return h.handleTodayAccount(storeID)
+// resolveUser 按钉钉用户ID查找已绑定且启用的系统账号，未绑定返回 nil（不再回退到默认门店）
+func (h *DingTalkCommandHandler) resolveUser(staffID string) *model.User {
+	return resolveDingTalkOperator(h.userModule, staffID)
+}
 
-	case content == "今日入库" || content == "入库查询":
-		return h.handleTodayInventoryIn(storeID)
+// hasPermission 按发送者角色校验权限码，规则与后台接口的 Permission 中间件一致
+func (h *DingTalkCommandHandler) hasPermission(user *model.User, code string) bool {
+	return dingTalkUserHasPermission(user, code)
+}
 
-	case strings.HasPrefix(content, "查询库存 "):
-		keyword := strings.TrimPrefix(content, "查询库存 ")
-		return h.handleInventorySearch(storeID, keyword)
+// resolveDingTalkOperator 钉钉命令与卡片回调共用：按钉钉用户ID找到已绑定且启用的系统账号
+func resolveDingTalkOperator(userModule *module.UserModule, staffID string) *model.User {
+	if userModule == nil || staffID == "" {
+		return nil
+	}
+	bound, err := userModule.GetByDingTalkID(staffID)
+	if err != nil {
+		return nil
+	}
+	user, err := userModule.GetByID(bound.ID)
+	if err != nil || user.Status != 1 {
+		return nil
+	}
+	return user
+}
 
-	default:
-		return h.handleUnknown(content)
+// dingTalkUserHasPermission 钉钉侧操作的权限校验，总部未绑定门店的管理员直接放行
+func dingTalkUserHasPermission(user *model.User, code string) bool {
+	if code == "" {
+		return true
 	}
+	roleCode := dingTalkUserRoleCode(user)
+	if model.HQUnboundAdminRole(roleCode, user.StoreID) {
+		return true
+	}
+	perms, err := GetUserPermissionCodes(user.ID, user.StoreID, user.RoleID, roleCode)
+	if err != nil {
+		logging.LogWarn("钉钉操作权限加载失败", zap.Uint("user_id", user.ID), zap.Error(err))
+		return false
+	}
+	for _, p := range perms {
+		if p == code {
+			return true
+		}
+	}
+	return false
 }
 
-// getStoreIDByStaffId 根据钉钉用户ID获取门店ID
-func (h *DingTalkCommandHandler) getStoreIDByStaffId(staffId string) uint {
-	if h.userModule == nil {
-		return 999 // 默认总部
+func dingTalkUserRoleCode(user *model.User) string {
+	if user.Role == nil {
+		return ""
 	}
-	// 通过钉钉用户ID查找用户
-	user, err := h.userModule.GetByDingTalkID(staffId)
-	if err != nil || user == nil {
-		return 999 // 默认总部
+	return user.Role.Code
+}
+
+func dingTalkReply(title, text string) *DingTalkCommandReply {
+	return &DingTalkCommandReply{Title: title, Text: text}
+}
+
+// dingTalkErrorText 业务错误只展示提示信息，不带错误码
+func dingTalkErrorText(err error) string {
+	var codeErr *apicode.ErrorCode
+	if errors.As(err, &codeErr) {
+		return codeErr.Code.Msg
+	}
+	var code apicode.Code
+	if errors.As(err, &code) {
+		return code.Msg
+	}
+	return err.Error()
+}
+
+func (h *DingTalkCommandHandler) bindingRequired() *DingTalkCommandReply {
+	return dingTalkReply("🔗 请先绑定账号",
+		"您的钉钉账号尚未绑定系统账号，暂时无法使用机器人命令。\n\n请发送：**绑定 手机号**\n\n手机号需与系统账号及钉钉通讯录中的手机号一致。")
+}
+
+// handleBind 绑定系统账号：手机号须对应启用的系统账号，且在钉钉通讯录中属于发送者本人
+func (h *DingTalkCommandHandler) handleBind(c *DingTalkCommandContext) *DingTalkCommandReply {
+	const title = "🔗 绑定账号"
+	mobile := c.Args[0]
+	if !dingTalkMobilePattern.MatchString(mobile) {
+		return dingTalkReply(title, "手机号格式不正确，请发送：**绑定 手机号**")
+	}
+	if c.User != nil && c.User.Phone == mobile {
+		return dingTalkReply(title, fmt.Sprintf("当前钉钉账号已绑定 %s", dingTalkUserDisplay(c.User)))
+	}
+	if h.userModule == nil || h.dingTalkService == nil {
+		return dingTalkReply(title, "绑定服务未初始化")
+	}
+	user, err := h.userModule.GetByPhone(mobile)
+	if err != nil {
+		return dingTalkReply(title, "系统中没有使用该手机号的账号，请联系管理员")
+	}
+	if user.Status != 1 {
+		return dingTalkReply(title, "该账号已停用，请联系管理员")
+	}
+	if err := h.dingTalkService.BindStaffMobile(c.BotID, c.StaffID, c.SenderNick, mobile); err != nil {
+		logging.LogWarn("钉钉账号绑定失败", zap.String("staff_id", c.StaffID), zap.Uint("bot_id", c.BotID), zap.Error(err))
+		return dingTalkReply(title, fmt.Sprintf("绑定失败：%s", err.Error()))
+	}
+	return dingTalkReply("✅ 绑定成功", fmt.Sprintf("已绑定 %s\n\n发送 **帮助** 查看可用命令", dingTalkUserDisplay(user)))
+}
+
+func dingTalkUserDisplay(user *model.User) string {
+	name := user.Nickname
+	if name == "" {
+		name = user.Username
+	}
+	if user.Store != nil && user.Store.Name != "" {
+		return fmt.Sprintf("%s（%s）", name, user.Store.Name)
+	}
+	return name
+}
+
+// handleConfirm 执行待确认的写操作
+func (h *DingTalkCommandHandler) handleConfirm(c *DingTalkCommandContext) *DingTalkCommandReply {
+	action := h.pending.Take(c.StaffID, time.Now())
+	if action == nil {
+		return dingTalkReply("确认", "没有待确认的操作，或已超时，请重新发送命令")
+	}
+	return dingTalkReply(action.Execute())
+}
+
+// handleCancel 放弃待确认的写操作
+func (h *DingTalkCommandHandler) handleCancel(c *DingTalkCommandContext) *DingTalkCommandReply {
+	if h.pending.Take(c.StaffID, time.Now()) == nil {
+		return dingTalkReply("取消", "没有待确认的操作")
 	}
-	return user.StoreID
+	return dingTalkReply("取消", "已取消")
 }
 
-// handleHelp 帮助菜单
-func (h *DingTalkCommandHandler) handleHelp() (string, string) {
+// handleHelp 帮助菜单：只列出发送者可以使用的命令
+func (h *DingTalkCommandHandler) handleHelp(c *DingTalkCommandContext) *DingTalkCommandReply {
+	var lines []string
+	for _, cmd := range h.router.Commands() {
+		if c.User == nil && !cmd.AllowUnbound {
+			continue
+		}
+		if c.User != nil && !h.hasPermission(c.User, cmd.Permission) {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("- **%s**：%s", cmd.Usage, cmd.Description))
+	}
+	commandList := strings.Join(lines, "\n")
+	if c.User == nil {
+		return dingTalkReply("📋 功能菜单", commandList+"\n\n绑定系统账号后可使用更多命令")
+	}
+
 	if h.templateService != nil {
-		title, text, err := h.templateService.RenderTemplate(model.TemplateBotHelp, nil)
+		title, text, err := h.templateService.RenderTemplate(model.TemplateBotHelp, map[string]interface{}{"CommandList": commandList})
 		if err == nil && text != "" {
-			return title, text
+			return dingTalkReply(title, text)
 		}
 	}
 	// 默认回复
-	return "📋 功能菜单", "发送 库存查询、今日记账、今日入库 等命令"
+	return dingTalkReply("📋 功能菜单", commandList+"\n\n写操作会先回复预览，回复 **确认** 后才执行")
+}
+
+// handleQuickInbound 快捷入库：按本店库存中的商品名匹配，确认后生成采购入库单
+func (h *DingTalkCommandHandler) handleQuickInbound(c *DingTalkCommandContext) *DingTalkCommandReply {
+	const title = "📦 快捷入库"
+	if c.StoreID == 0 {
+		return dingTalkReply(title, "当前账号未绑定门店，不能入库")
+	}
+	if h.inventoryService == nil || h.inventoryModule == nil {
+		return dingTalkReply(title, "库存模块未初始化")
+	}
+	quantity, unit, err := parseDingTalkQuantity(c.Args[1:])
+	if err != nil {
+		return dingTalkReply(title, err.Error()+"\n\n用法：**入库 商品名 数量[单位]**，如：入库 青岛啤酒 5箱")
+	}
+
+	list, _, err := h.inventoryModule.List(&model.ListInventoryReq{StoreID: c.StoreID, Keyword: c.Args[0], Page: 1, PageSize: 10})
+	if err != nil {
+		return dingTalkReply(title, fmt.Sprintf("查询商品失败: %v", err))
+	}
+	product, candidates := pickDingTalkInventoryProduct(list, c.Args[0])
+	if product == nil {
+		if len(candidates) == 0 {
+			return dingTalkReply(title, fmt.Sprintf("本店库存中未找到「%s」", c.Args[0]))
+		}
+		return dingTalkReply(title, fmt.Sprintf("「%s」匹配到多个商品，请输入完整名称：\n\n- %s", c.Args[0], strings.Join(candidates, "\n- ")))
+	}
+	if unit == "" {
+		unit = product.Unit
+	}
+
+	storeID, operatorID := c.StoreID, c.User.ID
+	req := &model.CreateInventoryOrderReq{
+		Type:   model.InventoryTypeIn,
+		Reason: model.ReasonPurchase,
+		Remark: "钉钉机器人快捷入库",
+		Items:  []model.CreateInventoryOrderItemReq{{ProductID: product.ProductID, Quantity: quantity, Unit: unit}},
+	}
+	return &DingTalkCommandReply{
+		Title: title,
+		Pending: &DingTalkPendingAction{
+			Summary: fmt.Sprintf("即将入库：**%s** × %g%s\n\n当前库存：%.2f%s", product.ProductName, quantity, unit, product.Quantity, product.Unit),
+			Execute: func() (string, string) {
+				order, err := h.inventoryService.CreateOrder(storeID, operatorID, req)
+				if err != nil {
+					return title, fmt.Sprintf("入库失败：%s", dingTalkErrorText(err))
+				}
+				return "✅ 入库成功", fmt.Sprintf("入库单号：%s\n\n%s 入库 %.2f%s", order.OrderNo, product.ProductName, order.TotalQuantity, product.Unit)
+			},
+		},
+	}
+}
+
+// handleConfirmPurchase 确认采购单
+func (h *DingTalkCommandHandler) handleConfirmPurchase(c *DingTalkCommandContext) *DingTalkCommandReply {
+	const title = "🛒 确认采购"
+	if h.purchaseOrderService == nil {
+		return dingTalkReply(title, "采购模块未初始化")
+	}
+	order, err := h.purchaseOrderService.GetOrderByNoScoped(c.Args[0], c.StoreID, c.HQUnbound)
+	if err != nil {
+		return dingTalkReply(title, fmt.Sprintf("采购单 %s：%s", c.Args[0], dingTalkErrorText(err)))
+	}
+	if order.Status != model.PurchaseStatusPending {
+		return dingTalkReply(title, fmt.Sprintf("采购单 %s 不是待确认状态", order.OrderNo))
+	}
+	storeName := ""
+	if order.Store != nil {
+		storeName = order.Store.Name
+	}
+
+	storeID, hqUnbound := c.StoreID, c.HQUnbound
+	return &DingTalkCommandReply{
+		Title: title,
+		Pending: &DingTalkPendingAction{
+			Summary: fmt.Sprintf("即将确认采购单：**%s**\n\n- 门店：%s\n- 金额：¥%.2f", order.OrderNo, storeName, order.TotalAmount),
+			Execute: func() (string, string) {
+				if err := h.purchaseOrderService.ConfirmOrderScoped(order.ID, storeID, hqUnbound); err != nil {
+					return title, fmt.Sprintf("确认失败：%s", dingTalkErrorText(err))
+				}
+				return "✅ 采购单已确认", fmt.Sprintf("采购单 %s 已确认", order.OrderNo)
+			},
+		},
+	}
+}
+
+// handlePreOrderDelivered 预订单标记为已配送
+func (h *DingTalkCommandHandler) handlePreOrderDelivered(c *DingTalkCommandContext) *DingTalkCommandReply {
+	const title = "🚚 预订送达"
+	if h.preOrderService == nil {
+		return dingTalkReply(title, "预订单模块未初始化")
+	}
+	order, err := h.preOrderService.GetByOrderNo(c.Args[0], c.StoreID, c.HQUnbound)
+	if err != nil {
+		return dingTalkReply(title, fmt.Sprintf("预订单 %s：%s", c.Args[0], dingTalkErrorText(err)))
+	}
+	if order.Status == model.PreOrderStatusDelivered {
+		return dingTalkReply(title, fmt.Sprintf("预订单 %s 已是已配送状态", order.OrderNo))
+	}
+	if !preOrderStatusTransitionAllowed(order.Status, model.PreOrderStatusDelivered) {
+		return dingTalkReply(title, fmt.Sprintf("预订单 %s 尚未备货或已取消，不能标记送达", order.OrderNo))
+	}
+
+	storeID, hqUnbound := c.StoreID, c.HQUnbound
+	return &DingTalkCommandReply{
+		Title: title,
+		Pending: &DingTalkPendingAction{
+			Summary: fmt.Sprintf("即将标记预订单已送达：**%s**\n\n- 客户：%s\n- 配送时间：%s",
+				order.OrderNo, order.CustomerName, order.ScheduledAt.In(preOrderLocation).Format("2006-01-02 15:04")),
+			Execute: func() (string, string) {
+				if _, err := h.preOrderService.UpdateStatus(order.ID, storeID, hqUnbound, model.PreOrderStatusDelivered); err != nil {
+					return title, fmt.Sprintf("操作失败：%s", dingTalkErrorText(err))
+				}
+				return "✅ 已送达", fmt.Sprintf("预订单 %s 已标记为已配送", order.OrderNo)
+			},
+		},
+	}
+}
+
+var (
+	dingTalkMobilePattern   = regexp.MustCompile(`^1\d{10}$`)
+	dingTalkQuantityPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)(\S*)$`)
+)
+
+// parseDingTalkQuantity 解析 "5箱"、"5 箱"、"2.5" 形式的数量与单位
+func parseDingTalkQuantity(args []string) (float64, string, error) {
+	if len(args) == 0 {
+		return 0, "", errors.New("请填写数量")
+	}
+	m := dingTalkQuantityPattern.FindStringSubmatch(args[0])
+	if m == nil {
+		return 0, "", fmt.Errorf("数量「%s」格式不正确", args[0])
+	}
+	quantity, err := strconv.ParseFloat(m[1], 64)
+	if err != nil || quantity <= 0 {
+		return 0, "", fmt.Errorf("数量「%s」必须大于 0", args[0])
+	}
+	unit := m[2]
+	if unit == "" && len(args) > 1 {
+		unit = args[1]
+	}
+	return quantity, unit, nil
+}
+
+// pickDingTalkInventoryProduct 从搜索结果中选出商品：名称完全一致优先，只有一个结果时直接使用，否则返回候选名称
+func pickDingTalkInventoryProduct(list []*model.InventoryWithProduct, name string) (*model.InventoryWithProduct, []string) {
+	for _, item := range list {
+		if item.ProductName == name {
+			return item, nil
+		}
+	}
+	if len(list) == 1 {
+		return list[0], nil
+	}
+	candidates := make([]string, 0, len(list))
+	for _, item := range list {
+		candidates = append(candidates, item.ProductName)
+	}
+	return nil, candidates
 }
 
 // handleInventoryQuery 库存查询
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+// dingTalkPendingTTL 写操作预览后等待确认的有效期
+const dingTalkPendingTTL = 5 * time.Minute
+
+// DingTalkCommandContext 一次命令调用的上下文
+type DingTalkCommandContext struct {
+	Ctx        context.Context
+	BotID      uint
+	StaffID    string
+	SenderNick string
+	User       *model.User // 未绑定系统账号时为 nil
+	StoreID    uint
+	HQUnbound  bool
+	Args       []string
+}
+
+// DingTalkCommandReply 命令回复；Pending 非空表示写操作，需发送者回复「确认」后才执行
+type DingTalkCommandReply struct {
+	Title   string
+	Text    string
+	Pending *DingTalkPendingAction
+}
+
+// DingTalkPendingAction 待确认的写操作
+type DingTalkPendingAction struct {
+	Summary string                      // 确认前展示的操作摘要
+	Execute func() (title, text string) // 确认后执行
+	expires time.Time
+}
+
+// DingTalkCommand 可注册的机器人命令
+type DingTalkCommand struct {
+	Name         string   // 命令词
+	Aliases      []string // 别名
+	Usage        string   // 用法，用于帮助菜单与参数错误提示
+	Description  string
+	Permission   string // 所需权限码，与后台接口一致；为空表示已绑定账号即可使用
+	AllowUnbound bool   // 未绑定系统账号也可使用（帮助、绑定）
+	MinArgs      int
+	Handler      func(c *DingTalkCommandContext) *DingTalkCommandReply
+}
+
+// DingTalkCommandRouter 命令路由：按首个词匹配命令，其余为参数
+type DingTalkCommandRouter struct {
+	commands []*DingTalkCommand
+	index    map[string]*DingTalkCommand
+}
+
+func NewDingTalkCommandRouter() *DingTalkCommandRouter {
+	return &DingTalkCommandRouter{index: make(map[string]*DingTalkCommand)}
+}
+
+// Register 注册命令，命令词或别名重复时 panic（启动期即可发现配置错误）
+func (r *DingTalkCommandRouter) Register(cmd *DingTalkCommand) {
+	for _, word := range append([]string{cmd.Name}, cmd.Aliases...) {
+		if _, exists := r.index[word]; exists {
+			panic(fmt.Sprintf("dingtalk command %q registered twice", word))
+		}
+		r.index[word] = cmd
+	}
+	r.commands = append(r.commands, cmd)
+}
+
+// Match 解析消息，返回命中的命令与参数；未命中时 cmd 为 nil
+func (r *DingTalkCommandRouter) Match(content string) (*DingTalkCommand, []string) {
+	fields := parseDingTalkCommandArgs(content)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	cmd, ok := r.index[strings.ToLower(fields[0])]
+	if !ok {
+		return nil, nil
+	}
+	return cmd, fields[1:]
+}
+
+// Commands 已注册命令（注册顺序）
+func (r *DingTalkCommandRouter) Commands() []*DingTalkCommand {
+	return r.commands
+}
+
+// parseDingTalkCommandArgs 按空白（含全角空格）切分消息
+func parseDingTalkCommandArgs(content string) []string {
+	content = strings.ReplaceAll(content, "　", " ")
+	return strings.Fields(content)
+}
+
+// dingTalkPendingStore 按钉钉用户保存待确认的写操作；每人同时只保留最近一条。
+// 只保存在内存中，服务重启后未确认的操作失效，需重新发送命令。
+type dingTalkPendingStore struct {
+	mu      sync.Mutex
+	actions map[string]*DingTalkPendingAction
+}
+
+func newDingTalkPendingStore() *dingTalkPendingStore {
+	return &dingTalkPendingStore{actions: make(map[string]*DingTalkPendingAction)}
+}
+
+func (p *dingTalkPendingStore) Put(staffID string, action *DingTalkPendingAction, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	action.expires = now.Add(dingTalkPendingTTL)
+	p.actions[staffID] = action
+	for id, a := range p.actions {
+		if now.After(a.expires) {
+			delete(p.actions, id)
+		}
+	}
+}
+
+// Take 取出并移除待确认操作，已过期返回 nil
+func (p *dingTalkPendingStore) Take(staffID string, now time.Time) *DingTalkPendingAction {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	action, ok := p.actions[staffID]
+	if !ok {
+		return nil
+	}
+	delete(p.actions, staffID)
+	if now.After(action.expires) {
+		return nil
+	}
+	return action
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+func TestDingTalkCommandRouterMatch(t *testing.T) {
+	r := NewDingTalkCommandRouter()
+	r.Register(&DingTalkCommand{Name: "入库"})
+	r.Register(&DingTalkCommand{Name: "帮助", Aliases: []string{"help"}})
+
+	cmd, args := r.Match("  入库　青岛啤酒  5箱 ")
+	if cmd == nil || cmd.Name != "入库" {
+		t.Fatalf("cmd = %#v, want 入库", cmd)
+	}
+	if len(args) != 2 || args[0] != "青岛啤酒" || args[1] != "5箱" {
+		t.Fatalf("args = %q", args)
+	}
+	if cmd, _ := r.Match("HELP"); cmd == nil || cmd.Name != "帮助" {
+		t.Fatalf("alias should match case-insensitively, got %#v", cmd)
+	}
+	if cmd, _ := r.Match("入库单"); cmd != nil {
+		t.Fatalf("prefix without separator should not match, got %s", cmd.Name)
+	}
+	if cmd, _ := r.Match("   "); cmd != nil {
+		t.Fatalf("blank message should not match")
+	}
+}
+
+func TestDingTalkCommandRouterRejectsDuplicate(t *testing.T) {
+	r := NewDingTalkCommandRouter()
+	r.Register(&DingTalkCommand{Name: "确认"})
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("duplicate alias should panic")
+		}
+	}()
+	r.Register(&DingTalkCommand{Name: "确认采购", Aliases: []string{"确认"}})
+}
+
+func TestDingTalkPendingStoreExpires(t *testing.T) {
+	store := newDingTalkPendingStore()
+	now := time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+
+	store.Put("staff-1", &DingTalkPendingAction{Summary: "a"}, now)
+	if got := store.Take("staff-1", now.Add(time.Minute)); got == nil || got.Summary != "a" {
+		t.Fatalf("pending action = %#v, want a", got)
+	}
+	if got := store.Take("staff-1", now.Add(time.Minute)); got != nil {
+		t.Fatalf("action should be consumed once")
+	}
+
+	store.Put("staff-1", &DingTalkPendingAction{Summary: "b"}, now)
+	if got := store.Take("staff-1", now.Add(dingTalkPendingTTL+time.Second)); got != nil {
+		t.Fatalf("expired action should not be returned")
+	}
+}
+
+func TestParseDingTalkQuantity(t *testing.T) {
+	cases := []struct {
+		args     []string
+		quantity float64
+		unit     string
+	}{
+		{[]string{"5箱"}, 5, "箱"},
+		{[]string{"5", "箱"}, 5, "箱"},
+		{[]string{"2.5"}, 2.5, ""},
+	}
+	for _, tc := range cases {
+		quantity, unit, err := parseDingTalkQuantity(tc.args)
+		if err != nil || quantity != tc.quantity || unit != tc.unit {
+			t.Fatalf("parse %q = %v %q %v", tc.args, quantity, unit, err)
+		}
+	}
+	for _, bad := range [][]string{{"箱"}, {"0"}, {"-1"}} {
+		if _, _, err := parseDingTalkQuantity(bad); err == nil {
+			t.Fatalf("parse %q should fail", bad)
+		}
+	}
+}
+
+func TestPickDingTalkInventoryProduct(t *testing.T) {
+	list := []*model.InventoryWithProduct{{ProductName: "青岛啤酒纯生"}, {ProductName: "青岛啤酒"}}
+	if got, _ := pickDingTalkInventoryProduct(list, "青岛啤酒"); got == nil || got.ProductName != "青岛啤酒" {
+		t.Fatalf("exact name should win, got %#v", got)
+	}
+	if got, candidates := pickDingTalkInventoryProduct(list, "青岛"); got != nil || len(candidates) != 2 {
+		t.Fatalf("ambiguous keyword should list candidates, got %#v %q", got, candidates)
+	}
+}
+
+func TestDingTalkCommandDispatch(t *testing.T) {
+	h := &DingTalkCommandHandler{router: NewDingTalkCommandRouter(), pending: newDingTalkPendingStore()}
+	h.registerCommands()
+	executed := false
+	h.router.Register(&DingTalkCommand{Name: "测试写入", MinArgs: 1, Handler: func(c *DingTalkCommandContext) *DingTalkCommandReply {
+		return &DingTalkCommandReply{Title: "测试", Pending: &DingTalkPendingAction{
+			Summary: "即将写入 " + c.Args[0],
+			Execute: func() (string, string) { executed = true; return "完成", "已写入" },
+		}}
+	}})
+
+	unbound := &DingTalkCommandContext{StaffID: "staff-1"}
+	if reply := h.dispatch(unbound, "库存查询"); !strings.Contains(reply.Text, "绑定 手机号") {
+		t.Fatalf("unbound user should be asked to bind, got %q", reply.Text)
+	}
+	help := h.dispatch(unbound, "帮助")
+	if !strings.Contains(help.Text, "绑定 手机号") || strings.Contains(help.Text, "库存查询") {
+		t.Fatalf("unbound help should only list unbound commands, got %q", help.Text)
+	}
+
+	bound := &DingTalkCommandContext{StaffID: "staff-1", User: &model.User{ID: 1, StoreID: 1}}
+	if reply := h.dispatch(bound, "测试写入"); !strings.Contains(reply.Text, "用法") {
+		t.Fatalf("missing args should show usage, got %q", reply.Text)
+	}
+	preview := h.dispatch(bound, "测试写入 A")
+	if executed || !strings.Contains(preview.Text, "即将写入 A") || !strings.Contains(preview.Text, "确认") {
+		t.Fatalf("write command should wait for confirmation, got %q", preview.Text)
+	}
+	if reply := h.dispatch(bound, "确认"); !executed || reply.Title != "完成" {
+		t.Fatalf("confirm should execute pending action, got %#v", reply)
+	}
+	if reply := h.dispatch(bound, "确认"); !strings.Contains(reply.Text, "没有待确认") {
+		t.Fatalf("second confirm should find nothing, got %q", reply.Text)
+	}
+}
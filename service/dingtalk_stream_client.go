@@ -9,6 +9,7 @@ import (
 	"github.com/Kevin-Jii/tower-go/model"
 	"github.com/Kevin-Jii/tower-go/utils/logging"
 
+	"github.com/open-dingtalk/dingtalk-stream-sdk-go/card"
 	"github.com/open-dingtalk/dingtalk-stream-sdk-go/chatbot"
 	"github.com/open-dingtalk/dingtalk-stream-sdk-go/client"
 	"github.com/open-dingtalk/dingtalk-stream-sdk-go/logger"
@@ -16,11 +17,15 @@ import (
 
 // DingTalkStreamClient Stream 模式客户端管理器
 type DingTalkStreamClient struct {
-	clients map[uint]*client.StreamClient // botID -> StreamClient
-	mu      sync.RWMutex
-	running bool
+	clients      map[uint]*client.StreamClient // botID -> StreamClient
+	mu           sync.RWMutex
+	running      bool
+	cardCallback DingTalkCardCallbackHandler
 }
 
+// DingTalkCardCallbackHandler 互动卡片回调处理，botID 为收到回调的机器人
+type DingTalkCardCallbackHandler func(ctx context.Context, botID uint, req *card.CardRequest) (*card.CardResponse, error)
+
 var (
 	globalStreamClient     *DingTalkStreamClient
 	globalStreamClientOnce sync.Once
@@ -63,10 +68,18 @@ func (sc *DingTalkStreamClient) StartBot(bot *model.DingTalkBot) error {
 		client.WithAppCredential(
 			client.NewAppCredentialConfig(bot.ClientID, bot.ClientSecret),
 		),
+		client.WithOpenApiHost(dingTalkAPIHost),
 	)
 
-	// 注册机器人消息回调(必须注册,否则连接会失败)
-	streamClient.RegisterChatBotCallbackRouter(sc.OnChatBotMessageReceived)
+	// 注册机器人消息回调(必须注册,否则连接会失败)；回调数据不含机器人标识，这里带上收到消息的机器人ID
+	botID := bot.ID
+	streamClient.RegisterChatBotCallbackRouter(func(ctx context.Context, data *chatbot.BotCallbackDataModel) ([]byte, error) {
+		return sc.OnChatBotMessageReceived(ctx, botID, data)
+	})
+	// 注册互动卡片回调（审批卡片按钮）
+	streamClient.RegisterCardCallbackRouter(func(ctx context.Context, req *card.CardRequest) (*card.CardResponse, error) {
+		return sc.OnCardCallback(ctx, botID, req)
+	})
 
 	// 启动客户端
 	go func() {
@@ -86,6 +99,13 @@ func (sc *DingTalkStreamClient) StartBot(bot *model.DingTalkBot) error {
 	return nil
 }
 
+// SetCardCallbackHandler 设置互动卡片回调处理，需在启动机器人前设置
+func (sc *DingTalkStreamClient) SetCardCallbackHandler(handler DingTalkCardCallbackHandler) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.cardCallback = handler
+}
+
 // StopBot 停止指定机器人的 Stream 连接
 func (sc *DingTalkStreamClient) StopBot(botID uint) error {
 	sc.mu.Lock()
@@ -143,11 +163,12 @@ func (sc *DingTalkStreamClient) GetBotCount() int {
 }
 
 // OnChatBotMessageReceived 处理机器人收到的消息回调
-// 符合 chatbot.IChatBotMessageHandler 接口：func(context.Context, *BotCallbackDataModel) ([]byte, error)
-func (sc *DingTalkStreamClient) OnChatBotMessageReceived(ctx context.Context, data *chatbot.BotCallbackDataModel) ([]byte, error) {
+// 由 StartBot 包装为 chatbot.IChatBotMessageHandler：func(context.Context, *BotCallbackDataModel) ([]byte, error)
+func (sc *DingTalkStreamClient) OnChatBotMessageReceived(ctx context.Context, botID uint, data *chatbot.BotCallbackDataModel) ([]byte, error) {
 	// 记录收到的消息
 	if logging.SugaredLogger != nil {
 		logging.SugaredLogger.Infow("📨 Received bot message",
+			"botID", botID,
 			"conversationId", data.ConversationId,
 			"senderStaffId", data.SenderStaffId,
 			"senderNick", data.SenderNick,
@@ -164,7 +185,7 @@ func (sc *DingTalkStreamClient) OnChatBotMessageReceived(ctx context.Context, da
 		handler := GetCommandHandler()
 		var title, content string
 		if handler != nil {
-			title, content = handler.HandleCommand(ctx, data)
+			title, content = handler.HandleCommand(ctx, botID, data)
 		} else {
 			// 命令处理器未初始化，使用默认回复
 			title = "消息已收到"
@@ -189,3 +210,22 @@ func (sc *DingTalkStreamClient) OnChatBotMessageReceived(ctx context.Context, da
 	// 返回空字节数组（SDK 要求）
 	return []byte(""), nil
 }
+
+// OnCardCallback 处理互动卡片按钮回调，转交给业务处理；未设置处理器时不更新卡片
+func (sc *DingTalkStreamClient) OnCardCallback(ctx context.Context, botID uint, req *card.CardRequest) (*card.CardResponse, error) {
+	sc.mu.RLock()
+	handler := sc.cardCallback
+	sc.mu.RUnlock()
+
+	if logging.SugaredLogger != nil {
+		logging.SugaredLogger.Infow("📨 Received card callback",
+			"botID", botID,
+			"outTrackId", req.OutTrackId,
+			"userId", req.UserId,
+		)
+	}
+	if handler == nil {
+		return &card.CardResponse{}, nil
+	}
+	return handler(ctx, botID, req)
+}
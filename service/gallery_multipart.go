@@ -525,7 +525,7 @@ func normalizeGalleryCategory(category string) (string, error) {
 		return "other", nil
 	}
 	switch category {
-	case "product", "supplier", "avatar", "purchase", "store-return", "other":
+	case "product", "supplier", "avatar", "purchase", "store-return", "member-wine", "other":
 		return category, nil
 	default:
 		return "", apicode.New(apicode.InvalidParameter.WithMessage("图库分类无效"))
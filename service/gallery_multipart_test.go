@@ -87,6 +87,9 @@ func TestNormalizeGalleryCategory(t *testing.T) {
 	if got, err := normalizeGalleryCategory("store-return"); err != nil || got != "store-return" {
 		t.Fatalf("store-return category = %q, %v", got, err)
 	}
+	if got, err := normalizeGalleryCategory("member-wine"); err != nil || got != "member-wine" {
+		t.Fatalf("member-wine category = %q, %v", got, err)
+	}
 	if _, err := normalizeGalleryCategory("../../escape"); !apicode.Is(err, apicode.InvalidParameter) {
 		t.Fatalf("invalid category error = %v", err)
 	}
@@ -23,8 +23,7 @@ type InventoryService struct {
 	userModule      *module.UserModule
 	storeModule     *module.StoreModule
 	productModule   *module.SupplierProductModule
-	dingTalkService *DingTalkService
-	botModule       *module.DingTalkBotModule
+	notifier        *NotificationService
 	templateService *MessageTemplateService
 }
 
@@ -34,8 +33,7 @@ func NewInventoryService(
 	userModule *module.UserModule,
 	storeModule *module.StoreModule,
 	productModule *module.SupplierProductModule,
-	dingTalkService *DingTalkService,
-	botModule *module.DingTalkBotModule,
+	notifier *NotificationService,
 	templateService *MessageTemplateService,
 ) *InventoryService {
 	return &InventoryService{
@@ -44,8 +42,7 @@ func NewInventoryService(
 		userModule:      userModule,
 		storeModule:     storeModule,
 		productModule:   productModule,
-		dingTalkService: dingTalkService,
-		botModule:       botModule,
+		notifier:        notifier,
 		templateService: templateService,
 	}
 }
@@ -146,57 +143,76 @@ func (s *InventoryService) CreateOrder(storeID, operatorID uint, req *model.Crea
 	if err := s.inventoryModule.CreateOrderWithStockApply(order); err != nil {
 		return nil, err
 	}
+	publishInventoryOrders(order)
 
-	// 异步发送钉钉通知（仅入库）
+	// 钉钉通知写入发件箱（仅入库）
 	if req.Type == model.InventoryTypeIn {
-		go s.sendDingTalkNotification(order, storeID)
+		s.enqueueDingTalkNotification(order, storeID)
 	}
 
 	return order, nil
 }
 
-// sendDingTalkNotification 发送入库通知到门店负责人
-func (s *InventoryService) sendDingTalkNotification(order *model.InventoryOrder, storeID uint) {
-	if s.dingTalkService == nil || s.storeModule == nil || s.botModule == nil {
-		return
+// inventoryOrderItemLines 入库明细文本，每项一行
+func inventoryOrderItemLines(order *model.InventoryOrder) []string {
+	lines := make([]string, 0, len(order.Items))
+	for i, item := range order.Items {
+		lines = append(lines, fmt.Sprintf("%d. %s x%.2f%s", i+1, item.ProductName, item.Quantity, item.Unit))
 	}
+	return lines
+}
 
-	// 获取门店信息
-	store, err := s.storeModule.GetByID(storeID)
-	if err != nil || store == nil {
-		if logging.SugaredLogger != nil {
-			logging.SugaredLogger.Warnw("Failed to get store for notification", "storeID", storeID, "error", err)
-		}
-		return
+// inventoryOrderTemplateData 入库通知模板数据
+func inventoryOrderTemplateData(order *model.InventoryOrder, storeName string) map[string]interface{} {
+	orderType := order.Reason
+	if orderType == "" {
+		orderType = "入库"
 	}
+	return map[string]interface{}{
+		"StoreName":    storeName,
+		"OrderNo":      order.OrderNo,
+		"OrderType":    orderType,
+		"OrderDate":    order.CreatedAt.Format("2006-01-02"),
+		"OperatorName": order.OperatorName,
+		"ItemList":     strings.Join(inventoryOrderItemLines(order), "\n\n"),
+		"TotalAmount":  fmt.Sprintf("%.2f", order.TotalQuantity),
+		"ItemCount":    order.ItemCount,
+		"CreateTime":   time.Now().Format("2006-01-02 15:04:05"),
+	}
+}
 
-	// 检查门店是否有联系电话
-	if store.Phone == "" {
-		if logging.SugaredLogger != nil {
-			logging.SugaredLogger.Warnw("Store has no phone, skip notification", "storeID", storeID)
+// NotificationTemplateData 按入库单构建通知模板数据，供模板预览使用
+func (s *InventoryService) NotificationTemplateData(id uint) (map[string]interface{}, error) {
+	order, err := s.inventoryModule.GetOrderByID(id)
+	if err != nil {
+		return nil, err
+	}
+	storeName := ""
+	if s.storeModule != nil {
+		if store, err := s.storeModule.GetByID(order.StoreID); err == nil && store != nil {
+			storeName = store.Name
 		}
-		return
 	}
+	return inventoryOrderTemplateData(order, storeName), nil
+}
 
-	// 获取门店绑定的机器人
-	bot, err := s.botModule.GetByStoreID(storeID)
-	if err != nil || bot == nil {
-		if logging.SugaredLogger != nil {
-			logging.SugaredLogger.Warnw("No bot found for store", "storeID", storeID, "error", err)
-		}
+// enqueueDingTalkNotification 生成入库通知，按通知路由写入发件箱
+func (s *InventoryService) enqueueDingTalkNotification(order *model.InventoryOrder, storeID uint) {
+	if s.notifier == nil || s.storeModule == nil {
 		return
 	}
 
-	if !bot.IsEnabled || bot.BotType != "stream" {
+	// 获取门店信息
+	store, err := s.storeModule.GetByID(storeID)
+	if err != nil || store == nil {
+		if logging.SugaredLogger != nil {
+			logging.SugaredLogger.Warnw("Failed to get store for notification", "storeID", storeID, "error", err)
+		}
 		return
 	}
 
 	// 构建商品明细
-	var itemLines []string
-	for i, item := range order.Items {
-		line := fmt.Sprintf("%d. %s x%.2f%s", i+1, item.ProductName, item.Quantity, item.Unit)
-		itemLines = append(itemLines, line)
-	}
+	itemLines := inventoryOrderItemLines(order)
 
 	// 入库类型显示
 	orderType := order.Reason
@@ -208,19 +224,8 @@ func (s *InventoryService) sendDingTalkNotification(order *model.InventoryOrder,
 
 	// 尝试使用模板
 	if s.templateService != nil {
-		data := map[string]interface{}{
-			"StoreName":    store.Name,
-			"OrderNo":      order.OrderNo,
-			"OrderType":    orderType,
-			"OrderDate":    order.CreatedAt.Format("2006-01-02"),
-			"OperatorName": order.OperatorName,
-			"ItemList":     strings.Join(itemLines, "\n\n"),
-			"TotalAmount":  fmt.Sprintf("%.2f", order.TotalQuantity),
-			"ItemCount":    order.ItemCount,
-			"CreateTime":   time.Now().Format("2006-01-02 15:04:05"),
-		}
 		var err error
-		title, text, err = s.templateService.RenderTemplate(model.TemplateInventoryCreated, data)
+		title, text, err = s.templateService.RenderTemplate(model.TemplateInventoryCreated, inventoryOrderTemplateData(order, store.Name))
 		if err != nil {
 			if logging.SugaredLogger != nil {
 				logging.SugaredLogger.Warnw("Failed to render template, using default", "error", err)
@@ -254,24 +259,17 @@ func (s *InventoryService) sendDingTalkNotification(order *model.InventoryOrder,
 		)
 	}
 
-	// 发送通知
-	if err := s.dingTalkService.SendStreamMarkdownToMobile(bot, title, text, store.Phone); err != nil {
-		if logging.SugaredLogger != nil {
-			logging.SugaredLogger.Errorw("Failed to send inventory notification",
-				"storeID", storeID,
-				"orderNo", order.OrderNo,
-				"error", err,
-			)
-		}
-	} else {
-		if logging.SugaredLogger != nil {
-			logging.SugaredLogger.Infow("Inventory notification sent",
-				"storeID", storeID,
-				"orderNo", order.OrderNo,
-				"mobile", store.Phone,
-			)
-		}
-	}
+	// 按通知路由写入发件箱，由后台任务投递并在失败时重试
+	_, _ = s.notifier.Notify(&model.NotificationEvent{
+		EventType: model.NotificationBizInventoryOrder,
+		StoreID:   storeID,
+		Channel:   order.Reason,
+	}, &model.NotificationOutbox{
+		BizID:   order.ID,
+		BizNo:   order.OrderNo,
+		Title:   title,
+		Content: text,
+	}, nil)
 }
 
 // GetOrderByNo 根据单号获取出入库单详情
@@ -324,9 +322,32 @@ func (s *InventoryService) ListOrders(ctx context.Context, req *model.ListInvent
 	return s.inventoryModule.ListOrders(req)
 }
 
-// UpdateInventory 修改库存数量
-func (s *InventoryService) UpdateInventory(id uint, quantity float64) error {
-	return s.inventoryModule.UpdateQuantity(id, quantity)
+// UpdateInventory 修改库存数量，差额以“直接修改”记入库存流水
+func (s *InventoryService) UpdateInventory(id uint, quantity float64, operatorID uint, remark string) error {
+	src := model.InventoryMovementSource{
+		Type:       model.MovementSourceManualAdjust,
+		ID:         id,
+		OperatorID: operatorID,
+		Remark:     strings.TrimSpace(remark),
+	}
+	if user, err := s.userModule.GetByID(operatorID); err == nil && user != nil {
+		src.OperatorName = user.Nickname
+		if src.OperatorName == "" {
+			src.OperatorName = user.Username
+		}
+	}
+	if err := s.inventoryModule.UpdateQuantity(id, quantity, src); err != nil {
+		return err
+	}
+	if inv, err := s.inventoryModule.GetByID(id); err == nil {
+		publishDataChanged(&model.DataChangedEvent{
+			Resource: model.RealtimeInventory,
+			Action:   model.RealtimeActionUpdated,
+			StoreID:  inv.StoreID,
+			ID:       inv.ID,
+		})
+	}
+	return nil
 }
 
 // GetInventoryByID 根据ID获取库存
@@ -350,3 +371,12 @@ func (s *InventoryService) GetInventoryByIDScoped(id, storeID uint, hqUnbound bo
 	}
 	return inv, nil
 }
+
+// GetInventoryLots 获取库存的批次明细（按到期日先到先出排序）
+func (s *InventoryService) GetInventoryLots(id, storeID uint, hqUnbound bool) (*model.InventoryLotBreakdown, error) {
+	inv, err := s.GetInventoryByIDScoped(id, storeID, hqUnbound)
+	if err != nil {
+		return nil, err
+	}
+	return s.inventoryModule.ListLotsByInventory(inv)
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/config"
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/module"
+	"github.com/Kevin-Jii/tower-go/utils/logging"
+	"go.uber.org/zap"
+)
+
+// expiryWarningMaxLines 单门店预警消息最多列出的批次数
+const expiryWarningMaxLines = 20
+
+// InventoryExpiryService 库存临期预警：统计临期/过期批次并按门店推送钉钉提醒
+type InventoryExpiryService struct {
+	statisticsModule *module.StatisticsModule
+	productModule    *module.SupplierProductModule
+	unitSpecModule   *module.ProductUnitSpecModule
+	storeModule      *module.StoreModule
+	notifier         *NotificationService
+}
+
+func NewInventoryExpiryService(
+	statisticsModule *module.StatisticsModule,
+	productModule *module.SupplierProductModule,
+	unitSpecModule *module.ProductUnitSpecModule,
+	storeModule *module.StoreModule,
+	notifier *NotificationService,
+) *InventoryExpiryService {
+	return &InventoryExpiryService{
+		statisticsModule: statisticsModule,
+		productModule:    productModule,
+		unitSpecModule:   unitSpecModule,
+		storeModule:      storeModule,
+		notifier:         notifier,
+	}
+}
+
+// GetExpiryStats 统计临期与已过期库存及其成本，days<=0 时使用配置的预警天数
+func (s *InventoryExpiryService) GetExpiryStats(storeID uint, days int, now time.Time) (*model.ExpiryStats, error) {
+	if days <= 0 {
+		days = config.GetInventoryConfig().ExpiryWarningDays
+	}
+	today := expiryDay(now)
+	cutoff := today.AddDate(0, 0, days)
+	rows, err := s.statisticsModule.ListExpiringLots(storeID, cutoff.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	s.fillLotCostPrices(rows)
+	return summarizeExpiringLots(rows, today, days), nil
+}
+
+// ProcessExpiryWarnings 扫描全部门店的临期/过期批次，按门店推送钉钉汇总
+func (s *InventoryExpiryService) ProcessExpiryWarnings(now time.Time) error {
+	stats, err := s.GetExpiryStats(0, 0, now)
+	if err != nil {
+		return err
+	}
+	var firstErr error
+	for i := range stats.Stores {
+		store := &stats.Stores[i]
+		if err := s.sendWarning(store, stats); err != nil {
+			logging.LogWarn("库存临期预警发送失败", zap.Uint("store_id", store.StoreID), zap.Error(err))
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// sendWarning 生成门店临期预警，按通知路由写入发件箱，金额门槛按临期与过期成本合计
+func (s *InventoryExpiryService) sendWarning(storeStats *model.ExpiryStoreStats, stats *model.ExpiryStats) error {
+	title := "库存临期预警"
+	text := buildExpiryWarningMarkdown(storeStats, stats.WarningDays)
+	count, err := s.notifier.Notify(&model.NotificationEvent{
+		EventType: model.NotificationBizInventoryExpiry,
+		StoreID:   storeStats.StoreID,
+		Amount:    storeStats.ExpiredCostAmount + storeStats.NearExpiryCostAmount,
+	}, &model.NotificationOutbox{
+		BizNo:   stats.Today,
+		Title:   title,
+		Content: text,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("no notification recipient for store %d", storeStats.StoreID)
+	}
+	return nil
+}
+
+// fillLotCostPrices 按基础单位规格成本价回填批次成本，未配置规格成本时回退商品价格
+func (s *InventoryExpiryService) fillLotCostPrices(rows []model.ExpiringLotRow) {
+	cache := make(map[string]float64)
+	for i := range rows {
+		key := fmt.Sprintf("%d|%s", rows[i].ProductID, rows[i].Unit)
+		if cost, ok := cache[key]; ok {
+			rows[i].CostPrice = cost
+			continue
+		}
+		specs, _ := s.unitSpecModule.ListEnabledByProductID(rows[i].ProductID)
+		cost := resolveUnitCostFromSpecs(rows[i].Unit, specs)
+		if cost <= 0 {
+			if product, err := s.productModule.GetByID(rows[i].ProductID); err == nil {
+				cost = resolveFallbackCostPrice(rows[i].Unit, product)
+			}
+		}
+		cache[key] = cost
+		rows[i].CostPrice = cost
+	}
+}
+
+func expiryDay(t time.Time) time.Time {
+	t = t.In(preOrderLocation)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, preOrderLocation)
+}
+
+// summarizeExpiringLots 按门店汇总临期/过期批次；rows 需按门店排序且已回填成本价
+func summarizeExpiringLots(rows []model.ExpiringLotRow, today time.Time, days int) *model.ExpiryStats {
+	stats := &model.ExpiryStats{
+		WarningDays: days,
+		Today:       today.Format("2006-01-02"),
+		Cutoff:      today.AddDate(0, 0, days).Format("2006-01-02"),
+		Stores:      []model.ExpiryStoreStats{},
+	}
+	index := make(map[uint]int)
+	for _, row := range rows {
+		pos, ok := index[row.StoreID]
+		if !ok {
+			stats.Stores = append(stats.Stores, model.ExpiryStoreStats{StoreID: row.StoreID, StoreName: row.StoreName})
+			pos = len(stats.Stores) - 1
+			index[row.StoreID] = pos
+		}
+		store := &stats.Stores[pos]
+
+		expiry := time.Date(row.ExpiryDate.Year(), row.ExpiryDate.Month(), row.ExpiryDate.Day(), 0, 0, 0, 0, today.Location())
+		daysLeft := int(expiry.Sub(today).Hours() / 24)
+		costAmount := roundMoney(row.CostPrice * row.Quantity)
+		item := model.ExpiryLotItem{
+			LotID:       row.LotID,
+			ProductID:   row.ProductID,
+			ProductName: row.ProductName,
+			ExpiryDate:  expiry.Format("2006-01-02"),
+			DaysLeft:    daysLeft,
+			Expired:     daysLeft < 0,
+			Quantity:    row.Quantity,
+			Unit:        row.Unit,
+			CostPrice:   row.CostPrice,
+			CostAmount:  costAmount,
+		}
+		store.Items = append(store.Items, item)
+		if item.Expired {
+			store.ExpiredQuantity = roundQuantity(store.ExpiredQuantity + row.Quantity)
+			store.ExpiredCostAmount = roundMoney(store.ExpiredCostAmount + costAmount)
+			stats.ExpiredQuantity = roundQuantity(stats.ExpiredQuantity + row.Quantity)
+			stats.ExpiredCostAmount = roundMoney(stats.ExpiredCostAmount + costAmount)
+		} else {
+			store.NearExpiryQuantity = roundQuantity(store.NearExpiryQuantity + row.Quantity)
+			store.NearExpiryCostAmount = roundMoney(store.NearExpiryCostAmount + costAmount)
+			stats.NearExpiryQuantity = roundQuantity(stats.NearExpiryQuantity + row.Quantity)
+			stats.NearExpiryCostAmount = roundMoney(stats.NearExpiryCostAmount + costAmount)
+		}
+	}
+	return stats
+}
+
+func buildExpiryWarningMarkdown(store *model.ExpiryStoreStats, warningDays int) string {
+	var b strings.Builder
+	b.WriteString("### 库存临期预警\n\n")
+	fmt.Fprintf(&b, "- **门店：** %s\n", store.StoreName)
+	if store.ExpiredQuantity > 0 {
+		fmt.Fprintf(&b, "- **已过期：** %g，成本 ¥%.2f\n", store.ExpiredQuantity, store.ExpiredCostAmount)
+	}
+	if store.NearExpiryQuantity > 0 {
+		fmt.Fprintf(&b, "- **%d 天内到期：** %g，成本 ¥%.2f\n", warningDays, store.NearExpiryQuantity, store.NearExpiryCostAmount)
+	}
+	b.WriteString("\n**批次明细**\n\n")
+	for i, item := range store.Items {
+		if i >= expiryWarningMaxLines {
+			fmt.Fprintf(&b, "- …… 另有 %d 个批次，请到后台查看\n", len(store.Items)-expiryWarningMaxLines)
+			break
+		}
+		status := fmt.Sprintf("剩 %d 天", item.DaysLeft)
+		if item.Expired {
+			status = fmt.Sprintf("已过期 %d 天", -item.DaysLeft)
+		} else if item.DaysLeft == 0 {
+			status = "今天到期"
+		}
+		fmt.Fprintf(&b, "- %s × %g%s（%s，%s）\n", item.ProductName, item.Quantity, item.Unit, item.ExpiryDate, status)
+	}
+	b.WriteString("\n请及时促销、调拨或报损处理。")
+	return b.String()
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+func TestSummarizeExpiringLotsSplitsExpiredAndNearExpiry(t *testing.T) {
+	today := expiryDay(time.Date(2026, 10, 18, 9, 0, 0, 0, preOrderLocation))
+	rows := []model.ExpiringLotRow{
+		{LotID: 1, StoreID: 1, StoreName: "一店", ProductName: "啤酒", ExpiryDate: time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), Quantity: 6, Unit: "瓶", CostPrice: 5},
+		{LotID: 2, StoreID: 1, StoreName: "一店", ProductName: "白酒", ExpiryDate: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), Quantity: 2, Unit: "瓶", CostPrice: 80.5},
+		{LotID: 3, StoreID: 2, StoreName: "二店", ProductName: "啤酒", ExpiryDate: time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC), Quantity: 12, Unit: "瓶", CostPrice: 5},
+	}
+	stats := summarizeExpiringLots(rows, today, 7)
+	if stats.Cutoff != "2026-10-25" || len(stats.Stores) != 2 {
+		t.Fatalf("stats = %#v", stats)
+	}
+	first := stats.Stores[0]
+	if first.ExpiredQuantity != 6 || first.ExpiredCostAmount != 30 || first.NearExpiryQuantity != 2 || first.NearExpiryCostAmount != 161 {
+		t.Fatalf("store 1 = %#v", first)
+	}
+	if !first.Items[0].Expired || first.Items[0].DaysLeft != -2 || first.Items[1].DaysLeft != 0 {
+		t.Fatalf("store 1 items = %#v", first.Items)
+	}
+	if stats.ExpiredCostAmount != 30 || stats.NearExpiryQuantity != 14 || stats.NearExpiryCostAmount != 221 {
+		t.Fatalf("totals = %#v", stats)
+	}
+}
+
+func TestBuildExpiryWarningMarkdown(t *testing.T) {
+	store := &model.ExpiryStoreStats{
+		StoreName:          "一店",
+		ExpiredQuantity:    6,
+		ExpiredCostAmount:  30,
+		NearExpiryQuantity: 2,
+		Items: []model.ExpiryLotItem{
+			{ProductName: "啤酒", Quantity: 6, Unit: "瓶", ExpiryDate: "2026-10-16", DaysLeft: -2, Expired: true},
+			{ProductName: "白酒", Quantity: 2, Unit: "瓶", ExpiryDate: "2026-10-18"},
+		},
+	}
+	text := buildExpiryWarningMarkdown(store, 7)
+	for _, want := range []string{"一店", "已过期 2 天", "今天到期", "7 天内到期"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("markdown missing %q:\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/module"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+)
+
+// stockCardMaxDays 库存卡单次查询的最大天数
+const stockCardMaxDays = 366
+
+type InventoryMovementService struct {
+	movementModule  *module.InventoryMovementModule
+	inventoryModule *module.InventoryModule
+	storeModule     *module.StoreModule
+	productModule   *module.SupplierProductModule
+}
+
+func NewInventoryMovementService(
+	movementModule *module.InventoryMovementModule,
+	inventoryModule *module.InventoryModule,
+	storeModule *module.StoreModule,
+	productModule *module.SupplierProductModule,
+) *InventoryMovementService {
+	return &InventoryMovementService{
+		movementModule:  movementModule,
+		inventoryModule: inventoryModule,
+		storeModule:     storeModule,
+		productModule:   productModule,
+	}
+}
+
+// GetStockCard 商品库存卡：区间期初结余、逐笔流水与期末结余。
+// 期初取区间前最后一条流水的结余；此前没有流水时按区间内第一笔变动前的结余，整段都无流水时取当前库存。
+func (s *InventoryMovementService) GetStockCard(storeID uint, hqUnbound bool, req *model.StockCardReq) (*model.StockCard, error) {
+	if hqUnbound && req.StoreID > 0 {
+		storeID = req.StoreID
+	}
+	if storeID == 0 {
+		return nil, apicode.New(apicode.StoreRequired)
+	}
+	start, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(req.StartDate), time.Local)
+	if err != nil {
+		return nil, apicode.New(apicode.InvalidDate)
+	}
+	endDay, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(req.EndDate), time.Local)
+	if err != nil {
+		return nil, apicode.New(apicode.InvalidDate)
+	}
+	end := endDay.AddDate(0, 0, 1)
+	if !end.After(start) {
+		return nil, apicode.Newf(apicode.InvalidParameter, "结束日期不能早于开始日期")
+	}
+	if end.Sub(start) > stockCardMaxDays*24*time.Hour {
+		return nil, apicode.Newf(apicode.InvalidParameter, "查询区间不能超过 %d 天", stockCardMaxDays)
+	}
+
+	store, err := s.storeModule.GetByID(storeID)
+	if err != nil || store == nil {
+		return nil, apicode.New(apicode.StoreNotFound)
+	}
+	product, err := s.productModule.GetByID(req.ProductID)
+	if err != nil || product == nil {
+		return nil, apicode.New(apicode.ProductNotFound)
+	}
+
+	movements, err := s.movementModule.ListByProduct(store.ID, product.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	card := &model.StockCard{
+		StoreID:     store.ID,
+		StoreName:   store.Name,
+		ProductID:   product.ID,
+		ProductName: product.Name,
+		Unit:        product.Unit,
+		StartDate:   start.Format("2006-01-02"),
+		EndDate:     endDay.Format("2006-01-02"),
+		Movements:   movements,
+	}
+	if inv, err := s.inventoryModule.GetByStoreAndProduct(store.ID, product.ID); err == nil && inv != nil {
+		card.Unit = inv.Unit
+	}
+
+	opening, err := s.stockCardOpening(store.ID, product.ID, start, end, movements)
+	if err != nil {
+		return nil, err
+	}
+	summarizeStockCard(card, opening)
+	return card, nil
+}
+
+func (s *InventoryMovementService) stockCardOpening(storeID, productID uint, start, end time.Time, movements []*model.InventoryMovement) (float64, error) {
+	last, err := s.movementModule.LastBefore(storeID, productID, start)
+	if err != nil {
+		return 0, err
+	}
+	if last != nil {
+		return last.BalanceAfter, nil
+	}
+	if len(movements) > 0 {
+		return roundQuantity(movements[0].BalanceAfter - movements[0].Delta), nil
+	}
+	next, err := s.movementModule.FirstFrom(storeID, productID, end)
+	if err != nil {
+		return 0, err
+	}
+	if next != nil {
+		return roundQuantity(next.BalanceAfter - next.Delta), nil
+	}
+	inv, err := s.inventoryModule.GetByStoreAndProduct(storeID, productID)
+	if err != nil || inv == nil {
+		return 0, nil
+	}
+	return inv.Quantity, nil
+}
+
+// summarizeStockCard 汇总区间入库、出库与期末结余
+func summarizeStockCard(card *model.StockCard, opening float64) {
+	card.OpeningBalance = opening
+	card.ClosingBalance = opening
+	for _, m := range card.Movements {
+		if m.Delta > 0 {
+			card.InQuantity = roundQuantity(card.InQuantity + m.Delta)
+		} else {
+			card.OutQuantity = roundQuantity(card.OutQuantity - m.Delta)
+		}
+		card.ClosingBalance = m.BalanceAfter
+	}
+	if card.Movements == nil {
+		card.Movements = []*model.InventoryMovement{}
+	}
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -12,12 +13,13 @@ import (
 
 // MemberService 会员服务
 type MemberService struct {
-	module          *module.MemberModule
-	storeModule     *module.StoreModule
-	botModule       *module.DingTalkBotModule
-	dictModule      *module.DictModule
-	userModule      *module.UserModule
-	dingTalkService *DingTalkService
+	module      *module.MemberModule
+	storeModule *module.StoreModule
+	dictModule  *module.DictModule
+	userModule  *module.UserModule
+	notifier    *NotificationService
+	approvals   *DingTalkApprovalService
+	wineLabels  *PrintLabelService
 }
 
 // NewMemberService 创建会员服务
@@ -28,16 +30,14 @@ func NewMemberService(m *module.MemberModule) *MemberService {
 // SetDependencies 设置依赖（用于解耦初始化）
 func (s *MemberService) SetDependencies(
 	storeModule *module.StoreModule,
-	botModule *module.DingTalkBotModule,
 	dictModule *module.DictModule,
 	userModule *module.UserModule,
-	dingTalkService *DingTalkService,
+	notifier *NotificationService,
 ) {
 	s.storeModule = storeModule
-	s.botModule = botModule
 	s.dictModule = dictModule
 	s.userModule = userModule
-	s.dingTalkService = dingTalkService
+	s.notifier = notifier
 }
 
 // ========== Member 操作 ==========
@@ -86,6 +86,18 @@ func (s *MemberService) ListMembersWithUnsettledAccounts(keyword string, page, p
 	return s.module.ListMembersWithUnsettledAccounts(keyword, page, pageSize, needPagination, storeID, isAdmin)
 }
 
+// SettleAccountsFromWallet 使用会员余额批量结算未结记账单
+func (s *MemberService) SettleAccountsFromWallet(memberID uint, req *model.SettleMemberAccountsReq, storeID uint, isAdmin bool) (*model.SettleMemberAccountsResult, error) {
+	result, err := s.module.SettleAccountsFromWallet(memberID, req, storeID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	for _, account := range result.Accounts {
+		publishStoreAccountChanged(account, model.RealtimeActionUpdated, false)
+	}
+	return result, nil
+}
+
 func (s *MemberService) ListPointRules(req *model.ListMemberPointRuleReq, storeID uint, isAdmin bool) ([]model.MemberPointRule, int64, error) {
 	return s.module.ListPointRules(req, storeID, isAdmin)
 }
@@ -107,7 +119,24 @@ func (s *MemberService) ListWineStorages(req *model.ListMemberWineStorageReq, st
 }
 
 func (s *MemberService) DepositWine(storeID, userID uint, isAdmin bool, req *model.MemberWineAdjustReq) (*model.MemberWineStorage, error) {
-	return s.module.AdjustWineStorage(storeID, userID, s.operatorName(userID), isAdmin, model.MemberWineTxnDeposit, req)
+	photos, err := normalizePhotoURLs(req.Photos, "存酒照片")
+	if err != nil {
+		return nil, err
+	}
+	req.PhotoURLs = photos
+	storage, err := s.module.AdjustWineStorage(storeID, userID, s.operatorName(userID), isAdmin, model.MemberWineTxnDeposit, req)
+	if err != nil {
+		return nil, err
+	}
+	if s.wineLabels != nil {
+		go s.wineLabels.PrintWineDepositTag(storage, req.Quantity, userID)
+	}
+	return storage, nil
+}
+
+// EnableWineLabelPrinting 存酒成功后自动在门店标签打印机打印存酒牌
+func (s *MemberService) EnableWineLabelPrinting(labels *PrintLabelService) {
+	s.wineLabels = labels
 }
 
 func (s *MemberService) WithdrawWine(storeID, userID uint, isAdmin bool, req *model.MemberWineAdjustReq) (*model.MemberWineStorage, error) {
@@ -135,19 +164,94 @@ func (s *MemberService) operatorName(userID uint) string {
 	return "未知"
 }
 
-// AdjustBalance 调整余额
-func (s *MemberService) AdjustBalance(id uint, amount model.DecimalType, changeType model.ChangeTypeEnum, remark string, version int, storeID, userID uint, isAdmin bool) (*model.Member, error) {
+// AdjustBalance 调整余额；命中钉钉审批路由时只提交审批并返回审批记录
+func (s *MemberService) AdjustBalance(id uint, amount model.DecimalType, changeType model.ChangeTypeEnum, remark string, version int, storeID, userID uint, isAdmin bool) (*model.Member, *model.DingTalkApproval, error) {
+	if s.approvals != nil {
+		approval, err := s.submitAdjustBalanceApproval(id, amount, changeType, remark, version, storeID, userID, isAdmin)
+		if err != nil || approval != nil {
+			return nil, approval, err
+		}
+	}
+	member, err := s.adjustBalance(id, amount, changeType, remark, version, storeID, userID, isAdmin)
+	return member, nil, err
+}
+
+func (s *MemberService) adjustBalance(id uint, amount model.DecimalType, changeType model.ChangeTypeEnum, remark string, version int, storeID, userID uint, isAdmin bool) (*model.Member, error) {
 	member, err := s.module.AdjustBalanceWithLock(id, amount, changeType, remark, version, storeID, isAdmin)
 	if err != nil {
 		return nil, err
 	}
 
-	// 异步发送钉钉通知
-	go s.sendAdjustBalanceDingTalkNotification(member, amount, changeType, remark, storeID, userID)
+	// 钉钉通知写入发件箱
+	s.enqueueAdjustBalanceDingTalkNotification(member, amount, changeType, remark, storeID, userID)
 
 	return member, nil
 }
 
+// submitAdjustBalanceApproval 调整金额达到审批路由门槛时提交钉钉审批；提交前先校验版本号，避免审批过期数据
+func (s *MemberService) submitAdjustBalanceApproval(id uint, amount model.DecimalType, changeType model.ChangeTypeEnum, remark string, version int, storeID, userID uint, isAdmin bool) (*model.DingTalkApproval, error) {
+	if changeType != model.ChangeTypeAdjustAdd && changeType != model.ChangeTypeAdjustLess {
+		return nil, apicode.Newf(apicode.ValidationFailed, "不支持的调整类型")
+	}
+	member, err := s.module.GetMember(id, storeID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if member.Version != version {
+		return nil, apicode.New(apicode.OptimisticLockConflict)
+	}
+
+	changeTypeName, changeSymbol := "余额调增", "+"
+	if changeType == model.ChangeTypeAdjustLess {
+		changeTypeName, changeSymbol = "余额调减", "-"
+	}
+	memberName := member.Name
+	if memberName == "" {
+		memberName = member.Phone
+	}
+	requester := "未知"
+	if s.userModule != nil && userID > 0 {
+		if user, err := s.userModule.GetByID(userID); err == nil && user != nil {
+			requester = dingTalkUserDisplay(user)
+		}
+	}
+	content := fmt.Sprintf("**会员：** %s\n\n**调整类型：** %s\n\n**调整金额：** %s%s 元\n\n**当前余额：** %s 元\n\n**备注：** %s\n\n**申请人：** %s",
+		memberName, changeTypeName, changeSymbol, amount.String(), member.Balance.String(), remark, requester)
+
+	return s.approvals.Submit(&DingTalkApprovalRequest{
+		BizType:     model.ApprovalBizMemberBalance,
+		BizID:       member.ID,
+		BizNo:       member.Phone,
+		StoreID:     member.StoreID,
+		Amount:      amount.Abs().InexactFloat64(),
+		Title:       "会员余额调整申请",
+		Content:     content,
+		Payload:     model.ApprovalMemberBalancePayload{Amount: amount, ChangeType: changeType, Remark: remark},
+		RequesterID: userID,
+	})
+}
+
+// EnableDingTalkApproval 开启余额调整钉钉审批：审批通过后按审批时的最新余额执行调整，驳回不做处理
+func (s *MemberService) EnableDingTalkApproval(approvals *DingTalkApprovalService) {
+	s.approvals = approvals
+	approvals.RegisterHandler(model.ApprovalBizMemberBalance, &DingTalkApprovalHandler{
+		Permission: "store:member:balance",
+		Approve: func(approval *model.DingTalkApproval, approver *model.User) error {
+			var payload model.ApprovalMemberBalancePayload
+			if err := json.Unmarshal([]byte(approval.Payload), &payload); err != nil {
+				return apicode.Wrap(apicode.ValidationFailed, err)
+			}
+			storeID, hqUnbound := dingTalkApproverScope(approver)
+			member, err := s.module.GetMember(approval.BizID, storeID, hqUnbound)
+			if err != nil {
+				return err
+			}
+			_, err = s.adjustBalance(member.ID, payload.Amount, payload.ChangeType, payload.Remark, member.Version, member.StoreID, approver.ID, hqUnbound)
+			return err
+		},
+	})
+}
+
 // ========== WalletLog 操作 ==========
 
 // CreateWalletLog 创建流水记录
@@ -169,20 +273,48 @@ func (s *MemberService) ListWalletLogs(req *model.ListWalletLogReq, page, pageSi
 
 // CreateRechargeOrder 创建充值单（自动完成支付）
 func (s *MemberService) CreateRechargeOrder(req *model.CreateRechargeOrderReq, storeID, userID uint, isAdmin bool) (*model.RechargeOrder, error) {
-	// 1. 创建充值单
+	member, err := s.module.GetMember(req.MemberID, storeID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if !req.PayAmount.IsPositive() {
+		return nil, apicode.Newf(apicode.ValidationFailed, "充值金额必须大于 0")
+	}
+
+	// 1. 匹配充值套餐（含首充赠送），再按会员等级追加充值赠送；首充资格在支付时锁定会员后复核
+	pkg, err := s.resolveRechargePackage(req, member, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if pkg != nil {
+		hasPaid, err := s.module.HasPaidRecharge(member.ID)
+		if err != nil {
+			return nil, err
+		}
+		applyRechargePackage(req, pkg, !hasPaid)
+	} else {
+		req.PackageID = 0
+	}
+	tier, err := s.module.GetMemberTier(member)
+	if err != nil {
+		return nil, err
+	}
+	applyTierRechargeBonus(req, tier)
+
+	// 2. 创建充值单
 	order, err := s.module.CreateRechargeOrder(req, storeID, isAdmin)
 	if err != nil {
 		return nil, err
 	}
 
-	// 2. 自动完成支付（更新会员余额、记录流水）
+	// 3. 自动完成支付（更新会员余额、记录流水）
 	order, err = s.module.PayRechargeOrder(order.OrderNo, storeID, isAdmin)
 	if err != nil {
 		return nil, err
 	}
 
-	// 3. 异步发送钉钉通知
-	go s.sendRechargeDingTalkNotification(order, storeID, userID)
+	// 4. 钉钉通知写入发件箱
+	s.enqueueRechargeDingTalkNotification(order, storeID, userID)
 
 	return order, nil
 }
@@ -209,15 +341,15 @@ func (s *MemberService) PayRechargeOrder(orderNo string, storeID, userID uint, i
 		return nil, err
 	}
 
-	// 异步发送钉钉通知
-	go s.sendRechargeDingTalkNotification(order, storeID, userID)
+	// 钉钉通知写入发件箱
+	s.enqueueRechargeDingTalkNotification(order, storeID, userID)
 
 	return order, nil
 }
 
-// sendRechargeDingTalkNotification 发送充值通知到门店钉钉群
-func (s *MemberService) sendRechargeDingTalkNotification(order *model.RechargeOrder, storeID, userID uint) {
-	if s.dingTalkService == nil || s.storeModule == nil || s.botModule == nil {
+// enqueueRechargeDingTalkNotification 生成充值通知，按通知路由写入发件箱
+func (s *MemberService) enqueueRechargeDingTalkNotification(order *model.RechargeOrder, storeID, userID uint) {
+	if s.notifier == nil || s.storeModule == nil {
 		return
 	}
 
@@ -230,27 +362,6 @@ func (s *MemberService) sendRechargeDingTalkNotification(order *model.RechargeOr
 		return
 	}
 
-	// 检查门店是否有联系电话
-	if store.Phone == "" {
-		if logging.SugaredLogger != nil {
-			logging.SugaredLogger.Warnw("Store has no phone, skip recharge notification", "storeID", storeID)
-		}
-		return
-	}
-
-	// 获取门店绑定的机器人
-	bot, err := s.botModule.GetByStoreID(storeID)
-	if err != nil || bot == nil {
-		if logging.SugaredLogger != nil {
-			logging.SugaredLogger.Warnw("No bot found for store", "storeID", storeID, "error", err)
-		}
-		return
-	}
-
-	if !bot.IsEnabled || bot.BotType != "stream" {
-		return
-	}
-
 	// 获取操作人名称
 	operatorName := ""
 	if s.userModule != nil && userID > 0 {
@@ -307,28 +418,22 @@ func (s *MemberService) sendRechargeDingTalkNotification(order *model.RechargeOr
 		time.Now().Format("2006-01-02 15:04:05"),
 	)
 
-	// 发送钉钉消息
-	if err := s.dingTalkService.SendStreamMarkdownToMobile(bot, title, text, store.Phone); err != nil {
-		if logging.SugaredLogger != nil {
-			logging.SugaredLogger.Errorw("Failed to send recharge notification",
-				"orderNo", order.OrderNo,
-				"storeID", storeID,
-				"error", err)
-		}
-		return
-	}
-
-	if logging.SugaredLogger != nil {
-		logging.SugaredLogger.Infow("Recharge notification sent successfully",
-			"orderNo", order.OrderNo,
-			"memberName", memberName,
-			"storeName", store.Name)
-	}
-}
-
-// sendAdjustBalanceDingTalkNotification 发送余额调整通知到门店钉钉群
-func (s *MemberService) sendAdjustBalanceDingTalkNotification(member *model.Member, amount model.DecimalType, changeType model.ChangeTypeEnum, remark string, storeID, userID uint) {
-	if s.dingTalkService == nil || s.storeModule == nil || s.botModule == nil {
+	_, _ = s.notifier.Notify(&model.NotificationEvent{
+		EventType: model.NotificationBizMemberRecharge,
+		StoreID:   storeID,
+		Channel:   payTypeName,
+		Amount:    order.PayAmount.InexactFloat64(),
+	}, &model.NotificationOutbox{
+		BizID:   order.ID,
+		BizNo:   order.OrderNo,
+		Title:   title,
+		Content: text,
+	}, nil)
+}
+
+// enqueueAdjustBalanceDingTalkNotification 生成余额调整通知，按通知路由写入发件箱
+func (s *MemberService) enqueueAdjustBalanceDingTalkNotification(member *model.Member, amount model.DecimalType, changeType model.ChangeTypeEnum, remark string, storeID, userID uint) {
+	if s.notifier == nil || s.storeModule == nil {
 		return
 	}
 
@@ -341,27 +446,6 @@ func (s *MemberService) sendAdjustBalanceDingTalkNotification(member *model.Memb
 		return
 	}
 
-	// 检查门店是否有联系电话
-	if store.Phone == "" {
-		if logging.SugaredLogger != nil {
-			logging.SugaredLogger.Warnw("Store has no phone, skip adjust balance notification", "storeID", storeID)
-		}
-		return
-	}
-
-	// 获取门店绑定的机器人
-	bot, err := s.botModule.GetByStoreID(storeID)
-	if err != nil || bot == nil {
-		if logging.SugaredLogger != nil {
-			logging.SugaredLogger.Warnw("No bot found for store", "storeID", storeID, "error", err)
-		}
-		return
-	}
-
-	if !bot.IsEnabled || bot.BotType != "stream" {
-		return
-	}
-
 	// 获取操作人名称
 	operatorName := ""
 	if s.userModule != nil && userID > 0 {
@@ -428,23 +512,17 @@ func (s *MemberService) sendAdjustBalanceDingTalkNotification(member *model.Memb
 		time.Now().Format("2006-01-02 15:04:05"),
 	)
 
-	// 发送钉钉消息
-	if err := s.dingTalkService.SendStreamMarkdownToMobile(bot, title, text, store.Phone); err != nil {
-		if logging.SugaredLogger != nil {
-			logging.SugaredLogger.Errorw("Failed to send adjust balance notification",
-				"memberID", member.ID,
-				"storeID", storeID,
-				"error", err)
-		}
-		return
-	}
-
-	if logging.SugaredLogger != nil {
-		logging.SugaredLogger.Infow("Adjust balance notification sent successfully",
-			"memberID", member.ID,
-			"memberName", memberName,
-			"storeName", store.Name)
-	}
+	_, _ = s.notifier.Notify(&model.NotificationEvent{
+		EventType: model.NotificationBizMemberBalance,
+		StoreID:   storeID,
+		Channel:   changeTypeName,
+		Amount:    amount.Abs().InexactFloat64(),
+	}, &model.NotificationOutbox{
+		BizID:   member.ID,
+		BizNo:   member.Phone,
+		Title:   title,
+		Content: text,
+	}, nil)
 }
 
 // getPayTypeName 获取支付方式名称
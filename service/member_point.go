@@ -0,0 +1,42 @@
+package service
+
+import (
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/utils/logging"
+	"go.uber.org/zap"
+)
+
+// pointExpireBatchSize 积分过期每批处理的批次数
+const pointExpireBatchSize = 200
+
+// RedeemPoints 积分兑换礼品，按先到期先扣的顺序扣减积分
+func (s *MemberService) RedeemPoints(memberID, storeID, operatorID uint, isAdmin bool, req *model.RedeemMemberPointsReq) (*model.Member, error) {
+	return s.module.RedeemPointsForGift(memberID, storeID, operatorID, isAdmin, req)
+}
+
+// ListPointLogs 查询会员积分流水
+func (s *MemberService) ListPointLogs(memberID uint, req *model.ListMemberPointLogReq, storeID uint, isAdmin bool) ([]model.MemberPointLog, int64, error) {
+	return s.module.ListPointLogs(memberID, req, storeID, isAdmin)
+}
+
+// ExpirePoints 清零已到期的积分批次，分批处理直到没有到期批次
+func (s *MemberService) ExpirePoints(now time.Time) error {
+	var lotTotal, pointTotal int
+	for {
+		lots, points, err := s.module.ExpireDuePoints(now, pointExpireBatchSize)
+		lotTotal += lots
+		pointTotal += points
+		if err != nil {
+			return err
+		}
+		if lots < pointExpireBatchSize {
+			break
+		}
+	}
+	if lotTotal > 0 {
+		logging.LogInfo("会员积分到期清零", zap.Int("lots", lotTotal), zap.Int("points", pointTotal))
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/utils/logging"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// memberTierBatchSize 夜间等级评定每批处理的会员数
+const memberTierBatchSize = 500
+
+func (s *MemberService) ListTiers(req *model.ListMemberTierReq, storeID uint, isAdmin bool) ([]model.MemberTier, error) {
+	return s.module.ListTiers(req, storeID, isAdmin)
+}
+
+func (s *MemberService) CreateTier(req *model.UpsertMemberTierReq, storeID uint, isAdmin bool) (*model.MemberTier, error) {
+	return s.module.CreateTier(req, storeID, isAdmin)
+}
+
+func (s *MemberService) UpdateTier(id uint, req *model.UpsertMemberTierReq, storeID uint, isAdmin bool) (*model.MemberTier, error) {
+	return s.module.UpdateTier(id, req, storeID, isAdmin)
+}
+
+func (s *MemberService) DeleteTier(id uint, storeID uint, isAdmin bool) error {
+	return s.module.DeleteTier(id, storeID, isAdmin)
+}
+
+func (s *MemberService) ListTierLogs(req *model.ListMemberTierLogReq, storeID uint, isAdmin bool) ([]model.MemberTierLog, int64, error) {
+	return s.module.ListTierLogs(req, storeID, isAdmin)
+}
+
+// EvaluateTiers 按近 12 个月消费、累计充值与当前积分重新评定全部会员等级，升级时通知门店
+func (s *MemberService) EvaluateTiers(now time.Time) error {
+	since := now.AddDate(-1, 0, 0)
+	tierSets := make(map[uint][]model.MemberTier)
+	var afterID uint
+	var upgraded, downgraded int
+	for {
+		members, err := s.module.ListMembersAfter(afterID, memberTierBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(members) == 0 {
+			break
+		}
+		afterID = members[len(members)-1].ID
+		metrics, err := s.module.LoadTierMetrics(members, since)
+		if err != nil {
+			return err
+		}
+		for i := range members {
+			member := &members[i]
+			tiers, ok := tierSets[member.StoreID]
+			if !ok {
+				if tiers, err = s.module.ListTiersForStore(member.StoreID); err != nil {
+					return err
+				}
+				tierSets[member.StoreID] = tiers
+			}
+			target := evaluateMemberTier(tiers, metrics[member.ID])
+			if target == nil || target.Level == member.Level {
+				continue
+			}
+			log := buildMemberTierLog(member, tiers, target, metrics[member.ID])
+			changed, err := s.module.ChangeMemberTier(member, log)
+			if err != nil {
+				logging.LogWarn("会员等级变更失败", zap.Uint("member_id", member.ID), zap.Error(err))
+				continue
+			}
+			if !changed {
+				continue
+			}
+			if log.ChangeType == model.MemberTierChangeUpgrade {
+				upgraded++
+				s.notifyTierUpgrade(member, log)
+			} else {
+				downgraded++
+			}
+		}
+		if len(members) < memberTierBatchSize {
+			break
+		}
+	}
+	if upgraded > 0 || downgraded > 0 {
+		logging.LogInfo("会员等级评定完成", zap.Int("upgraded", upgraded), zap.Int("downgraded", downgraded))
+	}
+	return nil
+}
+
+// evaluateMemberTier 取满足门槛的最高等级：任一门槛（大于 0）达到即满足，门槛全为 0 的等级所有会员都满足；
+// 都不满足时落在最低等级。未配置等级时返回 nil
+func evaluateMemberTier(tiers []model.MemberTier, metrics *model.MemberTierMetrics) *model.MemberTier {
+	if len(tiers) == 0 {
+		return nil
+	}
+	if metrics == nil {
+		metrics = &model.MemberTierMetrics{}
+	}
+	result := &tiers[0]
+	for i := range tiers {
+		t := &tiers[i]
+		if t.Level > result.Level && memberTierQualified(t, metrics) {
+			result = t
+		}
+	}
+	return result
+}
+
+func memberTierQualified(t *model.MemberTier, metrics *model.MemberTierMetrics) bool {
+	if t.MinSpend <= 0 && t.MinRecharge <= 0 && t.MinPoints <= 0 {
+		return true
+	}
+	return (t.MinSpend > 0 && metrics.Spend >= t.MinSpend) ||
+		(t.MinRecharge > 0 && metrics.RechargeTotal >= t.MinRecharge) ||
+		(t.MinPoints > 0 && metrics.Points >= t.MinPoints)
+}
+
+func buildMemberTierLog(member *model.Member, tiers []model.MemberTier, target *model.MemberTier, metrics *model.MemberTierMetrics) *model.MemberTierLog {
+	log := &model.MemberTierLog{
+		ChangeType: model.MemberTierChangeUpgrade,
+		FromLevel:  member.Level,
+		ToLevel:    target.Level,
+		ToName:     target.Name,
+	}
+	if target.Level < member.Level {
+		log.ChangeType = model.MemberTierChangeDowngrade
+	}
+	for _, t := range tiers {
+		if t.Level == member.Level {
+			log.FromName = t.Name
+		}
+	}
+	if metrics != nil {
+		log.Spend = metrics.Spend
+		log.RechargeTotal = metrics.RechargeTotal
+		log.Points = metrics.Points
+	}
+	return log
+}
+
+// applyTierRechargeBonus 按会员等级充值赠送比例追加赠送金额，并在备注中注明
+func applyTierRechargeBonus(req *model.CreateRechargeOrderReq, tier *model.MemberTier) {
+	if tier == nil || tier.RechargeBonusRate <= 0 || !req.PayAmount.IsPositive() {
+		return
+	}
+	bonus := req.PayAmount.Mul(decimal.NewFromFloat(tier.RechargeBonusRate)).Round(2)
+	if !bonus.IsPositive() {
+		return
+	}
+	req.GiftAmount = req.GiftAmount.Add(bonus)
+	note := fmt.Sprintf("%s充值赠送%s", tier.Name, bonus.StringFixed(2))
+	if req.Remark == "" {
+		req.Remark = note
+	} else {
+		req.Remark = req.Remark + "；" + note
+	}
+}
+
+// notifyTierUpgrade 会员升级通知写入发件箱，按会员所属门店的通知路由发送
+func (s *MemberService) notifyTierUpgrade(member *model.Member, log *model.MemberTierLog) {
+	if s.notifier == nil || s.storeModule == nil {
+		return
+	}
+	store, err := s.storeModule.GetByID(member.StoreID)
+	if err != nil || store == nil {
+		logging.LogWarn("会员升级通知读取门店失败", zap.Uint("member_id", member.ID), zap.Error(err))
+		return
+	}
+	title := "会员升级通知"
+	if _, err := s.notifier.Notify(&model.NotificationEvent{
+		EventType: model.NotificationBizMemberTier,
+		StoreID:   member.StoreID,
+	}, &model.NotificationOutbox{
+		BizID:   log.ID,
+		BizNo:   member.Phone,
+		Title:   title,
+		Content: buildMemberTierUpgradeMarkdown(store.Name, member, log),
+	}, nil); err != nil {
+		logging.LogWarn("会员升级通知写入失败", zap.Uint("member_id", member.ID), zap.Error(err))
+	}
+}
+
+func buildMemberTierUpgradeMarkdown(storeName string, member *model.Member, log *model.MemberTierLog) string {
+	name := strings.TrimSpace(member.Name)
+	if name == "" {
+		name = fmt.Sprintf("会员%d", member.ID)
+	}
+	fromName := log.FromName
+	if fromName == "" {
+		fromName = fmt.Sprintf("等级%d", log.FromLevel)
+	}
+	var b strings.Builder
+	b.WriteString("## 🎉 会员升级通知\n\n")
+	fmt.Fprintf(&b, "**门店**: %s\n\n", storeName)
+	fmt.Fprintf(&b, "**会员**: %s（尾号%s）\n\n", name, phoneTail(member.Phone))
+	fmt.Fprintf(&b, "**等级**: %s → **%s**\n\n", fromName, log.ToName)
+	fmt.Fprintf(&b, "**近12个月消费**: %.2f 元\n\n", log.Spend)
+	fmt.Fprintf(&b, "**累计充值**: %.2f 元\n\n", log.RechargeTotal)
+	fmt.Fprintf(&b, "**当前积分**: %d", log.Points)
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/shopspring/decimal"
+)
+
+func testMemberTiers() []model.MemberTier {
+	return []model.MemberTier{
+		{Level: 1, Name: "普通会员"},
+		{Level: 2, Name: "银卡", MinSpend: 1000, MinRecharge: 2000},
+		{Level: 3, Name: "金卡", MinSpend: 5000, MinPoints: 3000},
+	}
+}
+
+func TestEvaluateMemberTier(t *testing.T) {
+	tiers := testMemberTiers()
+	cases := []struct {
+		name    string
+		metrics *model.MemberTierMetrics
+		want    int
+	}{
+		{"无指标落在最低等级", nil, 1},
+		{"未达任何门槛", &model.MemberTierMetrics{Spend: 999}, 1},
+		{"消费达到银卡", &model.MemberTierMetrics{Spend: 1000}, 2},
+		{"充值达到银卡", &model.MemberTierMetrics{RechargeTotal: 2000}, 2},
+		{"积分达到金卡", &model.MemberTierMetrics{Points: 3000}, 3},
+		{"同时满足取最高", &model.MemberTierMetrics{Spend: 6000, RechargeTotal: 2000}, 3},
+	}
+	for _, c := range cases {
+		got := evaluateMemberTier(tiers, c.metrics)
+		if got == nil || got.Level != c.want {
+			t.Fatalf("%s: evaluateMemberTier() = %+v, want level %d", c.name, got, c.want)
+		}
+	}
+	if got := evaluateMemberTier(nil, &model.MemberTierMetrics{Spend: 10000}); got != nil {
+		t.Fatalf("evaluateMemberTier() without tiers = %+v, want nil", got)
+	}
+}
+
+func TestBuildMemberTierLog(t *testing.T) {
+	tiers := testMemberTiers()
+	member := &model.Member{ID: 9, Level: 3}
+	log := buildMemberTierLog(member, tiers, &tiers[1], &model.MemberTierMetrics{Spend: 1200, Points: 50})
+	if log.ChangeType != model.MemberTierChangeDowngrade {
+		t.Fatalf("ChangeType = %s, want downgrade", log.ChangeType)
+	}
+	if log.FromLevel != 3 || log.FromName != "金卡" || log.ToLevel != 2 || log.ToName != "银卡" {
+		t.Fatalf("unexpected log levels: %+v", log)
+	}
+	if log.Spend != 1200 || log.Points != 50 {
+		t.Fatalf("unexpected log metrics: %+v", log)
+	}
+
+	member.Level = 1
+	if log := buildMemberTierLog(member, tiers, &tiers[2], nil); log.ChangeType != model.MemberTierChangeUpgrade {
+		t.Fatalf("ChangeType = %s, want upgrade", log.ChangeType)
+	}
+}
+
+func TestBuildMemberTierUpgradeMarkdown(t *testing.T) {
+	member := &model.Member{ID: 9, Name: "张三", Phone: "13800001234"}
+	log := &model.MemberTierLog{FromLevel: 1, ToLevel: 2, ToName: "银卡", Spend: 1200, RechargeTotal: 500, Points: 80}
+	content := buildMemberTierUpgradeMarkdown("一号店", member, log)
+	for _, want := range []string{"一号店", "张三", "1234", "等级1 → **银卡**", "1200.00", "500.00", "80"} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("markdown missing %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestApplyTierRechargeBonus(t *testing.T) {
+	req := &model.CreateRechargeOrderReq{PayAmount: decimal.NewFromInt(1000), GiftAmount: decimal.NewFromInt(50), Remark: "活动"}
+	applyTierRechargeBonus(req, &model.MemberTier{Name: "银卡", RechargeBonusRate: 0.05})
+	if !req.GiftAmount.Equal(decimal.NewFromInt(100)) {
+		t.Fatalf("GiftAmount = %s, want 100", req.GiftAmount)
+	}
+	if req.Remark != "活动；银卡充值赠送50.00" {
+		t.Fatalf("Remark = %q", req.Remark)
+	}
+
+	req = &model.CreateRechargeOrderReq{PayAmount: decimal.NewFromInt(1000)}
+	applyTierRechargeBonus(req, &model.MemberTier{Name: "普通会员"})
+	applyTierRechargeBonus(req, nil)
+	if !req.GiftAmount.IsZero() || req.Remark != "" {
+		t.Fatalf("no bonus expected, got gift=%s remark=%q", req.GiftAmount, req.Remark)
+	}
+}
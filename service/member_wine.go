@@ -0,0 +1,466 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	"github.com/Kevin-Jii/tower-go/utils/logging"
+	"go.uber.org/zap"
+)
+
+const (
+	// wineExpireBatchSize 存酒到期处理每批处理的批次数
+	wineExpireBatchSize = 200
+	// wineDigestMaxLines 存酒提醒每个分组最多列出的条数
+	wineDigestMaxLines = 20
+	// wineSystemOperator 定时任务处理存酒时记录的操作人
+	wineSystemOperator = "系统"
+)
+
+// wineStoreDigest 单门店当日存酒提醒：到期处理结果、即将到期批次与久未存取的存酒
+type wineStoreDigest struct {
+	StoreID   uint
+	Policy    *model.MemberWinePolicy
+	Processed []model.MemberWineTransaction
+	Expiring  []model.MemberWineTransaction
+	Idle      []model.MemberWineStorage
+}
+
+func (d *wineStoreDigest) empty() bool {
+	return len(d.Processed) == 0 && len(d.Expiring) == 0 && len(d.Idle) == 0
+}
+
+// GetWinePolicy 获取门店生效的存酒策略，总部账号可查看任意门店或全局（store_id=0）
+func (s *MemberService) GetWinePolicy(req *model.GetMemberWinePolicyReq, storeID uint, isAdmin bool) (*model.MemberWinePolicy, error) {
+	if isAdmin {
+		storeID = req.StoreID
+	}
+	return s.module.GetWinePolicy(storeID)
+}
+
+// UpsertWinePolicy 保存存酒策略，门店账号只能维护本门店策略
+func (s *MemberService) UpsertWinePolicy(req *model.UpsertMemberWinePolicyReq, storeID uint, isAdmin bool) (*model.MemberWinePolicy, error) {
+	if !isAdmin {
+		if storeID == 0 {
+			return nil, apicode.New(apicode.StoreRequired)
+		}
+		req.StoreID = storeID
+	}
+	if err := validateWinePolicy(req); err != nil {
+		return nil, err
+	}
+	return s.module.UpsertWinePolicy(req)
+}
+
+func validateWinePolicy(req *model.UpsertMemberWinePolicyReq) error {
+	switch req.ExpireAction {
+	case model.MemberWineExpireForfeit:
+	case model.MemberWineExpireExtend:
+		if req.ExtendDays <= 0 {
+			return apicode.Newf(apicode.ValidationFailed, "到期自动续存时请填写续存天数")
+		}
+	default:
+		return apicode.Newf(apicode.ValidationFailed, "不支持的存酒到期处理方式")
+	}
+	if req.StorageDays > 0 && req.RemindDays >= req.StorageDays {
+		return apicode.Newf(apicode.ValidationFailed, "到期提醒天数需小于存酒期限天数")
+	}
+	return nil
+}
+
+// ListWineLots 查询有剩余的存入批次
+func (s *MemberService) ListWineLots(req *model.ListMemberWineLotReq, storeID uint, isAdmin bool) ([]model.MemberWineTransaction, int64, error) {
+	return s.module.ListWineLots(req, storeID, isAdmin)
+}
+
+// ExtendWineLot 手工为存入批次续存
+func (s *MemberService) ExtendWineLot(id, storeID, userID uint, isAdmin bool, req *model.ExtendMemberWineLotReq) (*model.MemberWineTransaction, error) {
+	if _, err := s.module.GetWineLot(id, storeID, isAdmin); err != nil {
+		return nil, err
+	}
+	return s.module.ExtendWineLot(id, req.Days, nil, userID, s.operatorName(userID), strings.TrimSpace(req.Remark))
+}
+
+// ForfeitWineLot 手工将存入批次剩余存酒转归门店，关联商品的同时按填写的酒瓶日期入库到对应批次
+func (s *MemberService) ForfeitWineLot(id, storeID, userID uint, isAdmin bool, req *model.ForfeitMemberWineLotReq) (*model.MemberWineTransaction, error) {
+	lot, err := s.module.GetWineLot(id, storeID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	operatorName := s.operatorName(userID)
+	remark := strings.TrimSpace(req.Remark)
+	if remark == "" {
+		remark = "存酒转归门店"
+	}
+	productionDate, err := parseOptionalDate(req.ProductionDate)
+	if err != nil {
+		return nil, err
+	}
+	expiryDate, err := parseOptionalDate(req.ExpiryDate)
+	if err != nil {
+		return nil, err
+	}
+	order := s.buildWineForfeitOrder(lot, userID, operatorName, remark)
+	if order != nil {
+		order.Items[0].ProductionDate = productionDate
+		order.Items[0].ExpiryDate = expiryDate
+	}
+	txn, err := s.module.ForfeitWineLot(id, order, nil, userID, operatorName, remark)
+	if err != nil {
+		return nil, err
+	}
+	if txn.InventoryOrderNo != "" {
+		publishInventoryOrders(order)
+	}
+	return txn, nil
+}
+
+// buildWineForfeitOrder 为关联了商品的存入批次生成到期入库单，数量由模块在锁内按剩余数量回填
+func (s *MemberService) buildWineForfeitOrder(lot *model.MemberWineTransaction, operatorID uint, operatorName, remark string) *model.InventoryOrder {
+	if lot == nil || lot.ProductID == 0 {
+		return nil
+	}
+	memberText := fmt.Sprintf("会员ID:%d", lot.MemberID)
+	if lot.Member != nil {
+		memberText = fmt.Sprintf("会员:%s %s", lot.Member.Name, lot.Member.Phone)
+	}
+	order := &model.InventoryOrder{
+		OrderNo:       memberWineForfeitInventoryOrderNo(lot.ID),
+		Type:          model.InventoryTypeIn,
+		StoreID:       lot.StoreID,
+		Reason:        model.ReasonWineForfeit,
+		Remark:        fmt.Sprintf("%s，%s，存入流水ID:%d", remark, memberText, lot.ID),
+		TotalQuantity: lot.Remaining,
+		ItemCount:     1,
+		OperatorID:    operatorID,
+		OperatorName:  operatorName,
+		Items: []model.InventoryOrderItem{{
+			ProductID:   lot.ProductID,
+			ProductName: lot.WineName,
+			Quantity:    lot.Remaining,
+			Unit:        lot.Unit,
+			Remark:      fmt.Sprintf("会员存酒%s入库", lot.CreatedAt.Format("2006-01-02")),
+		}},
+	}
+	if s.storeModule != nil {
+		if store, err := s.storeModule.GetByID(lot.StoreID); err == nil && store != nil {
+			order.StoreName = store.Name
+		}
+	}
+	if s.userModule != nil && operatorID > 0 {
+		if user, err := s.userModule.GetByID(operatorID); err == nil && user != nil {
+			order.OperatorPhone = user.Phone
+		}
+	}
+	return order
+}
+
+// 存酒入库单与存入批次一一对应，批次只会转归一次
+func memberWineForfeitInventoryOrderNo(lotID uint) string {
+	return fmt.Sprintf("RKCJ%010d", lotID)
+}
+
+// ProcessWineExpiry 每日存酒处理：按策略处理已到期批次，并按门店推送到期处理结果、临期与久未存取提醒
+func (s *MemberService) ProcessWineExpiry(now time.Time) error {
+	policies := make(map[uint]*model.MemberWinePolicy)
+	policyFor := func(storeID uint) (*model.MemberWinePolicy, error) {
+		if p, ok := policies[storeID]; ok {
+			return p, nil
+		}
+		p, err := s.module.GetWinePolicy(storeID)
+		if err != nil {
+			return nil, err
+		}
+		policies[storeID] = p
+		return p, nil
+	}
+	digests := make(map[uint]*wineStoreDigest)
+	digestFor := func(storeID uint, policy *model.MemberWinePolicy) *wineStoreDigest {
+		d, ok := digests[storeID]
+		if !ok {
+			d = &wineStoreDigest{StoreID: storeID, Policy: policy}
+			digests[storeID] = d
+		}
+		return d
+	}
+
+	processed, err := s.expireDueWineLots(now, policyFor)
+	for _, txn := range processed {
+		policy, _ := policyFor(txn.StoreID)
+		d := digestFor(txn.StoreID, policy)
+		d.Processed = append(d.Processed, txn)
+	}
+	firstErr := err
+
+	configured, err := s.module.ListWinePolicies()
+	if err != nil {
+		return err
+	}
+	remindDays, idleDays := winePolicyScanWindow(configured)
+	today := expiryDay(now)
+	if remindDays > 0 {
+		// 多查一天，避免服务器时区与门店时区不一致时漏掉最后一天的批次，逐条再按门店策略判断
+		lots, err := s.module.ListWineLotsToRemind(today.AddDate(0, 0, remindDays+2))
+		if err != nil {
+			return err
+		}
+		for _, lot := range lots {
+			policy, err := policyFor(lot.StoreID)
+			if err != nil {
+				return err
+			}
+			if wineLotNeedsReminder(&lot, policy, today) {
+				d := digestFor(lot.StoreID, policy)
+				d.Expiring = append(d.Expiring, lot)
+			}
+		}
+	}
+	if idleDays > 0 {
+		storages, err := s.module.ListIdleWineStorages(now.AddDate(0, 0, -idleDays))
+		if err != nil {
+			return err
+		}
+		for _, storage := range storages {
+			policy, err := policyFor(storage.StoreID)
+			if err != nil {
+				return err
+			}
+			if wineStorageIdle(&storage, policy, now) {
+				d := digestFor(storage.StoreID, policy)
+				d.Idle = append(d.Idle, storage)
+			}
+		}
+	}
+
+	for _, d := range digests {
+		if d.empty() {
+			continue
+		}
+		if err := s.sendWineDigest(d, now); err != nil {
+			logging.LogWarn("存酒提醒发送失败", zap.Uint("store_id", d.StoreID), zap.Error(err))
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// expireDueWineLots 按门店策略转归或续存已到期批次，返回生成的到期处理流水；单个批次失败时处理完本批后停止
+func (s *MemberService) expireDueWineLots(now time.Time, policyFor func(uint) (*model.MemberWinePolicy, error)) ([]model.MemberWineTransaction, error) {
+	results := make([]model.MemberWineTransaction, 0)
+	var firstErr error
+	for {
+		lots, err := s.module.ListDueWineLots(now, wineExpireBatchSize)
+		if err != nil {
+			return results, err
+		}
+		for i := range lots {
+			lot := &lots[i]
+			txn, err := s.expireWineLot(lot, now, policyFor)
+			if err != nil {
+				logging.LogWarn("存酒到期处理失败", zap.Uint("lot_id", lot.ID), zap.Error(err))
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			if txn != nil {
+				txn.Member = lot.Member
+				results = append(results, *txn)
+			}
+		}
+		if firstErr != nil || len(lots) < wineExpireBatchSize {
+			break
+		}
+	}
+	if len(results) > 0 {
+		logging.LogInfo("会员存酒到期处理", zap.Int("lots", len(results)))
+	}
+	return results, firstErr
+}
+
+func (s *MemberService) expireWineLot(lot *model.MemberWineTransaction, now time.Time, policyFor func(uint) (*model.MemberWinePolicy, error)) (*model.MemberWineTransaction, error) {
+	policy, err := policyFor(lot.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	if policy.ExpireAction == model.MemberWineExpireForfeit {
+		remark := "存酒到期转归门店"
+		order := s.buildWineForfeitOrder(lot, 0, wineSystemOperator, remark)
+		txn, err := s.module.ForfeitWineLot(lot.ID, order, &now, 0, wineSystemOperator, remark)
+		if err == nil && txn != nil && txn.InventoryOrderNo != "" {
+			publishInventoryOrders(order)
+		}
+		return txn, err
+	}
+	days := policy.ExtendDays
+	if days <= 0 {
+		days = 30
+	}
+	return s.module.ExtendWineLot(lot.ID, days, &now, 0, wineSystemOperator, fmt.Sprintf("存酒到期自动续存%d天", days))
+}
+
+// sendWineDigest 推送门店存酒提醒，成功写入发件箱后标记已提醒，避免次日重复提醒
+func (s *MemberService) sendWineDigest(d *wineStoreDigest, now time.Time) error {
+	if s.notifier == nil {
+		return nil
+	}
+	storeName := fmt.Sprintf("门店%d", d.StoreID)
+	if s.storeModule != nil {
+		if store, err := s.storeModule.GetByID(d.StoreID); err == nil && store != nil {
+			storeName = store.Name
+		}
+	}
+	today := expiryDay(now)
+	count, err := s.notifier.Notify(&model.NotificationEvent{
+		EventType: model.NotificationBizMemberWine,
+		StoreID:   d.StoreID,
+	}, &model.NotificationOutbox{
+		BizNo:   today.Format("2006-01-02"),
+		Title:   "会员存酒提醒",
+		Content: buildWineDigestMarkdown(storeName, d, now),
+	}, nil)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("no notification recipient for store %d", d.StoreID)
+	}
+	lotIDs := make([]uint, 0, len(d.Expiring))
+	for _, lot := range d.Expiring {
+		lotIDs = append(lotIDs, lot.ID)
+	}
+	if err := s.module.MarkWineLotsReminded(lotIDs, now); err != nil {
+		return err
+	}
+	storageIDs := make([]uint, 0, len(d.Idle))
+	for _, storage := range d.Idle {
+		storageIDs = append(storageIDs, storage.ID)
+	}
+	return s.module.MarkWineStoragesIdleReminded(storageIDs, now)
+}
+
+// winePolicyScanWindow 计算扫描范围：提醒天数取各策略最大值，久未存取天数取各策略最小的正值；默认策略始终参与
+func winePolicyScanWindow(policies []model.MemberWinePolicy) (int, int) {
+	def := model.DefaultMemberWinePolicy(0)
+	remindDays, idleDays := def.RemindDays, def.IdleDays
+	for _, p := range policies {
+		remindDays = max(remindDays, p.RemindDays)
+		if p.IdleDays > 0 && (idleDays == 0 || p.IdleDays < idleDays) {
+			idleDays = p.IdleDays
+		}
+	}
+	return remindDays, idleDays
+}
+
+// wineLotNeedsReminder 批次最后存放日在门店提醒天数内（含当天）
+func wineLotNeedsReminder(lot *model.MemberWineTransaction, policy *model.MemberWinePolicy, today time.Time) bool {
+	if lot.ExpireAt == nil || policy.RemindDays <= 0 {
+		return false
+	}
+	return wineDaysLeft(*lot.ExpireAt, today) <= policy.RemindDays
+}
+
+// wineStorageIdle 存酒最近存取距今已达门店久未存取天数
+func wineStorageIdle(storage *model.MemberWineStorage, policy *model.MemberWinePolicy, now time.Time) bool {
+	if policy.IdleDays <= 0 || storage.Quantity <= 0 {
+		return false
+	}
+	return !wineLastAccess(storage).After(now.AddDate(0, 0, -policy.IdleDays))
+}
+
+func wineLastAccess(storage *model.MemberWineStorage) time.Time {
+	if storage.LastAccessAt != nil {
+		return *storage.LastAccessAt
+	}
+	return storage.UpdatedAt
+}
+
+// wineLastDay 批次最后可存放的日期：到期时间为最后一天次日零点
+func wineLastDay(expireAt time.Time) time.Time {
+	return expiryDay(expireAt.AddDate(0, 0, -1))
+}
+
+// wineDaysLeft 距最后存放日的天数，0 表示今天是最后一天
+func wineDaysLeft(expireAt time.Time, today time.Time) int {
+	return int(wineLastDay(expireAt).Sub(today).Hours() / 24)
+}
+
+func wineMemberLabel(member *model.Member, memberID uint) string {
+	if member == nil {
+		return fmt.Sprintf("会员%d", memberID)
+	}
+	name := strings.TrimSpace(member.Name)
+	if name == "" {
+		name = fmt.Sprintf("会员%d", member.ID)
+	}
+	if member.Phone != "" {
+		return fmt.Sprintf("%s（%s）", name, member.Phone)
+	}
+	return name
+}
+
+func buildWineDigestMarkdown(storeName string, d *wineStoreDigest, now time.Time) string {
+	today := expiryDay(now)
+	var b strings.Builder
+	b.WriteString("### 会员存酒提醒\n\n")
+	fmt.Fprintf(&b, "- **门店：** %s\n", storeName)
+	fmt.Fprintf(&b, "- **日期：** %s\n", today.Format("2006-01-02"))
+
+	if len(d.Processed) > 0 {
+		b.WriteString("\n**今日到期处理**\n\n")
+		for i, txn := range d.Processed {
+			if i >= wineDigestMaxLines {
+				fmt.Fprintf(&b, "- …… 另有 %d 条，请到后台查看\n", len(d.Processed)-wineDigestMaxLines)
+				break
+			}
+			action := "已转归门店"
+			if txn.InventoryOrderNo != "" {
+				action = fmt.Sprintf("已转入门店库存（%s）", txn.InventoryOrderNo)
+			}
+			if txn.Type == model.MemberWineTxnExtend && txn.ExpireAt != nil {
+				action = fmt.Sprintf("已自动续存至 %s", wineLastDay(*txn.ExpireAt).Format("2006-01-02"))
+			}
+			fmt.Fprintf(&b, "- %s：%s × %g%s，%s\n", wineMemberLabel(txn.Member, txn.MemberID), txn.WineName, txn.Quantity, txn.Unit, action)
+		}
+	}
+
+	if len(d.Expiring) > 0 {
+		b.WriteString("\n**即将到期**\n\n")
+		for i, lot := range d.Expiring {
+			if i >= wineDigestMaxLines {
+				fmt.Fprintf(&b, "- …… 另有 %d 条，请到后台查看\n", len(d.Expiring)-wineDigestMaxLines)
+				break
+			}
+			status := fmt.Sprintf("剩 %d 天", wineDaysLeft(*lot.ExpireAt, today))
+			if wineDaysLeft(*lot.ExpireAt, today) == 0 {
+				status = "今天到期"
+			}
+			fmt.Fprintf(&b, "- %s：%s × %g%s（存至 %s，%s）\n", wineMemberLabel(lot.Member, lot.MemberID),
+				lot.WineName, lot.Remaining, lot.Unit, wineLastDay(*lot.ExpireAt).Format("2006-01-02"), status)
+		}
+		if d.Policy != nil && d.Policy.ExpireAction == model.MemberWineExpireForfeit {
+			b.WriteString("\n到期未取将转归门店，请提前联系会员取酒或续存。\n")
+		} else if d.Policy != nil {
+			fmt.Fprintf(&b, "\n到期未取将自动续存 %d 天，请提醒会员及时取酒。\n", d.Policy.ExtendDays)
+		}
+	}
+
+	if len(d.Idle) > 0 {
+		b.WriteString("\n**久未存取**\n\n")
+		for i, storage := range d.Idle {
+			if i >= wineDigestMaxLines {
+				fmt.Fprintf(&b, "- …… 另有 %d 条，请到后台查看\n", len(d.Idle)-wineDigestMaxLines)
+				break
+			}
+			idle := int(today.Sub(expiryDay(wineLastAccess(&storage))).Hours() / 24)
+			fmt.Fprintf(&b, "- %s：%s × %g%s（%d 天未存取）\n", wineMemberLabel(storage.Member, storage.MemberID),
+				storage.WineName, storage.Quantity, storage.Unit, idle)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+)
+
+func TestValidateWinePolicy(t *testing.T) {
+	if err := validateWinePolicy(&model.UpsertMemberWinePolicyReq{StorageDays: 180, RemindDays: 7, ExpireAction: model.MemberWineExpireForfeit}); err != nil {
+		t.Fatalf("forfeit policy error = %v", err)
+	}
+	if err := validateWinePolicy(&model.UpsertMemberWinePolicyReq{StorageDays: 180, ExpireAction: model.MemberWineExpireExtend}); !apicode.Is(err, apicode.ValidationFailed) {
+		t.Fatalf("extend without days error = %v", err)
+	}
+	if err := validateWinePolicy(&model.UpsertMemberWinePolicyReq{StorageDays: 7, RemindDays: 7, ExpireAction: model.MemberWineExpireForfeit}); !apicode.Is(err, apicode.ValidationFailed) {
+		t.Fatalf("remind days not below storage days error = %v", err)
+	}
+	if err := validateWinePolicy(&model.UpsertMemberWinePolicyReq{RemindDays: 30, ExpireAction: model.MemberWineExpireExtend, ExtendDays: 30}); err != nil {
+		t.Fatalf("unlimited storage policy error = %v", err)
+	}
+}
+
+func TestWinePolicyScanWindow(t *testing.T) {
+	remind, idle := winePolicyScanWindow([]model.MemberWinePolicy{
+		{StoreID: 0, RemindDays: 3, IdleDays: 0},
+		{StoreID: 1, RemindDays: 15, IdleDays: 60},
+	})
+	if remind != 15 || idle != 60 {
+		t.Fatalf("winePolicyScanWindow() = %d, %d", remind, idle)
+	}
+	remind, idle = winePolicyScanWindow(nil)
+	if remind != 7 || idle != 90 {
+		t.Fatalf("winePolicyScanWindow(nil) = %d, %d", remind, idle)
+	}
+}
+
+func TestWineLotNeedsReminder(t *testing.T) {
+	today := expiryDay(time.Date(2026, 10, 18, 9, 30, 0, 0, preOrderLocation))
+	policy := &model.MemberWinePolicy{RemindDays: 7}
+	lastDayIn7 := time.Date(2026, 10, 26, 0, 0, 0, 0, preOrderLocation) // 最后存放日 10-25
+	lastDayIn8 := time.Date(2026, 10, 27, 0, 0, 0, 0, preOrderLocation)
+	if !wineLotNeedsReminder(&model.MemberWineTransaction{ExpireAt: &lastDayIn7}, policy, today) {
+		t.Fatalf("lot ending in 7 days should be reminded")
+	}
+	if wineLotNeedsReminder(&model.MemberWineTransaction{ExpireAt: &lastDayIn8}, policy, today) {
+		t.Fatalf("lot ending in 8 days should not be reminded")
+	}
+	if wineLotNeedsReminder(&model.MemberWineTransaction{ExpireAt: &lastDayIn7}, &model.MemberWinePolicy{}, today) {
+		t.Fatalf("reminders disabled by policy")
+	}
+	if got := wineDaysLeft(lastDayIn7, today); got != 7 {
+		t.Fatalf("wineDaysLeft() = %d, want 7", got)
+	}
+}
+
+func TestWineStorageIdle(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, preOrderLocation)
+	policy := &model.MemberWinePolicy{IdleDays: 90}
+	old := now.AddDate(0, 0, -91)
+	recent := now.AddDate(0, 0, -10)
+	if !wineStorageIdle(&model.MemberWineStorage{Quantity: 2, LastAccessAt: &old}, policy, now) {
+		t.Fatalf("storage untouched for 91 days should be idle")
+	}
+	if wineStorageIdle(&model.MemberWineStorage{Quantity: 2, LastAccessAt: &recent, UpdatedAt: old}, policy, now) {
+		t.Fatalf("recently accessed storage should not be idle")
+	}
+	if !wineStorageIdle(&model.MemberWineStorage{Quantity: 2, UpdatedAt: old}, policy, now) {
+		t.Fatalf("legacy storage falls back to updated_at")
+	}
+	if wineStorageIdle(&model.MemberWineStorage{LastAccessAt: &old}, policy, now) {
+		t.Fatalf("empty storage should not be idle")
+	}
+}
+
+func TestBuildWineDigestMarkdown(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, preOrderLocation)
+	expiring := time.Date(2026, 10, 19, 0, 0, 0, 0, preOrderLocation)
+	extended := time.Date(2026, 11, 18, 0, 0, 0, 0, preOrderLocation)
+	lastAccess := now.AddDate(0, 0, -120)
+	member := &model.Member{ID: 1, Name: "张三", Phone: "13800000000"}
+	d := &wineStoreDigest{
+		Policy: &model.MemberWinePolicy{ExpireAction: model.MemberWineExpireForfeit},
+		Processed: []model.MemberWineTransaction{
+			{Type: model.MemberWineTxnForfeit, Member: member, WineName: "茅台", Quantity: 1, Unit: "瓶", InventoryOrderNo: "RKCJ0000000001"},
+			{Type: model.MemberWineTxnExtend, MemberID: 2, WineName: "啤酒", Quantity: 6, Unit: "瓶", ExpireAt: &extended},
+		},
+		Expiring: []model.MemberWineTransaction{{Member: member, WineName: "红酒", Remaining: 2, Unit: "瓶", ExpireAt: &expiring}},
+		Idle:     []model.MemberWineStorage{{Member: member, WineName: "威士忌", Quantity: 1, Unit: "瓶", LastAccessAt: &lastAccess}},
+	}
+	text := buildWineDigestMarkdown("一店", d, now)
+	for _, want := range []string{"一店", "张三（13800000000）", "RKCJ0000000001", "会员2", "续存至 2026-11-17", "今天到期", "转归门店", "120 天未存取"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("markdown missing %q:\n%s", want, text)
+		}
+	}
+}
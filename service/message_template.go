@@ -1,21 +1,35 @@
 package service
 
 import (
-	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
 	"strings"
-	"text/template"
 
 	"github.com/Kevin-Jii/tower-go/model"
 	"github.com/Kevin-Jii/tower-go/module"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
 )
 
+// TemplateDocumentSource 按业务单据ID构建模板数据，用于预览真实单据的渲染效果
+type TemplateDocumentSource func(docID uint) (map[string]interface{}, error)
+
 type MessageTemplateService struct {
 	templateModule *module.MessageTemplateModule
+	docSources     map[string]TemplateDocumentSource
 }
 
 func NewMessageTemplateService(templateModule *module.MessageTemplateModule) *MessageTemplateService {
 	return &MessageTemplateService{
 		templateModule: templateModule,
+		docSources:     make(map[string]TemplateDocumentSource),
+	}
+}
+
+// RegisterDocumentSource 为模板编码注册单据数据来源，预览时可按 doc_id 渲染
+func (s *MessageTemplateService) RegisterDocumentSource(source TemplateDocumentSource, codes ...string) {
+	for _, code := range codes {
+		s.docSources[code] = source
 	}
 }
 
@@ -28,14 +42,14 @@ func (s *MessageTemplateService) RenderTemplate(code string, data map[string]int
 
 	// 渲染标题
 	if tpl.Title != "" {
-		title, err = s.render(tpl.Title, data)
+		title, err = renderMessageTemplate(tpl.Title, data)
 		if err != nil {
 			return "", "", err
 		}
 	}
 
 	// 渲染内容
-	content, err = s.render(tpl.Content, data)
+	content, err = renderMessageTemplate(tpl.Content, data)
 	if err != nil {
 		return "", "", err
 	}
@@ -43,25 +57,6 @@ func (s *MessageTemplateService) RenderTemplate(code string, data map[string]int
 	return title, content, nil
 }
 
-// render 使用 Go template 渲染
-func (s *MessageTemplateService) render(tplStr string, data map[string]interface{}) (string, error) {
-	// 兼容误写模板语法：{{.range .Items}} / {{.end}}
-	tplStr = strings.ReplaceAll(tplStr, "{{.range ", "{{range ")
-	tplStr = strings.ReplaceAll(tplStr, "{{.end}}", "{{end}}")
-
-	tpl, err := template.New("msg").Parse(tplStr)
-	if err != nil {
-		return "", err
-	}
-
-	var buf bytes.Buffer
-	if err := tpl.Execute(&buf, data); err != nil {
-		return "", err
-	}
-
-	return buf.String(), nil
-}
-
 // GetByCode 获取模板
 func (s *MessageTemplateService) GetByCode(code string) (*model.MessageTemplate, error) {
 	return s.templateModule.GetByCode(code)
@@ -77,8 +72,11 @@ func (s *MessageTemplateService) GetByID(id uint) (*model.MessageTemplate, error
 	return s.templateModule.GetByID(id)
 }
 
-// Create 创建模板
-func (s *MessageTemplateService) Create(req *model.CreateMessageTemplateReq) (*model.MessageTemplate, error) {
+// Create 创建模板，同时生成第 1 版
+func (s *MessageTemplateService) Create(req *model.CreateMessageTemplateReq, operatorID uint) (*model.MessageTemplate, error) {
+	if err := validateMessageTemplate(req.Title, req.Content, req.Schema); err != nil {
+		return nil, err
+	}
 	tpl := &model.MessageTemplate{
 		Code:        req.Code,
 		Name:        req.Name,
@@ -86,29 +84,29 @@ func (s *MessageTemplateService) Create(req *model.CreateMessageTemplateReq) (*m
 		Content:     req.Content,
 		Description: req.Description,
 		Variables:   req.Variables,
+		Schema:      req.Schema,
 		IsEnabled:   true,
 	}
 	if req.IsEnabled != nil {
 		tpl.IsEnabled = *req.IsEnabled
 	}
-	if err := s.templateModule.Create(tpl); err != nil {
+	if err := s.templateModule.CreateWithVersion(tpl, operatorID); err != nil {
 		return nil, err
 	}
 	return tpl, nil
 }
 
-// Update 更新模板
-func (s *MessageTemplateService) Update(id uint, req *model.UpdateMessageTemplateReq) error {
+// Update 更新模板；标题、内容或变量结构变化时校验并生成新版本
+func (s *MessageTemplateService) Update(id uint, req *model.UpdateMessageTemplateReq, operatorID uint) error {
+	current, err := s.templateModule.GetByID(id)
+	if err != nil {
+		return apicode.New(apicode.NotFound)
+	}
+
 	updates := make(map[string]interface{})
 	if req.Name != nil {
 		updates["name"] = *req.Name
 	}
-	if req.Title != nil {
-		updates["title"] = *req.Title
-	}
-	if req.Content != nil {
-		updates["content"] = *req.Content
-	}
 	if req.Description != nil {
 		updates["description"] = *req.Description
 	}
@@ -118,10 +116,124 @@ func (s *MessageTemplateService) Update(id uint, req *model.UpdateMessageTemplat
 	if req.IsEnabled != nil {
 		updates["is_enabled"] = *req.IsEnabled
 	}
-	if len(updates) == 0 {
-		return nil
+
+	title, content, schema := current.Title, current.Content, current.Schema
+	if req.Title != nil {
+		title = *req.Title
+	}
+	if req.Content != nil {
+		content = *req.Content
+	}
+	if req.Schema != nil {
+		schema = *req.Schema
+	}
+	if title == current.Title && content == current.Content && reflect.DeepEqual(schema, current.Schema) {
+		if len(updates) == 0 {
+			return nil
+		}
+		return s.templateModule.Update(id, updates)
+	}
+
+	if err := validateMessageTemplate(title, content, schema); err != nil {
+		return err
+	}
+	updates["title"] = title
+	updates["content"] = content
+	updates["schema"] = schema
+	_, err = s.templateModule.UpdateWithVersion(current, updates, strings.TrimSpace(req.Remark), operatorID)
+	return translateTemplateVersionErr(err)
+}
+
+// ListVersions 模板历史版本
+func (s *MessageTemplateService) ListVersions(id uint) ([]*model.MessageTemplateVersion, error) {
+	if _, err := s.templateModule.GetByID(id); err != nil {
+		return nil, apicode.New(apicode.NotFound)
+	}
+	return s.templateModule.ListVersions(id)
+}
+
+// Rollback 回滚到指定版本：以该版本内容生成一个新版本，历史版本保持不变
+func (s *MessageTemplateService) Rollback(id uint, version int, operatorID uint) (*model.MessageTemplate, error) {
+	current, err := s.templateModule.GetByID(id)
+	if err != nil {
+		return nil, apicode.New(apicode.NotFound)
+	}
+	snapshot, err := s.templateModule.GetVersion(id, version)
+	if err != nil {
+		return nil, apicode.Newf(apicode.NotFound, "版本 v%d 不存在", version)
+	}
+	if version == current.Version {
+		return nil, apicode.Newf(apicode.ValidationFailed, "v%d 已是当前版本", version)
+	}
+	updates := map[string]interface{}{
+		"title":   snapshot.Title,
+		"content": snapshot.Content,
+		"schema":  snapshot.Schema,
+	}
+	remark := fmt.Sprintf("回滚至 v%d", version)
+	if _, err := s.templateModule.UpdateWithVersion(current, updates, remark, operatorID); err != nil {
+		return nil, translateTemplateVersionErr(err)
+	}
+	return s.templateModule.GetByID(id)
+}
+
+// Preview 预览模板渲染结果。请求中的标题/内容/变量结构覆盖已保存的模板（用于编辑中的草稿）；
+// 数据优先取 data，其次按 doc_id 读取真实单据，都未传时按变量结构生成示例数据。
+func (s *MessageTemplateService) Preview(id uint, req *model.PreviewMessageTemplateReq) (*model.MessageTemplatePreview, error) {
+	tpl, err := s.templateModule.GetByID(id)
+	if err != nil {
+		return nil, apicode.New(apicode.NotFound)
+	}
+	title, content, schema := tpl.Title, tpl.Content, tpl.Schema
+	if req.Title != nil {
+		title = *req.Title
+	}
+	if req.Content != nil {
+		content = *req.Content
+	}
+	if req.Schema != nil {
+		schema = *req.Schema
+	}
+	if err := validateTemplateSchema(schema, ""); err != nil {
+		return nil, apicode.Newf(apicode.ValidationFailed, "%v", err)
+	}
+
+	unknown, err := undeclaredTemplateRefs(title, content, schema)
+	if err != nil {
+		return nil, err
+	}
+	var warnings []string
+	for _, ref := range unknown {
+		warnings = append(warnings, fmt.Sprintf("变量 %s 未在变量结构中声明", ref))
 	}
-	return s.templateModule.Update(id, updates)
+
+	data := req.Data
+	switch {
+	case data != nil:
+	case req.DocID > 0:
+		source, ok := s.docSources[tpl.Code]
+		if !ok {
+			return nil, apicode.Newf(apicode.ValidationFailed, "模板 %s 不支持按单据预览", tpl.Code)
+		}
+		if data, err = source(req.DocID); err != nil {
+			return nil, apicode.Newf(apicode.NotFound, "单据 %d 读取失败: %v", req.DocID, err)
+		}
+	default:
+		data = sampleTemplateData(schema)
+	}
+	warnings = append(warnings, checkTemplateData(schema, data, "")...)
+
+	preview := &model.MessageTemplatePreview{Data: data, Warnings: warnings}
+	if preview.Title, err = renderMessageTemplate(title, data); err != nil {
+		return nil, apicode.Newf(apicode.ValidationFailed, "标题渲染失败: %v", err)
+	}
+	if preview.Content, err = renderMessageTemplate(content, data); err != nil {
+		return nil, apicode.Newf(apicode.ValidationFailed, "内容渲染失败: %v", err)
+	}
+	if preview.Warnings == nil {
+		preview.Warnings = []string{}
+	}
+	return preview, nil
 }
 
 // Delete 删除模板
@@ -129,6 +241,41 @@ func (s *MessageTemplateService) Delete(id uint) error {
 	return s.templateModule.Delete(id)
 }
 
+// validateMessageTemplate 校验变量结构与模板语法；声明了变量结构时，模板只能引用已声明的变量
+func validateMessageTemplate(title, content string, schema model.TemplateSchema) error {
+	if err := validateTemplateSchema(schema, ""); err != nil {
+		return apicode.Newf(apicode.ValidationFailed, "%v", err)
+	}
+	unknown, err := undeclaredTemplateRefs(title, content, schema)
+	if err != nil {
+		return err
+	}
+	if len(unknown) > 0 {
+		return apicode.Newf(apicode.ValidationFailed, "模板引用了未声明的变量: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// undeclaredTemplateRefs 解析标题与内容，返回未在变量结构中声明的引用
+func undeclaredTemplateRefs(title, content string, schema model.TemplateSchema) ([]string, error) {
+	var unknown []string
+	for _, part := range []string{title, content} {
+		refs, err := checkTemplateRefs(part, schema)
+		if err != nil {
+			return nil, apicode.Newf(apicode.ValidationFailed, "模板语法错误: %v", err)
+		}
+		unknown = append(unknown, refs...)
+	}
+	return unknown, nil
+}
+
+func translateTemplateVersionErr(err error) error {
+	if errors.Is(err, module.ErrTemplateVersionConflict) {
+		return apicode.Newf(apicode.Conflict, "模板已被修改，请刷新后重试")
+	}
+	return err
+}
+
 // InitDefaultTemplates 初始化默认模板
 func (s *MessageTemplateService) InitDefaultTemplates() error {
 	// 模板已迁移到 init_seed_data.sql，这里只做兼容处理
@@ -143,14 +290,14 @@ func (s *MessageTemplateService) InitDefaultTemplates() error {
 		return err
 	}
 	if len(list) > 0 {
-		return nil // 已有模板，跳过
+		return s.ensureBuiltinTemplates() // 已有模板，只补齐后续新增的内置模板
 	}
 
 	// 初始化基础模板（完整模板请执行 init_seed_data.sql）
 	templates := []model.MessageTemplate{
 		{Code: model.TemplateStoreAccountCreated, Name: "记账通知", Title: "📝 新记账通知 - {{.StoreName}}", Content: "记账编号: {{.AccountNo}}\n渠道: {{.ChannelName}}\n操作人: {{.OperatorName}}\n\n{{.ItemList}}\n\n合计: ¥{{.TotalAmount}}", IsEnabled: true},
 		{Code: model.TemplateInventoryCreated, Name: "入库通知", Title: "📦 新入库通知 - {{.StoreName}}", Content: "入库单号: {{.OrderNo}}\n类型: {{.OrderType}}\n操作人: {{.OperatorName}}\n\n{{.ItemList}}\n\n总量: {{.TotalAmount}}", IsEnabled: true},
-		{Code: model.TemplateBotHelp, Name: "机器人帮助", Title: "📋 功能菜单", Content: "## 📋 功能菜单\n\n**库存相关**\n- 库存查询\n- 查询库存 商品名\n\n**记账相关**\n- 今日记账\n\n**入库相关**\n- 今日入库\n\n---\n发送 **帮助** 查看此菜单", IsEnabled: true},
+		{Code: model.TemplateBotHelp, Name: "机器人帮助", Title: "📋 功能菜单", Content: "## 📋 功能菜单\n\n{{.CommandList}}\n\n---\n写操作会先回复预览，回复 **确认** 后才执行", IsEnabled: true},
 		{Code: model.TemplateBotInventoryQuery, Name: "库存查询回复", Title: "📦 库存查询", Content: "## 📦 库存查询\n\n**共{{.Total}}项**\n\n{{.ItemList}}\n\n---\n{{.CreateTime}}", IsEnabled: true},
 		{Code: model.TemplateBotTodayAccount, Name: "今日记账回复", Title: "📝 今日记账", Content: "## 📝 今日记账汇总\n\n**日期：** {{.Date}}\n\n**笔数：** {{.Count}} 笔\n\n**总额：** ¥{{.TotalAmount}}\n\n---\n{{.CreateTime}}", IsEnabled: true},
 		{Code: model.TemplateBotTodayInventory, Name: "今日入库回复", Title: "📦 今日入库", Content: "## 📦 今日入库汇总\n\n**日期：** {{.Date}}\n\n**单数：** {{.Count}} 单\n\n**总量：** {{.TotalQuantity}}\n\n{{.ItemList}}\n\n---\n{{.CreateTime}}", IsEnabled: true},
@@ -163,5 +310,109 @@ func (s *MessageTemplateService) InitDefaultTemplates() error {
 			return err
 		}
 	}
+	return s.ensureBuiltinTemplates()
+}
+
+// ensureBuiltinTemplates 补齐带变量结构的内置模板，已存在的不覆盖
+func (s *MessageTemplateService) ensureBuiltinTemplates() error {
+	for _, tpl := range builtinStructuredTemplates() {
+		if err := s.templateModule.CreateIfMissing(tpl); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// builtinStructuredTemplates 由代码拼装迁移为模板的消息，内容与代码中的默认格式一致
+func builtinStructuredTemplates() []*model.MessageTemplate {
+	str := func(name, description string, example interface{}) model.TemplateVariable {
+		return model.TemplateVariable{Name: name, Type: model.TemplateVarString, Description: description, Example: example}
+	}
+	num := func(name, description string, example interface{}) model.TemplateVariable {
+		return model.TemplateVariable{Name: name, Type: model.TemplateVarNumber, Description: description, Example: example}
+	}
+	required := func(v model.TemplateVariable) model.TemplateVariable {
+		v.Required = true
+		return v
+	}
+
+	return []*model.MessageTemplate{
+		{
+			Code:        model.TemplateStoreAccountCard,
+			Name:        "记账通知卡片",
+			Title:       "新记账通知 - {{.StoreName}}",
+			Description: "记账通知的 ActionCard 正文，未配置钉钉卡片或卡片发送失败时使用",
+			Content: "### 新记账通知 - {{.StoreName}}\n\n" +
+				"- 记账编号：{{.AccountNo}}\n" +
+				"- 渠道来源：{{.ChannelName}}\n" +
+				"- 记账日期：{{.AccountDate}}\n" +
+				"- 操作人：{{.OperatorName}}\n\n" +
+				"#### 记账明细\n" +
+				"{{range $i, $item := limit .Items 8}}- {{inc $i}}. {{$item.ProductName}} x{{$item.Quantity}} = {{$item.Amount}}\n" +
+				"{{else}}- 暂无商品明细\n{{end}}" +
+				"{{if gt (len .Items) 8}}- ...等共 {{len .Items}} 项\n{{end}}\n" +
+				"**合计：¥{{.TotalAmount}}**\n\n" +
+				"{{if .OtherExpense}}- 其他支出：¥{{money .OtherExpense}}\n{{end}}" +
+				"- 净收入：¥{{money .NetIncome}}\n" +
+				"{{with .Remark}}\n备注：{{.}}\n{{end}}" +
+				"{{with .ImageURL}}\n![记账回单]({{.}})\n{{end}}" +
+				"\n> 本消息由系统自动发送",
+			Schema: model.TemplateSchema{
+				required(str("StoreName", "门店名称", "旗舰店")),
+				required(str("AccountNo", "记账编号", "JZ20260101001")),
+				str("ChannelName", "渠道名称", "美团"),
+				str("AccountDate", "记账日期", "2026-01-01"),
+				str("OperatorName", "操作人", "张三"),
+				{Name: "Items", Type: model.TemplateVarList, Required: true, Description: "商品明细", Fields: []model.TemplateVariable{
+					str("ProductName", "商品名称", "青岛啤酒"),
+					str("Quantity", "数量及单位", "2.00箱"),
+					str("Amount", "金额", "¥96.00"),
+				}},
+				str("ItemList", "商品明细文本（每行一项）", "1. 青岛啤酒 x2.00箱 = ¥96.00"),
+				str("TotalAmount", "合计金额（两位小数）", "192.00"),
+				num("ItemCount", "商品项数", 2),
+				num("OtherExpense", "其他支出", 0),
+				num("NetIncome", "净收入", 192),
+				str("Remark", "备注", ""),
+				str("ImageURL", "回单图片地址", ""),
+				str("CreateTime", "通知时间", "2026-01-01 12:00:00"),
+			},
+			Version:   1,
+			IsEnabled: true,
+		},
+		{
+			Code:        model.TemplatePreOrderReminder,
+			Name:        "预订单配送提醒",
+			Title:       "预订单提醒｜{{.RelativeDate}}配送",
+			Description: "预订单配送前的定时提醒",
+			Content: "### 预订单{{.RelativeDate}}提醒\n\n" +
+				"- **门店：** {{.StoreName}}\n" +
+				"- **客户：** {{.CustomerName}}\n" +
+				"- **配送时间：** {{.ScheduledAt}}\n" +
+				"{{with .Contact}}- **联系人：** {{.}}\n{{end}}" +
+				"{{with .DeliveryAddress}}- **配送地址：** {{.}}\n{{end}}" +
+				"\n**备货明细**\n\n" +
+				"{{range .Items}}- {{.ProductName}} / {{.UnitName}} × {{.Quantity}}{{with .Remark}}（{{.}}）{{end}}\n{{end}}" +
+				"{{with .Remark}}\n- **备注：** {{.}}\n{{end}}" +
+				"\n预订单号：{{.OrderNo}}",
+			Schema: model.TemplateSchema{
+				required(str("RelativeDate", "相对日期：今天/明天", "明天")),
+				required(str("StoreName", "门店名称", "旗舰店")),
+				required(str("CustomerName", "客户名称", "某某酒楼")),
+				required(str("ScheduledAt", "配送时间", "2026-01-02 10:00")),
+				str("Contact", "联系人及电话", "李四 13800000000"),
+				str("DeliveryAddress", "配送地址", "人民路 1 号"),
+				{Name: "Items", Type: model.TemplateVarList, Required: true, Description: "备货明细", Fields: []model.TemplateVariable{
+					str("ProductName", "商品名称", "青岛啤酒"),
+					str("UnitName", "单位", "箱"),
+					num("Quantity", "数量", 5),
+					str("Remark", "明细备注", ""),
+				}},
+				str("Remark", "备注", ""),
+				required(str("OrderNo", "预订单号", "YD20260101001")),
+			},
+			Version:   1,
+			IsEnabled: true,
+		},
+	}
+}
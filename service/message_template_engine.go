@@ -0,0 +1,334 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+// messageTemplateFuncs 模板可用的辅助函数
+var messageTemplateFuncs = template.FuncMap{
+	// inc 序号从 1 开始：{{range $i, $item := .Items}}{{inc $i}}{{end}}
+	"inc": func(i int) int { return i + 1 },
+	// money 金额保留两位小数
+	"money": func(v interface{}) string {
+		if f, ok := toFloat(v); ok {
+			return fmt.Sprintf("%.2f", f)
+		}
+		return fmt.Sprint(v)
+	},
+	// limit 取列表前 n 项，用于消息只展示部分明细
+	"limit": func(list interface{}, n int) interface{} {
+		rv := reflect.ValueOf(list)
+		if rv.Kind() != reflect.Slice || rv.Len() <= n {
+			return list
+		}
+		return rv.Slice(0, n).Interface()
+	},
+	"join": strings.Join,
+	"trim": strings.TrimSpace,
+	// default 值为空时使用默认值：{{default "未知" .OperatorName}}
+	"default": func(def, v interface{}) interface{} {
+		if v == nil || reflect.ValueOf(v).IsZero() {
+			return def
+		}
+		return v
+	},
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// parseMessageTemplate 解析模板，兼容误写语法：{{.range .Items}} / {{.end}}
+func parseMessageTemplate(tplStr string) (*template.Template, error) {
+	tplStr = strings.ReplaceAll(tplStr, "{{.range ", "{{range ")
+	tplStr = strings.ReplaceAll(tplStr, "{{.end}}", "{{end}}")
+	return template.New("msg").Funcs(messageTemplateFuncs).Parse(tplStr)
+}
+
+func renderMessageTemplate(tplStr string, data map[string]interface{}) (string, error) {
+	tpl, err := parseMessageTemplate(tplStr)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+var templateVarNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateTemplateSchema 校验变量声明：名称合法且同级不重复，类型有效，只有 list/object 可声明字段
+func validateTemplateSchema(vars []model.TemplateVariable, path string) error {
+	seen := make(map[string]bool, len(vars))
+	for _, v := range vars {
+		name := path + v.Name
+		if !templateVarNamePattern.MatchString(v.Name) {
+			return fmt.Errorf("变量名 %q 无效", name)
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("变量 %s 重复声明", name)
+		}
+		seen[v.Name] = true
+		switch v.Type {
+		case model.TemplateVarString, model.TemplateVarNumber, model.TemplateVarBool:
+			if len(v.Fields) > 0 {
+				return fmt.Errorf("变量 %s 为 %s 类型，不能声明字段", name, v.Type)
+			}
+		case model.TemplateVarList, model.TemplateVarObject:
+			if err := validateTemplateSchema(v.Fields, name+"."); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("变量 %s 的类型 %q 无效", name, v.Type)
+		}
+	}
+	return nil
+}
+
+// templateScope 模板中 "." 当前指向的变量集合；nil 表示无法确定（不再校验）
+type templateScope map[string]*model.TemplateVariable
+
+func newTemplateScope(vars []model.TemplateVariable) templateScope {
+	if len(vars) == 0 {
+		return nil
+	}
+	scope := make(templateScope, len(vars))
+	for i := range vars {
+		scope[vars[i].Name] = &vars[i]
+	}
+	return scope
+}
+
+// templateRefChecker 遍历模板语法树，找出变量结构中未声明的引用
+type templateRefChecker struct {
+	root    templateScope
+	vars    map[string]templateScope
+	unknown []string
+	seen    map[string]bool
+}
+
+// checkTemplateRefs 返回模板中引用但未在 schema 声明的变量；schema 为空时不校验
+func checkTemplateRefs(tplStr string, schema model.TemplateSchema) ([]string, error) {
+	tpl, err := parseMessageTemplate(tplStr)
+	if err != nil {
+		return nil, err
+	}
+	if len(schema) == 0 || tpl.Tree == nil {
+		return nil, nil
+	}
+	c := &templateRefChecker{root: newTemplateScope(schema), vars: map[string]templateScope{}, seen: map[string]bool{}}
+	c.vars["$"] = c.root
+	c.walk(tpl.Tree.Root, c.root)
+	return c.unknown, nil
+}
+
+func (c *templateRefChecker) walk(node parse.Node, dot templateScope) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			c.walk(child, dot)
+		}
+	case *parse.ActionNode:
+		c.declare(n.Pipe, c.pipe(n.Pipe, dot))
+	case *parse.IfNode:
+		c.pipe(n.Pipe, dot)
+		c.walk(n.List, dot)
+		c.walk(n.ElseList, dot)
+	case *parse.WithNode:
+		v := c.pipe(n.Pipe, dot)
+		inner := templateScope(nil)
+		if v != nil && v.Type == model.TemplateVarObject {
+			inner = newTemplateScope(v.Fields)
+		}
+		c.walk(n.List, inner)
+		c.walk(n.ElseList, dot)
+	case *parse.RangeNode:
+		v := c.pipe(n.Pipe, dot)
+		elem := templateScope(nil)
+		if v != nil && v.Type == model.TemplateVarList {
+			elem = newTemplateScope(v.Fields)
+		}
+		if decl := n.Pipe.Decl; len(decl) > 0 {
+			c.vars[decl[len(decl)-1].Ident[0]] = elem
+			if len(decl) > 1 {
+				c.vars[decl[0].Ident[0]] = nil
+			}
+		}
+		c.walk(n.List, elem)
+		c.walk(n.ElseList, dot)
+	}
+}
+
+// declare 记录 {{$x := .Field}} 声明的变量作用域
+func (c *templateRefChecker) declare(pipe *parse.PipeNode, v *model.TemplateVariable) {
+	if pipe == nil {
+		return
+	}
+	for _, decl := range pipe.Decl {
+		if v != nil && (v.Type == model.TemplateVarObject || v.Type == model.TemplateVarList) {
+			c.vars[decl.Ident[0]] = newTemplateScope(v.Fields)
+		} else {
+			c.vars[decl.Ident[0]] = nil
+		}
+	}
+}
+
+// pipe 检查管道中所有引用，返回管道结果对应的变量（能确定时），用于 range/with 推断作用域
+func (c *templateRefChecker) pipe(pipe *parse.PipeNode, dot templateScope) *model.TemplateVariable {
+	if pipe == nil {
+		return nil
+	}
+	var result *model.TemplateVariable
+	for _, cmd := range pipe.Cmds {
+		result = nil
+		for _, arg := range cmd.Args {
+			if v := c.arg(arg, dot); v != nil && result == nil {
+				result = v
+			}
+		}
+	}
+	return result
+}
+
+func (c *templateRefChecker) arg(node parse.Node, dot templateScope) *model.TemplateVariable {
+	switch n := node.(type) {
+	case *parse.FieldNode:
+		return c.resolve(dot, n.Ident, "")
+	case *parse.VariableNode:
+		scope, ok := c.vars[n.Ident[0]]
+		if !ok || len(n.Ident) == 1 {
+			return nil
+		}
+		prefix := n.Ident[0]
+		if prefix == "$" {
+			prefix = ""
+		}
+		return c.resolve(scope, n.Ident[1:], prefix)
+	case *parse.ChainNode:
+		c.arg(n.Node, dot)
+	case *parse.PipeNode:
+		return c.pipe(n, dot)
+	}
+	return nil
+}
+
+// resolve 按字段链在作用域中查找变量，遇到未声明字段记入 unknown
+func (c *templateRefChecker) resolve(scope templateScope, idents []string, prefix string) *model.TemplateVariable {
+	var v *model.TemplateVariable
+	for i, ident := range idents {
+		if scope == nil {
+			return nil
+		}
+		found, ok := scope[ident]
+		if !ok {
+			ref := prefix + "." + strings.Join(idents[:i+1], ".")
+			if !c.seen[ref] {
+				c.seen[ref] = true
+				c.unknown = append(c.unknown, ref)
+			}
+			return nil
+		}
+		v = found
+		scope = nil
+		if v.Type == model.TemplateVarObject {
+			scope = newTemplateScope(v.Fields)
+		}
+	}
+	return v
+}
+
+// sampleTemplateData 按变量结构生成预览示例数据：优先取 Example，否则按类型给出占位值
+func sampleTemplateData(vars []model.TemplateVariable) map[string]interface{} {
+	data := make(map[string]interface{}, len(vars))
+	for _, v := range vars {
+		data[v.Name] = sampleTemplateValue(v)
+	}
+	return data
+}
+
+func sampleTemplateValue(v model.TemplateVariable) interface{} {
+	if v.Example != nil && v.Type != model.TemplateVarList && v.Type != model.TemplateVarObject {
+		return v.Example
+	}
+	switch v.Type {
+	case model.TemplateVarNumber:
+		return 0
+	case model.TemplateVarBool:
+		return false
+	case model.TemplateVarList:
+		return []interface{}{sampleTemplateData(v.Fields), sampleTemplateData(v.Fields)}
+	case model.TemplateVarObject:
+		return sampleTemplateData(v.Fields)
+	}
+	return "{" + v.Name + "}"
+}
+
+// checkTemplateData 对照变量结构检查数据，返回缺失的必填变量与类型不符之处
+func checkTemplateData(vars []model.TemplateVariable, data map[string]interface{}, path string) []string {
+	var warnings []string
+	for _, v := range vars {
+		name := path + v.Name
+		value, ok := data[v.Name]
+		if !ok || value == nil {
+			if v.Required {
+				warnings = append(warnings, fmt.Sprintf("缺少必填变量 %s", name))
+			}
+			continue
+		}
+		rv := reflect.ValueOf(value)
+		switch v.Type {
+		case model.TemplateVarString:
+			if rv.Kind() != reflect.String {
+				warnings = append(warnings, fmt.Sprintf("变量 %s 应为文本", name))
+			}
+		case model.TemplateVarNumber:
+			if _, ok := toFloat(value); !ok {
+				warnings = append(warnings, fmt.Sprintf("变量 %s 应为数字", name))
+			}
+		case model.TemplateVarBool:
+			if rv.Kind() != reflect.Bool {
+				warnings = append(warnings, fmt.Sprintf("变量 %s 应为布尔值", name))
+			}
+		case model.TemplateVarList:
+			if rv.Kind() != reflect.Slice {
+				warnings = append(warnings, fmt.Sprintf("变量 %s 应为列表", name))
+				continue
+			}
+			for i := 0; i < rv.Len(); i++ {
+				if item, ok := rv.Index(i).Interface().(map[string]interface{}); ok {
+					warnings = append(warnings, checkTemplateData(v.Fields, item, fmt.Sprintf("%s[%d].", name, i))...)
+				}
+			}
+		case model.TemplateVarObject:
+			item, ok := value.(map[string]interface{})
+			if !ok {
+				warnings = append(warnings, fmt.Sprintf("变量 %s 应为对象", name))
+				continue
+			}
+			warnings = append(warnings, checkTemplateData(v.Fields, item, name+".")...)
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+func TestCheckTemplateRefs(t *testing.T) {
+	schema := model.TemplateSchema{
+		{Name: "StoreName", Type: model.TemplateVarString},
+		{Name: "Items", Type: model.TemplateVarList, Fields: []model.TemplateVariable{
+			{Name: "Name", Type: model.TemplateVarString},
+		}},
+		{Name: "Customer", Type: model.TemplateVarObject, Fields: []model.TemplateVariable{
+			{Name: "Phone", Type: model.TemplateVarString},
+		}},
+		{Name: "Remark", Type: model.TemplateVarString},
+	}
+	tpl := "{{.StoreName}}{{.Missing}}" +
+		"{{range $i, $item := .Items}}{{inc $i}}{{$item.Name}}{{.Name}}{{.Price}}{{$.StoreName}}{{$.Other}}{{end}}" +
+		"{{with .Customer}}{{.Phone}}{{.Email}}{{end}}{{.Customer.Phone}}{{.Customer.Address}}" +
+		"{{with .Remark}}{{.}}{{end}}{{if .Missing}}{{end}}"
+	got, err := checkTemplateRefs(tpl, schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{".Missing", ".Price", ".Other", ".Email", ".Customer.Address"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+
+	if got, err := checkTemplateRefs("{{.Anything}}", nil); err != nil || len(got) != 0 {
+		t.Fatalf("empty schema should skip checks, got %v %v", got, err)
+	}
+	if _, err := checkTemplateRefs("{{range .Items}}", schema); err == nil {
+		t.Fatal("expected syntax error")
+	}
+}
+
+func TestValidateTemplateSchema(t *testing.T) {
+	cases := []struct {
+		name   string
+		schema model.TemplateSchema
+		ok     bool
+	}{
+		{"valid", model.TemplateSchema{{Name: "Items", Type: model.TemplateVarList, Fields: []model.TemplateVariable{{Name: "Name", Type: model.TemplateVarString}}}}, true},
+		{"bad name", model.TemplateSchema{{Name: "1st", Type: model.TemplateVarString}}, false},
+		{"duplicate", model.TemplateSchema{{Name: "A", Type: model.TemplateVarString}, {Name: "A", Type: model.TemplateVarNumber}}, false},
+		{"bad type", model.TemplateSchema{{Name: "A", Type: "date"}}, false},
+		{"fields on scalar", model.TemplateSchema{{Name: "A", Type: model.TemplateVarString, Fields: []model.TemplateVariable{{Name: "B", Type: model.TemplateVarString}}}}, false},
+		{"nested bad type", model.TemplateSchema{{Name: "A", Type: model.TemplateVarObject, Fields: []model.TemplateVariable{{Name: "B", Type: "x"}}}}, false},
+	}
+	for _, tc := range cases {
+		if err := validateTemplateSchema(tc.schema, ""); (err == nil) != tc.ok {
+			t.Fatalf("%s: got err %v", tc.name, err)
+		}
+	}
+}
+
+func TestCheckTemplateData(t *testing.T) {
+	schema := model.TemplateSchema{
+		{Name: "StoreName", Type: model.TemplateVarString, Required: true},
+		{Name: "Count", Type: model.TemplateVarNumber},
+		{Name: "Items", Type: model.TemplateVarList, Fields: []model.TemplateVariable{
+			{Name: "Name", Type: model.TemplateVarString, Required: true},
+		}},
+	}
+	data := map[string]interface{}{
+		"Count": "3",
+		"Items": []interface{}{map[string]interface{}{"Name": "a"}, map[string]interface{}{}},
+	}
+	got := checkTemplateData(schema, data, "")
+	want := []string{"缺少必填变量 StoreName", "变量 Count 应为数字", "缺少必填变量 Items[1].Name"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+}
+
+func TestBuiltinStructuredTemplatesRenderSampleData(t *testing.T) {
+	for _, tpl := range builtinStructuredTemplates() {
+		if err := validateMessageTemplate(tpl.Title, tpl.Content, tpl.Schema); err != nil {
+			t.Fatalf("%s: %v", tpl.Code, err)
+		}
+		data := sampleTemplateData(tpl.Schema)
+		if warnings := checkTemplateData(tpl.Schema, data, ""); len(warnings) != 0 {
+			t.Fatalf("%s: sample data warnings %v", tpl.Code, warnings)
+		}
+		if _, err := renderMessageTemplate(tpl.Content, data); err != nil {
+			t.Fatalf("%s: %v", tpl.Code, err)
+		}
+	}
+}
+
+func builtinTemplate(t *testing.T, code string) *model.MessageTemplate {
+	for _, tpl := range builtinStructuredTemplates() {
+		if tpl.Code == code {
+			return tpl
+		}
+	}
+	t.Fatalf("builtin template %s not found", code)
+	return nil
+}
+
+// 内置模板与代码默认格式保持一致，模板缺失时回退不会改变消息内容
+func TestStoreAccountCardTemplateMatchesDefault(t *testing.T) {
+	tpl := builtinTemplate(t, model.TemplateStoreAccountCard)
+	for _, itemCount := range []int{0, 2, 10} {
+		account := &model.StoreAccount{
+			AccountNo:          "JZ001",
+			AccountDate:        time.Date(2026, 1, 2, 0, 0, 0, 0, time.Local),
+			TotalAmount:        120,
+			OtherExpenseAmount: 5.5,
+			NetIncomeAmount:    114.5,
+			Remark:             "备注",
+		}
+		if itemCount == 2 {
+			account.OtherExpenseAmount = 0
+			account.Remark = ""
+		}
+		for i := 0; i < itemCount; i++ {
+			account.Items = append(account.Items, model.StoreAccountItem{ProductName: fmt.Sprintf("商品%d", i), Quantity: 2, Unit: "箱", Amount: 12})
+		}
+		imageURL := ""
+		if itemCount == 10 {
+			imageURL = "https://example.com/a.png"
+		}
+		s := &StoreAccountService{}
+		title, want, _, _ := s.buildAccountActionCard(account, "旗舰店", "张三", "美团", storeAccountItemLines(account), imageURL, nil)
+		data := storeAccountTemplateData(account, "旗舰店", "张三", "美团", imageURL)
+		got, err := renderMessageTemplate(tpl.Content, data)
+		if err != nil {
+			t.Fatalf("render: %v", err)
+		}
+		if got != want {
+			t.Fatalf("items=%d\ngot:\n%s\nwant:\n%s", itemCount, got, want)
+		}
+		if gotTitle, _ := renderMessageTemplate(tpl.Title, data); gotTitle != title {
+			t.Fatalf("title got %q want %q", gotTitle, title)
+		}
+	}
+}
+
+func TestPreOrderReminderTemplateMatchesDefault(t *testing.T) {
+	tpl := builtinTemplate(t, model.TemplatePreOrderReminder)
+	order := &model.PreOrder{
+		OrderNo:         "YD001",
+		CustomerName:    "某某酒楼",
+		ContactPerson:   "李四",
+		DeliveryAddress: "人民路 1 号",
+		ScheduledAt:     time.Date(2026, 1, 2, 10, 0, 0, 0, preOrderLocation),
+		Items: []model.PreOrderItem{
+			{ProductName: "青岛啤酒", UnitName: "箱", Quantity: 5},
+			{ProductName: "雪花", UnitName: "瓶", Quantity: 1.5, Remark: "冰镇"},
+		},
+	}
+	for _, remark := range []string{"", "早点送"} {
+		order.Remark = remark
+		want := buildPreOrderReminderMarkdown(order, "旗舰店", "明天")
+		got, err := renderMessageTemplate(tpl.Content, preOrderReminderTemplateData(order, "旗舰店", "明天"))
+		if err != nil {
+			t.Fatalf("render: %v", err)
+		}
+		if got != want {
+			t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+		}
+	}
+}
+
+func TestRenderMessageTemplateLegacySyntax(t *testing.T) {
+	got, err := renderMessageTemplate("{{.range .Items}}{{.Name}};{{.end}}", map[string]interface{}{
+		"Items": []map[string]interface{}{{"Name": "a"}, {"Name": "b"}},
+	})
+	if err != nil || strings.TrimSpace(got) != "a;b;" {
+		t.Fatalf("got %q err %v", got, err)
+	}
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/module"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	"github.com/Kevin-Jii/tower-go/utils/logging"
+	"go.uber.org/zap"
+)
+
+// 发件箱投递参数：每轮最多处理的条数，以及单条发送的租约（超时未完成视为中断，可被重新抢占）
+const (
+	notificationBatchSize = 50
+	notificationLease     = 2 * time.Minute
+)
+
+// NotificationService 外发通知发件箱：业务服务只负责写入，后台任务负责投递与重试
+type NotificationService struct {
+	notificationModule *module.NotificationModule
+	routeModule        *module.NotificationRouteModule
+	botModule          *module.DingTalkBotModule
+	storeModule        *module.StoreModule
+	dingTalkService    *DingTalkService
+	imageGenerator     *ImageGeneratorService
+}
+
+func NewNotificationService(
+	notificationModule *module.NotificationModule,
+	routeModule *module.NotificationRouteModule,
+	botModule *module.DingTalkBotModule,
+	storeModule *module.StoreModule,
+	dingTalkService *DingTalkService,
+	imageGenerator *ImageGeneratorService,
+) *NotificationService {
+	return &NotificationService{
+		notificationModule: notificationModule,
+		routeModule:        routeModule,
+		botModule:          botModule,
+		storeModule:        storeModule,
+		dingTalkService:    dingTalkService,
+		imageGenerator:     imageGenerator,
+	}
+}
+
+// Notify 按路由规则解析接收方，每个接收方写入一条发件箱记录，返回写入条数。
+// message 提供业务单据与消息内容，payload 为卡片参数，Markdown 消息传 nil。
+func (s *NotificationService) Notify(event *model.NotificationEvent, message *model.NotificationOutbox, payload *model.NotificationPayload) (int, error) {
+	if s == nil || s.notificationModule == nil {
+		return 0, nil
+	}
+	recipients, err := s.resolveRecipients(event)
+	if err != nil {
+		logging.LogWarn("通知路由解析失败", zap.String("event_type", event.EventType), zap.Uint("store_id", event.StoreID), zap.Error(err))
+		return 0, err
+	}
+	if len(recipients) == 0 {
+		logging.LogWarn("通知没有可用的接收方", zap.String("event_type", event.EventType), zap.Uint("store_id", event.StoreID), zap.String("biz_no", message.BizNo))
+		return 0, nil
+	}
+	var data string
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return 0, fmt.Errorf("marshal notification payload: %w", err)
+		}
+		data = string(encoded)
+	}
+	for i, recipient := range recipients {
+		outbox := *message
+		outbox.ID = 0
+		outbox.Channel = model.NotificationChannelDingTalk
+		outbox.StoreID = event.StoreID
+		outbox.BizType = event.EventType
+		outbox.BotID = recipient.BotID
+		outbox.TargetType = recipient.TargetType
+		outbox.Target = recipient.Target
+		outbox.Payload = data
+		if err := s.notificationModule.Enqueue(&outbox); err != nil {
+			logging.LogWarn("通知写入发件箱失败", zap.String("biz_type", outbox.BizType), zap.String("biz_no", outbox.BizNo), zap.Error(err))
+			return i, err
+		}
+	}
+	return len(recipients), nil
+}
+
+// resolveRecipients 命中路由规则时按规则展开接收方，否则回退到门店绑定的机器人：
+// Stream 机器人发给门店负责人手机号，Webhook 机器人发到所在群。
+func (s *NotificationService) resolveRecipients(event *model.NotificationEvent) ([]model.NotificationRecipient, error) {
+	storePhone := ""
+	if s.storeModule != nil && event.StoreID > 0 {
+		if store, err := s.storeModule.GetByID(event.StoreID); err == nil && store != nil {
+			storePhone = strings.TrimSpace(store.Phone)
+		}
+	}
+	if s.routeModule != nil {
+		routes, err := s.routeModule.Match(event)
+		if err != nil {
+			return nil, err
+		}
+		if len(routes) > 0 {
+			return expandNotificationRoutes(routes, storePhone), nil
+		}
+	}
+
+	if s.botModule == nil {
+		return nil, nil
+	}
+	bot, err := s.botModule.GetByStoreID(event.StoreID)
+	if err != nil || bot == nil {
+		return nil, nil
+	}
+	if !strings.EqualFold(bot.BotType, "stream") {
+		return []model.NotificationRecipient{{BotID: bot.ID, TargetType: model.NotificationTargetWebhook}}, nil
+	}
+	if storePhone == "" {
+		return nil, nil
+	}
+	return []model.NotificationRecipient{{BotID: bot.ID, TargetType: model.NotificationTargetUser, Target: storePhone}}, nil
+}
+
+// expandNotificationRoutes 将命中的规则展开为接收方并去重；user 规则每个手机号一条
+func expandNotificationRoutes(routes []*model.NotificationRoute, storePhone string) []model.NotificationRecipient {
+	seen := make(map[model.NotificationRecipient]bool)
+	var recipients []model.NotificationRecipient
+	add := func(recipient model.NotificationRecipient) {
+		if seen[recipient] {
+			return
+		}
+		seen[recipient] = true
+		recipients = append(recipients, recipient)
+	}
+	for _, route := range routes {
+		switch route.TargetType {
+		case model.NotificationTargetWebhook:
+			add(model.NotificationRecipient{BotID: route.BotID, TargetType: model.NotificationTargetWebhook})
+		case model.NotificationTargetGroup:
+			if route.OpenConversationID != "" {
+				add(model.NotificationRecipient{BotID: route.BotID, TargetType: model.NotificationTargetGroup, Target: route.OpenConversationID})
+			}
+		case model.NotificationTargetUser:
+			mobiles := append([]string{}, route.Mobiles...)
+			if route.IncludeStorePhone && storePhone != "" {
+				mobiles = append(mobiles, storePhone)
+			}
+			for _, mobile := range mobiles {
+				if mobile = strings.TrimSpace(mobile); mobile != "" {
+					add(model.NotificationRecipient{BotID: route.BotID, TargetType: model.NotificationTargetUser, Target: mobile})
+				}
+			}
+		}
+	}
+	return recipients
+}
+
+// DeliverDue 投递到期的通知，返回本轮处理条数
+func (s *NotificationService) DeliverDue(now time.Time) (int, error) {
+	outboxes, err := s.notificationModule.ClaimDue(now, notificationLease, notificationBatchSize)
+	for _, outbox := range outboxes {
+		if deliverErr := s.deliver(outbox, 0); deliverErr != nil && err == nil {
+			err = deliverErr
+		}
+	}
+	return len(outboxes), err
+}
+
+// Resend 人工重发失败或死信通知：重新入队并立即投递一次
+func (s *NotificationService) Resend(id, storeID, operatorID uint, isHQ bool) (*model.NotificationOutbox, error) {
+	if _, err := s.Get(id, storeID, isHQ); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := s.notificationModule.Requeue(id, now); err != nil {
+		return nil, err
+	}
+	outbox, err := s.notificationModule.ClaimByID(id, now, notificationLease)
+	if err != nil {
+		return nil, err
+	}
+	if outbox != nil {
+		if err := s.deliver(outbox, operatorID); err != nil {
+			return nil, err
+		}
+	}
+	return s.Get(id, storeID, isHQ)
+}
+
+// Get 通知详情（含每次投递记录），门店账号只能查看本店通知
+func (s *NotificationService) Get(id, storeID uint, isHQ bool) (*model.NotificationOutbox, error) {
+	outbox, err := s.notificationModule.GetWithDeliveries(id)
+	if err != nil {
+		return nil, apicode.New(apicode.NotFound)
+	}
+	if !isHQ && outbox.StoreID != storeID {
+		return nil, apicode.New(apicode.OperationDenied)
+	}
+	return outbox, nil
+}
+
+func (s *NotificationService) List(req *model.ListNotificationReq) ([]*model.NotificationOutbox, int64, error) {
+	return s.notificationModule.List(req)
+}
+
+// deliver 发送一次并记录结果；返回值仅表示状态落库失败，发送失败由重试机制处理
+func (s *NotificationService) deliver(outbox *model.NotificationOutbox, operatorID uint) error {
+	start := time.Now()
+	s.renderImage(outbox)
+	sendErr := s.send(outbox)
+	if sendErr != nil {
+		logging.LogWarn("钉钉通知发送失败",
+			zap.Uint("outbox_id", outbox.ID),
+			zap.String("biz_type", outbox.BizType),
+			zap.String("biz_no", outbox.BizNo),
+			zap.Int("attempt", outbox.Attempts),
+			zap.Error(sendErr))
+	}
+	return s.notificationModule.Complete(outbox, sendErr, time.Since(start), operatorID, time.Now())
+}
+
+// renderImage 发件箱带有待生成图片时在投递前生成并回填；生成失败不阻塞发送，按无图消息投递，下次重试再尝试生成
+func (s *NotificationService) renderImage(outbox *model.NotificationOutbox) {
+	if outbox.ImageURL != "" || outbox.Payload == "" || s.imageGenerator == nil {
+		return
+	}
+	var payload model.NotificationPayload
+	if err := json.Unmarshal([]byte(outbox.Payload), &payload); err != nil || payload.Image == nil {
+		return
+	}
+	imageURL, err := s.generateImage(payload.Image)
+	if err != nil {
+		logging.LogWarn("通知图片生成失败", zap.Uint("outbox_id", outbox.ID), zap.String("kind", payload.Image.Kind), zap.Error(err))
+		return
+	}
+	applyNotificationImage(outbox, &payload, imageURL)
+	encoded, err := json.Marshal(&payload)
+	if err != nil {
+		return
+	}
+	outbox.Payload = string(encoded)
+	if err := s.notificationModule.SaveRenderedImage(outbox.ID, outbox.ImageURL, outbox.Payload); err != nil {
+		logging.LogWarn("通知图片回填失败", zap.Uint("outbox_id", outbox.ID), zap.Error(err))
+	}
+}
+
+func (s *NotificationService) generateImage(spec *model.NotificationImageSpec) (string, error) {
+	switch spec.Kind {
+	case model.NotificationImageKindStoreAccount:
+		var data AccountNotifyData
+		if err := json.Unmarshal(spec.Data, &data); err != nil {
+			return "", fmt.Errorf("解析记账回单数据失败: %w", err)
+		}
+		return s.imageGenerator.GenerateAccountNotifyImage(&data)
+	}
+	return "", fmt.Errorf("未知的通知图片类型 %s", spec.Kind)
+}
+
+// applyNotificationImage 将生成的图片地址回填到发件箱、卡片参数和 ActionCard 按钮，并清除待生成标记
+func applyNotificationImage(outbox *model.NotificationOutbox, payload *model.NotificationPayload, imageURL string) {
+	spec := payload.Image
+	outbox.ImageURL = imageURL
+	if len(spec.CardParamKeys) > 0 {
+		if payload.CardParam == nil {
+			payload.CardParam = make(map[string]interface{})
+		}
+		for _, key := range spec.CardParamKeys {
+			payload.CardParam[key] = imageURL
+		}
+	}
+	if spec.ButtonTitle != "" {
+		payload.ActionButtonTitle = spec.ButtonTitle
+		payload.ActionButtonURL = imageURL
+	}
+	payload.Image = nil
+}
+
+// send 按发送时的机器人配置投递；单聊卡片消息依次回退到 ActionCard、Markdown，避免通知丢失
+func (s *NotificationService) send(outbox *model.NotificationOutbox) error {
+	if s.dingTalkService == nil || s.botModule == nil {
+		return fmt.Errorf("钉钉服务未初始化")
+	}
+	bot, err := s.botModule.GetByID(outbox.BotID)
+	if err != nil || bot == nil {
+		return fmt.Errorf("机器人 %d 不存在", outbox.BotID)
+	}
+	if !bot.IsEnabled {
+		return fmt.Errorf("机器人【%s】已停用", bot.Name)
+	}
+	targetType := outbox.TargetType
+	if targetType == "" {
+		targetType = model.NotificationTargetUser
+		if !strings.EqualFold(bot.BotType, "stream") {
+			targetType = model.NotificationTargetWebhook
+		}
+	}
+	switch targetType {
+	case model.NotificationTargetWebhook:
+		return s.dingTalkService.SendMarkdownToBot(bot, outbox.Title, outbox.Content)
+	case model.NotificationTargetGroup:
+		text := outbox.Content
+		if outbox.ImageURL != "" {
+			text += "\n\n![](" + outbox.ImageURL + ")"
+		}
+		return s.dingTalkService.SendStreamMarkdownToGroup(bot, outbox.Title, text, outbox.Target)
+	}
+	if strings.TrimSpace(outbox.Target) == "" {
+		return fmt.Errorf("stream 机器人需要接收人手机号")
+	}
+
+	var sendErr error
+	if outbox.MsgType == model.NotificationMsgCard && outbox.Payload != "" {
+		var payload model.NotificationPayload
+		if err := json.Unmarshal([]byte(outbox.Payload), &payload); err != nil {
+			return fmt.Errorf("解析卡片参数失败: %w", err)
+		}
+		cardMsgKey := payload.CardMsgKey
+		if cardMsgKey == "" {
+			cardMsgKey = strings.TrimSpace(bot.CardMsgKey)
+		}
+		sendErr = fmt.Errorf("未配置卡片")
+		if cardMsgKey != "" && payload.CardParam != nil {
+			sendErr = s.dingTalkService.SendStreamCardToMobile(bot, cardMsgKey, outbox.Target, payload.CardParam)
+		}
+		if sendErr != nil && payload.ActionText != "" {
+			sendErr = s.dingTalkService.SendStreamActionCardToMobile(bot, payload.ActionTitle, payload.ActionText, payload.ActionButtonTitle, payload.ActionButtonURL, outbox.Target)
+		}
+		if sendErr == nil {
+			return nil
+		}
+	}
+	if outbox.ImageURL != "" {
+		return s.dingTalkService.SendStreamMarkdownWithImageToMobile(bot, outbox.Title, outbox.Content, outbox.ImageURL, outbox.Target)
+	}
+	return s.dingTalkService.SendStreamMarkdownToMobile(bot, outbox.Title, outbox.Content, outbox.Target)
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/module"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+)
+
+// NotificationRouteService 通知路由规则维护
+type NotificationRouteService struct {
+	routeModule     *module.NotificationRouteModule
+	botModule       *module.DingTalkBotModule
+	storeModule     *module.StoreModule
+	dingTalkService *DingTalkService
+}
+
+func NewNotificationRouteService(
+	routeModule *module.NotificationRouteModule,
+	botModule *module.DingTalkBotModule,
+	storeModule *module.StoreModule,
+	dingTalkService *DingTalkService,
+) *NotificationRouteService {
+	return &NotificationRouteService{
+		routeModule:     routeModule,
+		botModule:       botModule,
+		storeModule:     storeModule,
+		dingTalkService: dingTalkService,
+	}
+}
+
+func (s *NotificationRouteService) Create(req *model.UpsertNotificationRouteReq) (*model.NotificationRoute, error) {
+	route := &model.NotificationRoute{IsEnabled: true}
+	if err := s.apply(route, req); err != nil {
+		return nil, err
+	}
+	if err := s.routeModule.Create(route); err != nil {
+		return nil, err
+	}
+	return s.routeModule.GetByID(route.ID)
+}
+
+func (s *NotificationRouteService) Update(id uint, req *model.UpsertNotificationRouteReq) (*model.NotificationRoute, error) {
+	route, err := s.routeModule.GetByID(id)
+	if err != nil {
+		return nil, apicode.New(apicode.NotFound)
+	}
+	if err := s.apply(route, req); err != nil {
+		return nil, err
+	}
+	route.Store = nil
+	if err := s.routeModule.Update(route); err != nil {
+		return nil, err
+	}
+	return s.routeModule.GetByID(id)
+}
+
+func (s *NotificationRouteService) Delete(id uint) error {
+	if _, err := s.routeModule.GetByID(id); err != nil {
+		return apicode.New(apicode.NotFound)
+	}
+	return s.routeModule.Delete(id)
+}
+
+func (s *NotificationRouteService) Get(id uint) (*model.NotificationRoute, error) {
+	route, err := s.routeModule.GetByID(id)
+	if err != nil {
+		return nil, apicode.New(apicode.NotFound)
+	}
+	return route, nil
+}
+
+func (s *NotificationRouteService) List(req *model.ListNotificationRouteReq) ([]*model.NotificationRoute, int64, error) {
+	return s.routeModule.List(req)
+}
+
+// apply 校验请求并写入规则：机器人类型须与接收目标匹配，群目标未填会话ID时按群号向钉钉解析
+func (s *NotificationRouteService) apply(route *model.NotificationRoute, req *model.UpsertNotificationRouteReq) error {
+	if !model.IsNotificationEventType(req.EventType) {
+		return apicode.Newf(apicode.ValidationFailed, "不支持的通知事件 %s", req.EventType)
+	}
+	if req.StoreID > 0 {
+		if _, err := s.storeModule.GetByID(req.StoreID); err != nil {
+			return apicode.New(apicode.StoreNotFound)
+		}
+	}
+	bot, err := s.botModule.GetByID(req.BotID)
+	if err != nil || bot == nil {
+		return apicode.Newf(apicode.ValidationFailed, "机器人 %d 不存在", req.BotID)
+	}
+	isStream := strings.EqualFold(bot.BotType, "stream")
+	if req.TargetType == model.NotificationTargetWebhook && isStream {
+		return apicode.Newf(apicode.ValidationFailed, "机器人【%s】为 Stream 模式，请选择指定用户或群会话", bot.Name)
+	}
+	if req.TargetType != model.NotificationTargetWebhook && !isStream {
+		return apicode.Newf(apicode.ValidationFailed, "机器人【%s】为 Webhook 模式，只能发送到其所在群", bot.Name)
+	}
+
+	mobiles := model.StringList{}
+	for _, mobile := range req.Mobiles {
+		if mobile = strings.TrimSpace(mobile); mobile != "" {
+			mobiles = append(mobiles, mobile)
+		}
+	}
+	chatID := strings.TrimSpace(req.ChatID)
+	openConversationID := strings.TrimSpace(req.OpenConversationID)
+	switch req.TargetType {
+	case model.NotificationTargetUser:
+		if len(mobiles) == 0 && !req.IncludeStorePhone {
+			return apicode.Newf(apicode.ValidationFailed, "请填写接收人手机号或勾选发送给门店负责人")
+		}
+		chatID, openConversationID = "", ""
+	case model.NotificationTargetGroup:
+		if openConversationID == "" && chatID != "" && (chatID != route.ChatID || route.OpenConversationID == "") {
+			openConversationID, err = s.resolveOpenConversationID(bot.ID, chatID)
+			if err != nil {
+				return err
+			}
+		}
+		if openConversationID == "" {
+			openConversationID = route.OpenConversationID
+		}
+		if openConversationID == "" {
+			return apicode.Newf(apicode.ValidationFailed, "请填写群号或群会话ID")
+		}
+		mobiles = model.StringList{}
+		req.IncludeStorePhone = false
+	default:
+		mobiles = model.StringList{}
+		req.IncludeStorePhone = false
+		chatID, openConversationID = "", ""
+	}
+
+	route.Name = strings.TrimSpace(req.Name)
+	route.EventType = req.EventType
+	route.StoreID = req.StoreID
+	route.Channel = strings.TrimSpace(req.Channel)
+	route.MinAmount = req.MinAmount
+	route.BotID = bot.ID
+	route.TargetType = req.TargetType
+	route.Mobiles = mobiles
+	route.IncludeStorePhone = req.IncludeStorePhone
+	route.ChatID = chatID
+	route.OpenConversationID = openConversationID
+	route.Remark = strings.TrimSpace(req.Remark)
+	if req.IsEnabled != nil {
+		route.IsEnabled = *req.IsEnabled
+	}
+	return nil
+}
+
+// resolveOpenConversationID 通过 GetOpenConversationIdByBotId 将群号解析为群会话ID
+func (s *NotificationRouteService) resolveOpenConversationID(botID uint, chatID string) (string, error) {
+	if s.dingTalkService == nil {
+		return "", apicode.New(apicode.ConfigMissing)
+	}
+	result, err := s.dingTalkService.GetOpenConversationIdByBotId(botID, chatID)
+	if err != nil {
+		return "", apicode.Newf(apicode.ExternalServiceFailed, "解析群会话ID失败: %v", err)
+	}
+	if id, ok := result["open_conversation_id"].(string); ok && id != "" {
+		return id, nil
+	}
+	return "", apicode.Newf(apicode.ValidationFailed, "未能通过群号解析群会话ID，请在群内@机器人后从回调中获取并直接填写")
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+func TestExpandNotificationRoutes(t *testing.T) {
+	routes := []*model.NotificationRoute{
+		{BotID: 1, TargetType: model.NotificationTargetWebhook},
+		{BotID: 2, TargetType: model.NotificationTargetUser, Mobiles: model.StringList{"13800000001", " 13800000002 "}, IncludeStorePhone: true},
+		{BotID: 2, TargetType: model.NotificationTargetUser, Mobiles: model.StringList{"13800000001"}},
+		{BotID: 2, TargetType: model.NotificationTargetGroup, OpenConversationID: "cid123"},
+		{BotID: 2, TargetType: model.NotificationTargetGroup},
+	}
+	got := expandNotificationRoutes(routes, "13900000000")
+	want := []model.NotificationRecipient{
+		{BotID: 1, TargetType: model.NotificationTargetWebhook},
+		{BotID: 2, TargetType: model.NotificationTargetUser, Target: "13800000001"},
+		{BotID: 2, TargetType: model.NotificationTargetUser, Target: "13800000002"},
+		{BotID: 2, TargetType: model.NotificationTargetUser, Target: "13900000000"},
+		{BotID: 2, TargetType: model.NotificationTargetGroup, Target: "cid123"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("recipient %d: got %+v want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestExpandNotificationRoutesSkipsEmptyStorePhone(t *testing.T) {
+	routes := []*model.NotificationRoute{
+		{BotID: 2, TargetType: model.NotificationTargetUser, IncludeStorePhone: true},
+	}
+	if got := expandNotificationRoutes(routes, ""); len(got) != 0 {
+		t.Fatalf("expected no recipients, got %+v", got)
+	}
+}
+
+func TestApplyNotificationImage(t *testing.T) {
+	outbox := &model.NotificationOutbox{}
+	payload := &model.NotificationPayload{
+		CardParam:         map[string]interface{}{"imageUrl": ""},
+		ActionButtonTitle: "查看详情",
+		ActionButtonURL:   "https://www.dingtalk.com/",
+		Image: &model.NotificationImageSpec{
+			Kind:          model.NotificationImageKindStoreAccount,
+			CardParamKeys: []string{"shangpinimg", "imageUrl"},
+			ButtonTitle:   "查看记账回单",
+		},
+	}
+	applyNotificationImage(outbox, payload, "https://img/1.png")
+	if outbox.ImageURL != "https://img/1.png" {
+		t.Fatalf("outbox image = %q", outbox.ImageURL)
+	}
+	if payload.CardParam["imageUrl"] != "https://img/1.png" || payload.CardParam["shangpinimg"] != "https://img/1.png" {
+		t.Fatalf("card params = %#v", payload.CardParam)
+	}
+	if payload.ActionButtonTitle != "查看记账回单" || payload.ActionButtonURL != "https://img/1.png" {
+		t.Fatalf("button = %q %q", payload.ActionButtonTitle, payload.ActionButtonURL)
+	}
+	if payload.Image != nil {
+		t.Fatal("image spec should be cleared after rendering")
+	}
+}
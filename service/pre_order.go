@@ -41,8 +41,9 @@ type PreOrderService struct {
 	productModule       *module.SupplierProductModule
 	unitSpecModule      *module.ProductUnitSpecModule
 	storeModule         *module.StoreModule
-	botModule           *module.DingTalkBotModule
-	dingTalkService     *DingTalkService
+	notifier            *NotificationService
+	templateService     *MessageTemplateService
+	autoPrinter         *PrintDocumentService
 }
 
 func NewPreOrderService(
@@ -52,8 +53,8 @@ func NewPreOrderService(
 	productModule *module.SupplierProductModule,
 	unitSpecModule *module.ProductUnitSpecModule,
 	storeModule *module.StoreModule,
-	botModule *module.DingTalkBotModule,
-	dingTalkService *DingTalkService,
+	notifier *NotificationService,
+	templateService *MessageTemplateService,
 ) *PreOrderService {
 	return &PreOrderService{
 		preOrderModule:      preOrderModule,
@@ -62,11 +63,16 @@ func NewPreOrderService(
 		productModule:       productModule,
 		unitSpecModule:      unitSpecModule,
 		storeModule:         storeModule,
-		botModule:           botModule,
-		dingTalkService:     dingTalkService,
+		notifier:            notifier,
+		templateService:     templateService,
 	}
 }
 
+// EnableAutoPrint 开启预订单创建后按门店规则自动打印拣货单
+func (s *PreOrderService) EnableAutoPrint(printer *PrintDocumentService) {
+	s.autoPrinter = printer
+}
+
 func (s *PreOrderService) Create(storeID, userID uint, req *model.CreatePreOrderReq) (*model.PreOrder, error) {
 	if storeID == 0 {
 		return nil, apicode.New(apicode.StoreRequired)
@@ -85,6 +91,10 @@ func (s *PreOrderService) Create(storeID, userID uint, req *model.CreatePreOrder
 	if err := s.preOrderModule.Create(order); err != nil {
 		return nil, err
 	}
+	if s.autoPrinter != nil {
+		go s.autoPrinter.AutoPrint(model.PrintDocPreOrderPick, order.ID, order.StoreID)
+	}
+	publishPreOrderChanged(order, model.RealtimeActionCreated)
 	return s.preOrderModule.GetByID(order.ID)
 }
 
@@ -112,6 +122,7 @@ func (s *PreOrderService) Update(id, storeID uint, hqUnbound bool, req *model.Up
 	if err := s.preOrderModule.Update(order, items, resetReminders); err != nil {
 		return nil, err
 	}
+	publishPreOrderChanged(existing, model.RealtimeActionUpdated)
 	return s.preOrderModule.GetByID(id)
 }
 
@@ -207,6 +218,18 @@ func (s *PreOrderService) Get(id, storeID uint, hqUnbound bool) (*model.PreOrder
 	return s.getScoped(id, storeID, hqUnbound)
 }
 
+// GetByOrderNo 按预订单号获取预订单，门店账号只能查看本店
+func (s *PreOrderService) GetByOrderNo(orderNo string, storeID uint, hqUnbound bool) (*model.PreOrder, error) {
+	id, err := s.preOrderModule.GetIDByOrderNo(orderNo)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apicode.New(apicode.OrderNotFound)
+		}
+		return nil, err
+	}
+	return s.getScoped(id, storeID, hqUnbound)
+}
+
 func (s *PreOrderService) List(req *model.ListPreOrderReq) ([]*model.PreOrder, int64, error) {
 	return s.preOrderModule.List(req)
 }
@@ -222,6 +245,11 @@ func (s *PreOrderService) UpdateStatus(id, storeID uint, hqUnbound bool, status
 	if err := s.preOrderModule.UpdateStatus(id, status, time.Now().In(preOrderLocation)); err != nil {
 		return nil, err
 	}
+	action := model.RealtimeActionUpdated
+	if status == model.PreOrderStatusCancelled {
+		action = model.RealtimeActionCanceled
+	}
+	publishPreOrderChanged(order, action)
 	return s.preOrderModule.GetByID(id)
 }
 
@@ -233,7 +261,11 @@ func (s *PreOrderService) Delete(id, storeID uint, hqUnbound bool) error {
 	if order.Status != model.PreOrderStatusPending && order.Status != model.PreOrderStatusCancelled {
 		return apicode.New(apicode.OrderDeletionDenied)
 	}
-	return s.preOrderModule.Delete(id)
+	if err := s.preOrderModule.Delete(id); err != nil {
+		return err
+	}
+	publishPreOrderChanged(order, model.RealtimeActionDeleted)
+	return nil
 }
 
 func (s *PreOrderService) getScoped(id, storeID uint, hqUnbound bool) (*model.PreOrder, error) {
@@ -342,24 +374,91 @@ func (s *PreOrderService) ProcessReminderSlot(now time.Time, slot string) error
 	return firstErr
 }
 
+// sendReminder 生成预订单提醒，按通知路由写入发件箱；没有可用接收方时返回错误以记录提醒失败
 func (s *PreOrderService) sendReminder(order *model.PreOrder, relativeDate string) error {
-	bot, err := s.botModule.GetByStoreID(order.StoreID)
-	if err != nil {
-		return fmt.Errorf("get DingTalk bot: %w", err)
-	}
 	store, err := s.storeModule.GetByID(order.StoreID)
 	if err != nil {
 		return fmt.Errorf("get store: %w", err)
 	}
-	title := fmt.Sprintf("预订单提醒｜%s配送", relativeDate)
-	text := buildPreOrderReminderMarkdown(order, store.Name, relativeDate)
-	if strings.EqualFold(bot.BotType, "stream") {
-		if strings.TrimSpace(store.Phone) == "" {
-			return fmt.Errorf("store phone is required for stream reminder")
+	title, text := s.renderReminder(order, store.Name, relativeDate)
+	count, err := s.notifier.Notify(&model.NotificationEvent{
+		EventType: model.NotificationBizPreOrderReminder,
+		StoreID:   order.StoreID,
+	}, &model.NotificationOutbox{
+		BizID:   order.ID,
+		BizNo:   order.OrderNo,
+		Title:   title,
+		Content: text,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("no notification recipient for store %d", order.StoreID)
+	}
+	return nil
+}
+
+// renderReminder 优先使用 pre_order_reminder 模板，模板缺失或渲染失败时按默认格式拼装
+func (s *PreOrderService) renderReminder(order *model.PreOrder, storeName, relativeDate string) (string, string) {
+	if s.templateService != nil {
+		title, text, err := s.templateService.RenderTemplate(model.TemplatePreOrderReminder, preOrderReminderTemplateData(order, storeName, relativeDate))
+		if err == nil && strings.TrimSpace(text) != "" {
+			if title == "" {
+				title = fmt.Sprintf("预订单提醒｜%s配送", relativeDate)
+			}
+			return title, text
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			logging.LogWarn("预订单提醒模板渲染失败，使用默认格式", zap.Uint("pre_order_id", order.ID), zap.Error(err))
 		}
-		return s.dingTalkService.SendStreamMarkdownToMobile(bot, title, text, store.Phone)
 	}
-	return s.dingTalkService.SendMarkdownToBot(bot, title, text)
+	return fmt.Sprintf("预订单提醒｜%s配送", relativeDate), buildPreOrderReminderMarkdown(order, storeName, relativeDate)
+}
+
+// preOrderReminderTemplateData 预订单提醒模板数据
+func preOrderReminderTemplateData(order *model.PreOrder, storeName, relativeDate string) map[string]interface{} {
+	items := make([]interface{}, 0, len(order.Items))
+	for _, item := range order.Items {
+		items = append(items, map[string]interface{}{
+			"ProductName": item.ProductName,
+			"UnitName":    item.UnitName,
+			"Quantity":    item.Quantity,
+			"Remark":      item.Remark,
+		})
+	}
+	return map[string]interface{}{
+		"RelativeDate":    relativeDate,
+		"StoreName":       storeName,
+		"CustomerName":    order.CustomerName,
+		"ScheduledAt":     order.ScheduledAt.In(preOrderLocation).Format("2006-01-02 15:04"),
+		"Contact":         strings.TrimSpace(strings.Join([]string{order.ContactPerson, order.ContactPhone}, " ")),
+		"DeliveryAddress": order.DeliveryAddress,
+		"Items":           items,
+		"Remark":          order.Remark,
+		"OrderNo":         order.OrderNo,
+	}
+}
+
+// ReminderTemplateData 按预订单构建提醒模板数据，供模板预览使用；相对日期按当前时间推算
+func (s *PreOrderService) ReminderTemplateData(id uint) (map[string]interface{}, error) {
+	order, err := s.preOrderModule.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	storeName := ""
+	if order.Store != nil {
+		storeName = order.Store.Name
+	}
+	now := time.Now()
+	relativeDate := order.ScheduledAt.In(preOrderLocation).Format("01月02日")
+	switch {
+	case samePreOrderDate(order.ScheduledAt, now):
+		relativeDate = "今天"
+	case samePreOrderDate(order.ScheduledAt, now.AddDate(0, 0, 1)):
+		relativeDate = "明天"
+	}
+	return preOrderReminderTemplateData(order, storeName, relativeDate), nil
 }
 
 func buildPreOrderReminderMarkdown(order *model.PreOrder, storeName, relativeDate string) string {
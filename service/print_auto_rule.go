@@ -0,0 +1,95 @@
+package service
+
+import (
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	"github.com/Kevin-Jii/tower-go/utils/logging"
+	"go.uber.org/zap"
+)
+
+// ListAutoRules 自动打印规则列表，storeID 为 0 时返回全部门店
+func (s *PrintDocumentService) ListAutoRules(storeID uint) ([]*model.PrintAutoRule, error) {
+	return s.autoRuleModule.List(storeID)
+}
+
+// SaveAutoRule 保存门店某类单据的自动打印规则；指定打印机时须为该门店的小票打印机
+func (s *PrintDocumentService) SaveAutoRule(storeID uint, isHQ bool, req *model.SavePrintAutoRuleReq) (*model.PrintAutoRule, error) {
+	ruleStoreID := req.StoreID
+	if !isHQ {
+		ruleStoreID = storeID
+	}
+	if ruleStoreID == 0 {
+		return nil, apicode.New(apicode.StoreRequired)
+	}
+	if req.PrinterID > 0 {
+		printer, err := s.printerModule.GetByID(req.PrinterID)
+		if err != nil {
+			return nil, apicode.New(apicode.PrinterNotFound)
+		}
+		if printer.StoreID != ruleStoreID {
+			return nil, apicode.Newf(apicode.OperationDenied, "只能选择本门店的打印机")
+		}
+		if printer.Type != model.PrinterTypeReceipt {
+			return nil, apicode.Newf(apicode.ValidationFailed, "单据需使用小票打印机打印")
+		}
+	}
+	rule := buildPrintAutoRule(ruleStoreID, req)
+	if err := s.autoRuleModule.Upsert(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// DeleteAutoRule 删除自动打印规则，门店账号只能删除本门店规则
+func (s *PrintDocumentService) DeleteAutoRule(id, storeID uint, isHQ bool) error {
+	rule, err := s.autoRuleModule.GetByID(id)
+	if err != nil || (!isHQ && rule.StoreID != storeID) {
+		return apicode.New(apicode.NotFound)
+	}
+	return s.autoRuleModule.Delete(rule.ID)
+}
+
+// AutoPrint 单据创建后按门店规则自动打印（异步调用）；未配置规则时跳过，失败只记录日志，可在打印任务中补打
+func (s *PrintDocumentService) AutoPrint(docType string, docID, storeID uint) {
+	rule, err := s.autoRuleModule.GetEnabled(storeID, docType)
+	if err != nil {
+		logging.LogWarn("查询自动打印规则失败", zap.Uint("store_id", storeID), zap.String("doc_type", docType), zap.Error(err))
+		return
+	}
+	if rule == nil {
+		return
+	}
+	var printDoc func(id, storeID uint, isHQ bool, operatorID uint, req *model.PrintDocumentReq) (*model.PrintJob, error)
+	switch docType {
+	case model.PrintDocStoreAccount:
+		printDoc = s.PrintStoreAccount
+	case model.PrintDocPreOrderPick:
+		printDoc = s.PrintPreOrderPicking
+	case model.PrintDocB2BDelivery:
+		printDoc = s.PrintB2BDeliveryNote
+	default:
+		return
+	}
+	req := &model.PrintDocumentReq{PrinterID: rule.PrinterID, Copies: rule.Copies}
+	if _, err := printDoc(docID, storeID, false, 0, req); err != nil {
+		logging.LogWarn("自动打印失败", zap.String("doc_type", docType), zap.Uint("doc_id", docID), zap.Error(err))
+	}
+}
+
+// buildPrintAutoRule 按请求生成规则，份数缺省 1 份，未传启用状态时默认启用
+func buildPrintAutoRule(storeID uint, req *model.SavePrintAutoRuleReq) *model.PrintAutoRule {
+	rule := &model.PrintAutoRule{
+		StoreID:   storeID,
+		DocType:   req.DocType,
+		PrinterID: req.PrinterID,
+		Copies:    req.Copies,
+		Enabled:   1,
+	}
+	if rule.Copies <= 0 {
+		rule.Copies = 1
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	return rule
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+func TestBuildPrintAutoRuleDefaults(t *testing.T) {
+	rule := buildPrintAutoRule(3, &model.SavePrintAutoRuleReq{DocType: model.PrintDocPreOrderPick})
+	if rule.StoreID != 3 || rule.DocType != model.PrintDocPreOrderPick {
+		t.Fatalf("unexpected rule %+v", rule)
+	}
+	if rule.Copies != 1 || rule.Enabled != 1 || rule.PrinterID != 0 {
+		t.Fatalf("defaults = copies %d enabled %d printer %d, want 1/1/0", rule.Copies, rule.Enabled, rule.PrinterID)
+	}
+}
+
+func TestBuildPrintAutoRuleExplicit(t *testing.T) {
+	disabled := int8(0)
+	rule := buildPrintAutoRule(3, &model.SavePrintAutoRuleReq{
+		StoreID:   9, // 以调用方解析的门店为准
+		DocType:   model.PrintDocB2BDelivery,
+		PrinterID: 5,
+		Copies:    2,
+		Enabled:   &disabled,
+	})
+	if rule.StoreID != 3 || rule.PrinterID != 5 || rule.Copies != 2 || rule.Enabled != 0 {
+		t.Fatalf("unexpected rule %+v", rule)
+	}
+}
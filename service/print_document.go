@@ -0,0 +1,360 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/module"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+)
+
+// PrintDocumentService 业务单据打印：加载单据、按打印机纸宽排版并提交打印任务
+type PrintDocumentService struct {
+	printerService     *PrinterService
+	printerModule      *module.PrinterModule
+	storeModule        *module.StoreModule
+	storeAccountModule *module.StoreAccountModule
+	preOrderModule     *module.PreOrderModule
+	b2bModule          *module.B2BModule
+	inventoryModule    *module.InventoryModule
+	storeReturnModule  *module.StoreReturnModule
+	autoRuleModule     *module.PrintAutoRuleModule
+}
+
+func NewPrintDocumentService(
+	printerService *PrinterService,
+	printerModule *module.PrinterModule,
+	storeModule *module.StoreModule,
+	storeAccountModule *module.StoreAccountModule,
+	preOrderModule *module.PreOrderModule,
+	b2bModule *module.B2BModule,
+	inventoryModule *module.InventoryModule,
+	storeReturnModule *module.StoreReturnModule,
+	autoRuleModule *module.PrintAutoRuleModule,
+) *PrintDocumentService {
+	return &PrintDocumentService{
+		printerService:     printerService,
+		printerModule:      printerModule,
+		storeModule:        storeModule,
+		storeAccountModule: storeAccountModule,
+		preOrderModule:     preOrderModule,
+		b2bModule:          b2bModule,
+		inventoryModule:    inventoryModule,
+		storeReturnModule:  storeReturnModule,
+		autoRuleModule:     autoRuleModule,
+	}
+}
+
+// PrintStoreAccount 打印记账销售小票
+func (s *PrintDocumentService) PrintStoreAccount(id, storeID uint, isHQ bool, operatorID uint, req *model.PrintDocumentReq) (*model.PrintJob, error) {
+	account, err := s.storeAccountModule.GetByIDScoped(id, storeID, isHQ)
+	if err != nil {
+		return nil, apicode.New(apicode.NotFound)
+	}
+	return s.submit(account.StoreID, storeID, isHQ, operatorID, req, &PrintJobSpec{
+		DocType: model.PrintDocStoreAccount,
+		DocID:   account.ID,
+		DocNo:   account.AccountNo,
+		Render:  func(paperWidth int) string { return renderStoreAccountReceipt(account, paperWidth) },
+	})
+}
+
+// PrintPreOrderPicking 打印预订单拣货单
+func (s *PrintDocumentService) PrintPreOrderPicking(id, storeID uint, isHQ bool, operatorID uint, req *model.PrintDocumentReq) (*model.PrintJob, error) {
+	order, err := s.preOrderModule.GetByID(id)
+	if err != nil || (!isHQ && order.StoreID != storeID) {
+		return nil, apicode.New(apicode.NotFound)
+	}
+	if order.Status == model.PreOrderStatusCancelled {
+		return nil, apicode.Newf(apicode.OperationDenied, "预订单已取消，无需拣货")
+	}
+	return s.submit(order.StoreID, storeID, isHQ, operatorID, req, &PrintJobSpec{
+		DocType: model.PrintDocPreOrderPick,
+		DocID:   order.ID,
+		DocNo:   order.OrderNo,
+		Render:  func(paperWidth int) string { return renderPreOrderPicking(order, paperWidth) },
+	})
+}
+
+// PrintB2BDeliveryNote 打印 B2B 送货单（含客户签收栏）
+func (s *PrintDocumentService) PrintB2BDeliveryNote(id, storeID uint, isHQ bool, operatorID uint, req *model.PrintDocumentReq) (*model.PrintJob, error) {
+	order, err := s.b2bModule.GetSupplyOrder(id)
+	if err != nil || (!isHQ && order.StoreID != storeID) {
+		return nil, apicode.New(apicode.OrderNotFound)
+	}
+	if order.DeliveryStatus == model.B2BDeliveryCancel {
+		return nil, apicode.Newf(apicode.OperationDenied, "供货单已取消，不能打印送货单")
+	}
+	storeName := s.storeName(order.StoreID)
+	return s.submit(order.StoreID, storeID, isHQ, operatorID, req, &PrintJobSpec{
+		DocType: model.PrintDocB2BDelivery,
+		DocID:   order.ID,
+		DocNo:   order.OrderNo,
+		Render:  func(paperWidth int) string { return renderB2BDeliveryNote(order, storeName, paperWidth) },
+	})
+}
+
+// PrintInventoryOrder 打印出入库单
+func (s *PrintDocumentService) PrintInventoryOrder(id, storeID uint, isHQ bool, operatorID uint, req *model.PrintDocumentReq) (*model.PrintJob, error) {
+	order, err := s.inventoryModule.GetOrderByID(id)
+	if err != nil || (!isHQ && order.StoreID != storeID) {
+		return nil, apicode.New(apicode.NotFound)
+	}
+	return s.submit(order.StoreID, storeID, isHQ, operatorID, req, &PrintJobSpec{
+		DocType: model.PrintDocInventory,
+		DocID:   order.ID,
+		DocNo:   order.OrderNo,
+		Render:  func(paperWidth int) string { return renderInventoryOrder(order, paperWidth) },
+	})
+}
+
+// PrintStoreReturn 打印返厂单
+func (s *PrintDocumentService) PrintStoreReturn(id, storeID uint, isHQ bool, operatorID uint, req *model.PrintDocumentReq) (*model.PrintJob, error) {
+	record, err := s.storeReturnModule.GetByIDScoped(id, storeID, isHQ)
+	if err != nil {
+		return nil, apicode.New(apicode.NotFound)
+	}
+	return s.submit(record.StoreID, storeID, isHQ, operatorID, req, &PrintJobSpec{
+		DocType: model.PrintDocStoreReturn,
+		DocID:   record.ID,
+		DocNo:   record.ReturnNo,
+		Render:  func(paperWidth int) string { return renderStoreReturn(record, paperWidth) },
+	})
+}
+
+// submit 选定小票打印机后提交任务：未指定时用单据门店的默认打印机，门店账号只能使用本门店打印机
+func (s *PrintDocumentService) submit(docStoreID, storeID uint, isHQ bool, operatorID uint, req *model.PrintDocumentReq, spec *PrintJobSpec) (*model.PrintJob, error) {
+	var (
+		printer *model.Printer
+		err     error
+	)
+	if req.PrinterID > 0 {
+		printer, err = s.printerModule.GetByID(req.PrinterID)
+	} else {
+		printer, err = s.printerModule.GetDefaultByStoreID(docStoreID)
+	}
+	if err != nil || printer == nil {
+		return nil, apicode.New(apicode.PrinterNotFound)
+	}
+	if !isHQ && printer.StoreID != storeID {
+		return nil, apicode.Newf(apicode.OperationDenied, "只能使用本门店的打印机")
+	}
+	if printer.Type != model.PrinterTypeReceipt {
+		return nil, apicode.Newf(apicode.ValidationFailed, "单据需使用小票打印机打印")
+	}
+	spec.PrinterID = printer.ID
+	spec.StoreID = docStoreID
+	spec.Copies = req.Copies
+	spec.OperatorID = operatorID
+	return s.printerService.SubmitJob(spec)
+}
+
+func (s *PrintDocumentService) storeName(storeID uint) string {
+	store, err := s.storeModule.GetByID(storeID)
+	if err != nil || store == nil {
+		return ""
+	}
+	return store.Name
+}
+
+func renderStoreAccountReceipt(account *model.StoreAccount, paperWidth int) string {
+	l := newPrintLayout(paperWidth)
+	if account.Store != nil {
+		l.title(account.Store.Name)
+	}
+	l.center("销售小票")
+	l.rule("=")
+	l.field("单号", account.AccountNo)
+	l.field("日期", account.AccountDate.Format("2006-01-02"))
+	l.field("渠道", account.Channel)
+	l.field("订单号", account.OrderNo)
+	if account.Member != nil {
+		l.field("会员", account.Member.Name)
+	}
+	if account.Operator != nil {
+		l.field("收银", account.Operator.Username)
+	}
+
+	rows := make([][]string, 0, len(account.Items))
+	for _, item := range account.Items {
+		rows = append(rows, []string{
+			item.ProductName,
+			formatPrintQty(item.Quantity) + item.Unit,
+			formatPrintMoney(item.Price),
+			formatPrintMoney(item.Amount),
+		})
+	}
+	l.table([]string{"商品", "数量", "单价", "金额"}, rows)
+
+	l.right("合计: " + formatPrintMoney(account.TotalAmount))
+	if account.MemberDiscount > 0 {
+		l.right(fmt.Sprintf("会员折扣(%s折): 已优惠%s", formatPrintQty(account.MemberDiscountRate*10), formatPrintMoney(account.MemberDiscount)))
+	}
+	if account.RoundAmount > 0 {
+		l.right("抹零: -" + formatPrintMoney(account.RoundAmount))
+	}
+	if account.PointsDeduction > 0 {
+		l.right(fmt.Sprintf("积分抵扣(%d分): -%s", account.PointsRedeemed, formatPrintMoney(account.PointsDeduction)))
+	}
+	l.right("<B>实收: " + formatPrintMoney(account.TotalAmount-account.RoundAmount-account.PointsDeduction) + "</B>")
+	if account.BalanceAmount > 0 {
+		l.right("会员余额支付: " + formatPrintMoney(account.BalanceAmount))
+		if rest := account.TotalAmount - account.RoundAmount - account.PointsDeduction - account.BalanceAmount; rest > 0.005 {
+			l.right(fmt.Sprintf("%s支付: %s", payMethodLabel(account.PayMethod), formatPrintMoney(rest)))
+		}
+	}
+	if account.PaymentStatus == model.StoreAccountPaymentUnpaid {
+		l.right("（未支付）")
+	}
+	if account.IsGiftWine == 1 && account.GiftWineProductName != "" {
+		l.field("赠品", account.GiftWineProductName+" "+formatPrintQty(account.GiftWineQuantity)+account.GiftWineUnit)
+	}
+	l.field("备注", account.Remark)
+	l.rule("=")
+	l.field("打印时间", time.Now().Format("2006-01-02 15:04:05"))
+	l.center("谢谢惠顾，欢迎再次光临")
+	l.cut()
+	return l.String()
+}
+
+func renderPreOrderPicking(order *model.PreOrder, paperWidth int) string {
+	l := newPrintLayout(paperWidth)
+	l.title("预订单拣货单")
+	l.rule("=")
+	l.field("单号", order.OrderNo)
+	if order.Store != nil {
+		l.field("门店", order.Store.Name)
+	}
+	l.field("客户", order.CustomerName)
+	l.field("联系人", order.ContactPerson)
+	l.field("电话", order.ContactPhone)
+	l.field("地址", order.DeliveryAddress)
+	l.line("<B>配送时间: " + order.ScheduledAt.Format("2006-01-02 15:04") + "</B>")
+
+	rows := make([][]string, 0, len(order.Items))
+	for _, item := range order.Items {
+		rows = append(rows, []string{"[ ]" + item.ProductName, formatPrintQty(item.Quantity) + item.UnitName})
+		if item.Remark != "" {
+			rows = append(rows, []string{"    备注: " + item.Remark, ""})
+		}
+	}
+	l.table([]string{"商品", "数量"}, rows)
+	l.right(fmt.Sprintf("共 %d 项", len(order.Items)))
+	l.field("备注", order.Remark)
+	l.signature("拣货人")
+	l.signature("复核人")
+	l.field("打印时间", time.Now().Format("2006-01-02 15:04:05"))
+	l.cut()
+	return l.String()
+}
+
+func renderB2BDeliveryNote(order *model.B2BSupplyOrder, storeName string, paperWidth int) string {
+	l := newPrintLayout(paperWidth)
+	if storeName != "" {
+		l.title(storeName)
+	}
+	l.center("送货单")
+	l.rule("=")
+	l.field("单号", order.OrderNo)
+	l.field("日期", order.OrderDate.Format("2006-01-02"))
+	l.field("客户", order.CustomerName)
+	if order.Customer != nil {
+		l.field("联系人", order.Customer.ContactPerson)
+		l.field("电话", order.Customer.Phone)
+		l.field("地址", order.Customer.Address)
+	}
+
+	rows := make([][]string, 0, len(order.Items))
+	for _, item := range order.Items {
+		rows = append(rows, []string{
+			item.ProductName,
+			formatPrintQty(item.Quantity) + item.UnitName,
+			formatPrintMoney(item.SupplyPrice),
+			formatPrintMoney(item.Amount),
+		})
+	}
+	l.table([]string{"商品", "数量", "单价", "金额"}, rows)
+
+	l.right("<B>合计: " + formatPrintMoney(order.TotalAmount) + "</B>")
+	if order.ReturnedAmount > 0 {
+		l.right("已退货: -" + formatPrintMoney(order.ReturnedAmount))
+		l.right("应收: " + formatPrintMoney(order.NetAmount()))
+	}
+	if order.PaidAmount > 0 {
+		l.right("已收: " + formatPrintMoney(order.PaidAmount))
+	}
+	l.right("未收: " + formatPrintMoney(order.UnpaidAmount))
+	l.field("备注", order.Remark)
+	l.field("送货人", order.OperatorName)
+	l.signature("客户签收")
+	l.signature("签收日期")
+	l.field("打印时间", time.Now().Format("2006-01-02 15:04:05"))
+	l.cut()
+	return l.String()
+}
+
+func renderInventoryOrder(order *model.InventoryOrder, paperWidth int) string {
+	l := newPrintLayout(paperWidth)
+	switch order.Type {
+	case model.InventoryTypeIn:
+		l.title("入库单")
+	case model.InventoryTypeOut:
+		l.title("出库单")
+	default:
+		l.title("盘点调整单")
+	}
+	l.rule("=")
+	l.field("单号", order.OrderNo)
+	l.field("门店", order.StoreName)
+	l.field("原因", order.Reason)
+	l.field("时间", order.CreatedAt.Format("2006-01-02 15:04"))
+	l.field("经办人", order.OperatorName)
+
+	rows := make([][]string, 0, len(order.Items))
+	for _, item := range order.Items {
+		rows = append(rows, []string{item.ProductName, formatPrintQty(item.Quantity) + item.Unit})
+		if item.ExpiryDate != nil {
+			rows = append(rows, []string{"    到期: " + item.ExpiryDate.Format("2006-01-02"), ""})
+		}
+	}
+	l.table([]string{"商品", "数量"}, rows)
+	l.right(fmt.Sprintf("共 %d 种，合计数量 %s", order.ItemCount, formatPrintQty(order.TotalQuantity)))
+	l.field("备注", order.Remark)
+	l.signature("经手人")
+	l.field("打印时间", time.Now().Format("2006-01-02 15:04:05"))
+	l.cut()
+	return l.String()
+}
+
+func renderStoreReturn(record *model.StoreReturn, paperWidth int) string {
+	l := newPrintLayout(paperWidth)
+	l.title("返厂单")
+	l.rule("=")
+	l.field("单号", record.ReturnNo)
+	if record.Store != nil {
+		l.field("门店", record.Store.Name)
+	}
+	l.field("日期", record.ReturnDate.Format("2006-01-02"))
+	l.field("经办人", record.OperatorName)
+
+	rows := make([][]string, 0, len(record.Items))
+	for _, item := range record.Items {
+		rows = append(rows, []string{
+			item.ProductName,
+			formatPrintQty(item.Quantity),
+			formatPrintMoney(item.Deposit),
+			formatPrintMoney(item.Deposit * item.Quantity),
+		})
+	}
+	l.table([]string{"商品", "数量", "押金", "小计"}, rows)
+	l.right("<B>押金合计: " + formatPrintMoney(record.TotalDeposit) + "</B>")
+	if record.LogisticsFee > 0 {
+		l.right("物流费: " + formatPrintMoney(record.LogisticsFee))
+	}
+	l.field("备注", record.Remark)
+	l.signature("供应商签收")
+	l.field("打印时间", time.Now().Format("2006-01-02 15:04:05"))
+	l.cut()
+	return l.String()
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	xpmodel "github.com/Kevin-Jii/tower-go/pkg/xpyun/model"
+	"github.com/Kevin-Jii/tower-go/utils/logging"
+	"go.uber.org/zap"
+)
+
+const (
+	printJobPollDelay    = 5 * time.Second  // 发送后稍等再查询，给打印机出纸留时间
+	printJobPollBatch    = 50               // 每轮最多查询的任务数
+	printJobPrintTimeout = 10 * time.Minute // 超过该时间仍未出纸视为失败
+)
+
+// PrintJobSpec 待提交的打印内容
+type PrintJobSpec struct {
+	PrinterID  uint // 为 0 时使用门店默认打印机
+	StoreID    uint
+	DocType    string
+	DocID      uint
+	DocNo      string
+	Content    string
+	Render     func(paperWidth int) string // 按最终打印机纸宽排版，Content 为空时使用
+	Copies     int
+	OperatorID uint
+	ReprintOf  uint
+}
+
+// SubmitJob 创建打印任务并发送到芯烨云；目标打印机离线时自动切换到同门店同类型的在线打印机，
+// 内容已按纸宽排好（Content 非空）时只切换到同纸宽的打印机。发送失败时任务记为失败并返回，便于之后补打。
+func (s *PrinterService) SubmitJob(spec *PrintJobSpec) (*model.PrintJob, error) {
+	if s.xpyunClient == nil {
+		return nil, apicode.New(apicode.ConfigMissing)
+	}
+	var (
+		printer *model.Printer
+		err     error
+	)
+	if spec.PrinterID > 0 {
+		printer, err = s.printerModule.GetByID(spec.PrinterID)
+	} else {
+		printer, err = s.printerModule.GetDefaultByStoreID(spec.StoreID)
+	}
+	if err != nil || printer == nil {
+		return nil, apicode.New(apicode.PrinterNotFound)
+	}
+	if spec.Copies <= 0 {
+		spec.Copies = 1
+	}
+
+	target, failoverFrom := s.pickOnlinePrinter(printer, spec.Content != "")
+	if spec.Content == "" && spec.Render != nil {
+		spec.Content = spec.Render(target.PaperWidth)
+	}
+	job := &model.PrintJob{
+		StoreID:      printer.StoreID,
+		PrinterID:    target.ID,
+		PrinterSn:    target.Sn,
+		PrinterName:  target.Name,
+		FailoverFrom: failoverFrom,
+		DocType:      spec.DocType,
+		DocID:        spec.DocID,
+		DocNo:        spec.DocNo,
+		Content:      spec.Content,
+		Copies:       spec.Copies,
+		Status:       model.PrintJobPending,
+		ReprintOf:    spec.ReprintOf,
+		OperatorID:   spec.OperatorID,
+	}
+	if err := s.printJobModule.Create(job); err != nil {
+		return nil, err
+	}
+	if failoverFrom > 0 {
+		logging.LogInfo("打印机离线，已切换打印机",
+			zap.Uint("job_id", job.ID), zap.Uint("from", failoverFrom), zap.Uint("to", target.ID))
+	}
+	return job, s.sendJob(job, target)
+}
+
+// sendJob 按打印机类型调用小票或标签接口，并记录发送结果
+func (s *PrinterService) sendJob(job *model.PrintJob, printer *model.Printer) error {
+	var resp *xpmodel.XPYunResp
+	if printer.Type == model.PrinterTypeLabel {
+		resp = s.xpyunClient.PrintLabel(printer.Sn, job.Content, job.Copies)
+	} else {
+		resp = s.xpyunClient.PrintReceipt(printer.Sn, job.Content, job.Copies)
+	}
+
+	var sendErr error
+	switch {
+	case resp == nil || resp.Content == nil:
+		sendErr = apicode.New(apicode.ExternalServiceFailed)
+	case !resp.Content.IsSuccess():
+		sendErr = apicode.Newf(apicode.ExternalServiceFailed, "打印失败: %s", resp.Content.Msg)
+	}
+	if sendErr != nil {
+		job.Status = model.PrintJobFailed
+		job.LastError = sendErr.Error()
+		if err := s.printJobModule.MarkFailed(job.ID, job.LastError); err != nil {
+			logging.LogWarn("记录打印任务失败状态出错", zap.Uint("job_id", job.ID), zap.Error(err))
+		}
+		return sendErr
+	}
+
+	now := time.Now()
+	job.Status = model.PrintJobSent
+	job.XpyunOrderID = resp.Content.OrderId
+	job.SentAt = &now
+	if err := s.printJobModule.MarkSent(job.ID, job.XpyunOrderID, now); err != nil {
+		logging.LogWarn("记录打印任务发送状态出错", zap.Uint("job_id", job.ID), zap.Error(err))
+	}
+	return nil
+}
+
+// pickOnlinePrinter 目标打印机在线时直接使用；离线或异常时依次尝试同门店的备用打印机，sameWidth 时只用同纸宽的。
+// 都不可用时仍发往原打印机，芯烨云会在打印机恢复后补打。返回值 failoverFrom 为切换前的打印机ID。
+func (s *PrinterService) pickOnlinePrinter(printer *model.Printer, sameWidth bool) (*model.Printer, uint) {
+	// 状态查询失败时不做切换，避免误判
+	if status, ok := s.livePrinterStatus(printer.Sn); printer.Status == 1 && (!ok || status == 1) {
+		return printer, 0
+	}
+	printers, err := s.printerModule.ListByStoreID(printer.StoreID)
+	if err != nil {
+		return printer, 0
+	}
+	for _, candidate := range failoverCandidates(printer, printers, sameWidth) {
+		if status, ok := s.livePrinterStatus(candidate.Sn); ok && status == 1 {
+			return candidate, printer.ID
+		}
+	}
+	return printer, 0
+}
+
+// livePrinterStatus 实时查询打印机状态并刷新本地缓存，0=离线 1=在线正常 2=在线异常
+func (s *PrinterService) livePrinterStatus(sn string) (int, bool) {
+	status, err := s.QueryPrinterStatus(sn)
+	if err != nil {
+		return 0, false
+	}
+	_ = s.printerModule.UpdateOnlineStatus(sn, status)
+	return status, true
+}
+
+// failoverCandidates 备用打印机：同门店、同类型（sameWidth 时还须同纸宽）、未停用，按缓存在线状态、是否默认、ID 排序
+func failoverCandidates(origin *model.Printer, printers []*model.Printer, sameWidth bool) []*model.Printer {
+	var out []*model.Printer
+	for _, p := range printers {
+		if p.ID == origin.ID || p.StoreID != origin.StoreID || p.Type != origin.Type || p.Status != 1 {
+			continue
+		}
+		if sameWidth && p.PaperWidth != origin.PaperWidth {
+			continue
+		}
+		out = append(out, p)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Online != out[j].Online {
+			return out[i].Online == 1
+		}
+		if out[i].IsDefault != out[j].IsDefault {
+			return out[i].IsDefault == 1
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// PollPrintJobs 查询已发送任务的出纸结果（定时任务调用），返回本轮状态有变化的任务数
+func (s *PrinterService) PollPrintJobs(now time.Time) (int, error) {
+	if s.xpyunClient == nil {
+		return 0, nil
+	}
+	jobs, err := s.printJobModule.ListAwaiting(now.Add(-printJobPollDelay), printJobPollBatch)
+	if err != nil {
+		return 0, err
+	}
+	changed := 0
+	for _, job := range jobs {
+		resp := s.xpyunClient.QueryOrderState(job.XpyunOrderID)
+		var content *xpmodel.XPYunRespContent
+		if resp != nil {
+			content = resp.Content
+		}
+		status, reason := resolvePrintJobState(job, content, now)
+		switch status {
+		case model.PrintJobPrinted:
+			err = s.printJobModule.MarkPrinted(job.ID, now)
+		case model.PrintJobFailed:
+			err = s.printJobModule.MarkFailed(job.ID, reason)
+		default:
+			continue
+		}
+		if err != nil {
+			logging.LogWarn("更新打印任务状态失败", zap.Uint("job_id", job.ID), zap.Error(err))
+			continue
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+// resolvePrintJobState 根据芯烨云订单状态判断任务结果：data=true 表示已打印；
+// 未打印或查询失败且超过超时时间则判定失败，否则保持已发送继续等待
+func resolvePrintJobState(job *model.PrintJob, content *xpmodel.XPYunRespContent, now time.Time) (int8, string) {
+	if content != nil && content.IsSuccess() {
+		if printed, _ := content.Data.(bool); printed {
+			return model.PrintJobPrinted, ""
+		}
+	}
+	if job.SentAt == nil || now.Sub(*job.SentAt) < printJobPrintTimeout {
+		return model.PrintJobSent, ""
+	}
+	if content != nil && !content.IsSuccess() {
+		return model.PrintJobFailed, fmt.Sprintf("查询打印结果失败: %s", content.Msg)
+	}
+	return model.PrintJobFailed, fmt.Sprintf("超过%d分钟未出纸，请检查打印机是否离线或缺纸", int(printJobPrintTimeout/time.Minute))
+}
+
+// ListPrintJobs 打印任务列表
+func (s *PrinterService) ListPrintJobs(req *model.ListPrintJobReq) ([]*model.PrintJob, int64, error) {
+	return s.printJobModule.List(req)
+}
+
+// GetPrintJob 打印任务详情，门店账号只能查看本门店任务
+func (s *PrinterService) GetPrintJob(id, storeID uint, isHQ bool) (*model.PrintJob, error) {
+	job, err := s.printJobModule.GetByID(id)
+	if err != nil {
+		return nil, apicode.New(apicode.NotFound)
+	}
+	if !isHQ && job.StoreID != storeID {
+		return nil, apicode.New(apicode.OperationDenied)
+	}
+	return job, nil
+}
+
+// Reprint 按原任务内容补打，生成新任务并记录来源；未指定打印机时沿用原打印机。
+// 原内容已按原打印机的类型和纸宽排版，指定其他打印机时须同门店、同类型、同纸宽。
+func (s *PrinterService) Reprint(id, storeID uint, isHQ bool, operatorID uint, req *model.ReprintJobReq) (*model.PrintJob, error) {
+	origin, err := s.GetPrintJob(id, storeID, isHQ)
+	if err != nil {
+		return nil, err
+	}
+	printerID := origin.PrinterID
+	if req.PrinterID > 0 && req.PrinterID != origin.PrinterID {
+		printer, err := s.printerModule.GetByID(req.PrinterID)
+		if err != nil || printer == nil {
+			return nil, apicode.New(apicode.PrinterNotFound)
+		}
+		if printer.StoreID != origin.StoreID {
+			return nil, apicode.Newf(apicode.OperationDenied, "只能补打到同门店的打印机")
+		}
+		originPrinter, err := s.printerModule.GetByID(origin.PrinterID)
+		if err != nil || originPrinter == nil {
+			return nil, apicode.Newf(apicode.OperationDenied, "原打印机已删除，无法确认纸宽，请重新打印单据")
+		}
+		if !reprintPrinterCompatible(originPrinter, printer) {
+			return nil, apicode.Newf(apicode.OperationDenied, "补打只能发往与原任务同类型、同纸宽的打印机")
+		}
+		printerID = printer.ID
+	}
+	copies := req.Copies
+	if copies <= 0 {
+		copies = origin.Copies
+	}
+	return s.SubmitJob(&PrintJobSpec{
+		PrinterID:  printerID,
+		StoreID:    origin.StoreID,
+		DocType:    origin.DocType,
+		DocID:      origin.DocID,
+		DocNo:      origin.DocNo,
+		Content:    origin.Content,
+		Copies:     copies,
+		OperatorID: operatorID,
+		ReprintOf:  origin.ID,
+	})
+}
+
+// reprintPrinterCompatible 补打沿用原任务已渲染的内容，目标打印机须与原打印机类型和纸宽一致
+func reprintPrinterCompatible(origin, target *model.Printer) bool {
+	return origin.Type == target.Type && origin.PaperWidth == target.PaperWidth
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	xpmodel "github.com/Kevin-Jii/tower-go/pkg/xpyun/model"
+)
+
+func TestFailoverCandidates(t *testing.T) {
+	origin := &model.Printer{ID: 1, StoreID: 9, Type: model.PrinterTypeReceipt, Status: 1, IsDefault: 1}
+	printers := []*model.Printer{
+		origin,
+		{ID: 2, StoreID: 9, Type: model.PrinterTypeReceipt, Status: 1, Online: 0},
+		{ID: 3, StoreID: 9, Type: model.PrinterTypeLabel, Status: 1, Online: 1},
+		{ID: 4, StoreID: 9, Type: model.PrinterTypeReceipt, Status: 2, Online: 1},
+		{ID: 5, StoreID: 8, Type: model.PrinterTypeReceipt, Status: 1, Online: 1},
+		{ID: 6, StoreID: 9, Type: model.PrinterTypeReceipt, Status: 1, Online: 1},
+		{ID: 7, StoreID: 9, Type: model.PrinterTypeReceipt, Status: 1, Online: 1, IsDefault: 1},
+	}
+	got := failoverCandidates(origin, printers, false)
+	want := []uint{7, 6, 2}
+	if len(got) != len(want) {
+		t.Fatalf("candidates = %d, want %d", len(got), len(want))
+	}
+	for i, p := range got {
+		if p.ID != want[i] {
+			t.Fatalf("candidate[%d] = %d, want %d", i, p.ID, want[i])
+		}
+	}
+}
+
+func TestFailoverCandidatesSameWidth(t *testing.T) {
+	origin := &model.Printer{ID: 1, StoreID: 9, Type: model.PrinterTypeReceipt, PaperWidth: model.PaperWidth58, Status: 1}
+	printers := []*model.Printer{
+		origin,
+		{ID: 2, StoreID: 9, Type: model.PrinterTypeReceipt, PaperWidth: model.PaperWidth80, Status: 1, Online: 1},
+		{ID: 3, StoreID: 9, Type: model.PrinterTypeReceipt, PaperWidth: model.PaperWidth58, Status: 1},
+	}
+	if got := failoverCandidates(origin, printers, true); len(got) != 1 || got[0].ID != 3 {
+		t.Fatalf("same-width candidates = %#v", got)
+	}
+}
+
+func TestReprintPrinterCompatible(t *testing.T) {
+	origin := &model.Printer{Type: model.PrinterTypeReceipt, PaperWidth: model.PaperWidth58}
+	if !reprintPrinterCompatible(origin, &model.Printer{Type: model.PrinterTypeReceipt, PaperWidth: model.PaperWidth58}) {
+		t.Fatal("same type and width should be compatible")
+	}
+	if reprintPrinterCompatible(origin, &model.Printer{Type: model.PrinterTypeReceipt, PaperWidth: model.PaperWidth80}) {
+		t.Fatal("different paper width should be rejected")
+	}
+	if reprintPrinterCompatible(origin, &model.Printer{Type: model.PrinterTypeLabel, PaperWidth: model.PaperWidth58}) {
+		t.Fatal("different printer type should be rejected")
+	}
+}
+
+func TestResolvePrintJobState(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.Local)
+	recent := now.Add(-time.Minute)
+	stale := now.Add(-printJobPrintTimeout - time.Second)
+
+	cases := []struct {
+		name    string
+		sentAt  *time.Time
+		content *xpmodel.XPYunRespContent
+		want    int8
+	}{
+		{"printed", &recent, &xpmodel.XPYunRespContent{Code: 0, Data: true}, model.PrintJobPrinted},
+		{"printed after timeout", &stale, &xpmodel.XPYunRespContent{Code: 0, Data: true}, model.PrintJobPrinted},
+		{"waiting", &recent, &xpmodel.XPYunRespContent{Code: 0, Data: false}, model.PrintJobSent},
+		{"query error waiting", &recent, nil, model.PrintJobSent},
+		{"timeout", &stale, &xpmodel.XPYunRespContent{Code: 0, Data: false}, model.PrintJobFailed},
+		{"query error timeout", &stale, &xpmodel.XPYunRespContent{Code: -1, Msg: "订单不存在"}, model.PrintJobFailed},
+	}
+	for _, tc := range cases {
+		got, reason := resolvePrintJobState(&model.PrintJob{SentAt: tc.sentAt}, tc.content, now)
+		if got != tc.want {
+			t.Fatalf("%s: status = %d, want %d", tc.name, got, tc.want)
+		}
+		if (got == model.PrintJobFailed) != (reason != "") {
+			t.Fatalf("%s: reason = %q", tc.name, reason)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/module"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	"github.com/Kevin-Jii/tower-go/utils/logging"
+	"go.uber.org/zap"
+)
+
+// 标签纸规格（40x30mm，203dpi 每毫米 8 点）
+const (
+	labelWidthMM   = 40
+	labelHeightMM  = 30
+	labelDotsPerMM = 8
+	labelMargin    = 16 // 左右边距（点）
+	labelFontDots  = 24 // 12 号字体中文宽高（点），英文数字为一半
+)
+
+// labelLayout 芯烨云标签排版：每页以 <SIZE> 开头，多页用 <PAGE> 分隔，文本按行自上而下排列
+type labelLayout struct {
+	sb    strings.Builder
+	pages int
+	y     int
+}
+
+func newLabelLayout() *labelLayout {
+	return &labelLayout{}
+}
+
+func (l *labelLayout) page() {
+	if l.pages > 0 {
+		l.sb.WriteString("<PAGE>")
+	}
+	l.pages++
+	l.y = labelMargin
+	fmt.Fprintf(&l.sb, "<SIZE>%d,%d</SIZE>", labelWidthMM, labelHeightMM)
+}
+
+// text 输出一行文本，scale 为放大倍数；超出标签宽度的部分截断
+func (l *labelLayout) text(text string, scale int) {
+	if text == "" {
+		return
+	}
+	maxWidth := (labelWidthMM*labelDotsPerMM - 2*labelMargin) / (labelFontDots / 2 * scale)
+	fmt.Fprintf(&l.sb, `<TEXT x="%d" y="%d" font="12" w="%d" h="%d" r="0">%s</TEXT>`,
+		labelMargin, l.y, scale, scale, truncateString(text, maxWidth))
+	l.y += labelFontDots*scale + 8
+}
+
+func (l *labelLayout) String() string {
+	return l.sb.String()
+}
+
+// PrintLabelService 标签打印：货架价签、库存批次标签、会员存酒牌
+type PrintLabelService struct {
+	printerService  *PrinterService
+	printerModule   *module.PrinterModule
+	storeModule     *module.StoreModule
+	priceListModule *module.PriceListModule
+	inventoryModule *module.InventoryModule
+	memberModule    *module.MemberModule
+}
+
+func NewPrintLabelService(
+	printerService *PrinterService,
+	printerModule *module.PrinterModule,
+	storeModule *module.StoreModule,
+	priceListModule *module.PriceListModule,
+	inventoryModule *module.InventoryModule,
+	memberModule *module.MemberModule,
+) *PrintLabelService {
+	return &PrintLabelService{
+		printerService:  printerService,
+		printerModule:   printerModule,
+		storeModule:     storeModule,
+		priceListModule: priceListModule,
+		inventoryModule: inventoryModule,
+		memberModule:    memberModule,
+	}
+}
+
+// PrintPriceTags 按价目单打印货架价签，每个商品一张
+func (s *PrintLabelService) PrintPriceTags(storeID uint, isHQ bool, operatorID uint, req *model.PrintPriceTagReq) (*model.PrintJob, error) {
+	priceList, categories, itemsByCategory, err := s.priceListModule.GetPriceListWithDetails(req.PriceListID)
+	if err != nil || (!isHQ && priceList.StoreID != storeID) {
+		return nil, apicode.New(apicode.NotFound)
+	}
+	selected := make(map[uint]bool, len(req.ItemIDs))
+	for _, id := range req.ItemIDs {
+		selected[id] = true
+	}
+	var items []*model.PriceListItem
+	for _, category := range categories {
+		for _, item := range itemsByCategory[category.ID] {
+			if len(selected) > 0 && !selected[item.ID] {
+				continue
+			}
+			if len(selected) == 0 && item.Status != 1 {
+				continue
+			}
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return nil, apicode.Newf(apicode.ValidationFailed, "没有可打印的商品")
+	}
+	storeName := s.storeName(priceList.StoreID)
+	return s.submit(priceList.StoreID, storeID, isHQ, operatorID, req.PrinterID, req.Copies, &PrintJobSpec{
+		DocType: model.PrintDocPriceTag,
+		DocID:   priceList.ID,
+		DocNo:   priceList.Name,
+		Content: renderPriceTags(items, storeName),
+	})
+}
+
+// PrintLotLabels 打印库存批次标签（生产日期、到期日）
+func (s *PrintLabelService) PrintLotLabels(storeID uint, isHQ bool, operatorID uint, req *model.PrintLotLabelReq) (*model.PrintJob, error) {
+	lots, err := s.inventoryModule.ListLotLabels(req.LotIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(lots) == 0 {
+		return nil, apicode.New(apicode.NotFound)
+	}
+	lotStoreID := lots[0].StoreID
+	for _, lot := range lots {
+		if lot.StoreID != lotStoreID {
+			return nil, apicode.Newf(apicode.ValidationFailed, "一次只能打印同一门店的批次")
+		}
+	}
+	if !isHQ && lotStoreID != storeID {
+		return nil, apicode.New(apicode.NotFound)
+	}
+	return s.submit(lotStoreID, storeID, isHQ, operatorID, req.PrinterID, req.Copies, &PrintJobSpec{
+		DocType: model.PrintDocLotLabel,
+		DocID:   lots[0].ID,
+		DocNo:   lots[0].SourceOrderNo,
+		Content: renderLotLabels(lots),
+	})
+}
+
+// PrintWineTag 补打会员存酒牌（显示当前存量）
+func (s *PrintLabelService) PrintWineTag(id, storeID uint, isHQ bool, operatorID uint, req *model.PrintDocumentReq) (*model.PrintJob, error) {
+	storage, err := s.memberModule.GetWineStorage(id, storeID, isHQ)
+	if err != nil {
+		return nil, apicode.New(apicode.WineStorageNotFound)
+	}
+	return s.submitWineTag(storage, storage.Quantity, storeID, isHQ, operatorID, req.PrinterID, req.Copies)
+}
+
+// PrintWineDepositTag 存酒后自动打印存酒牌；门店未绑定标签打印机时跳过，失败只记录日志不影响存酒
+func (s *PrintLabelService) PrintWineDepositTag(storage *model.MemberWineStorage, quantity float64, operatorID uint) {
+	_, err := s.submitWineTag(storage, quantity, storage.StoreID, true, operatorID, 0, 1)
+	if err != nil && !apicode.Is(err, apicode.PrinterNotFound) && !apicode.Is(err, apicode.ConfigMissing) {
+		logging.LogWarn("打印存酒牌失败", zap.Uint("storage_id", storage.ID), zap.Error(err))
+	}
+}
+
+func (s *PrintLabelService) submitWineTag(storage *model.MemberWineStorage, quantity float64, storeID uint, isHQ bool, operatorID, printerID uint, copies int) (*model.PrintJob, error) {
+	storeName := s.storeName(storage.StoreID)
+	return s.submit(storage.StoreID, storeID, isHQ, operatorID, printerID, copies, &PrintJobSpec{
+		DocType: model.PrintDocWineTag,
+		DocID:   storage.ID,
+		DocNo:   storage.WineName,
+		Content: renderWineTag(storage, quantity, storeName, time.Now()),
+	})
+}
+
+func (s *PrintLabelService) submit(docStoreID, storeID uint, isHQ bool, operatorID, printerID uint, copies int, spec *PrintJobSpec) (*model.PrintJob, error) {
+	printer, err := s.labelPrinter(printerID, docStoreID, storeID, isHQ)
+	if err != nil {
+		return nil, err
+	}
+	spec.PrinterID = printer.ID
+	spec.StoreID = docStoreID
+	spec.Copies = copies
+	spec.OperatorID = operatorID
+	return s.printerService.SubmitJob(spec)
+}
+
+// labelPrinter 指定打印机时校验类型与门店；未指定时取单据门店的标签打印机，默认打印机优先
+func (s *PrintLabelService) labelPrinter(printerID, docStoreID, storeID uint, isHQ bool) (*model.Printer, error) {
+	if printerID > 0 {
+		printer, err := s.printerModule.GetByID(printerID)
+		if err != nil {
+			return nil, apicode.New(apicode.PrinterNotFound)
+		}
+		if !isHQ && printer.StoreID != storeID {
+			return nil, apicode.Newf(apicode.OperationDenied, "只能使用本门店的打印机")
+		}
+		if printer.Type != model.PrinterTypeLabel {
+			return nil, apicode.Newf(apicode.ValidationFailed, "请选择标签打印机")
+		}
+		return printer, nil
+	}
+	printers, err := s.printerModule.ListByStoreID(docStoreID)
+	if err != nil {
+		return nil, err
+	}
+	var picked *model.Printer
+	for _, p := range printers {
+		if p.Type != model.PrinterTypeLabel || p.Status != 1 {
+			continue
+		}
+		if picked == nil || (p.IsDefault == 1 && picked.IsDefault != 1) {
+			picked = p
+		}
+	}
+	if picked == nil {
+		return nil, apicode.Newf(apicode.PrinterNotFound, "门店未绑定标签打印机")
+	}
+	return picked, nil
+}
+
+func (s *PrintLabelService) storeName(storeID uint) string {
+	store, err := s.storeModule.GetByID(storeID)
+	if err != nil || store == nil {
+		return ""
+	}
+	return store.Name
+}
+
+func renderPriceTags(items []*model.PriceListItem, storeName string) string {
+	l := newLabelLayout()
+	for _, item := range items {
+		name := item.DisplayName
+		if name == "" && item.Product != nil {
+			name = item.Product.Name
+		}
+		l.page()
+		l.text(name, 1)
+		l.text(strings.TrimSpace(item.Spec+" "+item.Unit), 1)
+		price := "¥" + formatPrintMoney(item.Price)
+		if item.Unit != "" {
+			price += "/" + item.Unit
+		}
+		l.text(price, 2)
+		l.text(storeName, 1)
+	}
+	return l.String()
+}
+
+func renderLotLabels(lots []*model.InventoryLotLabel) string {
+	l := newLabelLayout()
+	for _, lot := range lots {
+		l.page()
+		l.text(lot.ProductName, 1)
+		if lot.ProductionDate != nil {
+			l.text("生产: "+lot.ProductionDate.Format("2006-01-02"), 1)
+		}
+		if lot.ExpiryDate != nil {
+			l.text("到期: "+lot.ExpiryDate.Format("2006-01-02"), 1)
+		} else {
+			l.text("到期: 未设置", 1)
+		}
+		l.text("数量: "+formatPrintQty(lot.Quantity)+lot.Unit, 1)
+		if lot.SourceOrderNo != "" {
+			l.text("单号: "+lot.SourceOrderNo, 1)
+		}
+	}
+	return l.String()
+}
+
+func renderWineTag(storage *model.MemberWineStorage, quantity float64, storeName string, at time.Time) string {
+	l := newLabelLayout()
+	l.page()
+	l.text("存酒牌", 2)
+	if storage.Member != nil {
+		l.text("会员: "+storage.Member.Name+" 尾号"+phoneTail(storage.Member.Phone), 1)
+	}
+	l.text("酒品: "+storage.WineName, 1)
+	l.text("数量: "+formatPrintQty(quantity)+storage.Unit, 1)
+	l.text("存酒: "+at.Format("2006-01-02")+" "+storeName, 1)
+	return l.String()
+}
+
+// phoneTail 手机号后四位
+func phoneTail(phone string) string {
+	phone = strings.TrimSpace(phone)
+	if len(phone) <= 4 {
+		return phone
+	}
+	return phone[len(phone)-4:]
+}
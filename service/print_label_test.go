@@ -0,0 +1,55 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+func TestRenderPriceTagsOnePagePerItem(t *testing.T) {
+	items := []*model.PriceListItem{
+		{DisplayName: "青岛啤酒", Price: 8, Unit: "瓶", Spec: "500ml"},
+		{Product: &model.SupplierProduct{Name: "长城干红"}, Price: 128.5, Unit: "瓶"},
+	}
+	content := renderPriceTags(items, "一号店")
+	if got := strings.Count(content, "<SIZE>40,30</SIZE>"); got != 2 {
+		t.Fatalf("pages = %d, want 2", got)
+	}
+	if got := strings.Count(content, "<PAGE>"); got != 1 {
+		t.Fatalf("page breaks = %d, want 1", got)
+	}
+	for _, want := range []string{">青岛啤酒</TEXT>", ">500ml 瓶</TEXT>", `w="2" h="2" r="0">¥8.00/瓶</TEXT>`, ">长城干红</TEXT>", ">¥128.50/瓶</TEXT>", ">一号店</TEXT>"} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("content missing %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestRenderWineTag(t *testing.T) {
+	storage := &model.MemberWineStorage{
+		WineName: "茅台飞天53度",
+		Unit:     "瓶",
+		Member:   &model.Member{Name: "王五", Phone: "13812345678"},
+	}
+	content := renderWineTag(storage, 2, "一号店", time.Date(2026, 10, 1, 20, 0, 0, 0, time.Local))
+	for _, want := range []string{">会员: 王五 尾号5678</TEXT>", ">酒品: 茅台飞天53度</TEXT>", ">数量: 2瓶</TEXT>", ">存酒: 2026-10-01 一号店</TEXT>"} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("content missing %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestLabelTextTruncatesToLabelWidth(t *testing.T) {
+	l := newLabelLayout()
+	l.page()
+	l.text(strings.Repeat("酒", 20), 2)
+	// 40mm 宽去掉边距后，两倍字号最多 6 个汉字
+	if !strings.Contains(l.String(), ">"+strings.Repeat("酒", 6)+"</TEXT>") {
+		t.Fatalf("content = %s", l.String())
+	}
+	if phoneTail("138") != "138" || phoneTail(" 13900001111 ") != "1111" {
+		t.Fatalf("phoneTail mismatch")
+	}
+}
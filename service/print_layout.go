@@ -0,0 +1,113 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+// printLayout 按纸宽排版芯烨云小票内容：58mm 每行 32 字符，80mm 每行 48 字符（中文算 2 个字符）
+type printLayout struct {
+	width    int
+	numWidth int // 表格数值列宽度
+	sb       strings.Builder
+}
+
+func newPrintLayout(paperWidth int) *printLayout {
+	if paperWidth == model.PaperWidth80 {
+		return &printLayout{width: 48, numWidth: 9}
+	}
+	return &printLayout{width: 32, numWidth: 7}
+}
+
+func (l *printLayout) title(text string) {
+	l.sb.WriteString("<C><B>" + text + "</B></C><BR>")
+}
+
+func (l *printLayout) center(text string) {
+	l.sb.WriteString("<C>" + text + "</C><BR>")
+}
+
+func (l *printLayout) rule(ch string) {
+	l.sb.WriteString(strings.Repeat(ch, l.width) + "<BR>")
+}
+
+func (l *printLayout) line(text string) {
+	l.sb.WriteString(text + "<BR>")
+}
+
+// field 输出「标签: 值」，值为空时跳过
+func (l *printLayout) field(label, value string) {
+	if value == "" {
+		return
+	}
+	l.line(label + ": " + value)
+}
+
+func (l *printLayout) right(text string) {
+	l.sb.WriteString("<R>" + text + "</R><BR>")
+}
+
+func (l *printLayout) blank() {
+	l.sb.WriteString("<BR>")
+}
+
+// signature 签字栏，线长占满剩余宽度
+func (l *printLayout) signature(label string) {
+	prefix := label + ": "
+	l.blank()
+	l.line(prefix + strings.Repeat("_", l.width-displayWidth(prefix)))
+}
+
+// table 首列为名称列占用剩余宽度，其余列右对齐；名称超宽时单独占一行，数值换到下一行
+func (l *printLayout) table(headers []string, rows [][]string) {
+	nameWidth := l.width - l.numWidth*(len(headers)-1)
+	l.rule("-")
+	l.line(l.tableRow(headers, nameWidth))
+	l.rule("-")
+	for _, row := range rows {
+		if displayWidth(row[0]) > nameWidth {
+			l.line(row[0])
+			l.line(l.tableRow(append([]string{""}, row[1:]...), nameWidth))
+			continue
+		}
+		l.line(l.tableRow(row, nameWidth))
+	}
+	l.rule("-")
+}
+
+func (l *printLayout) tableRow(cols []string, nameWidth int) string {
+	var b strings.Builder
+	b.WriteString(padRight(cols[0], nameWidth))
+	for _, col := range cols[1:] {
+		b.WriteString(padLeft(col, l.numWidth))
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+func (l *printLayout) cut() {
+	l.sb.WriteString("<CUT>")
+}
+
+func (l *printLayout) String() string {
+	return l.sb.String()
+}
+
+// padLeft 按显示宽度左填充空格
+func padLeft(s string, width int) string {
+	w := displayWidth(s)
+	if w >= width {
+		return s
+	}
+	return strings.Repeat(" ", width-w) + s
+}
+
+func formatPrintQty(q float64) string {
+	return strconv.FormatFloat(q, 'f', -1, 64)
+}
+
+func formatPrintMoney(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
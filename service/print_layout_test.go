@@ -0,0 +1,74 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+func printLines(content string) []string {
+	content = strings.TrimSuffix(content, "<CUT>")
+	return strings.Split(strings.TrimSuffix(content, "<BR>"), "<BR>")
+}
+
+func TestPrintLayoutWidth(t *testing.T) {
+	for _, tc := range []struct {
+		paper int
+		width int
+	}{{model.PaperWidth58, 32}, {model.PaperWidth80, 48}, {0, 32}} {
+		l := newPrintLayout(tc.paper)
+		l.rule("-")
+		l.table([]string{"商品", "数量", "单价", "金额"}, [][]string{{"红酒", "2瓶", "88.00", "176.00"}})
+		l.signature("客户签收")
+		for _, line := range printLines(l.String()) {
+			if line == "" {
+				continue
+			}
+			if w := displayWidth(line); w > tc.width {
+				t.Fatalf("paper %d: line %q width %d exceeds %d", tc.paper, line, w, tc.width)
+			}
+		}
+		lines := printLines(l.String())
+		if sig := lines[len(lines)-1]; displayWidth(sig) != tc.width || !strings.HasPrefix(sig, "客户签收: ") {
+			t.Fatalf("paper %d: signature line %q", tc.paper, sig)
+		}
+	}
+}
+
+func TestPrintLayoutWrapsLongName(t *testing.T) {
+	l := newPrintLayout(model.PaperWidth58)
+	l.table([]string{"商品", "数量", "单价", "金额"}, [][]string{{"法国波尔多干红葡萄酒礼盒装", "1盒", "399.00", "399.00"}})
+	lines := printLines(l.String())
+	// 分隔线、表头、分隔线、名称行、数值行、分隔线
+	if len(lines) != 6 || lines[3] != "法国波尔多干红葡萄酒礼盒装" {
+		t.Fatalf("lines = %q", lines)
+	}
+	if !strings.HasSuffix(lines[4], " 399.00") || strings.TrimSpace(lines[4])[:4] != "1盒" {
+		t.Fatalf("value row = %q", lines[4])
+	}
+}
+
+func TestRenderB2BDeliveryNote(t *testing.T) {
+	order := &model.B2BSupplyOrder{
+		OrderNo:      "GH20261001001",
+		OrderDate:    time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local),
+		CustomerName: "城东餐厅",
+		TotalAmount:  300,
+		UnpaidAmount: 300,
+		Customer:     &model.B2BCustomer{ContactPerson: "李四", Phone: "13800000000"},
+		Items: []model.B2BSupplyOrderItem{
+			{ProductName: "啤酒", UnitName: "箱", Quantity: 5, SupplyPrice: 60, Amount: 300},
+		},
+	}
+	content := renderB2BDeliveryNote(order, "一号店", model.PaperWidth80)
+	for _, want := range []string{"<C><B>一号店</B></C>", "送货单", "单号: GH20261001001", "联系人: 李四", "啤酒", "5箱", "合计: 300.00", "客户签收: ___"} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("content missing %q:\n%s", want, content)
+		}
+	}
+	if !strings.HasSuffix(content, "<CUT>") {
+		t.Fatalf("content should end with cut")
+	}
+}
@@ -18,14 +18,16 @@ type PrinterService struct {
 	printerModule       *module.PrinterModule
 	storeModule         *module.StoreModule
 	purchaseOrderModule *module.PurchaseOrderModule
+	printJobModule      *module.PrintJobModule
 	xpyunClient         *xpyun.Client
 }
 
-func NewPrinterService(printerModule *module.PrinterModule, storeModule *module.StoreModule, purchaseOrderModule *module.PurchaseOrderModule) *PrinterService {
+func NewPrinterService(printerModule *module.PrinterModule, storeModule *module.StoreModule, purchaseOrderModule *module.PurchaseOrderModule, printJobModule *module.PrintJobModule) *PrinterService {
 	return &PrinterService{
 		printerModule:       printerModule,
 		storeModule:         storeModule,
 		purchaseOrderModule: purchaseOrderModule,
+		printJobModule:      printJobModule,
 	}
 }
 
@@ -72,6 +74,10 @@ func (s *PrinterService) BindPrinter(req *model.BindPrinterReq) error {
 		Remark:    req.Remark,
 		Status:    1,
 	}
+	printer.PaperWidth = req.PaperWidth
+	if printer.PaperWidth == 0 {
+		printer.PaperWidth = model.PaperWidth58
+	}
 
 	// 绑定到数据库
 	if err := s.printerModule.BindStore(printer); err != nil {
@@ -186,12 +192,12 @@ func (s *PrinterService) PrintReceipt(sn, content string, copies int) (string, e
 }
 
 // TestPrint 测试打印
-func (s *PrinterService) TestPrint(printerID uint, content string, copies int) (string, error) {
+func (s *PrinterService) TestPrint(printerID uint, content string, copies int, operatorID uint) (*model.PrintJob, error) {
 	logging.LogInfo(fmt.Sprintf("[TestPrint] printerID=%d, copies=%d", printerID, copies))
 
 	printer, err := s.printerModule.GetByID(printerID)
 	if err != nil {
-		return "", apicode.New(apicode.PrinterNotFound)
+		return nil, apicode.New(apicode.PrinterNotFound)
 	}
 
 	// 如果没有提供内容，使用默认测试内容
@@ -199,9 +205,16 @@ func (s *PrinterService) TestPrint(printerID uint, content string, copies int) (
 		content = "<C>测试打印</C><BR>----------------<BR>这是一张测试小票<BR>打印机: " + printer.Name + "<BR>SN: " + printer.Sn + "<BR>时间: " + time.Now().Format("2006-01-02 15:04:05") + "<BR>----------------<BR>"
 	}
 
-	logging.LogInfo(fmt.Sprintf("[TestPrint] printer found, sn=%s, calling PrintReceipt...", printer.Sn))
+	logging.LogInfo(fmt.Sprintf("[TestPrint] printer found, sn=%s, submitting job...", printer.Sn))
 
-	return s.PrintReceipt(printer.Sn, content, copies)
+	return s.SubmitJob(&PrintJobSpec{
+		PrinterID:  printer.ID,
+		StoreID:    printer.StoreID,
+		DocType:    model.PrintDocTest,
+		Content:    content,
+		Copies:     copies,
+		OperatorID: operatorID,
+	})
 }
 
 // GetPrinterWithStatus 获取打印机信息及在线状态
@@ -220,6 +233,7 @@ func (s *PrinterService) GetPrinterWithStatus(id uint) (*model.PrinterResp, erro
 		Status:        printer.Status,
 		IsDefault:     printer.IsDefault,
 		Online:        printer.Online,
+		PaperWidth:    printer.PaperWidth,
 		LastHeartbeat: printer.LastHeartbeat,
 		Remark:        printer.Remark,
 		CreatedAt:     printer.CreatedAt,
@@ -318,27 +332,36 @@ func (s *PrinterService) SyncAllPrinterStatus() error {
 }
 
 // PrintPurchaseOrder 打印采购单
-func (s *PrinterService) PrintPurchaseOrder(printerID uint, orderID uint) (string, error) {
+func (s *PrinterService) PrintPurchaseOrder(printerID uint, orderID uint, operatorID uint) (*model.PrintJob, error) {
 	logging.LogInfo(fmt.Sprintf("[PrintPurchaseOrder] printerID=%d, orderID=%d", printerID, orderID))
 
 	// 获取打印机信息
 	printer, err := s.printerModule.GetByID(printerID)
 	if err != nil {
-		return "", apicode.New(apicode.PrinterNotFound)
+		return nil, apicode.New(apicode.PrinterNotFound)
 	}
 
 	// 获取采购单完整信息
 	order, err := s.purchaseOrderModule.GetByIDWithDetails(orderID)
 	if err != nil {
-		return "", apicode.Newf(apicode.OrderNotFound, "采购单不存在: %v", err)
+		return nil, apicode.Newf(apicode.OrderNotFound, "采购单不存在: %v", err)
 	}
 
 	// 构建打印内容
 	content := s.buildPurchaseOrderContent(order)
 
-	logging.LogInfo(fmt.Sprintf("[PrintPurchaseOrder] printer found, sn=%s, calling PrintReceipt...", printer.Sn))
-
-	return s.PrintReceipt(printer.Sn, content, 1)
+	logging.LogInfo(fmt.Sprintf("[PrintPurchaseOrder] printer found, sn=%s, submitting job...", printer.Sn))
+
+	return s.SubmitJob(&PrintJobSpec{
+		PrinterID:  printer.ID,
+		StoreID:    printer.StoreID,
+		DocType:    model.PrintDocPurchaseOrder,
+		DocID:      order.ID,
+		DocNo:      order.OrderNo,
+		Content:    content,
+		Copies:     1,
+		OperatorID: operatorID,
+	})
 }
 
 // buildPurchaseOrderContent 构建采购单打印内容
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -13,25 +14,31 @@ import (
 	"github.com/Kevin-Jii/tower-go/pkg/statemachine"
 	"github.com/Kevin-Jii/tower-go/utils"
 	"github.com/Kevin-Jii/tower-go/utils/logging"
+	"gorm.io/gorm"
 )
 
 type PurchaseOrderService struct {
 	orderModule         *module.PurchaseOrderModule
+	receiptModule       *module.PurchaseReceiptModule
+	inventoryModule     *module.InventoryModule
+	userModule          *module.UserModule
 	productModule       *module.SupplierProductModule
 	storeSupplierModule *module.StoreSupplierModule
 	storeModule         *module.StoreModule
-	botModule           *module.DingTalkBotModule
-	dingTalkService     *DingTalkService
+	notifier            *NotificationService
+	approvals           *DingTalkApprovalService
 	stateMachine        *statemachine.StateMachine
 }
 
 func NewPurchaseOrderService(
 	orderModule *module.PurchaseOrderModule,
+	receiptModule *module.PurchaseReceiptModule,
+	inventoryModule *module.InventoryModule,
+	userModule *module.UserModule,
 	productModule *module.SupplierProductModule,
 	storeSupplierModule *module.StoreSupplierModule,
 	storeModule *module.StoreModule,
-	botModule *module.DingTalkBotModule,
-	dingTalkService *DingTalkService,
+	notifier *NotificationService,
 ) *PurchaseOrderService {
 	// 创建状态机并注册钩子
 	sm := statemachine.NewOrderStateMachine()
@@ -65,11 +72,13 @@ func NewPurchaseOrderService(
 
 	return &PurchaseOrderService{
 		orderModule:         orderModule,
+		receiptModule:       receiptModule,
+		inventoryModule:     inventoryModule,
+		userModule:          userModule,
 		productModule:       productModule,
 		storeSupplierModule: storeSupplierModule,
 		storeModule:         storeModule,
-		botModule:           botModule,
-		dingTalkService:     dingTalkService,
+		notifier:            notifier,
 		stateMachine:        sm,
 	}
 }
@@ -167,8 +176,9 @@ func (s *PurchaseOrderService) CreateOrder(storeID, userID uint, req *model.Crea
 	// 发布订单创建事件
 	utils.GlobalEventBus.Publish(utils.EventOrderCreated, order)
 
-	// 异步推送钉钉通知
-	go s.sendDingTalkNotification(order)
+	// 钉钉通知写入发件箱
+	s.enqueueDingTalkNotification(order)
+	publishPurchaseOrderChanged(order, model.RealtimeActionCreated, true)
 
 	return order, nil
 }
@@ -195,6 +205,21 @@ func (s *PurchaseOrderService) GetOrderScoped(id, storeID uint, hqUnbound bool)
 	return order, nil
 }
 
+// GetOrderByNoScoped 按订单编号获取采购单，门店账号只能查看本店
+func (s *PurchaseOrderService) GetOrderByNoScoped(orderNo string, storeID uint, hqUnbound bool) (*model.PurchaseOrder, error) {
+	order, err := s.orderModule.GetByOrderNo(orderNo)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apicode.New(apicode.OrderNotFound)
+		}
+		return nil, err
+	}
+	if !hqUnbound && (storeID == 0 || order.StoreID != storeID) {
+		return nil, apicode.New(apicode.OrderNotFound)
+	}
+	return order, nil
+}
+
 func (s *PurchaseOrderService) ensureOrderAccess(id, storeID uint, hqUnbound bool) error {
 	_, err := s.GetOrderScoped(id, storeID, hqUnbound)
 	return err
@@ -215,6 +240,13 @@ func (s *PurchaseOrderService) UpdateOrder(id uint, req *model.UpdatePurchaseOrd
 
 	// 使用状态机验证状态转换
 	if req.Status != nil {
+		// 完成须经收货登记或短收结案，已有收货的采购单不能再取消
+		if *req.Status == model.PurchaseStatusCompleted {
+			return apicode.Newf(apicode.OrderStateConflict, "请通过收货登记或短收结案完成采购单")
+		}
+		if *req.Status == model.PurchaseStatusCancelled && order.ReceiveStatus != model.PurchaseReceiveNone {
+			return apicode.Newf(apicode.OrderStateConflict, "采购单已有收货记录，不能取消，请短收结案")
+		}
 		action := s.getActionForStatus(order.Status, *req.Status)
 		if action == "" {
 			return apicode.New(apicode.OrderStateConflict)
@@ -227,7 +259,15 @@ func (s *PurchaseOrderService) UpdateOrder(id uint, req *model.UpdatePurchaseOrd
 		}
 	}
 
-	return s.orderModule.UpdateByID(id, req)
+	if err := s.orderModule.UpdateByID(id, req); err != nil {
+		return err
+	}
+	if req.Status != nil && *req.Status == model.PurchaseStatusCancelled {
+		publishPurchaseOrderChanged(order, model.RealtimeActionCanceled, true)
+	} else {
+		publishPurchaseOrderChanged(order, model.RealtimeActionUpdated, false)
+	}
+	return nil
 }
 
 func (s *PurchaseOrderService) UpdateOrderScoped(id, storeID uint, hqUnbound bool, req *model.UpdatePurchaseOrderReq) error {
@@ -284,15 +324,21 @@ func (s *PurchaseOrderService) ConfirmOrderScoped(id, storeID uint, hqUnbound bo
 	return s.UpdateOrderScoped(id, storeID, hqUnbound, &model.UpdatePurchaseOrderReq{Status: &status})
 }
 
-// CompleteOrder 完成采购单（便捷方法）
-func (s *PurchaseOrderService) CompleteOrder(id uint) error {
-	status := model.PurchaseStatusCompleted
-	return s.UpdateOrder(id, &model.UpdatePurchaseOrderReq{Status: &status})
+// CompleteOrder 完成采购单：剩余未收数量按订单单价一次收齐入库
+func (s *PurchaseOrderService) CompleteOrder(id, operatorID uint) error {
+	order, err := s.orderModule.GetByIDWithDetails(id)
+	if err != nil {
+		return apicode.New(apicode.OrderNotFound)
+	}
+	return s.receiveRemaining(order, operatorID)
 }
 
-func (s *PurchaseOrderService) CompleteOrderScoped(id, storeID uint, hqUnbound bool) error {
-	status := model.PurchaseStatusCompleted
-	return s.UpdateOrderScoped(id, storeID, hqUnbound, &model.UpdatePurchaseOrderReq{Status: &status})
+func (s *PurchaseOrderService) CompleteOrderScoped(id, storeID uint, hqUnbound bool, operatorID uint) error {
+	order, err := s.GetOrderScoped(id, storeID, hqUnbound)
+	if err != nil {
+		return err
+	}
+	return s.receiveRemaining(order, operatorID)
 }
 
 // CancelOrder 取消采购单（便捷方法）
@@ -315,7 +361,11 @@ func (s *PurchaseOrderService) DeleteOrder(id uint) error {
 	if order.Status != model.PurchaseStatusPending && order.Status != model.PurchaseStatusCancelled {
 		return apicode.Newf(apicode.OrderDeletionDenied, "订单仅允许删除待处理或已取消状态，当前状态为%s", getStatusName(order.Status))
 	}
-	return s.orderModule.Delete(id)
+	if err := s.orderModule.Delete(id); err != nil {
+		return err
+	}
+	publishPurchaseOrderChanged(order, model.RealtimeActionDeleted, false)
+	return nil
 }
 
 func (s *PurchaseOrderService) DeleteOrderScoped(id, storeID uint, hqUnbound bool) error {
@@ -386,9 +436,9 @@ func (s *PurchaseOrderService) GetOrdersBySupplierScoped(orderID, storeID uint,
 	return s.GetOrdersBySupplier(orderID)
 }
 
-// sendDingTalkNotification 采购单创建后异步推送钉钉通知
-func (s *PurchaseOrderService) sendDingTalkNotification(order *model.PurchaseOrder) {
-	if s.dingTalkService == nil || s.storeModule == nil || s.botModule == nil {
+// enqueueDingTalkNotification 采购单创建后生成钉钉通知，按通知路由写入发件箱
+func (s *PurchaseOrderService) enqueueDingTalkNotification(order *model.PurchaseOrder) {
+	if s.notifier == nil || s.storeModule == nil {
 		return
 	}
 
@@ -399,22 +449,6 @@ func (s *PurchaseOrderService) sendDingTalkNotification(order *model.PurchaseOrd
 		return
 	}
 
-	if store.Phone == "" {
-		logging.LogWarn(fmt.Sprintf("[PurchaseOrder] 门店无联系电话，跳过推送: storeID=%d", order.StoreID))
-		return
-	}
-
-	// 获取门店绑定的机器人
-	bot, err := s.botModule.GetByStoreID(order.StoreID)
-	if err != nil || bot == nil {
-		logging.LogWarn(fmt.Sprintf("[PurchaseOrder] 未找到门店绑定的机器人: storeID=%d, err=%v", order.StoreID, err))
-		return
-	}
-
-	if !bot.IsEnabled || bot.BotType != "stream" {
-		return
-	}
-
 	// 获取完整采购单（含明细）
 	fullOrder, err := s.orderModule.GetByIDWithDetails(order.ID)
 	if err != nil || fullOrder == nil {
@@ -473,9 +507,45 @@ func (s *PurchaseOrderService) sendDingTalkNotification(order *model.PurchaseOrd
 		time.Now().Format("2006-01-02 15:04:05"),
 	)
 
-	if err := s.dingTalkService.SendStreamMarkdownToMobile(bot, title, text, store.Phone); err != nil {
-		logging.LogWarn(fmt.Sprintf("[PurchaseOrder] 钉钉推送失败: orderNo=%s, err=%v", order.OrderNo, err))
-	} else {
-		logging.LogInfo(fmt.Sprintf("[PurchaseOrder] 钉钉推送成功: orderNo=%s, store=%s", order.OrderNo, store.Name))
+	_, _ = s.notifier.Notify(&model.NotificationEvent{
+		EventType: model.NotificationBizPurchaseOrder,
+		StoreID:   order.StoreID,
+		Amount:    fullOrder.TotalAmount,
+	}, &model.NotificationOutbox{
+		BizID:   order.ID,
+		BizNo:   fullOrder.OrderNo,
+		Title:   title,
+		Content: text,
+	}, nil)
+
+	// 命中审批路由时同时发送审批卡片，审批通过即确认采购单，驳回则取消
+	if _, err := s.approvals.Submit(&DingTalkApprovalRequest{
+		BizType:     model.ApprovalBizPurchaseOrder,
+		BizID:       order.ID,
+		BizNo:       fullOrder.OrderNo,
+		StoreID:     order.StoreID,
+		Amount:      fullOrder.TotalAmount,
+		Title:       fmt.Sprintf("采购单待确认 - %s", store.Name),
+		Content:     text,
+		RequesterID: fullOrder.CreatedBy,
+		Exclusive:   true,
+	}); err != nil {
+		logging.LogWarn(fmt.Sprintf("[PurchaseOrder] 发送审批卡片失败: orderNo=%s, err=%v", fullOrder.OrderNo, err))
 	}
 }
+
+// EnableDingTalkApproval 开启采购单钉钉审批：确认采购单需要 purchase:edit 权限
+func (s *PurchaseOrderService) EnableDingTalkApproval(approvals *DingTalkApprovalService) {
+	s.approvals = approvals
+	approvals.RegisterHandler(model.ApprovalBizPurchaseOrder, &DingTalkApprovalHandler{
+		Permission: "purchase:edit",
+		Approve: func(approval *model.DingTalkApproval, approver *model.User) error {
+			storeID, hqUnbound := dingTalkApproverScope(approver)
+			return s.ConfirmOrderScoped(approval.BizID, storeID, hqUnbound)
+		},
+		Reject: func(approval *model.DingTalkApproval, approver *model.User) error {
+			storeID, hqUnbound := dingTalkApproverScope(approver)
+			return s.CancelOrderScoped(approval.BizID, storeID, hqUnbound, "钉钉审批驳回")
+		},
+	})
+}
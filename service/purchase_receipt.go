@@ -0,0 +1,262 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	"github.com/Kevin-Jii/tower-go/pkg/statemachine"
+)
+
+// purchaseVarianceMaxDays 收货差异报表单次查询的最大天数
+const purchaseVarianceMaxDays = 366
+
+// ReceiveOrder 采购收货：按明细登记实收数量与实际单价，生成采购入库单；可分多次收货，收齐后采购单自动完成
+func (s *PurchaseOrderService) ReceiveOrder(id, storeID uint, hqUnbound bool, operatorID uint, req *model.ReceivePurchaseOrderReq) (*model.PurchaseReceipt, error) {
+	order, err := s.GetOrderScoped(id, storeID, hqUnbound)
+	if err != nil {
+		return nil, err
+	}
+	itemMap := make(map[uint]*model.PurchaseOrderItem, len(order.Items))
+	for i := range order.Items {
+		itemMap[order.Items[i].ID] = &order.Items[i]
+	}
+
+	lines := make([]model.PurchaseReceiptItem, 0, len(req.Items))
+	for _, reqItem := range req.Items {
+		item, ok := itemMap[reqItem.OrderItemID]
+		if !ok {
+			return nil, apicode.Newf(apicode.ValidationFailed, "收货明细不属于该采购单: %d", reqItem.OrderItemID)
+		}
+		unitPrice := item.UnitPrice
+		if reqItem.UnitPrice != nil {
+			unitPrice = *reqItem.UnitPrice
+		}
+		line := newPurchaseReceiptItem(item, reqItem.Quantity, unitPrice)
+		line.Remark = strings.TrimSpace(reqItem.Remark)
+		if line.ProductionDate, err = parseOptionalDate(reqItem.ProductionDate); err != nil {
+			return nil, err
+		}
+		if line.ExpiryDate, err = parseOptionalDate(reqItem.ExpiryDate); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return s.receive(order, operatorID, strings.TrimSpace(req.Remark), lines)
+}
+
+// receiveRemaining 剩余未收数量按订单单价全部收货
+func (s *PurchaseOrderService) receiveRemaining(order *model.PurchaseOrder, operatorID uint) error {
+	var lines []model.PurchaseReceiptItem
+	for i := range order.Items {
+		item := &order.Items[i]
+		if left := roundQuantity(item.Quantity - item.ReceivedQuantity); left > 0 {
+			lines = append(lines, newPurchaseReceiptItem(item, left, item.UnitPrice))
+		}
+	}
+	if len(lines) == 0 {
+		return apicode.Newf(apicode.OrderStateConflict, "采购单没有待收货的商品")
+	}
+	_, err := s.receive(order, operatorID, "一键收齐", lines)
+	return err
+}
+
+// receive 写入收货单与采购入库单；采购单收齐时执行完成动作以发布完成事件
+func (s *PurchaseOrderService) receive(order *model.PurchaseOrder, operatorID uint, remark string, lines []model.PurchaseReceiptItem) (*model.PurchaseReceipt, error) {
+	if order.Status != model.PurchaseStatusConfirmed {
+		return nil, apicode.Newf(apicode.OrderStateConflict, "采购单须为已确认状态才能收货，当前状态为%s", getStatusName(order.Status))
+	}
+
+	operatorName := ""
+	operatorPhone := ""
+	if user, err := s.userModule.GetByID(operatorID); err == nil && user != nil {
+		operatorName = user.Nickname
+		if operatorName == "" {
+			operatorName = user.Username
+		}
+		operatorPhone = user.Phone
+	}
+	storeName := ""
+	if order.Store != nil {
+		storeName = order.Store.Name
+	}
+
+	receipt := &model.PurchaseReceipt{
+		ReceiptNo:       s.receiptModule.GenerateReceiptNo(),
+		PurchaseOrderID: order.ID,
+		PurchaseOrderNo: order.OrderNo,
+		StoreID:         order.StoreID,
+		Remark:          remark,
+		OperatorID:      operatorID,
+		OperatorName:    operatorName,
+		Items:           lines,
+	}
+	invOrder := &model.InventoryOrder{
+		OrderNo:       s.inventoryModule.GenerateOrderNo(model.InventoryTypeIn),
+		Type:          model.InventoryTypeIn,
+		StoreID:       order.StoreID,
+		StoreName:     storeName,
+		Reason:        model.ReasonPurchase,
+		Remark:        fmt.Sprintf("采购单 %s 收货 %s", order.OrderNo, receipt.ReceiptNo),
+		ItemCount:     len(lines),
+		OperatorID:    operatorID,
+		OperatorName:  operatorName,
+		OperatorPhone: operatorPhone,
+	}
+	for _, line := range lines {
+		receipt.TotalQuantity = roundQuantity(receipt.TotalQuantity + line.Quantity)
+		receipt.TotalAmount = roundMoney(receipt.TotalAmount + line.Amount)
+		receipt.PriceVariance = roundMoney(receipt.PriceVariance + line.PriceVariance)
+		invOrder.Items = append(invOrder.Items, model.InventoryOrderItem{
+			ProductID:      line.ProductID,
+			ProductName:    line.ProductName,
+			Quantity:       line.Quantity,
+			Unit:           line.Unit,
+			ProductionDate: line.ProductionDate,
+			ExpiryDate:     line.ExpiryDate,
+			Remark:         line.Remark,
+		})
+	}
+	invOrder.TotalQuantity = receipt.TotalQuantity
+
+	completed, err := s.receiptModule.ReceiveWithStockIn(receipt, invOrder)
+	if err != nil {
+		return nil, err
+	}
+	if completed {
+		_, _ = s.stateMachine.Execute(statemachine.State(model.PurchaseStatusConfirmed), statemachine.ActionComplete)
+	}
+	publishPurchaseOrderChanged(order, model.RealtimeActionUpdated, false)
+	publishInventoryOrders(invOrder)
+	return receipt, nil
+}
+
+// newPurchaseReceiptItem 按采购单明细生成收货明细，价格差异 = (实际单价 - 订单单价) × 实收数量
+func newPurchaseReceiptItem(item *model.PurchaseOrderItem, quantity, unitPrice float64) model.PurchaseReceiptItem {
+	line := model.PurchaseReceiptItem{
+		OrderItemID: item.ID,
+		SupplierID:  item.SupplierID,
+		ProductID:   item.ProductID,
+		Quantity:    roundQuantity(quantity),
+		OrderPrice:  item.UnitPrice,
+		UnitPrice:   unitPrice,
+	}
+	if item.Product != nil {
+		line.ProductName = item.Product.Name
+		line.Unit = item.Product.Unit
+	}
+	line.Amount = roundMoney(line.Quantity * unitPrice)
+	line.PriceVariance = roundMoney(line.Amount - line.Quantity*item.UnitPrice)
+	return line
+}
+
+func parseOptionalDate(s string) (*time.Time, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	t, err := parseDate(strings.TrimSpace(s))
+	if err != nil {
+		return nil, apicode.New(apicode.InvalidDate)
+	}
+	return &t, nil
+}
+
+// CloseShortScoped 短收结案：部分收货的采购单不再等待剩余到货
+func (s *PurchaseOrderService) CloseShortScoped(id, storeID uint, hqUnbound bool, reason string) error {
+	order, err := s.GetOrderScoped(id, storeID, hqUnbound)
+	if err != nil {
+		return err
+	}
+	remark := "短收结案：" + strings.TrimSpace(reason)
+	if order.Remark != "" {
+		remark = order.Remark + "；" + remark
+	}
+	if r := []rune(remark); len(r) > 500 {
+		remark = string(r[:500])
+	}
+	if err := s.receiptModule.CloseShort(order.ID, order.StoreID, remark); err != nil {
+		return err
+	}
+	_, _ = s.stateMachine.Execute(statemachine.State(model.PurchaseStatusConfirmed), statemachine.ActionComplete)
+	publishPurchaseOrderChanged(order, model.RealtimeActionUpdated, false)
+	return nil
+}
+
+// ListReceiptsScoped 采购单的收货记录
+func (s *PurchaseOrderService) ListReceiptsScoped(id, storeID uint, hqUnbound bool) ([]*model.PurchaseReceipt, error) {
+	if err := s.ensureOrderAccess(id, storeID, hqUnbound); err != nil {
+		return nil, err
+	}
+	return s.receiptModule.ListByOrder(id)
+}
+
+// VarianceReport 按供应商汇总报菜日期区间内的订货、实收、短收与价格差异
+func (s *PurchaseOrderService) VarianceReport(storeID uint, hqUnbound bool, req *model.PurchaseVarianceReq) (*model.PurchaseVarianceReport, error) {
+	if !hqUnbound {
+		if storeID == 0 {
+			return nil, apicode.New(apicode.StoreRequired)
+		}
+		req.StoreID = storeID
+	}
+	start, err := parseDate(strings.TrimSpace(req.StartDate))
+	if err != nil {
+		return nil, apicode.New(apicode.InvalidDate)
+	}
+	endDay, err := parseDate(strings.TrimSpace(req.EndDate))
+	if err != nil {
+		return nil, apicode.New(apicode.InvalidDate)
+	}
+	end := endDay.AddDate(0, 0, 1)
+	if !end.After(start) {
+		return nil, apicode.Newf(apicode.InvalidParameter, "结束日期不能早于开始日期")
+	}
+	if end.Sub(start) > purchaseVarianceMaxDays*24*time.Hour {
+		return nil, apicode.Newf(apicode.InvalidParameter, "查询区间不能超过 %d 天", purchaseVarianceMaxDays)
+	}
+
+	rows, err := s.receiptModule.VarianceRows(req, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return &model.PurchaseVarianceReport{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   endDay.Format("2006-01-02"),
+		Suppliers: buildPurchaseVarianceReport(rows),
+	}, nil
+}
+
+// buildPurchaseVarianceReport 按供应商汇总商品行（行已按供应商排序）。
+// 到货率以订单单价计：实收 / (订货 - 待到货)，待到货部分尚无结论故不计入分母。
+func buildPurchaseVarianceReport(rows []model.PurchaseVarianceRow) []model.PurchaseSupplierVariance {
+	suppliers := make([]model.PurchaseSupplierVariance, 0)
+	receivedAtOrderPrice := make([]float64, 0)
+	for _, row := range rows {
+		row.PriceVariance = roundMoney(row.ReceivedAmount - row.ReceivedAtOrderPrice)
+		n := len(suppliers)
+		if n == 0 || suppliers[n-1].SupplierID != row.SupplierID {
+			suppliers = append(suppliers, model.PurchaseSupplierVariance{
+				SupplierID:   row.SupplierID,
+				SupplierName: row.SupplierName,
+			})
+			receivedAtOrderPrice = append(receivedAtOrderPrice, 0)
+			n++
+		}
+		sv := &suppliers[n-1]
+		sv.OrderedAmount = roundMoney(sv.OrderedAmount + row.OrderedAmount)
+		sv.ReceivedAmount = roundMoney(sv.ReceivedAmount + row.ReceivedAmount)
+		sv.ShortAmount = roundMoney(sv.ShortAmount + row.ShortAmount)
+		sv.OpenAmount = roundMoney(sv.OpenAmount + row.OpenAmount)
+		sv.PriceVariance = roundMoney(sv.PriceVariance + row.PriceVariance)
+		sv.Products = append(sv.Products, row)
+		receivedAtOrderPrice[n-1] += row.ReceivedAtOrderPrice
+	}
+	for i := range suppliers {
+		if due := suppliers[i].OrderedAmount - suppliers[i].OpenAmount; due > 0 {
+			suppliers[i].FillRate = math.Round(receivedAtOrderPrice[i]/due*10000) / 10000
+		}
+	}
+	return suppliers
+}
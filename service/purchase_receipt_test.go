@@ -0,0 +1,58 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+func TestNewPurchaseReceiptItemPriceVariance(t *testing.T) {
+	item := &model.PurchaseOrderItem{
+		ID: 5, SupplierID: 2, ProductID: 8, Quantity: 10, UnitPrice: 4.5,
+		Product: &model.SupplierProduct{Name: "土豆", Unit: "斤"},
+	}
+
+	line := newPurchaseReceiptItem(item, 6, 5)
+	if line.Amount != 30 || line.PriceVariance != 3 || line.OrderPrice != 4.5 || line.Unit != "斤" {
+		t.Fatalf("line = %#v", line)
+	}
+
+	line = newPurchaseReceiptItem(item, 4, 4.5)
+	if line.Amount != 18 || line.PriceVariance != 0 {
+		t.Fatalf("line at order price = %#v", line)
+	}
+}
+
+func TestBuildPurchaseVarianceReport(t *testing.T) {
+	rows := []model.PurchaseVarianceRow{
+		// 供应商 1：订 100 元，收到 80 元货（按订单价），实付 84，短收 20
+		{SupplierID: 1, SupplierName: "蔬菜", ProductID: 1, OrderedAmount: 60, ReceivedAmount: 52, ShortAmount: 10, ReceivedAtOrderPrice: 50},
+		{SupplierID: 1, SupplierName: "蔬菜", ProductID: 2, OrderedAmount: 40, ReceivedAmount: 32, ShortAmount: 10, ReceivedAtOrderPrice: 30},
+		// 供应商 2：一半待到货，已到部分全部收齐
+		{SupplierID: 2, SupplierName: "酒水", ProductID: 3, OrderedAmount: 200, ReceivedAmount: 95, OpenAmount: 100, ReceivedAtOrderPrice: 100},
+		// 供应商 3：全部待到货，到货率无结论
+		{SupplierID: 3, SupplierName: "干货", ProductID: 4, OrderedAmount: 50, OpenAmount: 50},
+	}
+
+	suppliers := buildPurchaseVarianceReport(rows)
+	if len(suppliers) != 3 {
+		t.Fatalf("suppliers = %#v", suppliers)
+	}
+
+	veg := suppliers[0]
+	if veg.OrderedAmount != 100 || veg.ReceivedAmount != 84 || veg.ShortAmount != 20 || veg.PriceVariance != 4 || veg.FillRate != 0.8 {
+		t.Fatalf("veg = %#v", veg)
+	}
+	if len(veg.Products) != 2 || veg.Products[0].PriceVariance != 2 {
+		t.Fatalf("veg products = %#v", veg.Products)
+	}
+
+	drinks := suppliers[1]
+	if drinks.OpenAmount != 100 || drinks.PriceVariance != -5 || drinks.FillRate != 1 {
+		t.Fatalf("drinks = %#v", drinks)
+	}
+
+	if suppliers[2].FillRate != 0 {
+		t.Fatalf("open-only supplier fill rate = %v", suppliers[2].FillRate)
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/internal/authctx"
+	"github.com/Kevin-Jii/tower-go/internal/datascope"
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	"github.com/Kevin-Jii/tower-go/utils"
+	"github.com/Kevin-Jii/tower-go/utils/session"
+)
+
+// RealtimeService 将业务数据变更推送到 WebSocket 订阅主题，按订阅者的数据范围过滤
+type RealtimeService struct{}
+
+func NewRealtimeService() *RealtimeService {
+	return &RealtimeService{}
+}
+
+// Start 订阅事件总线上的业务变更事件
+func (s *RealtimeService) Start(bus *utils.EventBus) {
+	bus.Subscribe(utils.EventDataChanged, s.dispatch)
+}
+
+func (s *RealtimeService) dispatch(data interface{}) {
+	changed, ok := data.(*model.DataChangedEvent)
+	sm := session.GetSessionManager()
+	if !ok || sm == nil {
+		return
+	}
+	topic := model.RealtimeStoreTopic(changed.StoreID, changed.Resource)
+	sm.Publish(topic, realtimeMessage(topic, changed), func(sess *session.Session) bool {
+		return datascope.CanAccessRow(sess.Auth, changed.StoreID, changed.CreatorID)
+	})
+	if changed.Alert {
+		sm.Publish(model.RealtimeTopicHQAlerts, realtimeMessage(model.RealtimeTopicHQAlerts, changed), func(sess *session.Session) bool {
+			return canSubscribeHQAlerts(sess.Auth)
+		})
+	}
+}
+
+// AuthorizeRealtimeTopic 校验能否订阅主题：门店主题按数据范围校验门店，总部告警仅限全部数据范围
+func AuthorizeRealtimeTopic(ac *authctx.Context, topic string) error {
+	if topic == model.RealtimeTopicHQAlerts {
+		if !canSubscribeHQAlerts(ac) {
+			return apicode.New(apicode.OperationDenied)
+		}
+		return nil
+	}
+	storeID, _, ok := model.ParseRealtimeStoreTopic(topic)
+	if !ok {
+		return apicode.Newf(apicode.ValidationFailed, "不支持的订阅主题: %s", topic)
+	}
+	if !datascope.CanAccessStore(ac, storeID) {
+		return apicode.New(apicode.OperationDenied)
+	}
+	return nil
+}
+
+func canSubscribeHQAlerts(ac *authctx.Context) bool {
+	return ac != nil && ac.EffectiveDataScope == model.DataScopeAll
+}
+
+func realtimeMessage(topic string, changed *model.DataChangedEvent) session.WebSocketMessage {
+	return session.WebSocketMessage{
+		Type: "event",
+		Payload: model.RealtimeEvent{
+			Topic:    topic,
+			Resource: changed.Resource,
+			Action:   changed.Action,
+			StoreID:  changed.StoreID,
+			ID:       changed.ID,
+			No:       changed.No,
+		},
+		Ts: time.Now().Unix(),
+	}
+}
+
+// publishDataChanged 异步发布业务变更事件，不影响业务流程
+func publishDataChanged(changed *model.DataChangedEvent) {
+	utils.GlobalEventBus.Publish(utils.EventDataChanged, changed)
+}
+
+// publishInventoryOrders 出入库单写入后通知门店库存主题，nil 或未落库的单据跳过
+func publishInventoryOrders(orders ...*model.InventoryOrder) {
+	for _, order := range orders {
+		if order == nil || order.ID == 0 {
+			continue
+		}
+		publishDataChanged(&model.DataChangedEvent{
+			Resource:  model.RealtimeInventory,
+			Action:    model.RealtimeActionCreated,
+			StoreID:   order.StoreID,
+			CreatorID: order.OperatorID,
+			ID:        order.ID,
+			No:        order.OrderNo,
+		})
+	}
+}
+
+// publishStoreAccountChanged 记账变更通知门店记账主题，alert 为 true 时同时推送总部告警
+func publishStoreAccountChanged(account *model.StoreAccount, action string, alert bool) {
+	publishDataChanged(&model.DataChangedEvent{
+		Resource:  model.RealtimeAccounts,
+		Action:    action,
+		StoreID:   account.StoreID,
+		CreatorID: account.OperatorID,
+		ID:        account.ID,
+		No:        account.AccountNo,
+		Alert:     alert,
+	})
+}
+
+// publishPreOrderChanged 预订单变更通知门店预订单主题
+func publishPreOrderChanged(order *model.PreOrder, action string) {
+	publishDataChanged(&model.DataChangedEvent{
+		Resource:  model.RealtimePreOrders,
+		Action:    action,
+		StoreID:   order.StoreID,
+		CreatorID: order.CreatedBy,
+		ID:        order.ID,
+		No:        order.OrderNo,
+	})
+}
+
+// publishPurchaseOrderChanged 采购单变更通知门店采购单主题，alert 为 true 时同时推送总部告警
+func publishPurchaseOrderChanged(order *model.PurchaseOrder, action string, alert bool) {
+	publishDataChanged(&model.DataChangedEvent{
+		Resource:  model.RealtimePurchaseOrders,
+		Action:    action,
+		StoreID:   order.StoreID,
+		CreatorID: order.CreatedBy,
+		ID:        order.ID,
+		No:        order.OrderNo,
+		Alert:     alert,
+	})
+}
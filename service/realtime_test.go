@@ -0,0 +1,66 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Kevin-Jii/tower-go/internal/authctx"
+	"github.com/Kevin-Jii/tower-go/internal/datascope"
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+func TestParseRealtimeStoreTopic(t *testing.T) {
+	storeID, resource, ok := model.ParseRealtimeStoreTopic("store:12:accounts")
+	if !ok || storeID != 12 || resource != model.RealtimeAccounts {
+		t.Fatalf("got %d %q %v", storeID, resource, ok)
+	}
+	for _, topic := range []string{"store:0:accounts", "store:x:accounts", "store:1:members", "store:1", "hq:alerts"} {
+		if _, _, ok := model.ParseRealtimeStoreTopic(topic); ok {
+			t.Fatalf("topic %q should be rejected", topic)
+		}
+	}
+}
+
+func TestAuthorizeRealtimeTopic(t *testing.T) {
+	hq := &authctx.Context{UserID: 1, EffectiveDataScope: model.DataScopeAll, HQUnbound: true}
+	store := &authctx.Context{UserID: 2, StoreID: 3, EffectiveDataScope: model.DataScopeStore}
+
+	cases := []struct {
+		name  string
+		ac    *authctx.Context
+		topic string
+		ok    bool
+	}{
+		{"总部订阅任意门店", hq, "store:9:inventory", true},
+		{"总部订阅告警", hq, model.RealtimeTopicHQAlerts, true},
+		{"门店订阅本店", store, "store:3:pre-orders", true},
+		{"门店订阅他店", store, "store:4:accounts", false},
+		{"门店订阅总部告警", store, model.RealtimeTopicHQAlerts, false},
+		{"非法主题", hq, "store:1:unknown", false},
+		{"未认证", nil, "store:3:accounts", false},
+	}
+	for _, c := range cases {
+		if err := AuthorizeRealtimeTopic(c.ac, c.topic); (err == nil) != c.ok {
+			t.Errorf("%s: err = %v, want ok=%v", c.name, err, c.ok)
+		}
+	}
+}
+
+func TestCanAccessRowSelfScope(t *testing.T) {
+	self := &authctx.Context{UserID: 5, StoreID: 3, EffectiveDataScope: model.DataScopeSelf}
+	if !datascope.CanAccessRow(self, 3, 5) {
+		t.Fatal("own row in own store should be visible")
+	}
+	if datascope.CanAccessRow(self, 3, 6) {
+		t.Fatal("row created by others should be hidden under self scope")
+	}
+	if !datascope.CanAccessRow(self, 3, 0) {
+		t.Fatal("store-level row without creator should be visible")
+	}
+	if datascope.CanAccessRow(self, 4, 5) {
+		t.Fatal("row in another store should be hidden")
+	}
+	custom := &authctx.Context{UserID: 7, EffectiveDataScope: model.DataScopeStore, CustomStoreIDs: []uint{3, 8}}
+	if !datascope.CanAccessRow(custom, 8, 0) || datascope.CanAccessRow(custom, 4, 0) {
+		t.Fatal("custom store list not honoured")
+	}
+}
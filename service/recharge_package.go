@@ -0,0 +1,133 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+)
+
+// 充值套餐由总部维护，门店账号只能查看与使用
+
+func (s *MemberService) ListRechargePackages(req *model.ListRechargePackageReq) ([]model.RechargePackage, error) {
+	return s.module.ListRechargePackages(req)
+}
+
+func (s *MemberService) CreateRechargePackage(req *model.UpsertRechargePackageReq, isAdmin bool) (*model.RechargePackage, error) {
+	if !isAdmin {
+		return nil, apicode.Newf(apicode.OperationDenied, "充值套餐仅总部可维护")
+	}
+	return s.module.CreateRechargePackage(req)
+}
+
+func (s *MemberService) UpdateRechargePackage(id uint, req *model.UpsertRechargePackageReq, isAdmin bool) (*model.RechargePackage, error) {
+	if !isAdmin {
+		return nil, apicode.Newf(apicode.OperationDenied, "充值套餐仅总部可维护")
+	}
+	return s.module.UpdateRechargePackage(id, req)
+}
+
+func (s *MemberService) DeleteRechargePackage(id uint, isAdmin bool) error {
+	if !isAdmin {
+		return apicode.Newf(apicode.OperationDenied, "充值套餐仅总部可维护")
+	}
+	return s.module.DeleteRechargePackage(id)
+}
+
+// ListAvailableRechargePackages 查询会员所属门店当前可用的充值套餐
+func (s *MemberService) ListAvailableRechargePackages(memberID uint, storeID uint, isAdmin bool) ([]model.RechargePackage, error) {
+	if memberID > 0 {
+		member, err := s.module.GetMember(memberID, storeID, isAdmin)
+		if err != nil {
+			return nil, err
+		}
+		storeID = member.StoreID
+	}
+	return s.module.ListAvailableRechargePackages(storeID, time.Now())
+}
+
+func (s *MemberService) RechargePackageReport(req *model.RechargePackageReportReq, storeID uint, isAdmin bool) (*model.RechargePackageReport, error) {
+	return s.module.RechargePackageReport(req, storeID, isAdmin)
+}
+
+// resolveRechargePackage 确定充值单使用的套餐：指定套餐时校验可用且充值金额一致，否则按充值金额自动匹配
+func (s *MemberService) resolveRechargePackage(req *model.CreateRechargeOrderReq, member *model.Member, now time.Time) (*model.RechargePackage, error) {
+	if req.PackageID > 0 {
+		pkg, err := s.module.GetRechargePackage(req.PackageID)
+		if err != nil {
+			return nil, err
+		}
+		if !rechargePackageAvailable(pkg, member.StoreID, now) {
+			return nil, apicode.New(apicode.RechargePackageInvalid)
+		}
+		if !req.PayAmount.Equal(pkg.PayAmount) {
+			return nil, apicode.Newf(apicode.RechargePackageInvalid, "充值金额与套餐「%s」金额 %s 不一致", pkg.Name, pkg.PayAmount.StringFixed(2))
+		}
+		return pkg, nil
+	}
+	packages, err := s.module.ListAvailableRechargePackages(member.StoreID, now)
+	if err != nil {
+		return nil, err
+	}
+	return pickRechargePackage(packages, req), nil
+}
+
+// rechargePackageAvailable 套餐已启用、在活动时间内且适用于该门店（未配置门店表示全部门店）
+func rechargePackageAvailable(pkg *model.RechargePackage, storeID uint, now time.Time) bool {
+	if pkg == nil || pkg.Status != model.RechargePackageEnabled {
+		return false
+	}
+	if pkg.StartAt != nil && now.Before(*pkg.StartAt) {
+		return false
+	}
+	if pkg.EndAt != nil && now.After(*pkg.EndAt) {
+		return false
+	}
+	if len(pkg.Stores) == 0 {
+		return true
+	}
+	for _, store := range pkg.Stores {
+		if store.StoreID == storeID {
+			return true
+		}
+	}
+	return false
+}
+
+// pickRechargePackage 取充值金额达到门槛的最高档套餐，同档取赠送更多的一个
+func pickRechargePackage(packages []model.RechargePackage, req *model.CreateRechargeOrderReq) *model.RechargePackage {
+	var best *model.RechargePackage
+	for i := range packages {
+		pkg := &packages[i]
+		if pkg.PayAmount.GreaterThan(req.PayAmount) {
+			continue
+		}
+		if best == nil || pkg.PayAmount.GreaterThan(best.PayAmount) ||
+			(pkg.PayAmount.Equal(best.PayAmount) && pkg.GiftAmount.GreaterThan(best.GiftAmount)) {
+			best = pkg
+		}
+	}
+	return best
+}
+
+// applyRechargePackage 按套餐赠送金额覆盖手工填写的赠送金额，首充时追加首充赠送
+func applyRechargePackage(req *model.CreateRechargeOrderReq, pkg *model.RechargePackage, firstRecharge bool) {
+	if pkg == nil {
+		return
+	}
+	req.PackageID = pkg.ID
+	req.PackageName = pkg.Name
+	req.GiftAmount = pkg.GiftAmount
+	note := fmt.Sprintf("%s赠送%s", pkg.Name, pkg.GiftAmount.StringFixed(2))
+	if firstRecharge && pkg.FirstGiftAmount.IsPositive() {
+		req.FirstGift = pkg.FirstGiftAmount
+		req.GiftAmount = req.GiftAmount.Add(pkg.FirstGiftAmount)
+		note += fmt.Sprintf("，首充加赠%s", pkg.FirstGiftAmount.StringFixed(2))
+	}
+	if req.Remark == "" {
+		req.Remark = note
+	} else {
+		req.Remark = req.Remark + "；" + note
+	}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/shopspring/decimal"
+)
+
+func TestRechargePackageAvailable(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.Local)
+	before := now.Add(-time.Hour)
+	after := now.Add(time.Hour)
+	cases := []struct {
+		name string
+		pkg  *model.RechargePackage
+		want bool
+	}{
+		{"不限时间与门店", &model.RechargePackage{Status: model.RechargePackageEnabled}, true},
+		{"已停用", &model.RechargePackage{Status: model.RechargePackageDisabled}, false},
+		{"活动未开始", &model.RechargePackage{Status: model.RechargePackageEnabled, StartAt: &after}, false},
+		{"活动已结束", &model.RechargePackage{Status: model.RechargePackageEnabled, EndAt: &before}, false},
+		{"活动进行中", &model.RechargePackage{Status: model.RechargePackageEnabled, StartAt: &before, EndAt: &after}, true},
+		{"适用门店", &model.RechargePackage{Status: model.RechargePackageEnabled, Stores: []model.RechargePackageStore{{StoreID: 3}, {StoreID: 5}}}, true},
+		{"不适用门店", &model.RechargePackage{Status: model.RechargePackageEnabled, Stores: []model.RechargePackageStore{{StoreID: 3}}}, false},
+		{"空套餐", nil, false},
+	}
+	for _, c := range cases {
+		if got := rechargePackageAvailable(c.pkg, 5, now); got != c.want {
+			t.Fatalf("%s: rechargePackageAvailable() = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestPickRechargePackage(t *testing.T) {
+	packages := []model.RechargePackage{
+		{ID: 1, PayAmount: decimal.NewFromInt(500), GiftAmount: decimal.NewFromInt(50)},
+		{ID: 2, PayAmount: decimal.NewFromInt(1000), GiftAmount: decimal.NewFromInt(120)},
+		{ID: 3, PayAmount: decimal.NewFromInt(1000), GiftAmount: decimal.NewFromInt(150)},
+		{ID: 4, PayAmount: decimal.NewFromInt(2000), GiftAmount: decimal.NewFromInt(400)},
+	}
+	cases := []struct {
+		pay  int64
+		want uint
+	}{
+		{300, 0},
+		{500, 1},
+		{1500, 3},
+		{5000, 4},
+	}
+	for _, c := range cases {
+		got := pickRechargePackage(packages, &model.CreateRechargeOrderReq{PayAmount: decimal.NewFromInt(c.pay)})
+		var gotID uint
+		if got != nil {
+			gotID = got.ID
+		}
+		if gotID != c.want {
+			t.Fatalf("pickRechargePackage(%d) = %d, want %d", c.pay, gotID, c.want)
+		}
+	}
+}
+
+func TestApplyRechargePackage(t *testing.T) {
+	pkg := &model.RechargePackage{
+		ID:              7,
+		Name:            "充1000送150",
+		PayAmount:       decimal.NewFromInt(1000),
+		GiftAmount:      decimal.NewFromInt(150),
+		FirstGiftAmount: decimal.NewFromInt(50),
+	}
+	req := &model.CreateRechargeOrderReq{PayAmount: decimal.NewFromInt(1000), GiftAmount: decimal.NewFromInt(999)}
+	applyRechargePackage(req, pkg, true)
+	if req.PackageID != 7 || req.PackageName != "充1000送150" {
+		t.Fatalf("package not recorded: %+v", req)
+	}
+	if !req.GiftAmount.Equal(decimal.NewFromInt(200)) || !req.FirstGift.Equal(decimal.NewFromInt(50)) {
+		t.Fatalf("GiftAmount = %s, FirstGift = %s, want 200 and 50", req.GiftAmount, req.FirstGift)
+	}
+	if req.Remark != "充1000送150赠送150.00，首充加赠50.00" {
+		t.Fatalf("Remark = %q", req.Remark)
+	}
+
+	req = &model.CreateRechargeOrderReq{PayAmount: decimal.NewFromInt(1000), Remark: "老客户"}
+	applyRechargePackage(req, pkg, false)
+	if !req.GiftAmount.Equal(decimal.NewFromInt(150)) || !req.FirstGift.IsZero() {
+		t.Fatalf("GiftAmount = %s, FirstGift = %s, want 150 and 0", req.GiftAmount, req.FirstGift)
+	}
+	if req.Remark != "老客户；充1000送150赠送150.00" {
+		t.Fatalf("Remark = %q", req.Remark)
+	}
+
+	req = &model.CreateRechargeOrderReq{GiftAmount: decimal.NewFromInt(20)}
+	applyRechargePackage(req, nil, true)
+	if !req.GiftAmount.Equal(decimal.NewFromInt(20)) || req.PackageID != 0 {
+		t.Fatalf("manual gift should be kept without package: %+v", req)
+	}
+}
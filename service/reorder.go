@@ -0,0 +1,287 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/config"
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/module"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+	"github.com/Kevin-Jii/tower-go/pkg/pipeline"
+)
+
+// ReorderService 门店补货：维护商品补货点/目标，并按库存、在途采购与日均消耗生成采购单草稿
+type ReorderService struct {
+	reorderModule       *module.ReorderModule
+	inventoryModule     *module.InventoryModule
+	productModule       *module.SupplierProductModule
+	unitSpecModule      *module.ProductUnitSpecModule
+	storeSupplierModule *module.StoreSupplierModule
+	storeModule         *module.StoreModule
+}
+
+func NewReorderService(
+	reorderModule *module.ReorderModule,
+	inventoryModule *module.InventoryModule,
+	productModule *module.SupplierProductModule,
+	unitSpecModule *module.ProductUnitSpecModule,
+	storeSupplierModule *module.StoreSupplierModule,
+	storeModule *module.StoreModule,
+) *ReorderService {
+	return &ReorderService{
+		reorderModule:       reorderModule,
+		inventoryModule:     inventoryModule,
+		productModule:       productModule,
+		unitSpecModule:      unitSpecModule,
+		storeSupplierModule: storeSupplierModule,
+		storeModule:         storeModule,
+	}
+}
+
+// UpsertSetting 新增或修改门店商品补货设置，商品须属于门店已绑定的供应商
+func (s *ReorderService) UpsertSetting(storeID uint, hqUnbound bool, req *model.UpsertReorderSettingReq) (*model.StoreProductReorderSetting, error) {
+	if hqUnbound && req.StoreID > 0 {
+		storeID = req.StoreID
+	}
+	if storeID == 0 {
+		return nil, apicode.New(apicode.StoreRequired)
+	}
+	product, err := s.productModule.GetByID(req.ProductID)
+	if err != nil || product == nil {
+		return nil, apicode.New(apicode.ProductNotFound)
+	}
+	unbound, err := s.storeSupplierModule.ValidateStoreProducts(storeID, []uint{product.ID})
+	if err != nil {
+		return nil, err
+	}
+	if len(unbound) > 0 {
+		return nil, apicode.New(apicode.ProductNotBound)
+	}
+
+	basis := req.Basis
+	if basis == "" {
+		basis = model.ReorderBasisFixed
+	}
+	switch basis {
+	case model.ReorderBasisFixed:
+		if req.TargetQuantity <= 0 || req.TargetQuantity < req.ReorderPoint {
+			return nil, apicode.Newf(apicode.ValidationFailed, "补货目标须大于 0 且不低于补货点")
+		}
+	case model.ReorderBasisConsumption:
+		if req.CoverDays <= 0 {
+			return nil, apicode.Newf(apicode.ValidationFailed, "按日均消耗补货时须设置覆盖天数")
+		}
+		if req.LeadDays <= 0 && req.ReorderPoint <= 0 {
+			return nil, apicode.Newf(apicode.ValidationFailed, "按日均消耗补货时须设置到货天数或补货点")
+		}
+	}
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	setting := &model.StoreProductReorderSetting{
+		StoreID:        storeID,
+		ProductID:      product.ID,
+		SupplierID:     product.SupplierID,
+		ReorderPoint:   roundQuantity(req.ReorderPoint),
+		TargetQuantity: roundQuantity(req.TargetQuantity),
+		Basis:          basis,
+		CoverDays:      req.CoverDays,
+		LeadDays:       req.LeadDays,
+		SafetyStock:    roundQuantity(req.SafetyStock),
+		Enabled:        enabled,
+		Remark:         strings.TrimSpace(req.Remark),
+	}
+	if err := s.reorderModule.Upsert(setting); err != nil {
+		return nil, err
+	}
+	return s.reorderModule.GetByStoreAndProduct(storeID, product.ID)
+}
+
+func (s *ReorderService) ListSettings(req *model.ListReorderSettingReq) ([]*model.StoreProductReorderSetting, int64, error) {
+	return s.reorderModule.List(req)
+}
+
+func (s *ReorderService) DeleteSetting(id, storeID uint, hqUnbound bool) error {
+	if !hqUnbound && storeID == 0 {
+		return apicode.New(apicode.StoreRequired)
+	}
+	setting, err := s.reorderModule.GetByIDScoped(id, storeID, hqUnbound)
+	if err != nil {
+		return apicode.New(apicode.NotFound)
+	}
+	return s.reorderModule.Delete(setting.ID)
+}
+
+// Suggest 计算门店补货建议：库存 + 在途采购不高于补货点时，建议采购到补货目标，按供应商生成采购单草稿。
+// 按日均消耗设置的商品，补货目标为近 N 天记账消耗的日均值 × 覆盖天数。
+func (s *ReorderService) Suggest(storeID uint, hqUnbound bool, req *model.ReorderSuggestionReq, now time.Time) (*model.ReorderSuggestion, error) {
+	if hqUnbound && req.StoreID > 0 {
+		storeID = req.StoreID
+	}
+	if storeID == 0 {
+		return nil, apicode.New(apicode.StoreRequired)
+	}
+	store, err := s.storeModule.GetByID(storeID)
+	if err != nil || store == nil {
+		return nil, apicode.New(apicode.StoreNotFound)
+	}
+	days := req.ConsumptionDays
+	if days <= 0 {
+		days = config.GetInventoryConfig().ReorderConsumptionDays
+	}
+
+	settings, err := s.reorderModule.ListEnabledByStore(store.ID, req.SupplierID)
+	if err != nil {
+		return nil, err
+	}
+	onOrder, err := s.reorderModule.SumOpenPurchaseQuantities(store.ID)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.averageDailyUsage(store.ID, days, now)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]model.ReorderSuggestionLine, 0, len(settings))
+	supplierNames := make(map[uint]string)
+	for _, setting := range settings {
+		product := setting.Product
+		if product == nil {
+			continue
+		}
+		if product.Supplier != nil {
+			supplierNames[product.SupplierID] = product.Supplier.SupplierName
+		}
+		onHand := 0.0
+		if inv, err := s.inventoryModule.GetByStoreAndProduct(store.ID, product.ID); err == nil && inv != nil {
+			onHand = inv.Quantity
+		}
+		lines = append(lines, computeReorderLine(setting, product, onHand, onOrder[product.ID], usage[product.ID]))
+	}
+
+	suggestion := &model.ReorderSuggestion{
+		StoreID:         store.ID,
+		StoreName:       store.Name,
+		ConsumptionDays: days,
+		Lines:           lines,
+		Suppliers:       buildReorderDrafts(lines, supplierNames, now),
+	}
+	for _, draft := range suggestion.Suppliers {
+		suggestion.TotalAmount = roundMoney(suggestion.TotalAmount + draft.SubTotal)
+	}
+	return suggestion, nil
+}
+
+// averageDailyUsage 近 days 天（含今天）记账消耗换算为库存单位后的日均值
+func (s *ReorderService) averageDailyUsage(storeID uint, days int, now time.Time) (map[uint]float64, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	rows, err := s.reorderModule.SumAccountConsumption(storeID, today.AddDate(0, 0, -(days-1)))
+	if err != nil {
+		return nil, err
+	}
+	products := make(map[uint]*model.SupplierProduct)
+	totals := make(map[uint]float64)
+	for _, row := range rows {
+		product, ok := products[row.ProductID]
+		if !ok {
+			product, _ = s.productModule.GetByID(row.ProductID)
+			products[row.ProductID] = product
+		}
+		quantity, _ := convertToBaseQuantity(s.unitSpecModule, product, row.ProductID, row.Quantity, row.Unit)
+		totals[row.ProductID] += quantity
+	}
+	usage := make(map[uint]float64, len(totals))
+	for productID, total := range totals {
+		usage[productID] = roundQuantity(total / float64(days))
+	}
+	return usage, nil
+}
+
+// computeReorderLine 计算单个商品的建议采购量：(库存 + 在途) ≤ 补货点时补到目标，按整单位向上取整。
+// 按日均消耗时补货点取 max(设置的补货点, 日均消耗 × 到货天数 + 安全库存)，目标取日均消耗 × 覆盖天数 + 安全库存且不低于补货点。
+func computeReorderLine(setting *model.StoreProductReorderSetting, product *model.SupplierProduct, onHand, onOrder, avgDailyUsage float64) model.ReorderSuggestionLine {
+	line := model.ReorderSuggestionLine{
+		ProductID:      product.ID,
+		ProductName:    product.Name,
+		SupplierID:     product.SupplierID,
+		Unit:           product.Unit,
+		OnHand:         onHand,
+		OnOrder:        roundQuantity(onOrder),
+		AvgDailyUsage:  avgDailyUsage,
+		ReorderPoint:   setting.ReorderPoint,
+		TargetQuantity: setting.TargetQuantity,
+		Basis:          setting.Basis,
+		UnitPrice:      product.Price,
+	}
+	if setting.Basis == model.ReorderBasisConsumption {
+		line.ReorderPoint = max(setting.ReorderPoint, roundQuantity(avgDailyUsage*float64(setting.LeadDays)+setting.SafetyStock))
+		line.TargetQuantity = max(line.ReorderPoint, roundQuantity(avgDailyUsage*float64(setting.CoverDays)+setting.SafetyStock))
+	}
+	projected := roundQuantity(onHand + onOrder)
+	if projected > line.ReorderPoint {
+		return line
+	}
+	if need := roundQuantity(line.TargetQuantity - projected); need > 0 {
+		line.SuggestedQuantity = math.Ceil(need)
+		line.Amount = roundMoney(line.SuggestedQuantity * line.UnitPrice)
+	}
+	return line
+}
+
+// buildReorderDrafts 将需补货的商品按供应商分组，每个供应商生成一张可直接提交的采购单草稿
+func buildReorderDrafts(lines []model.ReorderSuggestionLine, supplierNames map[uint]string, now time.Time) []model.ReorderSupplierDraft {
+	items := make([]model.PurchaseOrderItem, 0, len(lines))
+	lineByProduct := make(map[uint]model.ReorderSuggestionLine, len(lines))
+	for _, line := range lines {
+		if line.SuggestedQuantity <= 0 {
+			continue
+		}
+		items = append(items, model.PurchaseOrderItem{
+			SupplierID: line.SupplierID,
+			ProductID:  line.ProductID,
+			Quantity:   line.SuggestedQuantity,
+			UnitPrice:  line.UnitPrice,
+		})
+		lineByProduct[line.ProductID] = line
+	}
+	items = pipeline.CalculateAmounts(items)
+
+	grouped := pipeline.GroupBySupplier(items)
+	supplierIDs := make([]uint, 0, len(grouped))
+	for supplierID := range grouped {
+		supplierIDs = append(supplierIDs, supplierID)
+	}
+	sort.Slice(supplierIDs, func(i, j int) bool { return supplierIDs[i] < supplierIDs[j] })
+
+	drafts := make([]model.ReorderSupplierDraft, 0, len(supplierIDs))
+	for _, supplierID := range supplierIDs {
+		group := grouped[supplierID]
+		draft := model.ReorderSupplierDraft{
+			SupplierID:   supplierID,
+			SupplierName: supplierNames[supplierID],
+			SubTotal:     roundMoney(pipeline.SumTotal(group)),
+			Draft: model.CreatePurchaseOrderReq{
+				OrderDate: now.Format("2006-01-02"),
+				Remark:    fmt.Sprintf("补货建议（%s）", supplierNames[supplierID]),
+			},
+		}
+		for _, item := range group {
+			line := lineByProduct[item.ProductID]
+			draft.Lines = append(draft.Lines, line)
+			draft.Draft.Items = append(draft.Draft.Items, model.CreatePurchaseOrderItemReq{
+				ProductID: item.ProductID,
+				Quantity:  item.Quantity,
+				Remark:    fmt.Sprintf("库存 %g + 在途 %g，目标 %g", line.OnHand, line.OnOrder, line.TargetQuantity),
+			})
+		}
+		drafts = append(drafts, draft)
+	}
+	return drafts
+}
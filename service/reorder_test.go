@@ -0,0 +1,67 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+func TestComputeReorderLineFixedTarget(t *testing.T) {
+	setting := &model.StoreProductReorderSetting{ReorderPoint: 10, TargetQuantity: 30, Basis: model.ReorderBasisFixed}
+	product := &model.SupplierProduct{ID: 1, SupplierID: 7, Name: "啤酒", Unit: "瓶", Price: 5}
+
+	line := computeReorderLine(setting, product, 6.5, 2, 0)
+	if line.SuggestedQuantity != 22 || line.Amount != 110 {
+		t.Fatalf("line = %#v", line)
+	}
+
+	line = computeReorderLine(setting, product, 8, 4, 0)
+	if line.SuggestedQuantity != 0 {
+		t.Fatalf("above reorder point should not suggest, got %#v", line)
+	}
+}
+
+func TestComputeReorderLineConsumptionTarget(t *testing.T) {
+	setting := &model.StoreProductReorderSetting{ReorderPoint: 5, Basis: model.ReorderBasisConsumption, CoverDays: 7}
+	product := &model.SupplierProduct{ID: 2, SupplierID: 7, Name: "白酒", Unit: "瓶", Price: 80}
+
+	line := computeReorderLine(setting, product, 3, 0, 2.5)
+	if line.TargetQuantity != 17.5 || line.SuggestedQuantity != 15 {
+		t.Fatalf("line = %#v", line)
+	}
+}
+
+func TestComputeReorderLineConsumptionDerivesReorderPoint(t *testing.T) {
+	setting := &model.StoreProductReorderSetting{Basis: model.ReorderBasisConsumption, CoverDays: 10, LeadDays: 3, SafetyStock: 4}
+	product := &model.SupplierProduct{ID: 2, SupplierID: 7, Name: "白酒", Unit: "瓶", Price: 80}
+
+	// 补货点 = 2 × 3 + 4 = 10，库存 8 + 在途 1 未覆盖到货期间消耗，补到 2 × 10 + 4 = 24
+	line := computeReorderLine(setting, product, 8, 1, 2)
+	if line.ReorderPoint != 10 || line.TargetQuantity != 24 || line.SuggestedQuantity != 15 {
+		t.Fatalf("line = %#v", line)
+	}
+	if line = computeReorderLine(setting, product, 11, 0, 2); line.SuggestedQuantity != 0 {
+		t.Fatalf("above derived reorder point should not suggest, got %#v", line)
+	}
+}
+
+func TestBuildReorderDraftsGroupsBySupplier(t *testing.T) {
+	lines := []model.ReorderSuggestionLine{
+		{ProductID: 1, SupplierID: 9, SuggestedQuantity: 2, UnitPrice: 10},
+		{ProductID: 2, SupplierID: 3, SuggestedQuantity: 5, UnitPrice: 1.5},
+		{ProductID: 3, SupplierID: 9, SuggestedQuantity: 1, UnitPrice: 4},
+		{ProductID: 4, SupplierID: 3, SuggestedQuantity: 0, UnitPrice: 4},
+	}
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.Local)
+	drafts := buildReorderDrafts(lines, map[uint]string{3: "供应商A", 9: "供应商B"}, now)
+	if len(drafts) != 2 || drafts[0].SupplierID != 3 || drafts[1].SupplierID != 9 {
+		t.Fatalf("drafts = %#v", drafts)
+	}
+	if drafts[0].SubTotal != 7.5 || len(drafts[0].Draft.Items) != 1 {
+		t.Fatalf("supplier 3 draft = %#v", drafts[0])
+	}
+	if drafts[1].SubTotal != 24 || len(drafts[1].Draft.Items) != 2 || drafts[1].Draft.OrderDate != "2026-10-18" {
+		t.Fatalf("supplier 9 draft = %#v", drafts[1])
+	}
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/module"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+)
+
+type StocktakeService struct {
+	stocktakeModule *module.StocktakeModule
+	inventoryModule *module.InventoryModule
+	unitSpecModule  *module.ProductUnitSpecModule
+	productModule   *module.SupplierProductModule
+	storeModule     *module.StoreModule
+	userModule      *module.UserModule
+}
+
+func NewStocktakeService(
+	stocktakeModule *module.StocktakeModule,
+	inventoryModule *module.InventoryModule,
+	unitSpecModule *module.ProductUnitSpecModule,
+	productModule *module.SupplierProductModule,
+	storeModule *module.StoreModule,
+	userModule *module.UserModule,
+) *StocktakeService {
+	return &StocktakeService{
+		stocktakeModule: stocktakeModule,
+		inventoryModule: inventoryModule,
+		unitSpecModule:  unitSpecModule,
+		productModule:   productModule,
+		storeModule:     storeModule,
+		userModule:      userModule,
+	}
+}
+
+// Open 开启盘点：快照门店当前系统库存及基础单位成本价
+func (s *StocktakeService) Open(storeID, operatorID uint, req *model.CreateStocktakeReq, hqUnbound bool) (*model.Stocktake, error) {
+	if hqUnbound && req.StoreID > 0 {
+		storeID = req.StoreID
+	}
+	if storeID == 0 {
+		return nil, apicode.New(apicode.StoreRequired)
+	}
+	store, err := s.storeModule.GetByID(storeID)
+	if err != nil || store == nil {
+		return nil, apicode.New(apicode.StoreNotFound)
+	}
+
+	items, err := s.stocktakeModule.ListStoreSnapshot(store.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].CostPrice = s.baseUnitCost(items[i].ProductID, items[i].BaseUnit)
+	}
+
+	operatorName, _ := s.operatorInfo(operatorID)
+	stocktake := &model.Stocktake{
+		StocktakeNo: s.stocktakeModule.GenerateStocktakeNo(),
+		StoreID:     store.ID,
+		StoreName:   store.Name,
+		Status:      model.StocktakeStatusCounting,
+		Remark:      strings.TrimSpace(req.Remark),
+		CreatorID:   operatorID,
+		CreatorName: operatorName,
+		Items:       items,
+	}
+	if err := s.stocktakeModule.CreateOpen(stocktake); err != nil {
+		return nil, err
+	}
+	return s.stocktakeModule.GetByIDScoped(stocktake.ID, 0, true)
+}
+
+// RecordCounts 录入实盘数量，可按任意规格分多次录入，数量换算为基础库存单位后累加
+func (s *StocktakeService) RecordCounts(id, storeID, operatorID uint, req *model.RecordStocktakeCountReq, hqUnbound bool) (*model.Stocktake, error) {
+	stocktake, err := s.Get(id, storeID, hqUnbound)
+	if err != nil {
+		return nil, apicode.New(apicode.OrderNotFound)
+	}
+	if stocktake.Status != model.StocktakeStatusCounting {
+		return nil, apicode.Newf(apicode.OrderStateConflict, "盘点单已结束，不能继续录入")
+	}
+
+	operatorName, _ := s.operatorInfo(operatorID)
+	snapshot := make(map[uint]bool, len(stocktake.Items))
+	for _, item := range stocktake.Items {
+		snapshot[item.ProductID] = true
+	}
+
+	lines := make([]model.StocktakeCountLine, 0, len(req.Items))
+	for _, line := range req.Items {
+		product, err := s.productModule.GetByID(line.ProductID)
+		if err != nil || product == nil {
+			return nil, apicode.Newf(apicode.ProductNotFound, "商品 %d 不存在", line.ProductID)
+		}
+		unit := strings.TrimSpace(line.Unit)
+		if unit == "" {
+			unit = product.Unit
+		}
+		baseQuantity, baseUnit := convertToBaseQuantity(s.unitSpecModule, product, product.ID, line.Quantity, unit)
+
+		item := model.StocktakeItem{
+			ProductID:   product.ID,
+			ProductName: product.Name,
+			BaseUnit:    baseUnit,
+			Remark:      strings.TrimSpace(line.Remark),
+		}
+		if !snapshot[product.ID] {
+			// 开单后才出现的商品以首次录入时的系统库存为快照
+			if inv, err := s.inventoryModule.GetByStoreAndProduct(stocktake.StoreID, product.ID); err == nil && inv != nil {
+				item.SystemQuantity = inv.Quantity
+			}
+			item.CostPrice = s.baseUnitCost(product.ID, baseUnit)
+		}
+		lines = append(lines, model.StocktakeCountLine{
+			Item: item,
+			Entry: model.StocktakeEntry{
+				Unit:         unit,
+				Quantity:     line.Quantity,
+				BaseQuantity: roundQuantity(baseQuantity),
+				CounterID:    operatorID,
+				CounterName:  operatorName,
+			},
+			Replace: line.Replace,
+		})
+	}
+
+	if err := s.stocktakeModule.RecordCounts(stocktake.ID, storeID, hqUnbound, lines); err != nil {
+		return nil, err
+	}
+	return s.stocktakeModule.GetByIDScoped(stocktake.ID, storeID, hqUnbound)
+}
+
+// Post 复核后过账：差异生成一张原因为“库存调整”的盘点调整单，盘盈入库、盘亏出库
+func (s *StocktakeService) Post(id, storeID, operatorID uint, req *model.PostStocktakeReq, hqUnbound bool) (*model.Stocktake, error) {
+	stocktake, err := s.Get(id, storeID, hqUnbound)
+	if err != nil {
+		return nil, apicode.New(apicode.OrderNotFound)
+	}
+
+	operatorName, operatorPhone := s.operatorInfo(operatorID)
+	remark := fmt.Sprintf("盘点单 %s 差异调整", stocktake.StocktakeNo)
+	if r := strings.TrimSpace(req.Remark); r != "" {
+		remark += "；" + r
+	}
+	order := &model.InventoryOrder{
+		OrderNo:       s.inventoryModule.GenerateOrderNo(model.InventoryTypeAdjust),
+		Reason:        model.ReasonAdjust,
+		Remark:        remark,
+		OperatorID:    operatorID,
+		OperatorName:  operatorName,
+		OperatorPhone: operatorPhone,
+	}
+	if err := s.stocktakeModule.PostWithAdjustment(stocktake.ID, storeID, hqUnbound, req.ZeroUncounted, operatorID, operatorName, order); err != nil {
+		return nil, err
+	}
+	return s.stocktakeModule.GetByIDScoped(stocktake.ID, storeID, hqUnbound)
+}
+
+// Cancel 作废盘点中的单据，不影响库存
+func (s *StocktakeService) Cancel(id, storeID uint, hqUnbound bool) error {
+	if !hqUnbound && storeID == 0 {
+		return apicode.New(apicode.StoreRequired)
+	}
+	return s.stocktakeModule.Cancel(id, storeID, hqUnbound)
+}
+
+func (s *StocktakeService) Get(id, storeID uint, hqUnbound bool) (*model.Stocktake, error) {
+	if !hqUnbound && storeID == 0 {
+		return nil, apicode.New(apicode.StoreRequired)
+	}
+	return s.stocktakeModule.GetByIDScoped(id, storeID, hqUnbound)
+}
+
+func (s *StocktakeService) List(ctx context.Context, req *model.ListStocktakeReq) ([]*model.Stocktake, int64, error) {
+	_ = ctx
+	return s.stocktakeModule.List(req)
+}
+
+// baseUnitCost 基础库存单位成本价：优先取规格成本，未配置时回退商品价格
+func (s *StocktakeService) baseUnitCost(productID uint, baseUnit string) float64 {
+	specs, _ := s.unitSpecModule.ListEnabledByProductID(productID)
+	cost := resolveUnitCostFromSpecs(baseUnit, specs)
+	if cost > 0 {
+		return cost
+	}
+	product, err := s.productModule.GetByID(productID)
+	if err != nil {
+		return 0
+	}
+	return resolveFallbackCostPrice(baseUnit, product)
+}
+
+func (s *StocktakeService) operatorInfo(operatorID uint) (string, string) {
+	user, err := s.userModule.GetByID(operatorID)
+	if err != nil || user == nil {
+		return "", ""
+	}
+	name := user.Nickname
+	if name == "" {
+		name = user.Username
+	}
+	return name, user.Phone
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math"
 	"sort"
@@ -273,10 +274,11 @@ type StoreAccountService struct {
 	userModule            *module.UserModule
 	dictModule            *module.DictModule
 	b2bModule             *module.B2BModule
-	dingTalkService       *DingTalkService
-	botModule             *module.DingTalkBotModule
+	notifier              *NotificationService
 	templateService       *MessageTemplateService
 	imageGeneratorService *ImageGeneratorService
+	approvals             *DingTalkApprovalService
+	autoPrinter           *PrintDocumentService
 }
 
 func NewStoreAccountService(
@@ -289,8 +291,7 @@ func NewStoreAccountService(
 	userModule *module.UserModule,
 	dictModule *module.DictModule,
 	b2bModule *module.B2BModule,
-	dingTalkService *DingTalkService,
-	botModule *module.DingTalkBotModule,
+	notifier *NotificationService,
 	templateService *MessageTemplateService,
 	imageGeneratorService *ImageGeneratorService,
 ) *StoreAccountService {
@@ -304,8 +305,7 @@ func NewStoreAccountService(
 		userModule:            userModule,
 		dictModule:            dictModule,
 		b2bModule:             b2bModule,
-		dingTalkService:       dingTalkService,
-		botModule:             botModule,
+		notifier:              notifier,
 		templateService:       templateService,
 		imageGeneratorService: imageGeneratorService,
 	}
@@ -419,10 +419,13 @@ func (s *StoreAccountService) buildStoreAccountItems(requestItems []model.Create
 
 // Create 创建记账
 func (s *StoreAccountService) Create(storeID, operatorID uint, req *model.CreateStoreAccountReq) (*model.StoreAccount, error) {
+	var member *model.Member
 	if req.MemberID != nil && *req.MemberID > 0 && s.memberModule != nil {
-		if _, err := s.memberModule.GetMember(*req.MemberID, storeID, false); err != nil {
+		m, err := s.memberModule.GetMember(*req.MemberID, storeID, false)
+		if err != nil {
 			return nil, apicode.New(apicode.MemberNotFound)
 		}
+		member = m
 	}
 
 	channel := strings.TrimSpace(req.Channel)
@@ -462,6 +465,15 @@ func (s *StoreAccountService) Create(storeID, operatorID uint, req *model.Create
 	if err != nil {
 		return nil, err
 	}
+	// 会员等级折扣按明细打折；外卖等平台渠道自定义收入金额时不打折
+	var discountRate, memberDiscount float64
+	if member != nil && req.IncomeAmount == nil {
+		discountRate, err = s.memberDiscountRate(member)
+		if err != nil {
+			return nil, err
+		}
+		totalAmount, memberDiscount = applyMemberDiscount(items, discountRate)
+	}
 	var consumables []model.StoreAccountConsumable
 	var consumableAmount float64
 
@@ -572,17 +584,34 @@ func (s *StoreAccountService) Create(storeID, operatorID uint, req *model.Create
 		}
 		giftWineCostAmount = giftWine.CostAmount
 	}
+	pointsDeduction, err := s.resolvePointsDeduction(storeID, req.MemberID, req.RedeemPoints, totalAmount-req.RoundAmount)
+	if err != nil {
+		return nil, err
+	}
+	paymentStatus := resolvePaymentStatus(req.PaymentStatus)
+	balanceAmount, payMethod, err := resolveBalancePayment(req.BalanceAmount, req.PayMethod,
+		roundMoney(totalAmount-req.RoundAmount-pointsDeduction), paymentStatus, member != nil)
+	if err != nil {
+		return nil, err
+	}
 
 	account := &model.StoreAccount{
 		AccountNo:           accountNo,
 		StoreID:             storeID,
 		MemberID:            req.MemberID,
-		PaymentStatus:       resolvePaymentStatus(req.PaymentStatus),
+		PaymentStatus:       paymentStatus,
 		Channel:             channel,
 		OrderNo:             orderNo,
 		TotalAmount:         totalAmount,
 		OtherExpenseAmount:  req.OtherExpenseAmount,
 		RoundAmount:         req.RoundAmount,
+		PointsRedeemed:      req.RedeemPoints,
+		PointsDeduction:     pointsDeduction,
+		MemberDiscountRate:  discountRate,
+		MemberDiscount:      memberDiscount,
+		BalanceAmount:       balanceAmount,
+		PayMethod:           payMethod,
+		MemberVersion:       req.MemberVersion,
 		IsGiftWine:          isGiftWine,
 		GiftWineProductID:   giftWineValue(giftWine, func(v *giftWineSnapshot) uint { return v.ProductID }),
 		GiftWineProductName: giftWineStringValue(giftWine, func(v *giftWineSnapshot) string { return v.ProductName }),
@@ -654,6 +683,11 @@ func (s *StoreAccountService) Create(storeID, operatorID uint, req *model.Create
 	}
 
 	// 兼容历史库存：若库存仍是“箱/桶”等旧单位，先换算到基础单位再扣减
+	correction := model.InventoryMovementSource{
+		Type:         model.MovementSourceUnitCorrection,
+		OperatorID:   operatorID,
+		OperatorName: inventoryOutOrder.OperatorName,
+	}
 	for _, outItem := range inventoryOutOrder.Items {
 		inv, err := s.inventoryModule.GetByStoreAndProduct(storeID, outItem.ProductID)
 		if err != nil || inv == nil {
@@ -669,7 +703,9 @@ func (s *StoreAccountService) Create(storeID, operatorID uint, req *model.Create
 			if convertedQty <= 0 {
 				continue
 			}
-			if err := s.inventoryModule.UpdateQuantityAndUnit(inv.ID, convertedQty, baseUnit); err != nil {
+			correction.ID = inv.ID
+			correction.Remark = fmt.Sprintf("库存单位纠偏：%.2f%s → %.2f%s", inv.Quantity, inv.Unit, convertedQty, baseUnit)
+			if err := s.inventoryModule.UpdateQuantityAndUnit(inv.ID, convertedQty, baseUnit, correction); err != nil {
 				return nil, err
 			}
 			continue
@@ -689,7 +725,9 @@ func (s *StoreAccountService) Create(storeID, operatorID uint, req *model.Create
 				// 若“库存数量 * 大规格系数”能覆盖本次出库，按历史箱数纠偏一次
 				candidate := inv.Quantity * spec.FactorToBase
 				if candidate >= outItem.Quantity {
-					if err := s.inventoryModule.UpdateQuantityAndUnit(inv.ID, candidate, outUnit); err != nil {
+					correction.ID = inv.ID
+					correction.Remark = fmt.Sprintf("历史箱数纠偏：%.2f × %g → %.2f%s", inv.Quantity, spec.FactorToBase, candidate, outUnit)
+					if err := s.inventoryModule.UpdateQuantityAndUnit(inv.ID, candidate, outUnit, correction); err != nil {
 						return nil, err
 					}
 					legacyFixed = true
@@ -721,23 +759,22 @@ func (s *StoreAccountService) Create(storeID, operatorID uint, req *model.Create
 		}
 	}
 
-	// 获取渠道名称（字典转换）
-	channelName := account.Channel
-	if s.dictModule != nil && account.Channel != "" {
-		if dictData, err := s.dictModule.GetDataByTypeAndValue("sales_channel", account.Channel); err == nil && dictData != nil {
-			channelName = dictData.Label
-		}
-	}
+	// 提交后写入发件箱，回单图片由投递任务生成
+	s.enqueueDingTalkNotification(account, storeID, operatorName, s.channelLabel(account.Channel))
+	publishStoreAccountChanged(account, model.RealtimeActionCreated, false)
+	publishInventoryOrders(outForTx)
 
-	// 异步发送钉钉通知
-	go s.sendDingTalkNotification(account, storeID, operatorName, channelName)
+	// 补记账为事后补录，不自动打印小票
+	if s.autoPrinter != nil && account.IsSupplement != 1 {
+		go s.autoPrinter.AutoPrint(model.PrintDocStoreAccount, account.ID, account.StoreID)
+	}
 
 	return account, nil
 }
 
-// sendDingTalkNotification 发送记账通知到门店负责人
-func (s *StoreAccountService) sendDingTalkNotification(account *model.StoreAccount, storeID uint, operatorName, channelName string) {
-	if s.dingTalkService == nil || s.storeModule == nil || s.botModule == nil {
+// enqueueDingTalkNotification 生成记账通知，按通知路由写入发件箱；回单图片只记录数据，投递时再生成
+func (s *StoreAccountService) enqueueDingTalkNotification(account *model.StoreAccount, storeID uint, operatorName, channelName string) {
+	if s.notifier == nil || s.storeModule == nil {
 		return
 	}
 
@@ -750,35 +787,15 @@ func (s *StoreAccountService) sendDingTalkNotification(account *model.StoreAccou
 		return
 	}
 
-	// 检查门店是否有联系电话
-	if store.Phone == "" {
-		if logging.SugaredLogger != nil {
-			logging.SugaredLogger.Warnw("Store has no phone, skip notification", "storeID", storeID)
-		}
-		return
-	}
-
-	// 获取门店绑定的机器人
-	bot, err := s.botModule.GetByStoreID(storeID)
-	if err != nil || bot == nil {
-		if logging.SugaredLogger != nil {
-			logging.SugaredLogger.Warnw("No bot found for store", "storeID", storeID, "error", err)
-		}
-		return
-	}
-
-	if !bot.IsEnabled || bot.BotType != "stream" {
-		return
-	}
-
 	// 操作人显示
 	operatorDisplay := operatorName
 	if operatorDisplay == "" {
 		operatorDisplay = "未知"
 	}
 
-	// 尝试生成通知图片
+	// 回单图片数据随通知写入发件箱，由投递任务生成图片后回填
 	var imageURL string
+	var imageSpec *model.NotificationImageSpec
 	if s.imageGeneratorService != nil {
 		var items []AccountItemData
 		for _, item := range account.Items {
@@ -809,51 +826,25 @@ func (s *StoreAccountService) sendDingTalkNotification(account *model.StoreAccou
 			CreateTime:   time.Now().Format("2006-01-02 15:04:05"),
 		}
 
-		url, err := s.imageGeneratorService.GenerateAccountNotifyImage(imgData)
-		if err != nil {
-			if logging.SugaredLogger != nil {
-				logging.SugaredLogger.Warnw("Failed to generate notify image", "error", err)
+		if encoded, err := json.Marshal(imgData); err == nil {
+			imageSpec = &model.NotificationImageSpec{
+				Kind:          model.NotificationImageKindStoreAccount,
+				Data:          encoded,
+				CardParamKeys: []string{"shangpinimg", "imageUrl"},
+				ButtonTitle:   "查看记账回单",
 			}
-		} else {
-			imageURL = url
 		}
 	}
 
 	// 构建商品明细
-	var itemLines []string
-	type templateItem struct {
-		ProductName string
-		Quantity    string
-		Amount      string
-	}
-	templateItems := make([]templateItem, 0, len(account.Items))
-	for i, item := range account.Items {
-		line := fmt.Sprintf("%d. %s x%.2f%s = ¥%.2f", i+1, item.ProductName, item.Quantity, item.Unit, item.Amount)
-		itemLines = append(itemLines, line)
-		templateItems = append(templateItems, templateItem{
-			ProductName: strings.TrimSpace(item.ProductName),
-			Quantity:    fmt.Sprintf("%.2f%s", item.Quantity, strings.TrimSpace(item.Unit)),
-			Amount:      fmt.Sprintf("¥%.2f", item.Amount),
-		})
-	}
+	data := storeAccountTemplateData(account, store.Name, operatorDisplay, channelName, imageURL)
+	itemLines := storeAccountItemLines(account)
 
 	var title, text string
 
 	// 构建文字消息内容
 	// 尝试使用模板
 	if s.templateService != nil {
-		data := map[string]interface{}{
-			"StoreName":    store.Name,
-			"AccountNo":    account.AccountNo,
-			"ChannelName":  channelName,
-			"AccountDate":  account.AccountDate.Format("2006-01-02"),
-			"OperatorName": operatorDisplay,
-			"ItemList":     strings.Join(itemLines, "\n\n"),
-			"Items":        templateItems,
-			"TotalAmount":  fmt.Sprintf("%.2f", account.TotalAmount),
-			"ItemCount":    account.ItemCount,
-			"CreateTime":   time.Now().Format("2006-01-02 15:04:05"),
-		}
 		var err error
 		title, text, err = s.templateService.RenderTemplate(model.TemplateStoreAccountCreated, data)
 		if err != nil {
@@ -889,108 +880,82 @@ func (s *StoreAccountService) sendDingTalkNotification(account *model.StoreAccou
 		)
 	}
 
-	cardTitle, cardText, cardButtonTitle, cardButtonURL := s.buildAccountActionCard(account, store.Name, operatorDisplay, channelName, itemLines, imageURL)
+	cardTitle, cardText, cardButtonTitle, cardButtonURL := s.buildAccountActionCard(account, store.Name, operatorDisplay, channelName, itemLines, imageURL, data)
 
-	// 发送通知：记账通知优先使用钉钉卡片，失败后回退到 Markdown，避免通知丢失
-	var sendErr error
-	if strings.TrimSpace(bot.CardMsgKey) != "" {
-		itemListForCard := make([]map[string]interface{}, 0, len(account.Items))
-		for _, it := range account.Items {
-			name := strings.TrimSpace(it.ProductName)
-			if name == "" {
-				name = fmt.Sprintf("商品#%d", it.ProductID)
-			}
-			itemListForCard = append(itemListForCard, map[string]interface{}{
-				"name":     name,
-				"quantity": fmt.Sprintf("%.2f", it.Quantity),
-				"unit":     strings.TrimSpace(it.Unit),
-				"amount":   fmt.Sprintf("%.2f", it.Amount),
-			})
-		}
-		accountBlock := map[string]interface{}{
-			"account_no":    account.AccountNo,
-			"channel":       channelName,
-			"account_date":  account.AccountDate.Format("2006-01-02"),
-			"other_expense": fmt.Sprintf("%.2f", account.OtherExpenseAmount),
-			"net_income":    fmt.Sprintf("%.2f", account.NetIncomeAmount),
-		}
-		cardParam := map[string]interface{}{
-			"title":        title,
-			"storeName":    store.Name,
-			"storename":    store.Name,
-			"accountNo":    account.AccountNo,
-			"channelName":  channelName,
-			"accountDate":  account.AccountDate.Format("2006-01-02"),
-			"operatorName": operatorDisplay,
-			"content":      text,
-			"item_list":    itemListForCard,
-			"itemList":     strings.Join(itemLines, "\n"),
-			"shangpinls":   strings.Join(itemLines, "\n"),
-			"shangpinimg":  imageURL,
-			"itemCount":    account.ItemCount,
-			"totalAmount":  fmt.Sprintf("%.2f", account.TotalAmount),
-			"createTime":   time.Now().Format("2006-01-02 15:04:05"),
-			"imageUrl":     imageURL,
-			"account":      accountBlock,
-			// 兼容钉钉模板使用扁平点路径变量名的场景
-			"account.account_no":    accountBlock["account_no"],
-			"account.channel":       accountBlock["channel"],
-			"account.account_date":  accountBlock["account_date"],
-			"account.other_expense": accountBlock["other_expense"],
-			"account.net_income":    accountBlock["net_income"],
-			"account.total_amount":  fmt.Sprintf("%.2f", account.TotalAmount),
-			"ccount.total_amount":   fmt.Sprintf("%.2f", account.TotalAmount),
-		}
-		sendErr = s.dingTalkService.SendStreamCardToMobile(bot, bot.CardMsgKey, store.Phone, cardParam)
-		if sendErr != nil && logging.SugaredLogger != nil {
-			logging.SugaredLogger.Warnw("Failed to send custom account card, fallback to action card",
-				"storeID", storeID,
-				"accountNo", account.AccountNo,
-				"cardMsgKey", bot.CardMsgKey,
-				"error", sendErr,
-			)
-		}
-	}
-	if sendErr != nil || strings.TrimSpace(bot.CardMsgKey) == "" {
-		sendErr = s.dingTalkService.SendStreamActionCardToMobile(bot, cardTitle, cardText, cardButtonTitle, cardButtonURL, store.Phone)
-		if sendErr != nil && logging.SugaredLogger != nil {
-			logging.SugaredLogger.Warnw("Failed to send account action card, fallback to markdown",
-				"storeID", storeID,
-				"accountNo", account.AccountNo,
-				"error", sendErr,
-			)
-		}
-	}
-	if sendErr != nil {
-		if imageURL != "" {
-			sendErr = s.dingTalkService.SendStreamMarkdownWithImageToMobile(bot, title, text, imageURL, store.Phone)
-		} else {
-			sendErr = s.dingTalkService.SendStreamMarkdownToMobile(bot, title, text, store.Phone)
-		}
+	// 记账通知优先使用接收机器人配置的钉钉卡片，投递失败时回退到 ActionCard、Markdown，避免通知丢失
+	payload := &model.NotificationPayload{
+		ActionTitle:       cardTitle,
+		ActionText:        cardText,
+		ActionButtonTitle: cardButtonTitle,
+		ActionButtonURL:   cardButtonURL,
 	}
-
-	if sendErr != nil {
-		if logging.SugaredLogger != nil {
-			logging.SugaredLogger.Errorw("Failed to send account notification",
-				"storeID", storeID,
-				"accountNo", account.AccountNo,
-				"error", sendErr,
-			)
-		}
-	} else {
-		if logging.SugaredLogger != nil {
-			logging.SugaredLogger.Infow("Account notification sent",
-				"storeID", storeID,
-				"accountNo", account.AccountNo,
-				"mobile", store.Phone,
-				"hasImage", imageURL != "",
-				"messageType", "card",
-			)
+	itemListForCard := make([]map[string]interface{}, 0, len(account.Items))
+	for _, it := range account.Items {
+		name := strings.TrimSpace(it.ProductName)
+		if name == "" {
+			name = fmt.Sprintf("商品#%d", it.ProductID)
 		}
+		itemListForCard = append(itemListForCard, map[string]interface{}{
+			"name":     name,
+			"quantity": fmt.Sprintf("%.2f", it.Quantity),
+			"unit":     strings.TrimSpace(it.Unit),
+			"amount":   fmt.Sprintf("%.2f", it.Amount),
+		})
 	}
+	accountBlock := map[string]interface{}{
+		"account_no":    account.AccountNo,
+		"channel":       channelName,
+		"account_date":  account.AccountDate.Format("2006-01-02"),
+		"other_expense": fmt.Sprintf("%.2f", account.OtherExpenseAmount),
+		"net_income":    fmt.Sprintf("%.2f", account.NetIncomeAmount),
+	}
+	cardParam := map[string]interface{}{
+		"title":        title,
+		"storeName":    store.Name,
+		"storename":    store.Name,
+		"accountNo":    account.AccountNo,
+		"channelName":  channelName,
+		"accountDate":  account.AccountDate.Format("2006-01-02"),
+		"operatorName": operatorDisplay,
+		"content":      text,
+		"item_list":    itemListForCard,
+		"itemList":     strings.Join(itemLines, "\n"),
+		"shangpinls":   strings.Join(itemLines, "\n"),
+		"shangpinimg":  imageURL,
+		"itemCount":    account.ItemCount,
+		"totalAmount":  fmt.Sprintf("%.2f", account.TotalAmount),
+		"createTime":   time.Now().Format("2006-01-02 15:04:05"),
+		"imageUrl":     imageURL,
+		"account":      accountBlock,
+		// 兼容钉钉模板使用扁平点路径变量名的场景
+		"account.account_no":    accountBlock["account_no"],
+		"account.channel":       accountBlock["channel"],
+		"account.account_date":  accountBlock["account_date"],
+		"account.other_expense": accountBlock["other_expense"],
+		"account.net_income":    accountBlock["net_income"],
+		"account.total_amount":  fmt.Sprintf("%.2f", account.TotalAmount),
+		"ccount.total_amount":   fmt.Sprintf("%.2f", account.TotalAmount),
+	}
+	payload.CardParam = cardParam
+	payload.Image = imageSpec
+
+	_, _ = s.notifier.Notify(&model.NotificationEvent{
+		EventType: model.NotificationBizStoreAccount,
+		StoreID:   storeID,
+		Channel:   account.Channel,
+		Amount:    account.TotalAmount,
+	}, &model.NotificationOutbox{
+		BizID:    account.ID,
+		BizNo:    account.AccountNo,
+		MsgType:  model.NotificationMsgCard,
+		Title:    title,
+		Content:  text,
+		ImageURL: imageURL,
+	}, payload)
 }
 
-func (s *StoreAccountService) buildAccountActionCard(account *model.StoreAccount, storeName, operatorName, channelName string, itemLines []string, imageURL string) (string, string, string, string) {
+// buildAccountActionCard 记账 ActionCard：优先使用 store_account_card 模板，模板缺失或渲染失败时按默认格式拼装
+func (s *StoreAccountService) buildAccountActionCard(account *model.StoreAccount, storeName, operatorName, channelName string, itemLines []string, imageURL string, data map[string]interface{}) (string, string, string, string) {
 	title := fmt.Sprintf("新记账通知 - %s", storeName)
 	buttonTitle := "查看记账回单"
 	buttonURL := strings.TrimSpace(imageURL)
@@ -999,6 +964,19 @@ func (s *StoreAccountService) buildAccountActionCard(account *model.StoreAccount
 		buttonURL = "https://www.dingtalk.com/"
 	}
 
+	if s.templateService != nil {
+		tplTitle, text, err := s.templateService.RenderTemplate(model.TemplateStoreAccountCard, data)
+		if err == nil && strings.TrimSpace(text) != "" {
+			if tplTitle != "" {
+				title = tplTitle
+			}
+			return title, text, buttonTitle, buttonURL
+		}
+		if err != nil && logging.SugaredLogger != nil {
+			logging.SugaredLogger.Warnw("Failed to render account card template, using default", "error", err)
+		}
+	}
+
 	detailLines := itemLines
 	if len(detailLines) == 0 {
 		detailLines = []string{"暂无商品明细"}
@@ -1040,6 +1018,73 @@ func (s *StoreAccountService) buildAccountActionCard(account *model.StoreAccount
 	return title, b.String(), buttonTitle, buttonURL
 }
 
+// channelLabel 渠道字典值转换为名称
+func (s *StoreAccountService) channelLabel(channel string) string {
+	if s.dictModule != nil && channel != "" {
+		if dictData, err := s.dictModule.GetDataByTypeAndValue("sales_channel", channel); err == nil && dictData != nil {
+			return dictData.Label
+		}
+	}
+	return channel
+}
+
+// storeAccountItemLines 商品明细文本，每项一行
+func storeAccountItemLines(account *model.StoreAccount) []string {
+	lines := make([]string, 0, len(account.Items))
+	for i, item := range account.Items {
+		lines = append(lines, fmt.Sprintf("%d. %s x%.2f%s = ¥%.2f", i+1, item.ProductName, item.Quantity, item.Unit, item.Amount))
+	}
+	return lines
+}
+
+// storeAccountTemplateData 记账通知模板数据（store_account_created / store_account_card 共用）
+func storeAccountTemplateData(account *model.StoreAccount, storeName, operatorName, channelName, imageURL string) map[string]interface{} {
+	items := make([]interface{}, 0, len(account.Items))
+	for _, item := range account.Items {
+		items = append(items, map[string]interface{}{
+			"ProductName": strings.TrimSpace(item.ProductName),
+			"Quantity":    fmt.Sprintf("%.2f%s", item.Quantity, strings.TrimSpace(item.Unit)),
+			"Amount":      fmt.Sprintf("¥%.2f", item.Amount),
+		})
+	}
+	return map[string]interface{}{
+		"StoreName":    storeName,
+		"AccountNo":    account.AccountNo,
+		"ChannelName":  channelName,
+		"AccountDate":  account.AccountDate.Format("2006-01-02"),
+		"OperatorName": operatorName,
+		"ItemList":     strings.Join(storeAccountItemLines(account), "\n\n"),
+		"Items":        items,
+		"TotalAmount":  fmt.Sprintf("%.2f", account.TotalAmount),
+		"ItemCount":    account.ItemCount,
+		"OtherExpense": account.OtherExpenseAmount,
+		"NetIncome":    account.NetIncomeAmount,
+		"Remark":       strings.TrimSpace(account.Remark),
+		"ImageURL":     strings.TrimSpace(imageURL),
+		"CreateTime":   time.Now().Format("2006-01-02 15:04:05"),
+	}
+}
+
+// NotificationTemplateData 按记账单构建通知模板数据，供模板预览使用
+func (s *StoreAccountService) NotificationTemplateData(id uint) (map[string]interface{}, error) {
+	account, err := s.storeAccountModule.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	storeName := ""
+	if account.Store != nil {
+		storeName = account.Store.Name
+	}
+	operatorName := "未知"
+	if account.Operator != nil {
+		operatorName = account.Operator.Nickname
+		if operatorName == "" {
+			operatorName = account.Operator.Username
+		}
+	}
+	return storeAccountTemplateData(account, storeName, operatorName, s.channelLabel(account.Channel), ""), nil
+}
+
 func (s *StoreAccountService) isTakeoutChannel(channel string) bool {
 	if isTakeoutChannelValue(channel) {
 		return true
@@ -1293,9 +1338,18 @@ func (s *StoreAccountService) updateLoadedAccount(account *model.StoreAccount, s
 		updates["channel"] = nextChannel
 	}
 	if req.PaymentStatus != nil {
+		if account.BalanceAmount > 0 && resolvePaymentStatus(*req.PaymentStatus) != model.StoreAccountPaymentPaid {
+			return apicode.Newf(apicode.OperationDenied, "已使用会员余额支付的记账单不能改为未支付，请作废后重新记账")
+		}
 		updates["payment_status"] = resolvePaymentStatus(*req.PaymentStatus)
 	}
 	if req.MemberID != nil {
+		if account.PointsRedeemed > 0 && (account.MemberID == nil || *req.MemberID != *account.MemberID) {
+			return apicode.Newf(apicode.OperationDenied, "已使用积分抵扣的记账单不能更换会员")
+		}
+		if account.BalanceAmount > 0 && (account.MemberID == nil || *req.MemberID != *account.MemberID) {
+			return apicode.Newf(apicode.OperationDenied, "已使用会员余额支付的记账单不能更换会员")
+		}
 		if *req.MemberID > 0 {
 			if s.memberModule != nil {
 				if _, err := s.memberModule.GetMember(*req.MemberID, account.StoreID, false); err != nil {
@@ -1426,6 +1480,11 @@ func (s *StoreAccountService) updateLoadedAccount(account *model.StoreAccount, s
 		if err != nil {
 			return err
 		}
+		if account.MemberDiscountRate > 0 {
+			var discount float64
+			itemTotal, discount = applyMemberDiscount(items, account.MemberDiscountRate)
+			updates["member_discount"] = discount
+		}
 		inOrder, outOrder, err := s.buildAccountItemAdjustmentOrders(account, items, operatorID)
 		if err != nil {
 			return err
@@ -1472,7 +1531,7 @@ func (s *StoreAccountService) updateLoadedAccount(account *model.StoreAccount, s
 	}
 
 	if req.Items != nil {
-		return s.storeAccountModule.ReplaceItemsWithInventoryAdjustments(
+		if err := s.storeAccountModule.ReplaceItemsWithInventoryAdjustments(
 			account.ID,
 			storeID,
 			hqUnbound,
@@ -1480,9 +1539,23 @@ func (s *StoreAccountService) updateLoadedAccount(account *model.StoreAccount, s
 			replacementItems,
 			inventoryInOrder,
 			inventoryOutOrder,
-		)
+		); err != nil {
+			return err
+		}
+		publishInventoryOrders(inventoryInOrder, inventoryOutOrder)
+	} else if err := s.storeAccountModule.Update(account.ID, updates); err != nil {
+		return err
 	}
-	return s.storeAccountModule.Update(account.ID, updates)
+	// 改为已支付或补关联会员后补记积分；积分失败不回滚记账修改
+	_, paymentChanged := updates["payment_status"]
+	_, memberChanged := updates["member_id"]
+	if paymentChanged || memberChanged {
+		if err := s.storeAccountModule.AccrueMemberPoints(account.ID); err != nil && logging.SugaredLogger != nil {
+			logging.SugaredLogger.Warnw("Failed to accrue member points", "accountID", account.ID, "error", err)
+		}
+	}
+	publishStoreAccountChanged(account, model.RealtimeActionUpdated, false)
+	return nil
 }
 
 func (s *StoreAccountService) canApplyPaymentStatusOnlyUpdate(account *model.StoreAccount, req *model.UpdateStoreAccountReq) bool {
@@ -1531,28 +1604,105 @@ func (s *StoreAccountService) Delete(id uint) error {
 	return apicode.Newf(apicode.OperationDenied, "记账记录不允许删除")
 }
 
-// CancelScoped 作废记账单，并恢复系统商品库存。
-func (s *StoreAccountService) CancelScoped(id, storeID, operatorID uint, hqUnbound bool, req *model.CancelStoreAccountReq) error {
+// CancelScoped 作废记账单，并恢复系统商品库存；命中钉钉审批路由时返回待审批记录。
+func (s *StoreAccountService) CancelScoped(id, storeID, operatorID uint, hqUnbound bool, req *model.CancelStoreAccountReq) (*model.DingTalkApproval, error) {
+	account, err := s.loadCancelableAccount(id, storeID, hqUnbound)
+	if err != nil {
+		return nil, err
+	}
+	remark := ""
+	if req != nil {
+		remark = strings.TrimSpace(req.Remark)
+	}
+
+	// 命中钉钉审批路由时只提交审批，审批通过后再作废
+	approval, err := s.approvals.Submit(&DingTalkApprovalRequest{
+		BizType:     model.ApprovalBizStoreAccountCancel,
+		BizID:       account.ID,
+		BizNo:       account.AccountNo,
+		StoreID:     account.StoreID,
+		Amount:      account.TotalAmount,
+		Title:       "记账单作废申请",
+		Content:     s.cancelApprovalContent(account, operatorID, remark),
+		Payload:     model.ApprovalStoreAccountCancelPayload{Remark: remark},
+		RequesterID: operatorID,
+		Exclusive:   true,
+	})
+	if err != nil || approval != nil {
+		return approval, err
+	}
+	restoreOrder := s.buildCancelRestoreOrder(account, operatorID)
+	if err := s.storeAccountModule.CancelWithStockRestore(account.ID, storeID, hqUnbound, operatorID, remark, restoreOrder); err != nil {
+		return nil, err
+	}
+	publishStoreAccountChanged(account, model.RealtimeActionCanceled, true)
+	publishInventoryOrders(restoreOrder)
+	return nil, nil
+}
+
+func (s *StoreAccountService) loadCancelableAccount(id, storeID uint, hqUnbound bool) (*model.StoreAccount, error) {
 	if !hqUnbound && storeID == 0 {
-		return apicode.New(apicode.StoreRequired)
+		return nil, apicode.New(apicode.StoreRequired)
 	}
 	account, err := s.storeAccountModule.GetByIDScoped(id, storeID, hqUnbound)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if account.IsCanceled {
-		return apicode.Newf(apicode.DuplicateOperation, "记账单已作废")
+		return nil, apicode.Newf(apicode.DuplicateOperation, "记账单已作废")
 	}
 	if account.IsB2BSupplyOrderAccount() {
-		return apicode.Newf(apicode.OperationDenied, "B2B供货生成的记账单不允许作废")
+		return nil, apicode.Newf(apicode.OperationDenied, "B2B供货生成的记账单不允许作废")
 	}
+	return account, nil
+}
 
-	remark := ""
-	if req != nil {
-		remark = strings.TrimSpace(req.Remark)
+func (s *StoreAccountService) cancelApprovalContent(account *model.StoreAccount, operatorID uint, remark string) string {
+	storeName, requester := "", ""
+	if s.storeModule != nil {
+		if store, err := s.storeModule.GetByID(account.StoreID); err == nil && store != nil {
+			storeName = store.Name
+		}
 	}
-	restoreOrder := s.buildCancelRestoreOrder(account, operatorID)
-	return s.storeAccountModule.CancelWithStockRestore(account.ID, storeID, hqUnbound, operatorID, remark, restoreOrder)
+	if s.userModule != nil {
+		if user, err := s.userModule.GetByID(operatorID); err == nil && user != nil {
+			requester = dingTalkUserDisplay(user)
+		}
+	}
+	if remark == "" {
+		remark = "无"
+	}
+	return fmt.Sprintf("**记账单号：** %s\n\n**门店：** %s\n\n**金额：** ¥%.2f\n\n**申请人：** %s\n\n**作废原因：** %s",
+		account.AccountNo, storeName, account.TotalAmount, requester, remark)
+}
+
+// EnableAutoPrint 开启记账创建后按门店规则自动打印小票
+func (s *StoreAccountService) EnableAutoPrint(printer *PrintDocumentService) {
+	s.autoPrinter = printer
+}
+
+// EnableDingTalkApproval 开启记账作废钉钉审批：审批通过后由审批人作废并恢复库存，驳回不做处理
+func (s *StoreAccountService) EnableDingTalkApproval(approvals *DingTalkApprovalService) {
+	s.approvals = approvals
+	approvals.RegisterHandler(model.ApprovalBizStoreAccountCancel, &DingTalkApprovalHandler{
+		Permission: "store:account:edit",
+		Approve: func(approval *model.DingTalkApproval, approver *model.User) error {
+			var payload model.ApprovalStoreAccountCancelPayload
+			_ = json.Unmarshal([]byte(approval.Payload), &payload)
+			storeID, hqUnbound := dingTalkApproverScope(approver)
+			account, err := s.loadCancelableAccount(approval.BizID, storeID, hqUnbound)
+			if err != nil {
+				return err
+			}
+			restoreOrder := s.buildCancelRestoreOrder(account, approver.ID)
+			if err := s.storeAccountModule.CancelWithStockRestore(account.ID, storeID, hqUnbound, approver.ID, payload.Remark, restoreOrder); err != nil {
+				return err
+			}
+			publishStoreAccountChanged(account, model.RealtimeActionCanceled, true)
+			publishInventoryOrders(restoreOrder)
+			return nil
+		},
+	})
 }
 
 func (s *StoreAccountService) buildCancelRestoreOrder(account *model.StoreAccount, operatorID uint) *model.InventoryOrder {
@@ -1745,6 +1895,100 @@ func resolvePaymentStatus(v int) int {
 	return model.StoreAccountPaymentPaid
 }
 
+// resolvePointsDeduction 校验积分抵扣并计算抵扣金额：需关联会员、门店积分规则允许抵扣，且不超过抹零后的应收金额
+func (s *StoreAccountService) resolvePointsDeduction(storeID uint, memberID *uint, points int, payable float64) (float64, error) {
+	if points <= 0 {
+		return 0, nil
+	}
+	if memberID == nil || *memberID == 0 || s.memberModule == nil {
+		return 0, apicode.Newf(apicode.ValidationFailed, "积分抵扣需关联会员")
+	}
+	rule, err := s.memberModule.MatchPointRule(storeID)
+	if err != nil {
+		return 0, err
+	}
+	deduction := calcPointsDeduction(points, rule)
+	if deduction <= 0 {
+		return 0, apicode.Newf(apicode.ValidationFailed, "当前门店未开启积分抵扣")
+	}
+	if deduction > roundMoney(payable) {
+		return 0, apicode.Newf(apicode.ValidationFailed, "积分抵扣金额 %.2f 超过应收金额 %.2f", deduction, payable)
+	}
+	return deduction, nil
+}
+
+// memberDiscountRate 会员当前等级的记账折扣率，无折扣时返回 0
+func (s *StoreAccountService) memberDiscountRate(member *model.Member) (float64, error) {
+	tier, err := s.memberModule.GetMemberTier(member)
+	if err != nil || tier == nil {
+		return 0, err
+	}
+	if tier.DiscountRate <= 0 || tier.DiscountRate >= 1 {
+		return 0, nil
+	}
+	return tier.DiscountRate, nil
+}
+
+// applyMemberDiscount 按折扣率重算明细金额（单价保留原价），返回折后合计与优惠金额；rate 为 0 时不打折
+func applyMemberDiscount(items []model.StoreAccountItem, rate float64) (float64, float64) {
+	var total, discount float64
+	for i := range items {
+		if rate > 0 && rate < 1 {
+			discounted := roundMoney(items[i].Amount * rate)
+			discount += items[i].Amount - discounted
+			items[i].Amount = discounted
+		}
+		total += items[i].Amount
+	}
+	return roundMoney(total), roundMoney(discount)
+}
+
+// resolveBalancePayment 校验会员余额支付：需关联会员、记账为已支付且不超过实收金额；
+// 余额不足以付清时其余部分按 payMethod（默认现金）收取
+func resolveBalancePayment(balanceAmount float64, payMethod string, payable float64, paymentStatus int, hasMember bool) (float64, string, error) {
+	balanceAmount = roundMoney(balanceAmount)
+	if balanceAmount <= 0 {
+		return 0, strings.TrimSpace(payMethod), nil
+	}
+	if !hasMember {
+		return 0, "", apicode.Newf(apicode.ValidationFailed, "余额支付需关联会员")
+	}
+	if paymentStatus != model.StoreAccountPaymentPaid {
+		return 0, "", apicode.Newf(apicode.ValidationFailed, "余额支付的记账单须为已支付")
+	}
+	if balanceAmount > payable {
+		return 0, "", apicode.Newf(apicode.ValidationFailed, "余额支付金额不能超过实收金额 %.2f", payable)
+	}
+	if balanceAmount == payable {
+		return balanceAmount, "", nil
+	}
+	return balanceAmount, defaultPayMethod(payMethod), nil
+}
+
+// payMethodLabel 记账余额以外部分的支付方式名称
+func payMethodLabel(method string) string {
+	switch method {
+	case "cash":
+		return "现金"
+	case "bank":
+		return "银行卡"
+	case "wechat":
+		return "微信"
+	case "alipay":
+		return "支付宝"
+	default:
+		return "其他"
+	}
+}
+
+// calcPointsDeduction 按规则的积分价值计算抵扣金额，规则未设置积分价值时不可抵扣
+func calcPointsDeduction(points int, rule *model.MemberPointRule) float64 {
+	if rule == nil || rule.PointValue <= 0 || points <= 0 {
+		return 0
+	}
+	return roundMoney(float64(points) * rule.PointValue)
+}
+
 func (s *StoreAccountService) BindConsumables(accountID uint, req *model.BindStoreAccountConsumablesReq) error {
 	account, err := s.storeAccountModule.GetByID(accountID)
 	if err != nil {
@@ -406,3 +406,68 @@ func TestBuildAccountItemAdjustmentOrdersUsesStockDelta(t *testing.T) {
 		t.Fatalf("outOrder item = %#v, want product 11 quantity 3", outOrder.Items[0])
 	}
 }
+
+func TestCalcPointsDeduction(t *testing.T) {
+	rule := &model.MemberPointRule{PointValue: 0.01}
+	if got := calcPointsDeduction(1234, rule); got != 12.34 {
+		t.Fatalf("calcPointsDeduction() = %v, want 12.34", got)
+	}
+	if got := calcPointsDeduction(1234, &model.MemberPointRule{}); got != 0 {
+		t.Fatalf("calcPointsDeduction() without point value = %v, want 0", got)
+	}
+	if got := calcPointsDeduction(100, nil); got != 0 {
+		t.Fatalf("calcPointsDeduction() without rule = %v, want 0", got)
+	}
+}
+
+func TestApplyMemberDiscount(t *testing.T) {
+	items := []model.StoreAccountItem{
+		{Price: 10, Quantity: 3, Amount: 30},
+		{Price: 12.5, Quantity: 1, Amount: 12.5},
+	}
+	total, discount := applyMemberDiscount(items, 0.9)
+	if total != 38.25 || discount != 4.25 {
+		t.Fatalf("applyMemberDiscount() = %v, %v, want 38.25, 4.25", total, discount)
+	}
+	if items[0].Amount != 27 || items[0].Price != 10 || items[1].Amount != 11.25 {
+		t.Fatalf("unexpected discounted items: %+v", items)
+	}
+
+	items = []model.StoreAccountItem{{Price: 10, Quantity: 2, Amount: 20}}
+	total, discount = applyMemberDiscount(items, 0)
+	if total != 20 || discount != 0 || items[0].Amount != 20 {
+		t.Fatalf("applyMemberDiscount() without rate = %v, %v, items %+v", total, discount, items)
+	}
+}
+
+func TestResolveBalancePayment(t *testing.T) {
+	paid := model.StoreAccountPaymentPaid
+	cases := []struct {
+		name       string
+		balance    float64
+		method     string
+		payable    float64
+		status     int
+		hasMember  bool
+		wantAmount float64
+		wantMethod string
+		wantErr    bool
+	}{
+		{"未使用余额", 0, "wechat", 100, paid, false, 0, "wechat", false},
+		{"余额全额支付", 100, "wechat", 100, paid, true, 100, "", false},
+		{"组合支付默认现金", 60, "", 100, paid, true, 60, "cash", false},
+		{"组合支付指定方式", 60, "alipay", 100, paid, true, 60, "alipay", false},
+		{"未关联会员", 60, "", 100, paid, false, 0, "", true},
+		{"未支付记账", 60, "", 100, model.StoreAccountPaymentUnpaid, true, 0, "", true},
+		{"超过实收", 100.01, "", 100, paid, true, 0, "", true},
+	}
+	for _, c := range cases {
+		amount, method, err := resolveBalancePayment(c.balance, c.method, c.payable, c.status, c.hasMember)
+		if (err != nil) != c.wantErr {
+			t.Fatalf("%s: err = %v, wantErr %v", c.name, err, c.wantErr)
+		}
+		if err == nil && (amount != c.wantAmount || method != c.wantMethod) {
+			t.Fatalf("%s: resolveBalancePayment() = %v, %q, want %v, %q", c.name, amount, method, c.wantAmount, c.wantMethod)
+		}
+	}
+}
@@ -12,16 +12,17 @@ import (
 )
 
 type StoreReturnService struct {
-	returnModule *module.StoreReturnModule
-	userModule   *module.UserModule
+	returnModule        *module.StoreReturnModule
+	userModule          *module.UserModule
+	storeSupplierModule *module.StoreSupplierModule
 }
 
-func NewStoreReturnService(returnModule *module.StoreReturnModule, userModule *module.UserModule) *StoreReturnService {
-	return &StoreReturnService{returnModule: returnModule, userModule: userModule}
+func NewStoreReturnService(returnModule *module.StoreReturnModule, userModule *module.UserModule, storeSupplierModule *module.StoreSupplierModule) *StoreReturnService {
+	return &StoreReturnService{returnModule: returnModule, userModule: userModule, storeSupplierModule: storeSupplierModule}
 }
 
 func (s *StoreReturnService) Create(storeID, operatorID uint, req *model.CreateStoreReturnReq, hqUnbound bool) (*model.StoreReturn, error) {
-	record, err := s.buildRecord(storeID, operatorID, hqUnbound, req.StoreID, req.ReturnDate, req.LogisticsFee, req.Photos, req.Remark, req.Items)
+	record, err := s.buildRecord(storeID, operatorID, hqUnbound, req.StoreID, req.SupplierID, req.ReturnDate, req.LogisticsFee, req.Photos, req.Remark, req.Items)
 	if err != nil {
 		return nil, err
 	}
@@ -79,7 +80,7 @@ func (s *StoreReturnService) Update(id, storeID, operatorID uint, req *model.Upd
 	if !s.IsReturnEditable(existing) {
 		return nil, apicode.Newf(apicode.OrderStateConflict, "返厂记录仅允许在录入当天修改")
 	}
-	record, err := s.buildRecord(storeID, operatorID, hqUnbound, req.StoreID, req.ReturnDate, req.LogisticsFee, req.Photos, req.Remark, req.Items)
+	record, err := s.buildRecord(storeID, operatorID, hqUnbound, req.StoreID, req.SupplierID, req.ReturnDate, req.LogisticsFee, req.Photos, req.Remark, req.Items)
 	if err != nil {
 		return nil, err
 	}
@@ -96,6 +97,7 @@ func (s *StoreReturnService) buildRecord(
 	storeID, operatorID uint,
 	hqUnbound bool,
 	reqStoreID uint,
+	supplierID uint,
 	returnDate string,
 	logisticsFee float64,
 	photos []string,
@@ -114,6 +116,15 @@ func (s *StoreReturnService) buildRecord(
 	if err != nil {
 		return nil, apicode.New(apicode.ReturnDateInvalid)
 	}
+	if supplierID > 0 && s.storeSupplierModule != nil {
+		bound, err := s.storeSupplierModule.IsSupplierBound(realStoreID, supplierID)
+		if err != nil {
+			return nil, err
+		}
+		if !bound {
+			return nil, apicode.Newf(apicode.SupplierNotFound, "返厂供应商未绑定到当前门店")
+		}
+	}
 	photoURLs, err := normalizeStoreReturnPhotos(photos)
 	if err != nil {
 		return nil, err
@@ -177,6 +188,7 @@ func (s *StoreReturnService) buildRecord(
 
 	return &model.StoreReturn{
 		StoreID:      realStoreID,
+		SupplierID:   supplierID,
 		ReturnDate:   parsedDate,
 		LogisticsFee: logisticsFee,
 		TotalDeposit: totalDeposit,
@@ -190,8 +202,13 @@ func (s *StoreReturnService) buildRecord(
 }
 
 func normalizeStoreReturnPhotos(photos []string) (model.StringList, error) {
+	return normalizePhotoURLs(photos, "返厂照片")
+}
+
+// normalizePhotoURLs 校验图库上传后回传的照片地址：最多3张、须为 http(s) 地址，去空去重
+func normalizePhotoURLs(photos []string, label string) (model.StringList, error) {
 	if len(photos) > 3 {
-		return nil, apicode.Newf(apicode.ValidationFailed, "返厂照片最多上传3张")
+		return nil, apicode.Newf(apicode.ValidationFailed, "%s最多上传3张", label)
 	}
 
 	result := make(model.StringList, 0, len(photos))
@@ -202,11 +219,11 @@ func normalizeStoreReturnPhotos(photos []string) (model.StringList, error) {
 			continue
 		}
 		if len(photoURL) > 500 {
-			return nil, apicode.Newf(apicode.ValidationFailed, "返厂照片地址不能超过500个字符")
+			return nil, apicode.Newf(apicode.ValidationFailed, "%s地址不能超过500个字符", label)
 		}
 		parsed, err := url.Parse(photoURL)
 		if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
-			return nil, apicode.Newf(apicode.ValidationFailed, "返厂照片地址无效")
+			return nil, apicode.Newf(apicode.ValidationFailed, "%s地址无效", label)
 		}
 		if _, ok := seen[photoURL]; ok {
 			continue
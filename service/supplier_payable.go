@@ -0,0 +1,247 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+	"github.com/Kevin-Jii/tower-go/module"
+	"github.com/Kevin-Jii/tower-go/pkg/apicode"
+)
+
+// SupplierPayableService 供应商应付：采购收货与返厂押金形成的应付台账、付款登记、月度对账单与账龄
+type SupplierPayableService struct {
+	payableModule  *module.SupplierPayableModule
+	supplierModule *module.SupplierModule
+	storeModule    *module.StoreModule
+	userModule     *module.UserModule
+}
+
+func NewSupplierPayableService(
+	payableModule *module.SupplierPayableModule,
+	supplierModule *module.SupplierModule,
+	storeModule *module.StoreModule,
+	userModule *module.UserModule,
+) *SupplierPayableService {
+	return &SupplierPayableService{
+		payableModule:  payableModule,
+		supplierModule: supplierModule,
+		storeModule:    storeModule,
+		userModule:     userModule,
+	}
+}
+
+// resolvePayableStoreID 门店账号固定本店；总部账号可指定门店，不指定时为 0（全部门店）
+func resolvePayableStoreID(storeID uint, hqUnbound bool, reqStoreID uint) (uint, error) {
+	if hqUnbound {
+		return reqStoreID, nil
+	}
+	if storeID == 0 {
+		return 0, apicode.New(apicode.StoreRequired)
+	}
+	return storeID, nil
+}
+
+// CreatePayment 登记供应商付款，冲减门店对该供应商的应付
+func (s *SupplierPayableService) CreatePayment(storeID uint, hqUnbound bool, operatorID uint, req *model.CreateSupplierPaymentReq) (*model.SupplierPayment, error) {
+	storeID, err := resolvePayableStoreID(storeID, hqUnbound, req.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	if storeID == 0 {
+		return nil, apicode.New(apicode.StoreRequired)
+	}
+	if _, err := s.supplierModule.GetByID(req.SupplierID); err != nil {
+		return nil, apicode.New(apicode.SupplierNotFound)
+	}
+
+	now := time.Now()
+	payDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if strings.TrimSpace(req.PayDate) != "" {
+		if payDate, err = time.ParseInLocation("2006-01-02", strings.TrimSpace(req.PayDate), time.Local); err != nil {
+			return nil, apicode.New(apicode.InvalidDate)
+		}
+	}
+	method := req.Method
+	if method == "" {
+		method = "bank"
+	}
+
+	operatorName := ""
+	if user, err := s.userModule.GetByID(operatorID); err == nil && user != nil {
+		operatorName = user.Nickname
+		if operatorName == "" {
+			operatorName = user.Username
+		}
+	}
+
+	payment := &model.SupplierPayment{
+		PaymentNo:    s.payableModule.GeneratePaymentNo(),
+		StoreID:      storeID,
+		SupplierID:   req.SupplierID,
+		Amount:       roundMoney(req.Amount),
+		PayDate:      payDate,
+		Method:       method,
+		Reference:    strings.TrimSpace(req.Reference),
+		Remark:       strings.TrimSpace(req.Remark),
+		Status:       model.SupplierPaymentStatusValid,
+		OperatorID:   operatorID,
+		OperatorName: operatorName,
+	}
+	if err := s.payableModule.CreatePayment(payment); err != nil {
+		return nil, err
+	}
+	return s.payableModule.GetPaymentScoped(payment.ID, 0, true)
+}
+
+func (s *SupplierPayableService) VoidPayment(id, storeID uint, hqUnbound bool, reason string) error {
+	if !hqUnbound && storeID == 0 {
+		return apicode.New(apicode.StoreRequired)
+	}
+	return s.payableModule.VoidPayment(id, storeID, hqUnbound, strings.TrimSpace(reason))
+}
+
+func (s *SupplierPayableService) ListPayments(storeID uint, hqUnbound bool, req *model.ListSupplierPaymentReq) ([]*model.SupplierPayment, int64, error) {
+	scoped, err := resolvePayableStoreID(storeID, hqUnbound, req.StoreID)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.StoreID = scoped
+	return s.payableModule.ListPayments(req)
+}
+
+func (s *SupplierPayableService) ListEntries(storeID uint, hqUnbound bool, req *model.ListSupplierPayableEntryReq) ([]*model.SupplierPayableEntry, int64, error) {
+	scoped, err := resolvePayableStoreID(storeID, hqUnbound, req.StoreID)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.StoreID = scoped
+	return s.payableModule.ListEntries(req)
+}
+
+// Statement 供应商月度对账单：期初余额、本月分录、采购/返厂/付款小计与期末余额
+func (s *SupplierPayableService) Statement(storeID uint, hqUnbound bool, req *model.SupplierStatementReq) (*model.SupplierStatement, error) {
+	scoped, err := resolvePayableStoreID(storeID, hqUnbound, req.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	start, err := time.ParseInLocation("2006-01", strings.TrimSpace(req.Month), time.Local)
+	if err != nil {
+		return nil, apicode.New(apicode.InvalidDate)
+	}
+	end := start.AddDate(0, 1, 0)
+
+	supplier, err := s.supplierModule.GetByID(req.SupplierID)
+	if err != nil || supplier == nil {
+		return nil, apicode.New(apicode.SupplierNotFound)
+	}
+	statement := &model.SupplierStatement{
+		SupplierID:   supplier.ID,
+		SupplierName: supplier.SupplierName,
+		StoreID:      scoped,
+		StoreName:    "全部门店",
+		Month:        start.Format("2006-01"),
+	}
+	if scoped > 0 {
+		store, err := s.storeModule.GetByID(scoped)
+		if err != nil || store == nil {
+			return nil, apicode.New(apicode.StoreNotFound)
+		}
+		statement.StoreName = store.Name
+	}
+
+	opening, err := s.payableModule.BalanceBefore(scoped, supplier.ID, start)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.payableModule.EntriesBetween(scoped, supplier.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	statement.Entries = entries
+	summarizeSupplierStatement(statement, opening)
+	return statement, nil
+}
+
+// summarizeSupplierStatement 按来源汇总本月发生额并计算期末余额；返厂与付款以正数展示
+func summarizeSupplierStatement(statement *model.SupplierStatement, opening float64) {
+	statement.OpeningBalance = opening
+	closing := opening
+	for _, entry := range statement.Entries {
+		switch entry.SourceType {
+		case model.PayableSourcePurchaseReceipt:
+			statement.PurchaseAmount = roundMoney(statement.PurchaseAmount + entry.Amount)
+		case model.PayableSourceStoreReturn:
+			statement.ReturnAmount = roundMoney(statement.ReturnAmount - entry.Amount)
+		case model.PayableSourcePayment, model.PayableSourcePaymentVoid:
+			statement.PaymentAmount = roundMoney(statement.PaymentAmount - entry.Amount)
+		}
+		closing = roundMoney(closing + entry.Amount)
+	}
+	statement.ClosingBalance = closing
+	if statement.Entries == nil {
+		statement.Entries = []*model.SupplierPayableEntry{}
+	}
+}
+
+// Aging 截至 asOf 的供应商应付余额与账龄分布
+func (s *SupplierPayableService) Aging(storeID uint, hqUnbound bool, req *model.SupplierAgingReq, now time.Time) (*model.SupplierAgingReport, error) {
+	scoped, err := resolvePayableStoreID(storeID, hqUnbound, req.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	asOf := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if strings.TrimSpace(req.AsOf) != "" {
+		if asOf, err = time.ParseInLocation("2006-01-02", strings.TrimSpace(req.AsOf), time.Local); err != nil {
+			return nil, apicode.New(apicode.InvalidDate)
+		}
+	}
+
+	rows, err := s.payableModule.DailyNets(scoped, req.SupplierID, asOf)
+	if err != nil {
+		return nil, err
+	}
+	report := &model.SupplierAgingReport{
+		AsOf:      asOf.Format("2006-01-02"),
+		Suppliers: buildSupplierAging(rows, asOf),
+	}
+	for i := range report.Suppliers {
+		sa := &report.Suppliers[i]
+		if supplier, err := s.supplierModule.GetByID(sa.SupplierID); err == nil && supplier != nil {
+			sa.SupplierName = supplier.SupplierName
+		}
+		report.Balance = roundMoney(report.Balance + sa.Balance)
+		report.Days0To30 = roundMoney(report.Days0To30 + sa.Days0To30)
+		report.Days31To60 = roundMoney(report.Days31To60 + sa.Days31To60)
+		report.DaysOver60 = roundMoney(report.DaysOver60 + sa.DaysOver60)
+	}
+	return report, nil
+}
+
+// buildSupplierAging 逐个门店+供应商先进先出核销（付款与返厂冲减先抵扣最早的应付），再按供应商汇总。
+// 门店之间的超付不互相抵扣；rows 须按供应商、门店、业务日期排序。
+func buildSupplierAging(rows []model.PayableDailyNet, asOf time.Time) []model.SupplierAging {
+	result := make([]model.SupplierAging, 0)
+	var amounts []datedAmount
+	var total agingBalance
+	for i, row := range rows {
+		amounts = append(amounts, datedAmount{Date: row.BizDate, Amount: row.Amount})
+		last := i == len(rows)-1
+		if last || rows[i+1].SupplierID != row.SupplierID || rows[i+1].StoreID != row.StoreID {
+			total.add(ageDatedAmounts(amounts, asOf))
+			amounts = nil
+		}
+		if last || rows[i+1].SupplierID != row.SupplierID {
+			result = append(result, model.SupplierAging{
+				SupplierID:      row.SupplierID,
+				Balance:         total.Balance,
+				Days0To30:       total.Days0To30,
+				Days31To60:      total.Days31To60,
+				DaysOver60:      total.DaysOver60,
+				UnappliedCredit: total.UnappliedCredit,
+			})
+			total = agingBalance{}
+		}
+	}
+	return result
+}
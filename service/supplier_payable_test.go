@@ -0,0 +1,57 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Kevin-Jii/tower-go/model"
+)
+
+func TestSummarizeSupplierStatement(t *testing.T) {
+	statement := &model.SupplierStatement{Entries: []*model.SupplierPayableEntry{
+		{SourceType: model.PayableSourcePurchaseReceipt, Amount: 800},
+		{SourceType: model.PayableSourceStoreReturn, Amount: -50},
+		{SourceType: model.PayableSourcePayment, Amount: -600},
+		{SourceType: model.PayableSourcePaymentVoid, Amount: 100},
+	}}
+	summarizeSupplierStatement(statement, 200)
+	if statement.OpeningBalance != 200 || statement.PurchaseAmount != 800 || statement.ReturnAmount != 50 || statement.PaymentAmount != 500 {
+		t.Fatalf("statement = %#v", statement)
+	}
+	if statement.ClosingBalance != 450 {
+		t.Fatalf("closing = %v", statement.ClosingBalance)
+	}
+}
+
+func TestBuildSupplierAgingAppliesCreditsOldestFirst(t *testing.T) {
+	asOf := time.Date(2024, 6, 30, 0, 0, 0, 0, time.Local)
+	day := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 0, 0, 0, 0, time.Local) }
+	rows := []model.PayableDailyNet{
+		// 供应商 1 门店 1：4 月 10 日 300（81 天），5 月 15 日 200（46 天），6 月 20 日 100（10 天），6 月 25 日付款 350
+		{StoreID: 1, SupplierID: 1, BizDate: day(4, 10), Amount: 300},
+		{StoreID: 1, SupplierID: 1, BizDate: day(5, 15), Amount: 200},
+		{StoreID: 1, SupplierID: 1, BizDate: day(6, 20), Amount: 100},
+		{StoreID: 1, SupplierID: 1, BizDate: day(6, 25), Amount: -350},
+		// 供应商 1 门店 2：超付 40，不抵扣门店 1 的应付
+		{StoreID: 2, SupplierID: 1, BizDate: day(6, 1), Amount: 60},
+		{StoreID: 2, SupplierID: 1, BizDate: day(6, 2), Amount: -100},
+		// 供应商 2：31 天边界落入 31-60
+		{StoreID: 1, SupplierID: 2, BizDate: day(5, 30), Amount: 90},
+	}
+
+	aging := buildSupplierAging(rows, asOf)
+	if len(aging) != 2 {
+		t.Fatalf("aging = %#v", aging)
+	}
+	s1 := aging[0]
+	if s1.SupplierID != 1 || s1.DaysOver60 != 0 || s1.Days31To60 != 150 || s1.Days0To30 != 100 {
+		t.Fatalf("supplier 1 buckets = %#v", s1)
+	}
+	if s1.UnappliedCredit != 40 || s1.Balance != 210 {
+		t.Fatalf("supplier 1 balance = %#v", s1)
+	}
+	s2 := aging[1]
+	if s2.SupplierID != 2 || s2.Days31To60 != 90 || s2.Balance != 90 {
+		t.Fatalf("supplier 2 = %#v", s2)
+	}
+}
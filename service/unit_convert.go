@@ -1,6 +1,7 @@
 package service
 
 import (
+	"math"
 	"strings"
 
 	"github.com/Kevin-Jii/tower-go/model"
@@ -45,3 +46,8 @@ func convertToBaseQuantity(
 
 	return quantity, baseUnit
 }
+
+// roundQuantity 数量保留两位小数，与库存表 decimal(10,2) 精度一致
+func roundQuantity(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -12,6 +12,7 @@ const (
 	EventOrderCompleted EventType = "order.completed"
 	EventOrderCancelled EventType = "order.cancelled"
 	EventSupplierBound  EventType = "supplier.bound"
+	EventDataChanged    EventType = "data.changed" // 业务数据变更，用于 WebSocket 实时推送
 )
 
 // EventHandler 事件处理函数类型
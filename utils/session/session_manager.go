@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Kevin-Jii/tower-go/internal/authctx"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
@@ -25,6 +26,18 @@ type Session struct {
 	ExpiresAt time.Time
 	Conn      *websocket.Conn
 	CreatedAt time.Time
+	// Auth 建立连接时的权限与数据范围快照，订阅主题与推送过滤使用
+	Auth *authctx.Context
+
+	topics  map[string]struct{} // 已订阅主题，由 SessionManager.mu 保护
+	writeMu sync.Mutex          // websocket 连接不支持并发写
+}
+
+// WriteJSON 串行写入消息，推送与读循环的回复可能来自不同 goroutine
+func (s *Session) WriteJSON(v interface{}) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.Conn.WriteJSON(v)
 }
 
 // SessionManager 管理所有 WebSocket 会话
@@ -58,7 +71,7 @@ func InitSessionManager(strategy string, maxSessions int) {
 func GetSessionManager() *SessionManager { return globalSessionManager }
 
 // CreateSession 创建并注册一个新会话（调用方已认证 JWT）
-func (sm *SessionManager) CreateSession(userID uint, deviceID, token string, expiresAt time.Time, conn *websocket.Conn) (*Session, []*Session) {
+func (sm *SessionManager) CreateSession(userID uint, deviceID, token string, expiresAt time.Time, conn *websocket.Conn, auth *authctx.Context) (*Session, []*Session) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
@@ -100,6 +113,8 @@ func (sm *SessionManager) CreateSession(userID uint, deviceID, token string, exp
 		ExpiresAt: expiresAt,
 		Conn:      conn,
 		CreatedAt: time.Now(),
+		Auth:      auth,
+		topics:    make(map[string]struct{}),
 	}
 	sm.userSessions[userID][session.ID] = session
 	sm.sessionIndex[session.ID] = userID
@@ -142,7 +157,7 @@ func (sm *SessionManager) KickUser(userID uint, reason string) int {
 	sm.mu.Unlock()
 
 	for _, s := range sessions {
-		_ = s.Conn.WriteJSON(WebSocketMessage{Type: "kick", Payload: map[string]string{"reason": reason}, Ts: time.Now().Unix()})
+		_ = s.WriteJSON(WebSocketMessage{Type: "kick", Payload: map[string]string{"reason": reason}, Ts: time.Now().Unix()})
 		s.Conn.Close()
 	}
 	return len(sessions)
@@ -163,7 +178,7 @@ func (sm *SessionManager) KickSession(sessionID string, reason string) bool {
 		delete(sm.userSessions, userID)
 	}
 	sm.mu.Unlock()
-	_ = session.Conn.WriteJSON(WebSocketMessage{Type: "kick", Payload: map[string]string{"reason": reason}, Ts: time.Now().Unix()})
+	_ = session.WriteJSON(WebSocketMessage{Type: "kick", Payload: map[string]string{"reason": reason}, Ts: time.Now().Unix()})
 	session.Conn.Close()
 	return true
 }
@@ -182,11 +197,14 @@ func (sm *SessionManager) ListUserSessions(userID uint) []*Session {
 // Broadcast 向某用户广播
 func (sm *SessionManager) Broadcast(userID uint, msg WebSocketMessage) int {
 	sm.mu.RLock()
-	sessions := sm.userSessions[userID]
+	sessions := make([]*Session, 0, len(sm.userSessions[userID]))
+	for _, s := range sm.userSessions[userID] {
+		sessions = append(sessions, s)
+	}
 	sm.mu.RUnlock()
 	count := 0
 	for _, s := range sessions {
-		if err := s.Conn.WriteJSON(msg); err == nil {
+		if err := s.WriteJSON(msg); err == nil {
 			count++
 		}
 	}
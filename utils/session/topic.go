@@ -0,0 +1,56 @@
+package session
+
+// Subscribe 为会话订阅主题，会话不存在时返回 false
+func (sm *SessionManager) Subscribe(sessionID, topic string) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	s := sm.lookup(sessionID)
+	if s == nil {
+		return false
+	}
+	s.topics[topic] = struct{}{}
+	return true
+}
+
+// Unsubscribe 取消会话的主题订阅
+func (sm *SessionManager) Unsubscribe(sessionID, topic string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if s := sm.lookup(sessionID); s != nil {
+		delete(s.topics, topic)
+	}
+}
+
+// Publish 向订阅了主题的会话推送消息；allow 不为空时逐个会话过滤，返回成功推送的会话数
+func (sm *SessionManager) Publish(topic string, msg WebSocketMessage, allow func(*Session) bool) int {
+	sm.mu.RLock()
+	var targets []*Session
+	for _, sessions := range sm.userSessions {
+		for _, s := range sessions {
+			if _, ok := s.topics[topic]; ok {
+				targets = append(targets, s)
+			}
+		}
+	}
+	sm.mu.RUnlock()
+
+	count := 0
+	for _, s := range targets {
+		if allow != nil && !allow(s) {
+			continue
+		}
+		if err := s.WriteJSON(msg); err == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// lookup 按会话ID查找，调用方需持有锁
+func (sm *SessionManager) lookup(sessionID string) *Session {
+	userID, ok := sm.sessionIndex[sessionID]
+	if !ok {
+		return nil
+	}
+	return sm.userSessions[userID][sessionID]
+}